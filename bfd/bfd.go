@@ -0,0 +1,511 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bfd is an implementation of the asynchronous mode of Bidirectional Forwarding
+// Detection (RFC 5880) for single-hop (RFC 5881) and multi-hop (RFC 5883) sessions.
+package bfd
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/util/queue"
+
+	log "github.com/golang/glog"
+)
+
+const (
+	// slowTxInterval is the minimum interval between the packets of the sessions that are not up.
+	slowTxInterval = time.Second
+	// tickInterval is the resolution of the timers.
+	tickInterval = 10 * time.Millisecond
+)
+
+// DefaultParams are the parameters of the sessions whose parameters are not configured.
+var DefaultParams = Params{
+	DesiredMinTx:     300 * time.Millisecond,
+	RequiredMinRx:    300 * time.Millisecond,
+	DetectMultiplier: 3,
+}
+
+// Key identifies a session.
+type Key struct {
+	Peer     netip.Addr
+	Local    netip.Addr // The source address of the packets, invalid to use the address of the outgoing interface.
+	Multihop bool
+}
+
+func (k Key) String() string {
+	if k.Multihop {
+		return fmt.Sprintf("%v (multi-hop)", k.Peer)
+	}
+	return k.Peer.String()
+}
+
+// Params are the timer parameters of a session.
+type Params struct {
+	DesiredMinTx     time.Duration
+	RequiredMinRx    time.Duration
+	DetectMultiplier uint8
+}
+
+// WatchFunc is called when a session comes up or goes down.
+type WatchFunc func(key Key, up bool)
+
+// SessionState is the state of a session.
+type SessionState struct {
+	Key
+	State           State
+	RemoteState     State
+	LocalDiag       Diag
+	RemoteDiag      Diag
+	LocalDisc       uint32
+	RemoteDisc      uint32
+	Params          Params
+	RemoteMinRx     time.Duration
+	RemoteMinTx     time.Duration
+	RemoteMult      uint8
+	LastChange      time.Time
+	InPkts          uint64
+	OutPkts         uint64
+	UpTransitions   uint64
+	DownTransitions uint64
+}
+
+// conn sends the control packets of the sessions.
+type conn interface {
+	send(key Key, b []byte) error
+	close() error
+}
+
+type session struct {
+	SessionState
+	owners map[string]Params
+
+	poll       bool // A poll sequence is in progress.
+	nextTx     time.Time
+	detectTime time.Time // Zero if the detection timer is not running.
+}
+
+type event struct {
+	key Key
+	up  bool
+}
+
+// Server runs the BFD sessions of the clients, such as the static routes and BGP.
+type Server struct {
+	mu       sync.Mutex
+	conn     conn
+	sessions map[Key]*session
+	discs    map[uint32]*session
+	watchers []WatchFunc
+	rand     *rand.Rand
+	doneCh   chan struct{}
+	events   *queue.Queue
+	now      func() time.Time // The clock of the timers, replaced in tests.
+	// listen returns the connection of the control packets, it is called when the first session is added.
+	listen  func() (conn, error)
+	stopped bool
+}
+
+// New returns a new server. It listens for the control packets when the first session is added,
+// so that the servers of several devices in a network namespace can coexist until they use BFD.
+func New() *Server {
+	s := &Server{
+		sessions: map[Key]*session{},
+		discs:    map[uint32]*session{},
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:      time.Now,
+	}
+	s.listen = func() (conn, error) {
+		return listenUDP("", singleHopPort, multiHopPort, s.receive)
+	}
+	// The events are delivered in order, outside of the lock.
+	q, err := queue.NewUnbounded("bfd-events")
+	if err != nil {
+		log.Fatalf("failed to create BFD event queue: %v", err)
+	}
+	q.Run()
+	s.events = q
+	go func() {
+		for v := range q.Receive() {
+			e := v.(event)
+			s.mu.Lock()
+			watchers := slices.Clone(s.watchers)
+			s.mu.Unlock()
+			for _, w := range watchers {
+				w(e.key, e.up)
+			}
+		}
+	}()
+	return s
+}
+
+// start sends the packets of the sessions with c.
+func (s *Server) start(c conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.startLocked(c)
+}
+
+// startLocked sends the packets of the sessions with c. The caller must hold mu.
+func (s *Server) startLocked(c conn) {
+	if s.doneCh != nil {
+		return
+	}
+	s.conn = c
+	s.doneCh = make(chan struct{})
+	go func(done chan struct{}) {
+		tick := time.NewTicker(tickInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-tick.C:
+				s.tick(now)
+			}
+		}
+	}(s.doneCh)
+}
+
+// Stop stops sending and receiving the packets, the sessions go down on the peers.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	if s.doneCh == nil {
+		return
+	}
+	close(s.doneCh)
+	s.doneCh = nil
+	if err := s.conn.close(); err != nil {
+		log.Warningf("failed to close BFD sockets: %v", err)
+	}
+	s.conn = nil
+}
+
+// Watch calls f when the sessions come up or go down.
+func (s *Server) Watch(f WatchFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watchers = append(s.watchers, f)
+}
+
+// AddSession adds or updates the session of the owner. A session shared by several owners
+// uses the fastest parameters of its owners.
+// It returns an error if the server can't listen for the control packets, the session is
+// added but stays down, and listening is retried when a session is added again.
+func (s *Server) AddSession(owner string, key Key, p Params) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[key]
+	if !ok {
+		sess = &session{
+			SessionState: SessionState{
+				Key:         key,
+				State:       StateDown,
+				LocalDisc:   s.newDisc(),
+				RemoteMinRx: time.Microsecond,
+				LastChange:  s.now(),
+			},
+			owners: map[string]Params{},
+		}
+		s.sessions[key] = sess
+		s.discs[sess.LocalDisc] = sess
+		log.Infof("BFD: adding session %v", key)
+	}
+	sess.owners[owner] = p
+	s.updateParams(sess)
+	if s.conn != nil || s.stopped {
+		return nil
+	}
+	c, err := s.listen()
+	if err != nil {
+		return fmt.Errorf("failed to listen for BFD packets: %v", err)
+	}
+	s.startLocked(c)
+	return nil
+}
+
+// RemoveSession removes the owner of the session, the session is deleted once it has no owners.
+func (s *Server) RemoveSession(owner string, key Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[key]
+	if !ok {
+		return
+	}
+	delete(sess.owners, owner)
+	if len(sess.owners) != 0 {
+		s.updateParams(sess)
+		return
+	}
+	log.Infof("BFD: removing session %v", key)
+	if sess.State == StateUp {
+		s.notify(key, false)
+	}
+	// Tell the peer that the session is administratively down, so it does not wait for the detection time.
+	sess.State = StateAdminDown
+	sess.LocalDiag = DiagAdminDown
+	s.transmit(sess, s.now(), false)
+	delete(s.sessions, key)
+	delete(s.discs, sess.LocalDisc)
+}
+
+// Up returns whether the session is up.
+func (s *Server) Up(key Key) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[key]
+	return ok && sess.State == StateUp
+}
+
+// Sessions returns the state of the sessions.
+func (s *Server) Sessions() []*SessionState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var states []*SessionState
+	for _, sess := range s.sessions {
+		st := sess.SessionState
+		states = append(states, &st)
+	}
+	slices.SortFunc(states, func(a, b *SessionState) int {
+		return cmp.Or(a.Peer.Compare(b.Peer), a.Local.Compare(b.Local), cmp.Compare(boolInt(a.Multihop), boolInt(b.Multihop)))
+	})
+	return states
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// newDisc returns an unused local discriminator. The caller must hold mu.
+func (s *Server) newDisc() uint32 {
+	for {
+		if d := s.rand.Uint32(); d != 0 && s.discs[d] == nil {
+			return d
+		}
+	}
+}
+
+// updateParams applies the fastest parameters of the owners, and starts a poll sequence
+// to tell the peer. The caller must hold mu.
+func (s *Server) updateParams(sess *session) {
+	var p Params
+	for _, op := range sess.owners {
+		if op.DesiredMinTx == 0 {
+			op.DesiredMinTx = DefaultParams.DesiredMinTx
+		}
+		if op.RequiredMinRx == 0 {
+			op.RequiredMinRx = DefaultParams.RequiredMinRx
+		}
+		if op.DetectMultiplier == 0 {
+			op.DetectMultiplier = DefaultParams.DetectMultiplier
+		}
+		if p.DesiredMinTx == 0 || op.DesiredMinTx < p.DesiredMinTx {
+			p.DesiredMinTx = op.DesiredMinTx
+		}
+		if p.RequiredMinRx == 0 || op.RequiredMinRx < p.RequiredMinRx {
+			p.RequiredMinRx = op.RequiredMinRx
+		}
+		if p.DetectMultiplier == 0 || op.DetectMultiplier < p.DetectMultiplier {
+			p.DetectMultiplier = op.DetectMultiplier
+		}
+	}
+	if p == sess.Params {
+		return
+	}
+	sess.Params = p
+	if sess.State == StateUp {
+		sess.poll = true
+	}
+	// Send the new parameters immediately.
+	sess.nextTx = time.Time{}
+}
+
+// receive processes a control packet received from src on dst. The ttl is the TTL or
+// hop limit of the packet, negative if it is unknown.
+func (s *Server) receive(src, dst netip.Addr, multihop bool, ttl int, b []byte) {
+	p, err := parsePacket(b)
+	if err != nil {
+		log.V(1).Infof("BFD: dropping packet from %v: %v", src, err)
+		return
+	}
+	// The single-hop packets must not be forwarded (RFC 5881 section 5).
+	if !multihop && ttl >= 0 && ttl != 255 {
+		log.V(1).Infof("BFD: dropping single-hop packet from %v with TTL %d", src, ttl)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sess *session
+	if p.yourDisc != 0 {
+		sess = s.discs[p.yourDisc]
+	} else {
+		sess = s.sessions[Key{Peer: src, Local: dst, Multihop: multihop}]
+		if sess == nil {
+			sess = s.sessions[Key{Peer: src, Multihop: multihop}]
+		}
+	}
+	if sess == nil || sess.Peer != src || sess.Multihop != multihop {
+		log.V(1).Infof("BFD: dropping packet from %v without session", src)
+		return
+	}
+	s.process(sess, p, s.now())
+}
+
+// process runs the reception procedure of RFC 5880 section 6.8.6 for the session. The caller must hold mu.
+func (s *Server) process(sess *session, p *packet, now time.Time) {
+	sess.InPkts++
+	sess.RemoteDisc = p.myDisc
+	sess.RemoteState = p.state
+	sess.RemoteDiag = p.diag
+	sess.RemoteMinRx = p.requiredMinRx
+	sess.RemoteMinTx = p.desiredMinTx
+	sess.RemoteMult = p.detectMult
+	if p.final {
+		sess.poll = false
+	}
+	sess.detectTime = now.Add(sess.detectionTime())
+
+	switch {
+	case sess.State == StateAdminDown:
+		return
+	case p.state == StateAdminDown:
+		if sess.State != StateDown {
+			s.setState(sess, StateDown, DiagNeighborDown, now)
+		}
+	case sess.State == StateDown && p.state == StateDown:
+		s.setState(sess, StateInit, DiagNone, now)
+	case sess.State == StateDown && p.state == StateInit:
+		s.setState(sess, StateUp, DiagNone, now)
+	case sess.State == StateInit && (p.state == StateInit || p.state == StateUp):
+		s.setState(sess, StateUp, DiagNone, now)
+	case sess.State == StateUp && p.state == StateDown:
+		s.setState(sess, StateDown, DiagNeighborDown, now)
+	}
+	if p.poll {
+		s.transmit(sess, now, true)
+	}
+}
+
+// tick runs the transmission and detection timers of the sessions.
+func (s *Server) tick(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sess := range s.sessions {
+		if !sess.detectTime.IsZero() && !now.Before(sess.detectTime) {
+			sess.detectTime = time.Time{}
+			sess.RemoteDisc = 0
+			sess.RemoteState = StateDown
+			sess.RemoteMinRx = time.Microsecond
+			sess.poll = false
+			if sess.State == StateInit || sess.State == StateUp {
+				s.setState(sess, StateDown, DiagDetectionTimeExpired, now)
+			}
+		}
+		// The peer does not want to receive packets if its required minimum is 0.
+		if sess.RemoteMinRx != 0 && !now.Before(sess.nextTx) {
+			s.transmit(sess, now, false)
+		}
+	}
+}
+
+// setState changes the state of the session. The caller must hold mu.
+func (s *Server) setState(sess *session, state State, diag Diag, now time.Time) {
+	log.Infof("BFD: session %v changed from %v to %v, diagnostic %d", sess.Key, sess.State, state, diag)
+	wasUp := sess.State == StateUp
+	sess.State = state
+	sess.LocalDiag = diag
+	sess.LastChange = now
+	switch {
+	case state == StateUp:
+		sess.UpTransitions++
+		// The transmit interval changes from the slow rate.
+		sess.poll = true
+		sess.nextTx = time.Time{}
+		s.notify(sess.Key, true)
+	case wasUp:
+		sess.DownTransitions++
+		sess.poll = false
+		s.notify(sess.Key, false)
+	}
+}
+
+// notify calls the watchers with the new state of the session.
+func (s *Server) notify(key Key, up bool) {
+	if err := s.events.Write(event{key: key, up: up}); err != nil {
+		log.Warningf("BFD: failed to notify session %v: %v", key, err)
+	}
+}
+
+// detectionTime returns the time after which the session goes down without packets from the peer.
+func (sess *session) detectionTime() time.Duration {
+	return time.Duration(sess.RemoteMult) * max(sess.Params.RequiredMinRx, sess.RemoteMinTx)
+}
+
+// txInterval returns the interval between the periodic packets, before jitter.
+func (sess *session) txInterval() time.Duration {
+	tx := sess.Params.DesiredMinTx
+	if sess.State != StateUp {
+		tx = max(tx, slowTxInterval)
+	}
+	return max(tx, sess.RemoteMinRx)
+}
+
+// transmit sends a control packet of the session, with the final bit if it answers a poll.
+// The caller must hold mu.
+func (s *Server) transmit(sess *session, now time.Time, final bool) {
+	if !final {
+		// Reduce the interval by up to 25%, or 10% to 25% with a multiplier of 1 (RFC 5880 section 6.8.7).
+		jitter := 0.75 + 0.25*s.rand.Float64()
+		if sess.Params.DetectMultiplier == 1 {
+			jitter = 0.75 + 0.15*s.rand.Float64()
+		}
+		sess.nextTx = now.Add(time.Duration(float64(sess.txInterval()) * jitter))
+	}
+	if s.conn == nil {
+		return
+	}
+	desiredMinTx := sess.Params.DesiredMinTx
+	if sess.State != StateUp {
+		desiredMinTx = max(desiredMinTx, slowTxInterval)
+	}
+	p := &packet{
+		diag:          sess.LocalDiag,
+		state:         sess.State,
+		poll:          sess.poll && !final,
+		final:         final,
+		detectMult:    sess.Params.DetectMultiplier,
+		myDisc:        sess.LocalDisc,
+		yourDisc:      sess.RemoteDisc,
+		desiredMinTx:  desiredMinTx,
+		requiredMinRx: sess.Params.RequiredMinRx,
+	}
+	if err := s.conn.send(sess.Key, p.marshal()); err != nil {
+		log.V(1).Infof("BFD: failed to send packet of session %v: %v", sess.Key, err)
+		return
+	}
+	sess.OutPkts++
+}
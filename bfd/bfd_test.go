@@ -0,0 +1,424 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestPacket(t *testing.T) {
+	want := &packet{
+		diag:          DiagDetectionTimeExpired,
+		state:         StateInit,
+		poll:          true,
+		detectMult:    3,
+		myDisc:        1,
+		yourDisc:      2,
+		desiredMinTx:  50 * time.Millisecond,
+		requiredMinRx: time.Second,
+	}
+	b := want.marshal()
+	if len(b) != packetLength {
+		t.Errorf("marshal() got %d bytes, want %d", len(b), packetLength)
+	}
+	got, err := parsePacket(b)
+	if err != nil {
+		t.Fatalf("parsePacket() unexpected err: %v", err)
+	}
+	if d := cmp.Diff(got, want, cmp.AllowUnexported(packet{})); d != "" {
+		t.Errorf("parsePacket() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	modify := func(f func(b []byte)) []byte {
+		c := want.marshal()
+		f(c)
+		return c
+	}
+	tests := []struct {
+		desc    string
+		b       []byte
+		wantErr string
+	}{{
+		desc:    "truncated",
+		b:       b[:20],
+		wantErr: "too short",
+	}, {
+		desc:    "version",
+		b:       modify(func(b []byte) { b[0] = 2 << 5 }),
+		wantErr: "version",
+	}, {
+		desc:    "authentication",
+		b:       modify(func(b []byte) { b[1] |= flagAuth }),
+		wantErr: "authentication",
+	}, {
+		desc:    "zero multiplier",
+		b:       modify(func(b []byte) { b[2] = 0 }),
+		wantErr: "multiplier",
+	}, {
+		desc:    "zero your discriminator",
+		b:       modify(func(b []byte) { b[11] = 0 }),
+		wantErr: "your discriminator",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := parsePacket(tt.b)
+			if diff := errdiff.Check(err, tt.wantErr); diff != "" {
+				t.Errorf("parsePacket() unexpected err: %s", diff)
+			}
+		})
+	}
+}
+
+// fakeConn buffers the packets sent by a server, until they are delivered to the peer.
+type fakeConn struct {
+	mu   sync.Mutex
+	pkts [][]byte
+	keys []Key
+}
+
+func (c *fakeConn) send(key Key, b []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = append(c.keys, key)
+	c.pkts = append(c.pkts, b)
+	return nil
+}
+
+func (c *fakeConn) close() error { return nil }
+
+func (c *fakeConn) take() ([]Key, [][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys, pkts := c.keys, c.pkts
+	c.keys, c.pkts = nil, nil
+	return keys, pkts
+}
+
+// peer is a server with an address, whose packets are delivered to other peers.
+type peer struct {
+	*Server
+	addr   netip.Addr
+	conn   *fakeConn
+	events chan event
+}
+
+func newPeer(addr string) *peer {
+	p := &peer{Server: New(), addr: netip.MustParseAddr(addr), conn: &fakeConn{}, events: make(chan event, 10)}
+	p.Server.conn = p.conn
+	p.Watch(func(key Key, up bool) { p.events <- event{key: key, up: up} })
+	return p
+}
+
+// deliver delivers the packets sent by from to the peers with the destination address.
+func deliver(from *peer, ttl int, peers ...*peer) {
+	keys, pkts := from.conn.take()
+	for i, key := range keys {
+		for _, to := range peers {
+			if to.addr == key.Peer {
+				to.receive(from.addr, to.addr, key.Multihop, ttl, pkts[i])
+			}
+		}
+	}
+}
+
+// run runs the timers of the peers and exchanges their packets for d, the packets of the
+// silent peers are dropped.
+func run(now time.Time, d time.Duration, a, b *peer, silent ...*peer) time.Time {
+	end := now.Add(d)
+	for ; now.Before(end); now = now.Add(tickInterval) {
+		t := now
+		a.Server.now = func() time.Time { return t }
+		b.Server.now = func() time.Time { return t }
+		a.tick(now)
+		b.tick(now)
+		for _, p := range []struct{ from, to *peer }{{a, b}, {b, a}} {
+			drop := false
+			for _, s := range silent {
+				drop = drop || s == p.from
+			}
+			if drop {
+				p.from.conn.take()
+				continue
+			}
+			deliver(p.from, 255, p.to)
+		}
+	}
+	return end
+}
+
+// recvEvent returns the next state change of the peer's sessions.
+func recvEvent(t *testing.T, p *peer) event {
+	t.Helper()
+	select {
+	case e := <-p.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for session event of %v", p.addr)
+	}
+	return event{}
+}
+
+func TestSession(t *testing.T) {
+	a, b := newPeer("192.0.2.1"), newPeer("192.0.2.2")
+	keyA := Key{Peer: b.addr}
+	keyB := Key{Peer: a.addr}
+	fast := Params{DesiredMinTx: 50 * time.Millisecond, RequiredMinRx: 50 * time.Millisecond, DetectMultiplier: 3}
+	a.AddSession("static", keyA, fast)
+	b.AddSession("bgp", keyB, fast)
+
+	now := run(time.Now(), 3*time.Second, a, b)
+	if got, want := recvEvent(t, a), (event{key: keyA, up: true}); got != want {
+		t.Errorf("session event of a: got %+v, want %+v", got, want)
+	}
+	if got, want := recvEvent(t, b), (event{key: keyB, up: true}); got != want {
+		t.Errorf("session event of b: got %+v, want %+v", got, want)
+	}
+	if !a.Up(keyA) || !b.Up(keyB) {
+		t.Fatalf("Up() got false after the sessions came up")
+	}
+	sessions := a.Sessions()
+	want := []*SessionState{{
+		Key:           keyA,
+		State:         StateUp,
+		RemoteState:   StateUp,
+		Params:        fast,
+		RemoteMinRx:   fast.RequiredMinRx,
+		RemoteMinTx:   fast.DesiredMinTx,
+		RemoteMult:    3,
+		UpTransitions: 1,
+	}}
+	if d := cmp.Diff(sessions, want, cmpopts.EquateComparable(netip.Addr{}), cmpopts.IgnoreFields(SessionState{}, "LocalDisc", "RemoteDisc", "LastChange", "InPkts", "OutPkts")); d != "" {
+		t.Errorf("Sessions() failed: diff(-got,+want)\n:%s", d)
+	}
+	// The packets are sent at the fast rate once the session is up.
+	before := sessions[0].OutPkts
+	now = run(now, time.Second, a, b)
+	if got := a.Sessions()[0].OutPkts - before; got < 15 {
+		t.Errorf("a sent %d packets in one second, want at least 15", got)
+	}
+
+	// b stops sending, a detects the failure after 3 intervals of 50ms.
+	run(now, 200*time.Millisecond, a, b, b)
+	if got, want := recvEvent(t, a), (event{key: keyA, up: false}); got != want {
+		t.Errorf("session event of a: got %+v, want %+v", got, want)
+	}
+	if s := a.Sessions()[0]; s.State != StateDown || s.LocalDiag != DiagDetectionTimeExpired || s.DownTransitions != 1 {
+		t.Errorf("Sessions() got %+v, want down session after detection time", s)
+	}
+}
+
+func TestRemoveSession(t *testing.T) {
+	a, b := newPeer("2001:db8::1"), newPeer("2001:db8::2")
+	keyA := Key{Peer: b.addr, Multihop: true}
+	keyB := Key{Peer: a.addr, Multihop: true}
+	a.AddSession("static", keyA, Params{})
+	a.AddSession("bgp", keyA, Params{DesiredMinTx: 100 * time.Millisecond})
+	b.AddSession("bgp", keyB, Params{})
+	now := run(time.Now(), 3*time.Second, a, b)
+	recvEvent(t, a)
+	recvEvent(t, b)
+	if got := a.Sessions()[0].Params; got != (Params{DesiredMinTx: 100 * time.Millisecond, RequiredMinRx: 300 * time.Millisecond, DetectMultiplier: 3}) {
+		t.Errorf("Sessions() got params %+v, want fastest params of the owners", got)
+	}
+
+	// The session is kept until its last owner is removed.
+	a.RemoveSession("static", keyA)
+	if !a.Up(keyA) {
+		t.Errorf("Up() got false after removing one of the owners")
+	}
+	a.RemoveSession("bgp", keyA)
+	if got, want := recvEvent(t, a), (event{key: keyA, up: false}); got != want {
+		t.Errorf("session event of a: got %+v, want %+v", got, want)
+	}
+	// The peer goes down immediately when it receives the admin down packet.
+	deliver(a, 255, b)
+	if got, want := recvEvent(t, b), (event{key: keyB, up: false}); got != want {
+		t.Errorf("session event of b: got %+v, want %+v", got, want)
+	}
+	if s := b.Sessions()[0]; s.LocalDiag != DiagNeighborDown {
+		t.Errorf("Sessions() got diagnostic %v, want %v", s.LocalDiag, DiagNeighborDown)
+	}
+	if len(a.Sessions()) != 0 {
+		t.Errorf("Sessions() got sessions after removing the owners")
+	}
+	run(now, time.Second, a, b)
+}
+
+func TestSingleHopTTL(t *testing.T) {
+	a, b := newPeer("192.0.2.1"), newPeer("192.0.2.2")
+	a.AddSession("static", Key{Peer: b.addr}, Params{})
+	b.AddSession("static", Key{Peer: a.addr}, Params{})
+	a.tick(time.Now())
+	// The packet was forwarded by a router.
+	deliver(a, 254, b)
+	if got := b.Sessions()[0]; got.InPkts != 0 || got.State != StateDown {
+		t.Errorf("Sessions() got %+v, want packet with TTL 254 dropped", got)
+	}
+}
+
+// freePort returns a UDP port that is not used on the loopback addresses.
+func freePort(t *testing.T) int {
+	t.Helper()
+	c, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP() unexpected err: %v", err)
+	}
+	defer c.Close()
+	return c.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUDP(t *testing.T) {
+	singleHop, multiHop := freePort(t), freePort(t)
+	var peers []*Server
+	var events []chan event
+	for _, addr := range []string{"127.0.0.1", "127.0.0.2"} {
+		s := New()
+		c, err := listenUDP(addr, singleHop, multiHop, s.receive)
+		if err != nil {
+			t.Fatalf("listenUDP(%q) unexpected err: %v", addr, err)
+		}
+		s.start(c)
+		defer s.Stop()
+		ch := make(chan event, 10)
+		s.Watch(func(key Key, up bool) { ch <- event{key: key, up: up} })
+		peers = append(peers, s)
+		events = append(events, ch)
+	}
+	fast := Params{DesiredMinTx: 20 * time.Millisecond, RequiredMinRx: 20 * time.Millisecond, DetectMultiplier: 3}
+	peers[0].AddSession("static", Key{Peer: netip.MustParseAddr("127.0.0.2")}, fast)
+	peers[1].AddSession("static", Key{Peer: netip.MustParseAddr("127.0.0.1")}, fast)
+
+	for i, ch := range events {
+		select {
+		case e := <-ch:
+			if !e.up {
+				t.Fatalf("peer %d got event %+v, want session up", i, e)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("peer %d session did not come up", i)
+		}
+	}
+
+	// The failure of the peer is detected in less than a second.
+	start := time.Now()
+	peers[1].Stop()
+	select {
+	case e := <-events[0]:
+		if e.up {
+			t.Fatalf("got event %+v, want session down", e)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("session went down after %v, want less than a second", d)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("session did not go down")
+	}
+}
+
+func TestListen(t *testing.T) {
+	s := New()
+	defer s.Stop()
+	listens := 0
+	var listenErr error
+	s.listen = func() (conn, error) {
+		listens++
+		if listenErr != nil {
+			return nil, listenErr
+		}
+		return &fakeConn{}, nil
+	}
+	if listens != 0 {
+		t.Fatalf("New() listened %d times, want no listen before a session is added", listens)
+	}
+
+	listenErr = errors.New("address already in use")
+	key := Key{Peer: netip.MustParseAddr("192.0.2.1")}
+	if err := s.AddSession("static", key, Params{}); err == nil {
+		t.Fatalf("AddSession() got nil error, want listen error")
+	}
+	if got := len(s.Sessions()); got != 1 {
+		t.Errorf("Sessions() got %d sessions after failed listen, want 1", got)
+	}
+
+	listenErr = nil
+	if err := s.AddSession("static", key, Params{}); err != nil {
+		t.Fatalf("AddSession() unexpected error: %v", err)
+	}
+	if err := s.AddSession("bgp", Key{Peer: netip.MustParseAddr("192.0.2.2")}, Params{}); err != nil {
+		t.Fatalf("AddSession() unexpected error: %v", err)
+	}
+	if listens != 2 {
+		t.Errorf("AddSession() listened %d times, want 2", listens)
+	}
+
+	// The server does not listen again once stopped.
+	s.Stop()
+	if err := s.AddSession("static", Key{Peer: netip.MustParseAddr("192.0.2.3")}, Params{}); err != nil {
+		t.Fatalf("AddSession() after Stop() unexpected error: %v", err)
+	}
+	if listens != 2 {
+		t.Errorf("AddSession() after Stop() listened %d times, want 2", listens)
+	}
+}
+
+func TestStateLeaves(t *testing.T) {
+	st := &SessionState{
+		Key:             Key{Peer: netip.MustParseAddr("192.0.2.2"), Local: netip.MustParseAddr("192.0.2.1"), Multihop: true},
+		State:           StateDown,
+		RemoteState:     StateUp,
+		LocalDiag:       DiagDetectionTimeExpired,
+		LocalDisc:       7,
+		RemoteDisc:      9,
+		Params:          Params{DesiredMinTx: 50 * time.Millisecond, RequiredMinRx: 100 * time.Millisecond, DetectMultiplier: 3},
+		RemoteMinRx:     time.Millisecond,
+		LastChange:      time.Unix(0, 42),
+		InPkts:          10,
+		OutPkts:         11,
+		UpTransitions:   2,
+		DownTransitions: 1,
+	}
+	want := map[string]any{
+		"state/local-discriminator":             uint32(7),
+		"state/remote-discriminator":            uint32(9),
+		"state/local-address":                   "192.0.2.1",
+		"state/remote-address":                  "192.0.2.2",
+		"state/multihop":                        true,
+		"state/session-state":                   "DOWN",
+		"state/remote-session-state":            "UP",
+		"state/local-diagnostic-code":           "DETECTION_TIMEOUT",
+		"state/remote-diagnostic-code":          "NO_DIAGNOSTIC",
+		"state/detection-multiplier":            uint8(3),
+		"state/desired-minimum-tx-interval":     uint32(50000),
+		"state/required-minimum-receive":        uint32(100000),
+		"state/remote-minimum-receive-interval": uint32(1000),
+		"state/last-change":                     uint64(42),
+		"state/failure-transitions":             uint64(1),
+		"state/async/received-packets":          uint64(10),
+		"state/async/transmitted-packets":       uint64(11),
+		"state/async/up-transitions":            uint64(2),
+	}
+	if d := cmp.Diff(stateLeaves(st), want); d != "" {
+		t.Errorf("stateLeaves() failed: diff(-got,+want)\n:%s", d)
+	}
+}
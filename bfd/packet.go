@@ -0,0 +1,148 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// State is the state of a BFD session.
+type State uint8
+
+const (
+	StateAdminDown State = iota
+	StateDown
+	StateInit
+	StateUp
+)
+
+func (s State) String() string {
+	switch s {
+	case StateAdminDown:
+		return "ADMIN_DOWN"
+	case StateDown:
+		return "DOWN"
+	case StateInit:
+		return "INIT"
+	case StateUp:
+		return "UP"
+	}
+	return fmt.Sprintf("STATE_%d", uint8(s))
+}
+
+// Diag is the reason of the last state change of a session.
+type Diag uint8
+
+const (
+	DiagNone Diag = iota
+	DiagDetectionTimeExpired
+	DiagEchoFailed
+	DiagNeighborDown
+	DiagForwardingPlaneReset
+	DiagPathDown
+	DiagConcatenatedPathDown
+	DiagAdminDown
+	DiagReverseConcatenatedPathDown
+)
+
+const (
+	version = 1
+	// packetLength is the length of the control packets without authentication.
+	packetLength = 24
+
+	flagPoll       = 0x20
+	flagFinal      = 0x10
+	flagCPI        = 0x08
+	flagAuth       = 0x04
+	flagDemand     = 0x02
+	flagMultipoint = 0x01
+)
+
+// packet is a BFD control packet.
+type packet struct {
+	diag          Diag
+	state         State
+	poll          bool
+	final         bool
+	detectMult    uint8
+	myDisc        uint32
+	yourDisc      uint32
+	desiredMinTx  time.Duration
+	requiredMinRx time.Duration
+}
+
+// marshal returns the control packet on the wire, the intervals are in microseconds.
+func (p *packet) marshal() []byte {
+	b := make([]byte, 0, packetLength)
+	b = append(b, version<<5|uint8(p.diag)&0x1f)
+	flags := uint8(p.state) << 6
+	if p.poll {
+		flags |= flagPoll
+	}
+	if p.final {
+		flags |= flagFinal
+	}
+	b = append(b, flags, p.detectMult, packetLength)
+	b = binary.BigEndian.AppendUint32(b, p.myDisc)
+	b = binary.BigEndian.AppendUint32(b, p.yourDisc)
+	b = binary.BigEndian.AppendUint32(b, uint32(p.desiredMinTx.Microseconds()))
+	b = binary.BigEndian.AppendUint32(b, uint32(p.requiredMinRx.Microseconds()))
+	// Echo packets are not supported.
+	return binary.BigEndian.AppendUint32(b, 0)
+}
+
+// parsePacket returns the control packet in b, after the checks of RFC 5880 section 6.8.6
+// that do not depend on the session.
+func parsePacket(b []byte) (*packet, error) {
+	if len(b) < packetLength {
+		return nil, fmt.Errorf("control packet too short: %d bytes", len(b))
+	}
+	if v := b[0] >> 5; v != version {
+		return nil, fmt.Errorf("unsupported BFD version %d", v)
+	}
+	if l := int(b[3]); l < packetLength || l > len(b) {
+		return nil, fmt.Errorf("invalid length %d of %d bytes packet", l, len(b))
+	}
+	flags := b[1]
+	if flags&flagAuth != 0 {
+		return nil, fmt.Errorf("authentication is not supported")
+	}
+	if flags&flagMultipoint != 0 {
+		return nil, fmt.Errorf("multipoint bit is set")
+	}
+	p := &packet{
+		diag:          Diag(b[0] & 0x1f),
+		state:         State(flags >> 6),
+		poll:          flags&flagPoll != 0,
+		final:         flags&flagFinal != 0,
+		detectMult:    b[2],
+		myDisc:        binary.BigEndian.Uint32(b[4:8]),
+		yourDisc:      binary.BigEndian.Uint32(b[8:12]),
+		desiredMinTx:  time.Duration(binary.BigEndian.Uint32(b[12:16])) * time.Microsecond,
+		requiredMinRx: time.Duration(binary.BigEndian.Uint32(b[16:20])) * time.Microsecond,
+	}
+	if p.detectMult == 0 {
+		return nil, fmt.Errorf("detect multiplier is zero")
+	}
+	if p.myDisc == 0 {
+		return nil, fmt.Errorf("my discriminator is zero")
+	}
+	if p.yourDisc == 0 && p.state != StateDown && p.state != StateAdminDown {
+		return nil, fmt.Errorf("your discriminator is zero in state %v", p.state)
+	}
+	return p, nil
+}
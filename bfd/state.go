@@ -0,0 +1,172 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openconfig/ygnmi/schemaless"
+	"github.com/openconfig/ygnmi/ygnmi"
+
+	"github.com/openconfig/lemming/gnmi"
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/reconciler"
+
+	log "github.com/golang/glog"
+)
+
+// peerPath is the path of the state of a session, keyed by its local discriminator.
+// openconfig-bfd is no longer excluded by gnmi/generate.sh, but the checked-in oc package
+// predates it, so until it is regenerated the state is published as schemaless values in the
+// internal origin, with the leaves of the openconfig-bfd peers.
+// TODO: publish with the typed ocpath queries in the openconfig origin once oc is regenerated.
+var peerPath = fmt.Sprintf("/network-instances/network-instance[name=%s]/protocols/protocol[identifier=BFD][name=BFD]/bfd/peers/peer[local-discriminator=%%d]", fakedevice.DefaultNetworkInstance)
+
+// diagNames are the openconfig-bfd names of the diagnostic codes.
+var diagNames = map[Diag]string{
+	DiagNone:                        "NO_DIAGNOSTIC",
+	DiagDetectionTimeExpired:        "DETECTION_TIMEOUT",
+	DiagEchoFailed:                  "ECHO_FAILED",
+	DiagNeighborDown:                "NEIGHBOR_SIGNALED_DOWN",
+	DiagForwardingPlaneReset:        "FORWARDING_RESET",
+	DiagPathDown:                    "PATH_DOWN",
+	DiagConcatenatedPathDown:        "CONCATENATED_PATH_DOWN",
+	DiagAdminDown:                   "ADMIN_DOWN",
+	DiagReverseConcatenatedPathDown: "REVERSE_CONCATENATED_PATH_DOWN",
+}
+
+func (d Diag) String() string {
+	if name, ok := diagNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("DIAG_%d", uint8(d))
+}
+
+// stateLeaves returns the state leaves of the session, keyed by their path relative to the peer.
+func stateLeaves(st *SessionState) map[string]any {
+	leaves := map[string]any{
+		"state/local-discriminator":             st.LocalDisc,
+		"state/remote-discriminator":            st.RemoteDisc,
+		"state/remote-address":                  st.Peer.String(),
+		"state/multihop":                        st.Multihop,
+		"state/session-state":                   st.State.String(),
+		"state/remote-session-state":            st.RemoteState.String(),
+		"state/local-diagnostic-code":           st.LocalDiag.String(),
+		"state/remote-diagnostic-code":          st.RemoteDiag.String(),
+		"state/detection-multiplier":            st.Params.DetectMultiplier,
+		"state/desired-minimum-tx-interval":     uint32(st.Params.DesiredMinTx.Microseconds()),
+		"state/required-minimum-receive":        uint32(st.Params.RequiredMinRx.Microseconds()),
+		"state/remote-minimum-receive-interval": uint32(st.RemoteMinRx.Microseconds()),
+		"state/last-change":                     uint64(st.LastChange.UnixNano()),
+		"state/failure-transitions":             st.DownTransitions,
+		"state/async/received-packets":          st.InPkts,
+		"state/async/transmitted-packets":       st.OutPkts,
+		"state/async/up-transitions":            st.UpTransitions,
+	}
+	if st.Local.IsValid() {
+		leaves["state/local-address"] = st.Local.String()
+	}
+	return leaves
+}
+
+// batchReplaceLeaf adds the replace of a schemaless leaf of the peer to the batch.
+func batchReplaceLeaf[T any](sb *ygnmi.SetBatch, disc uint32, leaf string, val T) error {
+	q, err := schemaless.NewConfig[T](fmt.Sprintf(peerPath, disc)+"/"+leaf, gnmi.InternalOrigin)
+	if err != nil {
+		return err
+	}
+	gnmiclient.BatchReplace(sb, q, val)
+	return nil
+}
+
+// stateBatch returns a batch that replaces the state of the sessions and deletes the state of
+// the sessions that were removed since the published ones.
+func (s *Server) stateBatch(published map[uint32]bool) (*ygnmi.SetBatch, map[uint32]bool, error) {
+	sb := &ygnmi.SetBatch{}
+	current := map[uint32]bool{}
+	for _, st := range s.Sessions() {
+		current[st.LocalDisc] = true
+		for leaf, val := range stateLeaves(st) {
+			var err error
+			switch v := val.(type) {
+			case string:
+				err = batchReplaceLeaf(sb, st.LocalDisc, leaf, v)
+			case bool:
+				err = batchReplaceLeaf(sb, st.LocalDisc, leaf, v)
+			case uint8:
+				err = batchReplaceLeaf(sb, st.LocalDisc, leaf, v)
+			case uint32:
+				err = batchReplaceLeaf(sb, st.LocalDisc, leaf, v)
+			case uint64:
+				err = batchReplaceLeaf(sb, st.LocalDisc, leaf, v)
+			default:
+				err = fmt.Errorf("unsupported type %T of leaf %q", val, leaf)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	for disc := range published {
+		if current[disc] {
+			continue
+		}
+		q, err := schemaless.NewConfig[string](fmt.Sprintf(peerPath, disc), gnmi.InternalOrigin)
+		if err != nil {
+			return nil, nil, err
+		}
+		gnmiclient.BatchDelete(sb, q)
+	}
+	return sb, current, nil
+}
+
+// Reconciler returns a reconciler that publishes the state of the sessions every second,
+// and stops the server when it stops.
+func (s *Server) Reconciler() *reconciler.BuiltReconciler {
+	cancelFn := func() {}
+	return reconciler.NewBuilder("bfd").WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+		ctx, cancelFn = context.WithCancel(ctx)
+		go func() {
+			tick := time.NewTicker(time.Second)
+			defer tick.Stop()
+			published := map[uint32]bool{}
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tick.C:
+				}
+				sb, current, err := s.stateBatch(published)
+				if err != nil {
+					log.Errorf("BFD state: %v", err)
+					continue
+				}
+				if _, err := sb.Set(ctx, c); err != nil {
+					log.Errorf("BFD state: %v", err)
+					continue
+				}
+				published = current
+			}
+		}()
+		return nil
+	}).WithStop(func(context.Context) error {
+		cancelFn()
+		s.Stop()
+		return nil
+	}).Build()
+}
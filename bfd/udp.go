@@ -0,0 +1,207 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	log "github.com/golang/glog"
+)
+
+const (
+	singleHopPort = 3784
+	multiHopPort  = 4784
+	// minSrcPort is the first of the source ports of the control packets (RFC 5881 section 4).
+	minSrcPort = 49152
+	// ttl is the TTL or hop limit of the control packets, the single-hop packets are dropped if it was decremented.
+	ttl = 255
+	// maxPacketSize is larger than the control packets with authentication.
+	maxPacketSize = 512
+)
+
+// recvFunc processes a control packet received from src on dst.
+type recvFunc func(src, dst netip.Addr, multihop bool, ttl int, b []byte)
+
+// udpConn sends and receives the control packets with UDP sockets.
+type udpConn struct {
+	singleHopPort int
+	multiHopPort  int
+	tx4           *ipv4.PacketConn
+	tx6           *ipv6.PacketConn
+	closers       []func() error
+}
+
+// listenUDP listens for the control packets sent to addr, or to any address if it is empty.
+func listenUDP(addr string, singleHopPort, multiHopPort int, recv recvFunc) (_ *udpConn, rerr error) {
+	c := &udpConn{singleHopPort: singleHopPort, multiHopPort: multiHopPort}
+	defer func() {
+		if rerr != nil {
+			c.close()
+		}
+	}()
+	ip := net.ParseIP(addr)
+	listen4 := addr == "" || ip.To4() != nil
+	listen6 := addr == "" || ip.To4() == nil
+
+	for _, port := range []int{singleHopPort, multiHopPort} {
+		multihop := port == multiHopPort
+		if listen4 {
+			uc, err := net.ListenUDP("udp4", &net.UDPAddr{IP: ip, Port: port})
+			if err != nil {
+				return nil, err
+			}
+			c.closers = append(c.closers, uc.Close)
+			pc := ipv4.NewPacketConn(uc)
+			if err := pc.SetControlMessage(ipv4.FlagTTL|ipv4.FlagDst, true); err != nil {
+				return nil, err
+			}
+			go serve4(pc, multihop, recv)
+		}
+		if listen6 {
+			uc, err := net.ListenUDP("udp6", &net.UDPAddr{IP: ip, Port: port})
+			if err != nil {
+				return nil, err
+			}
+			c.closers = append(c.closers, uc.Close)
+			pc := ipv6.NewPacketConn(uc)
+			if err := pc.SetControlMessage(ipv6.FlagHopLimit|ipv6.FlagDst, true); err != nil {
+				return nil, err
+			}
+			go serve6(pc, multihop, recv)
+		}
+	}
+
+	if listen4 {
+		uc, err := listenSrcPort("udp4", ip)
+		if err != nil {
+			return nil, err
+		}
+		c.closers = append(c.closers, uc.Close)
+		c.tx4 = ipv4.NewPacketConn(uc)
+		if err := c.tx4.SetTTL(ttl); err != nil {
+			return nil, err
+		}
+	}
+	if listen6 {
+		uc, err := listenSrcPort("udp6", ip)
+		if err != nil {
+			return nil, err
+		}
+		c.closers = append(c.closers, uc.Close)
+		c.tx6 = ipv6.NewPacketConn(uc)
+		if err := c.tx6.SetHopLimit(ttl); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// listenSrcPort returns a socket bound to a free port in the range of the source ports.
+func listenSrcPort(network string, ip net.IP) (*net.UDPConn, error) {
+	for port := minSrcPort; port <= 65535; port++ {
+		uc, err := net.ListenUDP(network, &net.UDPAddr{IP: ip, Port: port})
+		if err == nil {
+			return uc, nil
+		}
+	}
+	return nil, fmt.Errorf("no free %s source port", network)
+}
+
+func serve4(pc *ipv4.PacketConn, multihop bool, recv recvFunc) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, cm, src, err := pc.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warningf("BFD: failed to read packet: %v", err)
+			continue
+		}
+		srcAddr := src.(*net.UDPAddr).AddrPort().Addr().Unmap()
+		hops := -1
+		var dst netip.Addr
+		if cm != nil {
+			hops = cm.TTL
+			dst, _ = netip.AddrFromSlice(cm.Dst.To4())
+		}
+		recv(srcAddr, dst, multihop, hops, buf[:n])
+	}
+}
+
+func serve6(pc *ipv6.PacketConn, multihop bool, recv recvFunc) {
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, cm, src, err := pc.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Warningf("BFD: failed to read packet: %v", err)
+			continue
+		}
+		srcAddr := src.(*net.UDPAddr).AddrPort().Addr()
+		hops := -1
+		var dst netip.Addr
+		if cm != nil {
+			hops = cm.HopLimit
+			dst, _ = netip.AddrFromSlice(cm.Dst)
+		}
+		recv(srcAddr, dst, multihop, hops, buf[:n])
+	}
+}
+
+func (c *udpConn) send(key Key, b []byte) error {
+	port := c.singleHopPort
+	if key.Multihop {
+		port = c.multiHopPort
+	}
+	dst := net.UDPAddrFromAddrPort(netip.AddrPortFrom(key.Peer, uint16(port)))
+	var err error
+	if key.Peer.Is4() {
+		if c.tx4 == nil {
+			return fmt.Errorf("IPv4 is not enabled")
+		}
+		var cm *ipv4.ControlMessage
+		if key.Local.IsValid() {
+			cm = &ipv4.ControlMessage{Src: key.Local.AsSlice()}
+		}
+		_, err = c.tx4.WriteTo(b, cm, dst)
+	} else {
+		if c.tx6 == nil {
+			return fmt.Errorf("IPv6 is not enabled")
+		}
+		var cm *ipv6.ControlMessage
+		if key.Local.IsValid() {
+			cm = &ipv6.ControlMessage{Src: key.Local.AsSlice()}
+		}
+		_, err = c.tx6.WriteTo(b, cm, dst)
+	}
+	return err
+}
+
+func (c *udpConn) close() error {
+	var errs []error
+	for _, f := range c.closers {
+		errs = append(errs, f())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bgp
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	log "github.com/golang/glog"
+	api "github.com/osrg/gobgp/v3/api"
+
+	"github.com/openconfig/lemming/bfd"
+	"github.com/openconfig/lemming/gnmi/oc"
+)
+
+const (
+	// bfdOwner is the owner of the BFD sessions of the neighbors.
+	bfdOwner = "bgp"
+	// bfdCommunication is the shutdown communication sent to the neighbors disabled by their BFD session.
+	bfdCommunication = "BFD session down"
+)
+
+// EnableBFD enables the BFD sessions of the neighbors configured with enable-bfd.
+// A neighbor is administratively disabled when its BFD session goes down,
+// and enabled again once the session is up.
+// It must be called before the reconciler is started.
+func (g *GoBGP) EnableBFD(s *bfd.Server) {
+	t := g.task
+	t.bfd = s
+	s.Watch(func(key bfd.Key, up bool) {
+		t.bfdChanged(context.Background(), key, up)
+	})
+}
+
+// bfdChanged disables the neighbor of a BFD session that went down, and enables it
+// once the session is up again.
+func (t *bgpTask) bfdChanged(ctx context.Context, key bfd.Key, up bool) {
+	t.appliedStateMu.Lock()
+	defer t.appliedStateMu.Unlock()
+	if _, ok := t.bfdSessions[key]; !ok || !t.bgpStarted {
+		return
+	}
+	switch {
+	case !up && !t.bfdDown[key]:
+		log.Infof("BFD session %v is down, disabling BGP neighbor", key)
+		if err := t.bgpServer.DisablePeer(ctx, &api.DisablePeerRequest{
+			Address:       key.Peer.String(),
+			Communication: bfdCommunication,
+		}); err != nil {
+			log.Warningf("failed to disable BGP neighbor %v: %v", key.Peer, err)
+			return
+		}
+		t.bfdDown[key] = true
+	case up && t.bfdDown[key]:
+		log.Infof("BFD session %v is up, enabling BGP neighbor", key)
+		t.enableBFDPeer(ctx, key)
+	}
+}
+
+// enableBFDPeer enables the neighbor that was disabled by its BFD session.
+// The caller must hold appliedStateMu.
+func (t *bgpTask) enableBFDPeer(ctx context.Context, key bfd.Key) {
+	delete(t.bfdDown, key)
+	if !t.bgpStarted {
+		return
+	}
+	if err := t.bgpServer.EnablePeer(ctx, &api.EnablePeerRequest{Address: key.Peer.String()}); err != nil {
+		log.Warningf("failed to enable BGP neighbor %v: %v", key.Peer, err)
+	}
+}
+
+// connectedPrefixes returns the subnets of the addresses configured on the interfaces.
+func connectedPrefixes(root *oc.Root) []netip.Prefix {
+	var prefixes []netip.Prefix
+	add := func(ip *string, length *uint8) {
+		if ip == nil || length == nil {
+			return
+		}
+		pfx, err := netip.ParsePrefix(fmt.Sprintf("%s/%d", *ip, *length))
+		if err != nil {
+			return
+		}
+		prefixes = append(prefixes, pfx.Masked())
+	}
+	for _, intf := range root.Interface {
+		for _, sub := range intf.Subinterface {
+			if v4 := sub.GetIpv4(); v4 != nil {
+				for _, a := range v4.Address {
+					add(a.Ip, a.PrefixLength)
+				}
+			}
+			if v6 := sub.GetIpv6(); v6 != nil {
+				for _, a := range v6.Address {
+					add(a.Ip, a.PrefixLength)
+				}
+			}
+		}
+	}
+	return prefixes
+}
+
+// neighborBFDSessions returns the BFD sessions of the neighbors with enable-bfd.
+// The sessions of the eBGP neighbors with ebgp-multihop and of the iBGP neighbors
+// outside of the connected subnets are multi-hop.
+func neighborBFDSessions(bgpoc *oc.NetworkInstance_Protocol_Bgp, connected []netip.Prefix) map[bfd.Key]bfd.Params {
+	sessions := map[bfd.Key]bfd.Params{}
+	for addr, neigh := range bgpoc.Neighbor {
+		c := neigh.GetEnableBfd()
+		if !c.GetEnabled() {
+			continue
+		}
+		peer, err := netip.ParseAddr(addr)
+		if err != nil {
+			continue
+		}
+		multihop := neigh.GetEbgpMultihop().GetEnabled()
+		if neigh.GetPeerAs() == bgpoc.GetGlobal().GetAs() {
+			multihop = !slices.ContainsFunc(connected, func(p netip.Prefix) bool { return p.Contains(peer) })
+		}
+		key := bfd.Key{
+			Peer:     peer,
+			Multihop: multihop,
+		}
+		// The local address may also be an interface name, which is left to the kernel.
+		if local, err := netip.ParseAddr(neigh.GetTransport().GetLocalAddress()); err == nil {
+			key.Local = local
+		}
+		sessions[key] = bfd.Params{
+			DesiredMinTx:     time.Duration(c.GetDesiredMinimumTxInterval()) * time.Microsecond,
+			RequiredMinRx:    time.Duration(c.GetRequiredMinimumReceive()) * time.Microsecond,
+			DetectMultiplier: c.GetDetectionMultiplier(),
+		}
+	}
+	return sessions
+}
+
+// syncBFD adds the BFD sessions of the neighbors and removes the ones that are
+// no longer configured. The caller must hold appliedStateMu.
+func (t *bgpTask) syncBFD(bgpoc *oc.NetworkInstance_Protocol_Bgp, connected []netip.Prefix) {
+	if t.bfd == nil {
+		return
+	}
+	sessions := neighborBFDSessions(bgpoc, connected)
+	for key, p := range sessions {
+		if err := t.bfd.AddSession(bfdOwner, key, p); err != nil {
+			log.Warningf("BFD session %v of BGP neighbor stays down: %v", key, err)
+		}
+	}
+	for key := range t.bfdSessions {
+		if _, ok := sessions[key]; !ok {
+			t.bfd.RemoveSession(bfdOwner, key)
+			// The neighbor no longer depends on the session.
+			if t.bfdDown[key] {
+				t.enableBFDPeer(context.Background(), key)
+			}
+		}
+	}
+	t.bfdSessions = sessions
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bgp
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/osrg/gobgp/v3/pkg/server"
+
+	api "github.com/osrg/gobgp/v3/api"
+
+	"github.com/openconfig/lemming/bfd"
+	"github.com/openconfig/lemming/gnmi/oc"
+)
+
+func TestNeighborBFDSessions(t *testing.T) {
+	bgpoc := &oc.NetworkInstance_Protocol_Bgp{}
+	bgpoc.GetOrCreateGlobal().As = ygot.Uint32(64500)
+
+	ebgp := bgpoc.GetOrCreateNeighbor("192.0.2.1")
+	ebgp.PeerAs = ygot.Uint32(64501)
+	ebgp.GetOrCreateEnableBfd().Enabled = ygot.Bool(true)
+
+	ibgp := bgpoc.GetOrCreateNeighbor("2001:db8::2")
+	ibgp.PeerAs = ygot.Uint32(64500)
+	ibgp.GetOrCreateTransport().LocalAddress = ygot.String("2001:db8::1")
+	ibfd := ibgp.GetOrCreateEnableBfd()
+	ibfd.Enabled = ygot.Bool(true)
+	ibfd.DesiredMinimumTxInterval = ygot.Uint32(100000)
+	ibfd.RequiredMinimumReceive = ygot.Uint32(200000)
+	ibfd.DetectionMultiplier = ygot.Uint8(4)
+
+	// iBGP neighbors in a connected subnet have single-hop sessions.
+	connected := bgpoc.GetOrCreateNeighbor("198.51.100.2")
+	connected.PeerAs = ygot.Uint32(64500)
+	connected.GetOrCreateEnableBfd().Enabled = ygot.Bool(true)
+
+	multihop := bgpoc.GetOrCreateNeighbor("192.0.2.4")
+	multihop.PeerAs = ygot.Uint32(64504)
+	multihop.GetOrCreateEbgpMultihop().Enabled = ygot.Bool(true)
+	multihop.GetOrCreateEnableBfd().Enabled = ygot.Bool(true)
+
+	disabled := bgpoc.GetOrCreateNeighbor("192.0.2.3")
+	disabled.PeerAs = ygot.Uint32(64503)
+	disabled.GetOrCreateEnableBfd().Enabled = ygot.Bool(false)
+
+	want := map[bfd.Key]bfd.Params{
+		{Peer: netip.MustParseAddr("192.0.2.1")}:                 {},
+		{Peer: netip.MustParseAddr("198.51.100.2")}:              {},
+		{Peer: netip.MustParseAddr("192.0.2.4"), Multihop: true}: {},
+		{Peer: netip.MustParseAddr("2001:db8::2"), Local: netip.MustParseAddr("2001:db8::1"), Multihop: true}: {
+			DesiredMinTx:     100 * time.Millisecond,
+			RequiredMinRx:    200 * time.Millisecond,
+			DetectMultiplier: 4,
+		},
+	}
+	root := &oc.Root{}
+	a := root.GetOrCreateInterface("eth0").GetOrCreateSubinterface(0).GetOrCreateIpv4().GetOrCreateAddress("198.51.100.1")
+	a.PrefixLength = ygot.Uint8(30)
+	if diff := cmp.Diff(want, neighborBFDSessions(bgpoc, connectedPrefixes(root)), cmpopts.EquateComparable(netip.Addr{})); diff != "" {
+		t.Errorf("neighborBFDSessions() (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBFDDisablesNeighbor(t *testing.T) {
+	ctx := context.Background()
+	g := NewGoBGP("local", "", 0)
+	g.task.bgpServer = server.NewBgpServer()
+	go g.task.bgpServer.Serve()
+	defer g.task.bgpServer.Stop()
+
+	if err := g.task.bgpServer.StartBgp(ctx, &api.StartBgpRequest{Global: &api.Global{Asn: 1, RouterId: "192.0.2.0", ListenPort: -1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.task.bgpServer.AddPeer(ctx, &api.AddPeerRequest{Peer: &api.Peer{Conf: &api.PeerConf{NeighborAddress: "192.0.2.1", PeerAsn: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	g.task.bgpStarted = true
+	g.EnableBFD(bfd.New())
+	key := bfd.Key{Peer: netip.MustParseAddr("192.0.2.1")}
+	g.task.bfdSessions = map[bfd.Key]bfd.Params{key: {}}
+
+	// The admin state is changed asynchronously by the FSM of the neighbor.
+	waitAdminState := func(desc string, want api.PeerState_AdminState) {
+		t.Helper()
+		var got api.PeerState_AdminState
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if err := g.task.bgpServer.ListPeer(ctx, &api.ListPeerRequest{Address: "192.0.2.1"}, func(p *api.Peer) {
+				got = p.GetState().GetAdminState()
+			}); err != nil {
+				t.Fatalf("ListPeer() unexpected error: %v", err)
+			}
+			if got == want {
+				return
+			}
+		}
+		t.Fatalf("neighbor admin state %s got %v, want %v", desc, got, want)
+	}
+
+	g.task.bfdChanged(ctx, key, false)
+	waitAdminState("after BFD down", api.PeerState_DOWN)
+	// The neighbor stays disabled until the session is up, even if it is reset meanwhile.
+	if err := g.task.bgpServer.ResetPeer(ctx, &api.ResetPeerRequest{Address: "192.0.2.1"}); err != nil {
+		t.Fatalf("ResetPeer() unexpected error: %v", err)
+	}
+	waitAdminState("after reset", api.PeerState_DOWN)
+	g.task.bfdChanged(ctx, key, true)
+	waitAdminState("after BFD up", api.PeerState_UP)
+
+	// Removing the session enables the neighbor.
+	g.task.bfdChanged(ctx, key, false)
+	waitAdminState("after BFD down", api.PeerState_DOWN)
+	g.task.syncBFD(&oc.NetworkInstance_Protocol_Bgp{}, nil)
+	waitAdminState("after removing the BFD session", api.PeerState_UP)
+}
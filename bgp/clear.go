@@ -0,0 +1,87 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bgp
+
+import (
+	"context"
+	"net/netip"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	api "github.com/osrg/gobgp/v3/api"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+)
+
+// ClearMode is how a BGP neighbor is cleared.
+type ClearMode int
+
+const (
+	// ClearHard tears down the session, which is then reestablished.
+	ClearHard ClearMode = iota
+	// ClearSoft requests a route refresh from the neighbor and readvertises the routes to it.
+	ClearSoft
+	// ClearSoftIn reapplies the import policies to the routes received from the neighbor.
+	ClearSoftIn
+)
+
+// clearCommunication is the shutdown communication sent to neighbors that are cleared.
+const clearCommunication = "cleared by administrator"
+
+// ClearNeighbor clears the BGP session with the neighbor in the network instance.
+// Only the default network instance is supported, it is used if niName is empty.
+func (g *GoBGP) ClearNeighbor(ctx context.Context, niName string, addr netip.Addr, mode ClearMode) error {
+	if niName != "" && niName != fakedevice.DefaultNetworkInstance {
+		return status.Errorf(codes.NotFound, "BGP is not running in network instance %q", niName)
+	}
+	t := g.task
+	t.appliedStateMu.Lock()
+	started := t.bgpStarted
+	t.appliedStateMu.Unlock()
+	if !started {
+		return status.Errorf(codes.FailedPrecondition, "BGP is not running")
+	}
+
+	found := false
+	if err := t.bgpServer.ListPeer(ctx, &api.ListPeerRequest{Address: addr.String()}, func(*api.Peer) {
+		found = true
+	}); err != nil {
+		return status.Errorf(codes.Internal, "failed to list BGP neighbors: %v", err)
+	}
+	if !found {
+		return status.Errorf(codes.NotFound, "BGP neighbor %v not found", addr)
+	}
+
+	req := &api.ResetPeerRequest{Address: addr.String()}
+	switch mode {
+	case ClearHard:
+		req.Communication = clearCommunication
+	case ClearSoft:
+		req.Soft = true
+		req.Direction = api.ResetPeerRequest_BOTH
+	case ClearSoftIn:
+		req.Soft = true
+		req.Direction = api.ResetPeerRequest_IN
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown clear mode %v", mode)
+	}
+	log.Infof("Clearing BGP neighbor %v, mode %v", addr, mode)
+	if err := t.bgpServer.ResetPeer(ctx, req); err != nil {
+		return status.Errorf(codes.Internal, "failed to clear BGP neighbor %v: %v", addr, err)
+	}
+	return nil
+}
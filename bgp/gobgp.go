@@ -34,6 +34,7 @@ import (
 	gobgpoc "github.com/osrg/gobgp/v3/pkg/config/oc"
 	"github.com/osrg/gobgp/v3/pkg/server"
 
+	"github.com/openconfig/lemming/bfd"
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/gnmiclient"
 	"github.com/openconfig/lemming/gnmi/oc"
@@ -56,8 +57,22 @@ var (
 
 // NewGoBGPTask creates a new GoBGP task implementing OpenConfig BGP functionalities.
 func NewGoBGPTask(targetName, zapiURL string, listenPort uint16) *reconciler.BuiltReconciler {
-	gobgpTask := newBgpTask(targetName, zapiURL, listenPort)
-	return reconciler.NewBuilder("gobgp").WithStart(gobgpTask.start).WithStop(gobgpTask.stop).WithValidator(
+	return NewGoBGP(targetName, zapiURL, listenPort).Reconciler()
+}
+
+// GoBGP implements OpenConfig BGP functionalities with a GoBGP server.
+type GoBGP struct {
+	task *bgpTask
+}
+
+// NewGoBGP creates a new GoBGP instance, its reconciler must be started to run the GoBGP server.
+func NewGoBGP(targetName, zapiURL string, listenPort uint16) *GoBGP {
+	return &GoBGP{task: newBgpTask(targetName, zapiURL, listenPort)}
+}
+
+// Reconciler returns the reconciler that applies the BGP config to the GoBGP server.
+func (g *GoBGP) Reconciler() *reconciler.BuiltReconciler {
+	return reconciler.NewBuilder("gobgp").WithStart(g.task.start).WithStop(g.task.stop).WithValidator(
 		[]ygnmi.PathStruct{
 			RoutingPolicyPath.DefinedSets().PrefixSetAny().Mode().Config().PathStruct(),
 		}, validatePrefixSetMode).Build()
@@ -117,6 +132,8 @@ type bgpTask struct {
 	listenPort    uint16
 
 	bgpStarted bool
+	// bgpStopped is true if BGP was stopped after being started.
+	bgpStopped bool
 
 	yclient *ygnmi.Client
 
@@ -127,6 +144,13 @@ type bgpTask struct {
 	appliedState         *oc.Root
 	appliedBGP           *oc.NetworkInstance_Protocol_Bgp
 	appliedRoutingPolicy *oc.RoutingPolicy
+
+	// bfd runs the BFD sessions of the neighbors, nil if BFD is not enabled.
+	bfd *bfd.Server
+	// bfdSessions contains the BFD sessions of the neighbors.
+	bfdSessions map[bfd.Key]bfd.Params
+	// bfdDown contains the BFD sessions that are down, whose neighbors are disabled.
+	bfdDown map[bfd.Key]bool
 }
 
 // newBgpTask creates a new bgpTask.
@@ -147,6 +171,8 @@ func newBgpTask(targetName, zapiURL string, listenPort uint16) *bgpTask {
 		appliedState:         appliedState,
 		appliedBGP:           appliedBGP,
 		appliedRoutingPolicy: appliedRoutingPolicy,
+
+		bfdDown: map[bfd.Key]bool{},
 	}
 }
 
@@ -170,6 +196,13 @@ func (t *bgpTask) start(ctx context.Context, yclient *ygnmi.Client) error {
 		BGPPath.NeighborAny().NeighborPort().Config().PathStruct(),
 		BGPPath.NeighborAny().Transport().LocalAddress().Config().PathStruct(),
 		BGPPath.NeighborAny().AsPathOptions().Config().PathStruct(),
+		BGPPath.NeighborAny().EnableBfd().Config().PathStruct(),
+		BGPPath.NeighborAny().EbgpMultihop().Enabled().Config().PathStruct(),
+		// The connected subnets tell the single-hop BFD sessions from the multi-hop ones.
+		ocpath.Root().InterfaceAny().SubinterfaceAny().Ipv4().AddressAny().Ip().Config().PathStruct(),
+		ocpath.Root().InterfaceAny().SubinterfaceAny().Ipv4().AddressAny().PrefixLength().Config().PathStruct(),
+		ocpath.Root().InterfaceAny().SubinterfaceAny().Ipv6().AddressAny().Ip().Config().PathStruct(),
+		ocpath.Root().InterfaceAny().SubinterfaceAny().Ipv6().AddressAny().PrefixLength().Config().PathStruct(),
 		// BGP Policy statements
 		RoutingPolicyPath.PolicyDefinitionAny().Name().Config().PathStruct(),
 		RoutingPolicyPath.PolicyDefinitionAny().StatementMap().Config().PathStruct(),
@@ -207,7 +240,11 @@ func (t *bgpTask) start(ctx context.Context, yclient *ygnmi.Client) error {
 		func(root *ygnmi.Value[*oc.Root]) error {
 			rootVal, ok := root.Val()
 			if !ok {
-				return ygnmi.Continue
+				if !t.bgpStarted {
+					return ygnmi.Continue
+				}
+				// All BGP config was deleted, so BGP needs to be stopped.
+				rootVal = &oc.Root{}
 			}
 
 			t.updateAppliedState(ctx, func() error {
@@ -238,6 +275,19 @@ func (t *bgpTask) reconcile(ctx context.Context, intended *oc.Root) error {
 	intendedGlobal := intendedBGP.GetOrCreateGlobal()
 	bgpShouldStart := intendedGlobal.As != nil && intendedGlobal.RouterId != nil
 	switch {
+	case bgpShouldStart && !t.bgpStarted && t.bgpStopped:
+		// The GoBGP server cannot be initialized twice, so only the
+		// global config is applied before updating the rest.
+		log.V(1).Info("Restarting BGP")
+		if err := t.bgpServer.StartBgp(ctx, &api.StartBgpRequest{Global: gobgpoc.NewGlobalFromConfigStruct(&newConfig.Global)}); err != nil {
+			return fmt.Errorf("Failed to restart BGP: %v", err)
+		}
+		var err error
+		t.currentConfig, err = config.UpdateConfig(ctx, t.bgpServer, t.currentConfig, newConfig)
+		if err != nil {
+			return fmt.Errorf("Failed to update BGP service: %v", newConfig)
+		}
+		t.bgpStarted = true
 	case bgpShouldStart && !t.bgpStarted:
 		log.V(1).Info("Starting BGP")
 		var err error
@@ -246,6 +296,21 @@ func (t *bgpTask) reconcile(ctx context.Context, intended *oc.Root) error {
 			return fmt.Errorf("Failed to apply initial BGP configuration %v", newConfig)
 		}
 		t.bgpStarted = true
+	case !bgpShouldStart && t.bgpStarted:
+		// The global config was removed (e.g. by a factory reset), so
+		// stop BGP, which removes all neighbors and their routes.
+		log.V(1).Info("Stopping BGP")
+		if err := t.bgpServer.StopBgp(ctx, &api.StopBgpRequest{}); err != nil {
+			return fmt.Errorf("Failed to stop BGP: %v", err)
+		}
+		t.currentConfig.Neighbors = nil
+		t.currentConfig.PeerGroups = nil
+		t.currentConfig.DynamicNeighbors = nil
+		t.bgpStarted = false
+		t.bgpStopped = true
+		*t.appliedBGP = oc.NetworkInstance_Protocol_Bgp{}
+		t.syncBFD(&oc.NetworkInstance_Protocol_Bgp{}, nil)
+		return nil
 	case t.bgpStarted:
 		log.V(1).Info("Updating BGP")
 		var err error
@@ -257,6 +322,7 @@ func (t *bgpTask) reconcile(ctx context.Context, intended *oc.Root) error {
 		// Waiting for BGP to be startable.
 		return nil
 	}
+	t.syncBFD(intendedBGP, connectedPrefixes(intended))
 
 	err := ygot.MergeStructInto(t.appliedBGP, intendedBGP, &ygot.MergeOverwriteExistingFields{})
 	// TODO(wenbli): Since policy definitions is an atomic node,
@@ -1,10 +1,18 @@
 package bgp
 
 import (
+	"context"
+	"net/netip"
 	"testing"
 
-	"github.com/openconfig/lemming/gnmi/oc"
 	"github.com/openconfig/ygot/ygot"
+	api "github.com/osrg/gobgp/v3/api"
+	"github.com/osrg/gobgp/v3/pkg/server"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/oc"
 )
 
 func TestValidatePrefixSetMode(t *testing.T) {
@@ -244,3 +252,63 @@ func TestPopulateAttrs(t *testing.T) {
 	}
 	r.completeAllocation()
 }
+
+func TestClearNeighbor(t *testing.T) {
+	ctx := context.Background()
+	g := NewGoBGP("local", "", 0)
+	g.task.bgpServer = server.NewBgpServer()
+	go g.task.bgpServer.Serve()
+	defer g.task.bgpServer.Stop()
+
+	if err := g.ClearNeighbor(ctx, "", netip.MustParseAddr("192.0.2.1"), ClearHard); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("ClearNeighbor() before BGP is started got error %v, want FailedPrecondition", err)
+	}
+
+	if err := g.task.bgpServer.StartBgp(ctx, &api.StartBgpRequest{Global: &api.Global{Asn: 1, RouterId: "192.0.2.0", ListenPort: -1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.task.bgpServer.AddPeer(ctx, &api.AddPeerRequest{Peer: &api.Peer{Conf: &api.PeerConf{NeighborAddress: "192.0.2.1", PeerAsn: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	g.task.bgpStarted = true
+
+	tests := []struct {
+		desc     string
+		niName   string
+		addr     string
+		mode     ClearMode
+		wantCode codes.Code
+	}{{
+		desc: "hard",
+		addr: "192.0.2.1",
+		mode: ClearHard,
+	}, {
+		desc: "soft",
+		addr: "192.0.2.1",
+		mode: ClearSoft,
+	}, {
+		desc:   "soft in",
+		niName: fakedevice.DefaultNetworkInstance,
+		addr:   "192.0.2.1",
+		mode:   ClearSoftIn,
+	}, {
+		desc:     "unknown neighbor",
+		addr:     "192.0.2.2",
+		mode:     ClearHard,
+		wantCode: codes.NotFound,
+	}, {
+		desc:     "unknown network instance",
+		niName:   "vrf-1",
+		addr:     "192.0.2.1",
+		mode:     ClearHard,
+		wantCode: codes.NotFound,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := g.ClearNeighbor(ctx, tt.niName, netip.MustParseAddr(tt.addr), tt.mode)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("ClearNeighbor() got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+		})
+	}
+}
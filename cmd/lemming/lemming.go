@@ -36,6 +36,8 @@ var (
 	gnmiAddr       = pflag.String("gnmi", ":9339", "gNMI listen address")
 	gribiAddr      = pflag.String("gribi", ":9340", "gRIBI listen address")
 	p4rtAddr       = pflag.String("p4rt_addr", ":9559", "p4rt listen address")
+	sshAddr        = pflag.String("ssh_addr", "", "SSH listen address, the SSH server enforces gNSI credentialz credentials. If unspecified, the SSH server is disabled.")
+	gribiPersist   = pflag.String("gribi_persist_file", "", "File the gRIBI RIB is checkpointed to and restored from on startup. If unspecified, gRIBI entries are not persisted across restarts.")
 	bgpPort        = pflag.Uint("bgp_port", 179, "BGP listening port")
 	target         = pflag.String("target", "fakedut", "name of the fake target")
 	tlsKeyFile     = pflag.String("tls_key_file", "", "Controls whether to enable TLS for gNXI services. If unspecified, insecure credentials are used.")
@@ -81,6 +83,8 @@ func main() {
 		lemming.WithFaultAddr(*faultAddr),
 		lemming.WithFaultInjection(*faultEnable),
 		lemming.WithP4RTAddr(*p4rtAddr),
+		lemming.WithSSHAddr(*sshAddr),
+		lemming.WithGRIBIPersistence(*gribiPersist),
 		lemming.WithDataplaneOpts(dplaneopts.WithSkipIPValidation()),
 	)
 	if err != nil {
@@ -36,6 +36,8 @@ type Options struct {
 	HardwareProfile *HardwareProfile
 	// SkipIPValidation skips droping packets with invalid src or dst IPs.
 	SkipIPValidation bool
+	// SysribAddr is the address of the sysrib gRPC server the routing protocols install their routes in.
+	SysribAddr string
 }
 
 // Option exposes additional configuration for the dataplane.
@@ -98,6 +100,15 @@ func WithSkipIPValidation() Option {
 	}
 }
 
+// WithSysribAddr sets the address of the sysrib gRPC server.
+// Default: unix:/tmp/sysrib.api
+func WithSysribAddr(addr string) Option {
+	return func(o *Options) error {
+		o.SysribAddr = addr
+		return nil
+	}
+}
+
 // ResolveOpts creates an option struct from the opts.
 func ResolveOpts(opts ...Option) *Options {
 	resolved := &Options{
@@ -106,6 +117,7 @@ func ResolveOpts(opts ...Option) *Options {
 		HostifNetDevType: fwdpb.PortType_PORT_TYPE_TAP,
 		PortType:         fwdpb.PortType_PORT_TYPE_KERNEL,
 		HardwareProfile:  &HardwareProfile{},
+		SysribAddr:       "unix:/tmp/sysrib.api",
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,639 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+)
+
+// aclEntryKey identifies an entry of an ACL set applied to an interface.
+type aclEntryKey struct {
+	intf    string // The id of the interface in /acl/interfaces.
+	ingress bool
+	setName string
+	setType oc.E_Acl_ACL_TYPE
+	seq     uint32
+	// implicitDeny is set for the entry that drops the packets that match no entry of the sets
+	// applied to the interface in the direction, the set name, type and sequence id are unset.
+	implicitDeny bool
+}
+
+// aclEntry is an ACL entry programmed in the dataplane.
+type aclEntry struct {
+	// req is the request that created the entry, without the counter action.
+	req        *saipb.CreateAclEntryRequest
+	oid        uint64
+	counterOID uint64
+}
+
+// aclState is the state of the ACL handler.
+type aclState struct {
+	// trapID is the user defined trap of the packets rejected by an ACL.
+	trapID       uint64
+	ingressTable uint64
+	egressTable  uint64
+	config       *oc.Acl
+	entries      map[aclEntryKey]*aclEntry
+}
+
+// StartACL starts the ACL handler, which programs the ingress and egress ACL sets of the interfaces
+// in the ACL tables of the dataplane and publishes the matched packets and octets of their entries.
+// Rejected packets are punted with the trap ID. Packets that match no entry of the sets applied to
+// an interface are dropped.
+func (rec *Reconciler) StartACL(ctx context.Context, client *ygnmi.Client, trapID uint64) error {
+	log.Info("starting acl handler")
+	st := &aclState{
+		trapID:  trapID,
+		entries: map[aclEntryKey]*aclEntry{},
+	}
+	var err error
+	if st.ingressTable, err = rec.createACLTable(ctx, saipb.AclStage_ACL_STAGE_INGRESS); err != nil {
+		return fmt.Errorf("failed to create ingress acl table: %v", err)
+	}
+	if st.egressTable, err = rec.createACLTable(ctx, saipb.AclStage_ACL_STAGE_EGRESS); err != nil {
+		return fmt.Errorf("failed to create egress acl table: %v", err)
+	}
+	rec.aclMu.Lock()
+	rec.acl = st
+	rec.aclMu.Unlock()
+
+	ctx, cancelFn := context.WithCancel(ctx)
+	rec.closers = append(rec.closers, cancelFn)
+
+	w := ygnmi.Watch(ctx, client, ocpath.Root().Acl().Config(), func(v *ygnmi.Value[*oc.Acl]) error {
+		cfg, _ := v.Val()
+		rec.aclMu.Lock()
+		defer rec.aclMu.Unlock()
+		rec.acl.config = cfg
+		rec.reconcileACL(ctx, client)
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := w.Await(); err != nil {
+			log.Warningf("acl watcher has stopped: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			rec.aclMu.Lock()
+			// Retry the entries of the interfaces that did not exist yet.
+			rec.reconcileACL(ctx, client)
+			sb := rec.aclCounterBatch(ctx)
+			rec.aclMu.Unlock()
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("acl handler: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// createACLTable creates an ACL table in a table group of the stage and binds the group to the switch.
+func (rec *Reconciler) createACLTable(ctx context.Context, stage saipb.AclStage) (uint64, error) {
+	group, err := rec.aclClient.CreateAclTableGroup(ctx, &saipb.CreateAclTableGroupRequest{
+		Switch:   rec.switchID,
+		AclStage: stage.Enum(),
+		Type:     saipb.AclTableGroupType_ACL_TABLE_GROUP_TYPE_PARALLEL.Enum(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	table, err := rec.aclClient.CreateAclTable(ctx, &saipb.CreateAclTableRequest{
+		Switch:   rec.switchID,
+		AclStage: stage.Enum(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	_, err = rec.aclClient.CreateAclTableGroupMember(ctx, &saipb.CreateAclTableGroupMemberRequest{
+		Switch:          rec.switchID,
+		AclTableGroupId: proto.Uint64(group.GetOid()),
+		AclTableId:      proto.Uint64(table.GetOid()),
+		Priority:        proto.Uint32(1),
+	})
+	if err != nil {
+		return 0, err
+	}
+	req := &saipb.SetSwitchAttributeRequest{Oid: rec.switchID}
+	if stage == saipb.AclStage_ACL_STAGE_EGRESS {
+		req.EgressAcl = proto.Uint64(group.GetOid())
+	} else {
+		req.IngressAcl = proto.Uint64(group.GetOid())
+	}
+	if _, err := rec.switchClient.SetSwitchAttribute(ctx, req); err != nil {
+		return 0, err
+	}
+	return table.GetOid(), nil
+}
+
+// reconcileACL programs the entries of the ACL sets applied to the interfaces in the config,
+// replacing the entries that changed and removing the ones no longer applied.
+// The caller must hold aclMu.
+func (rec *Reconciler) reconcileACL(ctx context.Context, client *ygnmi.Client) {
+	want := rec.wantACLEntries()
+	for k, e := range rec.acl.entries {
+		if req, ok := want[k]; ok && proto.Equal(req, e.req) {
+			continue
+		}
+		rec.removeACLEntry(ctx, client, k, e)
+	}
+	for k, req := range want {
+		if _, ok := rec.acl.entries[k]; ok {
+			continue
+		}
+		e, err := rec.createACLEntry(ctx, req)
+		if err != nil {
+			log.Warningf("failed to program entry %d of acl set %q on interface %q: %v", k.seq, k.setName, k.intf, err)
+			continue
+		}
+		rec.acl.entries[k] = e
+	}
+}
+
+// aclSetKey identifies an ACL set applied to an interface.
+type aclSetKey struct {
+	name string
+	typ  oc.E_Acl_ACL_TYPE
+}
+
+// wantACLEntries returns the requests of the entries of the ACL sets applied to the interfaces,
+// followed by a single implicit deny for each interface and direction,
+// skipping the interfaces that don't exist in the dataplane and the entries that can't be programmed.
+//
+// All the sets applied to an interface in a direction share the ACL table of the stage, so each one
+// gets its own band of priorities: the sets are ordered by type and name, and the entries of a set by
+// sequence id. The first matching entry of the sets applies.
+func (rec *Reconciler) wantACLEntries() map[aclEntryKey]*saipb.CreateAclEntryRequest {
+	want := map[aclEntryKey]*saipb.CreateAclEntryRequest{}
+	cfg := rec.acl.config
+	if cfg == nil {
+		return want
+	}
+	add := func(intf *oc.Acl_Interface, portID uint64, ingress bool, sets []aclSetKey) {
+		// bind sets the table and the port of the request.
+		bind := func(req *saipb.CreateAclEntryRequest) {
+			req.TableId = proto.Uint64(rec.acl.egressTable)
+			port := &saipb.AclFieldData{Data: &saipb.AclFieldData_DataOid{DataOid: portID}}
+			if ingress {
+				req.TableId = proto.Uint64(rec.acl.ingressTable)
+				req.FieldInPort = port
+			} else {
+				req.FieldOutPort = port
+			}
+		}
+		slices.SortFunc(sets, func(a, b aclSetKey) int {
+			if c := cmp.Compare(a.typ, b.typ); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.name, b.name)
+		})
+		// The priority 0 is reserved for the implicit deny.
+		prio := uint32(math.MaxUint32)
+		var types []oc.E_Acl_ACL_TYPE
+		for _, k := range sets {
+			set := cfg.GetAclSet(k.name, k.typ)
+			if set == nil {
+				continue
+			}
+			types = append(types, k.typ)
+			for _, seq := range slices.Sorted(maps.Keys(set.AclEntry)) {
+				if prio == 0 {
+					log.Warningf("skipping entry %d of acl set %q: no priority left on interface %q", seq, k.name, intf.GetId())
+					continue
+				}
+				req, err := rec.aclEntryRequest(set.AclEntry[seq], k.typ, prio)
+				if err != nil {
+					log.Warningf("skipping entry %d of acl set %q: %v", seq, k.name, err)
+					continue
+				}
+				prio--
+				bind(req)
+				want[aclEntryKey{intf: intf.GetId(), ingress: ingress, setName: k.name, setType: k.typ, seq: seq}] = req
+			}
+		}
+		if len(types) == 0 {
+			return
+		}
+		req, err := rec.aclDenyRequest(types)
+		if err != nil {
+			log.Warningf("skipping implicit deny of interface %q: %v", intf.GetId(), err)
+			return
+		}
+		bind(req)
+		want[aclEntryKey{intf: intf.GetId(), ingress: ingress, implicitDeny: true}] = req
+	}
+	rec.stateMu.RLock()
+	defer rec.stateMu.RUnlock()
+	for id, intf := range cfg.Interface {
+		ref := ocInterface{name: id}
+		if r := intf.GetInterfaceRef(); r.GetInterface() != "" {
+			ref = ocInterface{name: r.GetInterface(), subintf: r.GetSubinterface()}
+		}
+		data, ok := rec.ocInterfaceData[ref]
+		if !ok {
+			continue
+		}
+		var ingress, egress []aclSetKey
+		for k := range intf.IngressAclSet {
+			ingress = append(ingress, aclSetKey{name: k.SetName, typ: k.Type})
+		}
+		for k := range intf.EgressAclSet {
+			egress = append(egress, aclSetKey{name: k.SetName, typ: k.Type})
+		}
+		add(intf, data.portID, true, ingress)
+		add(intf, data.portID, false, egress)
+	}
+	return want
+}
+
+// createACLEntry creates the counter and the ACL entry of the request.
+func (rec *Reconciler) createACLEntry(ctx context.Context, req *saipb.CreateAclEntryRequest) (*aclEntry, error) {
+	counter, err := rec.aclClient.CreateAclCounter(ctx, &saipb.CreateAclCounterRequest{
+		Switch:            rec.switchID,
+		TableId:           proto.Uint64(req.GetTableId()),
+		EnablePacketCount: proto.Bool(true),
+		EnableByteCount:   proto.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	withCounter := proto.Clone(req).(*saipb.CreateAclEntryRequest)
+	withCounter.ActionCounter = &saipb.AclActionData{
+		Enable:    true,
+		Parameter: &saipb.AclActionData_Oid{Oid: counter.GetOid()},
+	}
+	resp, err := rec.aclClient.CreateAclEntry(ctx, withCounter)
+	if err != nil {
+		if _, err := rec.aclClient.RemoveAclCounter(ctx, &saipb.RemoveAclCounterRequest{Oid: counter.GetOid()}); err != nil {
+			log.Warningf("failed to remove acl counter %d: %v", counter.GetOid(), err)
+		}
+		return nil, err
+	}
+	return &aclEntry{req: req, oid: resp.GetOid(), counterOID: counter.GetOid()}, nil
+}
+
+// removeACLEntry removes the ACL entry, its counter and its state.
+func (rec *Reconciler) removeACLEntry(ctx context.Context, client *ygnmi.Client, k aclEntryKey, e *aclEntry) {
+	delete(rec.acl.entries, k)
+	if _, err := rec.aclClient.RemoveAclEntry(ctx, &saipb.RemoveAclEntryRequest{Oid: e.oid}); err != nil {
+		log.Warningf("failed to remove entry %d of acl set %q on interface %q: %v", k.seq, k.setName, k.intf, err)
+	}
+	if _, err := rec.aclClient.RemoveAclCounter(ctx, &saipb.RemoveAclCounterRequest{Oid: e.counterOID}); err != nil {
+		log.Warningf("failed to remove acl counter %d: %v", e.counterOID, err)
+	}
+	if k.implicitDeny {
+		return
+	}
+	var err error
+	if k.ingress {
+		_, err = gnmiclient.Delete(ctx, client, ocpath.Root().Acl().Interface(k.intf).IngressAclSet(k.setName, k.setType).AclEntry(k.seq).State())
+	} else {
+		_, err = gnmiclient.Delete(ctx, client, ocpath.Root().Acl().Interface(k.intf).EgressAclSet(k.setName, k.setType).AclEntry(k.seq).State())
+	}
+	if err != nil {
+		log.Warningf("failed to delete state of entry %d of acl set %q on interface %q: %v", k.seq, k.setName, k.intf, err)
+	}
+}
+
+// aclCounterBatch returns a batch that updates the matched packets and octets of the entries.
+// The caller must hold aclMu.
+func (rec *Reconciler) aclCounterBatch(ctx context.Context) *ygnmi.SetBatch {
+	sb := &ygnmi.SetBatch{}
+	for k, e := range rec.acl.entries {
+		// The implicit deny is not an entry of the set, so it has no state.
+		if k.implicitDeny {
+			continue
+		}
+		resp, err := rec.aclClient.GetAclCounterAttribute(ctx, &saipb.GetAclCounterAttributeRequest{
+			Oid:      e.counterOID,
+			AttrType: []saipb.AclCounterAttr{saipb.AclCounterAttr_ACL_COUNTER_ATTR_PACKETS, saipb.AclCounterAttr_ACL_COUNTER_ATTR_BYTES},
+		})
+		if err != nil {
+			log.Errorf("acl handler: could not retrieve counter of entry %d of acl set %q: %v", k.seq, k.setName, err)
+			continue
+		}
+		intf := ocpath.Root().Acl().Interface(k.intf)
+		gnmiclient.BatchUpdate(sb, intf.Id().State(), k.intf)
+		if k.ingress {
+			set := intf.IngressAclSet(k.setName, k.setType)
+			gnmiclient.BatchUpdate(sb, set.SetName().State(), k.setName)
+			gnmiclient.BatchUpdate(sb, set.Type().State(), k.setType)
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).SequenceId().State(), k.seq)
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).MatchedPackets().State(), resp.GetAttr().GetPackets())
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).MatchedOctets().State(), resp.GetAttr().GetBytes())
+		} else {
+			set := intf.EgressAclSet(k.setName, k.setType)
+			gnmiclient.BatchUpdate(sb, set.SetName().State(), k.setName)
+			gnmiclient.BatchUpdate(sb, set.Type().State(), k.setType)
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).SequenceId().State(), k.seq)
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).MatchedPackets().State(), resp.GetAttr().GetPackets())
+			gnmiclient.BatchUpdate(sb, set.AclEntry(k.seq).MatchedOctets().State(), resp.GetAttr().GetBytes())
+		}
+	}
+	return sb
+}
+
+// aclEntryRequest returns the request for the match fields and the action of the entry with the priority,
+// the caller sets the table and the port.
+func (rec *Reconciler) aclEntryRequest(entry *oc.Acl_AclSet_AclEntry, setType oc.E_Acl_ACL_TYPE, prio uint32) (*saipb.CreateAclEntryRequest, error) {
+	req := &saipb.CreateAclEntryRequest{
+		Switch:   rec.switchID,
+		Priority: proto.Uint32(prio),
+	}
+	switch setType {
+	case oc.Acl_ACL_TYPE_ACL_IPV4:
+		req.FieldAclIpType = &saipb.AclFieldData{Data: &saipb.AclFieldData_DataIpType{DataIpType: saipb.AclIpType_ACL_IP_TYPE_IPV4ANY}}
+	case oc.Acl_ACL_TYPE_ACL_IPV6:
+		req.FieldAclIpType = &saipb.AclFieldData{Data: &saipb.AclFieldData_DataIpType{DataIpType: saipb.AclIpType_ACL_IP_TYPE_IPV6ANY}}
+	case oc.Acl_ACL_TYPE_ACL_L2, oc.Acl_ACL_TYPE_ACL_MIXED:
+	default:
+		return nil, fmt.Errorf("unsupported acl type %v", setType)
+	}
+	if ip := entry.GetIpv4(); ip != nil {
+		if err := setIPFields(req, ip.SourceAddress, ip.DestinationAddress, ip.Dscp, ip.HopLimit, ip.Protocol, false); err != nil {
+			return nil, err
+		}
+	}
+	if ip := entry.GetIpv6(); ip != nil {
+		if err := setIPFields(req, ip.SourceAddress, ip.DestinationAddress, ip.Dscp, ip.HopLimit, ip.Protocol, true); err != nil {
+			return nil, err
+		}
+	}
+	if l2 := entry.GetL2(); l2 != nil {
+		if err := setL2Fields(req, l2); err != nil {
+			return nil, err
+		}
+	}
+	if t := entry.GetTransport(); t != nil {
+		var err error
+		if req.FieldL4SrcPort, err = aclPort(t.SourcePort); err != nil {
+			return nil, fmt.Errorf("source port: %v", err)
+		}
+		if req.FieldL4DstPort, err = aclPort(t.DestinationPort); err != nil {
+			return nil, fmt.Errorf("destination port: %v", err)
+		}
+	}
+
+	action := saipb.PacketAction_PACKET_ACTION_FORWARD
+	switch a := entry.GetActions().GetForwardingAction(); a {
+	case oc.Acl_FORWARDING_ACTION_ACCEPT:
+	case oc.Acl_FORWARDING_ACTION_DROP:
+		action = saipb.PacketAction_PACKET_ACTION_DROP
+	case oc.Acl_FORWARDING_ACTION_REJECT:
+		// Rejected packets are punted to the CPU, which replies with ICMP unreachable messages.
+		action = saipb.PacketAction_PACKET_ACTION_TRAP
+		req.ActionSetUserTrapId = &saipb.AclActionData{
+			Enable:    true,
+			Parameter: &saipb.AclActionData_Oid{Oid: rec.acl.trapID},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported forwarding action %v", a)
+	}
+	req.ActionPacketAction = &saipb.AclActionData{
+		Enable:    true,
+		Parameter: &saipb.AclActionData_PacketAction{PacketAction: action},
+	}
+	return req, nil
+}
+
+// aclDenyRequest returns the request for the lowest priority entry that drops the packets
+// of the types of the sets, the caller sets the table and the port.
+func (rec *Reconciler) aclDenyRequest(setTypes []oc.E_Acl_ACL_TYPE) (*saipb.CreateAclEntryRequest, error) {
+	req := &saipb.CreateAclEntryRequest{
+		Switch:   rec.switchID,
+		Priority: proto.Uint32(0),
+		ActionPacketAction: &saipb.AclActionData{
+			Enable:    true,
+			Parameter: &saipb.AclActionData_PacketAction{PacketAction: saipb.PacketAction_PACKET_ACTION_DROP},
+		},
+	}
+	var v4, v6, all bool
+	for _, t := range setTypes {
+		switch t {
+		case oc.Acl_ACL_TYPE_ACL_IPV4:
+			v4 = true
+		case oc.Acl_ACL_TYPE_ACL_IPV6:
+			v6 = true
+		case oc.Acl_ACL_TYPE_ACL_L2, oc.Acl_ACL_TYPE_ACL_MIXED:
+			all = true
+		default:
+			return nil, fmt.Errorf("unsupported acl type %v", t)
+		}
+	}
+	// L2 and mixed sets apply to all the packets, IP sets only to the packets of their version.
+	ipType := saipb.AclIpType_ACL_IP_TYPE_UNSPECIFIED
+	switch {
+	case all:
+	case v4 && v6:
+		ipType = saipb.AclIpType_ACL_IP_TYPE_IP
+	case v4:
+		ipType = saipb.AclIpType_ACL_IP_TYPE_IPV4ANY
+	case v6:
+		ipType = saipb.AclIpType_ACL_IP_TYPE_IPV6ANY
+	}
+	if ipType != saipb.AclIpType_ACL_IP_TYPE_UNSPECIFIED {
+		req.FieldAclIpType = &saipb.AclFieldData{Data: &saipb.AclFieldData_DataIpType{DataIpType: ipType}}
+	}
+	return req, nil
+}
+
+// setIPFields sets the IPv4 or IPv6 match fields of the request.
+func setIPFields(req *saipb.CreateAclEntryRequest, src, dst *string, dscp, hopLimit *uint8, protocol any, v6 bool) error {
+	if src != nil {
+		f, err := aclPrefix(*src, v6)
+		if err != nil {
+			return fmt.Errorf("source address: %v", err)
+		}
+		if v6 {
+			req.FieldSrcIpv6 = f
+		} else {
+			req.FieldSrcIp = f
+		}
+	}
+	if dst != nil {
+		f, err := aclPrefix(*dst, v6)
+		if err != nil {
+			return fmt.Errorf("destination address: %v", err)
+		}
+		if v6 {
+			req.FieldDstIpv6 = f
+		} else {
+			req.FieldDstIp = f
+		}
+	}
+	if dscp != nil {
+		req.FieldDscp = aclUint(uint64(*dscp), 0x3f)
+	}
+	if hopLimit != nil {
+		req.FieldTtl = aclUint(uint64(*hopLimit), 0xff)
+	}
+	var num uint64
+	switch p := protocol.(type) {
+	case nil:
+		return nil
+	case oc.UnionUint8:
+		num = uint64(p)
+	case oc.E_PacketMatchTypes_IP_PROTOCOL:
+		var ok bool
+		if num, ok = ipProtocols[p]; !ok {
+			return fmt.Errorf("unsupported protocol %v", p)
+		}
+		if p == oc.PacketMatchTypes_IP_PROTOCOL_IP_ICMP && v6 {
+			num = 58 // ICMPv6
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %v", p)
+	}
+	req.FieldIpProtocol = aclUint(num, 0xff)
+	return nil
+}
+
+// ipProtocols are the IP protocol numbers of the protocol identities.
+var ipProtocols = map[oc.E_PacketMatchTypes_IP_PROTOCOL]uint64{
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_AUTH:  51,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_GRE:   47,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_ICMP:  1,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_IGMP:  2,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_IN_IP: 4,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_L2TP:  115,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_PIM:   103,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_RSVP:  46,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_TCP:   6,
+	oc.PacketMatchTypes_IP_PROTOCOL_IP_UDP:   17,
+}
+
+// etherTypes are the EtherTypes of the EtherType identities.
+var etherTypes = map[oc.E_PacketMatchTypes_ETHERTYPE]uint64{
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_ARP:  0x0806,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_IPV4: 0x0800,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_IPV6: 0x86DD,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_LLDP: 0x88CC,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_MPLS: 0x8847,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_ROCE: 0x8915,
+	oc.PacketMatchTypes_ETHERTYPE_ETHERTYPE_VLAN: 0x8100,
+}
+
+// setL2Fields sets the Ethernet match fields of the request.
+func setL2Fields(req *saipb.CreateAclEntryRequest, l2 *oc.Acl_AclSet_AclEntry_L2) error {
+	var err error
+	if l2.SourceMac != nil {
+		if req.FieldSrcMac, err = aclMAC(l2.GetSourceMac(), l2.SourceMacMask); err != nil {
+			return fmt.Errorf("source mac: %v", err)
+		}
+	}
+	if l2.DestinationMac != nil {
+		if req.FieldDstMac, err = aclMAC(l2.GetDestinationMac(), l2.DestinationMacMask); err != nil {
+			return fmt.Errorf("destination mac: %v", err)
+		}
+	}
+	switch t := l2.Ethertype.(type) {
+	case nil:
+	case oc.UnionUint16:
+		req.FieldEtherType = aclUint(uint64(t), 0xffff)
+	case oc.E_PacketMatchTypes_ETHERTYPE:
+		v, ok := etherTypes[t]
+		if !ok {
+			return fmt.Errorf("unsupported ethertype %v", t)
+		}
+		req.FieldEtherType = aclUint(v, 0xffff)
+	default:
+		return fmt.Errorf("unsupported ethertype %v", t)
+	}
+	return nil
+}
+
+// aclPrefix returns the field data matching the IP prefix.
+func aclPrefix(s string, v6 bool) (*saipb.AclFieldData, error) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return nil, err
+	}
+	if p.Addr().Is6() != v6 {
+		return nil, fmt.Errorf("wrong address family for %v", p)
+	}
+	p = p.Masked()
+	return &saipb.AclFieldData{
+		Data: &saipb.AclFieldData_DataIp{DataIp: p.Addr().AsSlice()},
+		Mask: &saipb.AclFieldData_MaskIp{MaskIp: net.CIDRMask(p.Bits(), p.Addr().BitLen())},
+	}, nil
+}
+
+// aclMAC returns the field data matching the MAC address, with the optional mask.
+func aclMAC(addr string, mask *string) (*saipb.AclFieldData, error) {
+	mac, err := net.ParseMAC(addr)
+	if err != nil {
+		return nil, err
+	}
+	m := net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	if mask != nil {
+		if m, err = net.ParseMAC(*mask); err != nil {
+			return nil, err
+		}
+	}
+	return &saipb.AclFieldData{
+		Data: &saipb.AclFieldData_DataMac{DataMac: mac},
+		Mask: &saipb.AclFieldData_MaskMac{MaskMac: m},
+	}, nil
+}
+
+// aclPort returns the field data matching the transport port, or nil if it matches any port.
+// Only single ports are supported, not ranges.
+func aclPort(port any) (*saipb.AclFieldData, error) {
+	switch p := port.(type) {
+	case nil:
+		return nil, nil
+	case oc.UnionUint16:
+		return aclUint(uint64(p), 0xffff), nil
+	case oc.E_PacketMatchTypes_PortNumRange_Enum:
+		if p == oc.PacketMatchTypes_PortNumRange_Enum_ANY {
+			return nil, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported port %v", port)
+}
+
+func aclUint(data, mask uint64) *saipb.AclFieldData {
+	return &saipb.AclFieldData{
+		Data: &saipb.AclFieldData_DataUint{DataUint: data},
+		Mask: &saipb.AclFieldData_MaskUint{MaskUint: mask},
+	}
+}
@@ -19,8 +19,11 @@ package dplanerc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -58,6 +61,7 @@ type interfaceData struct {
 	hostifDevName   string
 	rifID           uint64
 	lagMembershipID uint64
+	aggregateID     string // the aggregate of a member, empty if the interface is not a member
 	isAggregate     bool
 	networkInstance string
 }
@@ -104,6 +108,11 @@ type protocolHanlder interface {
 	Reconcile(context.Context, *oc.Root, *ygnmi.Client) error
 }
 
+type lldpHandler interface {
+	protocolHanlder
+	ClearInterface(ctx context.Context, name string, c *ygnmi.Client) error
+}
+
 // Reconciler handles config updates to the paths.
 type Reconciler struct {
 	c *ygnmi.Client
@@ -120,14 +129,35 @@ type Reconciler struct {
 	nextHopGroupClient saipb.NextHopGroupClient
 	lagClient          saipb.LagClient
 	vrClient           saipb.VirtualRouterClient
+	mplsClient         saipb.MplsClient
+	counterClient      saipb.CounterClient
+	aclClient          saipb.AclClient
+	queueClient        saipb.QueueClient
+	schedulerClient    saipb.SchedulerClient
+	wredClient         saipb.WredClient
+	qosMapClient       saipb.QosMapClient
+	samplepacketClient saipb.SamplepacketClient
 	stateMu            sync.RWMutex
-	lldp               protocolHanlder
+	lldp               lldpHandler
 	// state keeps track of the applied state of the device's interfaces so that we do not issue duplicate configuration commands to the device's interfaces.
 	state           map[string]*oc.Interface
 	switchID        uint64
 	ifaceMgr        interfaceManager
 	ocInterfaceData interfaceMap
 	ocRouteData     routeMap
+	labelRouteData  map[uint32]*routeData // Keyed by incoming label, nil for drop routes.
+	labelMu         sync.Mutex
+	labelCounters   map[uint32]*labelCounter // Keyed by incoming label.
+	aclMu           sync.Mutex
+	acl             *aclState
+	qosMu           sync.Mutex
+	qos             *qosState
+	samplingMu      sync.Mutex
+	sampling        *samplingState
+	lacpMu          sync.Mutex
+	lacp            *lacpState
+	isisMu          sync.Mutex
+	isis            *isisState
 	cpuPortID       uint64
 	contextID       string
 	niDetail        map[string]*netInst
@@ -145,6 +175,8 @@ type interfaceManager interface {
 	LinkSubscribe(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error
 	AddrSubscribe(ch chan<- netlink.AddrUpdate, done <-chan struct{}) error
 	NeighSubscribe(ch chan<- netlink.NeighUpdate, done <-chan struct{}) error
+	NeighList(linkIndex, family int) ([]netlink.Neigh, error)
+	NeighDel(neigh *netlink.Neigh) error
 	LinkList() ([]netlink.Link, error)
 	LinkAdd(link netlink.Link) error
 	LinkByName(name string) (netlink.Link, error)
@@ -166,6 +198,8 @@ func New(conn grpc.ClientConnInterface, switchID, cpuPortID uint64, contextID st
 		contextID:          contextID,
 		ocInterfaceData:    interfaceMap{},
 		ocRouteData:        routeMap{},
+		labelRouteData:     map[uint32]*routeData{},
+		labelCounters:      map[uint32]*labelCounter{},
 		hostifClient:       saipb.NewHostifClient(conn),
 		portClient:         saipb.NewPortClient(conn),
 		switchClient:       saipb.NewSwitchClient(conn),
@@ -177,6 +211,14 @@ func New(conn grpc.ClientConnInterface, switchID, cpuPortID uint64, contextID st
 		fwdClient:          fwdpb.NewForwardingClient(conn),
 		lagClient:          saipb.NewLagClient(conn),
 		vrClient:           saipb.NewVirtualRouterClient(conn),
+		mplsClient:         saipb.NewMplsClient(conn),
+		counterClient:      saipb.NewCounterClient(conn),
+		aclClient:          saipb.NewAclClient(conn),
+		queueClient:        saipb.NewQueueClient(conn),
+		schedulerClient:    saipb.NewSchedulerClient(conn),
+		wredClient:         saipb.NewWredClient(conn),
+		qosMapClient:       saipb.NewQosMapClient(conn),
+		samplepacketClient: saipb.NewSamplepacketClient(conn),
 		lldp:               lldp.New(),
 		niDetail:           map[string]*netInst{},
 	}
@@ -481,13 +523,17 @@ func (ni *Reconciler) addLAGMember(ctx context.Context, intf ocInterface, member
 	if err := ni.ifaceMgr.LinkSetMaster(memberLink, bondLink); err != nil {
 		return fmt.Errorf("failed to add bond member: %v", err)
 	}
-	resp, err := ni.lagClient.CreateLagMember(ctx, &saipb.CreateLagMemberRequest{
-		Switch: ni.switchID,
-		LagId:  proto.Uint64(agg.portID),
-		PortId: proto.Uint64(memberData.portID),
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create lag member: %v", err)
+	// The members of LACP aggregates are added to the LAG when they are distributing.
+	if ni.getOrCreateInterface(aggID).GetAggregation().GetLagType() != oc.IfAggregate_AggregationType_LACP {
+		resp, err := ni.lagClient.CreateLagMember(ctx, &saipb.CreateLagMemberRequest{
+			Switch: ni.switchID,
+			LagId:  proto.Uint64(agg.portID),
+			PortId: proto.Uint64(memberData.portID),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create lag member: %v", err)
+		}
+		memberData.lagMembershipID = resp.Oid
 	}
 	ni.getOrCreateInterface(intf.name).GetOrCreateEthernet().AggregateId = &aggID
 	ni.getOrCreateInterface(aggID).GetAggregation().Member = append(ni.getOrCreateInterface(aggID).GetAggregation().Member, intf.name)
@@ -498,7 +544,7 @@ func (ni *Reconciler) addLAGMember(ctx context.Context, intf ocInterface, member
 		return fmt.Errorf("failed to update agg state: %v", err)
 	}
 
-	memberData.lagMembershipID = resp.Oid
+	memberData.aggregateID = aggID
 	return nil
 }
 
@@ -510,11 +556,14 @@ func (ni *Reconciler) removeLAGMember(ctx context.Context, intf ocInterface, mem
 	if err := ni.ifaceMgr.LinkSetNoMaster(memberLink); err != nil {
 		return fmt.Errorf("failed to remove bond: %v", err)
 	}
-	_, err = ni.lagClient.RemoveLagMember(ctx, &saipb.RemoveLagMemberRequest{
-		Oid: memberData.lagMembershipID,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to remove lag member: %v", err)
+	if memberData.lagMembershipID != 0 {
+		_, err = ni.lagClient.RemoveLagMember(ctx, &saipb.RemoveLagMemberRequest{
+			Oid: memberData.lagMembershipID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove lag member: %v", err)
+		}
+		memberData.lagMembershipID = 0
 	}
 	sb := &ygnmi.SetBatch{}
 	aggID := ni.getOrCreateInterface(intf.name).GetOrCreateEthernet().GetAggregateId()
@@ -535,7 +584,7 @@ func (ni *Reconciler) removeLAGMember(ctx context.Context, intf ocInterface, mem
 	if _, err := sb.Set(ctx, ni.c); err != nil {
 		return fmt.Errorf("failed to update agg state: %v", err)
 	}
-	memberData.lagMembershipID = 0
+	memberData.aggregateID = ""
 	return nil
 }
 
@@ -593,6 +642,16 @@ func (ni *Reconciler) reconcile(ctx context.Context, config *oc.Interface) {
 			log.Warningf("failed to set min links: %v", err)
 		}
 	}
+	// The members of the aggregate are moved in and out of the LAG by the LACP handler.
+	if lagType := config.GetAggregation().GetLagType(); data != nil && data.isAggregate && lagType != oc.IfAggregate_AggregationType_UNSET && lagType != state.GetAggregation().GetLagType() {
+		log.Infof("reconciling lag type of intf %v: %v", intf.name, lagType)
+		state.GetOrCreateAggregation().SetLagType(lagType)
+		sb := &ygnmi.SetBatch{}
+		gnmiclient.BatchUpdate(sb, ocpath.Root().Interface(intf.name).Aggregation().LagType().State(), lagType)
+		if _, err := sb.Set(ctx, ni.c); err != nil {
+			log.Warningf("failed to set lag type: %v", err)
+		}
+	}
 	ni.reconcileSubIntf(ctx, config, state)
 	ni.reconcileIPs(config, state)
 }
@@ -603,8 +662,8 @@ func (ni *Reconciler) reconcileEthernet(ctx context.Context, config, state *oc.I
 	}
 	if config.GetOrCreateEthernet().GetAggregateId() != state.GetOrCreateEthernet().GetAggregateId() {
 		log.Infof("reconciling lag member intf %v: config agg id %v, state agg id %v", intf.name, config.GetEthernet().GetAggregateId(), state.GetEthernet().GetAggregateId())
-		if data.lagMembershipID != 0 {
-			log.Infof("intf %v has existing lab membership lag membership id %d", intf.name, data.lagMembershipID)
+		if data.aggregateID != "" {
+			log.Infof("intf %v has existing lag membership of %v, lag membership id %d", intf.name, data.aggregateID, data.lagMembershipID)
 			if err := ni.removeLAGMember(ctx, intf, data); err != nil {
 				log.Warningf("intf %v failed to remove lag member: %v", intf.name, err)
 			}
@@ -919,6 +978,50 @@ func (ni *Reconciler) getOrCreateInterface(iface string) *oc.Interface {
 	return ni.state[iface]
 }
 
+// VRF returns the OID of the virtual router of the network instance.
+func (ni *Reconciler) VRF(niName string) (uint64, bool) {
+	ni.stateMu.RLock()
+	defer ni.stateMu.RUnlock()
+	detail, ok := ni.niDetail[niName]
+	if !ok {
+		return 0, false
+	}
+	return detail.vrOID, true
+}
+
+// PortAddr returns the lowest global unicast address of the address family on the interface of the port,
+// and the OID of the virtual router of the interface.
+func (ni *Reconciler) PortAddr(portID uint64, v6 bool) (netip.Addr, uint64, bool) {
+	ni.stateMu.RLock()
+	defer ni.stateMu.RUnlock()
+	intf, data := ni.ocInterfaceData.findByPortID(portID)
+	if data == nil || ni.niDetail[data.networkInstance] == nil {
+		return netip.Addr{}, 0, false
+	}
+	sub := ni.state[intf.name].GetSubinterface(intf.subintf)
+	var ips []string
+	if v6 {
+		for ip := range sub.GetIpv6().Address {
+			ips = append(ips, ip)
+		}
+	} else {
+		for ip := range sub.GetIpv4().Address {
+			ips = append(ips, ip)
+		}
+	}
+	var addrs []netip.Addr
+	for _, ip := range ips {
+		if addr, err := netip.ParseAddr(ip); err == nil && addr.IsGlobalUnicast() {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		return netip.Addr{}, 0, false
+	}
+	slices.SortFunc(addrs, netip.Addr.Compare)
+	return addrs[0], ni.niDetail[data.networkInstance].vrOID, true
+}
+
 func (ni *Reconciler) handleDataplaneEvent(ctx context.Context, resp *saipb.PortStateChangeNotificationResponse) {
 	for _, event := range resp.Data {
 		log.V(1).Infof("handling dataplane update on: %q", event.String())
@@ -1074,6 +1177,11 @@ func (ni *Reconciler) handleNeighborUpdate(ctx context.Context, nu *netlink.Neig
 
 	switch nu.Type {
 	case unix.RTM_DELNEIGH:
+		// The neighbor may already be removed by ClearNeighbors.
+		if nu.Family == unix.AF_INET6 && sub.GetIpv6().GetNeighbor(nu.IP.String()) == nil ||
+			nu.Family != unix.AF_INET6 && sub.GetIpv4().GetNeighbor(nu.IP.String()) == nil {
+			return
+		}
 		_, err := ni.neighborClient.RemoveNeighborEntry(ctx, &saipb.RemoveNeighborEntryRequest{
 			Entry: &saipb.NeighborEntry{
 				SwitchId:  ni.switchID,
@@ -1140,6 +1248,43 @@ func (ni *Reconciler) handleNeighborUpdate(ctx context.Context, nu *netlink.Neig
 	}
 }
 
+// ClearNeighbors removes the dynamic neighbors whose address matches from the kernel and the dataplane.
+// If intf is not empty, only the neighbors of the interface are removed.
+func (ni *Reconciler) ClearNeighbors(ctx context.Context, intf string, match func(netip.Addr) bool) error {
+	ni.stateMu.RLock()
+	links := map[int]bool{}
+	for ref, data := range ni.ocInterfaceData {
+		if intf == "" || ref.name == intf {
+			links[data.hostifIfIndex] = true
+		}
+	}
+	ni.stateMu.RUnlock()
+
+	for idx := range links {
+		neighs, err := ni.ifaceMgr.NeighList(idx, unix.AF_UNSPEC)
+		if err != nil {
+			return fmt.Errorf("failed to list neighbors of link %d: %v", idx, err)
+		}
+		for _, neigh := range neighs {
+			addr, ok := netip.AddrFromSlice(neigh.IP)
+			if !ok || neigh.State&unix.NUD_PERMANENT != 0 || !match(addr.Unmap()) {
+				continue
+			}
+			if err := ni.ifaceMgr.NeighDel(&neigh); err != nil && !errors.Is(err, unix.ENOENT) {
+				return fmt.Errorf("failed to delete neighbor %v: %v", neigh.IP, err)
+			}
+			// Remove the neighbor from the dataplane now instead of waiting for the netlink notification.
+			ni.handleNeighborUpdate(ctx, &netlink.NeighUpdate{Type: unix.RTM_DELNEIGH, Neigh: neigh})
+		}
+	}
+	return nil
+}
+
+// ClearLLDPInterface resets the LLDP neighbor and counters of the interface.
+func (ni *Reconciler) ClearLLDPInterface(ctx context.Context, intf string) error {
+	return ni.lldp.ClearInterface(ctx, intf, ni.c)
+}
+
 const (
 	internalSuffix = "-internal"
 )
@@ -0,0 +1,586 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/openconfig/ygnmi/ygnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/openconfig/lemming/dataplane/protocol/isis"
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	sysribpb "github.com/openconfig/lemming/proto/sysrib"
+)
+
+const (
+	// isisAdminDistance is the admin distance of the IS-IS routes in the sysrib.
+	isisAdminDistance = 115
+	isisProtocolName  = "ISIS"
+)
+
+// isisAdjacency is the key of a published IS-IS adjacency.
+type isisAdjacency struct {
+	intf   string
+	level  int
+	system string
+}
+
+// isisLSP is the key of a published LSP.
+type isisLSP struct {
+	level int
+	id    string
+}
+
+// isisState is the state of the IS-IS handler.
+type isisState struct {
+	daemon        *isis.Daemon
+	rib           sysribpb.SysribClient
+	protocols     map[oc.NetworkInstance_Protocol_Key]*oc.NetworkInstance_Protocol
+	interfaces    map[string]*oc.Interface
+	name          string // The name of the configured instance.
+	publishedName string // The name of the instance the state is published for.
+	applied       *isis.Config
+	routes        map[netip.Prefix]*isis.Route // The routes installed in the sysrib.
+	publishedAdjs map[isisAdjacency]bool
+	publishedLSPs map[isisLSP]bool
+}
+
+// StartISIS starts the IS-IS handler, which runs the IS-IS instance configured in the default
+// network instance with the daemon, installs its routes in the sysrib at sysribAddr and publishes
+// its adjacencies and database.
+func (rec *Reconciler) StartISIS(ctx context.Context, client *ygnmi.Client, d *isis.Daemon, sysribAddr string) error {
+	log.Info("starting IS-IS handler")
+	conn, err := grpc.NewClient(sysribAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("failed to dial sysrib: %v", err)
+	}
+	rec.isisMu.Lock()
+	rec.isis = &isisState{
+		daemon:        d,
+		rib:           sysribpb.NewSysribClient(conn),
+		routes:        map[netip.Prefix]*isis.Route{},
+		publishedAdjs: map[isisAdjacency]bool{},
+		publishedLSPs: map[isisLSP]bool{},
+	}
+	rec.isisMu.Unlock()
+
+	d.Start()
+	ctx, cancelFn := context.WithCancel(ctx)
+	// The daemon withdraws its routes when it stops, close the connection after.
+	rec.closers = append(rec.closers, cancelFn, d.Stop, func() { conn.Close() })
+
+	niPath := ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance)
+	pw := ygnmi.Watch(ctx, client, niPath.ProtocolMap().Config(), func(v *ygnmi.Value[map[oc.NetworkInstance_Protocol_Key]*oc.NetworkInstance_Protocol]) error {
+		protocols, _ := v.Val()
+		rec.isisMu.Lock()
+		defer rec.isisMu.Unlock()
+		rec.isis.protocols = protocols
+		rec.reconcileISIS()
+		return ygnmi.Continue
+	})
+	iw := ygnmi.Watch(ctx, client, ocpath.Root().InterfaceMap().Config(), func(v *ygnmi.Value[map[string]*oc.Interface]) error {
+		intfs, _ := v.Val()
+		rec.isisMu.Lock()
+		defer rec.isisMu.Unlock()
+		rec.isis.interfaces = intfs
+		rec.reconcileISIS()
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := pw.Await(); err != nil {
+			log.Warningf("IS-IS protocol watcher has stopped: %v", err)
+		}
+	}()
+	go func() {
+		if _, err := iw.Await(); err != nil {
+			log.Warningf("IS-IS interface watcher has stopped: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			rec.isisMu.Lock()
+			// Pick up the ports and addresses of the interfaces created after the configuration.
+			rec.reconcileISIS()
+			sb := rec.isisStateBatch()
+			rec.isisMu.Unlock()
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("IS-IS handler: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reconcileISIS configures the daemon with the first IS-IS instance of the default network
+// instance. The caller must hold isisMu.
+func (rec *Reconciler) reconcileISIS() {
+	var keys []oc.NetworkInstance_Protocol_Key
+	for key := range rec.isis.protocols {
+		if key.Identifier == oc.PolicyTypes_INSTALL_PROTOCOL_TYPE_ISIS {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	if len(keys) > 1 {
+		log.Warningf("only one IS-IS instance is supported, ignoring all but %q", keys[0].Name)
+	}
+
+	var cfg *isis.Config
+	rec.isis.name = ""
+	if len(keys) > 0 && (rec.isis.protocols[keys[0]].Enabled == nil || rec.isis.protocols[keys[0]].GetEnabled()) {
+		var err error
+		if cfg, err = rec.isisConfig(rec.isis.protocols[keys[0]].GetIsis()); err != nil {
+			log.Warningf("invalid IS-IS instance %q: %v", keys[0].Name, err)
+			cfg = nil
+		}
+		if cfg != nil {
+			rec.isis.name = keys[0].Name
+		}
+	}
+	if reflect.DeepEqual(cfg, rec.isis.applied) {
+		return
+	}
+	rec.isis.daemon.Configure(cfg)
+	rec.isis.applied = cfg
+}
+
+// isisLevels returns the levels of the level type, all the levels if it is unset.
+func isisLevels(t oc.E_IsisTypes_LevelType) isis.Levels {
+	switch t {
+	case oc.IsisTypes_LevelType_LEVEL_1:
+		return isis.Level1
+	case oc.IsisTypes_LevelType_LEVEL_2:
+		return isis.Level2
+	}
+	return isis.Level12
+}
+
+// isisConfig returns the configuration of the daemon for the IS-IS instance.
+func (rec *Reconciler) isisConfig(cfg *oc.NetworkInstance_Protocol_Isis) (*isis.Config, error) {
+	global := cfg.GetGlobal()
+	if len(global.GetNet()) == 0 {
+		return nil, fmt.Errorf("no NET is configured")
+	}
+	c := &isis.Config{
+		Levels:      isisLevels(global.GetLevelCapability()),
+		LSPLifetime: time.Duration(global.GetTimers().GetLspLifetimeInterval()) * time.Second,
+		LSPRefresh:  time.Duration(global.GetTimers().GetLspRefreshInterval()) * time.Second,
+	}
+	for i, n := range global.GetNet() {
+		area, sysID, err := isis.ParseNET(n)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && sysID != c.SystemID {
+			return nil, fmt.Errorf("the system ID of NET %q differs from %v", n, c.SystemID)
+		}
+		c.SystemID = sysID
+		c.Areas = append(c.Areas, area)
+	}
+	for level, lc := range cfg.Level {
+		if lc.Enabled != nil && !lc.GetEnabled() {
+			c.Levels &^= isis.Level(int(level))
+		}
+	}
+
+	rec.stateMu.RLock()
+	defer rec.stateMu.RUnlock()
+	for _, id := range slices.Sorted(maps.Keys(cfg.Interface)) {
+		intf := cfg.Interface[id]
+		if intf.Enabled != nil && !intf.GetEnabled() {
+			continue
+		}
+		if i := rec.isisInterface(intf); i != nil {
+			c.Interfaces = append(c.Interfaces, i)
+		}
+	}
+	return c, nil
+}
+
+// isisInterface returns the configuration of the daemon for the IS-IS interface, or nil if the
+// interface is unknown. The caller must hold stateMu.
+func (rec *Reconciler) isisInterface(cfg *oc.NetworkInstance_Protocol_Isis_Interface) *isis.Interface {
+	ref := ocInterface{name: cfg.GetInterfaceId()}
+	if cfg.GetInterfaceRef().GetInterface() != "" {
+		ref = ocInterface{name: cfg.GetInterfaceRef().GetInterface(), subintf: cfg.GetInterfaceRef().GetSubinterface()}
+	}
+	i := &isis.Interface{
+		Name:         ref.name,
+		PointToPoint: cfg.GetCircuitType() == oc.IsisTypes_CircuitType_POINT_TO_POINT,
+		Passive:      cfg.GetPassive(),
+	}
+	for _, level := range []uint8{1, 2} {
+		lc := cfg.GetLevel(level)
+		if lc == nil {
+			continue
+		}
+		if lc.Enabled != nil && !lc.GetEnabled() {
+			continue
+		}
+		i.Levels |= isis.Level(int(level))
+		// The circuit has one set of parameters, those of the lowest configured level are used.
+		if i.HelloInterval == 0 && lc.GetTimers().HelloInterval != nil {
+			i.HelloInterval = time.Duration(lc.GetTimers().GetHelloInterval()) * time.Second
+		}
+		if i.HelloMultiplier == 0 {
+			i.HelloMultiplier = int(lc.GetTimers().GetHelloMultiplier())
+		}
+		if i.Priority == 0 {
+			i.Priority = lc.GetPriority()
+		}
+		if i.Metric == 0 {
+			i.Metric = lc.GetAf(oc.IsisTypes_AFI_TYPE_IPV4, oc.IsisTypes_SAFI_TYPE_UNICAST).GetMetric()
+		}
+		if i.Metric == 0 {
+			i.Metric = lc.GetAf(oc.IsisTypes_AFI_TYPE_IPV6, oc.IsisTypes_SAFI_TYPE_UNICAST).GetMetric()
+		}
+		if lc.GetPassive() {
+			i.Passive = true
+		}
+	}
+	if len(cfg.Level) > 0 && i.Levels == 0 {
+		// All the configured levels are disabled.
+		return nil
+	}
+
+	// The interfaces without a port, like the loopbacks, only advertise their prefixes.
+	data, ok := rec.ocInterfaceData[ref]
+	if !ok {
+		i.Passive = true
+	} else if !i.Passive {
+		mac, err := net.ParseMAC(rec.state[ref.name].GetEthernet().GetMacAddress())
+		if err != nil {
+			log.Warningf("invalid MAC address of IS-IS interface %v: %v", ref.name, err)
+			return nil
+		}
+		i.PortID = data.portID
+		i.HostPort = data.hostifID
+		i.MAC = mac
+	}
+
+	sub := rec.state[ref.name].GetSubinterface(ref.subintf)
+	if sub == nil {
+		sub = rec.isis.interfaces[ref.name].GetSubinterface(ref.subintf)
+	}
+	for ip, addr := range sub.GetIpv4().Address {
+		if p, err := netip.ParsePrefix(fmt.Sprintf("%s/%d", ip, addr.GetPrefixLength())); err == nil {
+			i.Prefixes = append(i.Prefixes, p)
+		}
+	}
+	for ip, addr := range sub.GetIpv6().Address {
+		p, err := netip.ParsePrefix(fmt.Sprintf("%s/%d", ip, addr.GetPrefixLength()))
+		switch {
+		case err != nil:
+		case p.Addr().IsLinkLocalUnicast():
+			i.LinkLocal = p.Addr()
+		default:
+			i.Prefixes = append(i.Prefixes, p)
+		}
+	}
+	slices.SortFunc(i.Prefixes, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+	if !i.LinkLocal.IsValid() && len(i.MAC) == 6 {
+		i.LinkLocal = linkLocal(i.MAC)
+	}
+	return i
+}
+
+// linkLocal returns the EUI-64 link-local address of the MAC address.
+func linkLocal(mac net.HardwareAddr) netip.Addr {
+	a := [16]byte{0: 0xfe, 1: 0x80}
+	copy(a[8:11], mac[0:3])
+	a[11], a[12] = 0xff, 0xfe
+	copy(a[13:], mac[3:6])
+	a[8] ^= 0x02
+	return netip.AddrFrom16(a)
+}
+
+// SetISISRoutes installs the routes computed by IS-IS in the sysrib, and removes the routes
+// that are no longer computed.
+func (rec *Reconciler) SetISISRoutes(routes []*isis.Route) {
+	rec.isisMu.Lock()
+	defer rec.isisMu.Unlock()
+	if rec.isis == nil {
+		return
+	}
+	ctx := context.Background()
+
+	want := map[netip.Prefix]*isis.Route{}
+	for _, r := range routes {
+		want[r.Prefix] = r
+	}
+	for prefix, r := range rec.isis.routes {
+		if _, ok := want[prefix]; ok {
+			continue
+		}
+		if _, err := rec.isis.rib.SetRoute(ctx, isisRouteRequest(r, true)); err != nil {
+			log.Warningf("failed to delete IS-IS route %v: %v", prefix, err)
+		}
+		delete(rec.isis.routes, prefix)
+	}
+	for prefix, r := range want {
+		if old, ok := rec.isis.routes[prefix]; ok && reflect.DeepEqual(old, r) {
+			continue
+		}
+		if _, err := rec.isis.rib.SetRoute(ctx, isisRouteRequest(r, false)); err != nil {
+			log.Warningf("failed to set IS-IS route %v: %v", prefix, err)
+			continue
+		}
+		rec.isis.routes[prefix] = r
+	}
+}
+
+// ResetISISRoutes forgets the IS-IS routes installed in the sysrib, since resetting the sysrib
+// removes them, so that the routes computed next are installed again.
+func (rec *Reconciler) ResetISISRoutes(context.Context) error {
+	rec.isisMu.Lock()
+	defer rec.isisMu.Unlock()
+	if rec.isis == nil {
+		return nil
+	}
+	rec.isis.routes = map[netip.Prefix]*isis.Route{}
+	return nil
+}
+
+// isisRouteRequest returns the sysrib request that sets or deletes the route.
+func isisRouteRequest(r *isis.Route, del bool) *sysribpb.SetRouteRequest {
+	req := &sysribpb.SetRouteRequest{
+		AdminDistance:   isisAdminDistance,
+		ProtocolName:    isisProtocolName,
+		Metric:          r.Metric,
+		NetworkInstance: fakedevice.DefaultNetworkInstance,
+		Prefix: &sysribpb.Prefix{
+			Family:     sysribpb.Prefix_FAMILY_IPV4,
+			Address:    r.Prefix.Addr().String(),
+			MaskLength: uint32(r.Prefix.Bits()),
+		},
+		Delete: del,
+	}
+	if r.Prefix.Addr().Is6() {
+		req.Prefix.Family = sysribpb.Prefix_FAMILY_IPV6
+	}
+	for _, nh := range r.NextHops {
+		t := sysribpb.Nexthop_TYPE_IPV4
+		if nh.Address.Is6() {
+			t = sysribpb.Nexthop_TYPE_IPV6
+		}
+		req.Nexthops = append(req.Nexthops, &sysribpb.Nexthop{
+			Type:    t,
+			Address: nh.Address.String(),
+			Weight:  1,
+		})
+	}
+	return req
+}
+
+// isisStateBatch returns a batch that replaces the state of the adjacencies and the LSPs of the
+// daemon and deletes the state of the removed ones. The caller must hold isisMu.
+func (rec *Reconciler) isisStateBatch() *ygnmi.SetBatch {
+	sb := &ygnmi.SetBatch{}
+	adjs := map[isisAdjacency]bool{}
+	lsps := map[isisLSP]bool{}
+	name := rec.isis.name
+	if name != "" {
+		isisPath := ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance).Protocol(oc.PolicyTypes_INSTALL_PROTOCOL_TYPE_ISIS, name).Isis()
+		for _, a := range rec.isis.daemon.Adjacencies() {
+			key := isisAdjacency{intf: a.Interface, level: a.Level, system: a.SystemID.String()}
+			adjs[key] = true
+			gnmiclient.BatchReplace(sb, isisPath.Interface(a.Interface).Level(uint8(a.Level)).Adjacency(key.system).State(), isisAdjacencyState(a))
+		}
+		for _, l := range rec.isis.daemon.Database() {
+			key := isisLSP{level: l.Level, id: l.ID.String()}
+			lsps[key] = true
+			gnmiclient.BatchReplace(sb, isisPath.Level(uint8(l.Level)).Lsp(key.id).State(), isisLSPState(l))
+		}
+	}
+	// All the state of an instance that is renamed or removed is deleted.
+	renamed := name != rec.isis.publishedName
+	oldPath := ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance).Protocol(oc.PolicyTypes_INSTALL_PROTOCOL_TYPE_ISIS, rec.isis.publishedName).Isis()
+	for key := range rec.isis.publishedAdjs {
+		if renamed || !adjs[key] {
+			gnmiclient.BatchDelete(sb, oldPath.Interface(key.intf).Level(uint8(key.level)).Adjacency(key.system).State())
+		}
+	}
+	for key := range rec.isis.publishedLSPs {
+		if renamed || !lsps[key] {
+			gnmiclient.BatchDelete(sb, oldPath.Level(uint8(key.level)).Lsp(key.id).State())
+		}
+	}
+	rec.isis.publishedName = name
+	rec.isis.publishedAdjs = adjs
+	rec.isis.publishedLSPs = lsps
+	return sb
+}
+
+// isisLevelType returns the level type of the levels.
+func isisLevelType(l isis.Levels) oc.E_IsisTypes_LevelType {
+	switch l {
+	case isis.Level1:
+		return oc.IsisTypes_LevelType_LEVEL_1
+	case isis.Level2:
+		return oc.IsisTypes_LevelType_LEVEL_2
+	case isis.Level12:
+		return oc.IsisTypes_LevelType_LEVEL_1_2
+	}
+	return oc.IsisTypes_LevelType_UNSET
+}
+
+// isisAdjacencyState returns the OpenConfig state of the adjacency.
+func isisAdjacencyState(a *isis.Adjacency) *oc.NetworkInstance_Protocol_Isis_Interface_Level_Adjacency {
+	s := &oc.NetworkInstance_Protocol_Isis_Interface_Level_Adjacency{
+		SystemId:            ygot.String(a.SystemID.String()),
+		AdjacencyState:      oc.IsisTypes_IsisInterfaceAdjState_DOWN,
+		AdjacencyType:       isisLevelType(isis.Level(a.Level)),
+		NeighborCircuitType: isisLevelType(a.CircuitType),
+		AreaAddress:         a.Areas,
+		Priority:            ygot.Uint8(a.Priority),
+	}
+	switch a.State {
+	case isis.AdjacencyUp:
+		s.AdjacencyState = oc.IsisTypes_IsisInterfaceAdjState_UP
+	case isis.AdjacencyInit:
+		s.AdjacencyState = oc.IsisTypes_IsisInterfaceAdjState_INIT
+	}
+	if a.SNPA != nil {
+		s.NeighborSnpa = ygot.String(a.SNPA.String())
+	}
+	if a.PointToPoint {
+		s.LocalExtendedCircuitId = ygot.Uint32(a.LocalCircuitID)
+		s.NeighborExtendedCircuitId = ygot.Uint32(a.NeighborCircuitID)
+	} else if a.DIS != (isis.NodeID{}) {
+		s.DisSystemId = ygot.String(a.DIS.System().String())
+	}
+	for _, p := range a.Protocols {
+		switch p {
+		case isis.NLPIDIPv4:
+			s.Nlpid = append(s.Nlpid, oc.Adjacency_Nlpid_IPV4)
+		case isis.NLPIDIPv6:
+			s.Nlpid = append(s.Nlpid, oc.Adjacency_Nlpid_IPV6)
+		}
+	}
+	if len(a.IPv4) > 0 {
+		s.NeighborIpv4Address = ygot.String(a.IPv4[0].String())
+	}
+	if len(a.IPv6) > 0 {
+		s.NeighborIpv6Address = ygot.String(a.IPv6[0].String())
+	}
+	if !a.UpSince.IsZero() {
+		s.UpTimestamp = ygot.Uint64(uint64(a.UpSince.UnixNano()))
+	}
+	return s
+}
+
+// isisLSPState returns the OpenConfig state of the LSP, with its hostname, area addresses, protocols,
+// interface addresses and wide metric reachability TLVs.
+func isisLSPState(l *isis.LSP) *oc.NetworkInstance_Protocol_Isis_Level_Lsp {
+	s := &oc.NetworkInstance_Protocol_Isis_Level_Lsp{
+		LspId:             ygot.String(l.ID.String()),
+		SequenceNumber:    ygot.Uint32(l.Sequence),
+		Checksum:          ygot.Uint16(l.Checksum),
+		RemainingLifetime: ygot.Uint16(l.RemainingLifetime),
+		PduLength:         ygot.Uint16(l.PDULength),
+		IsType:            ygot.Uint8(uint8(l.ISType)),
+		PduType:           oc.Lsp_PduType_LEVEL_1,
+	}
+	if l.Level == 2 {
+		s.PduType = oc.Lsp_PduType_LEVEL_2
+	}
+	if l.Attached {
+		s.Flags = append(s.Flags, oc.Lsp_Flags_ATTACHED_DEFAULT)
+	}
+	if l.Overload {
+		s.Flags = append(s.Flags, oc.Lsp_Flags_OVERLOAD)
+	}
+	if l.Partition {
+		s.Flags = append(s.Flags, oc.Lsp_Flags_PARTITION_REPAIR)
+	}
+
+	if l.Hostname != "" {
+		s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_DYNAMIC_NAME).GetOrCreateHostname().Hostname = []string{l.Hostname}
+	}
+	if len(l.Areas) > 0 {
+		s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_AREA_ADDRESSES).GetOrCreateAreaAddress().Address = l.Areas
+	}
+	if len(l.Protocols) > 0 {
+		tlv := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_NLPID).GetOrCreateNlpid()
+		for _, p := range l.Protocols {
+			switch p {
+			case isis.NLPIDIPv4:
+				tlv.Nlpid = append(tlv.Nlpid, oc.Nlpid_Nlpid_IPV4)
+			case isis.NLPIDIPv6:
+				tlv.Nlpid = append(tlv.Nlpid, oc.Nlpid_Nlpid_IPV6)
+			}
+		}
+	}
+	if len(l.IPv4Addresses) > 0 {
+		tlv := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_IPV4_INTERFACE_ADDRESSES).GetOrCreateIpv4InterfaceAddresses()
+		for _, a := range l.IPv4Addresses {
+			tlv.Address = append(tlv.Address, a.String())
+		}
+	}
+	if len(l.IPv6Addresses) > 0 {
+		tlv := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_IPV6_INTERFACE_ADDRESSES).GetOrCreateIpv6InterfaceAddresses()
+		for _, a := range l.IPv6Addresses {
+			tlv.Address = append(tlv.Address, a.String())
+		}
+	}
+	if len(l.Neighbors) > 0 {
+		tlv := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_EXTENDED_IS_REACHABILITY).GetOrCreateExtendedIsReachability()
+		for _, n := range l.Neighbors {
+			tlv.GetOrCreateNeighbor(n.ID.String()).GetOrCreateInstance(0).Metric = ygot.Uint32(n.Metric)
+		}
+	}
+	for _, p := range l.Prefixes {
+		if p.Prefix.Addr().Is4() {
+			pfx := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_EXTENDED_IPV4_REACHABILITY).GetOrCreateExtendedIpv4Reachability().GetOrCreatePrefix(p.Prefix.String())
+			pfx.Metric = ygot.Uint32(p.Metric)
+			pfx.UpDown = ygot.Bool(p.Down)
+			continue
+		}
+		pfx := s.GetOrCreateTlv(oc.IsisLsdbTypes_ISIS_TLV_TYPE_IPV6_REACHABILITY).GetOrCreateIpv6Reachability().GetOrCreatePrefix(p.Prefix.String())
+		pfx.Metric = ygot.Uint32(p.Metric)
+		pfx.UpDown = ygot.Bool(p.Down)
+	}
+	return s
+}
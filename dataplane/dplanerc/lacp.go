@@ -0,0 +1,336 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/openconfig/ygnmi/ygnmi"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/dataplane/protocol/lacp"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+)
+
+// defaultLACPPriority is the system and port priority used when they are not configured.
+const defaultLACPPriority = 32768
+
+// lacpMember is the key of a member of an LACP aggregate.
+type lacpMember struct {
+	aggregate string
+	name      string
+}
+
+// lacpState is the state of the LACP handler.
+type lacpState struct {
+	daemon    *lacp.Daemon
+	config    *oc.Lacp
+	applied   []*lacp.Aggregate
+	published map[lacpMember]bool
+	lagTypes  map[string]oc.E_IfAggregate_AggregationType // The type of each aggregate at the last reconciliation.
+}
+
+// StartLACP starts the LACP handler, which negotiates the members of the aggregate interfaces
+// configured in /lacp/interfaces with the daemon and publishes their state.
+func (rec *Reconciler) StartLACP(ctx context.Context, client *ygnmi.Client, d *lacp.Daemon) error {
+	log.Info("starting LACP handler")
+	rec.lacpMu.Lock()
+	rec.lacp = &lacpState{
+		daemon:    d,
+		published: map[lacpMember]bool{},
+		lagTypes:  map[string]oc.E_IfAggregate_AggregationType{},
+	}
+	rec.lacpMu.Unlock()
+
+	d.Start()
+	ctx, cancelFn := context.WithCancel(ctx)
+	rec.closers = append(rec.closers, cancelFn, d.Stop)
+
+	w := ygnmi.Watch(ctx, client, ocpath.Root().Lacp().Config(), func(v *ygnmi.Value[*oc.Lacp]) error {
+		cfg, _ := v.Val()
+		rec.lacpMu.Lock()
+		defer rec.lacpMu.Unlock()
+		rec.lacp.config = cfg
+		rec.reconcileLACP()
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := w.Await(); err != nil {
+			log.Warningf("LACP watcher has stopped: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			rec.lacpMu.Lock()
+			// Pick up the aggregates and members created after the configuration.
+			rec.reconcileLACP()
+			sb := rec.lacpStateBatch()
+			rec.lacpMu.Unlock()
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("LACP handler: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reconcileLACP configures the daemon with the LACP aggregates and their members.
+// The caller must hold lacpMu.
+func (rec *Reconciler) reconcileLACP() {
+	rec.reconcileLAGTypes()
+	cfg := rec.lacp.config
+	if cfg == nil {
+		cfg = &oc.Lacp{}
+	}
+	var aggs []*lacp.Aggregate
+
+	rec.stateMu.RLock()
+	for name, intf := range cfg.Interface {
+		aggData, ok := rec.ocInterfaceData[ocInterface{name: name}]
+		if !ok || !aggData.isAggregate {
+			continue
+		}
+		aggState := rec.state[name]
+		if aggState.GetAggregation().GetLagType() != oc.IfAggregate_AggregationType_LACP {
+			continue
+		}
+		agg := &lacp.Aggregate{
+			Name:           name,
+			SystemPriority: defaultLACPPriority,
+			Key:            uint16(aggData.hostifIfIndex),
+			Active:         intf.GetLacpMode() == oc.Lacp_LacpActivityType_ACTIVE,
+			Fast:           intf.GetInterval() == oc.Lacp_LacpPeriodType_FAST,
+		}
+		switch {
+		case intf.SystemPriority != nil:
+			agg.SystemPriority = intf.GetSystemPriority()
+		case cfg.SystemPriority != nil:
+			agg.SystemPriority = cfg.GetSystemPriority()
+		}
+		sysMAC := intf.GetSystemIdMac()
+		if sysMAC == "" {
+			sysMAC = aggState.GetEthernet().GetMacAddress()
+		}
+		system, err := net.ParseMAC(sysMAC)
+		if err != nil {
+			log.Warningf("invalid LACP system id %q of %v: %v", sysMAC, name, err)
+			continue
+		}
+		agg.System = system
+
+		for ref, data := range rec.ocInterfaceData {
+			if ref.subintf != 0 || data.aggregateID != name {
+				continue
+			}
+			mac, err := net.ParseMAC(rec.state[ref.name].GetEthernet().GetMacAddress())
+			if err != nil {
+				log.Warningf("invalid MAC address of LACP member %v: %v", ref.name, err)
+				continue
+			}
+			m := &lacp.Member{
+				Name:         ref.name,
+				PortID:       data.portID,
+				HostPort:     data.hostifID,
+				MAC:          mac,
+				Port:         uint16(data.hostifIfIndex),
+				PortPriority: defaultLACPPriority,
+			}
+			if mc := intf.GetMember(ref.name); mc != nil && mc.PortPriority != nil {
+				m.PortPriority = mc.GetPortPriority()
+			}
+			agg.Members = append(agg.Members, m)
+		}
+		sort.Slice(agg.Members, func(i, j int) bool { return agg.Members[i].Name < agg.Members[j].Name })
+		aggs = append(aggs, agg)
+	}
+	rec.stateMu.RUnlock()
+
+	sort.Slice(aggs, func(i, j int) bool { return aggs[i].Name < aggs[j].Name })
+	if reflect.DeepEqual(aggs, rec.lacp.applied) {
+		return
+	}
+	rec.lacp.daemon.Configure(aggs)
+	rec.lacp.applied = aggs
+}
+
+// reconcileLAGTypes moves the members of the aggregates whose lag type changed since the last
+// reconciliation: the members of a STATIC aggregate are added to the LAG, the members of an LACP
+// aggregate are removed from it until the daemon distributes them. The caller must hold lacpMu.
+func (rec *Reconciler) reconcileLAGTypes() {
+	rec.stateMu.Lock()
+	defer rec.stateMu.Unlock()
+	ctx := context.Background()
+
+	types := map[string]oc.E_IfAggregate_AggregationType{}
+	for ref, aggData := range rec.ocInterfaceData {
+		if ref.subintf != 0 || !aggData.isAggregate {
+			continue
+		}
+		lagType := rec.state[ref.name].GetAggregation().GetLagType()
+		types[ref.name] = lagType
+		// The members of a new aggregate are added according to its type by addLAGMember.
+		if prev, ok := rec.lacp.lagTypes[ref.name]; !ok || prev == lagType {
+			continue
+		}
+		log.Infof("lag type of %v changed to %v, updating its members", ref.name, lagType)
+		for mref, data := range rec.ocInterfaceData {
+			if mref.subintf != 0 || data.aggregateID != ref.name {
+				continue
+			}
+			switch {
+			case lagType == oc.IfAggregate_AggregationType_LACP && data.lagMembershipID != 0:
+				if _, err := rec.lagClient.RemoveLagMember(ctx, &saipb.RemoveLagMemberRequest{Oid: data.lagMembershipID}); err != nil {
+					log.Warningf("failed to remove lag member %v of %v: %v", mref.name, ref.name, err)
+					continue
+				}
+				data.lagMembershipID = 0
+			case lagType != oc.IfAggregate_AggregationType_LACP && data.lagMembershipID == 0:
+				resp, err := rec.lagClient.CreateLagMember(ctx, &saipb.CreateLagMemberRequest{
+					Switch: rec.switchID,
+					LagId:  proto.Uint64(aggData.portID),
+					PortId: proto.Uint64(data.portID),
+				})
+				if err != nil {
+					log.Warningf("failed to create lag member %v of %v: %v", mref.name, ref.name, err)
+					continue
+				}
+				data.lagMembershipID = resp.GetOid()
+			}
+		}
+	}
+	rec.lacp.lagTypes = types
+}
+
+// lacpStateBatch returns a batch that replaces the state of the members negotiated by the daemon
+// and deletes the state of the removed members. The caller must hold lacpMu.
+func (rec *Reconciler) lacpStateBatch() *ygnmi.SetBatch {
+	sb := &ygnmi.SetBatch{}
+	published := map[lacpMember]bool{}
+	for _, s := range rec.lacp.daemon.Members() {
+		key := lacpMember{aggregate: s.Aggregate, name: s.Name}
+		published[key] = true
+
+		m := &oc.Lacp_Interface_Member{
+			Interface:       ygot.String(s.Name),
+			Activity:        oc.Lacp_LacpActivityType_PASSIVE,
+			Timeout:         oc.Lacp_LacpTimeoutType_LONG,
+			Aggregatable:    ygot.Bool(s.Actor.State&lacp.StateAggregation != 0),
+			Collecting:      ygot.Bool(s.Collecting),
+			Distributing:    ygot.Bool(s.Distributing),
+			Synchronization: oc.Lacp_LacpSynchronizationType_OUT_SYNC,
+			SystemId:        ygot.String(s.Actor.System.String()),
+			OperKey:         ygot.Uint16(s.Actor.Key),
+			PortNum:         ygot.Uint16(s.Actor.Port),
+			PortPriority:    ygot.Uint16(s.Actor.PortPriority),
+			Counters: &oc.Lacp_Interface_Member_Counters{
+				LacpInPkts:             ygot.Uint64(s.InPkts),
+				LacpOutPkts:            ygot.Uint64(s.OutPkts),
+				LacpRxErrors:           ygot.Uint64(s.RxErrors),
+				LacpTxErrors:           ygot.Uint64(s.TxErrors),
+				LacpTimeoutTransitions: ygot.Uint64(s.TimeoutTransitions),
+			},
+		}
+		if s.Actor.State&lacp.StateActivity != 0 {
+			m.Activity = oc.Lacp_LacpActivityType_ACTIVE
+		}
+		if s.Actor.State&lacp.StateTimeout != 0 {
+			m.Timeout = oc.Lacp_LacpTimeoutType_SHORT
+		}
+		if s.Actor.State&lacp.StateSynchronization != 0 {
+			m.Synchronization = oc.Lacp_LacpSynchronizationType_IN_SYNC
+		}
+		if s.Partner.System != nil {
+			m.PartnerId = ygot.String(s.Partner.System.String())
+			m.PartnerKey = ygot.Uint16(s.Partner.Key)
+			m.PartnerPortNum = ygot.Uint16(s.Partner.Port)
+			m.PartnerPortPriority = ygot.Uint16(s.Partner.PortPriority)
+		}
+		if !s.LastChange.IsZero() {
+			m.LastChange = ygot.Uint64(uint64(s.LastChange.UnixNano()))
+		}
+		gnmiclient.BatchReplace(sb, ocpath.Root().Lacp().Interface(s.Aggregate).Member(s.Name).State(), m)
+	}
+	for key := range rec.lacp.published {
+		if !published[key] {
+			gnmiclient.BatchDelete(sb, ocpath.Root().Lacp().Interface(key.aggregate).Member(key.name).State())
+		}
+	}
+	rec.lacp.published = published
+	return sb
+}
+
+// SetLACPDistributing adds the member to the LAG of the aggregate when it starts
+// distributing, and removes it when it stops.
+func (rec *Reconciler) SetLACPDistributing(aggregate, member string, distributing bool) {
+	rec.stateMu.Lock()
+	defer rec.stateMu.Unlock()
+	ctx := context.Background()
+
+	data, ok := rec.ocInterfaceData[ocInterface{name: member}]
+	if !ok {
+		log.Warningf("unknown LACP member %v", member)
+		return
+	}
+	// The daemon stops distributing the members of an aggregate changed to STATIC after its
+	// members are added to the LAG, they must stay in it.
+	if rec.state[aggregate].GetAggregation().GetLagType() != oc.IfAggregate_AggregationType_LACP {
+		return
+	}
+	if !distributing {
+		if data.lagMembershipID == 0 {
+			return
+		}
+		if _, err := rec.lagClient.RemoveLagMember(ctx, &saipb.RemoveLagMemberRequest{Oid: data.lagMembershipID}); err != nil {
+			log.Warningf("failed to remove lag member %v of %v: %v", member, aggregate, err)
+			return
+		}
+		data.lagMembershipID = 0
+		return
+	}
+	// The member may have left the aggregate after the daemon started distributing.
+	aggData, ok := rec.ocInterfaceData[ocInterface{name: aggregate}]
+	if !ok || data.aggregateID != aggregate || data.lagMembershipID != 0 {
+		return
+	}
+	resp, err := rec.lagClient.CreateLagMember(ctx, &saipb.CreateLagMemberRequest{
+		Switch: rec.switchID,
+		LagId:  proto.Uint64(aggData.portID),
+		PortId: proto.Uint64(data.portID),
+	})
+	if err != nil {
+		log.Warningf("failed to create lag member %v of %v: %v", member, aggregate, err)
+		return
+	}
+	data.lagMembershipID = resp.GetOid()
+}
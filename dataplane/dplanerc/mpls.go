@@ -0,0 +1,310 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/openconfig/ygnmi/schemaless"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/gnmi"
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/networkinstance"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	dpb "github.com/openconfig/lemming/proto/dataplane"
+	routingpb "github.com/openconfig/lemming/proto/routing"
+)
+
+// LabelRouteQuery returns a ygnmi query for an MPLS route with the given incoming label.
+func LabelRouteQuery(label uint32) ygnmi.ConfigQuery[*dpb.LabelRoute] {
+	q, err := schemaless.NewConfig[*dpb.LabelRoute](fmt.Sprintf("/dataplane/label-routes/route[label=%d]", label), gnmi.InternalOrigin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return q
+}
+
+// MustLabelRouteWildcardQuery returns a wildcard card query for all MPLS routes.
+func MustLabelRouteWildcardQuery() ygnmi.WildcardQuery[*dpb.LabelRoute] {
+	q, err := schemaless.NewWildcard[*dpb.LabelRoute]("/dataplane/label-routes/route[label=*]", gnmi.InternalOrigin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return q
+}
+
+// labelStack returns the labels pushed by the headers, ordered from the outermost label.
+// Headers and the labels within them are ordered from the bottom of the stack.
+// The returned bool is false if there are no headers or any header is not MPLS.
+func labelStack(hdrs []*routingpb.Header) ([]uint32, bool) {
+	if len(hdrs) == 0 {
+		return nil, false
+	}
+	var labels []uint32
+	for _, hdr := range hdrs {
+		if hdr.GetType() != routingpb.HeaderType_HEADER_TYPE_MPLS {
+			return nil, false
+		}
+		labels = append(labels, hdr.GetLabels()...)
+	}
+	slices.Reverse(labels)
+	return labels, true
+}
+
+// StartLabelRoute starts watching the MPLS routes and programs them as inseg entries.
+func (rec *Reconciler) StartLabelRoute(ctx context.Context, client *ygnmi.Client) error {
+	ctx, cancelFn := context.WithCancel(ctx)
+	w := ygnmi.WatchAll(ctx, client, MustLabelRouteWildcardQuery(), func(v *ygnmi.Value[*dpb.LabelRoute]) error {
+		route, present := v.Val()
+		l, err := strconv.ParseUint(v.Path.Elem[2].Key["label"], 10, 32)
+		if err != nil {
+			log.Warningf("failed to parse label: %v", err)
+			return ygnmi.Continue
+		}
+		label := uint32(l)
+		entry := &saipb.InsegEntry{
+			SwitchId: rec.switchID,
+			Label:    label,
+		}
+
+		// Updates replace the whole entry, so remove the existing entry first.
+		if rd, ok := rec.labelRouteData[label]; ok {
+			log.Infof("removing label route: %v", label)
+			if _, err := rec.mplsClient.RemoveInsegEntry(ctx, &saipb.RemoveInsegEntryRequest{Entry: entry}); err != nil {
+				log.Warningf("failed to delete label route: %v", err)
+			}
+			if rd != nil {
+				rec.removeNextHops(ctx, rd)
+			}
+			delete(rec.labelRouteData, label)
+		}
+		if !present {
+			rec.removeLabelCounter(ctx, client, label)
+			return ygnmi.Continue
+		}
+
+		req := &saipb.CreateInsegEntryRequest{
+			Entry:        entry,
+			PacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+		}
+		if counterID, err := rec.labelCounterID(ctx, client, label, route.GetSegmentRouting()); err != nil {
+			log.Warningf("failed to create counter of label route %v: %v", label, err)
+		} else {
+			req.CounterId = proto.Uint64(counterID)
+		}
+		if route.GetAction() == dpb.PacketAction_PACKET_ACTION_DROP || len(route.GetNextHops().GetHops()) == 0 {
+			if _, err := rec.mplsClient.CreateInsegEntry(ctx, req); err != nil {
+				log.Warningf("failed to create label route: %v", err)
+				return ygnmi.Continue
+			}
+			rec.labelRouteData[label] = nil
+			log.Infof("created drop label route: %v", req)
+			return ygnmi.Continue
+		}
+
+		// If every next hop pushes labels, the matched label is swapped instead of popped.
+		pops := route.GetPopLabels()
+		outseg := saipb.OutsegType_OUTSEG_TYPE_PUSH
+		if pops > 0 {
+			swap := true
+			for _, hop := range route.GetNextHops().GetHops() {
+				if labels, ok := labelStack(hop.GetHeaders().GetHeaders()); !ok || len(labels) == 0 {
+					swap = false
+				}
+			}
+			if swap {
+				pops--
+				outseg = saipb.OutsegType_OUTSEG_TYPE_SWAP
+			}
+		}
+
+		hopID, rd, err := rec.createNextHops(ctx, route.GetNextHops(), outseg)
+		if err != nil {
+			log.Warningf("failed to create next hops: %v", err)
+			return ygnmi.Continue
+		}
+		rec.labelRouteData[label] = rd
+		req.PacketAction = saipb.PacketAction_PACKET_ACTION_FORWARD.Enum()
+		req.NumOfPop = proto.Uint32(pops)
+		req.NextHopId = proto.Uint64(hopID)
+		if _, err := rec.mplsClient.CreateInsegEntry(ctx, req); err != nil {
+			log.Warningf("failed to create label route: %v", err)
+			return ygnmi.Continue
+		}
+		log.Infof("created label route: %v", req)
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := w.Await(); err != nil {
+			log.Warningf("label routes watch err: %v", err)
+		}
+	}()
+	rec.startLabelCounterUpdates(ctx, client)
+	rec.closers = append(rec.closers, cancelFn)
+	return nil
+}
+
+// labelCounter is the counter of the inseg entry of a label route.
+// SAI counters can't be cleared, so clearing a counter records its current values
+// which are subtracted from the reported values.
+type labelCounter struct {
+	oid         uint64
+	basePackets uint64
+	baseOctets  uint64
+	// sid is set if the label is a static or segment routing SID, whose counter is published.
+	sid bool
+}
+
+// aggregateSIDCounterPath returns the path of the aggregate SID counter of the label.
+func aggregateSIDCounterPath(label uint32) *networkinstance.NetworkInstance_Mpls_SignalingProtocols_SegmentRouting_AggregateSidCounterPath {
+	return ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance).Mpls().SignalingProtocols().SegmentRouting().AggregateSidCounter(oc.UnionUint32(label))
+}
+
+// labelCounterID returns the id of the counter of the label, creating the counter if it doesn't exist.
+// The counter is kept when the label route is updated, so that it only restarts when the route is removed.
+// The state of the counter is deleted if the label is no longer a SID.
+func (rec *Reconciler) labelCounterID(ctx context.Context, client *ygnmi.Client, label uint32, sid bool) (uint64, error) {
+	rec.labelMu.Lock()
+	defer rec.labelMu.Unlock()
+	if lc, ok := rec.labelCounters[label]; ok {
+		if lc.sid && !sid {
+			if _, err := gnmiclient.Delete(ctx, client, aggregateSIDCounterPath(label).State()); err != nil {
+				log.Warningf("failed to delete counter state of label route %v: %v", label, err)
+			}
+		}
+		lc.sid = sid
+		return lc.oid, nil
+	}
+	resp, err := rec.counterClient.CreateCounter(ctx, &saipb.CreateCounterRequest{
+		Switch:            rec.switchID,
+		Type:              saipb.CounterType_COUNTER_TYPE_REGULAR.Enum(),
+		EnablePacketCount: proto.Bool(true),
+		EnableByteCount:   proto.Bool(true),
+	})
+	if err != nil {
+		return 0, err
+	}
+	rec.labelCounters[label] = &labelCounter{oid: resp.GetOid(), sid: sid}
+	return resp.GetOid(), nil
+}
+
+// removeLabelCounter removes the counter of the label and its state.
+func (rec *Reconciler) removeLabelCounter(ctx context.Context, client *ygnmi.Client, label uint32) {
+	rec.labelMu.Lock()
+	defer rec.labelMu.Unlock()
+	lc, ok := rec.labelCounters[label]
+	if !ok {
+		return
+	}
+	delete(rec.labelCounters, label)
+	if _, err := rec.counterClient.RemoveCounter(ctx, &saipb.RemoveCounterRequest{Oid: lc.oid}); err != nil {
+		log.Warningf("failed to remove counter of label route %v: %v", label, err)
+	}
+	if !lc.sid {
+		return
+	}
+	if _, err := gnmiclient.Delete(ctx, client, aggregateSIDCounterPath(label).State()); err != nil {
+		log.Warningf("failed to delete counter state of label route %v: %v", label, err)
+	}
+}
+
+// labelCounterStats returns the packets and octets counted by the SAI counter.
+func (rec *Reconciler) labelCounterStats(ctx context.Context, oid uint64) (uint64, uint64, error) {
+	stats, err := rec.counterClient.GetCounterStats(ctx, &saipb.GetCounterStatsRequest{
+		Oid:        oid,
+		CounterIds: []saipb.CounterStat{saipb.CounterStat_COUNTER_STAT_PACKETS, saipb.CounterStat_COUNTER_STAT_BYTES},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(stats.GetValues()) != 2 {
+		return 0, 0, fmt.Errorf("unexpected number of counter values: %v", stats.GetValues())
+	}
+	return stats.GetValues()[0], stats.GetValues()[1], nil
+}
+
+// ClearLabelCounters resets the counters of the label routes with the given incoming labels,
+// or of all label routes if labels is empty.
+func (rec *Reconciler) ClearLabelCounters(ctx context.Context, labels []uint32) error {
+	rec.labelMu.Lock()
+	defer rec.labelMu.Unlock()
+	if len(labels) == 0 {
+		labels = slices.Collect(maps.Keys(rec.labelCounters))
+	}
+	for _, label := range labels {
+		lc, ok := rec.labelCounters[label]
+		if !ok {
+			return fmt.Errorf("no counter for label %d", label)
+		}
+		packets, octets, err := rec.labelCounterStats(ctx, lc.oid)
+		if err != nil {
+			return fmt.Errorf("failed to get counter of label %d: %v", label, err)
+		}
+		lc.basePackets, lc.baseOctets = packets, octets
+	}
+	return nil
+}
+
+// startLabelCounterUpdates periodically updates the aggregate SID counters from the counters of the SID label routes.
+func (rec *Reconciler) startLabelCounterUpdates(ctx context.Context, client *ygnmi.Client) {
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			// Copy the counters, so that the lock is not held while querying them.
+			rec.labelMu.Lock()
+			counters := map[uint32]labelCounter{}
+			for label, lc := range rec.labelCounters {
+				if lc.sid {
+					counters[label] = *lc
+				}
+			}
+			rec.labelMu.Unlock()
+			sb := &ygnmi.SetBatch{}
+			for label, lc := range counters {
+				packets, octets, err := rec.labelCounterStats(ctx, lc.oid)
+				if err != nil {
+					log.Errorf("label route handler: could not retrieve counter for label %v: %v", label, err)
+					continue
+				}
+				p := aggregateSIDCounterPath(label)
+				gnmiclient.BatchUpdate(sb, p.MplsLabel().State(), oc.NetworkInstance_Mpls_SignalingProtocols_SegmentRouting_AggregateSidCounter_MplsLabel_Union(oc.UnionUint32(label)))
+				gnmiclient.BatchUpdate(sb, p.InPkts().State(), packets-lc.basePackets)
+				gnmiclient.BatchUpdate(sb, p.InOctets().State(), octets-lc.baseOctets)
+			}
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("label route handler: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,522 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+)
+
+// qosQueueConfig is the scheduler and WRED profile of a queue, without their OIDs.
+type qosQueueConfig struct {
+	scheduler *saipb.SetSchedulerAttributeRequest
+	wred      *saipb.SetWredAttributeRequest // nil if the queue has no WRED profile
+}
+
+// qosPortConfig is the QoS configuration of a port. The traffic class of
+// the packets classified in a forwarding group is the id of the output queue
+// of the group, so traffic class N is scheduled in queue N.
+type qosPortConfig struct {
+	dscp   []*saipb.QOSMap
+	exp    []*saipb.QOSMap
+	queues map[uint8]*qosQueueConfig // keyed by queue id
+}
+
+func (c *qosPortConfig) equal(o *qosPortConfig) bool {
+	if c == nil || o == nil {
+		return c == o
+	}
+	mapsEqual := func(a, b []*saipb.QOSMap) bool {
+		return slices.EqualFunc(a, b, func(x, y *saipb.QOSMap) bool { return proto.Equal(x, y) })
+	}
+	if !mapsEqual(c.dscp, o.dscp) || !mapsEqual(c.exp, o.exp) || len(c.queues) != len(o.queues) {
+		return false
+	}
+	for id, q := range c.queues {
+		oq, ok := o.queues[id]
+		if !ok || !proto.Equal(q.scheduler, oq.scheduler) || !proto.Equal(q.wred, oq.wred) {
+			return false
+		}
+	}
+	return true
+}
+
+// qosPort is the QoS programming of a port in the dataplane.
+type qosPort struct {
+	portID     uint64
+	queueOIDs  []uint64 // the queues of the port, by index
+	dscpMap    uint64   // 0 if not created yet
+	expMap     uint64   // 0 if not created yet
+	schedulers map[uint8]uint64
+	wreds      map[uint8]uint64
+	queueNames map[string]uint8 // the queues published in the state, by name
+	applied    *qosPortConfig
+}
+
+// qosState is the state of the QoS handler.
+type qosState struct {
+	config *oc.Qos
+	ports  map[string]*qosPort // keyed by the id of the interface in /qos/interfaces.
+}
+
+// StartQoS starts the QoS handler, which programs the classifiers, scheduler policies and
+// queue management profiles of the interfaces in the dataplane and publishes the counters
+// of their output queues.
+func (rec *Reconciler) StartQoS(ctx context.Context, client *ygnmi.Client) error {
+	log.Info("starting qos handler")
+	rec.qosMu.Lock()
+	rec.qos = &qosState{ports: map[string]*qosPort{}}
+	rec.qosMu.Unlock()
+
+	ctx, cancelFn := context.WithCancel(ctx)
+	rec.closers = append(rec.closers, cancelFn)
+
+	w := ygnmi.Watch(ctx, client, ocpath.Root().Qos().Config(), func(v *ygnmi.Value[*oc.Qos]) error {
+		cfg, _ := v.Val()
+		rec.qosMu.Lock()
+		defer rec.qosMu.Unlock()
+		rec.qos.config = cfg
+		rec.reconcileQoS(ctx, client)
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := w.Await(); err != nil {
+			log.Warningf("qos watcher has stopped: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			rec.qosMu.Lock()
+			// Retry the interfaces that did not exist yet or failed to be programmed.
+			rec.reconcileQoS(ctx, client)
+			sb := rec.qosCounterBatch(ctx)
+			rec.qosMu.Unlock()
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("qos handler: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reconcileQoS programs the QoS configuration of the interfaces, resetting the
+// interfaces that are no longer configured. The caller must hold qosMu.
+func (rec *Reconciler) reconcileQoS(ctx context.Context, client *ygnmi.Client) {
+	want, names := rec.wantQoS()
+	for id, p := range rec.qos.ports {
+		if portID, ok := want[id]; ok && portID == p.portID {
+			continue
+		}
+		if err := rec.applyQoSPort(ctx, p, &qosPortConfig{}); err != nil {
+			log.Warningf("failed to reset qos of interface %q: %v", id, err)
+		}
+		for name := range p.queueNames {
+			if _, err := gnmiclient.Delete(ctx, client, ocpath.Root().Qos().Interface(id).Output().Queue(name).State()); err != nil {
+				log.Warningf("failed to delete state of queue %q on interface %q: %v", name, id, err)
+			}
+		}
+		delete(rec.qos.ports, id)
+	}
+	for id, portID := range want {
+		p, ok := rec.qos.ports[id]
+		if !ok {
+			resp, err := rec.portClient.GetPortAttribute(ctx, &saipb.GetPortAttributeRequest{
+				Oid:      portID,
+				AttrType: []saipb.PortAttr{saipb.PortAttr_PORT_ATTR_QOS_QUEUE_LIST},
+			})
+			if err != nil {
+				log.Warningf("failed to get queues of interface %q: %v", id, err)
+				continue
+			}
+			p = &qosPort{
+				portID:     portID,
+				queueOIDs:  resp.GetAttr().GetQosQueueList(),
+				schedulers: map[uint8]uint64{},
+				wreds:      map[uint8]uint64{},
+			}
+			rec.qos.ports[id] = p
+		}
+		p.queueNames = map[string]uint8{}
+		for name, qid := range names {
+			if int(qid) < len(p.queueOIDs) {
+				p.queueNames[name] = qid
+			}
+		}
+		cfg, err := rec.qosPortConfig(rec.qos.config.GetInterface(id))
+		if err != nil {
+			log.Warningf("failed to program qos of interface %q: %v", id, err)
+			continue
+		}
+		if cfg.equal(p.applied) {
+			continue
+		}
+		if err := rec.applyQoSPort(ctx, p, cfg); err != nil {
+			log.Warningf("failed to program qos of interface %q: %v", id, err)
+		}
+	}
+}
+
+// wantQoS returns the ports of the configured interfaces that exist in the dataplane, keyed by
+// interface id, and the ids of the configured queues, keyed by name.
+func (rec *Reconciler) wantQoS() (map[string]uint64, map[string]uint8) {
+	ports := map[string]uint64{}
+	names := map[string]uint8{}
+	cfg := rec.qos.config
+	if cfg == nil {
+		return ports, names
+	}
+	for name, q := range cfg.Queue {
+		if q.QueueId != nil {
+			names[name] = q.GetQueueId()
+		}
+	}
+	rec.stateMu.RLock()
+	defer rec.stateMu.RUnlock()
+	for id, intf := range cfg.Interface {
+		ref := ocInterface{name: id}
+		if r := intf.GetInterfaceRef(); r.GetInterface() != "" {
+			ref = ocInterface{name: r.GetInterface(), subintf: r.GetSubinterface()}
+		}
+		// Queues are only supported on ports, not on aggregates.
+		data, ok := rec.ocInterfaceData[ref]
+		if !ok || data.isAggregate {
+			continue
+		}
+		ports[id] = data.portID
+	}
+	return ports, names
+}
+
+// qosPortConfig returns the QoS configuration of the interface.
+func (rec *Reconciler) qosPortConfig(intf *oc.Qos_Interface) (*qosPortConfig, error) {
+	cfg := rec.qos.config
+	queueID := func(name string) (uint8, error) {
+		q := cfg.GetQueue(name)
+		if q == nil || q.QueueId == nil {
+			return 0, fmt.Errorf("queue %q has no queue id", name)
+		}
+		return q.GetQueueId(), nil
+	}
+	pc := &qosPortConfig{queues: map[uint8]*qosQueueConfig{}}
+
+	dscp := map[uint8]uint32{}
+	exp := map[uint8]uint32{}
+	for _, typ := range slices.Sorted(maps.Keys(intf.GetInput().Classifier)) {
+		name := intf.GetInput().GetClassifier(typ).GetName()
+		c := cfg.GetClassifier(name)
+		if c == nil {
+			return nil, fmt.Errorf("classifier %q does not exist", name)
+		}
+		for _, id := range slices.Sorted(maps.Keys(c.Term)) {
+			term := c.GetTerm(id)
+			group := term.GetActions().GetTargetGroup()
+			fg := cfg.GetForwardingGroup(group)
+			if fg == nil {
+				return nil, fmt.Errorf("term %q of classifier %q: forwarding group %q does not exist", id, name, group)
+			}
+			tc, err := queueID(fg.GetOutputQueue())
+			if err != nil {
+				return nil, fmt.Errorf("forwarding group %q: %v", group, err)
+			}
+			cond := term.GetConditions()
+			var dscps []uint8
+			if ip := cond.GetIpv4(); ip != nil {
+				if ip.Dscp != nil {
+					dscps = append(dscps, ip.GetDscp())
+				}
+				dscps = append(dscps, ip.DscpSet...)
+			}
+			if ip := cond.GetIpv6(); ip != nil {
+				if ip.Dscp != nil {
+					dscps = append(dscps, ip.GetDscp())
+				}
+				dscps = append(dscps, ip.DscpSet...)
+			}
+			// The first term that matches a value classifies the packets.
+			for _, d := range dscps {
+				if _, ok := dscp[d]; !ok {
+					dscp[d] = uint32(tc)
+				}
+			}
+			if mpls := cond.GetMpls(); mpls != nil && mpls.TrafficClass != nil {
+				if _, ok := exp[mpls.GetTrafficClass()]; !ok {
+					exp[mpls.GetTrafficClass()] = uint32(tc)
+				}
+			}
+		}
+	}
+	for _, d := range slices.Sorted(maps.Keys(dscp)) {
+		pc.dscp = append(pc.dscp, &saipb.QOSMap{Key: &saipb.QOSMapParams{Dscp: uint32(d)}, Value: &saipb.QOSMapParams{Tc: dscp[d]}})
+	}
+	for _, e := range slices.Sorted(maps.Keys(exp)) {
+		pc.exp = append(pc.exp, &saipb.QOSMap{Key: &saipb.QOSMapParams{MplsExp: uint32(e)}, Value: &saipb.QOSMapParams{Tc: exp[e]}})
+	}
+
+	if name := intf.GetOutput().GetSchedulerPolicy().GetName(); name != "" {
+		policy := cfg.GetSchedulerPolicy(name)
+		if policy == nil {
+			return nil, fmt.Errorf("scheduler policy %q does not exist", name)
+		}
+		for _, seq := range slices.Sorted(maps.Keys(policy.Scheduler)) {
+			s := policy.GetScheduler(seq)
+			for _, in := range s.Input {
+				qid, err := queueID(in.GetQueue())
+				if err != nil {
+					return nil, fmt.Errorf("scheduler %d of policy %q: %v", seq, name, err)
+				}
+				pc.queues[qid] = &qosQueueConfig{scheduler: qosSchedulerRequest(s, in)}
+			}
+		}
+	}
+	for name, q := range intf.GetOutput().Queue {
+		profile := q.GetQueueManagementProfile()
+		if profile == "" {
+			continue
+		}
+		qmp := cfg.GetQueueManagementProfile(profile)
+		if qmp == nil {
+			return nil, fmt.Errorf("queue management profile %q does not exist", profile)
+		}
+		u := qmp.GetWred().GetUniform()
+		if u == nil {
+			return nil, fmt.Errorf("queue management profile %q: only uniform WRED is supported", profile)
+		}
+		qid, err := queueID(name)
+		if err != nil {
+			return nil, err
+		}
+		qc, ok := pc.queues[qid]
+		if !ok {
+			qc = &qosQueueConfig{scheduler: defaultSchedulerRequest()}
+			pc.queues[qid] = qc
+		}
+		prob := uint32(100)
+		if u.MaxDropProbabilityPercent != nil {
+			prob = uint32(u.GetMaxDropProbabilityPercent())
+		}
+		qc.wred = &saipb.SetWredAttributeRequest{
+			GreenEnable:          proto.Bool(true),
+			GreenMinThreshold_64: proto.Uint64(u.GetMinThreshold()),
+			GreenMaxThreshold_64: proto.Uint64(u.GetMaxThreshold()),
+			GreenDropProbability: proto.Uint32(prob),
+		}
+	}
+	return pc, nil
+}
+
+// defaultSchedulerRequest returns the attributes of the scheduler of an unconfigured queue.
+func defaultSchedulerRequest() *saipb.SetSchedulerAttributeRequest {
+	return &saipb.SetSchedulerAttributeRequest{
+		SchedulingType:        saipb.SchedulingType_SCHEDULING_TYPE_DWRR.Enum(),
+		SchedulingWeight:      proto.Uint32(1),
+		MeterType:             saipb.MeterType_METER_TYPE_BYTES.Enum(),
+		MaxBandwidthRate:      proto.Uint64(0),
+		MaxBandwidthBurstRate: proto.Uint64(0),
+	}
+}
+
+// qosSchedulerRequest returns the attributes of the scheduler of a queue that is an input of the scheduler.
+func qosSchedulerRequest(s *oc.Qos_SchedulerPolicy_Scheduler, in *oc.Qos_SchedulerPolicy_Scheduler_Input) *saipb.SetSchedulerAttributeRequest {
+	req := defaultSchedulerRequest()
+	if s.GetPriority() == oc.Scheduler_Priority_STRICT {
+		req.SchedulingType = saipb.SchedulingType_SCHEDULING_TYPE_STRICT.Enum()
+	} else if w := in.GetWeight(); w != 0 {
+		req.SchedulingWeight = proto.Uint32(uint32(min(w, math.MaxUint32)))
+	}
+	if shaper := s.GetOneRateTwoColor(); shaper.GetCir() != 0 {
+		// The CIR is in bits per second and the SAI rate is in bytes per second.
+		req.MaxBandwidthRate = proto.Uint64(shaper.GetCir() / 8)
+		req.MaxBandwidthBurstRate = proto.Uint64(uint64(shaper.GetBc()))
+	}
+	return req
+}
+
+// applyQoSPort programs the QoS configuration of the port. Queues that were configured and
+// are no longer in the configuration are reset to the default scheduler without WRED.
+func (rec *Reconciler) applyQoSPort(ctx context.Context, p *qosPort, cfg *qosPortConfig) error {
+	p.applied = nil
+	if err := rec.applyQoSMap(ctx, p.portID, &p.dscpMap, saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, cfg.dscp); err != nil {
+		return fmt.Errorf("dscp map: %v", err)
+	}
+	if err := rec.applyQoSMap(ctx, p.portID, &p.expMap, saipb.QosMapType_QOS_MAP_TYPE_MPLS_EXP_TO_TC, cfg.exp); err != nil {
+		return fmt.Errorf("mpls exp map: %v", err)
+	}
+	for qid := range p.schedulers {
+		if _, ok := cfg.queues[qid]; !ok {
+			if err := rec.applyQoSQueue(ctx, p, qid, &qosQueueConfig{scheduler: defaultSchedulerRequest()}); err != nil {
+				return fmt.Errorf("queue %d: %v", qid, err)
+			}
+		}
+	}
+	for qid, qc := range cfg.queues {
+		if err := rec.applyQoSQueue(ctx, p, qid, qc); err != nil {
+			return fmt.Errorf("queue %d: %v", qid, err)
+		}
+	}
+	p.applied = cfg
+	return nil
+}
+
+// applyQoSMap programs the map of the type with the values and binds it to the port,
+// or unbinds the map of the type if there are no values.
+func (rec *Reconciler) applyQoSMap(ctx context.Context, portID uint64, oid *uint64, typ saipb.QosMapType, values []*saipb.QOSMap) error {
+	bind := func(mapID uint64) error {
+		req := &saipb.SetPortAttributeRequest{Oid: portID}
+		if typ == saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC {
+			req.QosDscpToTcMap = proto.Uint64(mapID)
+		} else {
+			req.QosMplsExpToTcMap = proto.Uint64(mapID)
+		}
+		_, err := rec.portClient.SetPortAttribute(ctx, req)
+		return err
+	}
+	if len(values) == 0 {
+		if *oid == 0 {
+			return nil
+		}
+		return bind(0)
+	}
+	if *oid == 0 {
+		resp, err := rec.qosMapClient.CreateQosMap(ctx, &saipb.CreateQosMapRequest{
+			Switch:         rec.switchID,
+			Type:           typ.Enum(),
+			MapToValueList: values,
+		})
+		if err != nil {
+			return err
+		}
+		*oid = resp.GetOid()
+		return bind(*oid)
+	}
+	if err := bind(0); err != nil {
+		return err
+	}
+	if _, err := rec.qosMapClient.SetQosMapAttribute(ctx, &saipb.SetQosMapAttributeRequest{Oid: *oid, MapToValueList: values}); err != nil {
+		return err
+	}
+	return bind(*oid)
+}
+
+// applyQoSQueue programs the scheduler and WRED profiles of the queue, creating them
+// and setting them on the queue the first time the queue is configured.
+func (rec *Reconciler) applyQoSQueue(ctx context.Context, p *qosPort, qid uint8, qc *qosQueueConfig) error {
+	if int(qid) >= len(p.queueOIDs) {
+		return fmt.Errorf("port has %d queues", len(p.queueOIDs))
+	}
+	queueOID := p.queueOIDs[qid]
+
+	sched := proto.Clone(qc.scheduler).(*saipb.SetSchedulerAttributeRequest)
+	if oid, ok := p.schedulers[qid]; ok {
+		sched.Oid = oid
+		if _, err := rec.schedulerClient.SetSchedulerAttribute(ctx, sched); err != nil {
+			return err
+		}
+	} else {
+		resp, err := rec.schedulerClient.CreateScheduler(ctx, &saipb.CreateSchedulerRequest{Switch: rec.switchID})
+		if err != nil {
+			return err
+		}
+		sched.Oid = resp.GetOid()
+		if _, err := rec.schedulerClient.SetSchedulerAttribute(ctx, sched); err != nil {
+			return err
+		}
+		if _, err := rec.queueClient.SetQueueAttribute(ctx, &saipb.SetQueueAttributeRequest{Oid: queueOID, SchedulerProfileId: proto.Uint64(sched.Oid)}); err != nil {
+			return err
+		}
+		p.schedulers[qid] = sched.Oid
+	}
+
+	wred := qc.wred
+	if wred == nil {
+		wred = &saipb.SetWredAttributeRequest{GreenEnable: proto.Bool(false)}
+	}
+	wred = proto.Clone(wred).(*saipb.SetWredAttributeRequest)
+	if oid, ok := p.wreds[qid]; ok {
+		wred.Oid = oid
+		_, err := rec.wredClient.SetWredAttribute(ctx, wred)
+		return err
+	}
+	if qc.wred == nil {
+		return nil
+	}
+	resp, err := rec.wredClient.CreateWred(ctx, &saipb.CreateWredRequest{Switch: rec.switchID})
+	if err != nil {
+		return err
+	}
+	wred.Oid = resp.GetOid()
+	if _, err := rec.wredClient.SetWredAttribute(ctx, wred); err != nil {
+		return err
+	}
+	if _, err := rec.queueClient.SetQueueAttribute(ctx, &saipb.SetQueueAttributeRequest{Oid: queueOID, WredProfileId: proto.Uint64(wred.Oid)}); err != nil {
+		return err
+	}
+	p.wreds[qid] = wred.Oid
+	return nil
+}
+
+// qosCounterBatch returns a batch that updates the transmit and drop counters of the
+// output queues of the interfaces. The caller must hold qosMu.
+func (rec *Reconciler) qosCounterBatch(ctx context.Context) *ygnmi.SetBatch {
+	sb := &ygnmi.SetBatch{}
+	for id, p := range rec.qos.ports {
+		intf := ocpath.Root().Qos().Interface(id)
+		gnmiclient.BatchUpdate(sb, intf.InterfaceId().State(), id)
+		for name, qid := range p.queueNames {
+			resp, err := rec.queueClient.GetQueueStats(ctx, &saipb.GetQueueStatsRequest{
+				Oid: p.queueOIDs[qid],
+				CounterIds: []saipb.QueueStat{
+					saipb.QueueStat_QUEUE_STAT_PACKETS,
+					saipb.QueueStat_QUEUE_STAT_BYTES,
+					saipb.QueueStat_QUEUE_STAT_DROPPED_PACKETS,
+					saipb.QueueStat_QUEUE_STAT_DROPPED_BYTES,
+				},
+			})
+			if err != nil || len(resp.GetValues()) != 4 {
+				log.Errorf("qos handler: could not retrieve counters of queue %q on interface %q: %v", name, id, err)
+				continue
+			}
+			q := intf.Output().Queue(name)
+			gnmiclient.BatchUpdate(sb, q.Name().State(), name)
+			gnmiclient.BatchUpdate(sb, q.TransmitPkts().State(), resp.GetValues()[0])
+			gnmiclient.BatchUpdate(sb, q.TransmitOctets().State(), resp.GetValues()[1])
+			gnmiclient.BatchUpdate(sb, q.DroppedPkts().State(), resp.GetValues()[2])
+			gnmiclient.BatchUpdate(sb, q.DroppedOctets().State(), resp.GetValues()[3])
+		}
+	}
+	return sb
+}
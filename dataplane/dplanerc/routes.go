@@ -91,27 +91,7 @@ func (rec *Reconciler) StartRoute(ctx context.Context, client *ygnmi.Client) err
 		if !present {
 			// Remove NextHop or NextHopGroup.
 			if routeData := rec.ocRouteData.findRoute(prefixStr, entry.GetVrId()); routeData != nil {
-				if routeData.isNHG {
-					log.Infof("removing next hop group")
-					for nhgID, nhs := range routeData.nhg {
-						for nhID, memberID := range nhs {
-							if err := rec.removeNextHopGroupMember(ctx, memberID); err != nil {
-								log.Warningf("failed to delete next hop group member: %v", err)
-							}
-							if err := rec.removeNextHop(ctx, nhID); err != nil {
-								log.Warningf("failed to delete next hop: %v", err)
-							}
-						}
-						if err := rec.removeNextHopGroup(ctx, nhgID); err != nil {
-							log.Warningf("failed to delete next hop group: %v", err)
-						}
-					}
-				} else {
-					log.Infof("removing next hop.")
-					if err := rec.removeNextHop(ctx, routeData.nh); err != nil {
-						log.Warningf("failed to delete next hop: %v", err)
-					}
-				}
+				rec.removeNextHops(ctx, routeData)
 			}
 
 			log.Infof("removing route: %v", prefix)
@@ -139,46 +119,12 @@ func (rec *Reconciler) StartRoute(ctx context.Context, client *ygnmi.Client) err
 			log.Infof("added connected route: %v", &rReq)
 			return ygnmi.Continue
 		}
-		var hopID uint64
-		routeKey := ocRoute{prefix: prefixStr, vrf: entry.GetVrId()}
-		if len(route.GetNextHops().GetHops()) == 1 {
-			hopID, err = rec.createNextHop(ctx, route.GetNextHops().Hops[0])
-			if err != nil {
-				log.Warningf("failed to create next hop: %v", err)
-				return ygnmi.Continue
-			}
-			rec.ocRouteData[routeKey] = &routeData{nh: hopID}
-		} else {
-			group, err := rec.nextHopGroupClient.CreateNextHopGroup(ctx, &saipb.CreateNextHopGroupRequest{
-				Switch: rec.switchID,
-				Type:   saipb.NextHopGroupType_NEXT_HOP_GROUP_TYPE_DYNAMIC_UNORDERED_ECMP.Enum(),
-			})
-			hopID = group.Oid
-			if err != nil {
-				log.Warningf("failed to create next hop group: %v", err)
-				return ygnmi.Continue
-			}
-			rd := &routeData{isNHG: true, nhg: map[uint64]map[uint64]uint64{hopID: {}}}
-			for i, nh := range route.GetNextHops().GetHops() {
-				hID, err := rec.createNextHop(ctx, nh)
-				if err != nil {
-					log.Warningf("failed to create next hop: %v", err)
-					return ygnmi.Continue
-				}
-				resp, err := rec.nextHopGroupClient.CreateNextHopGroupMember(ctx, &saipb.CreateNextHopGroupMemberRequest{
-					Switch:         rec.switchID,
-					NextHopGroupId: &group.Oid,
-					NextHopId:      &hID,
-					Weight:         proto.Uint32(uint32(route.GetNextHops().Weights[i])),
-				})
-				if err != nil {
-					log.Warningf("failed to create next group member: %v", err)
-					return ygnmi.Continue
-				}
-				rd.nhg[hopID][hID] = resp.Oid
-			}
-			rec.ocRouteData[routeKey] = rd
+		hopID, rd, err := rec.createNextHops(ctx, route.GetNextHops(), saipb.OutsegType_OUTSEG_TYPE_PUSH)
+		if err != nil {
+			log.Warningf("failed to create next hops: %v", err)
+			return ygnmi.Continue
 		}
+		rec.ocRouteData[ocRoute{prefix: prefixStr, vrf: entry.GetVrId()}] = rd
 		rReq.NextHopId = proto.Uint64(hopID)
 		if _, err := rec.routeClient.CreateRouteEntry(ctx, &rReq); err != nil {
 			log.Warningf("failed to create route: %v", err)
@@ -198,7 +144,72 @@ func (rec *Reconciler) StartRoute(ctx context.Context, client *ygnmi.Client) err
 	return nil
 }
 
-func (ni *Reconciler) createNextHop(ctx context.Context, hop *dpb.NextHop) (uint64, error) {
+// createNextHops creates a next hop, or a next hop group if there are multiple hops,
+// returning the OID to use as the next hop of an entry and the created objects.
+func (rec *Reconciler) createNextHops(ctx context.Context, hops *dpb.NextHopList, outseg saipb.OutsegType) (uint64, *routeData, error) {
+	if len(hops.GetHops()) == 1 {
+		hopID, err := rec.createNextHop(ctx, hops.GetHops()[0], outseg)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create next hop: %v", err)
+		}
+		return hopID, &routeData{nh: hopID}, nil
+	}
+	group, err := rec.nextHopGroupClient.CreateNextHopGroup(ctx, &saipb.CreateNextHopGroupRequest{
+		Switch: rec.switchID,
+		Type:   saipb.NextHopGroupType_NEXT_HOP_GROUP_TYPE_DYNAMIC_UNORDERED_ECMP.Enum(),
+	})
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create next hop group: %v", err)
+	}
+	hopID := group.Oid
+	rd := &routeData{isNHG: true, nhg: map[uint64]map[uint64]uint64{hopID: {}}}
+	for i, nh := range hops.GetHops() {
+		hID, err := rec.createNextHop(ctx, nh, outseg)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create next hop: %v", err)
+		}
+		resp, err := rec.nextHopGroupClient.CreateNextHopGroupMember(ctx, &saipb.CreateNextHopGroupMemberRequest{
+			Switch:         rec.switchID,
+			NextHopGroupId: &group.Oid,
+			NextHopId:      &hID,
+			Weight:         proto.Uint32(uint32(hops.Weights[i])),
+		})
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to create next group member: %v", err)
+		}
+		rd.nhg[hopID][hID] = resp.Oid
+	}
+	return hopID, rd, nil
+}
+
+// removeNextHops removes the next hop or next hop group created by createNextHops.
+func (rec *Reconciler) removeNextHops(ctx context.Context, rd *routeData) {
+	if rd.isNHG {
+		log.Infof("removing next hop group")
+		for nhgID, nhs := range rd.nhg {
+			for nhID, memberID := range nhs {
+				if err := rec.removeNextHopGroupMember(ctx, memberID); err != nil {
+					log.Warningf("failed to delete next hop group member: %v", err)
+				}
+				if err := rec.removeNextHop(ctx, nhID); err != nil {
+					log.Warningf("failed to delete next hop: %v", err)
+				}
+			}
+			if err := rec.removeNextHopGroup(ctx, nhgID); err != nil {
+				log.Warningf("failed to delete next hop group: %v", err)
+			}
+		}
+		return
+	}
+	log.Infof("removing next hop.")
+	if err := rec.removeNextHop(ctx, rd.nh); err != nil {
+		log.Warningf("failed to delete next hop: %v", err)
+	}
+}
+
+// createNextHop creates a next hop. If the hop only has MPLS encap headers,
+// an MPLS next hop with the given outseg type is created.
+func (ni *Reconciler) createNextHop(ctx context.Context, hop *dpb.NextHop, outseg saipb.OutsegType) (uint64, error) {
 	ip, err := netip.ParseAddr(hop.GetNextHopIp())
 	if err != nil {
 		return 0, err
@@ -210,11 +221,21 @@ func (ni *Reconciler) createNextHop(ctx context.Context, hop *dpb.NextHop) (uint
 		Ip:                ip.AsSlice(),
 		RouterInterfaceId: proto.Uint64(data.rifID),
 	}
+	labels, isMPLS := labelStack(hop.GetHeaders().GetHeaders())
+	if isMPLS {
+		hopReq.Type = saipb.NextHopType_NEXT_HOP_TYPE_MPLS.Enum()
+		hopReq.Labelstack = labels
+		hopReq.OutsegType = outseg.Enum()
+		hopReq.OutsegTtlMode = saipb.OutsegTtlMode_OUTSEG_TTL_MODE_PIPE.Enum()
+	}
 	resp, err := ni.nextHopClient.CreateNextHop(ctx, &hopReq)
 	if err != nil {
 		return 0, err
 	}
 	log.Infof("created next hop: %v", &hopReq)
+	if isMPLS {
+		return resp.Oid, nil
+	}
 	if hop.GetGue() != nil {
 		acts, err := gueActions(hop.GetGue())
 		if err != nil {
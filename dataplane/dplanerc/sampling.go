@@ -0,0 +1,398 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dplanerc
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"time"
+
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/dataplane/protocol/sflow"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	log "github.com/golang/glog"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+)
+
+// samplingDirection is the samplepacket bound to a port in a direction.
+type samplingDirection struct {
+	oid  uint64 // 0 if the direction is not sampled
+	rate uint32
+}
+
+// samplingPort is the sampling programming of a port in the dataplane.
+type samplingPort struct {
+	name    string // the name of the interface
+	ingress samplingDirection
+	egress  samplingDirection
+}
+
+// samplingState is the state of the sampling handler.
+type samplingState struct {
+	agent      *sflow.Agent
+	config     *oc.Sampling_Sflow
+	ports      map[uint64]*samplingPort // keyed by port id
+	collectors map[netip.AddrPort]oc.Sampling_Sflow_Collector_Key
+	applied    *sflow.Config // nil if the agent is disabled
+	// The interfaces and collectors whose state was last published, so that the
+	// state of those no longer sampled or configured is deleted.
+	publishedIntfs      map[string]bool
+	publishedCollectors map[oc.Sampling_Sflow_Collector_Key]bool
+}
+
+// StartSampling starts the sampling handler, which samples the packets of the interfaces
+// configured in /sampling/sflow and exports them with the sFlow agent.
+func (rec *Reconciler) StartSampling(ctx context.Context, client *ygnmi.Client, agent *sflow.Agent) error {
+	log.Info("starting sampling handler")
+	rec.samplingMu.Lock()
+	rec.sampling = &samplingState{
+		agent:      agent,
+		ports:      map[uint64]*samplingPort{},
+		collectors: map[netip.AddrPort]oc.Sampling_Sflow_Collector_Key{},
+	}
+	rec.samplingMu.Unlock()
+
+	ctx, cancelFn := context.WithCancel(ctx)
+	rec.closers = append(rec.closers, cancelFn, func() {
+		if err := agent.Configure(nil); err != nil {
+			log.Warningf("failed to stop sflow agent: %v", err)
+		}
+	})
+
+	w := ygnmi.Watch(ctx, client, ocpath.Root().Sampling().Sflow().Config(), func(v *ygnmi.Value[*oc.Sampling_Sflow]) error {
+		cfg, _ := v.Val()
+		rec.samplingMu.Lock()
+		defer rec.samplingMu.Unlock()
+		rec.sampling.config = cfg
+		rec.reconcileSampling(ctx)
+		return ygnmi.Continue
+	})
+	go func() {
+		if _, err := w.Await(); err != nil {
+			log.Warningf("sampling watcher has stopped: %v", err)
+		}
+	}()
+
+	tick := time.NewTicker(time.Second)
+	rec.closers = append(rec.closers, tick.Stop)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C:
+			}
+			rec.samplingMu.Lock()
+			// Retry the interfaces that did not exist yet or failed to be programmed.
+			rec.reconcileSampling(ctx)
+			sb := rec.samplingCounterBatch()
+			rec.samplingMu.Unlock()
+			if _, err := sb.Set(ctx, client); err != nil {
+				log.Errorf("sampling handler: %v", err)
+			}
+		}
+	}()
+	return nil
+}
+
+// reconcileSampling programs the sampling rates of the interfaces and configures the agent.
+// The caller must hold samplingMu.
+func (rec *Reconciler) reconcileSampling(ctx context.Context) {
+	cfg := rec.sampling.config
+	if cfg == nil {
+		cfg = &oc.Sampling_Sflow{}
+	}
+	agentCfg := &sflow.Config{
+		SampleSize:      int(cfg.GetSampleSize()),
+		PollingInterval: time.Duration(cfg.GetPollingInterval()) * time.Second,
+		Interfaces:      map[uint64]*sflow.Interface{},
+	}
+	collectors := map[netip.AddrPort]oc.Sampling_Sflow_Collector_Key{}
+	for k, c := range cfg.Collector {
+		addr, err := netip.ParseAddr(c.GetAddress())
+		if err != nil {
+			log.Warningf("invalid sflow collector address %q: %v", c.GetAddress(), err)
+			continue
+		}
+		ap := netip.AddrPortFrom(addr, c.GetPort())
+		agentCfg.Collectors = append(agentCfg.Collectors, ap)
+		collectors[ap] = k
+	}
+	rec.sampling.collectors = collectors
+
+	want := map[uint64]*samplingPort{}
+	exists := map[uint64]bool{}
+	rec.stateMu.RLock()
+	for _, data := range rec.ocInterfaceData {
+		if !data.isAggregate {
+			exists[data.portID] = true
+		}
+	}
+	for name, intf := range cfg.Interface {
+		if !cfg.GetEnabled() || !intf.GetEnabled() {
+			continue
+		}
+		// Sampling is only supported on ports, not on aggregates.
+		data, ok := rec.ocInterfaceData[ocInterface{name: name}]
+		if !ok || data.isAggregate {
+			continue
+		}
+		p := &samplingPort{
+			name:    name,
+			ingress: samplingDirection{rate: cfg.GetIngressSamplingRate()},
+			egress:  samplingDirection{rate: cfg.GetEgressSamplingRate()},
+		}
+		if intf.IngressSamplingRate != nil {
+			p.ingress.rate = intf.GetIngressSamplingRate()
+		}
+		if intf.EgressSamplingRate != nil {
+			p.egress.rate = intf.GetEgressSamplingRate()
+		}
+		want[data.portID] = p
+		agentCfg.Interfaces[data.portID] = &sflow.Interface{
+			Index:       uint32(data.hostifIfIndex),
+			IngressRate: p.ingress.rate,
+			EgressRate:  p.egress.rate,
+		}
+	}
+	rec.stateMu.RUnlock()
+
+	for portID, p := range rec.sampling.ports {
+		if _, ok := want[portID]; ok {
+			continue
+		}
+		// The port was removed from the dataplane, so only its samplepackets are left to remove.
+		if !exists[portID] {
+			rec.removeSamplepackets(ctx, p)
+			delete(rec.sampling.ports, portID)
+			continue
+		}
+		if err := rec.applySamplingPort(ctx, portID, p, &samplingPort{name: p.name}); err != nil {
+			log.Warningf("failed to reset sampling of interface %q: %v", p.name, err)
+			continue
+		}
+		delete(rec.sampling.ports, portID)
+	}
+	for portID, w := range want {
+		p, ok := rec.sampling.ports[portID]
+		if !ok {
+			p = &samplingPort{name: w.name}
+			rec.sampling.ports[portID] = p
+		}
+		if err := rec.applySamplingPort(ctx, portID, p, w); err != nil {
+			log.Warningf("failed to program sampling of interface %q: %v", w.name, err)
+		}
+	}
+
+	if !cfg.GetEnabled() {
+		agentCfg = nil
+	} else {
+		agent := cfg.GetAgentIdIpv4()
+		if agent == "" {
+			agent = cfg.GetAgentIdIpv6()
+		}
+		if addr, err := netip.ParseAddr(agent); err != nil {
+			log.Warningf("invalid sflow agent id %q: %v", agent, err)
+			agentCfg = nil
+		} else {
+			agentCfg.Agent = addr
+		}
+	}
+	// Reconfiguring the agent restarts the polling of the counters, so it is only
+	// reconfigured when the configuration changes.
+	if reflect.DeepEqual(agentCfg, rec.sampling.applied) {
+		return
+	}
+	if err := rec.sampling.agent.Configure(agentCfg); err != nil {
+		log.Warningf("failed to configure sflow agent: %v", err)
+		return
+	}
+	rec.sampling.applied = agentCfg
+}
+
+// applySamplingPort programs the sampling rates of the port in both directions.
+func (rec *Reconciler) applySamplingPort(ctx context.Context, portID uint64, p, want *samplingPort) error {
+	if err := rec.applySamplingDirection(ctx, portID, &p.ingress, want.ingress.rate, true); err != nil {
+		return fmt.Errorf("ingress: %v", err)
+	}
+	if err := rec.applySamplingDirection(ctx, portID, &p.egress, want.egress.rate, false); err != nil {
+		return fmt.Errorf("egress: %v", err)
+	}
+	return nil
+}
+
+// applySamplingDirection samples the packets of the port in the direction at the rate,
+// creating a samplepacket the first time the direction is sampled and removing it
+// when the rate is 0.
+func (rec *Reconciler) applySamplingDirection(ctx context.Context, portID uint64, d *samplingDirection, rate uint32, ingress bool) error {
+	if d.rate == rate && (d.oid != 0) == (rate != 0) {
+		return nil
+	}
+	bind := func(oid uint64) error {
+		req := &saipb.SetPortAttributeRequest{Oid: portID}
+		if ingress {
+			req.IngressSamplepacketEnable = proto.Uint64(oid)
+		} else {
+			req.EgressSamplepacketEnable = proto.Uint64(oid)
+		}
+		_, err := rec.portClient.SetPortAttribute(ctx, req)
+		return err
+	}
+	if rate == 0 {
+		if err := bind(0); err != nil {
+			return err
+		}
+		if _, err := rec.samplepacketClient.RemoveSamplepacket(ctx, &saipb.RemoveSamplepacketRequest{Oid: d.oid}); err != nil {
+			return err
+		}
+		*d = samplingDirection{}
+		return nil
+	}
+	if d.oid != 0 {
+		if _, err := rec.samplepacketClient.SetSamplepacketAttribute(ctx, &saipb.SetSamplepacketAttributeRequest{Oid: d.oid, SampleRate: proto.Uint32(rate)}); err != nil {
+			return err
+		}
+		d.rate = rate
+		return nil
+	}
+	resp, err := rec.samplepacketClient.CreateSamplepacket(ctx, &saipb.CreateSamplepacketRequest{
+		Switch:     rec.switchID,
+		SampleRate: proto.Uint32(rate),
+		Type:       saipb.SamplepacketType_SAMPLEPACKET_TYPE_SLOW_PATH.Enum(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := bind(resp.GetOid()); err != nil {
+		return err
+	}
+	*d = samplingDirection{oid: resp.GetOid(), rate: rate}
+	return nil
+}
+
+// removeSamplepackets removes the samplepackets of a port that no longer exists.
+func (rec *Reconciler) removeSamplepackets(ctx context.Context, p *samplingPort) {
+	for _, d := range []samplingDirection{p.ingress, p.egress} {
+		if d.oid == 0 {
+			continue
+		}
+		if _, err := rec.samplepacketClient.RemoveSamplepacket(ctx, &saipb.RemoveSamplepacketRequest{Oid: d.oid}); err != nil {
+			log.Warningf("failed to remove samplepacket of interface %q: %v", p.name, err)
+		}
+	}
+}
+
+// samplingCounterBatch returns a batch that updates the packets sampled on the interfaces
+// and the datagrams sent to the collectors, and deletes the state of the interfaces and
+// collectors that were removed. The caller must hold samplingMu.
+func (rec *Reconciler) samplingCounterBatch() *ygnmi.SetBatch {
+	sb := &ygnmi.SetBatch{}
+	sflowPath := ocpath.Root().Sampling().Sflow()
+	intfs := map[string]bool{}
+	for portID, p := range rec.sampling.ports {
+		intf := sflowPath.Interface(p.name)
+		gnmiclient.BatchUpdate(sb, intf.Name().State(), p.name)
+		gnmiclient.BatchUpdate(sb, intf.PacketsSampled().State(), rec.sampling.agent.PacketsSampled(portID))
+		intfs[p.name] = true
+	}
+	for name := range rec.sampling.publishedIntfs {
+		if !intfs[name] {
+			gnmiclient.BatchDelete(sb, sflowPath.Interface(name).State())
+		}
+	}
+	rec.sampling.publishedIntfs = intfs
+
+	collectors := map[oc.Sampling_Sflow_Collector_Key]bool{}
+	for ap, k := range rec.sampling.collectors {
+		c := sflowPath.Collector(k.Address, k.Port)
+		gnmiclient.BatchUpdate(sb, c.Address().State(), k.Address)
+		gnmiclient.BatchUpdate(sb, c.Port().State(), k.Port)
+		gnmiclient.BatchUpdate(sb, c.PacketsSent().State(), rec.sampling.agent.PacketsSent(ap))
+		collectors[k] = true
+	}
+	for k := range rec.sampling.publishedCollectors {
+		if !collectors[k] {
+			gnmiclient.BatchDelete(sb, sflowPath.Collector(k.Address, k.Port).State())
+		}
+	}
+	rec.sampling.publishedCollectors = collectors
+	return sb
+}
+
+// SFlowCounters returns the counters of the port exported by the sFlow agent.
+func (rec *Reconciler) SFlowCounters(portID uint64) (*sflow.Counters, error) {
+	ctx := context.Background()
+	attr, err := rec.portClient.GetPortAttribute(ctx, &saipb.GetPortAttributeRequest{
+		Oid: portID,
+		AttrType: []saipb.PortAttr{
+			saipb.PortAttr_PORT_ATTR_ADMIN_STATE,
+			saipb.PortAttr_PORT_ATTR_OPER_STATUS,
+			saipb.PortAttr_PORT_ATTR_OPER_SPEED,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	stats, err := rec.portClient.GetPortStats(ctx, &saipb.GetPortStatsRequest{
+		Oid: portID,
+		CounterIds: []saipb.PortStat{
+			saipb.PortStat_PORT_STAT_IF_IN_OCTETS,          // 0
+			saipb.PortStat_PORT_STAT_IF_IN_UCAST_PKTS,      // 1
+			saipb.PortStat_PORT_STAT_IF_IN_MULTICAST_PKTS,  // 2
+			saipb.PortStat_PORT_STAT_IF_IN_BROADCAST_PKTS,  // 3
+			saipb.PortStat_PORT_STAT_IF_IN_DISCARDS,        // 4
+			saipb.PortStat_PORT_STAT_IF_IN_ERRORS,          // 5
+			saipb.PortStat_PORT_STAT_IF_OUT_OCTETS,         // 6
+			saipb.PortStat_PORT_STAT_IF_OUT_UCAST_PKTS,     // 7
+			saipb.PortStat_PORT_STAT_IF_OUT_MULTICAST_PKTS, // 8
+			saipb.PortStat_PORT_STAT_IF_OUT_BROADCAST_PKTS, // 9
+			saipb.PortStat_PORT_STAT_IF_OUT_DISCARDS,       // 10
+			saipb.PortStat_PORT_STAT_IF_OUT_ERRORS,         // 11
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	v := stats.GetValues()
+	if len(v) != 12 {
+		return nil, fmt.Errorf("got %d port counters, want 12", len(v))
+	}
+	return &sflow.Counters{
+		// The SAI speed is in Mbps.
+		Speed:            uint64(attr.GetAttr().GetOperSpeed()) * 1000000,
+		AdminUp:          attr.GetAttr().GetAdminState(),
+		OperUp:           attr.GetAttr().GetOperStatus() == saipb.PortOperStatus_PORT_OPER_STATUS_UP,
+		InOctets:         v[0],
+		InUnicastPkts:    v[1],
+		InMulticastPkts:  v[2],
+		InBroadcastPkts:  v[3],
+		InDiscards:       v[4],
+		InErrors:         v[5],
+		OutOctets:        v[6],
+		OutUnicastPkts:   v[7],
+		OutMulticastPkts: v[8],
+		OutBroadcastPkts: v[9],
+		OutDiscards:      v[10],
+		OutErrors:        v[11],
+	}, nil
+}
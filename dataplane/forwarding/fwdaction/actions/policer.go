@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdcontext"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdobject"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdpacket"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// A policer is an action that meters packets with a color blind three color
+// marker and continues with the actions of the color of the packet.
+//
+// The marker uses two token buckets, implemented by the ratelimit action.
+// In the single rate marker (RFC 2697), the committed bucket is filled at
+// the committed rate and its overflow fills the excess bucket. In the two
+// rate marker (RFC 2698), the committed bucket is filled at the committed
+// rate and the peak bucket is filled at the peak rate. Both buckets are full
+// when the first packet arrives.
+type policer struct {
+	mode      fwdpb.PolicerMode
+	packets   bool       // true if the buckets count packets instead of bytes
+	committed *ratelimit // committed bucket
+	peak      *ratelimit // excess bucket for SR_TCM, peak bucket for TR_TCM
+	clock     func() time.Time
+
+	mu                 sync.Mutex
+	green, yellow, red fwdaction.Actions
+}
+
+// String formats the state of the action as a string.
+func (p *policer) String() string {
+	return fmt.Sprintf("Type=%v;Mode=%v;Packets=%v;<Committed=%v>;<Peak=%v>;<Green=%v>;<Yellow=%v>;<Red=%v>;", fwdpb.ActionType_ACTION_TYPE_POLICER, p.mode, p.packets, p.committed, p.peak, p.green, p.yellow, p.red)
+}
+
+// Cleanup releases references held by the actions of the colors.
+func (p *policer) Cleanup() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, a := range []fwdaction.Actions{p.green, p.yellow, p.red} {
+		a.Cleanup()
+	}
+	p.green, p.yellow, p.red = nil, nil, nil
+}
+
+// color returns the actions of the color of a packet of the specified size.
+func (p *policer) color(size uint64) fwdaction.Actions {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.committed.mu.Lock()
+	defer p.committed.mu.Unlock()
+	p.peak.mu.Lock()
+	defer p.peak.mu.Unlock()
+
+	now := p.clock()
+	if p.mode == fwdpb.PolicerMode_POLICER_MODE_TR_TCM {
+		p.committed.fill(now)
+		p.peak.fill(now)
+		switch {
+		case !p.peak.take(size):
+			return p.red
+		case !p.committed.take(size):
+			return p.yellow
+		default:
+			return p.green
+		}
+	}
+	p.peak.fill(now) // the excess bucket has no rate of its own
+	p.peak.add(p.committed.fill(now))
+	switch {
+	case p.committed.take(size):
+		return p.green
+	case p.peak.take(size):
+		return p.yellow
+	default:
+		return p.red
+	}
+}
+
+// Process meters the packet and continues with the actions of its color.
+func (p *policer) Process(packet fwdpacket.Packet, _ fwdobject.Counters) (fwdaction.Actions, fwdaction.State) {
+	size := uint64(1)
+	if !p.packets {
+		size = uint64(packet.Length())
+	}
+	a := p.color(size)
+	packet.Log().V(3).Info("policer actions", "actions", a)
+	return a, fwdaction.CONTINUE
+}
+
+// A policerBuilder builds policer actions.
+type policerBuilder struct{}
+
+// init registers a builder for the policer action type.
+func init() {
+	fwdaction.Register(fwdpb.ActionType_ACTION_TYPE_POLICER, &policerBuilder{})
+}
+
+// Build creates a new policer action.
+func (*policerBuilder) Build(desc *fwdpb.ActionDesc, ctx *fwdcontext.Context) (fwdaction.Action, error) {
+	pd, ok := desc.Action.(*fwdpb.ActionDesc_Policer)
+	if !ok {
+		return nil, fmt.Errorf("actions: Build for policer action failed, missing desc")
+	}
+	d := pd.Policer
+	p := &policer{
+		mode:      d.GetMode(),
+		packets:   d.GetPackets(),
+		committed: &ratelimit{rate: d.GetCir(), burst: d.GetCbs()},
+		clock:     time.Now,
+	}
+	switch d.GetMode() {
+	case fwdpb.PolicerMode_POLICER_MODE_SR_TCM:
+		p.peak = &ratelimit{burst: d.GetPbs()}
+	case fwdpb.PolicerMode_POLICER_MODE_TR_TCM:
+		if d.GetPir() < d.GetCir() {
+			return nil, fmt.Errorf("actions: Build for policer action failed, peak rate %v is less than committed rate %v", d.GetPir(), d.GetCir())
+		}
+		p.peak = &ratelimit{rate: d.GetPir(), burst: d.GetPbs()}
+	default:
+		return nil, fmt.Errorf("actions: Build for policer action failed, unsupported mode %v", d.GetMode())
+	}
+
+	var err error
+	if p.green, err = fwdaction.NewActions(d.GetGreenActions(), ctx); err != nil {
+		return nil, fmt.Errorf("actions: Build for policer action failed, err %v", err)
+	}
+	if p.yellow, err = fwdaction.NewActions(d.GetYellowActions(), ctx); err != nil {
+		p.green.Cleanup()
+		return nil, fmt.Errorf("actions: Build for policer action failed, err %v", err)
+	}
+	if p.red, err = fwdaction.NewActions(d.GetRedActions(), ctx); err != nil {
+		p.green.Cleanup()
+		p.yellow.Cleanup()
+		return nil, fmt.Errorf("actions: Build for policer action failed, err %v", err)
+	}
+	return p, nil
+}
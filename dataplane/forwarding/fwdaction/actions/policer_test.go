@@ -0,0 +1,152 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction/mock_fwdpacket"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdcontext"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdobject"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// TestPolicer tests the policer action and builder.
+func TestPolicer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := fwdcontext.New("test", "fwd")
+
+	// The colors are identified by the number of actions in their list.
+	drop := &fwdpb.ActionDesc{ActionType: fwdpb.ActionType_ACTION_TYPE_DROP}
+	colors := map[int]string{0: "green", 1: "yellow", 2: "red"}
+
+	type packet struct {
+		length   int           // Packet length in bytes
+		duration time.Duration // Time elapsed since the first packet
+		color    string        // Expected color of the packet
+	}
+
+	tests := []struct {
+		desc    string
+		policer *fwdpb.PolicerActionDesc
+		packets []packet
+		wantErr bool
+	}{{
+		desc: "srTCM",
+		policer: &fwdpb.PolicerActionDesc{
+			Mode: fwdpb.PolicerMode_POLICER_MODE_SR_TCM,
+			Cir:  1000,
+			Cbs:  100,
+			Pbs:  100,
+		},
+		packets: []packet{
+			{length: 100, duration: 0, color: "green"},
+			{length: 100, duration: 0, color: "yellow"},
+			{length: 1, duration: 0, color: "red"},
+			{length: 100, duration: 100 * time.Millisecond, color: "green"},
+			{length: 100, duration: 300 * time.Millisecond, color: "green"},
+			{length: 100, duration: 300 * time.Millisecond, color: "yellow"},
+			{length: 1, duration: 300 * time.Millisecond, color: "red"},
+		},
+	}, {
+		desc: "trTCM",
+		policer: &fwdpb.PolicerActionDesc{
+			Mode: fwdpb.PolicerMode_POLICER_MODE_TR_TCM,
+			Cir:  1000,
+			Cbs:  100,
+			Pir:  2000,
+			Pbs:  200,
+		},
+		packets: []packet{
+			{length: 150, duration: 0, color: "yellow"},
+			{length: 50, duration: 0, color: "green"},
+			{length: 1, duration: 0, color: "red"},
+			{length: 100, duration: 100 * time.Millisecond, color: "green"},
+			{length: 100, duration: 100 * time.Millisecond, color: "yellow"},
+			{length: 1, duration: 100 * time.Millisecond, color: "red"},
+		},
+	}, {
+		desc: "srTCM in packets",
+		policer: &fwdpb.PolicerActionDesc{
+			Mode:    fwdpb.PolicerMode_POLICER_MODE_SR_TCM,
+			Packets: true,
+			Cir:     10,
+			Cbs:     1,
+		},
+		packets: []packet{
+			{length: 1000, duration: 0, color: "green"},
+			{length: 1000, duration: 0, color: "red"},
+			{length: 1000, duration: 100 * time.Millisecond, color: "green"},
+		},
+	}, {
+		desc: "peak rate less than committed rate",
+		policer: &fwdpb.PolicerActionDesc{
+			Mode: fwdpb.PolicerMode_POLICER_MODE_TR_TCM,
+			Cir:  2000,
+			Pir:  1000,
+		},
+		wantErr: true,
+	}, {
+		desc:    "unspecified mode",
+		policer: &fwdpb.PolicerActionDesc{},
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			test.policer.YellowActions = []*fwdpb.ActionDesc{drop}
+			test.policer.RedActions = []*fwdpb.ActionDesc{drop, drop}
+			desc := &fwdpb.ActionDesc{
+				ActionType: fwdpb.ActionType_ACTION_TYPE_POLICER,
+				Action: &fwdpb.ActionDesc_Policer{
+					Policer: test.policer,
+				},
+			}
+			action, err := fwdaction.New(desc, ctx)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("New(%v) got err %v, want err %v", desc, err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer action.(fwdobject.Composite).Cleanup()
+
+			// Change the policer action's clock function.
+			p := action.(*policer)
+			now := time.Now()
+			for pos, pkt := range test.packets {
+				p.clock = func() time.Time {
+					return now.Add(pkt.duration)
+				}
+				packet := mock_fwdpacket.NewMockPacket(ctrl)
+				packet.EXPECT().Length().Return(pkt.length).AnyTimes()
+				packet.EXPECT().Log().Return(testr.New(t)).AnyTimes()
+				next, state := action.Process(packet, nil)
+				if state != fwdaction.CONTINUE {
+					t.Errorf("%d: Process() got state %v, want %v", pos, state, fwdaction.CONTINUE)
+				}
+				if got := colors[len(next)]; got != pkt.color {
+					t.Errorf("%d: Process() got color %q, want %q", pos, got, pkt.color)
+				}
+			}
+		})
+	}
+}
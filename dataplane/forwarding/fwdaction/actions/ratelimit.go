@@ -16,6 +16,8 @@ package actions
 
 import (
 	"fmt"
+	"math"
+	"math/bits"
 	"sync"
 	"time"
 
@@ -104,6 +106,56 @@ func (r *ratelimit) Allowed(length uint64) bool {
 	return true
 }
 
+// fill adds the tokens accumulated since the last fill to the bucket and
+// returns the number of tokens that did not fit in the bucket.
+// The caller must hold the lock.
+func (r *ratelimit) fill(now time.Time) uint64 {
+	if !r.running {
+		r.tokens = r.burst
+		r.last = now
+		r.running = true
+		return 0
+	}
+	interval := now.Sub(r.last)
+	if interval <= 0 {
+		return 0
+	}
+	// Saturate instead of overflowing when the bucket is idle for a long time.
+	tokens := uint64(math.MaxUint64)
+	if hi, lo := bits.Mul64(r.rate, uint64(interval)); hi < uint64(time.Second) {
+		tokens, _ = bits.Div64(hi, lo, uint64(time.Second))
+	}
+	if tokens == 0 {
+		return 0
+	}
+	r.last = now
+	r.update.tokens = tokens
+	r.update.interval = interval
+	return r.add(tokens)
+}
+
+// add adds tokens to the bucket and returns the number of tokens that did
+// not fit in the bucket. The caller must hold the lock.
+func (r *ratelimit) add(tokens uint64) uint64 {
+	if tokens <= r.burst-r.tokens {
+		r.tokens += tokens
+		return 0
+	}
+	overflow := tokens - (r.burst - r.tokens)
+	r.tokens = r.burst
+	return overflow
+}
+
+// take consumes tokens from the bucket and returns true if the bucket had
+// sufficient tokens. The caller must hold the lock.
+func (r *ratelimit) take(tokens uint64) bool {
+	if tokens > r.tokens {
+		return false
+	}
+	r.tokens -= tokens
+	return true
+}
+
 // Process allows packet processing to continue if the bucket has sufficient
 // tokens. If the attribute "RatelimitAdvisory" is set, the packet is not
 // actually dropped, but only counted as ratelimited.
@@ -124,6 +176,24 @@ func (r *ratelimit) Process(packet fwdpacket.Packet, counters fwdobject.Counters
 	return nil, fwdaction.CONTINUE
 }
 
+// A TokenBucket limits the rate of packets using a token bucket.
+type TokenBucket interface {
+	// Allowed returns true if a packet of the specified length is allowed.
+	Allowed(length uint64) bool
+}
+
+// NewTokenBucket returns a token bucket that allows rate bytes per second
+// with bursts of up to burst bytes. It uses the same token bucket as the
+// ratelimit action, so that packets can be shaped outside of an action.
+func NewTokenBucket(rate, burst uint64) TokenBucket {
+	return &ratelimit{
+		last:  time.Now(),
+		burst: burst,
+		rate:  rate,
+		clock: time.Now,
+	}
+}
+
 // A ratelimitBuilder builds ratelimit actions.
 type ratelimitBuilder struct{}
 
@@ -29,6 +29,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
 
 	log "github.com/golang/glog"
 
@@ -104,6 +105,43 @@ type Port interface {
 	Desc() *fwdpb.PortDesc
 }
 
+// A Scheduler queues packets written to a port and transmits them out of the
+// port at a later time, e.g. to implement egress queuing.
+type Scheduler interface {
+	// Enqueue queues a packet for transmission. It returns fwdaction.CONSUME
+	// if the packet is queued and fwdaction.DROP if the packet is dropped.
+	Enqueue(packet fwdpacket.Packet) (fwdaction.State, error)
+}
+
+// schedulers is a map of ports to the schedulers of their outgoing packets.
+var (
+	schedulersMu sync.RWMutex
+	schedulers   = make(map[Port]Scheduler)
+)
+
+// SetScheduler sets the scheduler of the packets written to the port. If the
+// scheduler is nil, packets are written to the port directly.
+func SetScheduler(port Port, s Scheduler) {
+	schedulersMu.Lock()
+	defer schedulersMu.Unlock()
+	if s == nil {
+		delete(schedulers, port)
+		return
+	}
+	schedulers[port] = s
+}
+
+// write writes a packet to the port or queues it in the port's scheduler.
+func write(port Port, packet fwdpacket.Packet) (fwdaction.State, error) {
+	schedulersMu.RLock()
+	s, ok := schedulers[port]
+	schedulersMu.RUnlock()
+	if ok {
+		return s.Enqueue(packet)
+	}
+	return port.Write(packet)
+}
+
 // A Builder can build Ports of the specified type.
 type Builder interface {
 	// Build builds a port.
@@ -356,7 +394,7 @@ func Output(port Port, packet fwdpacket.Packet, dir fwdpb.PortAction, _ *fwdcont
 	packet.Log().V(3).Info("output packet", "frame", fwdpacket.IncludeFrameInLog)
 	state, err := fwdaction.ProcessPacket(packet, port.Actions(dir), port)
 	if err == nil && state == fwdaction.CONTINUE {
-		state, err = port.Write(packet)
+		state, err = write(port, packet)
 	}
 	if err != nil {
 		Increment(port, packet.Length(), fwdpb.CounterId_COUNTER_ID_TX_ERROR_PACKETS, fwdpb.CounterId_COUNTER_ID_TX_ERROR_OCTETS)
@@ -393,6 +431,16 @@ func Write(port Port, packet fwdpacket.Packet) {
 	}
 }
 
+// Transmit writes out a packet dequeued by the scheduler of a port. The packet
+// was counted when it was output, so only errors are counted.
+func Transmit(port Port, packet fwdpacket.Packet) {
+	packet.Log().V(1).Info("transmit packet", "port", port.ID(), "frame", fwdpacket.IncludeFrameInLog)
+	if _, err := port.Write(packet); err != nil {
+		packet.Log().Error(err, "transmit failed")
+		Increment(port, packet.Length(), fwdpb.CounterId_COUNTER_ID_TX_ERROR_PACKETS, fwdpb.CounterId_COUNTER_ID_TX_ERROR_OCTETS)
+	}
+}
+
 // Process processes a packet on the specified port, action direction and context.
 // If the attribute "PacketDebug" is set, then the packet debugging is enabled.
 func Process(port Port, packet fwdpacket.Packet, dir fwdpb.PortAction, ctx *fwdcontext.Context, prefix string) {
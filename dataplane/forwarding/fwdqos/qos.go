@@ -0,0 +1,391 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fwdqos implements egress queuing for lucius ports.
+//
+// A Scheduler is set as the scheduler of a port when it is created. Packets
+// output on the port are placed in one of the scheduler's queues using the
+// traffic class of the packet, which is carried in a packet attribute set
+// during classification. The scheduler transmits the queued packets in its
+// own goroutine, serving strict priority queues first (higher index first)
+// and the remaining queues using deficit weighted round robin. Each queue
+// can be shaped using a token bucket and can drop packets using WRED.
+// Packets are transmitted at the rate of the port, so that the queues build
+// up and the scheduling takes effect when the port is congested.
+package fwdqos
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction/actions"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdport"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdpacket"
+
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// TCInstance is the instance of the PACKET_ATTRIBUTE_8 field that carries
+// the traffic class of a packet.
+const TCInstance = 0
+
+// tcField is the packet field that carries the traffic class.
+var tcField = fwdpacket.NewFieldIDFromNum(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ATTRIBUTE_8, TCInstance)
+
+const (
+	// maxQueuePackets is the number of packets a queue holds before tail dropping.
+	maxQueuePackets = 1024
+	// maxFrameSize is the size of the largest frame, shaping bursts are
+	// never smaller than a frame so that shaped queues are not stuck.
+	maxFrameSize = 9216
+	// quantum is the number of bytes added to the deficit of a queue with
+	// weight 1 in each DWRR round.
+	quantum = 1500
+	// shapingInterval is the interval after which shaped queues are retried.
+	shapingInterval = time.Millisecond
+	// portBurstInterval is the interval of transmission at the port rate that
+	// the port can burst, so that pacing is not limited by the retry interval.
+	portBurstInterval = 10 * time.Millisecond
+)
+
+// SchedulingType is the scheduling discipline of a queue.
+type SchedulingType int
+
+const (
+	// DWRR queues share the bandwidth left by strict priority queues in
+	// proportion to their weight.
+	DWRR SchedulingType = iota
+	// StrictPriority queues are served before all DWRR queues.
+	StrictPriority
+)
+
+// WRED is a weighted random early detection profile. When the depth of a
+// queue is between the thresholds, packets are dropped with a probability
+// that grows linearly up to DropProbability percent. Packets are always
+// dropped when the depth is above the maximum threshold.
+type WRED struct {
+	MinThreshold    uint64 // minimum threshold in bytes
+	MaxThreshold    uint64 // maximum threshold in bytes
+	DropProbability uint32 // drop probability at the maximum threshold in percent
+}
+
+// QueueConfig is the configuration of a queue.
+type QueueConfig struct {
+	Type   SchedulingType
+	Weight uint64 // weight of a DWRR queue, 0 is treated as 1
+	// RateBps and BurstBytes shape the queue if the rate is not 0.
+	RateBps    uint64
+	BurstBytes uint64
+	WRED       *WRED // nil for tail drop only
+}
+
+// Stats are the counters of a queue.
+type Stats struct {
+	TxPackets   uint64
+	TxOctets    uint64
+	DropPackets uint64
+	DropOctets  uint64
+}
+
+// queue is a FIFO of packets with its configuration and counters.
+type queue struct {
+	config  QueueConfig
+	shaper  actions.TokenBucket // nil if the queue is not shaped
+	packets []fwdpacket.Packet
+	bytes   uint64 // sum of the length of the queued packets
+	deficit uint64
+	stats   Stats
+}
+
+// A Scheduler queues the packets output on a port and transmits them.
+type Scheduler struct {
+	port fwdport.Port
+	wake chan struct{}
+	done chan struct{}
+	rand func() float64 // function used to evaluate WRED drops
+
+	mu        sync.Mutex
+	queues    []*queue
+	tcToQueue map[uint8]int
+	rr        int  // DWRR queue being served
+	credited  bool // true if the DWRR queue being served got its quantum
+
+	// The port token bucket paces the transmission at the port rate. Its
+	// tokens may be negative after a packet larger than the available tokens.
+	now        func() time.Time // function used to refill the port tokens
+	portRate   uint64           // rate of the port in bytes per second, 0 if not paced
+	portBurst  int64
+	portTokens int64
+	portLast   time.Time
+}
+
+// NewScheduler creates a scheduler with the specified number of DWRR queues,
+// sets it as the scheduler of the port and starts transmitting packets.
+// Traffic class N is mapped to queue N until a map is set.
+func NewScheduler(port fwdport.Port, numQueues int) *Scheduler {
+	s := newScheduler(port, numQueues)
+	fwdport.SetScheduler(port, s)
+	go s.run()
+	return s
+}
+
+func newScheduler(port fwdport.Port, numQueues int) *Scheduler {
+	s := &Scheduler{
+		port:   port,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		rand:   rand.Float64,
+		now:    time.Now,
+		queues: make([]*queue, numQueues),
+	}
+	for i := range s.queues {
+		s.queues[i] = &queue{}
+	}
+	return s
+}
+
+// Stop removes the scheduler from the port and stops transmitting packets.
+// Packets that are still queued are lost.
+func (s *Scheduler) Stop() {
+	fwdport.SetScheduler(s.port, nil)
+	close(s.done)
+}
+
+// SetQueue configures the queue at the specified index.
+func (s *Scheduler) SetQueue(index int, config QueueConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.queues) {
+		return fmt.Errorf("fwdqos: queue %d out of range", index)
+	}
+	q := s.queues[index]
+	q.config = config
+	q.shaper = nil
+	if config.RateBps != 0 {
+		q.shaper = actions.NewTokenBucket(config.RateBps, max(config.BurstBytes, maxFrameSize))
+	}
+	return nil
+}
+
+// SetPortRate sets the rate of the port in bytes per second. Packets are
+// transmitted as fast as the port accepts them if the rate is 0.
+func (s *Scheduler) SetPortRate(rate uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.portRate = rate
+	s.portBurst = int64(max(rate*uint64(portBurstInterval)/uint64(time.Second), maxFrameSize))
+	s.portTokens = s.portBurst
+	s.portLast = s.now()
+}
+
+// portReady refills the port tokens and returns true if the port can transmit.
+// It is called with the lock held.
+func (s *Scheduler) portReady() bool {
+	if s.portRate == 0 {
+		return true
+	}
+	now := s.now()
+	if elapsed := now.Sub(s.portLast); elapsed > 0 {
+		tokens := s.portBurst
+		if elapsed < time.Second {
+			tokens = int64(s.portRate * uint64(elapsed) / uint64(time.Second))
+		}
+		// Keep the time of the last refill if no token was added, to not lose the fraction.
+		if tokens != 0 {
+			s.portTokens = min(s.portTokens+tokens, s.portBurst)
+			s.portLast = now
+		}
+	}
+	return s.portTokens > 0
+}
+
+// SetTCToQueue sets the map of traffic classes to queue indices. Traffic
+// classes that are not in the map use queue 0.
+func (s *Scheduler) SetTCToQueue(m map[uint8]int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for tc, index := range m {
+		if index < 0 || index >= len(s.queues) {
+			return fmt.Errorf("fwdqos: traffic class %d mapped to queue %d out of range", tc, index)
+		}
+	}
+	s.tcToQueue = m
+	return nil
+}
+
+// Stats returns the counters of the queue at the specified index.
+func (s *Scheduler) Stats(index int) (Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if index < 0 || index >= len(s.queues) {
+		return Stats{}, fmt.Errorf("fwdqos: queue %d out of range", index)
+	}
+	return s.queues[index].stats, nil
+}
+
+// queueIndex returns the queue index of the packet. It is called with the lock held.
+func (s *Scheduler) queueIndex(packet fwdpacket.Packet) int {
+	var tc uint8
+	if f, err := packet.Field(tcField); err == nil && len(f) == 1 {
+		tc = f[0]
+	}
+	if s.tcToQueue == nil {
+		if int(tc) < len(s.queues) {
+			return int(tc)
+		}
+		return 0
+	}
+	return s.tcToQueue[tc]
+}
+
+// drop returns true if the next packet must be dropped by the queue.
+func (s *Scheduler) drop(q *queue) bool {
+	if len(q.packets) >= maxQueuePackets {
+		return true
+	}
+	w := q.config.WRED
+	if w == nil || q.bytes < w.MinThreshold {
+		return false
+	}
+	if q.bytes >= w.MaxThreshold {
+		return true
+	}
+	p := float64(w.DropProbability) / 100 * float64(q.bytes-w.MinThreshold) / float64(w.MaxThreshold-w.MinThreshold)
+	return s.rand() < p
+}
+
+// Enqueue queues a copy of the packet in the queue of its traffic class.
+func (s *Scheduler) Enqueue(packet fwdpacket.Packet) (fwdaction.State, error) {
+	length := uint64(packet.Length())
+	s.mu.Lock()
+	q := s.queues[s.queueIndex(packet)]
+	if s.drop(q) {
+		q.stats.DropPackets++
+		q.stats.DropOctets += length
+		s.mu.Unlock()
+		return fwdaction.DROP, nil
+	}
+	s.mu.Unlock()
+
+	// The frame of the packet may be reused once the packet is processed, so queue a copy.
+	cp, err := packet.Mirror(nil)
+	if err != nil {
+		return fwdaction.DROP, err
+	}
+	s.mu.Lock()
+	q.packets = append(q.packets, cp)
+	q.bytes += length
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return fwdaction.CONSUME, nil
+}
+
+// dequeue removes the packet at the head of the queue, counts it and takes
+// its length from the port tokens. It is called with the lock held.
+func (s *Scheduler) dequeue(q *queue) fwdpacket.Packet {
+	packet := q.packets[0]
+	q.packets[0] = nil
+	q.packets = q.packets[1:]
+	length := uint64(packet.Length())
+	q.bytes -= length
+	q.stats.TxPackets++
+	q.stats.TxOctets += length
+	if s.portRate != 0 {
+		s.portTokens -= int64(length)
+	}
+	return packet
+}
+
+// next returns the next packet to transmit. If there is no packet, it also
+// returns true if some queues have packets that are held back by shaping or
+// by the port rate.
+func (s *Scheduler) next() (fwdpacket.Packet, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.portReady() {
+		for _, q := range s.queues {
+			if len(q.packets) != 0 {
+				return nil, true
+			}
+		}
+		return nil, false
+	}
+	shaped := false
+	for i := len(s.queues) - 1; i >= 0; i-- {
+		q := s.queues[i]
+		if q.config.Type != StrictPriority || len(q.packets) == 0 {
+			continue
+		}
+		if q.shaper != nil && !q.shaper.Allowed(uint64(q.packets[0].Length())) {
+			shaped = true
+			continue
+		}
+		return s.dequeue(q), false
+	}
+
+	// Each queue may need several rounds to accumulate enough deficit for a
+	// large packet, so visit the queues enough times for the largest frame.
+	visits := len(s.queues) * (maxFrameSize/quantum + 2)
+	for range visits {
+		q := s.queues[s.rr]
+		if q.config.Type == DWRR && len(q.packets) != 0 {
+			if !s.credited {
+				q.deficit += quantum * max(q.config.Weight, 1)
+				s.credited = true
+			}
+			length := uint64(q.packets[0].Length())
+			if length <= q.deficit {
+				if q.shaper == nil || q.shaper.Allowed(length) {
+					q.deficit -= length
+					return s.dequeue(q), false
+				}
+				shaped = true
+			}
+		} else {
+			q.deficit = 0
+		}
+		s.rr = (s.rr + 1) % len(s.queues)
+		s.credited = false
+	}
+	return nil, shaped
+}
+
+// run transmits packets until the scheduler is stopped.
+func (s *Scheduler) run() {
+	for {
+		packet, shaped := s.next()
+		if packet != nil {
+			fwdport.Transmit(s.port, packet)
+			continue
+		}
+		var retry <-chan time.Time
+		if shaped {
+			retry = time.After(shapingInterval)
+		}
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		case <-retry:
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fwdqos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdaction"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdport"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdobject"
+	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdpacket"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+
+	_ "github.com/openconfig/lemming/dataplane/forwarding/protocol/ethernet"
+	_ "github.com/openconfig/lemming/dataplane/forwarding/protocol/metadata"
+	_ "github.com/openconfig/lemming/dataplane/forwarding/protocol/opaque"
+)
+
+// testPort is a port that sends the length of the written packets to a channel.
+type testPort struct {
+	fwdobject.Base
+	written chan int
+}
+
+func (testPort) Desc() *fwdpb.PortDesc                                { return nil }
+func (testPort) Type() fwdpb.PortType                                 { return fwdpb.PortType_PORT_TYPE_UNSPECIFIED }
+func (testPort) Update(*fwdpb.PortUpdateDesc) error                   { return nil }
+func (testPort) Actions(fwdpb.PortAction) fwdaction.Actions           { return nil }
+func (testPort) State(*fwdpb.PortInfo) (*fwdpb.PortStateReply, error) { return nil, nil }
+func (p *testPort) Write(packet fwdpacket.Packet) (fwdaction.State, error) {
+	p.written <- packet.Length()
+	return fwdaction.CONSUME, nil
+}
+
+// newPacket returns an ethernet packet of the specified length and traffic class.
+func newPacket(t *testing.T, length int, tc uint8) fwdpacket.Packet {
+	t.Helper()
+	frame := make([]byte, length)
+	copy(frame, []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x00, 0x01, 0x02, 0x03, 0x04, 0x06, 0x88, 0xb5})
+	packet, err := fwdpacket.New(fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET, frame)
+	if err != nil {
+		t.Fatalf("failed to create packet: %v", err)
+	}
+	if err := packet.Update(tcField, fwdpacket.OpSet, []byte{tc}); err != nil {
+		t.Fatalf("failed to set traffic class: %v", err)
+	}
+	return packet
+}
+
+// enqueue queues packets of the specified traffic classes and checks they are queued.
+func enqueue(t *testing.T, s *Scheduler, length int, tcs ...uint8) {
+	t.Helper()
+	for _, tc := range tcs {
+		state, err := s.Enqueue(newPacket(t, length, tc))
+		if err != nil || state != fwdaction.CONSUME {
+			t.Fatalf("Enqueue(tc %d) got (%v, %v), want (%v, nil)", tc, state, err, fwdaction.CONSUME)
+		}
+	}
+}
+
+// dequeueOrder returns the queue index of the next n packets, using the
+// length of the packet to identify the queue.
+func dequeueOrder(s *Scheduler, n int) []int {
+	var got []int
+	for range n {
+		packet, _ := s.next()
+		if packet == nil {
+			break
+		}
+		got = append(got, packet.Length()/100)
+	}
+	return got
+}
+
+func TestStrictPriority(t *testing.T) {
+	s := newScheduler(nil, 4)
+	for _, i := range []int{2, 3} {
+		if err := s.SetQueue(i, QueueConfig{Type: StrictPriority}); err != nil {
+			t.Fatalf("SetQueue(%d) unexpected err: %v", i, err)
+		}
+	}
+	for _, tc := range []uint8{0, 2, 1, 3, 2, 3} {
+		enqueue(t, s, 100*int(tc)+100, tc)
+	}
+	// Lengths are 100 * (queue + 1).
+	got := dequeueOrder(s, 6)
+	want := []int{4, 4, 3, 3, 1, 2}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("dequeue order (-want,+got):\n%s", d)
+	}
+}
+
+func TestDWRR(t *testing.T) {
+	s := newScheduler(nil, 2)
+	if err := s.SetQueue(0, QueueConfig{Weight: 3}); err != nil {
+		t.Fatalf("SetQueue(0) unexpected err: %v", err)
+	}
+	if err := s.SetQueue(1, QueueConfig{Weight: 1}); err != nil {
+		t.Fatalf("SetQueue(1) unexpected err: %v", err)
+	}
+	for range 8 {
+		enqueue(t, s, 1000, 0)
+		enqueue(t, s, 1100, 1)
+	}
+	// Queue 0 gets 4500 bytes and queue 1 gets 1500 bytes in each round.
+	got := dequeueOrder(s, 16)
+	want := []int{10, 10, 10, 10, 11, 10, 10, 10, 10, 11, 11, 11, 11, 11, 11, 11}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("dequeue order (-want,+got):\n%s", d)
+	}
+}
+
+func TestShaping(t *testing.T) {
+	s := newScheduler(nil, 1)
+	if err := s.SetQueue(0, QueueConfig{RateBps: 1}); err != nil {
+		t.Fatalf("SetQueue(0) unexpected err: %v", err)
+	}
+	for range 10 {
+		enqueue(t, s, 1000, 0)
+	}
+	// The burst is at least maxFrameSize bytes.
+	if got := len(dequeueOrder(s, 10)); got != 9 {
+		t.Errorf("dequeued %d packets, want 9", got)
+	}
+	if packet, shaped := s.next(); packet != nil || !shaped {
+		t.Errorf("next() got (%v, %v), want (nil, true)", packet, shaped)
+	}
+}
+
+func TestPortRate(t *testing.T) {
+	s := newScheduler(nil, 2)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	if err := s.SetQueue(1, QueueConfig{Type: StrictPriority}); err != nil {
+		t.Fatalf("SetQueue(1) unexpected err: %v", err)
+	}
+	// The burst of the port is maxFrameSize bytes.
+	s.SetPortRate(100000)
+
+	// The strict priority queue has a backlog larger than the burst of the port and is
+	// offered twice the port rate for a second, so it always has packets and starves
+	// the DWRR queue.
+	for range 20 {
+		enqueue(t, s, 1000, 1)
+	}
+	for range 100 {
+		now = now.Add(10 * time.Millisecond)
+		enqueue(t, s, 1000, 1, 1)
+		enqueue(t, s, 100, 0)
+		for {
+			packet, _ := s.next()
+			if packet == nil {
+				break
+			}
+		}
+	}
+	sp, _ := s.Stats(1)
+	if want := uint64(100000 + maxFrameSize + 1000); sp.TxOctets < 100000 || sp.TxOctets > want {
+		t.Errorf("strict priority queue transmitted %d bytes, want between 100000 and %d", sp.TxOctets, want)
+	}
+	if dwrr, _ := s.Stats(0); dwrr.TxPackets != 0 {
+		t.Errorf("DWRR queue transmitted %d packets under congestion, want 0", dwrr.TxPackets)
+	}
+	if packet, shaped := s.next(); packet != nil || !shaped {
+		t.Errorf("next() got (%v, %v), want (nil, true)", packet, shaped)
+	}
+
+	// Once the strict priority queue is drained, the DWRR queue is served.
+	for {
+		packet, shaped := s.next()
+		if packet == nil && !shaped {
+			break
+		}
+		now = now.Add(10 * time.Millisecond)
+	}
+	if dwrr, _ := s.Stats(0); dwrr.TxPackets != 100 {
+		t.Errorf("DWRR queue transmitted %d packets after congestion, want 100", dwrr.TxPackets)
+	}
+}
+
+func TestWRED(t *testing.T) {
+	s := newScheduler(nil, 1)
+	s.rand = func() float64 { return 0.5 }
+	if err := s.SetQueue(0, QueueConfig{WRED: &WRED{MinThreshold: 100, MaxThreshold: 400, DropProbability: 100}}); err != nil {
+		t.Fatalf("SetQueue(0) unexpected err: %v", err)
+	}
+	var got []fwdaction.State
+	for range 5 {
+		state, err := s.Enqueue(newPacket(t, 100, 0))
+		if err != nil {
+			t.Fatalf("Enqueue() unexpected err: %v", err)
+		}
+		got = append(got, state)
+	}
+	// The drop probability is 0, 1/3, 2/3 and then all packets are dropped.
+	want := []fwdaction.State{fwdaction.CONSUME, fwdaction.CONSUME, fwdaction.CONSUME, fwdaction.DROP, fwdaction.DROP}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("Enqueue() states (-want,+got):\n%s", d)
+	}
+	stats, err := s.Stats(0)
+	if err != nil {
+		t.Fatalf("Stats(0) unexpected err: %v", err)
+	}
+	if want := (Stats{DropPackets: 2, DropOctets: 200}); stats != want {
+		t.Errorf("Stats(0) got %+v, want %+v", stats, want)
+	}
+}
+
+func TestTCToQueue(t *testing.T) {
+	s := newScheduler(nil, 2)
+	if err := s.SetTCToQueue(map[uint8]int{5: 2}); err == nil {
+		t.Errorf("SetTCToQueue() got no error for out of range queue")
+	}
+	if err := s.SetTCToQueue(map[uint8]int{5: 1}); err != nil {
+		t.Fatalf("SetTCToQueue() unexpected err: %v", err)
+	}
+	enqueue(t, s, 100, 5, 1)
+	for i, want := range []Stats{{}, {}} {
+		if got, _ := s.Stats(i); got != want {
+			t.Errorf("Stats(%d) before dequeue got %+v, want %+v", i, got, want)
+		}
+	}
+	dequeueOrder(s, 2)
+	// Traffic class 1 is not in the map and uses queue 0.
+	for i, want := range []Stats{{TxPackets: 1, TxOctets: 100}, {TxPackets: 1, TxOctets: 100}} {
+		if got, _ := s.Stats(i); got != want {
+			t.Errorf("Stats(%d) got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestOutput(t *testing.T) {
+	port := &testPort{written: make(chan int, 1)}
+	if err := port.InitCounters("port", fwdport.CounterList...); err != nil {
+		t.Fatalf("InitCounters() unexpected err: %v", err)
+	}
+	s := NewScheduler(port, 1)
+	defer s.Stop()
+
+	if err := fwdport.Output(port, newPacket(t, 100, 0), fwdpb.PortAction_PORT_ACTION_OUTPUT, nil); err != nil {
+		t.Fatalf("Output() unexpected err: %v", err)
+	}
+	select {
+	case got := <-port.written:
+		if got != 100 {
+			t.Errorf("transmitted packet length got %d, want 100", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("packet was not transmitted")
+	}
+	if got, _ := s.Stats(0); got != (Stats{TxPackets: 1, TxOctets: 100}) {
+		t.Errorf("Stats(0) got %+v", got)
+	}
+}
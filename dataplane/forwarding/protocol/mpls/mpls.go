@@ -162,6 +162,8 @@ func (m *mpls) Rebuild() error {
 const (
 	ipv4ExplicitNull = 0
 	ipv6ExplicitNull = 3
+	versionBitOffset = 4 // The IP version is the leftmost 4 bits of the payload.
+	versionBitLen    = 4
 )
 
 func parseMPLS(f *frame.Frame, desc *protocol.Desc) (protocol.Handler, fwdpb.PacketHeaderId, error) {
@@ -185,16 +187,32 @@ func parseMPLS(f *frame.Frame, desc *protocol.Desc) (protocol.Handler, fwdpb.Pac
 			break
 		}
 	}
-	// If the last label is explicit null, then use that to parse the next header, otherwise treat the rest of the packet as opaque.
-	next := fwdpb.PacketHeaderId_PACKET_HEADER_ID_OPAQUE
+	// If the last label is explicit null, then use that to parse the next header.
 	switch label.Value() {
 	case ipv4ExplicitNull:
-		next = fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP4
+		return m, fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP4, nil
 	case ipv6ExplicitNull:
-		next = fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP6
+		return m, fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP6, nil
 	}
+	return m, payloadID(f), nil
+}
 
-	return m, next, nil
+// payloadID guesses the header following the label stack from the IP version nibble,
+// so that labelled IP packets can be forwarded after their last label is popped.
+// Anything else is treated as opaque.
+func payloadID(f *frame.Frame) fwdpb.PacketHeaderId {
+	b, err := f.Peek(0, 1)
+	if err != nil {
+		return fwdpb.PacketHeaderId_PACKET_HEADER_ID_OPAQUE
+	}
+	switch b.BitField(versionBitOffset, versionBitLen).Value() {
+	case 4:
+		return fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP4
+	case 6:
+		return fwdpb.PacketHeaderId_PACKET_HEADER_ID_IP6
+	default:
+		return fwdpb.PacketHeaderId_PACKET_HEADER_ID_OPAQUE
+	}
 }
 
 func add(id fwdpb.PacketHeaderId, desc *protocol.Desc) (protocol.Handler, error) {
@@ -93,6 +93,16 @@ func TestMPLSFields(t *testing.T) {
 			ID:     fwdpacket.NewFieldIDFromNum(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TTL, 0),
 			Result: []byte{1},
 		}},
+	}, {
+		StartHeader: fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
+		Orig:        [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeMPLSUnicast), genMPLS(t, 100, 0, false, 10), genMPLS(t, 200, 0, true, 10), genIP(t, true)},
+		Queries: []packettestutil.FieldQuery{{
+			ID:     fwdpacket.NewFieldIDFromNum(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL, 1),
+			Result: binary.BigEndian.AppendUint32([]byte{}, 200),
+		}, {
+			ID:     fwdpacket.NewFieldIDFromNum(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_VERSION, 0),
+			Result: []byte{4},
+		}},
 	}}
 	packettestutil.TestPacketFields("mpls", t, tests)
 }
@@ -130,6 +140,14 @@ func TestMPLSEncap(t *testing.T) {
 			Encap:  true,
 			Result: [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeMPLSUnicast), genMPLS(t, 0, 0, true, 0), genIP(t, true)},
 		}},
+	}, {
+		StartHeader: fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
+		Orig:        [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeIPv4), genIP(t, true)},
+		Updates: []packettestutil.HeaderUpdate{{
+			ID:     fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS,
+			Encap:  true,
+			Result: [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeMPLSUnicast), genMPLS(t, 0, 0, true, 0), genIP(t, true)},
+		}},
 	}}
 	packettestutil.TestPacketHeaders("mpls", t, tests)
 }
@@ -151,6 +169,14 @@ func TestMPLSDecap(t *testing.T) {
 			Encap:  false,
 			Result: [][]byte{genIP(t, true)},
 		}},
+	}, {
+		StartHeader: fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
+		Orig:        [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeMPLSUnicast), genMPLS(t, 15, 0, true, 0), genIP(t, true)},
+		Updates: []packettestutil.HeaderUpdate{{
+			ID:     fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS,
+			Encap:  false,
+			Result: [][]byte{genEth(t, "00:00:00:00:00:00", "00:00:00:00:00:00", layers.EthernetTypeIPv4), genIP(t, true)},
+		}},
 	}}
 	packettestutil.TestPacketHeaders("mpls", t, tests)
 }
@@ -136,6 +136,16 @@ func (k *Interfaces) NeighSubscribe(ch chan<- netlink.NeighUpdate, done <-chan s
 	return netlink.NeighSubscribe(ch, done)
 }
 
+// NeighList lists the neighbors of the address family on a network interface.
+func (k *Interfaces) NeighList(linkIndex, family int) ([]netlink.Neigh, error) {
+	return netlink.NeighList(linkIndex, family)
+}
+
+// NeighDel deletes a neighbor.
+func (k *Interfaces) NeighDel(neigh *netlink.Neigh) error {
+	return netlink.NeighDel(neigh)
+}
+
 // LinkList lists all Linux network interfaces.
 func (k *Interfaces) LinkList() ([]netlink.Link, error) {
 	links, err := netlink.LinkList()
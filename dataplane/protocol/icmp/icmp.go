@@ -0,0 +1,288 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package icmp sends ICMP and UDP probes through the dataplane and replies to
+// expired and rejected packets with ICMP error messages.
+package icmp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+const (
+	// defaultTTL is the TTL of the packets originated by the CPU.
+	defaultTTL = 64
+	// minIPv6MTU is the minimum MTU of IPv6 links, ICMPv6 errors must not exceed it.
+	minIPv6MTU = 1280
+)
+
+// Injector injects frames from the CPU port into the forwarding pipeline.
+type Injector interface {
+	// Inject routes the frame in the virtual router, zero uses the virtual router of the CPU port.
+	Inject(frame []byte, vrf uint64) error
+}
+
+// message is an ICMP or ICMPv6 message.
+type message struct {
+	src, dst netip.Addr
+	v6       bool
+	ttl      uint8
+	typ      uint8
+	code     uint8
+	body     []byte // The message after the type, code and checksum.
+}
+
+// parseIP returns the IP layer of the Ethernet frame.
+func parseIP(frame []byte) (gopacket.Layer, bool) {
+	pkt := gopacket.NewPacket(frame, layers.LayerTypeEthernet, gopacket.Lazy)
+	if l := pkt.Layer(layers.LayerTypeIPv4); l != nil {
+		return l, true
+	}
+	if l := pkt.Layer(layers.LayerTypeIPv6); l != nil {
+		return l, true
+	}
+	return nil, false
+}
+
+// parseMessage returns the ICMP message in the Ethernet frame, if it contains one.
+func parseMessage(frame []byte) (*message, bool) {
+	l, ok := parseIP(frame)
+	if !ok {
+		return nil, false
+	}
+	var m *message
+	switch ip := l.(type) {
+	case *layers.IPv4:
+		if ip.Protocol != layers.IPProtocolICMPv4 {
+			return nil, false
+		}
+		m = &message{src: addr(ip.SrcIP), dst: addr(ip.DstIP), ttl: ip.TTL}
+	case *layers.IPv6:
+		if ip.NextHeader != layers.IPProtocolICMPv6 {
+			return nil, false
+		}
+		m = &message{src: addr(ip.SrcIP), dst: addr(ip.DstIP), ttl: ip.HopLimit, v6: true}
+	}
+	payload := l.LayerPayload()
+	if len(payload) < 4 {
+		return nil, false
+	}
+	m.typ, m.code, m.body = payload[0], payload[1], payload[4:]
+	return m, true
+}
+
+// isError returns whether the message is an ICMP error message.
+func (m *message) isError() bool {
+	if m.v6 {
+		return m.typ < 128
+	}
+	switch m.typ {
+	case layers.ICMPv4TypeDestinationUnreachable, layers.ICMPv4TypeSourceQuench, layers.ICMPv4TypeRedirect,
+		layers.ICMPv4TypeTimeExceeded, layers.ICMPv4TypeParameterProblem:
+		return true
+	}
+	return false
+}
+
+func addr(ip net.IP) netip.Addr {
+	a, _ := netip.AddrFromSlice(ip)
+	return a.Unmap()
+}
+
+// serialize serializes the IP packet in an Ethernet frame.
+// The MAC addresses are left unset, since the packets are routed by the dataplane.
+func serialize(ip gopacket.NetworkLayer, ls ...gopacket.SerializableLayer) ([]byte, error) {
+	eth := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 0},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 0},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+	if _, ok := ip.(*layers.IPv6); ok {
+		eth.EthernetType = layers.EthernetTypeIPv6
+	}
+	for _, l := range ls {
+		if c, ok := l.(interface {
+			SetNetworkLayerForChecksum(gopacket.NetworkLayer) error
+		}); ok {
+			if err := c.SetNetworkLayerForChecksum(ip); err != nil {
+				return nil, err
+			}
+		}
+	}
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buf, opts, append([]gopacket.SerializableLayer{eth, ip.(gopacket.SerializableLayer)}, ls...)...); err != nil {
+		return nil, fmt.Errorf("failed to serialize packet: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newIP returns the IP header of a packet from src to dst.
+func newIP(src, dst netip.Addr, ttl uint8, proto layers.IPProtocol, dontFragment bool) (gopacket.NetworkLayer, error) {
+	if !src.IsValid() || !dst.IsValid() || src.Is4() != dst.Is4() {
+		return nil, fmt.Errorf("invalid source %v and destination %v", src, dst)
+	}
+	if dst.Is4() {
+		ip := &layers.IPv4{
+			Version:  4,
+			IHL:      5,
+			TTL:      ttl,
+			Protocol: proto,
+			SrcIP:    src.AsSlice(),
+			DstIP:    dst.AsSlice(),
+		}
+		if dontFragment {
+			ip.Flags = layers.IPv4DontFragment
+		}
+		return ip, nil
+	}
+	return &layers.IPv6{
+		Version:    6,
+		HopLimit:   ttl,
+		NextHeader: proto,
+		SrcIP:      src.AsSlice(),
+		DstIP:      dst.AsSlice(),
+	}, nil
+}
+
+// timeExceeded returns an ICMP time exceeded message from src to the source of the expired packet.
+func timeExceeded(frame []byte, src netip.Addr) ([]byte, error) {
+	return icmpError(frame, src,
+		layers.CreateICMPv4TypeCode(layers.ICMPv4TypeTimeExceeded, layers.ICMPv4CodeTTLExceeded),
+		layers.CreateICMPv6TypeCode(layers.ICMPv6TypeTimeExceeded, layers.ICMPv6CodeHopLimitExceeded))
+}
+
+// unreachable returns an ICMP administratively prohibited destination unreachable message
+// from src to the source of the rejected packet.
+func unreachable(frame []byte, src netip.Addr) ([]byte, error) {
+	return icmpError(frame, src,
+		layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, layers.ICMPv4CodeCommAdminProhibited),
+		layers.CreateICMPv6TypeCode(layers.ICMPv6TypeDestinationUnreachable, layers.ICMPv6CodeAdminProhibited))
+}
+
+// icmpError returns an ICMP error message of the type from src to the source of the packet in the frame.
+func icmpError(frame []byte, src netip.Addr, v4 layers.ICMPv4TypeCode, v6 layers.ICMPv6TypeCode) ([]byte, error) {
+	l, ok := parseIP(frame)
+	if !ok {
+		return nil, fmt.Errorf("not an IP packet")
+	}
+	if m, ok := parseMessage(frame); ok && m.isError() {
+		return nil, fmt.Errorf("not replying to ICMP error from %v", m.src)
+	}
+	switch orig := l.(type) {
+	case *layers.IPv4:
+		ip, err := newIP(src, addr(orig.SrcIP), defaultTTL, layers.IPProtocolICMPv4, false)
+		if err != nil {
+			return nil, err
+		}
+		// The reply contains the IP header and the first 8 bytes of the payload of the original packet.
+		quoted := orig.LayerPayload()
+		quoted = append(append([]byte{}, orig.LayerContents()...), quoted[:min(len(quoted), 8)]...)
+		return serialize(ip, &layers.ICMPv4{TypeCode: v4}, gopacket.Payload(quoted))
+	case *layers.IPv6:
+		ip, err := newIP(src, addr(orig.SrcIP), defaultTTL, layers.IPProtocolICMPv6, false)
+		if err != nil {
+			return nil, err
+		}
+		// The reply contains as much of the original packet as fits in the minimum MTU,
+		// after the unused 4 bytes of the ICMPv6 message.
+		quoted := append(append(make([]byte, 4), orig.LayerContents()...), orig.LayerPayload()...)
+		quoted = quoted[:min(len(quoted), minIPv6MTU-40-4)]
+		return serialize(ip, &layers.ICMPv6{TypeCode: v6}, gopacket.Payload(quoted))
+	}
+	return nil, fmt.Errorf("not an IP packet")
+}
+
+// errorResponder replies to the packets punted with a trap ID with ICMP error messages.
+type errorResponder struct {
+	injector Injector
+	trapID   uint64
+	addr     func(port uint64, v6 bool) (netip.Addr, uint64, bool)
+	reply    func(frame []byte, src netip.Addr) ([]byte, error)
+}
+
+// Matched returns true if the packet was punted with the trap.
+func (r *errorResponder) Matched(po *pktiopb.PacketOut) bool {
+	return po.GetPacket().GetHostPort() == r.trapID
+}
+
+// Process replies to the punted packet.
+func (r *errorResponder) Process(po *pktiopb.PacketOut) error {
+	l, ok := parseIP(po.GetPacket().GetFrame())
+	if !ok {
+		return fmt.Errorf("punted packet is not an IP packet")
+	}
+	_, v6 := l.(*layers.IPv6)
+	src, vrf, ok := r.addr(po.GetPacket().GetInputPort(), v6)
+	if !ok {
+		return fmt.Errorf("no address on input port %d", po.GetPacket().GetInputPort())
+	}
+	frame, err := r.reply(po.GetPacket().GetFrame(), src)
+	if err != nil {
+		return err
+	}
+	return r.injector.Inject(frame, vrf)
+}
+
+// TimeExceededResponder replies to the packets punted by the TTL error trap with ICMP time exceeded messages.
+type TimeExceededResponder struct {
+	errorResponder
+}
+
+// NewTimeExceededResponder returns a responder for the packets punted with the trap ID.
+// addr returns the address and the virtual router of the input port of the expired packet,
+// the reply is sent from the address and routed in the virtual router.
+func NewTimeExceededResponder(inj Injector, trapID uint64, addr func(port uint64, v6 bool) (netip.Addr, uint64, bool)) *TimeExceededResponder {
+	return &TimeExceededResponder{
+		errorResponder: errorResponder{
+			injector: inj,
+			trapID:   trapID,
+			addr:     addr,
+			reply:    timeExceeded,
+		},
+	}
+}
+
+// UnreachableResponder replies to the packets punted by the reject action of an ACL
+// with ICMP administratively prohibited destination unreachable messages.
+type UnreachableResponder struct {
+	errorResponder
+}
+
+// NewUnreachableResponder returns a responder for the packets punted with the trap ID.
+// addr returns the address and the virtual router of the input port of the rejected packet,
+// the reply is sent from the address and routed in the virtual router.
+func NewUnreachableResponder(inj Injector, trapID uint64, addr func(port uint64, v6 bool) (netip.Addr, uint64, bool)) *UnreachableResponder {
+	return &UnreachableResponder{
+		errorResponder: errorResponder{
+			injector: inj,
+			trapID:   trapID,
+			addr:     addr,
+			reply:    unreachable,
+		},
+	}
+}
+
+// uint16At returns the big endian uint16 at the offset of b.
+func uint16At(b []byte, off int) uint16 {
+	return binary.BigEndian.Uint16(b[off : off+2])
+}
@@ -0,0 +1,229 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package icmp
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/openconfig/gnmi/errdiff"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+// injectorFunc injects the frames by calling the function.
+type injectorFunc func(frame []byte, vrf uint64) error
+
+func (f injectorFunc) Inject(frame []byte, vrf uint64) error {
+	return f(frame, vrf)
+}
+
+func mustFrame(t testing.TB, ip gopacket.NetworkLayer, ls ...gopacket.SerializableLayer) []byte {
+	t.Helper()
+	frame, err := serialize(ip, ls...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame
+}
+
+func mustIP(t testing.TB, src, dst string, proto layers.IPProtocol) gopacket.NetworkLayer {
+	t.Helper()
+	ip, err := newIP(netip.MustParseAddr(src), netip.MustParseAddr(dst), 1, proto, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ip
+}
+
+func TestTimeExceeded(t *testing.T) {
+	udp := &layers.UDP{SrcPort: 1000, DstPort: 2000}
+	tests := []struct {
+		desc     string
+		frame    []byte
+		src      string
+		wantMsg  *message
+		wantBody int
+		wantErr  string
+	}{{
+		desc:     "ipv4",
+		frame:    mustFrame(t, mustIP(t, "10.0.0.1", "10.0.1.1", layers.IPProtocolUDP), udp, gopacket.Payload(make([]byte, 100))),
+		src:      "10.0.0.2",
+		wantMsg:  &message{src: netip.MustParseAddr("10.0.0.2"), dst: netip.MustParseAddr("10.0.0.1"), ttl: defaultTTL, typ: layers.ICMPv4TypeTimeExceeded},
+		wantBody: 4 + 20 + 8,
+	}, {
+		desc:     "ipv6",
+		frame:    mustFrame(t, mustIP(t, "2001::1", "2001::1:1", layers.IPProtocolUDP), udp, gopacket.Payload(make([]byte, 100))),
+		src:      "2001::2",
+		wantMsg:  &message{src: netip.MustParseAddr("2001::2"), dst: netip.MustParseAddr("2001::1"), ttl: defaultTTL, v6: true, typ: layers.ICMPv6TypeTimeExceeded},
+		wantBody: 4 + 40 + 8 + 100,
+	}, {
+		desc: "icmp error",
+		frame: mustFrame(t, mustIP(t, "10.0.0.1", "10.0.1.1", layers.IPProtocolICMPv4), &layers.ICMPv4{
+			TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, 0),
+		}),
+		src:     "10.0.0.2",
+		wantErr: "not replying to ICMP error",
+	}, {
+		desc:    "address family mismatch",
+		frame:   mustFrame(t, mustIP(t, "10.0.0.1", "10.0.1.1", layers.IPProtocolUDP), udp),
+		src:     "2001::2",
+		wantErr: "invalid source",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := timeExceeded(tt.frame, netip.MustParseAddr(tt.src))
+			if d := errdiff.Substring(err, tt.wantErr); d != "" {
+				t.Fatalf("timeExceeded() unexpected error: %s", d)
+			}
+			if err != nil {
+				return
+			}
+			m, ok := parseMessage(got)
+			if !ok {
+				t.Fatalf("timeExceeded() returned a frame without an ICMP message: %x", got)
+			}
+			if d := cmp.Diff(tt.wantMsg, m, cmp.AllowUnexported(message{}), cmpopts.IgnoreFields(message{}, "body"), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+				t.Errorf("timeExceeded() unexpected message (-want,+got):\n%s", d)
+			}
+			if len(m.body) != tt.wantBody {
+				t.Errorf("timeExceeded() got body length %d, want %d", len(m.body), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	udp := &layers.UDP{SrcPort: 1000, DstPort: 2000}
+	tests := []struct {
+		desc    string
+		frame   []byte
+		src     string
+		wantMsg *message
+	}{{
+		desc:    "ipv4",
+		frame:   mustFrame(t, mustIP(t, "10.0.0.1", "10.0.1.1", layers.IPProtocolUDP), udp),
+		src:     "10.0.0.2",
+		wantMsg: &message{src: netip.MustParseAddr("10.0.0.2"), dst: netip.MustParseAddr("10.0.0.1"), ttl: defaultTTL, typ: layers.ICMPv4TypeDestinationUnreachable, code: layers.ICMPv4CodeCommAdminProhibited},
+	}, {
+		desc:    "ipv6",
+		frame:   mustFrame(t, mustIP(t, "2001::1", "2001::1:1", layers.IPProtocolUDP), udp),
+		src:     "2001::2",
+		wantMsg: &message{src: netip.MustParseAddr("2001::2"), dst: netip.MustParseAddr("2001::1"), ttl: defaultTTL, v6: true, typ: layers.ICMPv6TypeDestinationUnreachable, code: layers.ICMPv6CodeAdminProhibited},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := unreachable(tt.frame, netip.MustParseAddr(tt.src))
+			if err != nil {
+				t.Fatalf("unreachable() unexpected error: %v", err)
+			}
+			m, ok := parseMessage(got)
+			if !ok {
+				t.Fatalf("unreachable() returned a frame without an ICMP message: %x", got)
+			}
+			if d := cmp.Diff(tt.wantMsg, m, cmp.AllowUnexported(message{}), cmpopts.IgnoreFields(message{}, "body"), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+				t.Errorf("unreachable() unexpected message (-want,+got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	hop := netip.MustParseAddr("192.0.2.1")
+	tests := []struct {
+		desc  string
+		probe *Probe
+		// reply returns the reply to the probe, if any.
+		reply   func(t testing.TB, probe []byte) []byte
+		want    *Reply
+		wantErr string
+	}{{
+		desc:  "icmp time exceeded",
+		probe: &Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("10.0.1.1"), TTL: 2, Protocol: ProtocolICMP, Size: 32},
+		reply: func(t testing.TB, probe []byte) []byte {
+			frame, err := timeExceeded(probe, hop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return frame
+		},
+		want: &Reply{From: hop, Type: ReplyTimeExceeded, TTL: defaultTTL},
+	}, {
+		desc:  "udp time exceeded",
+		probe: &Probe{Src: netip.MustParseAddr("2001::1"), Dst: netip.MustParseAddr("2001::1:1"), TTL: 2, Protocol: ProtocolUDP},
+		reply: func(t testing.TB, probe []byte) []byte {
+			frame, err := timeExceeded(probe, netip.MustParseAddr("2001::2"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return frame
+		},
+		want: &Reply{From: netip.MustParseAddr("2001::2"), Type: ReplyTimeExceeded, TTL: defaultTTL},
+	}, {
+		desc:  "echo reply",
+		probe: &Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("10.0.1.1"), VRF: 10, TTL: 64, Protocol: ProtocolICMP},
+		reply: func(t testing.TB, probe []byte) []byte {
+			m, _ := parseMessage(probe)
+			return mustFrame(t, mustIP(t, "10.0.1.1", "10.0.0.1", layers.IPProtocolICMPv4), &layers.ICMPv4{
+				TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoReply, 0),
+				Id:       uint16At(m.body, 0),
+				Seq:      uint16At(m.body, 2),
+			})
+		},
+		want: &Reply{From: netip.MustParseAddr("10.0.1.1"), Type: ReplyEcho, TTL: 1},
+	}, {
+		desc:  "no reply",
+		probe: &Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("10.0.1.1"), TTL: 2, Protocol: ProtocolICMP},
+	}, {
+		desc:    "invalid probe",
+		probe:   &Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("2001::1"), TTL: 2, Protocol: ProtocolICMP},
+		wantErr: "invalid source",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var p *Prober
+			p = NewProber(injectorFunc(func(frame []byte, vrf uint64) error {
+				if vrf != tt.probe.VRF {
+					t.Errorf("Probe() injected packet in VRF %d, want %d", vrf, tt.probe.VRF)
+				}
+				if tt.reply == nil {
+					return nil
+				}
+				po := &pktiopb.PacketOut{Packet: &pktiopb.Packet{Frame: tt.reply(t, frame)}}
+				if !p.Matched(po) {
+					t.Errorf("Matched() got false, want true")
+					return nil
+				}
+				return p.Process(po)
+			}))
+			got, err := p.Probe(context.Background(), tt.probe, 10*time.Millisecond)
+			if d := errdiff.Substring(err, tt.wantErr); d != "" {
+				t.Fatalf("Probe() unexpected error: %s", d)
+			}
+			if d := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(Reply{}, "RTT"), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+				t.Errorf("Probe() unexpected reply (-want,+got):\n%s", d)
+			}
+			if len(p.pending) != 0 {
+				t.Errorf("Probe() left %d pending probes", len(p.pending))
+			}
+		})
+	}
+}
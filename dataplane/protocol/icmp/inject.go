@@ -0,0 +1,84 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package icmp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// ForwardingInjector injects frames with the PacketInject RPC of the forwarding service.
+type ForwardingInjector struct {
+	ctx       context.Context
+	client    fwdpb.ForwardingClient
+	contextID string
+	cpuPortID string
+	hostPort  uint64
+
+	mu     sync.Mutex
+	stream fwdpb.Forwarding_PacketInjectClient
+}
+
+// NewForwardingInjector returns an injector that injects the frames into the CPU port of the forwarding context.
+// The frames are injected with the host port, which must submit them to the ingress pipeline.
+// The stream is opened on first use and is closed when the context is cancelled.
+func NewForwardingInjector(ctx context.Context, c fwdpb.ForwardingClient, contextID string, cpuPortID, hostPort uint64) *ForwardingInjector {
+	return &ForwardingInjector{
+		ctx:       ctx,
+		client:    c,
+		contextID: contextID,
+		cpuPortID: fmt.Sprint(cpuPortID),
+		hostPort:  hostPort,
+	}
+}
+
+// Inject injects the frame, setting its virtual router if vrf is not zero.
+func (inj *ForwardingInjector) Inject(frame []byte, vrf uint64) error {
+	acts := []*fwdpb.ActionDesc{
+		fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_HOST_PORT_ID).WithUint64Value(inj.hostPort)).Build(),
+	}
+	if vrf != 0 {
+		acts = append(acts, fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_VRF).WithUint64Value(vrf)).Build())
+	}
+	req := &fwdpb.PacketInjectRequest{
+		ContextId:    &fwdpb.ContextId{Id: inj.contextID},
+		PortId:       &fwdpb.PortId{ObjectId: &fwdpb.ObjectId{Id: inj.cpuPortID}},
+		StartHeader:  fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
+		Bytes:        frame,
+		Preprocesses: acts,
+		Action:       fwdpb.PortAction_PORT_ACTION_INPUT,
+	}
+
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.stream == nil {
+		stream, err := inj.client.PacketInject(inj.ctx)
+		if err != nil {
+			return fmt.Errorf("failed to open packet inject stream: %v", err)
+		}
+		inj.stream = stream
+	}
+	if err := inj.stream.Send(req); err != nil {
+		// The stream is broken, reopen it on the next injection.
+		inj.stream = nil
+		return fmt.Errorf("failed to inject packet: %v", err)
+	}
+	return nil
+}
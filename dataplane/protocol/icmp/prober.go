@@ -0,0 +1,277 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package icmp
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+// Protocol is the protocol of the probes.
+type Protocol int
+
+const (
+	// ProtocolICMP sends ICMP or ICMPv6 echo requests.
+	ProtocolICMP Protocol = iota
+	// ProtocolUDP sends UDP datagrams to the traceroute ports.
+	ProtocolUDP
+)
+
+// udpBasePort is the destination port of the first UDP probe, as used by traceroute.
+const udpBasePort = 33434
+
+// ReplyType is the type of the ICMP message received in reply to a probe.
+type ReplyType int
+
+const (
+	// ReplyEcho is an echo reply from the destination.
+	ReplyEcho ReplyType = iota + 1
+	// ReplyTimeExceeded is a time exceeded message from a hop on the path.
+	ReplyTimeExceeded
+	// ReplyUnreachable is a destination unreachable message, the code has the reason.
+	ReplyUnreachable
+)
+
+// Probe is a packet sent by the prober.
+type Probe struct {
+	Src, Dst      netip.Addr
+	VRF           uint64 // Virtual router of the probe, zero uses the virtual router of the CPU port.
+	TTL           uint8
+	Protocol      Protocol
+	Size          int // Size of the payload.
+	DoNotFragment bool
+}
+
+// Reply is the ICMP message received in reply to a probe.
+type Reply struct {
+	From netip.Addr
+	Type ReplyType
+	Code uint8 // ICMP or ICMPv6 code, depending on the address family of From.
+	TTL  uint8 // TTL or hop limit of the reply.
+	RTT  time.Duration
+}
+
+// probeKey identifies a probe from the header of its transport protocol.
+type probeKey struct {
+	proto Protocol
+	id    uint16 // The echo identifier or the UDP source port.
+	seq   uint16 // The echo sequence number or the UDP destination port.
+}
+
+type pending struct {
+	sent  time.Time
+	reply chan *Reply
+}
+
+// Prober sends probes from the CPU port and matches the ICMP replies punted to the CPU port.
+type Prober struct {
+	injector Injector
+	id       uint16
+
+	mu      sync.Mutex
+	seq     uint16
+	pending map[probeKey]*pending
+}
+
+// NewProber returns a prober that injects the probes with the injector.
+func NewProber(inj Injector) *Prober {
+	return &Prober{
+		injector: inj,
+		id:       uint16(rand.N(1 << 16)), //nolint:gosec // The identifier only distinguishes the probes of the device.
+		pending:  map[probeKey]*pending{},
+	}
+}
+
+// Probe sends the probe and waits for a reply until the wait time elapses.
+// It returns a nil reply if no reply was received.
+func (p *Prober) Probe(ctx context.Context, probe *Probe, wait time.Duration) (*Reply, error) {
+	p.mu.Lock()
+	p.seq++
+	key := probeKey{proto: probe.Protocol, id: p.id, seq: p.seq}
+	p.mu.Unlock()
+	if probe.Protocol == ProtocolUDP {
+		key.seq = udpBasePort + key.seq%1000
+	}
+	frame, err := probe.frame(key)
+	if err != nil {
+		return nil, err
+	}
+
+	pd := &pending{sent: time.Now(), reply: make(chan *Reply, 1)}
+	p.mu.Lock()
+	p.pending[key] = pd
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.pending, key)
+		p.mu.Unlock()
+	}()
+
+	if err := p.injector.Inject(frame, probe.VRF); err != nil {
+		return nil, fmt.Errorf("failed to send probe: %v", err)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case r := <-pd.reply:
+		return r, nil
+	case <-timer.C:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// frame returns the Ethernet frame of the probe.
+func (probe *Probe) frame(key probeKey) ([]byte, error) {
+	var l4 gopacket.SerializableLayer
+	proto := layers.IPProtocolUDP
+	switch probe.Protocol {
+	case ProtocolICMP:
+		if probe.Dst.Is4() {
+			proto = layers.IPProtocolICMPv4
+			l4 = &layers.ICMPv4{
+				TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0),
+				Id:       key.id,
+				Seq:      key.seq,
+			}
+		} else {
+			proto = layers.IPProtocolICMPv6
+			l4 = &layers.ICMPv6{
+				TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeEchoRequest, 0),
+			}
+		}
+	case ProtocolUDP:
+		l4 = &layers.UDP{
+			SrcPort: layers.UDPPort(key.id),
+			DstPort: layers.UDPPort(key.seq),
+		}
+	default:
+		return nil, fmt.Errorf("unsupported probe protocol %v", probe.Protocol)
+	}
+	ip, err := newIP(probe.Src, probe.Dst, probe.TTL, proto, probe.DoNotFragment)
+	if err != nil {
+		return nil, err
+	}
+	ls := []gopacket.SerializableLayer{l4}
+	if proto == layers.IPProtocolICMPv6 {
+		ls = append(ls, &layers.ICMPv6Echo{Identifier: key.id, SeqNumber: key.seq})
+	}
+	return serialize(ip, append(ls, gopacket.Payload(make([]byte, probe.Size)))...)
+}
+
+// replyKey returns the key of the probe that the ICMP message replies to.
+func replyKey(m *message) (probeKey, ReplyType, bool) {
+	var typ ReplyType
+	switch {
+	case !m.v6 && m.typ == layers.ICMPv4TypeEchoReply, m.v6 && m.typ == layers.ICMPv6TypeEchoReply:
+		if len(m.body) < 4 {
+			return probeKey{}, 0, false
+		}
+		return probeKey{proto: ProtocolICMP, id: uint16At(m.body, 0), seq: uint16At(m.body, 2)}, ReplyEcho, true
+	case !m.v6 && m.typ == layers.ICMPv4TypeTimeExceeded, m.v6 && m.typ == layers.ICMPv6TypeTimeExceeded:
+		typ = ReplyTimeExceeded
+	case !m.v6 && m.typ == layers.ICMPv4TypeDestinationUnreachable, m.v6 && m.typ == layers.ICMPv6TypeDestinationUnreachable:
+		typ = ReplyUnreachable
+	default:
+		return probeKey{}, 0, false
+	}
+
+	// The error message quotes the probe after 4 unused bytes.
+	if len(m.body) < 4 {
+		return probeKey{}, 0, false
+	}
+	quoted := m.body[4:]
+	var proto uint8
+	var l4 []byte
+	if m.v6 {
+		if len(quoted) < 40 {
+			return probeKey{}, 0, false
+		}
+		proto, l4 = quoted[6], quoted[40:]
+	} else {
+		if len(quoted) < 20 {
+			return probeKey{}, 0, false
+		}
+		ihl := int(quoted[0]&0x0f) * 4
+		if len(quoted) < ihl {
+			return probeKey{}, 0, false
+		}
+		proto, l4 = quoted[9], quoted[ihl:]
+	}
+	switch layers.IPProtocol(proto) {
+	case layers.IPProtocolICMPv4, layers.IPProtocolICMPv6:
+		if len(l4) < 8 {
+			return probeKey{}, 0, false
+		}
+		return probeKey{proto: ProtocolICMP, id: uint16At(l4, 4), seq: uint16At(l4, 6)}, typ, true
+	case layers.IPProtocolUDP:
+		if len(l4) < 4 {
+			return probeKey{}, 0, false
+		}
+		return probeKey{proto: ProtocolUDP, id: uint16At(l4, 0), seq: uint16At(l4, 2)}, typ, true
+	}
+	return probeKey{}, 0, false
+}
+
+// Matched returns true if the packet is an ICMP reply to a pending probe.
+func (p *Prober) Matched(po *pktiopb.PacketOut) bool {
+	m, ok := parseMessage(po.GetPacket().GetFrame())
+	if !ok {
+		return false
+	}
+	key, _, ok := replyKey(m)
+	if !ok {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok = p.pending[key]
+	return ok
+}
+
+// Process delivers the reply to the pending probe.
+func (p *Prober) Process(po *pktiopb.PacketOut) error {
+	now := time.Now()
+	m, ok := parseMessage(po.GetPacket().GetFrame())
+	if !ok {
+		return fmt.Errorf("not an ICMP message")
+	}
+	key, typ, ok := replyKey(m)
+	if !ok {
+		return fmt.Errorf("not a reply to a probe")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pd, ok := p.pending[key]
+	if !ok {
+		return nil
+	}
+	select {
+	case pd.reply <- &Reply{From: m.src, Type: typ, Code: m.code, TTL: m.ttl, RTT: now.Sub(pd.sent)}:
+	default: // Duplicate reply.
+	}
+	return nil
+}
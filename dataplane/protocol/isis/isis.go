@@ -0,0 +1,1179 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package isis is a minimal implementation of the IS-IS routing protocol (ISO 10589, RFC 1195),
+// with level 1 and level 2 routing over point-to-point and broadcast circuits, the wide
+// metrics of RFC 5305 and the IPv6 reachability of RFC 5308.
+package isis
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/util/queue"
+
+	log "github.com/golang/glog"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+const (
+	defaultHelloInterval   = 10 * time.Second
+	defaultHelloMultiplier = 3
+	defaultMetric          = 10
+	defaultLSPLifetime     = 1200 * time.Second
+	defaultLSPRefresh      = 900 * time.Second
+	// csnpInterval is the interval of the CSNPs sent by the DIS of a broadcast circuit.
+	csnpInterval = 10 * time.Second
+	// retransmitInterval is the interval of the retransmissions of the unacknowledged LSPs
+	// on point-to-point circuits.
+	retransmitInterval = 5 * time.Second
+	// zeroAgeLifetime is the time the purged LSPs are kept in the database.
+	zeroAgeLifetime = 60 * time.Second
+	// tickInterval is the resolution of the timers.
+	tickInterval = 100 * time.Millisecond
+)
+
+// Sender sends the frames originated by the CPU.
+type Sender interface {
+	Send(*pktiopb.PacketIn) error
+}
+
+// RouteFunc is called with all the routes computed by the SPF, when they change.
+type RouteFunc func([]*Route)
+
+// Config is the configuration of the IS-IS instance.
+type Config struct {
+	SystemID    SystemID
+	Areas       [][]byte
+	Levels      Levels // The levels the system routes at.
+	Hostname    string
+	LSPLifetime time.Duration
+	LSPRefresh  time.Duration // The interval the originated LSPs are refreshed at.
+	Interfaces  []*Interface
+}
+
+// Interface is an interface IS-IS is enabled on.
+type Interface struct {
+	Name            string
+	PortID          uint64           // The dataplane port, the PDUs are received from it.
+	HostPort        uint64           // The host port the PDUs are sent with, which transmits them on the port.
+	MAC             net.HardwareAddr // The source address of the PDUs.
+	PointToPoint    bool
+	Passive         bool   // Whether the prefixes are advertised without forming adjacencies.
+	Levels          Levels // The levels of the circuit, all the levels of the system if zero.
+	Priority        uint8  // The priority to be the DIS of broadcast circuits.
+	HelloInterval   time.Duration
+	HelloMultiplier int
+	Metric          uint32
+	Prefixes        []netip.Prefix // The addresses of the interface.
+	LinkLocal       netip.Addr     // The IPv6 link-local address of the interface.
+}
+
+// AdjacencyState is the state of an adjacency.
+type AdjacencyState uint8
+
+const (
+	AdjacencyDown AdjacencyState = iota
+	AdjacencyInit
+	AdjacencyUp
+)
+
+func (s AdjacencyState) String() string {
+	switch s {
+	case AdjacencyDown:
+		return "DOWN"
+	case AdjacencyInit:
+		return "INIT"
+	case AdjacencyUp:
+		return "UP"
+	}
+	return fmt.Sprintf("STATE_%d", uint8(s))
+}
+
+// Adjacency is the state of an adjacency at a level.
+type Adjacency struct {
+	Interface         string
+	Level             int
+	SystemID          SystemID
+	State             AdjacencyState
+	PointToPoint      bool
+	SNPA              net.HardwareAddr
+	CircuitType       Levels // The levels of the neighbor's circuit.
+	Priority          uint8
+	DIS               NodeID // The LAN ID of broadcast circuits.
+	LocalCircuitID    uint32
+	NeighborCircuitID uint32
+	Areas             []string
+	Protocols         []byte // The NLPIDs of the supported protocols.
+	IPv4              []netip.Addr
+	IPv6              []netip.Addr
+	UpSince           time.Time
+}
+
+// adjacency is the protocol state of a neighbor.
+type adjacency struct {
+	system      SystemID
+	state       AdjacencyState
+	levels      Levels // The levels of the adjacency, it is one level on broadcast circuits.
+	mac         net.HardwareAddr
+	circuitType Levels
+	priority    uint8
+	lanID       NodeID
+	circuitID   uint32 // The extended circuit ID of point-to-point neighbors.
+	areas       [][]byte
+	protocols   []byte
+	ipv4        []netip.Addr
+	ipv6        []netip.Addr // Link-local addresses.
+	ipv6Global  []netip.Addr
+	expires     time.Time
+	upSince     time.Time
+}
+
+// circuitLevel is the state of a circuit at a level.
+type circuitLevel struct {
+	adjs      map[SystemID]*adjacency // The neighbors of broadcast circuits.
+	lanID     NodeID                  // The pseudonode of the DIS, zero if there is none.
+	dis       bool                    // Whether the system is the DIS.
+	srm       map[LSPID]time.Time     // The LSPs to send, at the time.
+	ssn       map[LSPID]bool          // The LSPs to acknowledge, or request on broadcast circuits.
+	nextHello time.Time
+	nextCSNP  time.Time
+}
+
+// circuit is the protocol state of an interface.
+type circuit struct {
+	cfg       *Interface
+	id        uint8  // The local circuit ID, the pseudonode ID of the DIS.
+	levels    Levels // The levels of the circuit the system routes at.
+	p2p       *adjacency
+	lvl       [3]*circuitLevel // Indexed by level, nil for the levels of passive circuits.
+	nextHello time.Time        // The time of the next point-to-point hello.
+}
+
+// dbEntry is an LSP of the database.
+type dbEntry struct {
+	lsp *lsp
+	// expires is the time the remaining lifetime reaches zero, or the time a purged LSP is removed.
+	expires time.Time
+	refresh time.Time // The time an originated LSP is refreshed.
+}
+
+func (e *dbEntry) purged() bool {
+	return e.lsp.lifetime == 0
+}
+
+// remaining returns the remaining lifetime of the LSP in seconds.
+func (e *dbEntry) remaining(now time.Time) uint16 {
+	if e.purged() {
+		return 0
+	}
+	return uint16(max(1, math.Ceil(e.expires.Sub(now).Seconds())))
+}
+
+func (e *dbEntry) entry(now time.Time) lspEntry {
+	return lspEntry{lifetime: e.remaining(now), id: e.lsp.id, seq: e.lsp.seq, checksum: e.lsp.checksum}
+}
+
+// Daemon runs IS-IS on the interfaces.
+type Daemon struct {
+	sender Sender
+	notify RouteFunc
+	events *queue.Queue
+	now    func() time.Time // The clock of the timers, replaced in tests.
+
+	mu       sync.Mutex
+	cfg      *Config
+	circuits map[uint64]*circuit   // keyed by port ID, the passive circuits have no port.
+	passive  []*Interface          // The passive interfaces.
+	db       [3]map[LSPID]*dbEntry // Indexed by level.
+	lspDirty bool                  // Whether the originated LSPs must be regenerated.
+	spfDirty bool                  // Whether the SPF must be run.
+	routes   []*Route
+	leaked   []prefixReach // The level 1 routes advertised at level 2.
+	attached bool          // Whether level 2 reaches other areas.
+	doneCh   chan struct{}
+}
+
+// New returns a daemon that sends the PDUs with the sender, notify is called when the
+// routes change.
+func New(sender Sender, notify RouteFunc) (*Daemon, error) {
+	q, err := queue.NewUnbounded("isis")
+	if err != nil {
+		return nil, err
+	}
+	q.Run()
+	d := &Daemon{
+		sender:   sender,
+		notify:   notify,
+		events:   q,
+		now:      time.Now,
+		circuits: map[uint64]*circuit{},
+		db:       [3]map[LSPID]*dbEntry{nil, {}, {}},
+	}
+	// The notifications are sent in order, without holding the lock.
+	go func() {
+		for e := range q.Receive() {
+			d.notify(e.([]*Route))
+		}
+	}()
+	return d, nil
+}
+
+// Start starts the timers of the circuits and the LSPs.
+func (d *Daemon) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.doneCh != nil {
+		return
+	}
+	d.doneCh = make(chan struct{})
+	go func(done chan struct{}) {
+		tick := time.NewTicker(tickInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-tick.C:
+				d.tick(now)
+			}
+		}
+	}(d.doneCh)
+}
+
+// Stop stops the timers, the adjacencies and the routes are removed.
+func (d *Daemon) Stop() {
+	d.Configure(nil)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.doneCh == nil {
+		return
+	}
+	close(d.doneCh)
+	d.doneCh = nil
+}
+
+// Configure replaces the configuration, IS-IS is disabled if it is nil. The database is
+// cleared if the system ID or the levels change, the adjacencies of the circuits whose
+// port or type change are restarted.
+func (d *Daemon) Configure(cfg *Config) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	if cfg == nil || d.cfg == nil || cfg.SystemID != d.cfg.SystemID || cfg.Levels != d.cfg.Levels {
+		d.circuits = map[uint64]*circuit{}
+		d.db = [3]map[LSPID]*dbEntry{nil, {}, {}}
+		d.leaked, d.attached = nil, false
+	}
+	d.cfg = nil
+	d.passive = nil
+	if cfg == nil {
+		d.spfDirty = true
+		d.update(now)
+		return
+	}
+	c := *cfg
+	c.LSPLifetime = cmp.Or(c.LSPLifetime, defaultLSPLifetime)
+	c.LSPRefresh = cmp.Or(c.LSPRefresh, defaultLSPRefresh)
+	c.Interfaces = nil
+	d.cfg = &c
+
+	want := map[uint64]*circuit{}
+	for _, intf := range cfg.Interfaces {
+		i := *intf
+		i.HelloInterval = cmp.Or(i.HelloInterval, defaultHelloInterval)
+		i.HelloMultiplier = cmp.Or(i.HelloMultiplier, defaultHelloMultiplier)
+		i.Metric = cmp.Or(i.Metric, defaultMetric)
+		d.cfg.Interfaces = append(d.cfg.Interfaces, &i)
+		levels := c.Levels
+		if i.Levels != 0 {
+			levels &= i.Levels
+		}
+		if levels == 0 {
+			continue
+		}
+		if i.Passive {
+			d.passive = append(d.passive, &i)
+			continue
+		}
+		want[i.PortID] = &circuit{cfg: &i, levels: levels}
+	}
+	used := map[uint8]bool{}
+	for id, old := range d.circuits {
+		w, ok := want[id]
+		if ok && w.cfg.Name == old.cfg.Name && w.cfg.HostPort == old.cfg.HostPort && w.cfg.PointToPoint == old.cfg.PointToPoint &&
+			w.levels == old.levels && w.cfg.MAC.String() == old.cfg.MAC.String() {
+			// Keep the adjacencies, the parameters are advertised in the next hellos.
+			old.cfg = w.cfg
+			want[id] = old
+			used[old.id] = true
+			continue
+		}
+		delete(d.circuits, id)
+	}
+	for _, id := range slices.Sorted(maps.Keys(want)) {
+		w := want[id]
+		if _, ok := d.circuits[id]; ok {
+			continue
+		}
+		for w.id = 1; used[w.id] && w.id < 255; w.id++ {
+		}
+		used[w.id] = true
+		for level := 1; level <= 2; level++ {
+			if w.levels.Has(level) {
+				w.lvl[level] = &circuitLevel{adjs: map[SystemID]*adjacency{}, srm: map[LSPID]time.Time{}, ssn: map[LSPID]bool{}, nextHello: now}
+			}
+		}
+		w.nextHello = now
+		d.circuits[id] = w
+	}
+	d.lspDirty = true
+	d.spfDirty = true
+	d.update(now)
+}
+
+// Adjacencies returns the state of the adjacencies, sorted by interface, level and system ID.
+func (d *Daemon) Adjacencies() []*Adjacency {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var adjs []*Adjacency
+	for _, c := range d.circuits {
+		for level := 1; level <= 2; level++ {
+			cl := c.lvl[level]
+			if cl == nil {
+				continue
+			}
+			var as []*adjacency
+			if c.cfg.PointToPoint {
+				if c.p2p != nil && c.p2p.levels.Has(level) {
+					as = append(as, c.p2p)
+				}
+			} else {
+				as = slices.Collect(maps.Values(cl.adjs))
+			}
+			for _, a := range as {
+				s := &Adjacency{
+					Interface:      c.cfg.Name,
+					Level:          level,
+					SystemID:       a.system,
+					State:          a.state,
+					PointToPoint:   c.cfg.PointToPoint,
+					SNPA:           a.mac,
+					CircuitType:    a.circuitType,
+					Priority:       a.priority,
+					LocalCircuitID: uint32(c.id),
+					Protocols:      a.protocols,
+					IPv4:           a.ipv4,
+					IPv6:           append(slices.Clone(a.ipv6), a.ipv6Global...),
+					UpSince:        a.upSince,
+				}
+				if c.cfg.PointToPoint {
+					s.NeighborCircuitID = a.circuitID
+				} else {
+					s.DIS = cl.lanID
+				}
+				for _, area := range a.areas {
+					s.Areas = append(s.Areas, areaString(area))
+				}
+				adjs = append(adjs, s)
+			}
+		}
+	}
+	slices.SortFunc(adjs, func(a, b *Adjacency) int {
+		return cmp.Or(cmp.Compare(a.Interface, b.Interface), cmp.Compare(a.Level, b.Level), bytes.Compare(a.SystemID[:], b.SystemID[:]))
+	})
+	return adjs
+}
+
+// Matched returns true if the packet is an IS-IS PDU.
+func (d *Daemon) Matched(po *pktiopb.PacketOut) bool {
+	return isISIS(po.GetPacket().GetFrame())
+}
+
+// Process processes the PDU received on a circuit.
+func (d *Daemon) Process(po *pktiopb.PacketOut) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c, ok := d.circuits[po.GetPacket().GetInputPort()]
+	if !ok {
+		return fmt.Errorf("port %d is not an IS-IS circuit", po.GetPacket().GetInputPort())
+	}
+	src, b, err := parseFrame(po.GetPacket().GetFrame())
+	if err != nil {
+		return err
+	}
+	pduType, b, err := parseHeader(b)
+	if err != nil {
+		return err
+	}
+	now := d.now()
+	switch pduType {
+	case pduL1LANHello, pduL2LANHello:
+		if c.cfg.PointToPoint {
+			return fmt.Errorf("LAN hello received on point-to-point circuit %q", c.cfg.Name)
+		}
+		h, err := parseHello(pduType, b)
+		if err != nil {
+			return err
+		}
+		d.receiveLANHello(c, h, src, now)
+	case pduP2PHello:
+		if !c.cfg.PointToPoint {
+			return fmt.Errorf("point-to-point hello received on broadcast circuit %q", c.cfg.Name)
+		}
+		h, err := parseHello(pduType, b)
+		if err != nil {
+			return err
+		}
+		d.receiveP2PHello(c, h, src, now)
+	case pduL1LSP, pduL2LSP:
+		level := levelOf(pduType, pduL2LSP)
+		if !d.levelUp(c, level, src) {
+			return nil
+		}
+		l, err := parseLSP(b)
+		if err != nil {
+			return err
+		}
+		d.receiveLSP(c, level, l, now)
+	case pduL1CSNP, pduL2CSNP, pduL1PSNP, pduL2PSNP:
+		level := levelOf(pduType, pduL2CSNP, pduL2PSNP)
+		if !d.levelUp(c, level, src) {
+			return nil
+		}
+		s, err := parseSNP(pduType, b)
+		if err != nil {
+			return err
+		}
+		d.receiveSNP(c, level, s, now)
+	}
+	d.update(now)
+	return nil
+}
+
+func levelOf(pduType uint8, l2Types ...uint8) int {
+	if slices.Contains(l2Types, pduType) {
+		return 2
+	}
+	return 1
+}
+
+// levelUp returns whether the circuit has an adjacency up at the level, with the neighbor
+// of the address on broadcast circuits.
+func (d *Daemon) levelUp(c *circuit, level int, src net.HardwareAddr) bool {
+	cl := c.lvl[level]
+	if cl == nil {
+		return false
+	}
+	if c.cfg.PointToPoint {
+		return c.p2p != nil && c.p2p.state == AdjacencyUp && c.p2p.levels.Has(level)
+	}
+	for _, a := range cl.adjs {
+		if a.state == AdjacencyUp && (src == nil || bytes.Equal(a.mac, src)) {
+			return true
+		}
+	}
+	return false
+}
+
+// areasMatch returns whether one of the areas is an area of the system.
+func (d *Daemon) areasMatch(areas [][]byte) bool {
+	for _, a := range areas {
+		for _, b := range d.cfg.Areas {
+			if bytes.Equal(a, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateNeighbor records the information of the neighbor's hello.
+func updateNeighbor(a *adjacency, h *hello, src net.HardwareAddr, now time.Time) {
+	a.mac = slices.Clone(src)
+	a.circuitType = h.circuitType
+	a.priority = h.priority
+	a.lanID = h.lanID
+	a.areas = h.areas
+	a.protocols = h.protocols
+	a.ipv4 = h.ipv4
+	a.ipv6 = h.ipv6
+	a.ipv6Global = h.ipv6Global
+	a.expires = now.Add(time.Duration(max(1, h.holdTime)) * time.Second)
+}
+
+// setState changes the state of the adjacency. The caller must hold mu.
+func (d *Daemon) setState(a *adjacency, state AdjacencyState, now time.Time) bool {
+	if a.state == state {
+		return false
+	}
+	log.Infof("IS-IS adjacency with %v: %v -> %v", a.system, a.state, state)
+	if state == AdjacencyUp {
+		a.upSince = now
+	}
+	if state == AdjacencyUp || a.state == AdjacencyUp {
+		d.lspDirty = true
+		d.spfDirty = true
+	}
+	a.state = state
+	return true
+}
+
+// receiveLANHello updates the adjacency of the neighbor, which is up once the neighbor
+// reports the address of the circuit. The caller must hold mu.
+func (d *Daemon) receiveLANHello(c *circuit, h *hello, src net.HardwareAddr, now time.Time) {
+	level := levelOf(h.pduType, pduL2LANHello)
+	cl := c.lvl[level]
+	if cl == nil || h.source == d.cfg.SystemID || !h.circuitType.Has(level) {
+		return
+	}
+	if level == 1 && !d.areasMatch(h.areas) {
+		return
+	}
+	a, ok := cl.adjs[h.source]
+	if !ok {
+		a = &adjacency{system: h.source, levels: Level(level), state: AdjacencyDown}
+		cl.adjs[h.source] = a
+	}
+	if a.priority != h.priority || a.lanID != h.lanID {
+		d.lspDirty = true
+	}
+	updateNeighbor(a, h, src, now)
+	state := AdjacencyInit
+	for _, n := range h.neighbors {
+		if bytes.Equal(n, c.cfg.MAC) {
+			state = AdjacencyUp
+		}
+	}
+	if d.setState(a, state, now) {
+		// Let the neighbor know about the system without waiting for the next hello.
+		cl.nextHello = now
+	}
+}
+
+// receiveP2PHello runs the three-way handshake of RFC 5303 with the neighbor. The caller must hold mu.
+func (d *Daemon) receiveP2PHello(c *circuit, h *hello, src net.HardwareAddr, now time.Time) {
+	if h.source == d.cfg.SystemID {
+		return
+	}
+	levels := c.levels & h.circuitType
+	if levels.Has(1) && !d.areasMatch(h.areas) {
+		levels &^= Level1
+	}
+	a := c.p2p
+	if a != nil && (a.system != h.source || levels == 0) {
+		d.dropP2P(c, now)
+		a = nil
+	}
+	if levels == 0 {
+		return
+	}
+	if a == nil {
+		a = &adjacency{system: h.source, state: AdjacencyDown}
+		c.p2p = a
+	}
+	if a.levels != levels {
+		a.levels = levels
+		d.lspDirty = true
+		d.spfDirty = true
+	}
+	updateNeighbor(a, h, src, now)
+	state := AdjacencyUp
+	if t := h.p2p; t != nil {
+		a.circuitID = t.localCircuit
+		if t.hasNeighbor && (t.neighbor != d.cfg.SystemID || t.neighborCircuit != uint32(c.id)) {
+			// The neighbor has an adjacency with another circuit.
+			d.dropP2P(c, now)
+			return
+		}
+		switch {
+		case t.state == p2pStateDown:
+			state = AdjacencyInit
+		case t.state == p2pStateUp && a.state == AdjacencyDown:
+			state = AdjacencyDown
+		}
+	}
+	prev := a.state
+	if !d.setState(a, state, now) {
+		return
+	}
+	c.nextHello = now
+	if state == AdjacencyUp && prev != AdjacencyUp {
+		// Synchronize the databases, the neighbor acknowledges the LSPs it has.
+		for level := 1; level <= 2; level++ {
+			if cl := c.lvl[level]; cl != nil && levels.Has(level) {
+				for id := range d.db[level] {
+					cl.srm[id] = time.Time{}
+				}
+			}
+		}
+	}
+}
+
+// dropP2P removes the adjacency of the point-to-point circuit. The caller must hold mu.
+func (d *Daemon) dropP2P(c *circuit, now time.Time) {
+	if c.p2p == nil {
+		return
+	}
+	d.setState(c.p2p, AdjacencyDown, now)
+	c.p2p = nil
+	c.nextHello = now
+	for _, cl := range c.lvl {
+		if cl != nil {
+			clear(cl.srm)
+			clear(cl.ssn)
+		}
+	}
+}
+
+// receiveLSP updates the database with the LSP and floods it (ISO 10589 section 7.3.15.1).
+// The caller must hold mu.
+func (d *Daemon) receiveLSP(c *circuit, level int, l *lsp, now time.Time) {
+	cl := c.lvl[level]
+	e := d.db[level][l.id]
+	if l.id.Node().System() == d.cfg.SystemID && (e == nil || newer(l.seq, l.lifetime, e.lsp.seq, e.remaining(now))) {
+		// A copy of an LSP of the system from before a restart, it is superseded by a new
+		// sequence number if the system still originates it, or else purged.
+		if e != nil && !e.purged() {
+			d.originateLSP(level, l.id, l.seq+1, e.lsp.flags, e.lsp.raw[lspHeaderLength:], now)
+			return
+		}
+		if l.lifetime != 0 {
+			d.purge(level, &dbEntry{lsp: l}, now)
+			return
+		}
+	}
+	switch {
+	case e == nil && l.lifetime == 0:
+		// Acknowledge the purge of an unknown LSP without storing it.
+		if c.cfg.PointToPoint {
+			d.send(c, level, (&snp{pduType: psnpType(level), source: nodeOf(d.cfg.SystemID, 0), entries: []lspEntry{{id: l.id, seq: l.seq, checksum: l.checksum}}}).marshal())
+		}
+	case e == nil || newer(l.seq, l.lifetime, e.lsp.seq, e.remaining(now)):
+		d.install(level, l, now)
+		d.flood(level, l.id, c)
+		if c.cfg.PointToPoint {
+			cl.ssn[l.id] = true
+		}
+	case !newer(e.lsp.seq, e.remaining(now), l.seq, l.lifetime):
+		// The same LSP, which acknowledges it.
+		delete(cl.srm, l.id)
+		if c.cfg.PointToPoint {
+			cl.ssn[l.id] = true
+		}
+	default:
+		cl.srm[l.id] = time.Time{}
+		delete(cl.ssn, l.id)
+	}
+}
+
+// receiveSNP compares the LSP entries with the database, the LSPs that are newer in the
+// database are sent and the others are requested. The caller must hold mu.
+func (d *Daemon) receiveSNP(c *circuit, level int, s *snp, now time.Time) {
+	cl := c.lvl[level]
+	complete := s.pduType == pduL1CSNP || s.pduType == pduL2CSNP
+	if !complete && !c.cfg.PointToPoint && !cl.dis {
+		// Only the DIS answers the requests of a broadcast circuit.
+		return
+	}
+	listed := map[LSPID]bool{}
+	for _, r := range s.entries {
+		listed[r.id] = true
+		e := d.db[level][r.id]
+		switch {
+		case e == nil || newer(r.seq, r.lifetime, e.lsp.seq, e.remaining(now)):
+			if e != nil || r.lifetime != 0 {
+				cl.ssn[r.id] = true
+			}
+		case !newer(e.lsp.seq, e.remaining(now), r.seq, r.lifetime):
+			delete(cl.srm, r.id)
+			if !complete && !c.cfg.PointToPoint {
+				// A request for the LSP the neighbor has.
+				cl.srm[r.id] = time.Time{}
+			}
+		default:
+			cl.srm[r.id] = time.Time{}
+		}
+	}
+	if !complete {
+		return
+	}
+	// The neighbor does not have the LSPs in the range that it does not list.
+	for id, e := range d.db[level] {
+		if !listed[id] && !e.purged() && bytes.Compare(id[:], s.start[:]) >= 0 && bytes.Compare(id[:], s.end[:]) <= 0 {
+			cl.srm[id] = time.Time{}
+		}
+	}
+}
+
+// install stores the LSP in the database. The caller must hold mu.
+func (d *Daemon) install(level int, l *lsp, now time.Time) {
+	e := &dbEntry{lsp: l, expires: now.Add(time.Duration(l.lifetime) * time.Second)}
+	if l.lifetime == 0 {
+		e.expires = now.Add(zeroAgeLifetime)
+	}
+	d.db[level][l.id] = e
+	d.spfDirty = true
+}
+
+// flood sets the LSP to be sent on the circuits of the level, but the one it was received on.
+// The caller must hold mu.
+func (d *Daemon) flood(level int, id LSPID, from *circuit) {
+	for _, c := range d.circuits {
+		cl := c.lvl[level]
+		if cl == nil || c == from {
+			continue
+		}
+		cl.srm[id] = time.Time{}
+		delete(cl.ssn, id)
+	}
+}
+
+// purge removes the content of the LSP and floods it with a zero remaining lifetime.
+// The caller must hold mu.
+func (d *Daemon) purge(level int, e *dbEntry, now time.Time) {
+	raw := marshalLSP(level, e.lsp.id, 0, e.lsp.seq, e.lsp.flags, nil)
+	l, err := parseLSP(raw)
+	if err != nil {
+		log.Warningf("failed to purge LSP %v: %v", e.lsp.id, err)
+		return
+	}
+	d.install(level, l, now)
+	d.flood(level, l.id, nil)
+}
+
+// originateLSP stores and floods an LSP of the system. The caller must hold mu.
+func (d *Daemon) originateLSP(level int, id LSPID, seq uint32, flags uint8, body []byte, now time.Time) {
+	lifetime := uint16(min(d.cfg.LSPLifetime/time.Second, math.MaxUint16))
+	l, err := parseLSP(marshalLSP(level, id, lifetime, seq, flags, body))
+	if err != nil {
+		log.Warningf("failed to originate LSP %v: %v", id, err)
+		return
+	}
+	d.install(level, l, now)
+	d.db[level][id].refresh = now.Add(d.cfg.LSPRefresh)
+	d.flood(level, id, nil)
+}
+
+// ownLSP is the content of an LSP fragment originated by the system.
+type ownLSP struct {
+	flags uint8
+	body  []byte
+}
+
+// originate regenerates the LSPs of the system and of the pseudonodes of the circuits it is
+// the DIS of, the LSPs whose content changed get a new sequence number and the LSPs that
+// are no longer originated are purged. The caller must hold mu.
+func (d *Daemon) originate(now time.Time) {
+	d.lspDirty = false
+	for level := 1; level <= 2; level++ {
+		want := map[LSPID]ownLSP{}
+		if d.cfg != nil && d.cfg.Levels.Has(level) {
+			flags := uint8(lspTypeL1)
+			if d.cfg.Levels.Has(2) {
+				flags = lspTypeL2
+			}
+			sys := flags
+			if level == 1 && d.attached {
+				sys |= lspFlagAttached
+			}
+			for i, body := range fragmentTLVs(d.systemLSP(level).tlvs()) {
+				want[lspOf(nodeOf(d.cfg.SystemID, 0), uint8(i))] = ownLSP{flags: sys, body: body}
+			}
+			for _, c := range d.circuits {
+				cl := c.lvl[level]
+				if cl == nil || !cl.dis {
+					continue
+				}
+				pn := &lsp{neighbors: []isReach{{neighbor: nodeOf(d.cfg.SystemID, 0)}}}
+				for _, a := range cl.adjs {
+					if a.state == AdjacencyUp {
+						pn.neighbors = append(pn.neighbors, isReach{neighbor: nodeOf(a.system, 0)})
+					}
+				}
+				slices.SortFunc(pn.neighbors, func(a, b isReach) int { return bytes.Compare(a.neighbor[:], b.neighbor[:]) })
+				for i, body := range fragmentTLVs(pn.tlvs()) {
+					want[lspOf(nodeOf(d.cfg.SystemID, c.id), uint8(i))] = ownLSP{flags: flags, body: body}
+				}
+			}
+		}
+		for _, id := range slices.SortedFunc(maps.Keys(want), func(a, b LSPID) int { return bytes.Compare(a[:], b[:]) }) {
+			o := want[id]
+			e := d.db[level][id]
+			if e != nil && !e.purged() && e.lsp.flags == o.flags && bytes.Equal(e.lsp.raw[lspHeaderLength:], o.body) {
+				continue
+			}
+			seq := uint32(1)
+			if e != nil {
+				seq = e.lsp.seq + 1
+			}
+			d.originateLSP(level, id, seq, o.flags, o.body, now)
+		}
+		for id, e := range d.db[level] {
+			if _, ok := want[id]; !ok && d.cfg != nil && id.Node().System() == d.cfg.SystemID && !e.purged() {
+				d.purge(level, e, now)
+			}
+		}
+	}
+}
+
+// systemLSP returns the content of the LSP of the system at the level. The caller must hold mu.
+func (d *Daemon) systemLSP(level int) *lsp {
+	l := &lsp{areas: d.cfg.Areas, hostname: d.cfg.Hostname}
+	var has4, has6 bool
+	prefixes := map[netip.Prefix]prefixReach{}
+	addPrefix := func(p prefixReach) {
+		if o, ok := prefixes[p.prefix]; !ok || p.metric < o.metric {
+			prefixes[p.prefix] = p
+		}
+	}
+	intfs := slices.Clone(d.passive)
+	for _, c := range d.circuits {
+		intfs = append(intfs, c.cfg)
+	}
+	slices.SortFunc(intfs, func(a, b *Interface) int { return cmp.Compare(a.Name, b.Name) })
+	for _, i := range intfs {
+		levels := d.cfg.Levels
+		if i.Levels != 0 {
+			levels &= i.Levels
+		}
+		if !levels.Has(level) {
+			continue
+		}
+		for _, p := range i.Prefixes {
+			if p.Addr().IsLinkLocalUnicast() {
+				continue
+			}
+			if p.Addr().Is4() {
+				has4 = true
+				l.ipv4Addrs = append(l.ipv4Addrs, p.Addr())
+			} else {
+				has6 = true
+				l.ipv6Addrs = append(l.ipv6Addrs, p.Addr())
+			}
+			addPrefix(prefixReach{prefix: p.Masked(), metric: i.Metric})
+		}
+	}
+	if level == 2 {
+		for _, p := range d.leaked {
+			addPrefix(p)
+		}
+	}
+	l.prefixes = slices.SortedFunc(maps.Values(prefixes), comparePrefixReach)
+	if has4 || !has6 {
+		l.protocols = append(l.protocols, NLPIDIPv4)
+	}
+	if has6 || !has4 {
+		l.protocols = append(l.protocols, NLPIDIPv6)
+	}
+
+	for _, c := range d.circuits {
+		cl := c.lvl[level]
+		if cl == nil {
+			continue
+		}
+		switch {
+		case c.cfg.PointToPoint:
+			if c.p2p != nil && c.p2p.state == AdjacencyUp && c.p2p.levels.Has(level) {
+				l.neighbors = append(l.neighbors, isReach{neighbor: nodeOf(c.p2p.system, 0), metric: c.cfg.Metric})
+			}
+		case cl.lanID != NodeID{} && d.levelUp(c, level, nil):
+			l.neighbors = append(l.neighbors, isReach{neighbor: cl.lanID, metric: c.cfg.Metric})
+		}
+	}
+	slices.SortFunc(l.neighbors, func(a, b isReach) int {
+		return cmp.Or(bytes.Compare(a.neighbor[:], b.neighbor[:]), cmp.Compare(a.metric, b.metric))
+	})
+	return l
+}
+
+func comparePrefixReach(a, b prefixReach) int {
+	return cmp.Or(a.prefix.Addr().Compare(b.prefix.Addr()), cmp.Compare(a.prefix.Bits(), b.prefix.Bits()))
+}
+
+// elect elects the DIS of the broadcast circuit at the level, which is the system with the
+// highest priority and then the highest address. The caller must hold mu.
+func (d *Daemon) elect(c *circuit, level int, now time.Time) {
+	cl := c.lvl[level]
+	var best *adjacency
+	for _, a := range cl.adjs {
+		if a.state != AdjacencyUp {
+			continue
+		}
+		if best == nil || a.priority > best.priority || (a.priority == best.priority && bytes.Compare(a.mac, best.mac) > 0) {
+			best = a
+		}
+	}
+	dis, lanID := false, NodeID{}
+	switch {
+	case best == nil:
+	case c.cfg.Priority > best.priority || (c.cfg.Priority == best.priority && bytes.Compare(c.cfg.MAC, best.mac) > 0):
+		dis, lanID = true, nodeOf(d.cfg.SystemID, c.id)
+	case best.lanID.System() == best.system:
+		lanID = best.lanID
+	}
+	if cl.dis == dis && cl.lanID == lanID {
+		return
+	}
+	log.Infof("IS-IS DIS of %q at level %d: %v", c.cfg.Name, level, lanID)
+	cl.dis, cl.lanID = dis, lanID
+	cl.nextHello = now
+	if dis {
+		cl.nextCSNP = now
+	}
+}
+
+// tick runs the timers of the adjacencies and the LSPs.
+func (d *Daemon) tick(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cfg == nil {
+		return
+	}
+	for _, c := range d.circuits {
+		if c.p2p != nil && now.After(c.p2p.expires) {
+			d.dropP2P(c, now)
+		}
+		for _, cl := range c.lvl {
+			if cl == nil {
+				continue
+			}
+			for id, a := range cl.adjs {
+				if now.After(a.expires) {
+					d.setState(a, AdjacencyDown, now)
+					delete(cl.adjs, id)
+					d.lspDirty = true
+				}
+			}
+		}
+	}
+	for level := 1; level <= 2; level++ {
+		for id, e := range d.db[level] {
+			switch {
+			case e.purged() && !now.Before(e.expires):
+				delete(d.db[level], id)
+			case e.purged():
+			case !e.refresh.IsZero() && !now.Before(e.refresh):
+				d.originateLSP(level, id, e.lsp.seq+1, e.lsp.flags, e.lsp.raw[lspHeaderLength:], now)
+			case !now.Before(e.expires):
+				d.purge(level, e, now)
+			}
+		}
+	}
+	d.update(now)
+}
+
+// update regenerates the LSPs, runs the SPF and sends the PDUs that are due. The caller must hold mu.
+func (d *Daemon) update(now time.Time) {
+	if d.cfg != nil && d.lspDirty {
+		for _, c := range d.circuits {
+			for level := 1; level <= 2; level++ {
+				if c.lvl[level] != nil && !c.cfg.PointToPoint {
+					d.elect(c, level, now)
+				}
+			}
+		}
+		d.originate(now)
+	}
+	if d.spfDirty {
+		d.runSPF()
+		if d.lspDirty {
+			d.originate(now)
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(d.circuits)) {
+		d.transmit(d.circuits[id], now)
+	}
+}
+
+// transmit sends the hellos, the LSPs and the SNPs that are due on the circuit. The caller must hold mu.
+func (d *Daemon) transmit(c *circuit, now time.Time) {
+	if c.cfg.PointToPoint && !now.Before(c.nextHello) {
+		c.nextHello = now.Add(c.cfg.HelloInterval)
+		d.send(c, 0, d.hello(c, pduP2PHello).marshal())
+	}
+	for level := 1; level <= 2; level++ {
+		cl := c.lvl[level]
+		if cl == nil {
+			continue
+		}
+		if !c.cfg.PointToPoint && !now.Before(cl.nextHello) {
+			cl.nextHello = now.Add(c.cfg.HelloInterval)
+			pduType := uint8(pduL1LANHello)
+			if level == 2 {
+				pduType = pduL2LANHello
+			}
+			d.send(c, level, d.hello(c, pduType).marshal())
+		}
+		if !d.levelUp(c, level, nil) {
+			clear(cl.srm)
+			clear(cl.ssn)
+			continue
+		}
+		for _, id := range slices.SortedFunc(maps.Keys(cl.srm), func(a, b LSPID) int { return bytes.Compare(a[:], b[:]) }) {
+			if cl.srm[id].After(now) {
+				continue
+			}
+			e := d.db[level][id]
+			if e == nil {
+				delete(cl.srm, id)
+				continue
+			}
+			if c.cfg.PointToPoint {
+				// Retransmitted until it is acknowledged.
+				cl.srm[id] = now.Add(retransmitInterval)
+			} else {
+				delete(cl.srm, id)
+			}
+			raw := bytes.Clone(e.lsp.raw)
+			rem := e.remaining(now)
+			raw[10], raw[11] = uint8(rem>>8), uint8(rem)
+			d.send(c, level, raw)
+		}
+		if len(cl.ssn) > 0 {
+			s := &snp{pduType: psnpType(level), source: nodeOf(d.cfg.SystemID, 0)}
+			for _, id := range slices.SortedFunc(maps.Keys(cl.ssn), func(a, b LSPID) int { return bytes.Compare(a[:], b[:]) }) {
+				if e := d.db[level][id]; e != nil {
+					s.entries = append(s.entries, e.entry(now))
+				} else {
+					s.entries = append(s.entries, lspEntry{id: id})
+				}
+			}
+			clear(cl.ssn)
+			for _, entries := range slices.Collect(slices.Chunk(s.entries, maxSNPEntries)) {
+				d.send(c, level, (&snp{pduType: s.pduType, source: s.source, entries: entries}).marshal())
+			}
+		}
+		if cl.dis && !now.Before(cl.nextCSNP) {
+			cl.nextCSNP = now.Add(csnpInterval)
+			d.sendCSNPs(c, level, now)
+		}
+	}
+}
+
+// maxSNPEntries is the number of LSP entries of the SNPs, which fit in an Ethernet frame.
+const maxSNPEntries = 90
+
+func psnpType(level int) uint8 {
+	if level == 2 {
+		return pduL2PSNP
+	}
+	return pduL1PSNP
+}
+
+// sendCSNPs sends the CSNPs that describe the database of the level. The caller must hold mu.
+func (d *Daemon) sendCSNPs(c *circuit, level int, now time.Time) {
+	pduType := uint8(pduL1CSNP)
+	if level == 2 {
+		pduType = pduL2CSNP
+	}
+	ids := slices.SortedFunc(maps.Keys(d.db[level]), func(a, b LSPID) int { return bytes.Compare(a[:], b[:]) })
+	last := LSPID{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	start := LSPID{}
+	for i := 0; i == 0 || i < len(ids); i += maxSNPEntries {
+		s := &snp{pduType: pduType, source: nodeOf(d.cfg.SystemID, 0), start: start, end: last}
+		chunk := ids[i:min(i+maxSNPEntries, len(ids))]
+		if i+maxSNPEntries < len(ids) {
+			s.end = chunk[len(chunk)-1]
+			start = ids[i+maxSNPEntries]
+		}
+		for _, id := range chunk {
+			s.entries = append(s.entries, d.db[level][id].entry(now))
+		}
+		d.send(c, level, s.marshal())
+	}
+}
+
+// hello returns the hello of the circuit. The caller must hold mu.
+func (d *Daemon) hello(c *circuit, pduType uint8) *hello {
+	h := &hello{
+		pduType:     pduType,
+		circuitType: c.levels,
+		source:      d.cfg.SystemID,
+		holdTime:    uint16(min(math.MaxUint16, max(1, int(c.cfg.HelloInterval.Seconds())*c.cfg.HelloMultiplier))),
+		priority:    c.cfg.Priority,
+		circuitID:   c.id,
+		areas:       d.cfg.Areas,
+		protocols:   []byte{NLPIDIPv4, NLPIDIPv6},
+	}
+	for _, p := range c.cfg.Prefixes {
+		switch {
+		case p.Addr().Is4():
+			h.ipv4 = append(h.ipv4, p.Addr())
+		case !p.Addr().IsLinkLocalUnicast():
+			h.ipv6Global = append(h.ipv6Global, p.Addr())
+		}
+	}
+	if c.cfg.LinkLocal.IsValid() {
+		h.ipv6 = []netip.Addr{c.cfg.LinkLocal}
+	}
+	if pduType == pduP2PHello {
+		t := &p2pAdjacency{state: p2pStateDown, localCircuit: uint32(c.id)}
+		if a := c.p2p; a != nil {
+			t.hasNeighbor = true
+			t.neighbor = a.system
+			t.neighborCircuit = a.circuitID
+			switch a.state {
+			case AdjacencyInit:
+				t.state = p2pStateInit
+			case AdjacencyUp:
+				t.state = p2pStateUp
+			}
+		}
+		h.p2p = t
+		return h
+	}
+	cl := c.lvl[levelOf(pduType, pduL2LANHello)]
+	h.lanID = cl.lanID
+	if h.lanID == (NodeID{}) {
+		h.lanID = nodeOf(d.cfg.SystemID, c.id)
+	}
+	for _, id := range slices.SortedFunc(maps.Keys(cl.adjs), func(a, b SystemID) int { return bytes.Compare(a[:], b[:]) }) {
+		h.neighbors = append(h.neighbors, cl.adjs[id].mac)
+	}
+	return h
+}
+
+// send sends the PDU on the circuit, to the destination of the level on broadcast circuits.
+// The caller must hold mu.
+func (d *Daemon) send(c *circuit, level int, pdu []byte) {
+	dst := allISs
+	switch {
+	case c.cfg.PointToPoint:
+	case level == 2:
+		dst = allL2ISs
+	default:
+		dst = allL1ISs
+	}
+	err := d.sender.Send(&pktiopb.PacketIn{
+		Msg: &pktiopb.PacketIn_Packet{
+			Packet: &pktiopb.Packet{
+				HostPort: c.cfg.HostPort,
+				Frame:    frame(dst, c.cfg.MAC, pdu),
+			},
+		},
+	})
+	if err != nil {
+		log.Warningf("failed to send IS-IS PDU on %q: %v", c.cfg.Name, err)
+	}
+}
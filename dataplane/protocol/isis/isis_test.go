@@ -0,0 +1,624 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isis
+
+import (
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gnmi/errdiff"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+func TestParseNET(t *testing.T) {
+	area, sys, err := ParseNET("49.0001.1921.6800.1001.00")
+	if err != nil {
+		t.Fatalf("ParseNET() unexpected err: %v", err)
+	}
+	if got, want := areaString(area), "49.0001"; got != want {
+		t.Errorf("ParseNET() got area %s, want %s", got, want)
+	}
+	if got, want := sys.String(), "1921.6800.1001"; got != want {
+		t.Errorf("ParseNET() got system ID %s, want %s", got, want)
+	}
+	for _, net := range []string{"49.0001.1921.6800.1001.01", "1921.6800.1001.00", "49.zz01.1921.6800.1001.00"} {
+		if _, _, err := ParseNET(net); err == nil {
+			t.Errorf("ParseNET(%q) got no error", net)
+		}
+	}
+}
+
+func TestPDU(t *testing.T) {
+	mac := net.HardwareAddr{2, 0, 0, 0, 0, 1}
+	sys := SystemID{0, 0, 0, 0, 0, 1}
+	hellos := []*hello{{
+		pduType:     pduL1LANHello,
+		circuitType: Level12,
+		source:      sys,
+		holdTime:    30,
+		priority:    64,
+		lanID:       nodeOf(sys, 1),
+		areas:       [][]byte{{0x49, 0, 1}},
+		neighbors:   []net.HardwareAddr{{2, 0, 0, 0, 0, 2}},
+		protocols:   []byte{NLPIDIPv4, NLPIDIPv6},
+		ipv4:        []netip.Addr{netip.MustParseAddr("192.0.2.1")},
+		ipv6:        []netip.Addr{netip.MustParseAddr("fe80::1")},
+		ipv6Global:  []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+	}, {
+		pduType:     pduP2PHello,
+		circuitType: Level2,
+		source:      sys,
+		holdTime:    30,
+		circuitID:   3,
+		areas:       [][]byte{{0x49, 0, 2}},
+		p2p:         &p2pAdjacency{state: p2pStateInit, localCircuit: 3, neighbor: SystemID{0, 0, 0, 0, 0, 2}, neighborCircuit: 1, hasNeighbor: true},
+	}}
+	for _, want := range hellos {
+		pduType, b, err := parseHeader(want.marshal())
+		if err != nil {
+			t.Fatalf("parseHeader() unexpected err: %v", err)
+		}
+		got, err := parseHello(pduType, b)
+		if err != nil {
+			t.Fatalf("parseHello() unexpected err: %v", err)
+		}
+		if d := cmp.Diff(got, want, cmp.AllowUnexported(hello{}, p2pAdjacency{}), cmpopts.EquateComparable(netip.Addr{})); d != "" {
+			t.Errorf("parseHello() failed: diff(-got,+want)\n:%s", d)
+		}
+	}
+
+	content := &lsp{
+		areas:     [][]byte{{0x49, 0, 1}},
+		protocols: []byte{NLPIDIPv4, NLPIDIPv6},
+		hostname:  "r1",
+		ipv4Addrs: []netip.Addr{netip.MustParseAddr("192.0.2.1")},
+		ipv6Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+		neighbors: []isReach{{neighbor: nodeOf(SystemID{0, 0, 0, 0, 0, 2}, 0), metric: 10}, {neighbor: nodeOf(sys, 1), metric: 20}},
+		prefixes: []prefixReach{
+			{prefix: netip.MustParsePrefix("10.0.0.0/8"), metric: 10},
+			{prefix: netip.MustParsePrefix("192.0.2.0/25"), metric: 5, down: true},
+			{prefix: netip.MustParsePrefix("2001:db8::/32"), metric: 7},
+		},
+	}
+	bodies := fragmentTLVs(content.tlvs())
+	if len(bodies) != 1 {
+		t.Fatalf("fragmentTLVs() got %d fragments, want 1", len(bodies))
+	}
+	id := lspOf(nodeOf(sys, 0), 0)
+	raw := marshalLSP(2, id, 1200, 7, lspTypeL2|lspFlagAttached, bodies[0])
+	frame := frame(allL2ISs, mac, raw)
+	if !isISIS(frame) {
+		t.Fatalf("isISIS() got false, want true")
+	}
+	src, pdu, err := parseFrame(frame)
+	if err != nil || src.String() != mac.String() {
+		t.Fatalf("parseFrame() got source %v, err %v, want %v", src, err, mac)
+	}
+	pduType, b, err := parseHeader(pdu)
+	if err != nil || pduType != pduL2LSP {
+		t.Fatalf("parseHeader() got type %d, err %v, want LSP", pduType, err)
+	}
+	got, err := parseLSP(b)
+	if err != nil {
+		t.Fatalf("parseLSP() unexpected err: %v", err)
+	}
+	want := *content
+	want.id, want.lifetime, want.seq, want.flags = id, 1200, 7, lspTypeL2|lspFlagAttached
+	if d := cmp.Diff(got, &want, cmp.AllowUnexported(lsp{}, isReach{}, prefixReach{}), cmpopts.EquateComparable(netip.Addr{}, netip.Prefix{}),
+		cmpopts.IgnoreFields(lsp{}, "checksum", "raw")); d != "" {
+		t.Errorf("parseLSP() failed: diff(-got,+want)\n:%s", d)
+	}
+	if got.level() != 2 {
+		t.Errorf("level() got %d, want 2", got.level())
+	}
+
+	wantSNP := &snp{
+		pduType: pduL1CSNP,
+		source:  nodeOf(sys, 0),
+		end:     LSPID{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		entries: []lspEntry{{lifetime: 1000, id: id, seq: 7, checksum: got.checksum}},
+	}
+	pduType, b, err = parseHeader(wantSNP.marshal())
+	if err != nil {
+		t.Fatalf("parseHeader() unexpected err: %v", err)
+	}
+	gotSNP, err := parseSNP(pduType, b)
+	if err != nil {
+		t.Fatalf("parseSNP() unexpected err: %v", err)
+	}
+	if d := cmp.Diff(gotSNP, wantSNP, cmp.AllowUnexported(snp{}, lspEntry{})); d != "" {
+		t.Errorf("parseSNP() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	modify := func(f func(b []byte)) []byte {
+		c := append([]byte{}, raw...)
+		f(c)
+		return c
+	}
+	tests := []struct {
+		desc    string
+		b       []byte
+		wantErr string
+	}{{
+		desc:    "truncated",
+		b:       raw[:6],
+		wantErr: "too short",
+	}, {
+		desc:    "discriminator",
+		b:       modify(func(b []byte) { b[0] = 0x82 }),
+		wantErr: "discriminator",
+	}, {
+		desc:    "ID length",
+		b:       modify(func(b []byte) { b[3] = 8 }),
+		wantErr: "ID length",
+	}, {
+		desc:    "PDU length",
+		b:       modify(func(b []byte) { b[8] = 0xFF }),
+		wantErr: "PDU length",
+	}, {
+		desc:    "checksum",
+		b:       modify(func(b []byte) { b[len(b)-1]++ }),
+		wantErr: "checksum",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pduType, b, err := parseHeader(tt.b)
+			if err == nil {
+				_, err = parseLSP(b)
+				if pduType != pduL2LSP {
+					t.Fatalf("parseHeader() got type %d, want LSP", pduType)
+				}
+			}
+			if diff := errdiff.Check(err, tt.wantErr); diff != "" {
+				t.Errorf("parse unexpected err: %s", diff)
+			}
+		})
+	}
+}
+
+func TestFragments(t *testing.T) {
+	l := &lsp{hostname: "r1"}
+	for i := 0; i < 400; i++ {
+		l.prefixes = append(l.prefixes, prefixReach{prefix: netip.PrefixFrom(netip.AddrFrom4([4]byte{10, byte(i >> 8), byte(i), 0}), 24), metric: 10})
+	}
+	bodies := fragmentTLVs(l.tlvs())
+	if len(bodies) < 2 {
+		t.Fatalf("fragmentTLVs() got %d fragments, want several", len(bodies))
+	}
+	var got []prefixReach
+	for i, body := range bodies {
+		raw := marshalLSP(1, lspOf(NodeID{}, uint8(i)), 1200, 1, lspTypeL1, body)
+		if len(raw) > maxLSPSize {
+			t.Errorf("fragment %d got %d bytes, want at most %d", i, len(raw), maxLSPSize)
+		}
+		f, err := parseLSP(raw)
+		if err != nil {
+			t.Fatalf("parseLSP() unexpected err: %v", err)
+		}
+		got = append(got, f.prefixes...)
+	}
+	if d := cmp.Diff(got, l.prefixes, cmp.AllowUnexported(prefixReach{}), cmpopts.EquateComparable(netip.Prefix{})); d != "" {
+		t.Errorf("prefixes of the fragments: diff(-got,+want)\n:%s", d)
+	}
+}
+
+// fakeSender records the packets sent by a daemon.
+type fakeSender struct {
+	mu   sync.Mutex
+	pkts []*pktiopb.Packet
+}
+
+func (s *fakeSender) Send(p *pktiopb.PacketIn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pkts = append(s.pkts, p.GetPacket())
+	return nil
+}
+
+func (s *fakeSender) take() []*pktiopb.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pkts := s.pkts
+	s.pkts = nil
+	return pkts
+}
+
+// port is a port of a peer.
+type port struct {
+	peer *peer
+	id   uint64
+}
+
+// peer is a daemon connected to other daemons, the packets sent with a host port
+// are received by the other ports of its segment.
+type peer struct {
+	d       *Daemon
+	sender  *fakeSender
+	links   map[uint64][]port
+	routes  chan []*Route
+	silent  bool // Whether the packets of the peer are dropped.
+	network *network
+}
+
+// network is a set of peers sharing a clock.
+type network struct {
+	now   time.Time
+	peers []*peer
+}
+
+func (n *network) newPeer(t *testing.T, cfg *Config) *peer {
+	t.Helper()
+	p := &peer{sender: &fakeSender{}, links: map[uint64][]port{}, routes: make(chan []*Route, 100), network: n}
+	d, err := New(p.sender, func(routes []*Route) { p.routes <- routes })
+	if err != nil {
+		t.Fatalf("New() unexpected err: %v", err)
+	}
+	d.now = func() time.Time { return n.now }
+	p.d = d
+	n.peers = append(n.peers, p)
+	d.Configure(cfg)
+	return p
+}
+
+// connect attaches the ports to a segment, the host port of a port is its ID plus 100.
+func connect(ports ...port) {
+	for _, a := range ports {
+		for _, b := range ports {
+			if a != b {
+				a.peer.links[a.id+100] = append(a.peer.links[a.id+100], b)
+			}
+		}
+	}
+}
+
+// exchange delivers the packets sent by the peers over their links until they stop sending.
+func (n *network) exchange(t *testing.T) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		sent := false
+		for _, p := range n.peers {
+			for _, pkt := range p.sender.take() {
+				if p.silent {
+					continue
+				}
+				sent = true
+				for _, l := range p.links[pkt.GetHostPort()] {
+					po := &pktiopb.PacketOut{Packet: &pktiopb.Packet{InputPort: l.id, Frame: pkt.GetFrame()}}
+					if !l.peer.d.Matched(po) {
+						t.Fatalf("Matched() got false for IS-IS PDU")
+					}
+					if err := l.peer.d.Process(po); err != nil {
+						t.Fatalf("Process() unexpected err: %v", err)
+					}
+				}
+			}
+		}
+		if !sent {
+			return
+		}
+	}
+	t.Fatalf("exchange() did not converge")
+}
+
+// run runs the timers of the peers and exchanges their packets for d.
+func (n *network) run(t *testing.T, d time.Duration) {
+	t.Helper()
+	n.exchange(t)
+	end := n.now.Add(d)
+	for n.now.Before(end) {
+		n.now = n.now.Add(tickInterval)
+		for _, p := range n.peers {
+			p.d.tick(n.now)
+		}
+		n.exchange(t)
+	}
+}
+
+// lastRoutes returns the last notification of the routes of the peer.
+func lastRoutes(t *testing.T, p *peer) []*Route {
+	t.Helper()
+	var routes []*Route
+	for {
+		select {
+		case routes = <-p.routes:
+		case <-time.After(100 * time.Millisecond):
+			return routes
+		}
+	}
+}
+
+func config(sys byte, area string, levels Levels, intfs ...*Interface) *Config {
+	a, _, err := ParseNET(area + ".0000.0000.0000.00")
+	if err != nil {
+		panic(err)
+	}
+	return &Config{
+		SystemID:   SystemID{0, 0, 0, 0, 0, sys},
+		Areas:      [][]byte{a},
+		Levels:     levels,
+		Hostname:   "r" + string(rune('0'+sys)),
+		Interfaces: intfs,
+	}
+}
+
+func intf(sys byte, id uint64, p2p bool, prefixes ...string) *Interface {
+	i := &Interface{
+		Name:         "eth" + string(rune('0'+id)),
+		PortID:       id,
+		HostPort:     id + 100,
+		MAC:          net.HardwareAddr{2, 0, 0, 0, sys, byte(id)},
+		PointToPoint: p2p,
+		Priority:     64,
+		LinkLocal:    netip.AddrFrom16([16]byte{0: 0xfe, 1: 0x80, 14: sys, 15: byte(id)}),
+	}
+	for _, p := range prefixes {
+		i.Prefixes = append(i.Prefixes, netip.MustParsePrefix(p))
+	}
+	return i
+}
+
+func loopback(prefixes ...string) *Interface {
+	i := &Interface{Name: "lo", Passive: true, Metric: 1}
+	for _, p := range prefixes {
+		i.Prefixes = append(i.Prefixes, netip.MustParsePrefix(p))
+	}
+	return i
+}
+
+var routeOpts = []cmp.Option{cmpopts.EquateComparable(netip.Addr{}, netip.Prefix{})}
+
+func TestPointToPoint(t *testing.T) {
+	n := &network{now: time.Unix(1000, 0)}
+	a := n.newPeer(t, config(1, "49.0001", Level2, intf(1, 1, true, "192.0.2.1/24", "2001:db8:12::1/64"), loopback("10.0.0.1/32", "2001:db8::1/128")))
+	b := n.newPeer(t, config(2, "49.0002", Level2, intf(2, 1, true, "192.0.2.2/24", "2001:db8:12::2/64")))
+	connect(port{a, 1}, port{b, 1})
+	n.run(t, time.Second)
+
+	adjs := b.d.Adjacencies()
+	wantAdjs := []*Adjacency{{
+		Interface:         "eth1",
+		Level:             2,
+		SystemID:          a.d.cfg.SystemID,
+		State:             AdjacencyUp,
+		PointToPoint:      true,
+		SNPA:              net.HardwareAddr{2, 0, 0, 0, 1, 1},
+		CircuitType:       Level2,
+		LocalCircuitID:    1,
+		NeighborCircuitID: 1,
+		Areas:             []string{"49.0001"},
+		Protocols:         []byte{NLPIDIPv4, NLPIDIPv6},
+		IPv4:              []netip.Addr{netip.MustParseAddr("192.0.2.1")},
+		IPv6:              []netip.Addr{netip.MustParseAddr("fe80::101"), netip.MustParseAddr("2001:db8:12::1")},
+		UpSince:           n.now.Add(-time.Second),
+	}}
+	if d := cmp.Diff(adjs, wantAdjs, routeOpts...); d != "" {
+		t.Errorf("Adjacencies() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	wantRoutes := []*Route{{
+		Prefix:   netip.MustParsePrefix("10.0.0.1/32"),
+		Level:    2,
+		Metric:   11,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("192.0.2.1"), Interface: "eth1"}},
+	}, {
+		Prefix:   netip.MustParsePrefix("2001:db8::1/128"),
+		Level:    2,
+		Metric:   11,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("2001:db8:12::1"), Interface: "eth1"}},
+	}}
+	if d := cmp.Diff(lastRoutes(t, b), wantRoutes, routeOpts...); d != "" {
+		t.Errorf("routes of b: diff(-got,+want)\n:%s", d)
+	}
+	if d := cmp.Diff(b.d.Routes(), wantRoutes, routeOpts...); d != "" {
+		t.Errorf("Routes() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	// Both databases have the LSPs of both systems.
+	for _, p := range []*peer{a, b} {
+		db := p.d.Database()
+		if len(db) != 2 {
+			t.Fatalf("Database() got %d LSPs, want 2", len(db))
+		}
+		if db[0].Hostname != "r1" || db[1].Hostname != "r2" {
+			t.Errorf("Database() got hostnames %q and %q, want r1 and r2", db[0].Hostname, db[1].Hostname)
+		}
+		want := []Neighbor{{ID: nodeOf(b.d.cfg.SystemID, 0), Metric: defaultMetric}}
+		if d := cmp.Diff(db[0].Neighbors, want); d != "" {
+			t.Errorf("Database() got neighbors of r1: diff(-got,+want)\n:%s", d)
+		}
+		if db[0].ISType != Level12 || db[0].Level != 2 {
+			t.Errorf("Database() got LSP %+v, want level 2 LSP of level 2 system", db[0])
+		}
+	}
+
+	// The LSPs are refreshed before they expire.
+	seq := a.d.Database()[0].Sequence
+	n.run(t, defaultLSPRefresh)
+	if got := b.d.Database()[0]; got.Sequence <= seq || got.RemainingLifetime < 1000 {
+		t.Errorf("Database() got LSP %+v after refresh interval, want sequence number above %d", got, seq)
+	}
+
+	// a stops sending, the adjacency times out after 30 seconds and the routes are withdrawn.
+	a.silent = true
+	n.run(t, 31*time.Second)
+	if got := b.d.Adjacencies(); len(got) != 0 {
+		t.Errorf("Adjacencies() got %v after hold time, want none", got)
+	}
+	if got := lastRoutes(t, b); len(got) != 0 {
+		t.Errorf("routes of b: got %v after hold time, want none", got)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	n := &network{now: time.Unix(1000, 0)}
+	i1 := intf(1, 1, false, "192.0.2.1/24")
+	i2 := intf(2, 1, false, "192.0.2.2/24")
+	i2.Priority = 100
+	i3 := intf(3, 1, false, "192.0.2.3/24")
+	r1 := n.newPeer(t, config(1, "49.0001", Level1, i1, loopback("10.0.0.1/32")))
+	r2 := n.newPeer(t, config(2, "49.0001", Level1, i2))
+	r3 := n.newPeer(t, config(3, "49.0001", Level1, i3, loopback("10.0.0.3/32")))
+	connect(port{r1, 1}, port{r2, 1}, port{r3, 1})
+	n.run(t, 11*time.Second)
+
+	// r2 has the highest priority and is the DIS.
+	lanID := nodeOf(r2.d.cfg.SystemID, 1)
+	for _, p := range []*peer{r1, r2, r3} {
+		adjs := p.d.Adjacencies()
+		if len(adjs) != 2 {
+			t.Fatalf("Adjacencies() got %d adjacencies, want 2", len(adjs))
+		}
+		for _, a := range adjs {
+			if a.State != AdjacencyUp || a.DIS != lanID || a.Level != 1 {
+				t.Errorf("Adjacencies() got %+v, want up adjacency with DIS %v", a, lanID)
+			}
+		}
+		// The pseudonode of a DIS elected before all the adjacencies were up may have been purged.
+		var ids []LSPID
+		for _, l := range p.d.Database() {
+			if l.RemainingLifetime != 0 {
+				ids = append(ids, l.ID)
+			}
+		}
+		want := []LSPID{lspOf(nodeOf(r1.d.cfg.SystemID, 0), 0), lspOf(nodeOf(r2.d.cfg.SystemID, 0), 0), lspOf(lanID, 0), lspOf(nodeOf(r3.d.cfg.SystemID, 0), 0)}
+		if d := cmp.Diff(ids, want); d != "" {
+			t.Errorf("Database() got LSP IDs: diff(-got,+want)\n:%s", d)
+		}
+	}
+
+	wantRoutes := []*Route{{
+		Prefix:   netip.MustParsePrefix("10.0.0.1/32"),
+		Level:    1,
+		Metric:   11,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("192.0.2.1"), Interface: "eth1"}},
+	}}
+	if d := cmp.Diff(lastRoutes(t, r3), wantRoutes, routeOpts...); d != "" {
+		t.Errorf("routes of r3: diff(-got,+want)\n:%s", d)
+	}
+
+	// The DIS leaves, r3 is elected with the highest address.
+	r2.silent = true
+	n.run(t, 31*time.Second)
+	lanID = nodeOf(r3.d.cfg.SystemID, 1)
+	for _, a := range r1.d.Adjacencies() {
+		if a.SystemID == r2.d.cfg.SystemID || a.DIS != lanID {
+			t.Errorf("Adjacencies() got %+v, want adjacency with r3 as DIS", a)
+		}
+	}
+	if d := cmp.Diff(r3.d.Routes(), wantRoutes, routeOpts...); d != "" {
+		t.Errorf("routes of r3 with new DIS: diff(-got,+want)\n:%s", d)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	// r1 is in area 49.0001 at level 1, r2 is in the same area at both levels and r3 is
+	// in area 49.0002 at level 2.
+	n := &network{now: time.Unix(1000, 0)}
+	r1 := n.newPeer(t, config(1, "49.0001", Level1, intf(1, 1, true, "192.0.2.1/24"), loopback("10.0.0.1/32")))
+	r2 := n.newPeer(t, config(2, "49.0001", Level12, intf(2, 1, true, "192.0.2.2/24"), intf(2, 2, true, "198.51.100.2/24")))
+	r3 := n.newPeer(t, config(3, "49.0002", Level2, intf(3, 2, true, "198.51.100.3/24"), loopback("10.0.0.3/32")))
+	connect(port{r1, 1}, port{r2, 1})
+	connect(port{r2, 2}, port{r3, 2})
+	n.run(t, time.Second)
+
+	// r2 advertises the level 1 routes at level 2.
+	want := []*Route{{
+		Prefix:   netip.MustParsePrefix("10.0.0.1/32"),
+		Level:    2,
+		Metric:   21,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("198.51.100.2"), Interface: "eth2"}},
+	}, {
+		Prefix:   netip.MustParsePrefix("192.0.2.0/24"),
+		Level:    2,
+		Metric:   20,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("198.51.100.2"), Interface: "eth2"}},
+	}}
+	if d := cmp.Diff(lastRoutes(t, r3), want, routeOpts...); d != "" {
+		t.Errorf("routes of r3: diff(-got,+want)\n:%s", d)
+	}
+
+	// r2 is attached to another area, r1 routes to it by default.
+	want = []*Route{{
+		Prefix:   netip.MustParsePrefix("0.0.0.0/0"),
+		Level:    1,
+		Metric:   10,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("192.0.2.2"), Interface: "eth1"}},
+	}, {
+		Prefix:   netip.MustParsePrefix("198.51.100.0/24"),
+		Level:    1,
+		Metric:   20,
+		NextHops: []NextHop{{Address: netip.MustParseAddr("192.0.2.2"), Interface: "eth1"}},
+	}}
+	if d := cmp.Diff(lastRoutes(t, r1), want, routeOpts...); d != "" {
+		t.Errorf("routes of r1: diff(-got,+want)\n:%s", d)
+	}
+	for _, l := range r1.d.Database() {
+		if l.ID.Node() == nodeOf(r2.d.cfg.SystemID, 0) && !l.Attached {
+			t.Errorf("Database() got level 1 LSP %+v of r2 without attached flag", l)
+		}
+		if l.Level != 1 {
+			t.Errorf("Database() of r1 got level %d LSP %v", l.Level, l.ID)
+		}
+	}
+
+	// r2 routes to r1 at level 1, although it has the level 2 path to the same prefixes.
+	for _, r := range r2.d.Routes() {
+		if r.Prefix == netip.MustParsePrefix("10.0.0.1/32") && r.Level != 1 {
+			t.Errorf("Routes() of r2 got %+v, want level 1 route", r)
+		}
+	}
+}
+
+func TestRestart(t *testing.T) {
+	n := &network{now: time.Unix(1000, 0)}
+	cfgA := config(1, "49.0001", Level2, intf(1, 1, true, "192.0.2.1/24"))
+	a := n.newPeer(t, cfgA)
+	b := n.newPeer(t, config(2, "49.0001", Level2, intf(2, 1, true, "192.0.2.2/24")))
+	connect(port{a, 1}, port{b, 1})
+	n.run(t, time.Second)
+	// a reoriginates its LSP a few times.
+	for i := 0; i < 3; i++ {
+		n.run(t, defaultLSPRefresh)
+	}
+	seq := b.d.Database()[0].Sequence
+
+	// a restarts, it takes over its LSP from b with a higher sequence number.
+	n.peers = []*peer{b}
+	a2 := n.newPeer(t, cfgA)
+	b.links = map[uint64][]port{}
+	connect(port{a2, 1}, port{b, 1})
+	n.run(t, 31*time.Second)
+	got := a2.d.Database()[0]
+	if got.Sequence <= seq {
+		t.Errorf("Database() got sequence number %d after restart, want above %d", got.Sequence, seq)
+	}
+	if d := cmp.Diff(b.d.Database()[0], got, routeOpts...); d != "" {
+		t.Errorf("LSP of a in b: diff(-got,+want)\n:%s", d)
+	}
+	if err := a2.d.Process(&pktiopb.PacketOut{Packet: &pktiopb.Packet{InputPort: 2, Frame: make([]byte, 60)}}); err == nil {
+		t.Errorf("Process() got no error for port that is not a circuit")
+	}
+
+	a2.d.Configure(nil)
+	if got := a2.d.Adjacencies(); len(got) != 0 {
+		t.Errorf("Adjacencies() got %d adjacencies after removing the configuration", len(got))
+	}
+}
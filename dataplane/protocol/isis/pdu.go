@@ -0,0 +1,864 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isis
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+var (
+	// allL1ISs and allL2ISs are the destinations of the PDUs of each level on broadcast circuits.
+	allL1ISs = net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x14}
+	allL2ISs = net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x15}
+	// allISs is the destination of the PDUs on point-to-point circuits.
+	allISs = net.HardwareAddr{0x09, 0x00, 0x2B, 0x00, 0x00, 0x05}
+	// llc is the 802.2 LLC header of the IS-IS frames.
+	llc = []byte{0xFE, 0xFE, 0x03}
+)
+
+const (
+	discriminator = 0x83
+	version       = 1
+	// maxLSPSize is the size of the originated LSPs, which fits in the Ethernet MTU with the LLC header.
+	maxLSPSize = 1492
+	// maxWideMetric is the maximum metric of an extended IS reachability.
+	maxWideMetric = 0xFFFFFE
+	// maxPathMetric is the maximum metric of a route, larger paths are unreachable.
+	maxPathMetric = 0xFE000000
+
+	pduL1LANHello = 15
+	pduL2LANHello = 16
+	pduP2PHello   = 17
+	pduL1LSP      = 18
+	pduL2LSP      = 20
+	pduL1CSNP     = 24
+	pduL2CSNP     = 25
+	pduL1PSNP     = 26
+	pduL2PSNP     = 27
+
+	lanHelloHeaderLength = 27
+	p2pHelloHeaderLength = 20
+	lspHeaderLength      = 27
+	csnpHeaderLength     = 33
+	psnpHeaderLength     = 17
+
+	tlvAreaAddresses       = 1
+	tlvISNeighbors         = 6
+	tlvLSPEntries          = 9
+	tlvExtISReach          = 22
+	tlvProtocols           = 129
+	tlvIPv4Addresses       = 132
+	tlvExtIPReach          = 135
+	tlvHostname            = 137
+	tlvIPv6Addresses       = 232
+	tlvIPv6GlobalAddresses = 233
+	tlvIPv6Reach           = 236
+	tlvP2PAdjacency        = 240
+
+	lspFlagPartition = 0x80
+	lspFlagAttached  = 0x08 // The default metric bit of the attached flags.
+	lspFlagOverload  = 0x04
+	lspTypeL1        = 0x01
+	lspTypeL2        = 0x03
+
+	// The extended IP reachability control bits.
+	extIPDown   = 0x80
+	extIPSubTLV = 0x40
+	// The IPv6 reachability control bits.
+	ipv6Down     = 0x80
+	ipv6External = 0x40
+	ipv6SubTLV   = 0x20
+)
+
+// Levels is a set of IS-IS levels, it is also the circuit type of the hellos.
+type Levels uint8
+
+const (
+	Level1  Levels = 1
+	Level2  Levels = 2
+	Level12 Levels = Level1 | Level2
+)
+
+// Has returns whether the set contains the level, 1 or 2.
+func (l Levels) Has(level int) bool {
+	return level >= 1 && level <= 2 && l&(1<<(level-1)) != 0
+}
+
+// Level returns the set of the level, 1 or 2.
+func Level(level int) Levels {
+	return Levels(1 << (level - 1))
+}
+
+func (l Levels) String() string {
+	switch l {
+	case Level1:
+		return "L1"
+	case Level2:
+		return "L2"
+	case Level12:
+		return "L1L2"
+	}
+	return fmt.Sprintf("LEVELS_%d", uint8(l))
+}
+
+// The NLPIDs of the protocols supported by the systems.
+const (
+	NLPIDIPv4 = 0xCC
+	NLPIDIPv6 = 0x8E
+)
+
+// SystemID is the identifier of an intermediate system.
+type SystemID [6]byte
+
+func (id SystemID) String() string {
+	return fmt.Sprintf("%02x%02x.%02x%02x.%02x%02x", id[0], id[1], id[2], id[3], id[4], id[5])
+}
+
+// NodeID identifies a system, or a pseudonode of a broadcast circuit if its last byte is not zero.
+type NodeID [7]byte
+
+func (id NodeID) String() string {
+	return fmt.Sprintf("%v.%02x", id.System(), id[6])
+}
+
+// System returns the system of the node, the designated system of a pseudonode.
+func (id NodeID) System() SystemID {
+	return SystemID(id[:6])
+}
+
+// Pseudonode returns whether the node is a pseudonode.
+func (id NodeID) Pseudonode() bool {
+	return id[6] != 0
+}
+
+func nodeOf(sys SystemID, pseudonode uint8) NodeID {
+	var id NodeID
+	copy(id[:], sys[:])
+	id[6] = pseudonode
+	return id
+}
+
+// LSPID identifies a fragment of the LSP of a node.
+type LSPID [8]byte
+
+func (id LSPID) String() string {
+	return fmt.Sprintf("%v-%02x", id.Node(), id[7])
+}
+
+// Node returns the node that originated the LSP.
+func (id LSPID) Node() NodeID {
+	return NodeID(id[:7])
+}
+
+// Fragment returns the fragment number of the LSP.
+func (id LSPID) Fragment() uint8 {
+	return id[7]
+}
+
+func lspOf(node NodeID, fragment uint8) LSPID {
+	var id LSPID
+	copy(id[:], node[:])
+	id[7] = fragment
+	return id
+}
+
+// ParseNET returns the area address and the system ID of a network entity title,
+// such as 49.0001.0000.0000.0001.00.
+func ParseNET(net string) ([]byte, SystemID, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(net, ".", ""))
+	if err != nil {
+		return nil, SystemID{}, fmt.Errorf("invalid NET %q: %v", net, err)
+	}
+	// The area is at least one byte, followed by the system ID and the NSEL.
+	if len(b) < 8 || len(b) > 20 {
+		return nil, SystemID{}, fmt.Errorf("invalid NET %q: length %d", net, len(b))
+	}
+	if b[len(b)-1] != 0 {
+		return nil, SystemID{}, fmt.Errorf("invalid NET %q: NSEL is not zero", net)
+	}
+	return b[:len(b)-7], SystemID(b[len(b)-7 : len(b)-1]), nil
+}
+
+// areaString returns the area address in the dotted format of the NETs.
+func areaString(area []byte) string {
+	s := hex.EncodeToString(area[:1])
+	for i := 1; i < len(area); i += 2 {
+		s += "." + hex.EncodeToString(area[i:min(i+2, len(area))])
+	}
+	return s
+}
+
+// tlv is a type-length-value field of a PDU.
+type tlv struct {
+	typ   uint8
+	value []byte
+}
+
+func appendTLV(b []byte, typ uint8, value []byte) []byte {
+	b = append(b, typ, uint8(len(value)))
+	return append(b, value...)
+}
+
+// appendTLVs appends the entries in as many TLVs of the type as needed, each TLV holds as many
+// entries as fit in 255 bytes.
+func appendTLVs(b []byte, typ uint8, entries [][]byte) []byte {
+	var value []byte
+	for _, e := range entries {
+		if len(value)+len(e) > 255 {
+			b = appendTLV(b, typ, value)
+			value = nil
+		}
+		value = append(value, e...)
+	}
+	if len(value) > 0 {
+		b = appendTLV(b, typ, value)
+	}
+	return b
+}
+
+func parseTLVs(b []byte) ([]tlv, error) {
+	var tlvs []tlv
+	for len(b) > 0 {
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return nil, fmt.Errorf("truncated TLV")
+		}
+		tlvs = append(tlvs, tlv{typ: b[0], value: b[2 : 2+int(b[1])]})
+		b = b[2+int(b[1]):]
+	}
+	return tlvs, nil
+}
+
+// header returns the common header of a PDU.
+func header(pduType uint8, length uint8) []byte {
+	// The ID length and maximum area addresses are zero for 6 bytes IDs and 3 areas.
+	return []byte{discriminator, length, version, 0, pduType, version, 0, 0}
+}
+
+// parseHeader checks the common header of a PDU and returns its type and the PDU
+// truncated to its length.
+func parseHeader(b []byte) (uint8, []byte, error) {
+	if len(b) < 8 {
+		return 0, nil, fmt.Errorf("PDU too short: %d bytes", len(b))
+	}
+	if b[0] != discriminator {
+		return 0, nil, fmt.Errorf("invalid discriminator %#x", b[0])
+	}
+	if b[2] != version || b[5] != version {
+		return 0, nil, fmt.Errorf("unsupported version %d", b[2])
+	}
+	if b[3] != 0 && b[3] != 6 {
+		return 0, nil, fmt.Errorf("unsupported ID length %d", b[3])
+	}
+	if b[7] != 0 && b[7] != 3 {
+		return 0, nil, fmt.Errorf("unsupported maximum area addresses %d", b[7])
+	}
+	pduType := b[4] & 0x1f
+	var hdrLen, lenOffset int
+	switch pduType {
+	case pduL1LANHello, pduL2LANHello:
+		hdrLen, lenOffset = lanHelloHeaderLength, 17
+	case pduP2PHello:
+		hdrLen, lenOffset = p2pHelloHeaderLength, 17
+	case pduL1LSP, pduL2LSP:
+		hdrLen, lenOffset = lspHeaderLength, 8
+	case pduL1CSNP, pduL2CSNP:
+		hdrLen, lenOffset = csnpHeaderLength, 8
+	case pduL1PSNP, pduL2PSNP:
+		hdrLen, lenOffset = psnpHeaderLength, 8
+	default:
+		return 0, nil, fmt.Errorf("unsupported PDU type %d", pduType)
+	}
+	if int(b[1]) != hdrLen || len(b) < hdrLen {
+		return 0, nil, fmt.Errorf("invalid header length %d of PDU type %d", b[1], pduType)
+	}
+	pduLen := int(binary.BigEndian.Uint16(b[lenOffset:]))
+	if pduLen < hdrLen || pduLen > len(b) {
+		return 0, nil, fmt.Errorf("invalid PDU length %d of %d bytes", pduLen, len(b))
+	}
+	return pduType, b[:pduLen], nil
+}
+
+// frame returns the Ethernet frame of the PDU.
+func frame(dst, src net.HardwareAddr, pdu []byte) []byte {
+	b := make([]byte, 0, 17+len(pdu))
+	b = append(b, dst...)
+	b = append(b, src...)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(llc)+len(pdu)))
+	b = append(b, llc...)
+	return append(b, pdu...)
+}
+
+// isISIS returns whether the frame is an IS-IS PDU.
+func isISIS(frame []byte) bool {
+	if len(frame) < 18 || binary.BigEndian.Uint16(frame[12:14]) >= 0x0600 {
+		return false
+	}
+	dst := net.HardwareAddr(frame[:6])
+	if !bytes.Equal(dst, allL1ISs) && !bytes.Equal(dst, allL2ISs) && !bytes.Equal(dst, allISs) {
+		return false
+	}
+	return bytes.Equal(frame[14:17], llc) && frame[17] == discriminator
+}
+
+// parseFrame returns the source address and the PDU of an IS-IS frame.
+func parseFrame(frame []byte) (net.HardwareAddr, []byte, error) {
+	if !isISIS(frame) {
+		return nil, nil, fmt.Errorf("not an IS-IS frame")
+	}
+	l := int(binary.BigEndian.Uint16(frame[12:14]))
+	if l < len(llc) || 14+l > len(frame) {
+		return nil, nil, fmt.Errorf("invalid frame length %d", l)
+	}
+	return net.HardwareAddr(frame[6:12]), frame[14+len(llc) : 14+l], nil
+}
+
+// Three-way adjacency states of the point-to-point hellos (RFC 5303).
+const (
+	p2pStateUp   = 0
+	p2pStateInit = 1
+	p2pStateDown = 2
+)
+
+// p2pAdjacency is the point-to-point three-way adjacency TLV.
+type p2pAdjacency struct {
+	state           uint8
+	localCircuit    uint32
+	neighbor        SystemID
+	neighborCircuit uint32
+	hasNeighbor     bool
+}
+
+// hello is an IS-IS hello PDU.
+type hello struct {
+	pduType     uint8
+	circuitType Levels
+	source      SystemID
+	holdTime    uint16
+	priority    uint8  // LAN hellos only.
+	lanID       NodeID // LAN hellos only.
+	circuitID   uint8  // Point-to-point hellos only.
+	areas       [][]byte
+	neighbors   []net.HardwareAddr // The SNPAs of the neighbors, LAN hellos only.
+	protocols   []byte
+	ipv4        []netip.Addr
+	ipv6        []netip.Addr // Link-local addresses.
+	ipv6Global  []netip.Addr
+	p2p         *p2pAdjacency
+}
+
+func (h *hello) marshal() []byte {
+	hdrLen := lanHelloHeaderLength
+	if h.pduType == pduP2PHello {
+		hdrLen = p2pHelloHeaderLength
+	}
+	b := header(h.pduType, uint8(hdrLen))
+	b = append(b, uint8(h.circuitType))
+	b = append(b, h.source[:]...)
+	b = binary.BigEndian.AppendUint16(b, h.holdTime)
+	b = append(b, 0, 0) // PDU length
+	if h.pduType == pduP2PHello {
+		b = append(b, h.circuitID)
+	} else {
+		b = append(b, h.priority&0x7f)
+		b = append(b, h.lanID[:]...)
+	}
+	b = appendAreas(b, h.areas)
+	if len(h.protocols) > 0 {
+		b = appendTLV(b, tlvProtocols, h.protocols)
+	}
+	if len(h.neighbors) > 0 {
+		var entries [][]byte
+		for _, n := range h.neighbors {
+			entries = append(entries, n)
+		}
+		b = appendTLVs(b, tlvISNeighbors, entries)
+	}
+	b = appendAddrs(b, tlvIPv4Addresses, h.ipv4)
+	b = appendAddrs(b, tlvIPv6Addresses, h.ipv6)
+	b = appendAddrs(b, tlvIPv6GlobalAddresses, h.ipv6Global)
+	if h.p2p != nil {
+		v := []byte{h.p2p.state}
+		v = binary.BigEndian.AppendUint32(v, h.p2p.localCircuit)
+		if h.p2p.hasNeighbor {
+			v = append(v, h.p2p.neighbor[:]...)
+			v = binary.BigEndian.AppendUint32(v, h.p2p.neighborCircuit)
+		}
+		b = appendTLV(b, tlvP2PAdjacency, v)
+	}
+	binary.BigEndian.PutUint16(b[17:], uint16(len(b)))
+	return b
+}
+
+func parseHello(pduType uint8, b []byte) (*hello, error) {
+	h := &hello{
+		pduType:     pduType,
+		circuitType: Levels(b[8] & 0x03),
+		source:      SystemID(b[9:15]),
+		holdTime:    binary.BigEndian.Uint16(b[15:17]),
+	}
+	hdrLen := p2pHelloHeaderLength
+	if pduType == pduP2PHello {
+		h.circuitID = b[19]
+	} else {
+		hdrLen = lanHelloHeaderLength
+		h.priority = b[19] & 0x7f
+		h.lanID = NodeID(b[20:27])
+	}
+	if h.circuitType == 0 {
+		return nil, fmt.Errorf("invalid circuit type 0")
+	}
+	tlvs, err := parseTLVs(b[hdrLen:])
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tlvs {
+		switch t.typ {
+		case tlvAreaAddresses:
+			if h.areas, err = parseAreas(t.value, h.areas); err != nil {
+				return nil, err
+			}
+		case tlvProtocols:
+			h.protocols = append(h.protocols, t.value...)
+		case tlvISNeighbors:
+			if len(t.value)%6 != 0 {
+				return nil, fmt.Errorf("invalid IS neighbors length %d", len(t.value))
+			}
+			for i := 0; i < len(t.value); i += 6 {
+				h.neighbors = append(h.neighbors, net.HardwareAddr(t.value[i:i+6]))
+			}
+		case tlvIPv4Addresses:
+			h.ipv4 = parseAddrs(t.value, 4, h.ipv4)
+		case tlvIPv6Addresses:
+			h.ipv6 = parseAddrs(t.value, 16, h.ipv6)
+		case tlvIPv6GlobalAddresses:
+			h.ipv6Global = parseAddrs(t.value, 16, h.ipv6Global)
+		case tlvP2PAdjacency:
+			if len(t.value) != 1 && len(t.value) != 5 && len(t.value) != 11 && len(t.value) != 15 {
+				return nil, fmt.Errorf("invalid three-way adjacency length %d", len(t.value))
+			}
+			h.p2p = &p2pAdjacency{state: t.value[0]}
+			if len(t.value) >= 5 {
+				h.p2p.localCircuit = binary.BigEndian.Uint32(t.value[1:5])
+			}
+			if len(t.value) >= 11 {
+				h.p2p.hasNeighbor = true
+				h.p2p.neighbor = SystemID(t.value[5:11])
+			}
+			if len(t.value) == 15 {
+				h.p2p.neighborCircuit = binary.BigEndian.Uint32(t.value[11:15])
+			}
+		}
+	}
+	return h, nil
+}
+
+func appendAreas(b []byte, areas [][]byte) []byte {
+	var v []byte
+	for _, a := range areas {
+		v = append(v, uint8(len(a)))
+		v = append(v, a...)
+	}
+	if len(v) == 0 {
+		return b
+	}
+	return appendTLV(b, tlvAreaAddresses, v)
+}
+
+func parseAreas(v []byte, areas [][]byte) ([][]byte, error) {
+	for len(v) > 0 {
+		l := int(v[0])
+		if l == 0 || len(v) < 1+l {
+			return nil, fmt.Errorf("invalid area address")
+		}
+		areas = append(areas, bytes.Clone(v[1:1+l]))
+		v = v[1+l:]
+	}
+	return areas, nil
+}
+
+func appendAddrs(b []byte, typ uint8, addrs []netip.Addr) []byte {
+	var entries [][]byte
+	for _, a := range addrs {
+		entries = append(entries, a.AsSlice())
+	}
+	return appendTLVs(b, typ, entries)
+}
+
+func parseAddrs(v []byte, size int, addrs []netip.Addr) []netip.Addr {
+	for i := 0; i+size <= len(v); i += size {
+		a, _ := netip.AddrFromSlice(v[i : i+size])
+		addrs = append(addrs, a)
+	}
+	return addrs
+}
+
+// isReach is a neighbor of an extended IS reachability TLV.
+type isReach struct {
+	neighbor NodeID
+	metric   uint32
+}
+
+// prefixReach is a prefix of an extended IP or IPv6 reachability TLV.
+type prefixReach struct {
+	prefix netip.Prefix
+	metric uint32
+	down   bool // Whether the prefix was leaked from level 2 into level 1.
+}
+
+// lsp is a link state PDU.
+type lsp struct {
+	id        LSPID
+	lifetime  uint16
+	seq       uint32
+	checksum  uint16
+	flags     uint8
+	areas     [][]byte
+	protocols []byte
+	hostname  string
+	ipv4Addrs []netip.Addr
+	ipv6Addrs []netip.Addr
+	neighbors []isReach
+	prefixes  []prefixReach
+	// raw is the PDU, which is flooded unmodified apart from the remaining lifetime.
+	raw []byte
+}
+
+// level returns the level of the LSP.
+func (l *lsp) level() int {
+	if l.raw[4]&0x1f == pduL2LSP {
+		return 2
+	}
+	return 1
+}
+
+// newer returns whether the LSP is more recent than the LSP entry (ISO 10589 section 7.3.16).
+func newer(seq uint32, lifetime uint16, otherSeq uint32, otherLifetime uint16) bool {
+	if seq != otherSeq {
+		return seq > otherSeq
+	}
+	return lifetime == 0 && otherLifetime != 0
+}
+
+// lspTLVs returns the TLVs of the content of the LSP, they are split in fragments by marshalLSPs.
+func (l *lsp) tlvs() [][]byte {
+	var tlvs [][]byte
+	if b := appendAreas(nil, l.areas); len(b) > 0 {
+		tlvs = append(tlvs, b)
+	}
+	if len(l.protocols) > 0 {
+		tlvs = append(tlvs, appendTLV(nil, tlvProtocols, l.protocols))
+	}
+	if l.hostname != "" {
+		tlvs = append(tlvs, appendTLV(nil, tlvHostname, []byte(l.hostname[:min(len(l.hostname), 255)])))
+	}
+	for _, a := range l.ipv4Addrs {
+		tlvs = append(tlvs, appendAddrs(nil, tlvIPv4Addresses, []netip.Addr{a}))
+	}
+	for _, a := range l.ipv6Addrs {
+		tlvs = append(tlvs, appendAddrs(nil, tlvIPv6Addresses, []netip.Addr{a}))
+	}
+	var neighbors [][]byte
+	for _, n := range l.neighbors {
+		e := append([]byte{}, n.neighbor[:]...)
+		m := min(n.metric, maxWideMetric)
+		e = append(e, uint8(m>>16), uint8(m>>8), uint8(m), 0)
+		neighbors = append(neighbors, e)
+	}
+	tlvs = append(tlvs, splitTLVs(tlvExtISReach, neighbors)...)
+	var v4, v6 [][]byte
+	for _, p := range l.prefixes {
+		bits := p.prefix.Bits()
+		addr := p.prefix.Addr().AsSlice()[:(bits+7)/8]
+		if p.prefix.Addr().Is4() {
+			e := binary.BigEndian.AppendUint32(nil, p.metric)
+			ctrl := uint8(bits)
+			if p.down {
+				ctrl |= extIPDown
+			}
+			e = append(e, ctrl)
+			v4 = append(v4, append(e, addr...))
+		} else {
+			e := binary.BigEndian.AppendUint32(nil, p.metric)
+			var ctrl uint8
+			if p.down {
+				ctrl |= ipv6Down
+			}
+			e = append(e, ctrl, uint8(bits))
+			v6 = append(v6, append(e, addr...))
+		}
+	}
+	tlvs = append(tlvs, splitTLVs(tlvExtIPReach, v4)...)
+	tlvs = append(tlvs, splitTLVs(tlvIPv6Reach, v6)...)
+	return tlvs
+}
+
+// splitTLVs returns the TLVs of the type holding the entries, each TLV holds as many entries as fit.
+func splitTLVs(typ uint8, entries [][]byte) [][]byte {
+	var tlvs [][]byte
+	var value []byte
+	for _, e := range entries {
+		if len(value)+len(e) > 255 {
+			tlvs = append(tlvs, appendTLV(nil, typ, value))
+			value = nil
+		}
+		value = append(value, e...)
+	}
+	if len(value) > 0 {
+		tlvs = append(tlvs, appendTLV(nil, typ, value))
+	}
+	return tlvs
+}
+
+// fragmentTLVs packs the TLVs in the bodies of the fragments of an LSP.
+func fragmentTLVs(tlvs [][]byte) [][]byte {
+	var frags [][]byte
+	var cur []byte
+	for _, t := range tlvs {
+		if lspHeaderLength+len(cur)+len(t) > maxLSPSize && len(cur) > 0 {
+			frags = append(frags, cur)
+			cur = nil
+		}
+		cur = append(cur, t...)
+	}
+	return append(frags, cur)
+}
+
+// marshalLSP returns the PDU of an LSP fragment with the body, and sets its checksum.
+func marshalLSP(level int, id LSPID, lifetime uint16, seq uint32, flags uint8, body []byte) []byte {
+	pduType := uint8(pduL1LSP)
+	if level == 2 {
+		pduType = pduL2LSP
+	}
+	b := header(pduType, lspHeaderLength)
+	b = binary.BigEndian.AppendUint16(b, uint16(lspHeaderLength+len(body)))
+	b = binary.BigEndian.AppendUint16(b, lifetime)
+	b = append(b, id[:]...)
+	b = binary.BigEndian.AppendUint32(b, seq)
+	b = append(b, 0, 0, flags)
+	b = append(b, body...)
+	// A purged LSP has no checksum.
+	if lifetime != 0 {
+		binary.BigEndian.PutUint16(b[24:], fletcher(b[12:], 12))
+	}
+	return b
+}
+
+func parseLSP(b []byte) (*lsp, error) {
+	l := &lsp{
+		lifetime: binary.BigEndian.Uint16(b[10:12]),
+		id:       LSPID(b[12:20]),
+		seq:      binary.BigEndian.Uint32(b[20:24]),
+		checksum: binary.BigEndian.Uint16(b[24:26]),
+		flags:    b[26],
+		raw:      bytes.Clone(b),
+	}
+	if l.lifetime != 0 && !fletcherValid(b[12:]) {
+		return nil, fmt.Errorf("invalid checksum of LSP %v", l.id)
+	}
+	tlvs, err := parseTLVs(b[lspHeaderLength:])
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tlvs {
+		switch t.typ {
+		case tlvAreaAddresses:
+			if l.areas, err = parseAreas(t.value, l.areas); err != nil {
+				return nil, err
+			}
+		case tlvProtocols:
+			l.protocols = append(l.protocols, t.value...)
+		case tlvHostname:
+			l.hostname = string(t.value)
+		case tlvIPv4Addresses:
+			l.ipv4Addrs = parseAddrs(t.value, 4, l.ipv4Addrs)
+		case tlvIPv6Addresses:
+			l.ipv6Addrs = parseAddrs(t.value, 16, l.ipv6Addrs)
+		case tlvExtISReach:
+			for v := t.value; len(v) > 0; {
+				if len(v) < 11 || len(v) < 11+int(v[10]) {
+					return nil, fmt.Errorf("truncated extended IS reachability")
+				}
+				l.neighbors = append(l.neighbors, isReach{
+					neighbor: NodeID(v[:7]),
+					metric:   uint32(v[7])<<16 | uint32(v[8])<<8 | uint32(v[9]),
+				})
+				v = v[11+int(v[10]):]
+			}
+		case tlvExtIPReach:
+			for v := t.value; len(v) > 0; {
+				if len(v) < 5 {
+					return nil, fmt.Errorf("truncated extended IP reachability")
+				}
+				ctrl := v[4]
+				bits := int(ctrl & 0x3f)
+				n := 5 + (bits+7)/8
+				if bits > 32 || len(v) < n {
+					return nil, fmt.Errorf("invalid extended IP reachability")
+				}
+				var a [4]byte
+				copy(a[:], v[5:n])
+				if ctrl&extIPSubTLV != 0 {
+					if len(v) < n+1 || len(v) < n+1+int(v[n]) {
+						return nil, fmt.Errorf("truncated extended IP reachability sub-TLVs")
+					}
+					n += 1 + int(v[n])
+				}
+				l.prefixes = append(l.prefixes, prefixReach{
+					prefix: netip.PrefixFrom(netip.AddrFrom4(a), bits).Masked(),
+					metric: binary.BigEndian.Uint32(v[:4]),
+					down:   ctrl&extIPDown != 0,
+				})
+				v = v[n:]
+			}
+		case tlvIPv6Reach:
+			for v := t.value; len(v) > 0; {
+				if len(v) < 6 {
+					return nil, fmt.Errorf("truncated IPv6 reachability")
+				}
+				ctrl, bits := v[4], int(v[5])
+				n := 6 + (bits+7)/8
+				if bits > 128 || len(v) < n {
+					return nil, fmt.Errorf("invalid IPv6 reachability")
+				}
+				var a [16]byte
+				copy(a[:], v[6:n])
+				if ctrl&ipv6SubTLV != 0 {
+					if len(v) < n+1 || len(v) < n+1+int(v[n]) {
+						return nil, fmt.Errorf("truncated IPv6 reachability sub-TLVs")
+					}
+					n += 1 + int(v[n])
+				}
+				l.prefixes = append(l.prefixes, prefixReach{
+					prefix: netip.PrefixFrom(netip.AddrFrom16(a), bits).Masked(),
+					metric: binary.BigEndian.Uint32(v[:4]),
+					down:   ctrl&ipv6Down != 0,
+				})
+				v = v[n:]
+			}
+		}
+	}
+	return l, nil
+}
+
+// fletcher returns the ISO 8473 checksum of b, whose checksum is at the offset.
+func fletcher(b []byte, offset int) uint16 {
+	var c0, c1 int
+	for i, v := range b {
+		if i == offset || i == offset+1 {
+			v = 0
+		}
+		c0 = (c0 + int(v)) % 255
+		c1 = (c1 + c0) % 255
+	}
+	x := ((len(b)-offset-1)*c0 - c1) % 255
+	if x <= 0 {
+		x += 255
+	}
+	y := 510 - c0 - x
+	if y > 255 {
+		y -= 255
+	}
+	return uint16(x)<<8 | uint16(y)
+}
+
+// fletcherValid returns whether the checksum of b is valid.
+func fletcherValid(b []byte) bool {
+	var c0, c1 int
+	for _, v := range b {
+		c0 = (c0 + int(v)) % 255
+		c1 = (c1 + c0) % 255
+	}
+	return c0 == 0 && c1 == 0
+}
+
+// lspEntry describes an LSP in the sequence number PDUs.
+type lspEntry struct {
+	lifetime uint16
+	id       LSPID
+	seq      uint32
+	checksum uint16
+}
+
+// snp is a complete or partial sequence number PDU.
+type snp struct {
+	pduType uint8
+	source  NodeID
+	start   LSPID // CSNPs only.
+	end     LSPID // CSNPs only.
+	entries []lspEntry
+}
+
+func (s *snp) marshal() []byte {
+	complete := s.pduType == pduL1CSNP || s.pduType == pduL2CSNP
+	hdrLen := psnpHeaderLength
+	if complete {
+		hdrLen = csnpHeaderLength
+	}
+	b := header(s.pduType, uint8(hdrLen))
+	b = append(b, 0, 0) // PDU length
+	b = append(b, s.source[:]...)
+	if complete {
+		b = append(b, s.start[:]...)
+		b = append(b, s.end[:]...)
+	}
+	var entries [][]byte
+	for _, e := range s.entries {
+		v := binary.BigEndian.AppendUint16(nil, e.lifetime)
+		v = append(v, e.id[:]...)
+		v = binary.BigEndian.AppendUint32(v, e.seq)
+		v = binary.BigEndian.AppendUint16(v, e.checksum)
+		entries = append(entries, v)
+	}
+	b = appendTLVs(b, tlvLSPEntries, entries)
+	binary.BigEndian.PutUint16(b[8:], uint16(len(b)))
+	return b
+}
+
+func parseSNP(pduType uint8, b []byte) (*snp, error) {
+	s := &snp{pduType: pduType, source: NodeID(b[10:17])}
+	hdrLen := psnpHeaderLength
+	if pduType == pduL1CSNP || pduType == pduL2CSNP {
+		hdrLen = csnpHeaderLength
+		s.start = LSPID(b[17:25])
+		s.end = LSPID(b[25:33])
+	}
+	tlvs, err := parseTLVs(b[hdrLen:])
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tlvs {
+		if t.typ != tlvLSPEntries {
+			continue
+		}
+		if len(t.value)%16 != 0 {
+			return nil, fmt.Errorf("invalid LSP entries length %d", len(t.value))
+		}
+		for v := t.value; len(v) > 0; v = v[16:] {
+			s.entries = append(s.entries, lspEntry{
+				lifetime: binary.BigEndian.Uint16(v[:2]),
+				id:       LSPID(v[2:10]),
+				seq:      binary.BigEndian.Uint32(v[10:14]),
+				checksum: binary.BigEndian.Uint16(v[14:16]),
+			})
+		}
+	}
+	return s, nil
+}
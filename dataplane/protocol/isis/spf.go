@@ -0,0 +1,392 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isis
+
+import (
+	"bytes"
+	"cmp"
+	"maps"
+	"net/netip"
+	"slices"
+
+	log "github.com/golang/glog"
+)
+
+// NextHop is a next hop of a route.
+type NextHop struct {
+	Address   netip.Addr
+	Interface string
+}
+
+// Route is a route computed by the SPF.
+type Route struct {
+	Prefix   netip.Prefix
+	Level    int
+	Metric   uint32
+	NextHops []NextHop // Sorted by address.
+}
+
+func (r *Route) equal(o *Route) bool {
+	return r.Prefix == o.Prefix && r.Level == o.Level && r.Metric == o.Metric && slices.Equal(r.NextHops, o.NextHops)
+}
+
+// Neighbor is a neighbor of an LSP.
+type Neighbor struct {
+	ID     NodeID
+	Metric uint32
+}
+
+// Prefix is a prefix of an LSP.
+type Prefix struct {
+	Prefix netip.Prefix
+	Metric uint32
+	Down   bool // Whether the prefix was advertised from level 2 into level 1.
+}
+
+// LSP is an LSP of the database.
+type LSP struct {
+	Level             int
+	ID                LSPID
+	Sequence          uint32
+	Checksum          uint16
+	RemainingLifetime uint16
+	PDULength         uint16
+	Attached          bool
+	Overload          bool
+	Partition         bool
+	ISType            Levels
+	Hostname          string
+	Areas             []string
+	Protocols         []byte
+	IPv4Addresses     []netip.Addr
+	IPv6Addresses     []netip.Addr
+	Neighbors         []Neighbor
+	Prefixes          []Prefix
+}
+
+// Database returns the LSPs of the databases, sorted by level and LSP ID.
+func (d *Daemon) Database() []*LSP {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	var lsps []*LSP
+	for level := 1; level <= 2; level++ {
+		for _, id := range slices.SortedFunc(maps.Keys(d.db[level]), func(a, b LSPID) int { return bytes.Compare(a[:], b[:]) }) {
+			e := d.db[level][id]
+			l := &LSP{
+				Level:             level,
+				ID:                id,
+				Sequence:          e.lsp.seq,
+				Checksum:          e.lsp.checksum,
+				RemainingLifetime: e.remaining(now),
+				PDULength:         uint16(len(e.lsp.raw)),
+				Attached:          e.lsp.flags&lspFlagAttached != 0,
+				Overload:          e.lsp.flags&lspFlagOverload != 0,
+				Partition:         e.lsp.flags&lspFlagPartition != 0,
+				ISType:            Levels(e.lsp.flags & 0x03),
+				Hostname:          e.lsp.hostname,
+				Protocols:         e.lsp.protocols,
+				IPv4Addresses:     e.lsp.ipv4Addrs,
+				IPv6Addresses:     e.lsp.ipv6Addrs,
+			}
+			for _, a := range e.lsp.areas {
+				l.Areas = append(l.Areas, areaString(a))
+			}
+			for _, n := range e.lsp.neighbors {
+				l.Neighbors = append(l.Neighbors, Neighbor{ID: n.neighbor, Metric: n.metric})
+			}
+			for _, p := range e.lsp.prefixes {
+				l.Prefixes = append(l.Prefixes, Prefix{Prefix: p.prefix, Metric: p.metric, Down: p.down})
+			}
+			lsps = append(lsps, l)
+		}
+	}
+	return lsps
+}
+
+// Routes returns the routes computed by the last SPF, sorted by prefix.
+func (d *Daemon) Routes() []*Route {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.routes)
+}
+
+// node is the union of the LSP fragments of a node.
+type node struct {
+	neighbors []isReach
+	prefixes  []prefixReach
+	areas     [][]byte
+	protocols []byte
+	overload  bool
+	attached  bool
+}
+
+// nodes returns the nodes of the database of the level, the nodes without a fragment
+// zero are ignored. The caller must hold mu.
+func (d *Daemon) nodes(level int) map[NodeID]*node {
+	nodes := map[NodeID]*node{}
+	for id, e := range d.db[level] {
+		if id.Fragment() == 0 && !e.purged() {
+			nodes[id.Node()] = &node{
+				overload: e.lsp.flags&lspFlagOverload != 0,
+				attached: e.lsp.flags&lspFlagAttached != 0,
+			}
+		}
+	}
+	for id, e := range d.db[level] {
+		n := nodes[id.Node()]
+		if n == nil || e.purged() {
+			continue
+		}
+		n.neighbors = append(n.neighbors, e.lsp.neighbors...)
+		n.prefixes = append(n.prefixes, e.lsp.prefixes...)
+		n.areas = append(n.areas, e.lsp.areas...)
+		n.protocols = append(n.protocols, e.lsp.protocols...)
+	}
+	return nodes
+}
+
+// hop is the adjacency of a first hop.
+type hop struct {
+	c   *circuit
+	adj *adjacency
+}
+
+// spfResult is the shortest path tree of a level.
+type spfResult struct {
+	nodes map[NodeID]*node
+	dist  map[NodeID]uint32
+	hops  map[NodeID][]hop
+}
+
+// spf computes the shortest paths from the system to the nodes of the level, the links
+// are used if both nodes report them and the nodes in overload are not transited.
+// The caller must hold mu.
+func (d *Daemon) spf(level int) *spfResult {
+	root := nodeOf(d.cfg.SystemID, 0)
+	r := &spfResult{
+		nodes: d.nodes(level),
+		dist:  map[NodeID]uint32{root: 0},
+		hops:  map[NodeID][]hop{},
+	}
+	done := map[NodeID]bool{}
+	for {
+		var u NodeID
+		found := false
+		for id, dist := range r.dist {
+			if done[id] {
+				continue
+			}
+			if !found || dist < r.dist[u] || (dist == r.dist[u] && bytes.Compare(id[:], u[:]) < 0) {
+				u, found = id, true
+			}
+		}
+		if !found {
+			return r
+		}
+		done[u] = true
+		n := r.nodes[u]
+		if n == nil || (u != root && n.overload && !u.Pseudonode()) {
+			continue
+		}
+		for _, e := range n.neighbors {
+			v := e.neighbor
+			nv := r.nodes[v]
+			if done[v] || nv == nil || !slices.ContainsFunc(nv.neighbors, func(b isReach) bool { return b.neighbor == u }) {
+				continue
+			}
+			dist := r.dist[u] + e.metric
+			if dist > maxPathMetric {
+				continue
+			}
+			hops := d.firstHops(level, root, u, v, r.hops[u])
+			switch old, ok := r.dist[v]; {
+			case !ok || dist < old:
+				r.dist[v] = dist
+				r.hops[v] = hops
+			case dist == old:
+				for _, h := range hops {
+					if !slices.Contains(r.hops[v], h) {
+						r.hops[v] = append(r.hops[v], h)
+					}
+				}
+			}
+		}
+	}
+}
+
+// firstHops returns the first hops of the path to v through u. The caller must hold mu.
+func (d *Daemon) firstHops(level int, root, u, v NodeID, uHops []hop) []hop {
+	var hops []hop
+	switch {
+	case u == root && !v.Pseudonode():
+		for _, c := range d.circuits {
+			if a := c.p2p; c.cfg.PointToPoint && a != nil && a.state == AdjacencyUp && a.levels.Has(level) && a.system == v.System() {
+				hops = append(hops, hop{c: c, adj: a})
+			}
+		}
+	case u == root:
+		// The pseudonode of a circuit of the system, the hops are its neighbors.
+	case u.Pseudonode() && len(uHops) == 0:
+		for _, c := range d.circuits {
+			if cl := c.lvl[level]; cl != nil && !c.cfg.PointToPoint && cl.lanID == u {
+				if a := cl.adjs[v.System()]; a != nil && a.state == AdjacencyUp {
+					hops = append(hops, hop{c: c, adj: a})
+				}
+			}
+		}
+	default:
+		hops = uHops
+	}
+	return hops
+}
+
+// runSPF computes the routes of the levels, the level 1 routes are preferred. The routes
+// are notified if they changed. The caller must hold mu.
+func (d *Daemon) runSPF() {
+	d.spfDirty = false
+	var routes []*Route
+	var leaked []prefixReach
+	attached := false
+	if d.cfg != nil {
+		best := map[netip.Prefix]*Route{}
+		own := map[netip.Prefix]bool{}
+		for _, i := range d.cfg.Interfaces {
+			for _, p := range i.Prefixes {
+				own[p.Masked()] = true
+			}
+		}
+		for level := 1; level <= 2; level++ {
+			if !d.cfg.Levels.Has(level) {
+				continue
+			}
+			r := d.spf(level)
+			levelRoutes := map[netip.Prefix]*Route{}
+			var attachedDist uint32
+			var attachedHops []hop
+			var attachedProtocols []byte
+			for id, dist := range r.dist {
+				n := r.nodes[id]
+				hops := r.hops[id]
+				if n == nil || len(hops) == 0 {
+					continue
+				}
+				if level == 1 && n.attached && !id.Pseudonode() {
+					switch {
+					case attachedHops == nil || dist < attachedDist:
+						attachedDist, attachedHops, attachedProtocols = dist, slices.Clone(hops), slices.Clone(n.protocols)
+					case dist == attachedDist:
+						attachedHops = append(attachedHops, hops...)
+						attachedProtocols = append(attachedProtocols, n.protocols...)
+					}
+				}
+				if level == 2 && !id.Pseudonode() && !d.areasMatch(n.areas) {
+					attached = true
+				}
+				for _, p := range n.prefixes {
+					if own[p.prefix] {
+						continue
+					}
+					metric := min(dist+p.metric, maxPathMetric)
+					d.addRoute(levelRoutes, level, p.prefix, metric, hops)
+				}
+			}
+			// The level 1 only systems route to the other areas through the nearest attached
+			// systems, in the families they support.
+			if level == 1 && d.cfg.Levels == Level1 && attachedHops != nil {
+				defaults := map[netip.Prefix]byte{netip.MustParsePrefix("0.0.0.0/0"): NLPIDIPv4, netip.MustParsePrefix("::/0"): NLPIDIPv6}
+				for p, nlpid := range defaults {
+					if _, ok := levelRoutes[p]; !ok && slices.Contains(attachedProtocols, nlpid) {
+						d.addRoute(levelRoutes, level, p, attachedDist, attachedHops)
+					}
+				}
+			}
+			for p, rt := range levelRoutes {
+				if _, ok := best[p]; ok {
+					continue
+				}
+				best[p] = rt
+				if level == 1 && d.cfg.Levels == Level12 {
+					leaked = append(leaked, prefixReach{prefix: p, metric: rt.Metric})
+				}
+			}
+		}
+		routes = slices.SortedFunc(maps.Values(best), func(a, b *Route) int {
+			return cmp.Or(a.Prefix.Addr().Compare(b.Prefix.Addr()), cmp.Compare(a.Prefix.Bits(), b.Prefix.Bits()))
+		})
+	}
+	slices.SortFunc(leaked, comparePrefixReach)
+	if !slices.Equal(leaked, d.leaked) || attached != d.attached {
+		d.leaked, d.attached = leaked, attached
+		d.lspDirty = true
+	}
+	if slices.EqualFunc(routes, d.routes, (*Route).equal) {
+		return
+	}
+	d.routes = routes
+	if err := d.events.Write(slices.Clone(routes)); err != nil {
+		log.Warningf("failed to notify IS-IS routes: %v", err)
+	}
+}
+
+// addRoute adds the path to the prefix to the routes, if it is not longer than the current path.
+func (d *Daemon) addRoute(routes map[netip.Prefix]*Route, level int, prefix netip.Prefix, metric uint32, hops []hop) {
+	var nhs []NextHop
+	for _, h := range hops {
+		if nh, ok := nextHop(h, prefix.Addr().Is4()); ok && !slices.Contains(nhs, nh) {
+			nhs = append(nhs, nh)
+		}
+	}
+	if len(nhs) == 0 {
+		return
+	}
+	rt, ok := routes[prefix]
+	switch {
+	case !ok || metric < rt.Metric:
+		rt = &Route{Prefix: prefix, Level: level, Metric: metric}
+		routes[prefix] = rt
+	case metric > rt.Metric:
+		return
+	}
+	for _, nh := range nhs {
+		if !slices.Contains(rt.NextHops, nh) {
+			rt.NextHops = append(rt.NextHops, nh)
+		}
+	}
+	slices.SortFunc(rt.NextHops, func(a, b NextHop) int {
+		return cmp.Or(a.Address.Compare(b.Address), cmp.Compare(a.Interface, b.Interface))
+	})
+}
+
+// nextHop returns the address of the neighbor of the first hop in the family, preferably
+// in a subnet of the circuit. The IPv6 global addresses are preferred to the link-local
+// addresses, which cannot be resolved without the interface.
+func nextHop(h hop, ipv4 bool) (NextHop, bool) {
+	addrs := h.adj.ipv4
+	if !ipv4 {
+		addrs = append(slices.Clone(h.adj.ipv6Global), h.adj.ipv6...)
+	}
+	for _, a := range addrs {
+		for _, p := range h.c.cfg.Prefixes {
+			if p.Contains(a) {
+				return NextHop{Address: a, Interface: h.c.cfg.Name}, true
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return NextHop{}, false
+	}
+	return NextHop{Address: addrs[0], Interface: h.c.cfg.Name}, true
+}
@@ -0,0 +1,472 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lacp is an implementation of the Link Aggregation Control Protocol (IEEE 802.1AX),
+// which negotiates the members of the aggregate interfaces with their partners.
+package lacp
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/util/queue"
+
+	log "github.com/golang/glog"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+const (
+	fastPeriod = time.Second
+	slowPeriod = 30 * time.Second
+	// The partner information expires after 3 periods without LACPDUs.
+	fastTimeout = 3 * fastPeriod
+	slowTimeout = 3 * slowPeriod
+	// tickInterval is the resolution of the timers.
+	tickInterval = 100 * time.Millisecond
+)
+
+// Sender sends the frames originated by the CPU.
+type Sender interface {
+	Send(*pktiopb.PacketIn) error
+}
+
+// NotifyFunc is called when a member starts or stops collecting and distributing
+// the frames of its aggregate.
+type NotifyFunc func(aggregate, member string, distributing bool)
+
+// Member is a member port of an aggregate.
+type Member struct {
+	Name         string
+	PortID       uint64           // The dataplane port, the LACPDUs are received from it.
+	HostPort     uint64           // The host port the LACPDUs are sent with, which transmits them on the port.
+	MAC          net.HardwareAddr // The source address of the LACPDUs.
+	Port         uint16
+	PortPriority uint16
+}
+
+// Aggregate is an aggregate interface whose members are negotiated with LACP.
+type Aggregate struct {
+	Name           string
+	SystemPriority uint16
+	System         net.HardwareAddr
+	Key            uint16
+	Active         bool // Whether the LACPDUs are sent when the partner is passive.
+	Fast           bool // Whether the partner is asked to send LACPDUs every second, instead of every 30 seconds.
+	Members        []*Member
+}
+
+// MemberState is the state of a member.
+type MemberState struct {
+	Aggregate    string
+	Name         string
+	Actor        PortInfo
+	Partner      PortInfo // Zero if the partner is defaulted.
+	Collecting   bool
+	Distributing bool
+	LastChange   time.Time
+
+	InPkts             uint64
+	OutPkts            uint64
+	RxErrors           uint64
+	TxErrors           uint64
+	TimeoutTransitions uint64
+}
+
+// muxState is the state of the mux machine of a member, the member collects
+// and distributes the frames in the same state.
+type muxState int
+
+const (
+	muxDetached muxState = iota
+	muxAttached
+	muxCollectingDistributing
+)
+
+// member is the protocol state of a member.
+type member struct {
+	cfg *Member
+	agg *Aggregate
+
+	partner     PortInfo
+	defaulted   bool
+	expired     bool
+	partnerSync bool // Whether the partner is in sync with the actor information.
+	selected    bool
+	mux         muxState
+
+	currentWhile time.Time // The time the partner information expires.
+	nextTx       time.Time // The time of the next periodic LACPDU.
+	ntt          bool      // Whether a LACPDU needs to be transmitted.
+	lastChange   time.Time
+
+	inPkts, outPkts, rxErrors, txErrors, timeoutTransitions uint64
+}
+
+// event is a change of the distributing state of a member.
+type event struct {
+	aggregate, member string
+	distributing      bool
+}
+
+// Daemon runs LACP on the members of the aggregates.
+type Daemon struct {
+	sender Sender
+	notify NotifyFunc
+	events *queue.Queue
+
+	mu      sync.Mutex
+	members map[uint64]*member // keyed by port ID
+	doneCh  chan struct{}
+}
+
+// New returns a daemon that sends the LACPDUs with the sender, notify is called when
+// the members start or stop distributing.
+func New(sender Sender, notify NotifyFunc) (*Daemon, error) {
+	q, err := queue.NewUnbounded("lacp")
+	if err != nil {
+		return nil, err
+	}
+	q.Run()
+	d := &Daemon{
+		sender:  sender,
+		notify:  notify,
+		events:  q,
+		members: map[uint64]*member{},
+	}
+	// The notifications are sent in order, without holding the lock.
+	go func() {
+		for e := range q.Receive() {
+			ev := e.(*event)
+			d.notify(ev.aggregate, ev.member, ev.distributing)
+		}
+	}()
+	return d, nil
+}
+
+// Start starts the timers of the members.
+func (d *Daemon) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.doneCh != nil {
+		return
+	}
+	d.doneCh = make(chan struct{})
+	go func(done chan struct{}) {
+		tick := time.NewTicker(tickInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-tick.C:
+				d.tick(now)
+			}
+		}
+	}(d.doneCh)
+}
+
+// Stop stops the timers, the members are removed and stop distributing.
+func (d *Daemon) Stop() {
+	d.Configure(nil)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.doneCh == nil {
+		return
+	}
+	close(d.doneCh)
+	d.doneCh = nil
+}
+
+// Configure replaces the aggregates. The members that are no longer configured, or whose
+// aggregate changed, stop distributing and restart the protocol.
+func (d *Daemon) Configure(aggs []*Aggregate) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	want := map[uint64]*member{}
+	for _, agg := range aggs {
+		for _, m := range agg.Members {
+			want[m.PortID] = &member{cfg: m, agg: agg}
+		}
+	}
+	for id, m := range d.members {
+		w, ok := want[id]
+		if ok && w.agg.Name == m.agg.Name && w.cfg.Name == m.cfg.Name && w.agg.Key == m.agg.Key {
+			// Keep the protocol state, the actor information is updated by the next LACPDU.
+			if m.cfg.Port != w.cfg.Port || m.cfg.PortPriority != w.cfg.PortPriority || m.agg.SystemPriority != w.agg.SystemPriority ||
+				m.agg.Active != w.agg.Active || m.agg.Fast != w.agg.Fast || m.agg.System.String() != w.agg.System.String() {
+				m.ntt = true
+			}
+			m.cfg, m.agg = w.cfg, w.agg
+			continue
+		}
+		if m.mux == muxCollectingDistributing {
+			d.notifyDistributing(m, false)
+		}
+		delete(d.members, id)
+	}
+	for id, w := range want {
+		if _, ok := d.members[id]; ok {
+			continue
+		}
+		w.defaulted = true
+		w.lastChange = now
+		w.ntt = true
+		d.members[id] = w
+	}
+	d.update(now)
+}
+
+// Members returns the state of the members, sorted by aggregate and name.
+func (d *Daemon) Members() []*MemberState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var states []*MemberState
+	for _, m := range d.members {
+		s := &MemberState{
+			Aggregate:          m.agg.Name,
+			Name:               m.cfg.Name,
+			Actor:              m.actor(),
+			Collecting:         m.mux == muxCollectingDistributing,
+			Distributing:       m.mux == muxCollectingDistributing,
+			LastChange:         m.lastChange,
+			InPkts:             m.inPkts,
+			OutPkts:            m.outPkts,
+			RxErrors:           m.rxErrors,
+			TxErrors:           m.txErrors,
+			TimeoutTransitions: m.timeoutTransitions,
+		}
+		if !m.defaulted {
+			s.Partner = m.partner
+		}
+		states = append(states, s)
+	}
+	slices.SortFunc(states, func(a, b *MemberState) int {
+		return cmp.Or(cmp.Compare(a.Aggregate, b.Aggregate), cmp.Compare(a.Name, b.Name))
+	})
+	return states
+}
+
+// Matched returns true if the packet is a LACPDU.
+func (d *Daemon) Matched(po *pktiopb.PacketOut) bool {
+	return isLACP(po.GetPacket().GetFrame())
+}
+
+// Process records the partner information of the LACPDU received on a member.
+func (d *Daemon) Process(po *pktiopb.PacketOut) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m, ok := d.members[po.GetPacket().GetInputPort()]
+	if !ok {
+		return fmt.Errorf("port %d is not a LACP member", po.GetPacket().GetInputPort())
+	}
+	p, err := parsePDU(po.GetPacket().GetFrame())
+	if err != nil {
+		m.rxErrors++
+		return err
+	}
+	d.receive(m, p, time.Now())
+	d.update(time.Now())
+	return nil
+}
+
+// receive records the partner information of the LACPDU. The caller must hold mu.
+func (d *Daemon) receive(m *member, p *pdu, now time.Time) {
+	m.inPkts++
+	if m.defaulted || !m.partner.samePort(&p.actor) || m.partner.State&StateAggregation != p.actor.State&StateAggregation {
+		// The member must be selected again for the new partner.
+		m.selected = false
+	}
+	m.partner = p.actor
+	m.defaulted = false
+	m.expired = false
+
+	// The partner is in sync if it has the current actor information and reports that it is in sync.
+	actor := m.actor()
+	matched := actor.samePort(&p.partner) && actor.State&StateAggregation == p.partner.State&StateAggregation
+	m.partnerSync = matched && p.actor.State&StateSynchronization != 0
+	// Make the partner update its information of the actor.
+	if !matched || (actor.State&^StateExpired) != (p.partner.State&^StateExpired) {
+		m.ntt = true
+	}
+	timeout := slowTimeout
+	if m.agg.Fast {
+		timeout = fastTimeout
+	}
+	m.currentWhile = now.Add(timeout)
+}
+
+// tick runs the timers of the members.
+func (d *Daemon) tick(now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, m := range d.members {
+		if !m.defaulted && !m.currentWhile.IsZero() && !now.Before(m.currentWhile) {
+			if !m.expired {
+				// Ask the partner to send LACPDUs quickly, the partner information is
+				// defaulted if none is received.
+				m.expired = true
+				m.partnerSync = false
+				m.partner.State |= StateTimeout
+				m.currentWhile = now.Add(fastTimeout)
+				m.ntt = true
+			} else {
+				m.defaulted = true
+				m.expired = false
+				m.partner = PortInfo{}
+				m.partnerSync = false
+				m.selected = false
+				m.currentWhile = time.Time{}
+				m.timeoutTransitions++
+			}
+		}
+		if m.periodic() && !now.Before(m.nextTx) {
+			m.ntt = true
+		}
+	}
+	d.update(now)
+}
+
+// periodic returns whether the member sends LACPDUs periodically, which is the case
+// unless both the actor and the partner are passive.
+func (m *member) periodic() bool {
+	return m.agg.Active || (!m.defaulted && m.partner.State&StateActivity != 0)
+}
+
+// period returns the interval of the periodic LACPDUs, which is requested by the partner.
+func (m *member) period() time.Duration {
+	if m.defaulted || m.partner.State&StateTimeout != 0 {
+		return fastPeriod
+	}
+	return slowPeriod
+}
+
+// actor returns the actor information of the member.
+func (m *member) actor() PortInfo {
+	state := StateAggregation
+	if m.agg.Active {
+		state |= StateActivity
+	}
+	if m.agg.Fast {
+		state |= StateTimeout
+	}
+	if m.mux != muxDetached {
+		state |= StateSynchronization
+	}
+	if m.mux == muxCollectingDistributing {
+		state |= StateCollecting | StateDistributing
+	}
+	if m.defaulted {
+		state |= StateDefaulted
+	}
+	if m.expired {
+		state |= StateExpired
+	}
+	return PortInfo{
+		SystemPriority: m.agg.SystemPriority,
+		System:         m.agg.System,
+		Key:            m.agg.Key,
+		PortPriority:   m.cfg.PortPriority,
+		Port:           m.cfg.Port,
+		State:          state,
+	}
+}
+
+// update selects the aggregate of the members, runs their mux machines and transmits
+// the LACPDUs of the members that need to. The caller must hold mu.
+func (d *Daemon) update(now time.Time) {
+	ids := slices.Sorted(maps.Keys(d.members))
+	// A member is selected if its partner is aggregatable. The members of an aggregate must
+	// have the same partner system and key, so the members selected first have precedence.
+	for _, id := range ids {
+		m := d.members[id]
+		if m.selected {
+			continue
+		}
+		if m.defaulted || m.partner.State&StateAggregation == 0 {
+			continue
+		}
+		m.selected = true
+		for _, o := range d.members {
+			if o != m && o.agg.Name == m.agg.Name && o.selected && !o.partner.sameSystem(&m.partner) {
+				m.selected = false
+				break
+			}
+		}
+	}
+	for _, id := range ids {
+		m := d.members[id]
+		prev := m.mux
+		// The actor is in sync once the member is attached, and the member collects
+		// and distributes once the partner is in sync too.
+		switch {
+		case !m.selected:
+			m.mux = muxDetached
+		case m.partnerSync:
+			m.mux = muxCollectingDistributing
+		default:
+			m.mux = muxAttached
+		}
+		if m.mux != prev {
+			m.lastChange = now
+			m.ntt = true
+			if (m.mux == muxCollectingDistributing) != (prev == muxCollectingDistributing) {
+				d.notifyDistributing(m, m.mux == muxCollectingDistributing)
+			}
+		}
+		if m.ntt {
+			d.transmit(m, now)
+		}
+	}
+}
+
+// notifyDistributing queues the notification of the distributing state of the member.
+func (d *Daemon) notifyDistributing(m *member, distributing bool) {
+	if err := d.events.Write(&event{aggregate: m.agg.Name, member: m.cfg.Name, distributing: distributing}); err != nil {
+		log.Warningf("failed to notify distributing state of %q: %v", m.cfg.Name, err)
+	}
+}
+
+// transmit sends a LACPDU on the member. The caller must hold mu.
+func (d *Daemon) transmit(m *member, now time.Time) {
+	m.ntt = false
+	if !m.periodic() {
+		return
+	}
+	m.nextTx = now.Add(m.period())
+	p := &pdu{actor: m.actor()}
+	if !m.defaulted {
+		p.partner = m.partner
+	}
+	err := d.sender.Send(&pktiopb.PacketIn{
+		Msg: &pktiopb.PacketIn_Packet{
+			Packet: &pktiopb.Packet{
+				HostPort: m.cfg.HostPort,
+				Frame:    p.frame(m.cfg.MAC),
+			},
+		},
+	})
+	if err != nil {
+		m.txErrors++
+		log.Warningf("failed to send LACPDU on %q: %v", m.cfg.Name, err)
+		return
+	}
+	m.outPkts++
+}
@@ -0,0 +1,297 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lacp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+func TestPDU(t *testing.T) {
+	want := &pdu{
+		actor: PortInfo{
+			SystemPriority: 100,
+			System:         net.HardwareAddr{0, 1, 2, 3, 4, 5},
+			Key:            7,
+			PortPriority:   255,
+			Port:           3,
+			State:          StateActivity | StateAggregation | StateSynchronization,
+		},
+		partner: PortInfo{
+			System: net.HardwareAddr{0, 0, 0, 0, 0, 0},
+			State:  StateDefaulted,
+		},
+	}
+	frame := want.frame(net.HardwareAddr{2, 0, 0, 0, 0, 1})
+	if len(frame) != 124 {
+		t.Errorf("frame() got %d bytes, want 124", len(frame))
+	}
+	if !isLACP(frame) {
+		t.Errorf("isLACP() got false, want true")
+	}
+	got, err := parsePDU(frame)
+	if err != nil {
+		t.Fatalf("parsePDU() unexpected err: %v", err)
+	}
+	if d := cmp.Diff(got, want, cmp.AllowUnexported(pdu{})); d != "" {
+		t.Errorf("parsePDU() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	tests := []struct {
+		desc    string
+		frame   []byte
+		wantErr string
+	}{{
+		desc:    "not LACP",
+		frame:   make([]byte, 60),
+		wantErr: "not a LACPDU",
+	}, {
+		desc:    "truncated",
+		frame:   frame[:40],
+		wantErr: "too short",
+	}, {
+		desc:    "invalid actor",
+		frame:   append(append([]byte{}, frame[:16]...), append([]byte{tlvPartner}, frame[17:]...)...),
+		wantErr: "actor information",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := parsePDU(tt.frame)
+			if diff := errdiff.Check(err, tt.wantErr); diff != "" {
+				t.Errorf("parsePDU() unexpected err: %s", diff)
+			}
+		})
+	}
+}
+
+// fakeSender records the packets sent by a daemon.
+type fakeSender struct {
+	mu   sync.Mutex
+	pkts []*pktiopb.Packet
+}
+
+func (s *fakeSender) Send(p *pktiopb.PacketIn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pkts = append(s.pkts, p.GetPacket())
+	return nil
+}
+
+func (s *fakeSender) take() []*pktiopb.Packet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pkts := s.pkts
+	s.pkts = nil
+	return pkts
+}
+
+// link is the port of the peer connected to a host port.
+type link struct {
+	peer *peer
+	port uint64
+}
+
+// peer is a daemon connected to other daemons, the packets sent with a host port
+// are received by the peer of the link.
+type peer struct {
+	d      *Daemon
+	sender *fakeSender
+	links  map[uint64]link
+	events chan event
+}
+
+// connect links the host port of a to the port of b, and the host port of b to the port of a.
+func connect(a *peer, aPort uint64, b *peer, bPort uint64) {
+	a.links[aPort+100] = link{peer: b, port: bPort}
+	b.links[bPort+100] = link{peer: a, port: aPort}
+}
+
+func newPeer(t *testing.T) *peer {
+	t.Helper()
+	p := &peer{sender: &fakeSender{}, links: map[uint64]link{}, events: make(chan event, 10)}
+	d, err := New(p.sender, func(agg, member string, distributing bool) {
+		p.events <- event{aggregate: agg, member: member, distributing: distributing}
+	})
+	if err != nil {
+		t.Fatalf("New() unexpected err: %v", err)
+	}
+	p.d = d
+	return p
+}
+
+// exchange delivers the packets sent by the peers over their links until they stop sending.
+func exchange(t *testing.T, peers ...*peer) {
+	t.Helper()
+	for i := 0; i < 20; i++ {
+		sent := false
+		for _, p := range peers {
+			for _, pkt := range p.sender.take() {
+				sent = true
+				l, ok := p.links[pkt.GetHostPort()]
+				if !ok {
+					t.Fatalf("host port %d is not linked", pkt.GetHostPort())
+				}
+				po := &pktiopb.PacketOut{Packet: &pktiopb.Packet{InputPort: l.port, Frame: pkt.GetFrame()}}
+				if !l.peer.d.Matched(po) {
+					t.Fatalf("Matched() got false for LACPDU")
+				}
+				if err := l.peer.d.Process(po); err != nil {
+					t.Fatalf("Process() unexpected err: %v", err)
+				}
+			}
+		}
+		if !sent {
+			return
+		}
+	}
+	t.Fatalf("exchange() did not converge")
+}
+
+// recvEvents returns the n next notifications of the peer.
+func recvEvents(t *testing.T, p *peer, n int) map[string]bool {
+	t.Helper()
+	got := map[string]bool{}
+	for i := 0; i < n; i++ {
+		select {
+		case e := <-p.events:
+			got[e.aggregate+"/"+e.member] = e.distributing
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for notification, got %v", got)
+		}
+	}
+	return got
+}
+
+func aggregate(name string, system byte, active bool, ports ...uint64) *Aggregate {
+	agg := &Aggregate{
+		Name:           name,
+		SystemPriority: 32768,
+		System:         net.HardwareAddr{2, 0, 0, 0, 0, system},
+		Key:            1,
+		Active:         active,
+		Fast:           true,
+	}
+	for _, port := range ports {
+		agg.Members = append(agg.Members, &Member{
+			Name:         name + "-" + string(rune('a'+port)),
+			PortID:       port,
+			HostPort:     port + 100,
+			MAC:          net.HardwareAddr{2, 0, 0, 0, 1, byte(port)},
+			Port:         uint16(port),
+			PortPriority: 32768,
+		})
+	}
+	return agg
+}
+
+func TestNegotiation(t *testing.T) {
+	// Ports 1 and 2 of a are connected to ports 1 and 2 of b.
+	a, b := newPeer(t), newPeer(t)
+	connect(a, 1, b, 1)
+	connect(a, 2, b, 2)
+	a.d.Configure([]*Aggregate{aggregate("lag1", 1, true, 1, 2)})
+	b.d.Configure([]*Aggregate{aggregate("lag2", 2, false, 1, 2)})
+	exchange(t, a, b)
+
+	want := map[string]bool{"lag1/lag1-b": true, "lag1/lag1-c": true}
+	if d := cmp.Diff(recvEvents(t, a, 2), want); d != "" {
+		t.Errorf("notifications of active peer: diff(-got,+want)\n:%s", d)
+	}
+	want = map[string]bool{"lag2/lag2-b": true, "lag2/lag2-c": true}
+	if d := cmp.Diff(recvEvents(t, b, 2), want); d != "" {
+		t.Errorf("notifications of passive peer: diff(-got,+want)\n:%s", d)
+	}
+	for _, s := range a.d.Members() {
+		if !s.Collecting || !s.Distributing {
+			t.Errorf("Members() got member %q not collecting and distributing", s.Name)
+		}
+		wantState := StateActivity | StateTimeout | StateAggregation | StateSynchronization | StateCollecting | StateDistributing
+		if s.Actor.State != wantState {
+			t.Errorf("Members() got actor state %08b for %q, want %08b", s.Actor.State, s.Name, wantState)
+		}
+		if s.Partner.System.String() != "02:00:00:00:00:02" || s.Partner.Port != uint16(s.Actor.Port) {
+			t.Errorf("Members() got partner %+v for %q, want port %d of 02:00:00:00:00:02", s.Partner, s.Name, s.Actor.Port)
+		}
+		if s.InPkts == 0 || s.OutPkts == 0 {
+			t.Errorf("Members() got no LACPDUs counted for %q", s.Name)
+		}
+	}
+
+	// The partner stops sending LACPDUs, the members expire and then default.
+	now := time.Now()
+	a.d.tick(now.Add(fastTimeout + time.Second))
+	b.sender.take()
+	a.d.tick(now.Add(2*fastTimeout + 2*time.Second))
+	want = map[string]bool{"lag1/lag1-b": false, "lag1/lag1-c": false}
+	if d := cmp.Diff(recvEvents(t, a, 2), want); d != "" {
+		t.Errorf("notifications after timeout: diff(-got,+want)\n:%s", d)
+	}
+	for _, s := range a.d.Members() {
+		if s.Distributing || s.Actor.State&StateDefaulted == 0 || s.TimeoutTransitions != 1 {
+			t.Errorf("Members() got member %+v, want defaulted member", s)
+		}
+	}
+
+	// The members stop distributing when they are removed.
+	b.d.Configure(nil)
+	want = map[string]bool{"lag2/lag2-b": false, "lag2/lag2-c": false}
+	if d := cmp.Diff(recvEvents(t, b, 2), want); d != "" {
+		t.Errorf("notifications after removal: diff(-got,+want)\n:%s", d)
+	}
+	if got := b.d.Members(); len(got) != 0 {
+		t.Errorf("Members() got %d members after removal, want 0", len(got))
+	}
+}
+
+func TestPartnerSystems(t *testing.T) {
+	// Port 1 is connected to b and port 2 to c, the aggregate only uses the first partner.
+	a, b, c := newPeer(t), newPeer(t), newPeer(t)
+	connect(a, 1, b, 1)
+	connect(a, 2, c, 2)
+	b.d.Configure([]*Aggregate{aggregate("lag2", 2, true, 1)})
+	a.d.Configure([]*Aggregate{aggregate("lag1", 1, true, 1)})
+	exchange(t, a, b)
+	c.d.Configure([]*Aggregate{aggregate("lag3", 3, true, 2)})
+	a.d.Configure([]*Aggregate{aggregate("lag1", 1, true, 1, 2)})
+	exchange(t, a, b, c)
+
+	if d := cmp.Diff(recvEvents(t, a, 1), map[string]bool{"lag1/lag1-b": true}); d != "" {
+		t.Errorf("notifications: diff(-got,+want)\n:%s", d)
+	}
+	for _, s := range a.d.Members() {
+		if s.Name == "lag1-c" && (s.Distributing || s.Actor.State&StateSynchronization != 0) {
+			t.Errorf("Members() got member %+v attached to a second partner", s)
+		}
+	}
+}
+
+func TestPassive(t *testing.T) {
+	a := newPeer(t)
+	a.d.Configure([]*Aggregate{aggregate("lag1", 1, false, 1)})
+	a.d.tick(time.Now().Add(time.Minute))
+	if got := a.sender.take(); len(got) != 0 {
+		t.Errorf("passive member sent %d LACPDUs to a defaulted partner, want 0", len(got))
+	}
+	if err := a.d.Process(&pktiopb.PacketOut{Packet: &pktiopb.Packet{InputPort: 2, Frame: make([]byte, 60)}}); err == nil {
+		t.Errorf("Process() got no error for port that is not a member")
+	}
+}
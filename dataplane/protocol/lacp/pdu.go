@@ -0,0 +1,148 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lacp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// The bits of the actor and partner state.
+const (
+	StateActivity uint8 = 1 << iota
+	StateTimeout
+	StateAggregation
+	StateSynchronization
+	StateCollecting
+	StateDistributing
+	StateDefaulted
+	StateExpired
+)
+
+const (
+	etherTypeSlow = 0x8809
+	subtypeLACP   = 1
+	versionLACP   = 1
+
+	tlvActor      = 1
+	tlvPartner    = 2
+	tlvCollector  = 3
+	tlvTerminator = 0
+
+	infoLength      = 20
+	collectorLength = 16
+	// pduLength is the length of the LACPDU after the Ethernet header.
+	pduLength = 110
+)
+
+// dstMAC is the Slow Protocols multicast address, the destination of the LACPDUs.
+var dstMAC = net.HardwareAddr{0x01, 0x80, 0xC2, 0x00, 0x00, 0x02}
+
+// PortInfo is the information of the actor or the partner of a port in a LACPDU.
+type PortInfo struct {
+	SystemPriority uint16
+	System         net.HardwareAddr
+	Key            uint16
+	PortPriority   uint16
+	Port           uint16
+	State          uint8
+}
+
+// sameSystem returns whether the ports are in the same system and aggregate.
+func (p *PortInfo) sameSystem(o *PortInfo) bool {
+	return p.SystemPriority == o.SystemPriority && bytes.Equal(p.System, o.System) && p.Key == o.Key
+}
+
+// samePort returns whether the ports are the same port of the same aggregate.
+func (p *PortInfo) samePort(o *PortInfo) bool {
+	return p.sameSystem(o) && p.PortPriority == o.PortPriority && p.Port == o.Port
+}
+
+func (p *PortInfo) append(b []byte, typ uint8) []byte {
+	b = append(b, typ, infoLength)
+	b = binary.BigEndian.AppendUint16(b, p.SystemPriority)
+	sys := make([]byte, 6)
+	copy(sys, p.System)
+	b = append(b, sys...)
+	b = binary.BigEndian.AppendUint16(b, p.Key)
+	b = binary.BigEndian.AppendUint16(b, p.PortPriority)
+	b = binary.BigEndian.AppendUint16(b, p.Port)
+	return append(b, p.State, 0, 0, 0)
+}
+
+func parsePortInfo(b []byte, typ uint8) (PortInfo, error) {
+	if b[0] != typ || b[1] != infoLength {
+		return PortInfo{}, fmt.Errorf("invalid TLV type %d and length %d, want type %d", b[0], b[1], typ)
+	}
+	return PortInfo{
+		SystemPriority: binary.BigEndian.Uint16(b[2:4]),
+		System:         net.HardwareAddr(bytes.Clone(b[4:10])),
+		Key:            binary.BigEndian.Uint16(b[10:12]),
+		PortPriority:   binary.BigEndian.Uint16(b[12:14]),
+		Port:           binary.BigEndian.Uint16(b[14:16]),
+		State:          b[16],
+	}, nil
+}
+
+// pdu is a LACPDU.
+type pdu struct {
+	actor   PortInfo
+	partner PortInfo
+}
+
+// frame returns the LACPDU in an Ethernet frame from src.
+func (p *pdu) frame(src net.HardwareAddr) []byte {
+	b := append(bytes.Clone(dstMAC), src...)
+	b = binary.BigEndian.AppendUint16(b, etherTypeSlow)
+	b = append(b, subtypeLACP, versionLACP)
+	b = p.actor.append(b, tlvActor)
+	b = p.partner.append(b, tlvPartner)
+	// The collector max delay is 0, the actor delivers the frames immediately.
+	b = append(b, tlvCollector, collectorLength)
+	b = append(b, make([]byte, collectorLength-2)...)
+	b = append(b, tlvTerminator, 0)
+	return append(b, make([]byte, 14+pduLength-len(b))...)
+}
+
+// isLACP returns whether the Ethernet frame contains a LACPDU.
+func isLACP(frame []byte) bool {
+	return len(frame) > 14 && binary.BigEndian.Uint16(frame[12:14]) == etherTypeSlow && frame[14] == subtypeLACP
+}
+
+// parsePDU returns the LACPDU in the Ethernet frame.
+func parsePDU(frame []byte) (*pdu, error) {
+	if !isLACP(frame) {
+		return nil, fmt.Errorf("not a LACPDU")
+	}
+	b := frame[14:]
+	// Version 1 LACPDUs have a fixed length, later versions may add TLVs after the partner information.
+	if len(b) < 2+2*infoLength {
+		return nil, fmt.Errorf("LACPDU too short: %d bytes", len(b))
+	}
+	if b[1] < versionLACP {
+		return nil, fmt.Errorf("unsupported LACP version %d", b[1])
+	}
+	actor, err := parsePortInfo(b[2:], tlvActor)
+	if err != nil {
+		return nil, fmt.Errorf("actor information: %v", err)
+	}
+	partner, err := parsePortInfo(b[2+infoLength:], tlvPartner)
+	if err != nil {
+		return nil, fmt.Errorf("partner information: %v", err)
+	}
+	return &pdu{actor: actor, partner: partner}, nil
+}
@@ -18,11 +18,13 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 	"github.com/openconfig/ygnmi/ygnmi"
+	"github.com/openconfig/ygot/ygot"
 
 	"github.com/openconfig/lemming/gnmi/gnmiclient"
 	"github.com/openconfig/lemming/gnmi/oc"
@@ -36,6 +38,7 @@ import (
 
 // Daemon is the implementation of the LLDP protocol.
 type Daemon struct {
+	mu          sync.Mutex
 	enabled     bool                   // whether LLDP is enabled globally
 	portEnabled map[string]bool        // contains the enabled ports
 	portDaemons map[string]*portDaemon // tracks the active port daemons
@@ -51,6 +54,8 @@ func New() *Daemon {
 
 // Start starts the procotol handler.
 func (d *Daemon) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	if d.portDaemons == nil {
 		d.portDaemons = map[string]*portDaemon{}
 	}
@@ -59,6 +64,8 @@ func (d *Daemon) Start() {
 
 // Stop stops the procotol handler by stopping all port daemons.
 func (d *Daemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	for _, p := range d.portDaemons {
 		p.Stop()
 	}
@@ -93,6 +100,8 @@ func (d *Daemon) changePortState(intf *oc.Lldp_Interface) bool {
 
 // Reconcile reconciles LLDP for all ports.
 func (d *Daemon) Reconcile(ctx context.Context, intent *oc.Root, c *ygnmi.Client) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 	sb := &ygnmi.SetBatch{}
 	if wantEnabled := intent.Lldp.GetEnabled(); d.enabled != wantEnabled {
 		d.enabled = wantEnabled
@@ -116,21 +125,51 @@ func (d *Daemon) Matched(po *packetio.PacketOut) bool {
 
 // Process dispatches the packet to the corresponding port handler.
 func (d *Daemon) Process(p *packetio.Packet) error {
+	d.mu.Lock()
 	pd, ok := d.portDaemons[fmt.Sprintf("%d", p.HostPort)]
+	d.mu.Unlock()
 	if !ok {
 		return fmt.Errorf("port %q not found", p.HostPort)
 	}
 	return pd.Process(p)
 }
 
+// ClearInterface forgets the neighbor of the port, removes it from the state and resets the counters.
+// It is a noop if LLDP is not running on the port.
+func (d *Daemon) ClearInterface(ctx context.Context, name string, c *ygnmi.Client) error {
+	d.mu.Lock()
+	pd, ok := d.portDaemons[name]
+	d.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	pd.clear()
+
+	sb := &ygnmi.SetBatch{}
+	intf := ocpath.Root().Lldp().Interface(name)
+	gnmiclient.BatchDelete(sb, intf.NeighborMap().State())
+	gnmiclient.BatchReplace(sb, intf.Counters().State(), &oc.Lldp_Interface_Counters{
+		FrameIn:      ygot.Uint64(0),
+		FrameOut:     ygot.Uint64(0),
+		FrameErrorIn: ygot.Uint64(0),
+		LastClear:    ygot.String(time.Now().UTC().Format(time.RFC3339Nano)),
+	})
+	if _, err := sb.Set(ctx, c); err != nil {
+		return fmt.Errorf("failed to clear LLDP state of interface %q: %v", name, err)
+	}
+	return nil
+}
+
 // portDaemon contains the required information for LLDP and processes the LLDP frames for a given hostif.
 type portDaemon struct {
 	Name      string
-	info      *lldpInfo
 	Interval  time.Duration
 	doneCh    chan struct{}
 	inCh      chan *packetio.Packet
 	errRecvCh chan error
+
+	mu   sync.Mutex // protects info
+	info *lldpInfo
 }
 
 // newPortDaemon creates a port daemon to send/recv LLDP protocol.
@@ -149,7 +188,9 @@ func newPortDaemon(hostif string) *portDaemon {
 				log.Infof("Stop sending LLDP frame")
 				return
 			default:
+				d.mu.Lock()
 				_, err := d.info.Frame()
+				d.mu.Unlock()
 				if err != nil {
 					log.Errorf("failed to create LLDP frame: %v", err)
 					continue
@@ -182,8 +223,10 @@ func newPortDaemon(hostif string) *portDaemon {
 						d.errRecvCh <- fmt.Errorf("packet is not LinkLayerDiscoveryInfo: %+v", layer)
 						continue
 					}
+					d.mu.Lock()
 					d.info.RemoteSysName = info.SysName
 					d.info.RemoteSysDesc = info.SysDescription
+					d.mu.Unlock()
 				}
 			}
 		}
@@ -201,6 +244,18 @@ func (d *portDaemon) Process(p *packetio.Packet) error {
 	return err
 }
 
+// clear forgets the remote system.
+func (d *portDaemon) clear() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.info != nil {
+		d.info.RemoteSysName = ""
+		d.info.RemoteSysDesc = ""
+		d.info.RemotePortName = ""
+		d.info.RemotePortDesc = ""
+	}
+}
+
 // Stop stops the port daemon.
 func (d *portDaemon) Stop() {
 	d.doneCh <- struct{}{}
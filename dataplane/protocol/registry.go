@@ -44,6 +44,7 @@ type Registry struct {
 	psc     pktiopb.PacketIO_CPUPacketStreamClient // the original packet stream client.
 	doneCh  chan struct{}
 	mu      sync.Mutex
+	sendMu  sync.Mutex         // serializes sends on the stream client, since handlers may send concurrently.
 	reg     map[string]Handler // map the protocol name to its handler.
 }
 
@@ -92,6 +93,7 @@ func (r *Registry) Start() {
 					continue
 				}
 				processed := false
+				r.mu.Lock()
 				for name, ph := range r.reg {
 					if ph.Matched(pkt) {
 						if err := ph.Process(pkt); err != nil {
@@ -101,6 +103,7 @@ func (r *Registry) Start() {
 						break
 					}
 				}
+				r.mu.Unlock()
 				if !processed {
 					if err := r.bypassQ.Write(pkt); err != nil {
 						log.Warningf("Error occurred when inserting packet: %v", err)
@@ -129,6 +132,8 @@ func (r *Registry) Recv() (*packetio.PacketOut, error) {
 
 // Send sends the packet via the streaming client it holds.
 func (r *Registry) Send(pkt *packetio.PacketIn) error {
+	r.sendMu.Lock()
+	defer r.sendMu.Unlock()
 	return r.psc.Send(pkt)
 }
 
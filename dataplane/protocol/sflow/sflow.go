@@ -0,0 +1,398 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sflow is an sFlow version 5 agent, which exports the packets sampled by
+// the dataplane and the counters of the sampled interfaces to sFlow collectors.
+package sflow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+const (
+	version = 5
+
+	flowSampleFormat      = 1
+	counterSampleFormat   = 2
+	rawHeaderFormat       = 1
+	genericCountersFormat = 1
+
+	headerProtocolEthernet = 1
+	ifTypeEthernet         = 6
+	ifDirectionFullDuplex  = 1
+	fcsLength              = 4
+
+	// DefaultSampleSize is the default maximum number of bytes of the sampled packets exported.
+	DefaultSampleSize = 128
+	// maxCounterSamples is the maximum number of counter samples in a datagram, which keeps
+	// the datagrams within the MTU.
+	maxCounterSamples = 10
+)
+
+// Interface is an interface sampled by the agent.
+type Interface struct {
+	Index       uint32 // The ifIndex of the interface, which identifies it in the samples.
+	IngressRate uint32 // One in IngressRate received packets is sampled, 0 if ingress sampling is disabled.
+	EgressRate  uint32 // One in EgressRate transmitted packets is sampled, 0 if egress sampling is disabled.
+}
+
+// Config is the configuration of the agent.
+type Config struct {
+	Agent           netip.Addr            // The address identifying the agent in the datagrams.
+	Collectors      []netip.AddrPort      // The collectors the datagrams are sent to.
+	SampleSize      int                   // The maximum number of bytes of the sampled packets exported.
+	PollingInterval time.Duration         // The interval of the counter samples, 0 disables them.
+	Interfaces      map[uint64]*Interface // The sampled interfaces, keyed by port id.
+}
+
+// Counters are the counters of an interface.
+type Counters struct {
+	Speed            uint64 // In bits per second.
+	AdminUp          bool
+	OperUp           bool
+	InOctets         uint64
+	InUnicastPkts    uint64
+	InMulticastPkts  uint64
+	InBroadcastPkts  uint64
+	InDiscards       uint64
+	InErrors         uint64
+	OutOctets        uint64
+	OutUnicastPkts   uint64
+	OutMulticastPkts uint64
+	OutBroadcastPkts uint64
+	OutDiscards      uint64
+	OutErrors        uint64
+}
+
+// CounterFunc returns the counters of the port.
+type CounterFunc func(port uint64) (*Counters, error)
+
+// Agent exports the packets punted with the sample trap as flow samples, and
+// periodically exports the counters of the sampled interfaces as counter samples.
+type Agent struct {
+	trapID   uint64
+	counters CounterFunc
+	start    time.Time
+
+	mu       sync.Mutex
+	cfg      *Config // nil if the agent is disabled.
+	conn     net.PacketConn
+	stopPoll func()
+	seq      uint32                    // The sequence number of the datagrams.
+	flowSeq  map[uint32]uint32         // The sequence number of the flow samples, keyed by data source.
+	cntSeq   map[uint32]uint32         // The sequence number of the counter samples, keyed by data source.
+	pool     map[uint32]uint32         // The packets that could have been sampled, keyed by data source.
+	sampled  map[uint64]uint64         // The packets sampled, keyed by port id.
+	sent     map[netip.AddrPort]uint64 // The datagrams sent, keyed by collector.
+}
+
+// New returns a disabled agent for the packets punted with the trap ID, counters returns
+// the counters of the sampled interfaces.
+func New(trapID uint64, counters CounterFunc) *Agent {
+	return &Agent{
+		trapID:   trapID,
+		counters: counters,
+		start:    time.Now(),
+		flowSeq:  map[uint32]uint32{},
+		cntSeq:   map[uint32]uint32{},
+		pool:     map[uint32]uint32{},
+		sampled:  map[uint64]uint64{},
+		sent:     map[netip.AddrPort]uint64{},
+	}
+}
+
+// Configure replaces the configuration of the agent, a nil configuration disables the agent.
+func (a *Agent) Configure(cfg *Config) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopPoll != nil {
+		a.stopPoll()
+		a.stopPoll = nil
+	}
+	if cfg == nil {
+		a.cfg = nil
+		if a.conn != nil {
+			a.conn.Close()
+			a.conn = nil
+		}
+		return nil
+	}
+	if !cfg.Agent.IsValid() {
+		return fmt.Errorf("invalid agent address %v", cfg.Agent)
+	}
+	if a.conn == nil {
+		conn, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			return fmt.Errorf("failed to open socket: %v", err)
+		}
+		a.conn = conn
+	}
+	c := *cfg
+	if c.SampleSize <= 0 {
+		c.SampleSize = DefaultSampleSize
+	}
+	a.cfg = &c
+	if c.PollingInterval > 0 {
+		done := make(chan struct{})
+		a.stopPoll = func() { close(done) }
+		go a.poll(c.PollingInterval, done)
+	}
+	return nil
+}
+
+// PacketsSent returns the number of datagrams sent to the collector.
+func (a *Agent) PacketsSent(collector netip.AddrPort) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sent[collector]
+}
+
+// PacketsSampled returns the number of packets sampled on the port.
+func (a *Agent) PacketsSampled(port uint64) uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.sampled[port]
+}
+
+// Matched returns true if the packet was punted with the sample trap.
+func (a *Agent) Matched(po *pktiopb.PacketOut) bool {
+	return po.GetPacket().GetHostPort() == a.trapID
+}
+
+// Process exports the sampled packet as a flow sample. Packets sampled on egress
+// carry their output port, and packets sampled on ingress do not.
+func (a *Agent) Process(po *pktiopb.PacketOut) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cfg == nil {
+		return nil
+	}
+	in, out := po.GetPacket().GetInputPort(), po.GetPacket().GetOutputPort()
+	port, ingress := out, false
+	if out == 0 {
+		port, ingress = in, true
+	}
+	intf, ok := a.cfg.Interfaces[port]
+	if !ok {
+		return fmt.Errorf("port %d is not sampled", port)
+	}
+	rate := intf.EgressRate
+	if ingress {
+		rate = intf.IngressRate
+	}
+	s := &flowSample{
+		source: intf.Index,
+		rate:   rate,
+		frame:  po.GetPacket().GetFrame(),
+	}
+	if i, ok := a.cfg.Interfaces[in]; ok {
+		s.input = i.Index
+	}
+	if i, ok := a.cfg.Interfaces[out]; ok && !ingress {
+		s.output = i.Index
+	}
+	a.flowSeq[s.source]++
+	a.pool[s.source] += rate
+	a.sampled[port]++
+	s.seq, s.pool = a.flowSeq[s.source], a.pool[s.source]
+	return a.send([][]byte{s.marshal(a.cfg.SampleSize)})
+}
+
+// poll exports the counters of the sampled interfaces every interval until done is closed.
+func (a *Agent) poll(interval time.Duration, done chan struct{}) {
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-tick.C:
+		}
+		a.mu.Lock()
+		if stopped(done) {
+			a.mu.Unlock()
+			return
+		}
+		intfs := maps.Clone(a.cfg.Interfaces)
+		a.mu.Unlock()
+
+		var samples []*counterSample
+		for _, port := range slices.Sorted(maps.Keys(intfs)) {
+			c, err := a.counters(port)
+			if err != nil {
+				log.Warningf("sflow: failed to get counters of port %d: %v", port, err)
+				continue
+			}
+			samples = append(samples, &counterSample{source: intfs[port].Index, counters: c})
+		}
+
+		a.mu.Lock()
+		// The agent may have been reconfigured while the counters were polled.
+		if stopped(done) {
+			a.mu.Unlock()
+			return
+		}
+		for len(samples) > 0 {
+			n := min(len(samples), maxCounterSamples)
+			var data [][]byte
+			for _, s := range samples[:n] {
+				a.cntSeq[s.source]++
+				s.seq = a.cntSeq[s.source]
+				data = append(data, s.marshal())
+			}
+			if err := a.send(data); err != nil {
+				log.Warningf("sflow: %v", err)
+			}
+			samples = samples[n:]
+		}
+		a.mu.Unlock()
+	}
+}
+
+// stopped returns whether done is closed.
+func stopped(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// send sends a datagram with the samples to the collectors. The caller must hold mu.
+func (a *Agent) send(samples [][]byte) error {
+	a.seq++
+	b := marshalDatagram(a.cfg.Agent, a.seq, uint32(time.Since(a.start).Milliseconds()), samples)
+	var errs []error
+	for _, c := range a.cfg.Collectors {
+		if _, err := a.conn.WriteTo(b, net.UDPAddrFromAddrPort(c)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send datagram to %v: %v", c, err))
+			continue
+		}
+		a.sent[c]++
+	}
+	return errors.Join(errs...)
+}
+
+// marshalDatagram returns the datagram with the samples, the sub-agent ID is always 0.
+func marshalDatagram(agent netip.Addr, seq, uptime uint32, samples [][]byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, version)
+	if agent.Is4() {
+		b = binary.BigEndian.AppendUint32(b, 1)
+	} else {
+		b = binary.BigEndian.AppendUint32(b, 2)
+	}
+	b = append(b, agent.AsSlice()...)
+	b = binary.BigEndian.AppendUint32(b, 0)
+	b = binary.BigEndian.AppendUint32(b, seq)
+	b = binary.BigEndian.AppendUint32(b, uptime)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(samples)))
+	for _, s := range samples {
+		b = append(b, s...)
+	}
+	return b
+}
+
+// appendRecord appends the record of the format, prefixed by its format and length.
+func appendRecord(b []byte, format uint32, record []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, format)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(record)))
+	return append(b, record...)
+}
+
+// flowSample is a packet sampled on an interface, the interfaces are identified by their ifIndex.
+type flowSample struct {
+	seq    uint32
+	source uint32
+	rate   uint32
+	pool   uint32
+	input  uint32 // 0 if unknown.
+	output uint32 // 0 if unknown.
+	frame  []byte
+}
+
+// marshal returns the flow sample with a raw packet header record of at most size bytes.
+func (s *flowSample) marshal(size int) []byte {
+	header := s.frame[:min(len(s.frame), size)]
+	// The frame length includes the FCS, which is not in the frames punted by the dataplane.
+	r := binary.BigEndian.AppendUint32(nil, headerProtocolEthernet)
+	r = binary.BigEndian.AppendUint32(r, uint32(len(s.frame)+fcsLength))
+	r = binary.BigEndian.AppendUint32(r, fcsLength)
+	r = binary.BigEndian.AppendUint32(r, uint32(len(header)))
+	r = append(r, header...)
+	// The header is padded to a multiple of 4 bytes.
+	r = append(r, make([]byte, (4-len(header)%4)%4)...)
+
+	b := binary.BigEndian.AppendUint32(nil, s.seq)
+	b = binary.BigEndian.AppendUint32(b, s.source)
+	b = binary.BigEndian.AppendUint32(b, s.rate)
+	b = binary.BigEndian.AppendUint32(b, s.pool)
+	b = binary.BigEndian.AppendUint32(b, 0) // drops
+	b = binary.BigEndian.AppendUint32(b, s.input)
+	b = binary.BigEndian.AppendUint32(b, s.output)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = appendRecord(b, rawHeaderFormat, r)
+	return appendRecord(nil, flowSampleFormat, b)
+}
+
+// counterSample is the counters of an interface, identified by its ifIndex.
+type counterSample struct {
+	seq      uint32
+	source   uint32
+	counters *Counters
+}
+
+// marshal returns the counter sample with a generic interface counters record.
+// The packet counters are 32-bit and wrap around.
+func (s *counterSample) marshal() []byte {
+	c := s.counters
+	var status uint32
+	if c.AdminUp {
+		status |= 1
+	}
+	if c.OperUp {
+		status |= 2
+	}
+	r := binary.BigEndian.AppendUint32(nil, s.source)
+	r = binary.BigEndian.AppendUint32(r, ifTypeEthernet)
+	r = binary.BigEndian.AppendUint64(r, c.Speed)
+	r = binary.BigEndian.AppendUint32(r, ifDirectionFullDuplex)
+	r = binary.BigEndian.AppendUint32(r, status)
+	r = binary.BigEndian.AppendUint64(r, c.InOctets)
+	for _, v := range []uint64{c.InUnicastPkts, c.InMulticastPkts, c.InBroadcastPkts, c.InDiscards, c.InErrors, 0} {
+		r = binary.BigEndian.AppendUint32(r, uint32(v))
+	}
+	r = binary.BigEndian.AppendUint64(r, c.OutOctets)
+	for _, v := range []uint64{c.OutUnicastPkts, c.OutMulticastPkts, c.OutBroadcastPkts, c.OutDiscards, c.OutErrors, 0} {
+		r = binary.BigEndian.AppendUint32(r, uint32(v))
+	}
+
+	b := binary.BigEndian.AppendUint32(nil, s.seq)
+	b = binary.BigEndian.AppendUint32(b, s.source)
+	b = binary.BigEndian.AppendUint32(b, 1)
+	b = appendRecord(b, genericCountersFormat, r)
+	return appendRecord(nil, counterSampleFormat, b)
+}
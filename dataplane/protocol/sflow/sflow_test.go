@@ -0,0 +1,211 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sflow
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/openconfig/gnmi/errdiff"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+)
+
+// listen returns a collector listening on the loopback address.
+func listen(t *testing.T) (net.PacketConn, netip.AddrPort) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() unexpected err: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, conn.LocalAddr().(*net.UDPAddr).AddrPort()
+}
+
+// recv returns the next datagram received by the collector.
+func recv(t *testing.T, conn net.PacketConn) *layers.SFlowDatagram {
+	t.Helper()
+	buf := make([]byte, 65535)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() unexpected err: %v", err)
+	}
+	d := &layers.SFlowDatagram{}
+	if err := d.DecodeFromBytes(buf[:n], gopacket.NilDecodeFeedback); err != nil {
+		t.Fatalf("DecodeFromBytes() unexpected err: %v", err)
+	}
+	return d
+}
+
+func TestFlowSample(t *testing.T) {
+	frame := bytes.Repeat([]byte{0xab}, 150)
+	tests := []struct {
+		desc       string
+		pkt        *pktiopb.Packet
+		wantSource uint32
+		wantRate   uint32
+		wantIn     uint32
+		wantOut    uint32
+		wantErr    string
+	}{{
+		desc:       "ingress",
+		pkt:        &pktiopb.Packet{HostPort: 5, InputPort: 10, Frame: frame},
+		wantSource: 1,
+		wantRate:   100,
+		wantIn:     1,
+	}, {
+		desc:       "egress",
+		pkt:        &pktiopb.Packet{HostPort: 5, InputPort: 10, OutputPort: 20, Frame: frame},
+		wantSource: 2,
+		wantRate:   1000,
+		wantIn:     1,
+		wantOut:    2,
+	}, {
+		desc:    "unknown port",
+		pkt:     &pktiopb.Packet{HostPort: 5, InputPort: 30, Frame: frame},
+		wantErr: "not sampled",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			conn, collector := listen(t)
+			a := New(5, nil)
+			err := a.Configure(&Config{
+				Agent:      netip.MustParseAddr("192.0.2.1"),
+				Collectors: []netip.AddrPort{collector},
+				SampleSize: 64,
+				Interfaces: map[uint64]*Interface{
+					10: {Index: 1, IngressRate: 100},
+					20: {Index: 2, EgressRate: 1000},
+				},
+			})
+			if err != nil {
+				t.Fatalf("Configure() unexpected err: %v", err)
+			}
+			defer a.Configure(nil)
+
+			po := &pktiopb.PacketOut{Packet: tt.pkt}
+			if !a.Matched(po) {
+				t.Fatalf("Matched() got false, want true")
+			}
+			gotErr := a.Process(po)
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("Process() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+			d := recv(t, conn)
+			if !d.AgentAddress.Equal(net.ParseIP("192.0.2.1")) || d.SequenceNumber != 1 || len(d.FlowSamples) != 1 {
+				t.Fatalf("Process() got datagram %+v, want one flow sample from 192.0.2.1", d)
+			}
+			got := d.FlowSamples[0]
+			want := layers.SFlowFlowSample{
+				Format:          layers.SFlowTypeFlowSample,
+				SequenceNumber:  1,
+				SourceIDIndex:   layers.SFlowSourceValue(tt.wantSource),
+				SamplingRate:    tt.wantRate,
+				SamplePool:      tt.wantRate,
+				InputInterface:  tt.wantIn,
+				OutputInterface: tt.wantOut,
+				RecordCount:     1,
+			}
+			if d := cmp.Diff(got, want, cmpopts.IgnoreFields(layers.SFlowFlowSample{}, "SampleLength", "Records")); d != "" {
+				t.Errorf("Process() failed: diff(-got,+want)\n:%s", d)
+			}
+			r, ok := got.Records[0].(layers.SFlowRawPacketFlowRecord)
+			if !ok {
+				t.Fatalf("Process() got record %T, want raw packet header", got.Records[0])
+			}
+			if r.FrameLength != 154 || r.PayloadRemoved != 4 || r.HeaderLength != 64 || !bytes.Equal(r.Header.Data(), frame[:64]) {
+				t.Errorf("Process() got raw packet header %+v, want the first 64 bytes of the frame", r)
+			}
+			if got := a.PacketsSent(collector); got != 1 {
+				t.Errorf("PacketsSent() got %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestCounterSample(t *testing.T) {
+	conn, collector := listen(t)
+	a := New(5, func(port uint64) (*Counters, error) {
+		return &Counters{
+			Speed:          1e9,
+			AdminUp:        true,
+			OperUp:         true,
+			InOctets:       1000,
+			InUnicastPkts:  10,
+			OutOctets:      2000,
+			OutUnicastPkts: 20,
+			OutDiscards:    uint64(port),
+		}, nil
+	})
+	err := a.Configure(&Config{
+		Agent:           netip.MustParseAddr("2001:db8::1"),
+		Collectors:      []netip.AddrPort{collector},
+		PollingInterval: 10 * time.Millisecond,
+		Interfaces:      map[uint64]*Interface{10: {Index: 1, IngressRate: 100}},
+	})
+	if err != nil {
+		t.Fatalf("Configure() unexpected err: %v", err)
+	}
+	defer a.Configure(nil)
+
+	d := recv(t, conn)
+	if !d.AgentAddress.Equal(net.ParseIP("2001:db8::1")) || len(d.CounterSamples) != 1 || len(d.CounterSamples[0].Records) != 1 {
+		t.Fatalf("poll() got datagram %+v, want one counter sample from 2001:db8::1", d)
+	}
+	got, ok := d.CounterSamples[0].Records[0].(layers.SFlowGenericInterfaceCounters)
+	if !ok {
+		t.Fatalf("poll() got record %T, want generic interface counters", d.CounterSamples[0].Records[0])
+	}
+	want := layers.SFlowGenericInterfaceCounters{
+		IfIndex:        1,
+		IfType:         6,
+		IfSpeed:        1e9,
+		IfDirection:    1,
+		IfStatus:       3,
+		IfInOctets:     1000,
+		IfInUcastPkts:  10,
+		IfOutOctets:    2000,
+		IfOutUcastPkts: 20,
+		IfOutDiscards:  10,
+	}
+	if d := cmp.Diff(got, want, cmpopts.IgnoreFields(layers.SFlowGenericInterfaceCounters{}, "SFlowBaseCounterRecord")); d != "" {
+		t.Errorf("poll() failed: diff(-got,+want)\n:%s", d)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	a := New(5, nil)
+	if err := a.Configure(&Config{}); err == nil {
+		t.Errorf("Configure() got no error for missing agent address")
+	}
+	// A disabled agent ignores the sampled packets.
+	if err := a.Process(&pktiopb.PacketOut{Packet: &pktiopb.Packet{HostPort: 5, InputPort: 10}}); err != nil {
+		t.Errorf("Process() unexpected err: %v", err)
+	}
+	if a.Matched(&pktiopb.PacketOut{Packet: &pktiopb.Packet{HostPort: 6}}) {
+		t.Errorf("Matched() got true for packet punted with another trap")
+	}
+}
@@ -18,17 +18,68 @@
 package dataplane
 
 import (
+	"context"
+
+	"github.com/openconfig/ygnmi/ygnmi"
 	"google.golang.org/grpc"
 
 	"github.com/openconfig/lemming/dataplane/dplanerc"
+	"github.com/openconfig/lemming/dataplane/protocol"
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
+	"github.com/openconfig/lemming/dataplane/protocol/isis"
+	"github.com/openconfig/lemming/dataplane/protocol/lacp"
+	"github.com/openconfig/lemming/dataplane/protocol/sflow"
 	"github.com/openconfig/lemming/gnmi/reconciler"
 )
 
-func getReconcilers(conn grpc.ClientConnInterface, switchID uint64, cpuPortID uint64, contextID string) []reconciler.Reconciler {
+func getReconcilers(conn grpc.ClientConnInterface, switchID uint64, cpuPortID uint64, contextID string, pr *protocol.Registry, inj icmp.Injector, ttlTrapID, aclTrapID, sampleTrapID uint64, sysribAddr string) ([]reconciler.Reconciler, interfaces) {
 	r := dplanerc.New(conn, switchID, cpuPortID, contextID)
 
 	return []reconciler.Reconciler{
 		reconciler.NewBuilder("inferface").WithStart(r.StartInterface).Build(),
 		reconciler.NewBuilder("routes").WithStart(r.StartRoute).WithStop(r.Stop).Build(),
-	}
+		reconciler.NewBuilder("label-routes").WithStart(r.StartLabelRoute).Build(),
+		reconciler.NewBuilder("qos").WithStart(r.StartQoS).Build(),
+		// Reply to expired packets from the address of their input interface.
+		reconciler.NewBuilder("ttl-error").WithStart(func(context.Context, *ygnmi.Client) error {
+			return pr.Register("ttl-error", icmp.NewTimeExceededResponder(inj, ttlTrapID, r.PortAddr))
+		}).Build(),
+		// Reply to the packets rejected by an ACL from the address of their input interface.
+		reconciler.NewBuilder("acl").WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+			if err := pr.Register("acl-reject", icmp.NewUnreachableResponder(inj, aclTrapID, r.PortAddr)); err != nil {
+				return err
+			}
+			return r.StartACL(ctx, c, aclTrapID)
+		}).Build(),
+		// Export the packets sampled by the interfaces to the sFlow collectors.
+		reconciler.NewBuilder("sampling").WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+			agent := sflow.New(sampleTrapID, r.SFlowCounters)
+			if err := pr.Register("sflow", agent); err != nil {
+				return err
+			}
+			return r.StartSampling(ctx, c, agent)
+		}).Build(),
+		// Negotiate the members of the LACP aggregate interfaces with their partners.
+		reconciler.NewBuilder("lacp").WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+			d, err := lacp.New(pr, r.SetLACPDistributing)
+			if err != nil {
+				return err
+			}
+			if err := pr.Register("lacp", d); err != nil {
+				return err
+			}
+			return r.StartLACP(ctx, c, d)
+		}).Build(),
+		// Run IS-IS over the CPU port and install its routes in the sysrib.
+		reconciler.NewBuilder("isis").WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+			d, err := isis.New(pr, r.SetISISRoutes)
+			if err != nil {
+				return err
+			}
+			if err := pr.Register("isis", d); err != nil {
+				return err
+			}
+			return r.StartISIS(ctx, c, d, sysribAddr)
+		}).Build(),
+	}, r
 }
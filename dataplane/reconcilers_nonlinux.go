@@ -21,13 +21,15 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/openconfig/lemming/dataplane/dplanerc"
+	"github.com/openconfig/lemming/dataplane/protocol"
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
 	"github.com/openconfig/lemming/gnmi/reconciler"
 )
 
-func getReconcilers(conn grpc.ClientConnInterface, switchID uint64, cpuPortID uint64, contextID string) []reconciler.Reconciler {
+func getReconcilers(conn grpc.ClientConnInterface, switchID uint64, cpuPortID uint64, contextID string, _ *protocol.Registry, _ icmp.Injector, _, _, _ uint64, _ string) ([]reconciler.Reconciler, interfaces) {
 	r := dplanerc.New(conn, switchID, cpuPortID, contextID)
 
 	return []reconciler.Reconciler{
 		reconciler.NewBuilder("inferface").WithStart(r.StartInterface).Build(),
-	}
+	}, nil
 }
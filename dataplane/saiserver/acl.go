@@ -181,6 +181,13 @@ func (a *acl) createAclEntryFields(req *saipb.CreateAclEntryRequest, id uint64,
 			Masks:   req.GetFieldDstIp().GetMaskIp(),
 		})
 	}
+	if req.GetFieldSrcIp() != nil {
+		aReq.EntryDesc.GetFlow().Fields = append(aReq.EntryDesc.GetFlow().Fields, &fwdpb.PacketFieldMaskedBytes{
+			FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_SRC}},
+			Bytes:   req.GetFieldSrcIp().GetDataIp(),
+			Masks:   req.GetFieldSrcIp().GetMaskIp(),
+		})
+	}
 	if req.GetFieldInPort() != nil {
 		fwdCtx, err := a.dataplane.FindContext(&fwdpb.ContextId{Id: a.dataplane.ID()})
 		if err != nil {
@@ -273,6 +280,11 @@ func (a *acl) createAclEntryFields(req *saipb.CreateAclEntryRequest, id uint64,
 	ipv6Field := false
 	ipv6Addr := make([]byte, 16)
 	ipv6Mask := make([]byte, 16)
+	if req.GetFieldDstIpv6() != nil {
+		ipv6Field = true
+		copy(ipv6Addr, req.GetFieldDstIpv6().GetDataIp())
+		copy(ipv6Mask, req.GetFieldDstIpv6().GetMaskIp())
+	}
 	if req.GetFieldDstIpv6Word0() != nil { // Word0 is supposed to match 0:0:0:0:0:0:ffff:ffff
 		ipv6Field = true
 		copy(ipv6Addr[12:16], req.GetFieldDstIpv6Word0().GetDataIp()[12:16])
@@ -303,6 +315,11 @@ func (a *acl) createAclEntryFields(req *saipb.CreateAclEntryRequest, id uint64,
 	srcIpv6Field := false
 	srcIpv6Addr := make([]byte, 16)
 	srcIpv6Mask := make([]byte, 16)
+	if req.GetFieldSrcIpv6() != nil {
+		srcIpv6Field = true
+		copy(srcIpv6Addr, req.GetFieldSrcIpv6().GetDataIp())
+		copy(srcIpv6Mask, req.GetFieldSrcIpv6().GetMaskIp())
+	}
 	if req.GetFieldSrcIpv6Word0() != nil { // Word0 is supposed to match 0:0:0:0:0:0:ffff:ffff
 		srcIpv6Field = true
 		copy(srcIpv6Addr[12:16], req.GetFieldSrcIpv6Word0().GetDataIp()[12:16])
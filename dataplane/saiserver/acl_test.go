@@ -534,6 +534,40 @@ func TestCreateAclEntry(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		desc: "src ip and dst ipv6",
+		req: &saipb.CreateAclEntryRequest{
+			TableId: proto.Uint64(2),
+			FieldSrcIp: &saipb.AclFieldData{
+				Data: &saipb.AclFieldData_DataIp{DataIp: []byte{192, 0, 2, 1}},
+				Mask: &saipb.AclFieldData_MaskIp{MaskIp: []byte{255, 255, 255, 255}},
+			},
+			FieldDstIpv6: &saipb.AclFieldData{
+				Data: &saipb.AclFieldData_DataIp{DataIp: []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}},
+				Mask: &saipb.AclFieldData_MaskIp{MaskIp: []byte{0xff, 0xff, 0xff, 0xff, 15: 0}},
+			},
+		},
+		want: &fwdpb.TableEntryAddRequest{
+			ContextId: &fwdpb.ContextId{Id: "foo"},
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: "1"}},
+			EntryDesc: &fwdpb.EntryDesc{
+				Entry: &fwdpb.EntryDesc_Flow{
+					Flow: &fwdpb.FlowEntryDesc{
+						Id:       2,
+						Priority: math.MaxUint32,
+						Fields: []*fwdpb.PacketFieldMaskedBytes{{
+							FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_SRC}},
+							Bytes:   []byte{192, 0, 2, 1},
+							Masks:   []byte{255, 255, 255, 255},
+						}, {
+							FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_DST}},
+							Bytes:   []byte{0x20, 0x01, 0x0d, 0xb8, 15: 1},
+							Masks:   []byte{0xff, 0xff, 0xff, 0xff, 15: 0},
+						}},
+					},
+				},
+			},
+		},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -0,0 +1,99 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// counter implements the generic SAI counter objects, which can be attached to table entries
+// (e.g. inseg entries) using the entry's counter id. Each counter is backed by a flow counter.
+type counter struct {
+	saipb.UnimplementedCounterServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+}
+
+func newCounter(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server) *counter {
+	c := &counter{
+		mgr:       mgr,
+		dataplane: dataplane,
+	}
+	saipb.RegisterCounterServer(s, c)
+	return c
+}
+
+// CreateCounter creates a flow counter.
+func (c *counter) CreateCounter(ctx context.Context, req *saipb.CreateCounterRequest) (*saipb.CreateCounterResponse, error) {
+	id := c.mgr.NextID()
+	_, err := c.dataplane.FlowCounterCreate(ctx, &fwdpb.FlowCounterCreateRequest{
+		ContextId: &fwdpb.ContextId{Id: c.dataplane.ID()},
+		Id:        &fwdpb.FlowCounterId{ObjectId: &fwdpb.ObjectId{Id: fmt.Sprint(id)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saipb.CreateCounterResponse{Oid: id}, nil
+}
+
+// RemoveCounter removes the flow counter.
+func (c *counter) RemoveCounter(ctx context.Context, req *saipb.RemoveCounterRequest) (*saipb.RemoveCounterResponse, error) {
+	_, err := c.dataplane.ObjectDelete(ctx, &fwdpb.ObjectDeleteRequest{
+		ContextId: &fwdpb.ContextId{Id: c.dataplane.ID()},
+		ObjectId:  &fwdpb.ObjectId{Id: fmt.Sprint(req.GetOid())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &saipb.RemoveCounterResponse{}, nil
+}
+
+// GetCounterStats returns the packets and bytes counted by the flow counter.
+func (c *counter) GetCounterStats(ctx context.Context, req *saipb.GetCounterStatsRequest) (*saipb.GetCounterStatsResponse, error) {
+	count, err := c.dataplane.FlowCounterQuery(ctx, &fwdpb.FlowCounterQueryRequest{
+		ContextId: &fwdpb.ContextId{Id: c.dataplane.ID()},
+		Ids:       []*fwdpb.FlowCounterId{{ObjectId: &fwdpb.ObjectId{Id: fmt.Sprint(req.GetOid())}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var packets, octets uint64
+	if len(count.GetCounters()) > 0 {
+		packets = count.GetCounters()[0].GetPackets()
+		octets = count.GetCounters()[0].GetOctets()
+	}
+	resp := &saipb.GetCounterStatsResponse{}
+	for _, id := range req.GetCounterIds() {
+		switch id {
+		case saipb.CounterStat_COUNTER_STAT_PACKETS:
+			resp.Values = append(resp.Values, packets)
+		case saipb.CounterStat_COUNTER_STAT_BYTES:
+			resp.Values = append(resp.Values, octets)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported counter stat %v", id)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+func TestCreateCounter(t *testing.T) {
+	dplane := &fakeSwitchDataplane{}
+	c, stopFn := newTestCounter(t, dplane)
+	defer stopFn()
+	got, err := c.CreateCounter(context.TODO(), &saipb.CreateCounterRequest{})
+	if err != nil {
+		t.Fatalf("CreateCounter() unexpected err: %v", err)
+	}
+	want := &fwdpb.FlowCounterCreateRequest{
+		ContextId: &fwdpb.ContextId{Id: "foo"},
+		Id:        &fwdpb.FlowCounterId{ObjectId: &fwdpb.ObjectId{Id: "1"}},
+	}
+	if got.GetOid() != 1 {
+		t.Errorf("CreateCounter() got oid %d, want 1", got.GetOid())
+	}
+	if d := cmp.Diff(dplane.gotFlowCounterCreateReqs[0], want, protocmp.Transform()); d != "" {
+		t.Errorf("CreateCounter() failed: diff(-got,+want)\n:%s", d)
+	}
+}
+
+func TestGetCounterStats(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     *saipb.GetCounterStatsRequest
+		replies []*fwdpb.FlowCounterQueryReply
+		want    *saipb.GetCounterStatsResponse
+		wantErr string
+	}{{
+		desc: "packets and bytes",
+		req: &saipb.GetCounterStatsRequest{
+			Oid:        1,
+			CounterIds: []saipb.CounterStat{saipb.CounterStat_COUNTER_STAT_BYTES, saipb.CounterStat_COUNTER_STAT_PACKETS},
+		},
+		replies: []*fwdpb.FlowCounterQueryReply{{
+			Counters: []*fwdpb.FlowCounter{{
+				Packets: 2,
+				Octets:  200,
+			}},
+		}},
+		want: &saipb.GetCounterStatsResponse{
+			Values: []uint64{200, 2},
+		},
+	}, {
+		desc: "empty counters",
+		req: &saipb.GetCounterStatsRequest{
+			Oid:        1,
+			CounterIds: []saipb.CounterStat{saipb.CounterStat_COUNTER_STAT_PACKETS},
+		},
+		want: &saipb.GetCounterStatsResponse{
+			Values: []uint64{0},
+		},
+	}, {
+		desc: "unsupported stat",
+		req: &saipb.GetCounterStatsRequest{
+			Oid:        1,
+			CounterIds: []saipb.CounterStat{saipb.CounterStat_COUNTER_STAT_CUSTOM_RANGE_BASE},
+		},
+		wantErr: "InvalidArgument",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dplane := &fakeSwitchDataplane{
+				flowQueryReplies: tt.replies,
+			}
+			c, stopFn := newTestCounter(t, dplane)
+			defer stopFn()
+			got, gotErr := c.GetCounterStats(context.TODO(), tt.req)
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("GetCounterStats() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+			if d := cmp.Diff(got, tt.want, protocmp.Transform()); d != "" {
+				t.Errorf("GetCounterStats() failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
+
+func newTestCounter(t testing.TB, api switchDataplaneAPI) (saipb.CounterClient, func()) {
+	conn, _, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
+		newCounter(mgr, api, srv)
+	})
+	return saipb.NewCounterClient(conn), stopFn
+}
@@ -20,10 +20,12 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/openconfig/lemming/dataplane/dplaneopts"
 	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
@@ -40,6 +42,8 @@ func newHostif(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Serve
 		dataplane:        dataplane,
 		trapIDToHostifID: map[uint64]uint64{},
 		groupIDToQueue:   map[uint64]uint32{},
+		groupIDToPolicer: map[uint64]uint64{},
+		groupIDToTraps:   map[uint64][]*fwdpb.TableEntryAddRequest{},
 		remoteHostifs:    map[uint64]*pktiopb.HostPortControlMessage{},
 		opts:             opts,
 	}
@@ -54,11 +58,15 @@ type hostif struct {
 	dataplane        switchDataplaneAPI
 	trapIDToHostifID map[uint64]uint64
 	groupIDToQueue   map[uint64]uint32
+	groupIDToPolicer map[uint64]uint64
+	groupIDToTraps   map[uint64][]*fwdpb.TableEntryAddRequest // Trap table entries of the traps in the group, without the policer.
 	opts             *dplaneopts.Options
 	remoteMu         sync.Mutex
 	remoteHostifs    map[uint64]*pktiopb.HostPortControlMessage
 	remoteClosers    []func()
 	remotePortReq    func(msg *pktiopb.HostPortControlMessage) error
+	p4rtTrapID       atomic.Uint64 // The OID of the P4RT trap, also used as the host port of P4RT packets.
+	sampleTrapID     atomic.Uint64 // The OID of the samplepacket trap, also used as the host port of sampled packets.
 }
 
 func (hostif *hostif) Reset() {
@@ -69,8 +77,12 @@ func (hostif *hostif) Reset() {
 	hostif.remoteClosers = nil
 	hostif.trapIDToHostifID = map[uint64]uint64{}
 	hostif.groupIDToQueue = map[uint64]uint32{}
+	hostif.groupIDToPolicer = map[uint64]uint64{}
+	hostif.groupIDToTraps = map[uint64][]*fwdpb.TableEntryAddRequest{}
 	hostif.remoteHostifs = map[uint64]*pktiopb.HostPortControlMessage{}
 	hostif.remotePortReq = nil
+	hostif.p4rtTrapID.Store(0)
+	hostif.sampleTrapID.Store(0)
 }
 
 const (
@@ -238,8 +250,19 @@ func (hostif *hostif) SetHostifAttribute(ctx context.Context, req *saipb.SetHost
 }
 
 // SetHostifTrapGroupAttribute sets the trap group attribute.
-func (*hostif) SetHostifTrapGroupAttribute(context.Context, *saipb.SetHostifTrapGroupAttributeRequest) (*saipb.SetHostifTrapGroupAttributeResponse, error) {
-	// TODO: provide implementation.
+// Changing the policer of the group updates the trap entries of the traps in the group.
+func (hostif *hostif) SetHostifTrapGroupAttribute(ctx context.Context, req *saipb.SetHostifTrapGroupAttributeRequest) (*saipb.SetHostifTrapGroupAttributeResponse, error) {
+	if req.Queue != nil {
+		hostif.groupIDToQueue[req.GetOid()] = req.GetQueue()
+	}
+	if req.Policer != nil {
+		hostif.groupIDToPolicer[req.GetOid()] = req.GetPolicer()
+		for _, entries := range hostif.groupIDToTraps[req.GetOid()] {
+			if err := hostif.addTrapEntries(ctx, entries, req.GetPolicer()); err != nil {
+				return nil, err
+			}
+		}
+	}
 	return &saipb.SetHostifTrapGroupAttributeResponse{}, nil
 }
 
@@ -250,6 +273,10 @@ var (
 	ndDstMAC      = []byte{0x33, 0x33, 0x00, 0x00, 0x00, 0x00} // ND is generic IPv6 multicast MAC.
 	ndDstMACMask  = []byte{0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00}
 	lacpDstMAC    = []byte{0x01, 0x80, 0xC2, 0x00, 0x00, 0x02}
+	// isisL1L2MAC matches AllL1ISs and AllL2ISs, isisISMAC matches AllESs and AllISs.
+	isisL1L2MAC = []byte{0x01, 0x80, 0xC2, 0x00, 0x00, 0x14}
+	isisISMAC   = []byte{0x09, 0x00, 0x2B, 0x00, 0x00, 0x04}
+	isisMACMask = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFE}
 )
 
 const (
@@ -292,6 +319,14 @@ func (hostif *hostif) CreateHostifTrap(ctx context.Context, req *saipb.CreateHos
 		fwdReq.AppendEntry(fwdconfig.EntryDesc(fwdconfig.FlowEntry(
 			fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_MAC_DST).
 				WithBytes(lacpDstMAC, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}))))
+	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_ISIS:
+		fwdReq.AppendEntry(fwdconfig.EntryDesc(fwdconfig.FlowEntry(
+			fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_MAC_DST).
+				WithBytes(isisL1L2MAC, isisMACMask))))
+		fwdReq.AppendEntry(fwdconfig.EntryDesc(fwdconfig.FlowEntry(
+			fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_MAC_DST).
+				WithBytes(isisISMAC, isisMACMask))))
+		entriesAdded = 2
 	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_IP2ME,
 		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_GNMI,
 		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_SSH,
@@ -299,12 +334,32 @@ func (hostif *hostif) CreateHostifTrap(ctx context.Context, req *saipb.CreateHos
 		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_NTPCLIENT,
 		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_NTPSERVER,
 		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_HTTPCLIENT,
-		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_HTTPSERVER,
-		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_P4RT:
+		saipb.HostifTrapType_HOSTIF_TRAP_TYPE_HTTPSERVER:
 		// IP2ME routes are added to the FIB, do nothing here.
 		return &saipb.CreateHostifTrapResponse{
 			Oid: id,
 		}, nil
+	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_P4RT:
+		if err := hostif.createP4RTTrap(ctx, id); err != nil {
+			return nil, err
+		}
+		return &saipb.CreateHostifTrapResponse{
+			Oid: id,
+		}, nil
+	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_SAMPLEPACKET:
+		if err := hostif.createSamplepacketTrap(ctx, id); err != nil {
+			return nil, err
+		}
+		return &saipb.CreateHostifTrapResponse{
+			Oid: id,
+		}, nil
+	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_TTL_ERROR:
+		if err := hostif.createTTLErrorTrap(ctx, id); err != nil {
+			return nil, err
+		}
+		return &saipb.CreateHostifTrapResponse{
+			Oid: id,
+		}, nil
 	case saipb.HostifTrapType_HOSTIF_TRAP_TYPE_BGP, saipb.HostifTrapType_HOSTIF_TRAP_TYPE_BGPV6:
 		// TODO: This should only match for packets destined to the management IP.
 		fwdReq.AppendEntry(fwdconfig.EntryDesc(fwdconfig.FlowEntry(
@@ -326,26 +381,117 @@ func (hostif *hostif) CreateHostifTrap(ctx context.Context, req *saipb.CreateHos
 		addReq.Entries[i].Actions = append(addReq.Entries[i].Actions, act)
 	}
 
-	if _, err := hostif.dataplane.TableEntryAdd(ctx, addReq); err != nil {
+	if err := hostif.addTrapEntries(ctx, addReq, hostif.groupIDToPolicer[req.GetTrapGroup()]); err != nil {
 		return nil, err
 	}
+	hostif.groupIDToTraps[req.GetTrapGroup()] = append(hostif.groupIDToTraps[req.GetTrapGroup()], addReq)
 	// TODO: Support multiple queues, by using the group ID.
 	return &saipb.CreateHostifTrapResponse{
 		Oid: id,
 	}, nil
 }
 
+// addTrapEntries adds the trap table entries of a trap. If the trap group of the trap has a policer,
+// the trapped packets are metered by the policer, which limits the rate of the packets sent to the CPU.
+func (hostif *hostif) addTrapEntries(ctx context.Context, entries *fwdpb.TableEntryAddRequest, policer uint64) error {
+	req := proto.Clone(entries).(*fwdpb.TableEntryAddRequest)
+	if policer != 0 {
+		for _, entry := range req.Entries {
+			entry.Actions = append(entry.Actions,
+				fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_POLICER_ID).
+					WithUint64Value(policer)).Build(),
+				fwdconfig.Action(fwdconfig.LookupAction(policerTabler)).Build(),
+			)
+		}
+	}
+	_, err := hostif.dataplane.TableEntryAdd(ctx, req)
+	return err
+}
+
+// createP4RTTrap sets up the packet path for P4RT packet I/O.
+// Packets punted with the P4RT trap ID are sent over the CPU packet stream using the trap ID as the host port,
+// packets received with the trap ID as the host port are submitted to the ingress pipeline.
+func (hostif *hostif) createP4RTTrap(ctx context.Context, id uint64) error {
+	if hostif.p4rtTrapID.Load() != 0 {
+		return status.Errorf(codes.AlreadyExists, "P4RT trap already exists: %v", hostif.p4rtTrapID.Load())
+	}
+	if err := hostif.addTrapHostPort(ctx, id); err != nil {
+		return err
+	}
+	hostif.p4rtTrapID.Store(id)
+	return nil
+}
+
+// createSamplepacketTrap sets up the packet path for the packets sampled by the samplepackets bound to ports.
+// Like the P4RT trap, the sampled packets are sent over the CPU packet stream using the trap ID as the host port.
+func (hostif *hostif) createSamplepacketTrap(ctx context.Context, id uint64) error {
+	if hostif.sampleTrapID.Load() != 0 {
+		return status.Errorf(codes.AlreadyExists, "samplepacket trap already exists: %v", hostif.sampleTrapID.Load())
+	}
+	if err := hostif.addTrapHostPort(ctx, id); err != nil {
+		return err
+	}
+	hostif.sampleTrapID.Store(id)
+	return nil
+}
+
+// createTTLErrorTrap punts routed packets that would expire when their TTL (or hop limit) is decremented,
+// instead of dropping them, so that the CPU can reply with ICMP time exceeded messages.
+// Like the P4RT trap, the trap ID is used as the host port of the punted packets, and packets received
+// with the trap ID as the host port (e.g. the ICMP replies) are submitted to the ingress pipeline.
+func (hostif *hostif) createTTLErrorTrap(ctx context.Context, id uint64) error {
+	for _, table := range []string{invalidIngressV4Table, invalidIngressV6Table} {
+		req := fwdconfig.TableEntryAddRequest(hostif.dataplane.ID(), table).
+			AppendEntry(
+				fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP).WithBytes([]byte{0x01}, []byte{0xFF})).WithPriority(ttlErrorTrapPriority)),
+				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64Value(id),
+			).Build()
+		req.Entries[0].Actions = append(req.Entries[0].Actions, computePacketAction(saipb.PacketAction_PACKET_ACTION_TRAP))
+		if _, err := hostif.dataplane.TableEntryAdd(ctx, req); err != nil {
+			return err
+		}
+	}
+	return hostif.addTrapHostPort(ctx, id)
+}
+
+// addTrapHostPort maps the trap ID to a host port with the same ID, and submits the packets received
+// from the host port to the ingress pipeline.
+func (hostif *hostif) addTrapHostPort(ctx context.Context, id uint64) error {
+	trapReq := fwdconfig.TableEntryAddRequest(hostif.dataplane.ID(), trapIDToHostifTable).
+		AppendEntry(
+			fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64(id))),
+			fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_HOST_PORT_ID).WithUint64Value(id)).
+		Build()
+	if _, err := hostif.dataplane.TableEntryAdd(ctx, trapReq); err != nil {
+		return err
+	}
+	portReq := fwdconfig.TableEntryAddRequest(hostif.dataplane.ID(), hostifToPortTable).
+		AppendEntry(fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_HOST_PORT_ID).WithUint64(id)))).
+		Build()
+	portReq.Entries[0].Actions = getPreIngressPipeline()
+	_, err := hostif.dataplane.TableEntryAdd(ctx, portReq)
+	return err
+}
+
 func (hostif *hostif) CreateHostifTrapGroup(_ context.Context, req *saipb.CreateHostifTrapGroupRequest) (*saipb.CreateHostifTrapGroupResponse, error) {
 	id := hostif.mgr.NextID()
 	hostif.groupIDToQueue[id] = req.GetQueue()
+	hostif.groupIDToPolicer[id] = req.GetPolicer()
 	return &saipb.CreateHostifTrapGroupResponse{Oid: id}, nil
 }
 
-func (hostif *hostif) CreateHostifUserDefinedTrap(_ context.Context, req *saipb.CreateHostifUserDefinedTrapRequest) (*saipb.CreateHostifUserDefinedTrapResponse, error) {
+// CreateHostifUserDefinedTrap creates a trap for the packets punted by ACL entries.
+// Until a hostif table entry maps the trap to a host interface, the punted packets are sent
+// over the CPU packet stream with the trap ID as the host port, like the P4RT trap.
+func (hostif *hostif) CreateHostifUserDefinedTrap(ctx context.Context, req *saipb.CreateHostifUserDefinedTrapRequest) (*saipb.CreateHostifUserDefinedTrapResponse, error) {
 	if req.GetType() != saipb.HostifUserDefinedTrapType_HOSTIF_USER_DEFINED_TRAP_TYPE_ACL {
 		return nil, status.Errorf(codes.InvalidArgument, "unsupported trap type: %v", req.GetType())
 	}
-	return &saipb.CreateHostifUserDefinedTrapResponse{Oid: hostif.mgr.NextID()}, nil
+	id := hostif.mgr.NextID()
+	if err := hostif.addTrapHostPort(ctx, id); err != nil {
+		return nil, err
+	}
+	return &saipb.CreateHostifUserDefinedTrapResponse{Oid: id}, nil
 }
 
 const (
@@ -465,6 +611,16 @@ func (hostif *hostif) CPUPacketStream(srv pktiopb.PacketIO_CPUPacketStreamServer
 			}
 			slog.Debug("received packet", "packet", pkt.GetPacket().GetFrame())
 
+			// P4RT packets with an egress port bypass the pipeline and are sent directly out the port.
+			if trapID := hostif.p4rtTrapID.Load(); trapID != 0 && pkt.GetPacket().GetHostPort() == trapID && pkt.GetPacket().GetOutputPort() != 0 {
+				err = hostif.dataplane.InjectPacket(&fwdpb.ContextId{Id: hostif.dataplane.ID()}, &fwdpb.PortId{ObjectId: &fwdpb.ObjectId{Id: fmt.Sprint(pkt.GetPacket().GetOutputPort())}}, fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
+					pkt.GetPacket().GetFrame(), nil, true, fwdpb.PortAction_PORT_ACTION_OUTPUT)
+				if err != nil {
+					slog.WarnContext(ctx, "inject err", "err", err)
+				}
+				continue
+			}
+
 			acts := []*fwdpb.ActionDesc{fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_HOST_PORT_ID).
 				WithUint64Value(pkt.GetPacket().GetHostPort())).Build()}
 			err = hostif.dataplane.InjectPacket(&fwdpb.ContextId{Id: hostif.dataplane.ID()}, &fwdpb.PortId{ObjectId: &fwdpb.ObjectId{Id: cpuPortID}}, fwdpb.PacketHeaderId_PACKET_HEADER_ID_ETHERNET,
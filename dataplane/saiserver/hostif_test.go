@@ -33,6 +33,7 @@ import (
 	"google.golang.org/protobuf/testing/protocmp"
 
 	"github.com/openconfig/lemming/dataplane/dplaneopts"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
 	"github.com/openconfig/lemming/dataplane/forwarding/fwdport"
 	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdcontext"
 	"github.com/openconfig/lemming/dataplane/forwarding/infra/fwdpacket"
@@ -289,10 +290,11 @@ func TestCPUPacketStream(t *testing.T) {
 
 func TestCreateHostifTrap(t *testing.T) {
 	tests := []struct {
-		desc    string
-		req     *saipb.CreateHostifTrapRequest
-		want    *saipb.CreateHostifTrapResponse
-		wantErr string
+		desc       string
+		req        *saipb.CreateHostifTrapRequest
+		want       *saipb.CreateHostifTrapResponse
+		wantTables []string
+		wantErr    string
 	}{{
 		desc: "p4rt trap",
 		req: &saipb.CreateHostifTrapRequest{
@@ -303,6 +305,29 @@ func TestCreateHostifTrap(t *testing.T) {
 		want: &saipb.CreateHostifTrapResponse{
 			Oid: 1,
 		},
+		wantTables: []string{trapIDToHostifTable, hostifToPortTable},
+	}, {
+		desc: "ttl error trap",
+		req: &saipb.CreateHostifTrapRequest{
+			Switch:       1,
+			TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_TTL_ERROR.Enum(),
+			PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+		},
+		want: &saipb.CreateHostifTrapResponse{
+			Oid: 1,
+		},
+		wantTables: []string{invalidIngressV4Table, invalidIngressV6Table, trapIDToHostifTable, hostifToPortTable},
+	}, {
+		desc: "samplepacket trap",
+		req: &saipb.CreateHostifTrapRequest{
+			Switch:       1,
+			TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_SAMPLEPACKET.Enum(),
+			PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+		},
+		want: &saipb.CreateHostifTrapResponse{
+			Oid: 1,
+		},
+		wantTables: []string{trapIDToHostifTable, hostifToPortTable},
 	}, {
 		desc: "arp trap",
 		req: &saipb.CreateHostifTrapRequest{
@@ -313,6 +338,7 @@ func TestCreateHostifTrap(t *testing.T) {
 		want: &saipb.CreateHostifTrapResponse{
 			Oid: 1,
 		},
+		wantTables: []string{trapTableID},
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -337,10 +363,67 @@ func TestCreateHostifTrap(t *testing.T) {
 			if d := cmp.Diff(got, tt.want, protocmp.Transform()); d != "" {
 				t.Errorf("CreateHostifTrap() failed: diff(-got,+want)\n:%s", d)
 			}
+			var gotTables []string
+			for _, req := range dplane.gotEntryAddReqs {
+				gotTables = append(gotTables, req.GetTableId().GetObjectId().GetId())
+			}
+			if d := cmp.Diff(gotTables, tt.wantTables); d != "" {
+				t.Errorf("CreateHostifTrap() failed: diff(-got,+want)\n:%s", d)
+			}
 		})
 	}
 }
 
+func TestHostifTrapGroupPolicer(t *testing.T) {
+	dplane := &fakeSwitchDataplane{
+		ctx: fwdcontext.New("foo", "foo"),
+	}
+	c, mgr, stopFn := newTestHostif(t, dplane)
+	defer stopFn()
+	mgr.StoreAttributes(1, &saipb.SwitchAttribute{
+		CpuPort: proto.Uint64(10),
+	})
+	ctx := context.Background()
+
+	group, err := c.CreateHostifTrapGroup(ctx, &saipb.CreateHostifTrapGroupRequest{
+		Policer: proto.Uint64(5),
+	})
+	if err != nil {
+		t.Fatalf("CreateHostifTrapGroup() unexpected err: %v", err)
+	}
+	if _, err := c.CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       1,
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_LLDP.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+		TrapGroup:    proto.Uint64(group.GetOid()),
+	}); err != nil {
+		t.Fatalf("CreateHostifTrap() unexpected err: %v", err)
+	}
+	if _, err := c.SetHostifTrapGroupAttribute(ctx, &saipb.SetHostifTrapGroupAttributeRequest{
+		Oid:     group.GetOid(),
+		Policer: proto.Uint64(0),
+	}); err != nil {
+		t.Fatalf("SetHostifTrapGroupAttribute() unexpected err: %v", err)
+	}
+
+	entry := fwdconfig.TableEntryAddRequest("foo", trapTableID).AppendEntry(fwdconfig.EntryDesc(fwdconfig.FlowEntry(
+		fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE).
+			WithBytes(etherTypeLLDP, []byte{0xFF, 0xFF}))))
+	policed := entry.Build()
+	policed.Entries[0].Actions = []*fwdpb.ActionDesc{
+		computePacketAction(saipb.PacketAction_PACKET_ACTION_TRAP),
+		fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_POLICER_ID).WithUint64Value(5)).Build(),
+		fwdconfig.Action(fwdconfig.LookupAction(policerTabler)).Build(),
+	}
+	unpoliced := entry.Build()
+	unpoliced.Entries[0].Actions = []*fwdpb.ActionDesc{computePacketAction(saipb.PacketAction_PACKET_ACTION_TRAP)}
+
+	want := []*fwdpb.TableEntryAddRequest{policed, unpoliced}
+	if d := cmp.Diff(dplane.gotEntryAddReqs, want, protocmp.Transform()); d != "" {
+		t.Errorf("trap group policer failed: diff(-got,+want)\n:%s", d)
+	}
+}
+
 func createPacket(t testing.TB, nid uint64) fwdpacket.Packet {
 	t.Helper()
 	eth := &layers.Ethernet{
@@ -401,3 +484,35 @@ func newTestHostif(t testing.TB, api switchDataplaneAPI) (*hostifClient, *attrmg
 		PacketIOClient: pktiopb.NewPacketIOClient(conn),
 	}, mgr, stopFn
 }
+
+func TestCreateHostifUserDefinedTrap(t *testing.T) {
+	dplane := &fakeSwitchDataplane{
+		ctx: fwdcontext.New("foo", "foo"),
+	}
+	c, _, stopFn := newTestHostif(t, dplane)
+	defer stopFn()
+
+	if _, err := c.CreateHostifUserDefinedTrap(context.TODO(), &saipb.CreateHostifUserDefinedTrapRequest{
+		Switch: 1,
+		Type:   saipb.HostifUserDefinedTrapType_HOSTIF_USER_DEFINED_TRAP_TYPE_NEIGHBOR.Enum(),
+	}); errdiff.Check(err, "InvalidArgument") != "" {
+		t.Fatalf("CreateHostifUserDefinedTrap() got err %v, want InvalidArgument", err)
+	}
+	got, err := c.CreateHostifUserDefinedTrap(context.TODO(), &saipb.CreateHostifUserDefinedTrapRequest{
+		Switch: 1,
+		Type:   saipb.HostifUserDefinedTrapType_HOSTIF_USER_DEFINED_TRAP_TYPE_ACL.Enum(),
+	})
+	if err != nil {
+		t.Fatalf("CreateHostifUserDefinedTrap() unexpected err: %v", err)
+	}
+	if got.GetOid() == 0 {
+		t.Errorf("CreateHostifUserDefinedTrap() got no oid")
+	}
+	var gotTables []string
+	for _, req := range dplane.gotEntryAddReqs {
+		gotTables = append(gotTables, req.GetTableId().GetObjectId().GetId())
+	}
+	if d := cmp.Diff(gotTables, []string{trapIDToHostifTable, hostifToPortTable}); d != "" {
+		t.Errorf("CreateHostifUserDefinedTrap() failed: diff(-got,+want)\n:%s", d)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/openconfig/gnmi/errlist"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// defaultOutsegTTL is the TTL of pushed labels if none is specified.
+const defaultOutsegTTL = 255
+
+type mpls struct {
+	saipb.UnimplementedMplsServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+}
+
+func newMPLS(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server) *mpls {
+	m := &mpls{
+		mgr:       mgr,
+		dataplane: dataplane,
+	}
+	saipb.RegisterMplsServer(s, m)
+	return m
+}
+
+func insegEntryDesc(entry *saipb.InsegEntry) *fwdconfig.EntryDescBuilder {
+	return fwdconfig.EntryDesc(fwdconfig.ExactEntry(
+		fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL).WithBytes(binary.BigEndian.AppendUint32(nil, entry.GetLabel())),
+	))
+}
+
+// CreateInsegEntry creates an entry in the MPLS FIB, which pops labels and forwards the packet to the next hop.
+func (m *mpls) CreateInsegEntry(ctx context.Context, req *saipb.CreateInsegEntryRequest) (*saipb.CreateInsegEntryResponse, error) {
+	forward := true
+	switch req.GetPacketAction() {
+	case saipb.PacketAction_PACKET_ACTION_DROP, saipb.PacketAction_PACKET_ACTION_TRAP, saipb.PacketAction_PACKET_ACTION_DENY:
+		forward = false
+	}
+
+	actions := []fwdconfig.ActionDescBuilder{}
+	if req.CounterId != nil {
+		actions = append(actions, fwdconfig.FlowCounterAction(fmt.Sprint(req.GetCounterId())))
+	}
+	if forward {
+		actions = append(actions,
+			fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{1}),
+			fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_DEC, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TTL).WithValue([]byte{0x1}), // Decrement TTL.
+		)
+		for i := uint32(0); i < req.GetNumOfPop(); i++ {
+			actions = append(actions, fwdconfig.DecapAction(fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS))
+		}
+		switch nextType := m.mgr.GetType(fmt.Sprint(req.GetNextHopId())); nextType {
+		case saipb.ObjectType_OBJECT_TYPE_NEXT_HOP:
+			actions = append(actions,
+				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_NEXT_HOP_ID).WithUint64Value(req.GetNextHopId()),
+				fwdconfig.LookupAction(NHTable),
+			)
+		case saipb.ObjectType_OBJECT_TYPE_NEXT_HOP_GROUP:
+			actions = append(actions,
+				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_NEXT_HOP_GROUP_ID).WithUint64Value(req.GetNextHopId()),
+				fwdconfig.LookupAction(NHGTable),
+			)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unknown next hop type: %v", nextType)
+		}
+	} else {
+		actions = append(actions, fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{0}))
+	}
+
+	entry := fwdconfig.TableEntryAddRequest(m.dataplane.ID(), MPLSFIBTable).AppendEntry(insegEntryDesc(req.GetEntry()), actions...).Build()
+	if _, err := m.dataplane.TableEntryAdd(ctx, entry); err != nil {
+		return nil, err
+	}
+	return &saipb.CreateInsegEntryResponse{}, nil
+}
+
+// RemoveInsegEntry removes an entry from the MPLS FIB.
+func (m *mpls) RemoveInsegEntry(ctx context.Context, req *saipb.RemoveInsegEntryRequest) (*saipb.RemoveInsegEntryResponse, error) {
+	delReq := fwdconfig.TableEntryRemoveRequest(m.dataplane.ID(), MPLSFIBTable).AppendEntry(insegEntryDesc(req.GetEntry())).Build()
+	if _, err := m.dataplane.TableEntryRemove(ctx, delReq); err != nil {
+		return nil, err
+	}
+	return &saipb.RemoveInsegEntryResponse{}, nil
+}
+
+func (m *mpls) CreateInsegEntries(ctx context.Context, re *saipb.CreateInsegEntriesRequest) (*saipb.CreateInsegEntriesResponse, error) {
+	var errs errlist.List
+	resp := &saipb.CreateInsegEntriesResponse{}
+	for _, req := range re.GetReqs() {
+		res, err := attrmgr.InvokeAndSave(ctx, m.mgr, m.CreateInsegEntry, req)
+		errs.Add(err)
+		resp.Resps = append(resp.Resps, res)
+	}
+	return resp, errs.Err()
+}
+
+func (m *mpls) RemoveInsegEntries(ctx context.Context, re *saipb.RemoveInsegEntriesRequest) (*saipb.RemoveInsegEntriesResponse, error) {
+	resp := &saipb.RemoveInsegEntriesResponse{}
+	for _, req := range re.GetReqs() {
+		res, err := attrmgr.InvokeAndSave(ctx, m.mgr, m.RemoveInsegEntry, req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Resps = append(resp.Resps, res)
+	}
+	return resp, nil
+}
+
+// outsegActions returns the actions that apply the label stack of an MPLS next hop.
+// The label stack is ordered from the outermost label. If the outseg type is SWAP,
+// the innermost label replaces the top label of the packet, otherwise all labels are pushed.
+// Only the pipe TTL model is supported, so pushed labels get the outseg TTL value.
+func outsegActions(req *saipb.CreateNextHopRequest) []*fwdpb.ActionDesc {
+	ttl := uint32(defaultOutsegTTL)
+	if req.OutsegTtlValue != nil {
+		ttl = req.GetOutsegTtlValue()
+	}
+	labels := req.GetLabelstack()
+	var actions []*fwdpb.ActionDesc
+	if req.GetOutsegType() == saipb.OutsegType_OUTSEG_TYPE_SWAP && len(labels) > 0 {
+		actions = append(actions, fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL).
+			WithValue(binary.BigEndian.AppendUint32(nil, labels[len(labels)-1]))).Build())
+		labels = labels[:len(labels)-1]
+	}
+	for i := len(labels) - 1; i >= 0; i-- {
+		actions = append(actions,
+			fwdconfig.Action(fwdconfig.EncapAction(fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS)).Build(),
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL).
+				WithValue(binary.BigEndian.AppendUint32(nil, labels[i]))).Build(),
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TTL).
+				WithValue([]byte{byte(ttl)})).Build(),
+		)
+	}
+	return actions
+}
@@ -0,0 +1,292 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+var insegEntry100 = &fwdpb.EntryDesc{
+	Entry: &fwdpb.EntryDesc_Exact{
+		Exact: &fwdpb.ExactEntryDesc{
+			Fields: []*fwdpb.PacketFieldBytes{{
+				FieldId: &fwdpb.PacketFieldId{
+					Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL},
+				},
+				Bytes: []byte{0x00, 0x00, 0x00, 0x64},
+			}},
+		},
+	},
+}
+
+func TestCreateInsegEntry(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     *saipb.CreateInsegEntryRequest
+		types   map[string]saipb.ObjectType
+		wantReq *fwdpb.TableEntryAddRequest
+		wantErr string
+	}{{
+		desc: "unknown next hop type",
+		req: &saipb.CreateInsegEntryRequest{
+			Entry:     &saipb.InsegEntry{Label: 100},
+			NextHopId: proto.Uint64(2),
+		},
+		wantErr: "InvalidArgument",
+	}, {
+		desc: "drop",
+		req: &saipb.CreateInsegEntryRequest{
+			Entry:        &saipb.InsegEntry{Label: 100},
+			PacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+		},
+		wantReq: &fwdpb.TableEntryAddRequest{
+			ContextId: &fwdpb.ContextId{Id: "foo"},
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+			Entries: []*fwdpb.TableEntryAddRequest_Entry{{
+				EntryDesc: insegEntry100,
+				Actions: []*fwdpb.ActionDesc{{
+					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+					Action: &fwdpb.ActionDesc_Update{
+						Update: &fwdpb.UpdateActionDesc{
+							Type:     fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE,
+							FieldId:  &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION}},
+							Field:    &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+							BitCount: 1,
+							Value:    []byte{0x00},
+						},
+					},
+				}},
+			}},
+		},
+	}, {
+		desc: "drop with counter",
+		req: &saipb.CreateInsegEntryRequest{
+			Entry:        &saipb.InsegEntry{Label: 100},
+			PacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+			CounterId:    proto.Uint64(3),
+		},
+		wantReq: &fwdpb.TableEntryAddRequest{
+			ContextId: &fwdpb.ContextId{Id: "foo"},
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+			Entries: []*fwdpb.TableEntryAddRequest_Entry{{
+				EntryDesc: insegEntry100,
+				Actions: []*fwdpb.ActionDesc{{
+					ActionType: fwdpb.ActionType_ACTION_TYPE_FLOW_COUNTER,
+					Action: &fwdpb.ActionDesc_Flow{
+						Flow: &fwdpb.FlowCounterActionDesc{
+							CounterId: &fwdpb.FlowCounterId{ObjectId: &fwdpb.ObjectId{Id: "3"}},
+						},
+					},
+				}, {
+					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+					Action: &fwdpb.ActionDesc_Update{
+						Update: &fwdpb.UpdateActionDesc{
+							Type:     fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE,
+							FieldId:  &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION}},
+							Field:    &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+							BitCount: 1,
+							Value:    []byte{0x00},
+						},
+					},
+				}},
+			}},
+		},
+	}, {
+		desc: "pop to next hop",
+		req: &saipb.CreateInsegEntryRequest{
+			Entry:     &saipb.InsegEntry{Label: 100},
+			NumOfPop:  proto.Uint32(1),
+			NextHopId: proto.Uint64(2),
+		},
+		types: map[string]saipb.ObjectType{
+			"2": saipb.ObjectType_OBJECT_TYPE_NEXT_HOP,
+		},
+		wantReq: &fwdpb.TableEntryAddRequest{
+			ContextId: &fwdpb.ContextId{Id: "foo"},
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+			Entries: []*fwdpb.TableEntryAddRequest_Entry{{
+				EntryDesc: insegEntry100,
+				Actions: []*fwdpb.ActionDesc{{
+					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+					Action: &fwdpb.ActionDesc_Update{
+						Update: &fwdpb.UpdateActionDesc{
+							Type:     fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE,
+							FieldId:  &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION}},
+							Field:    &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+							BitCount: 1,
+							Value:    []byte{0x01},
+						},
+					},
+				}, {
+					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+					Action: &fwdpb.ActionDesc_Update{
+						Update: &fwdpb.UpdateActionDesc{
+							Type:    fwdpb.UpdateType_UPDATE_TYPE_DEC,
+							FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TTL}},
+							Field:   &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+							Value:   []byte{0x01},
+						},
+					},
+				}, {
+					ActionType: fwdpb.ActionType_ACTION_TYPE_DECAP,
+					Action: &fwdpb.ActionDesc_Decap{
+						Decap: &fwdpb.DecapActionDesc{HeaderId: fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS},
+					},
+				}, {
+					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+					Action: &fwdpb.ActionDesc_Update{
+						Update: &fwdpb.UpdateActionDesc{
+							Type:    fwdpb.UpdateType_UPDATE_TYPE_SET,
+							FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_NEXT_HOP_ID}},
+							Field:   &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+							Value:   []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02},
+						},
+					},
+				}, {
+					ActionType: fwdpb.ActionType_ACTION_TYPE_LOOKUP,
+					Action: &fwdpb.ActionDesc_Lookup{
+						Lookup: &fwdpb.LookupActionDesc{
+							TableId: &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: NHTable}},
+						},
+					},
+				}},
+			}},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dplane := &fakeSwitchDataplane{}
+			c, mgr, stopFn := newTestMPLS(t, dplane)
+			defer stopFn()
+			for k, v := range tt.types {
+				mgr.SetType(k, v)
+			}
+			_, gotErr := c.CreateInsegEntry(context.TODO(), tt.req)
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("CreateInsegEntry() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+			if d := cmp.Diff(dplane.gotEntryAddReqs[0], tt.wantReq, protocmp.Transform()); d != "" {
+				t.Errorf("CreateInsegEntry() failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
+
+func TestRemoveInsegEntry(t *testing.T) {
+	dplane := &fakeSwitchDataplane{}
+	c, _, stopFn := newTestMPLS(t, dplane)
+	defer stopFn()
+	if _, err := c.CreateInsegEntry(context.TODO(), &saipb.CreateInsegEntryRequest{
+		Entry:        &saipb.InsegEntry{Label: 100},
+		PacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+	}); err != nil {
+		t.Fatalf("CreateInsegEntry() unexpected err: %v", err)
+	}
+	if _, err := c.RemoveInsegEntry(context.TODO(), &saipb.RemoveInsegEntryRequest{Entry: &saipb.InsegEntry{Label: 100}}); err != nil {
+		t.Fatalf("RemoveInsegEntry() unexpected err: %v", err)
+	}
+	want := &fwdpb.TableEntryRemoveRequest{
+		ContextId: &fwdpb.ContextId{Id: "foo"},
+		TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+		Entries:   []*fwdpb.EntryDesc{insegEntry100},
+	}
+	if d := cmp.Diff(dplane.gotEntryRemoveReqs[0], want, protocmp.Transform()); d != "" {
+		t.Errorf("RemoveInsegEntry() failed: diff(-got,+want)\n:%s", d)
+	}
+}
+
+func TestOutsegActions(t *testing.T) {
+	setLabel := func(label byte) *fwdpb.ActionDesc {
+		return &fwdpb.ActionDesc{
+			ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+			Action: &fwdpb.ActionDesc_Update{
+				Update: &fwdpb.UpdateActionDesc{
+					Type:    fwdpb.UpdateType_UPDATE_TYPE_SET,
+					FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL}},
+					Field:   &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+					Value:   []byte{0x00, 0x00, 0x00, label},
+				},
+			},
+		}
+	}
+	setTTL := func(ttl byte) *fwdpb.ActionDesc {
+		return &fwdpb.ActionDesc{
+			ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
+			Action: &fwdpb.ActionDesc_Update{
+				Update: &fwdpb.UpdateActionDesc{
+					Type:    fwdpb.UpdateType_UPDATE_TYPE_SET,
+					FieldId: &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TTL}},
+					Field:   &fwdpb.PacketFieldId{Field: &fwdpb.PacketField{}},
+					Value:   []byte{ttl},
+				},
+			},
+		}
+	}
+	encap := &fwdpb.ActionDesc{
+		ActionType: fwdpb.ActionType_ACTION_TYPE_ENCAP,
+		Action: &fwdpb.ActionDesc_Encap{
+			Encap: &fwdpb.EncapActionDesc{HeaderId: fwdpb.PacketHeaderId_PACKET_HEADER_ID_MPLS},
+		},
+	}
+	tests := []struct {
+		desc string
+		req  *saipb.CreateNextHopRequest
+		want []*fwdpb.ActionDesc
+	}{{
+		desc: "push",
+		req: &saipb.CreateNextHopRequest{
+			Labelstack: []uint32{10, 20},
+			OutsegType: saipb.OutsegType_OUTSEG_TYPE_PUSH.Enum(),
+		},
+		want: []*fwdpb.ActionDesc{encap, setLabel(20), setTTL(255), encap, setLabel(10), setTTL(255)},
+	}, {
+		desc: "swap",
+		req: &saipb.CreateNextHopRequest{
+			Labelstack:     []uint32{10, 20},
+			OutsegType:     saipb.OutsegType_OUTSEG_TYPE_SWAP.Enum(),
+			OutsegTtlValue: proto.Uint32(64),
+		},
+		want: []*fwdpb.ActionDesc{setLabel(20), encap, setLabel(10), setTTL(64)},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := outsegActions(tt.req)
+			if d := cmp.Diff(got, tt.want, protocmp.Transform()); d != "" {
+				t.Errorf("outsegActions() failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
+
+func newTestMPLS(t testing.TB, api switchDataplaneAPI) (saipb.MplsClient, *attrmgr.AttrMgr, func()) {
+	conn, mgr, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
+		newMPLS(mgr, api, srv)
+	})
+	return saipb.NewMplsClient(conn), mgr, stopFn
+}
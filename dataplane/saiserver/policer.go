@@ -16,8 +16,11 @@ package saiserver
 
 import (
 	"context"
+	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
 	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
@@ -41,7 +44,20 @@ func newPolicer(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Serv
 	return p
 }
 
-// CreatePolicer creates a new policer, QOS is not actually supported. the GREEN action is always taken.
+// The colors of the packets metered by a policer, each color has its own flow counter.
+const (
+	policerGreen  = "green"
+	policerYellow = "yellow"
+	policerRed    = "red"
+)
+
+// policerCounterID returns the ID of the flow counter of the packets of a color metered by a policer.
+func policerCounterID(oid uint64, color string) string {
+	return fmt.Sprintf("%d-%s-counter", oid, color)
+}
+
+// CreatePolicer creates a new policer.
+// Policers without a mode do not meter packets, and all packets take the GREEN action.
 func (p *policer) CreatePolicer(ctx context.Context, req *saipb.CreatePolicerRequest) (*saipb.CreatePolicerResponse, error) {
 	id := p.mgr.NextID()
 
@@ -51,14 +67,28 @@ func (p *policer) CreatePolicer(ctx context.Context, req *saipb.CreatePolicerReq
 		return nil, err
 	}
 
-	action := computePacketAction(req.GetGreenPacketAction())
-
-	tReq := fwdconfig.TableEntryAddRequest(p.dataplane.ID(), policerTabler).
-		AppendEntry(fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_POLICER_ID).WithUint64(id)))).Build()
-
-	tReq.Entries[0].Actions = append(tReq.Entries[0].Actions, action)
+	for _, color := range []string{policerGreen, policerYellow, policerRed} {
+		_, err := p.dataplane.FlowCounterCreate(ctx, &fwdpb.FlowCounterCreateRequest{
+			ContextId: &fwdpb.ContextId{Id: p.dataplane.ID()},
+			Id:        &fwdpb.FlowCounterId{ObjectId: &fwdpb.ObjectId{Id: policerCounterID(id, color)}},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	if _, err := p.dataplane.TableEntryAdd(ctx, tReq); err != nil {
+	attr := &saipb.PolicerAttribute{
+		MeterType:          req.MeterType,
+		Mode:               req.Mode,
+		Cbs:                req.Cbs,
+		Cir:                req.Cir,
+		Pbs:                req.Pbs,
+		Pir:                req.Pir,
+		GreenPacketAction:  req.GreenPacketAction,
+		YellowPacketAction: req.YellowPacketAction,
+		RedPacketAction:    req.RedPacketAction,
+	}
+	if err := p.updateEntry(ctx, id, attr); err != nil {
 		return nil, err
 	}
 
@@ -67,6 +97,67 @@ func (p *policer) CreatePolicer(ctx context.Context, req *saipb.CreatePolicerReq
 	}, nil
 }
 
+// updateEntry adds the entry of the policer to the policer table, replacing the existing entry.
+func (p *policer) updateEntry(ctx context.Context, id uint64, attr *saipb.PolicerAttribute) error {
+	green := policerActions(id, policerGreen, attr.GetGreenPacketAction())
+
+	var actions []*fwdpb.ActionDesc
+	switch mode := attr.GetMode(); mode {
+	case saipb.PolicerMode_POLICER_MODE_UNSPECIFIED:
+		actions = green
+	case saipb.PolicerMode_POLICER_MODE_SR_TCM, saipb.PolicerMode_POLICER_MODE_TR_TCM, saipb.PolicerMode_POLICER_MODE_STORM_CONTROL:
+		desc := &fwdpb.PolicerActionDesc{
+			Mode:          fwdpb.PolicerMode_POLICER_MODE_SR_TCM,
+			Packets:       attr.GetMeterType() == saipb.MeterType_METER_TYPE_PACKETS,
+			Cir:           attr.GetCir(),
+			Cbs:           attr.GetCbs(),
+			Pbs:           attr.GetPbs(),
+			GreenActions:  green,
+			YellowActions: policerActions(id, policerYellow, attr.GetYellowPacketAction()),
+			RedActions:    policerActions(id, policerRed, attr.GetRedPacketAction()),
+		}
+		switch mode {
+		case saipb.PolicerMode_POLICER_MODE_TR_TCM:
+			if attr.GetPir() < attr.GetCir() {
+				return status.Errorf(codes.InvalidArgument, "peak rate %d is less than committed rate %d", attr.GetPir(), attr.GetCir())
+			}
+			desc.Mode = fwdpb.PolicerMode_POLICER_MODE_TR_TCM
+			desc.Pir = attr.GetPir()
+		case saipb.PolicerMode_POLICER_MODE_STORM_CONTROL:
+			// Storm control only has a committed rate, packets exceeding it are red.
+			desc.Pbs = 0
+		}
+		actions = []*fwdpb.ActionDesc{{
+			ActionType: fwdpb.ActionType_ACTION_TYPE_POLICER,
+			Action:     &fwdpb.ActionDesc_Policer{Policer: desc},
+		}}
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported policer mode %v", mode)
+	}
+
+	tReq := fwdconfig.TableEntryAddRequest(p.dataplane.ID(), policerTabler).
+		AppendEntry(fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_POLICER_ID).WithUint64(id)))).Build()
+	tReq.Entries[0].Actions = actions
+
+	_, err := p.dataplane.TableEntryAdd(ctx, tReq)
+	return err
+}
+
+// policerActions returns the actions for the packets of a color: the packets are counted and take the packet action.
+// Forwarded packets keep the packet action set by the previous lookups (e.g. the trap action of a CoPP entry),
+// and dropped packets are not copied to the CPU either.
+func policerActions(id uint64, color string, act saipb.PacketAction) []*fwdpb.ActionDesc {
+	actions := []*fwdpb.ActionDesc{fwdconfig.Action(fwdconfig.FlowCounterAction(policerCounterID(id, color))).Build()}
+	switch act {
+	case saipb.PacketAction_PACKET_ACTION_UNSPECIFIED, saipb.PacketAction_PACKET_ACTION_FORWARD:
+	case saipb.PacketAction_PACKET_ACTION_DROP:
+		actions = append(actions, computePacketAction(saipb.PacketAction_PACKET_ACTION_DENY))
+	default:
+		actions = append(actions, computePacketAction(act))
+	}
+	return actions
+}
+
 // RemovePolicer removes the entry from the table.
 func (p *policer) RemovePolicer(ctx context.Context, req *saipb.RemovePolicerRequest) (*saipb.RemovePolicerResponse, error) {
 	tReq := fwdconfig.TableEntryRemoveRequest(p.dataplane.ID(), policerTabler).
@@ -75,10 +166,73 @@ func (p *policer) RemovePolicer(ctx context.Context, req *saipb.RemovePolicerReq
 	if _, err := p.dataplane.TableEntryRemove(ctx, tReq.Build()); err != nil {
 		return nil, err
 	}
+	for _, color := range []string{policerGreen, policerYellow, policerRed} {
+		_, err := p.dataplane.ObjectDelete(ctx, &fwdpb.ObjectDeleteRequest{
+			ContextId: &fwdpb.ContextId{Id: p.dataplane.ID()},
+			ObjectId:  &fwdpb.ObjectId{Id: policerCounterID(req.GetOid(), color)},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &saipb.RemovePolicerResponse{}, nil
 }
 
-func (p *policer) SetPolicerAttribute(context.Context, *saipb.SetPolicerAttributeRequest) (*saipb.SetPolicerAttributeResponse, error) {
+// SetPolicerAttribute updates the rates, burst sizes and packet actions of the policer.
+// The buckets of the policer are reset to full when its entry is replaced.
+func (p *policer) SetPolicerAttribute(ctx context.Context, req *saipb.SetPolicerAttributeRequest) (*saipb.SetPolicerAttributeResponse, error) {
+	p.mgr.StoreAttributes(req.GetOid(), req)
+	attr := &saipb.PolicerAttribute{}
+	if err := p.mgr.PopulateAllAttributes(fmt.Sprint(req.GetOid()), attr); err != nil {
+		return nil, err
+	}
+	if err := p.updateEntry(ctx, req.GetOid(), attr); err != nil {
+		return nil, err
+	}
 	return &saipb.SetPolicerAttributeResponse{}, nil
 }
+
+// GetPolicerStats returns the packet and byte counts of the packets metered by the policer.
+func (p *policer) GetPolicerStats(ctx context.Context, req *saipb.GetPolicerStatsRequest) (*saipb.GetPolicerStatsResponse, error) {
+	reply, err := p.dataplane.FlowCounterQuery(ctx, &fwdpb.FlowCounterQueryRequest{
+		ContextId: &fwdpb.ContextId{Id: p.dataplane.ID()},
+		Ids: []*fwdpb.FlowCounterId{
+			{ObjectId: &fwdpb.ObjectId{Id: policerCounterID(req.GetOid(), policerGreen)}},
+			{ObjectId: &fwdpb.ObjectId{Id: policerCounterID(req.GetOid(), policerYellow)}},
+			{ObjectId: &fwdpb.ObjectId{Id: policerCounterID(req.GetOid(), policerRed)}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var green, yellow, red *fwdpb.FlowCounter
+	if counters := reply.GetCounters(); len(counters) == 3 {
+		green, yellow, red = counters[0], counters[1], counters[2]
+	}
+
+	resp := &saipb.GetPolicerStatsResponse{}
+	for _, id := range req.GetCounterIds() {
+		switch id {
+		case saipb.PolicerStat_POLICER_STAT_PACKETS:
+			resp.Values = append(resp.Values, green.GetPackets()+yellow.GetPackets()+red.GetPackets())
+		case saipb.PolicerStat_POLICER_STAT_ATTR_BYTES:
+			resp.Values = append(resp.Values, green.GetOctets()+yellow.GetOctets()+red.GetOctets())
+		case saipb.PolicerStat_POLICER_STAT_GREEN_PACKETS:
+			resp.Values = append(resp.Values, green.GetPackets())
+		case saipb.PolicerStat_POLICER_STAT_GREEN_BYTES:
+			resp.Values = append(resp.Values, green.GetOctets())
+		case saipb.PolicerStat_POLICER_STAT_YELLOW_PACKETS:
+			resp.Values = append(resp.Values, yellow.GetPackets())
+		case saipb.PolicerStat_POLICER_STAT_YELLOW_BYTES:
+			resp.Values = append(resp.Values, yellow.GetOctets())
+		case saipb.PolicerStat_POLICER_STAT_RED_PACKETS:
+			resp.Values = append(resp.Values, red.GetPackets())
+		case saipb.PolicerStat_POLICER_STAT_RED_BYTES:
+			resp.Values = append(resp.Values, red.GetOctets())
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported policer stat %v", id)
+		}
+	}
+	return resp, nil
+}
@@ -25,6 +25,7 @@ import (
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/testing/protocmp"
 
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
 	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
 	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
 	fwdpb "github.com/openconfig/lemming/proto/forwarding"
@@ -58,6 +59,13 @@ func TestCreatePolicer(t *testing.T) {
 					},
 				},
 				Actions: []*fwdpb.ActionDesc{{
+					ActionType: fwdpb.ActionType_ACTION_TYPE_FLOW_COUNTER,
+					Action: &fwdpb.ActionDesc_Flow{
+						Flow: &fwdpb.FlowCounterActionDesc{
+							CounterId: &fwdpb.FlowCounterId{ObjectId: &fwdpb.ObjectId{Id: "2-green-counter"}},
+						},
+					},
+				}, {
 					ActionType: fwdpb.ActionType_ACTION_TYPE_UPDATE,
 					Action: &fwdpb.ActionDesc_Update{
 						Update: &fwdpb.UpdateActionDesc{
@@ -71,6 +79,68 @@ func TestCreatePolicer(t *testing.T) {
 				}},
 			}},
 		},
+	}, {
+		desc: "trTCM",
+		req: &saipb.CreatePolicerRequest{
+			MeterType:       saipb.MeterType_METER_TYPE_BYTES.Enum(),
+			Mode:            saipb.PolicerMode_POLICER_MODE_TR_TCM.Enum(),
+			Cir:             proto.Uint64(1000),
+			Cbs:             proto.Uint64(100),
+			Pir:             proto.Uint64(2000),
+			Pbs:             proto.Uint64(200),
+			RedPacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+		},
+		want: policerEntry(2, &fwdpb.PolicerActionDesc{
+			Mode: fwdpb.PolicerMode_POLICER_MODE_TR_TCM,
+			Cir:  1000,
+			Cbs:  100,
+			Pir:  2000,
+			Pbs:  200,
+			GreenActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-green-counter")).Build(),
+			},
+			YellowActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-yellow-counter")).Build(),
+			},
+			RedActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-red-counter")).Build(),
+				computePacketAction(saipb.PacketAction_PACKET_ACTION_DENY),
+			},
+		}),
+	}, {
+		desc: "storm control in packets",
+		req: &saipb.CreatePolicerRequest{
+			MeterType:       saipb.MeterType_METER_TYPE_PACKETS.Enum(),
+			Mode:            saipb.PolicerMode_POLICER_MODE_STORM_CONTROL.Enum(),
+			Cir:             proto.Uint64(10),
+			Cbs:             proto.Uint64(1),
+			Pbs:             proto.Uint64(5),
+			RedPacketAction: saipb.PacketAction_PACKET_ACTION_DROP.Enum(),
+		},
+		want: policerEntry(2, &fwdpb.PolicerActionDesc{
+			Mode:    fwdpb.PolicerMode_POLICER_MODE_SR_TCM,
+			Packets: true,
+			Cir:     10,
+			Cbs:     1,
+			GreenActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-green-counter")).Build(),
+			},
+			YellowActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-yellow-counter")).Build(),
+			},
+			RedActions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.FlowCounterAction("2-red-counter")).Build(),
+				computePacketAction(saipb.PacketAction_PACKET_ACTION_DENY),
+			},
+		}),
+	}, {
+		desc: "peak rate less than committed rate",
+		req: &saipb.CreatePolicerRequest{
+			Mode: saipb.PolicerMode_POLICER_MODE_TR_TCM.Enum(),
+			Cir:  proto.Uint64(2000),
+			Pir:  proto.Uint64(1000),
+		},
+		wantErr: "less than committed rate",
 	}}
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -156,6 +226,131 @@ func TestRemovePolicer(t *testing.T) {
 	}
 }
 
+func TestSetPolicerAttribute(t *testing.T) {
+	dplane := &fakeSwitchDataplane{}
+	c, p, stopFn := newTestPolicer(t, dplane)
+	defer stopFn()
+	p.mgr.StoreAttributes(p.mgr.NextID(), &saipb.SwitchAttribute{
+		CpuPort: proto.Uint64(10),
+	})
+	resp, err := c.CreatePolicer(context.TODO(), &saipb.CreatePolicerRequest{
+		MeterType: saipb.MeterType_METER_TYPE_BYTES.Enum(),
+		Mode:      saipb.PolicerMode_POLICER_MODE_SR_TCM.Enum(),
+		Cir:       proto.Uint64(1000),
+		Cbs:       proto.Uint64(100),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SetPolicerAttribute(context.TODO(), &saipb.SetPolicerAttributeRequest{
+		Oid:                resp.GetOid(),
+		Cir:                proto.Uint64(2000),
+		YellowPacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	}); err != nil {
+		t.Fatalf("SetPolicerAttribute() unexpected err: %v", err)
+	}
+	want := policerEntry(2, &fwdpb.PolicerActionDesc{
+		Mode: fwdpb.PolicerMode_POLICER_MODE_SR_TCM,
+		Cir:  2000,
+		Cbs:  100,
+		GreenActions: []*fwdpb.ActionDesc{
+			fwdconfig.Action(fwdconfig.FlowCounterAction("2-green-counter")).Build(),
+		},
+		YellowActions: []*fwdpb.ActionDesc{
+			fwdconfig.Action(fwdconfig.FlowCounterAction("2-yellow-counter")).Build(),
+			computePacketAction(saipb.PacketAction_PACKET_ACTION_TRAP),
+		},
+		RedActions: []*fwdpb.ActionDesc{
+			fwdconfig.Action(fwdconfig.FlowCounterAction("2-red-counter")).Build(),
+		},
+	})
+	if len(dplane.gotEntryAddReqs) != 2 {
+		t.Fatalf("SetPolicerAttribute() got %d entry adds, want 2", len(dplane.gotEntryAddReqs))
+	}
+	if d := cmp.Diff(dplane.gotEntryAddReqs[1], want, protocmp.Transform()); d != "" {
+		t.Errorf("SetPolicerAttribute() failed: diff(-got,+want)\n:%s", d)
+	}
+}
+
+func TestGetPolicerStats(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     *saipb.GetPolicerStatsRequest
+		replies []*fwdpb.FlowCounterQueryReply
+		want    *saipb.GetPolicerStatsResponse
+		wantErr string
+	}{{
+		desc: "per color stats",
+		req: &saipb.GetPolicerStatsRequest{
+			Oid: 2,
+			CounterIds: []saipb.PolicerStat{
+				saipb.PolicerStat_POLICER_STAT_PACKETS,
+				saipb.PolicerStat_POLICER_STAT_ATTR_BYTES,
+				saipb.PolicerStat_POLICER_STAT_GREEN_PACKETS,
+				saipb.PolicerStat_POLICER_STAT_GREEN_BYTES,
+				saipb.PolicerStat_POLICER_STAT_YELLOW_PACKETS,
+				saipb.PolicerStat_POLICER_STAT_YELLOW_BYTES,
+				saipb.PolicerStat_POLICER_STAT_RED_PACKETS,
+				saipb.PolicerStat_POLICER_STAT_RED_BYTES,
+			},
+		},
+		replies: []*fwdpb.FlowCounterQueryReply{{
+			Counters: []*fwdpb.FlowCounter{
+				{Packets: 1, Octets: 100},
+				{Packets: 2, Octets: 200},
+				{Packets: 3, Octets: 300},
+			},
+		}},
+		want: &saipb.GetPolicerStatsResponse{
+			Values: []uint64{6, 600, 1, 100, 2, 200, 3, 300},
+		},
+	}, {
+		desc: "no counters",
+		req: &saipb.GetPolicerStatsRequest{
+			Oid:        2,
+			CounterIds: []saipb.PolicerStat{saipb.PolicerStat_POLICER_STAT_PACKETS},
+		},
+		want: &saipb.GetPolicerStatsResponse{
+			Values: []uint64{0},
+		},
+	}, {
+		desc: "unsupported stat",
+		req: &saipb.GetPolicerStatsRequest{
+			Oid:        2,
+			CounterIds: []saipb.PolicerStat{saipb.PolicerStat_POLICER_STAT_CUSTOM_RANGE_BASE},
+		},
+		wantErr: "unsupported",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dplane := &fakeSwitchDataplane{flowQueryReplies: tt.replies}
+			c, _, stopFn := newTestPolicer(t, dplane)
+			defer stopFn()
+			got, gotErr := c.GetPolicerStats(context.TODO(), tt.req)
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("GetPolicerStats() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+			if d := cmp.Diff(got, tt.want, protocmp.Transform()); d != "" {
+				t.Errorf("GetPolicerStats() failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
+
+// policerEntry returns the policer table entry of a metering policer.
+func policerEntry(id uint64, desc *fwdpb.PolicerActionDesc) *fwdpb.TableEntryAddRequest {
+	req := fwdconfig.TableEntryAddRequest("foo", policerTabler).
+		AppendEntry(fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_POLICER_ID).WithUint64(id)))).Build()
+	req.Entries[0].Actions = []*fwdpb.ActionDesc{{
+		ActionType: fwdpb.ActionType_ACTION_TYPE_POLICER,
+		Action:     &fwdpb.ActionDesc_Policer{Policer: desc},
+	}}
+	return req
+}
+
 func newTestPolicer(t testing.TB, api switchDataplaneAPI) (saipb.PolicerClient, *policer, func()) {
 	var p *policer
 	conn, _, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
@@ -34,7 +34,7 @@ import (
 	fwdpb "github.com/openconfig/lemming/proto/forwarding"
 )
 
-func newPort(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server, vlan saipb.VlanServer, queue saipb.QueueServer, sg saipb.SchedulerGroupServer, opts *dplaneopts.Options) (*port, error) {
+func newPort(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server, vlan saipb.VlanServer, queue *queue, sg saipb.SchedulerGroupServer, qosMap *qosMap, samplepacket *samplepacket, opts *dplaneopts.Options) (*port, error) {
 	p := &port{
 		mgr:       mgr,
 		dataplane: dataplane,
@@ -42,6 +42,8 @@ func newPort(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server,
 		opts:      opts,
 		queue:     queue,
 		sg:        sg,
+		qosMap:    qosMap,
+		sample:    samplepacket,
 	}
 
 	saipb.RegisterPortServer(s, p)
@@ -54,8 +56,10 @@ type port struct {
 	mgr       *attrmgr.AttrMgr
 	dataplane switchDataplaneAPI
 	opts      *dplaneopts.Options
-	queue     saipb.QueueServer
+	queue     *queue
 	sg        saipb.SchedulerGroupServer
+	qosMap    *qosMap
+	sample    *samplepacket
 }
 
 // stub for testing
@@ -552,6 +556,35 @@ func (port *port) SetPortAttribute(ctx context.Context, req *saipb.SetPortAttrib
 			return nil, err
 		}
 	}
+	if req.Speed != nil {
+		port.queue.setPortSpeed(req.GetOid(), req.GetSpeed())
+	}
+	qosMaps := []struct {
+		oid *uint64
+		typ saipb.QosMapType
+	}{
+		{req.QosDscpToTcMap, saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC},
+		{req.QosMplsExpToTcMap, saipb.QosMapType_QOS_MAP_TYPE_MPLS_EXP_TO_TC},
+		{req.QosTcToQueueMap, saipb.QosMapType_QOS_MAP_TYPE_TC_TO_QUEUE},
+	}
+	for _, m := range qosMaps {
+		if m.oid == nil {
+			continue
+		}
+		if err := port.qosMap.bindPort(ctx, req.GetOid(), m.typ, *m.oid); err != nil {
+			return nil, err
+		}
+	}
+	if req.IngressSamplepacketEnable != nil {
+		if err := port.sample.bindPort(ctx, req.GetOid(), true, req.GetIngressSamplepacketEnable()); err != nil {
+			return nil, err
+		}
+	}
+	if req.EgressSamplepacketEnable != nil {
+		if err := port.sample.bindPort(ctx, req.GetOid(), false, req.GetEgressSamplepacketEnable()); err != nil {
+			return nil, err
+		}
+	}
 	portAttr := &saipb.GetPortAttributeResponse{}
 	port.mgr.PopulateAttributes(&saipb.GetPortAttributeRequest{Oid: req.GetOid(), AttrType: []saipb.PortAttr{saipb.PortAttr_PORT_ATTR_HW_LANE_LIST, saipb.PortAttr_PORT_ATTR_SPEED}}, portAttr)
 
@@ -675,6 +708,10 @@ func (port *port) GetPortStats(ctx context.Context, req *saipb.GetPortStatsReque
 }
 
 func (port *port) RemovePort(ctx context.Context, req *saipb.RemovePortRequest) (*saipb.RemovePortResponse, error) {
+	if err := port.qosMap.removePort(ctx, req.GetOid()); err != nil {
+		return nil, err
+	}
+	port.queue.removePort(req.GetOid())
 	if _, err := port.dataplane.ObjectDelete(ctx, &fwdpb.ObjectDeleteRequest{
 		ContextId: &fwdpb.ContextId{Id: port.dataplane.ID()},
 		ObjectId:  &fwdpb.ObjectId{Id: fmt.Sprint(req.GetOid())},
@@ -829,41 +866,6 @@ func (l *lag) SetLagMemberAttribute(context.Context, *saipb.SetLagMemberAttribut
 	return &saipb.SetLagMemberAttributeResponse{}, nil
 }
 
-type queue struct {
-	saipb.UnimplementedQueueServer
-	mgr       *attrmgr.AttrMgr
-	dataplane switchDataplaneAPI
-}
-
-func newQueue(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server) *queue {
-	q := &queue{
-		mgr:       mgr,
-		dataplane: dataplane,
-	}
-	saipb.RegisterQueueServer(s, q)
-	return q
-}
-
-// CreateQueue creates a queue.
-// TODO: Implement this.
-func (q *queue) CreateQueue(context.Context, *saipb.CreateQueueRequest) (*saipb.CreateQueueResponse, error) {
-	id := q.mgr.NextID()
-
-	return &saipb.CreateQueueResponse{
-		Oid: id,
-	}, nil
-}
-
-// SetQueueAttribute sets an attribute.
-// TODO: Implement this.
-func (q *queue) SetQueueAttribute(context.Context, *saipb.SetQueueAttributeRequest) (*saipb.SetQueueAttributeResponse, error) {
-	return &saipb.SetQueueAttributeResponse{}, nil
-}
-
-func (q *queue) RemoveQueue(context.Context, *saipb.RemoveQueueRequest) (*saipb.RemoveQueueResponse, error) {
-	return &saipb.RemoveQueueResponse{}, nil
-}
-
 type schedulerGroup struct {
 	saipb.UnimplementedSchedulerGroupServer
 	mgr       *attrmgr.AttrMgr
@@ -895,64 +897,6 @@ func (sg *schedulerGroup) SetSchedulerGroupAttribute(context.Context, *saipb.Set
 	return &saipb.SetSchedulerGroupAttributeResponse{}, nil
 }
 
-func newScheduler(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server) *scheduler {
-	s := &scheduler{
-		mgr:       mgr,
-		dataplane: dataplane,
-	}
-	saipb.RegisterSchedulerServer(srv, s)
-	return s
-}
-
-type scheduler struct {
-	saipb.UnimplementedSchedulerServer
-	mgr       *attrmgr.AttrMgr
-	dataplane switchDataplaneAPI
-}
-
-func (s *scheduler) CreateScheduler(context.Context, *saipb.CreateSchedulerRequest) (*saipb.CreateSchedulerResponse, error) {
-	id := s.mgr.NextID()
-
-	s.mgr.StoreAttributes(id, &saipb.SchedulerAttribute{
-		MinBandwidthRate: proto.Uint64(0),
-	})
-
-	return &saipb.CreateSchedulerResponse{
-		Oid: id,
-	}, nil
-}
-
-func (s *scheduler) SetSchedulerAttribute(context.Context, *saipb.SetSchedulerAttributeRequest) (*saipb.SetSchedulerAttributeResponse, error) {
-	return &saipb.SetSchedulerAttributeResponse{}, nil
-}
-
-func newQOSMap(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server) *qosMap {
-	q := &qosMap{
-		mgr:       mgr,
-		dataplane: dataplane,
-	}
-	saipb.RegisterQosMapServer(srv, q)
-	return q
-}
-
-type qosMap struct {
-	saipb.UnimplementedQosMapServer
-	mgr       *attrmgr.AttrMgr
-	dataplane switchDataplaneAPI
-}
-
-func (q *qosMap) CreateQosMap(context.Context, *saipb.CreateQosMapRequest) (*saipb.CreateQosMapResponse, error) {
-	id := q.mgr.NextID()
-
-	return &saipb.CreateQosMapResponse{
-		Oid: id,
-	}, nil
-}
-
-func (q *qosMap) SetQosMapAttribute(context.Context, *saipb.SetQosMapAttributeRequest) (*saipb.SetQosMapAttributeResponse, error) {
-	return &saipb.SetQosMapAttributeResponse{}, nil
-}
-
 type buffer struct {
 	saipb.UnimplementedBufferServer
 	mgr       *attrmgr.AttrMgr
@@ -999,34 +943,3 @@ func (b *buffer) SetBufferPoolAttribute(context.Context, *saipb.SetBufferPoolAtt
 func (b *buffer) SetBufferProfileAttribute(context.Context, *saipb.SetBufferProfileAttributeRequest) (*saipb.SetBufferProfileAttributeResponse, error) {
 	return &saipb.SetBufferProfileAttributeResponse{}, nil
 }
-
-type wred struct {
-	saipb.UnimplementedWredServer
-	mgr       *attrmgr.AttrMgr
-	dataplane switchDataplaneAPI
-}
-
-func newWRED(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server) *wred {
-	w := &wred{
-		mgr:       mgr,
-		dataplane: dataplane,
-	}
-	saipb.RegisterWredServer(srv, w)
-	return w
-}
-
-func (w *wred) CreateWred(context.Context, *saipb.CreateWredRequest) (*saipb.CreateWredResponse, error) {
-	id := w.mgr.NextID()
-
-	return &saipb.CreateWredResponse{
-		Oid: id,
-	}, nil
-}
-
-func (w *wred) SetWredAttribute(context.Context, *saipb.SetWredAttributeRequest) (*saipb.SetWredAttributeResponse, error) {
-	return &saipb.SetWredAttributeResponse{}, nil
-}
-
-func (w *wred) RemoveWred(context.Context, *saipb.RemoveWredRequest) (*saipb.RemoveWredResponse, error) {
-	return &saipb.RemoveWredResponse{}, nil
-}
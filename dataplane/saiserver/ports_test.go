@@ -571,7 +571,7 @@ func newTestPort(t testing.TB, api switchDataplaneAPI, opts *dplaneopts.Options)
 		mgr.StoreAttributes(swID, &saipb.SwitchAttribute{
 			DefaultVlanId: proto.Uint64(resp.GetOid()),
 		})
-		newPort(mgr, api, srv, vlan, q, sg, opts)
+		newPort(mgr, api, srv, vlan, q, sg, newQOSMap(mgr, api, srv, q), newSamplepacket(mgr, api, srv, newHostif(mgr, api, srv, opts)), opts)
 	})
 	return saipb.NewPortClient(conn), mgr, stopFn
 }
@@ -708,7 +708,7 @@ func TestCreateScheduler(t *testing.T) {
 
 func newTestScheduler(t testing.TB, api switchDataplaneAPI) (saipb.SchedulerClient, *attrmgr.AttrMgr, func()) {
 	conn, mgr, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
-		newScheduler(mgr, api, srv)
+		newScheduler(mgr, api, srv, newQueue(mgr, api, srv))
 	})
 	return saipb.NewSchedulerClient(conn), mgr, stopFn
 }
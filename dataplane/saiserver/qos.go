@@ -0,0 +1,589 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdport"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdqos"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+// qosClassifierTable sets the traffic class of packets using the DSCP and MPLS EXP
+// to TC maps bound to their input port.
+const qosClassifierTable = "qos-classifier"
+
+// queue implements the SAI queues using a lucius scheduler per port. The scheduler
+// of a port is created when one of the port's queues or its TC to queue map is configured,
+// until then packets are written to the port without queuing. The scheduler transmits
+// at the speed of the port.
+type queue struct {
+	saipb.UnimplementedQueueServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+
+	mu sync.Mutex
+	// schedulers maps port ids to the scheduler of the port.
+	schedulers map[uint64]*fwdqos.Scheduler
+}
+
+func newQueue(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server) *queue {
+	q := &queue{
+		mgr:        mgr,
+		dataplane:  dataplane,
+		schedulers: map[uint64]*fwdqos.Scheduler{},
+	}
+	saipb.RegisterQueueServer(s, q)
+	return q
+}
+
+// CreateQueue creates a queue. The queue is programmed once its profiles are set.
+func (q *queue) CreateQueue(context.Context, *saipb.CreateQueueRequest) (*saipb.CreateQueueResponse, error) {
+	id := q.mgr.NextID()
+
+	return &saipb.CreateQueueResponse{
+		Oid: id,
+	}, nil
+}
+
+// SetQueueAttribute sets the scheduler and WRED profiles of the queue.
+func (q *queue) SetQueueAttribute(ctx context.Context, req *saipb.SetQueueAttributeRequest) (*saipb.SetQueueAttributeResponse, error) {
+	if req.SchedulerProfileId == nil && req.WredProfileId == nil {
+		return &saipb.SetQueueAttributeResponse{}, nil
+	}
+	// The attributes are stored after the request, so store them now to apply all of them.
+	q.mgr.StoreAttributes(req.GetOid(), req)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.apply(req.GetOid()); err != nil {
+		return nil, err
+	}
+	return &saipb.SetQueueAttributeResponse{}, nil
+}
+
+func (q *queue) RemoveQueue(context.Context, *saipb.RemoveQueueRequest) (*saipb.RemoveQueueResponse, error) {
+	return &saipb.RemoveQueueResponse{}, nil
+}
+
+// GetQueueStats returns the counters of the queue. Queues of ports without a scheduler have no counters.
+func (q *queue) GetQueueStats(_ context.Context, req *saipb.GetQueueStatsRequest) (*saipb.GetQueueStatsResponse, error) {
+	attr := &saipb.QueueAttribute{}
+	if err := q.mgr.PopulateAllAttributes(fmt.Sprint(req.GetOid()), attr); err != nil {
+		return nil, err
+	}
+	var stats fwdqos.Stats
+	q.mu.Lock()
+	if s, ok := q.schedulers[attr.GetPort()]; ok {
+		var err error
+		if stats, err = s.Stats(int(attr.GetIndex())); err != nil {
+			q.mu.Unlock()
+			return nil, err
+		}
+	}
+	q.mu.Unlock()
+
+	resp := &saipb.GetQueueStatsResponse{}
+	for _, id := range req.GetCounterIds() {
+		switch id {
+		case saipb.QueueStat_QUEUE_STAT_PACKETS:
+			resp.Values = append(resp.Values, stats.TxPackets)
+		case saipb.QueueStat_QUEUE_STAT_BYTES:
+			resp.Values = append(resp.Values, stats.TxOctets)
+		case saipb.QueueStat_QUEUE_STAT_DROPPED_PACKETS:
+			resp.Values = append(resp.Values, stats.DropPackets)
+		case saipb.QueueStat_QUEUE_STAT_DROPPED_BYTES:
+			resp.Values = append(resp.Values, stats.DropOctets)
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported queue stat %v", id)
+		}
+	}
+	return resp, nil
+}
+
+// scheduler returns the scheduler of the port, creating it if it doesn't exist.
+// It must be called with the lock held.
+func (q *queue) scheduler(portID uint64) (*fwdqos.Scheduler, error) {
+	if s, ok := q.schedulers[portID]; ok {
+		return s, nil
+	}
+	fwdCtx, err := q.dataplane.FindContext(&fwdpb.ContextId{Id: q.dataplane.ID()})
+	if err != nil {
+		return nil, err
+	}
+	p, err := fwdport.Find(&fwdpb.PortId{ObjectId: &fwdpb.ObjectId{Id: fmt.Sprint(portID)}}, fwdCtx)
+	if err != nil {
+		return nil, err
+	}
+	n := numQueues
+	if v, ok := q.mgr.GetAttribute(fmt.Sprint(portID), int32(saipb.PortAttr_PORT_ATTR_QOS_NUMBER_OF_QUEUES)).(uint32); ok {
+		n = int(v)
+	}
+	s := fwdqos.NewScheduler(p, n)
+	speed, ok := q.mgr.GetAttribute(fmt.Sprint(portID), int32(saipb.PortAttr_PORT_ATTR_SPEED)).(uint32)
+	if !ok {
+		speed, _ = q.mgr.GetAttribute(fmt.Sprint(portID), int32(saipb.PortAttr_PORT_ATTR_OPER_SPEED)).(uint32)
+	}
+	s.SetPortRate(portRate(speed))
+	q.schedulers[portID] = s
+	return s, nil
+}
+
+// portRate returns the rate in bytes per second of a port speed in Mbps.
+func portRate(speed uint32) uint64 {
+	return uint64(speed) * 1000000 / 8
+}
+
+// setPortSpeed sets the speed in Mbps of the port, which paces its scheduler.
+func (q *queue) setPortSpeed(portID uint64, speed uint32) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if s, ok := q.schedulers[portID]; ok {
+		s.SetPortRate(portRate(speed))
+	}
+}
+
+// removePort stops the scheduler of the port, if any.
+func (q *queue) removePort(portID uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.schedulers[portID]
+	if !ok {
+		return
+	}
+	s.Stop()
+	delete(q.schedulers, portID)
+}
+
+// setTCToQueue sets the map of traffic classes to queue indices of the port.
+// A nil map maps each traffic class to the queue with the same index.
+func (q *queue) setTCToQueue(portID uint64, m map[uint8]int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.schedulers[portID]; !ok && m == nil {
+		return nil
+	}
+	s, err := q.scheduler(portID)
+	if err != nil {
+		return err
+	}
+	return s.SetTCToQueue(m)
+}
+
+// apply programs the queue with its scheduler and WRED profiles.
+// It must be called with the lock held.
+func (q *queue) apply(oid uint64) error {
+	attr := &saipb.QueueAttribute{}
+	if err := q.mgr.PopulateAllAttributes(fmt.Sprint(oid), attr); err != nil {
+		return err
+	}
+	config, err := q.queueConfig(attr)
+	if err != nil {
+		return err
+	}
+	s, err := q.scheduler(attr.GetPort())
+	if err != nil {
+		return err
+	}
+	return s.SetQueue(int(attr.GetIndex()), config)
+}
+
+// queueConfig returns the lucius configuration of the queue.
+func (q *queue) queueConfig(attr *saipb.QueueAttribute) (fwdqos.QueueConfig, error) {
+	config := fwdqos.QueueConfig{}
+	if id := attr.GetSchedulerProfileId(); id != 0 {
+		sched := &saipb.SchedulerAttribute{}
+		if err := q.mgr.PopulateAllAttributes(fmt.Sprint(id), sched); err != nil {
+			return config, err
+		}
+		switch sched.GetSchedulingType() {
+		case saipb.SchedulingType_SCHEDULING_TYPE_STRICT:
+			config.Type = fwdqos.StrictPriority
+		default:
+			// WRR is treated as DWRR.
+			config.Type = fwdqos.DWRR
+			config.Weight = uint64(sched.GetSchedulingWeight())
+		}
+		if rate := sched.GetMaxBandwidthRate(); rate != 0 {
+			if sched.GetMeterType() == saipb.MeterType_METER_TYPE_PACKETS {
+				return config, status.Errorf(codes.InvalidArgument, "packet meters are not supported for scheduler %d", id)
+			}
+			config.RateBps = rate
+			config.BurstBytes = sched.GetMaxBandwidthBurstRate()
+		}
+	}
+	if id := attr.GetWredProfileId(); id != 0 {
+		w := &saipb.WredAttribute{}
+		if err := q.mgr.PopulateAllAttributes(fmt.Sprint(id), w); err != nil {
+			return config, err
+		}
+		if w.GetGreenEnable() {
+			wred := &fwdqos.WRED{
+				MinThreshold:    uint64(w.GetGreenMinThreshold()),
+				MaxThreshold:    uint64(w.GetGreenMaxThreshold()),
+				DropProbability: 100,
+			}
+			if w.GreenMinThreshold_64 != nil {
+				wred.MinThreshold = w.GetGreenMinThreshold_64()
+			}
+			if w.GreenMaxThreshold_64 != nil {
+				wred.MaxThreshold = w.GetGreenMaxThreshold_64()
+			}
+			if w.GreenDropProbability != nil {
+				wred.DropProbability = w.GetGreenDropProbability()
+			}
+			if wred.MaxThreshold < wred.MinThreshold {
+				return config, status.Errorf(codes.InvalidArgument, "WRED profile %d max threshold is less than min threshold", id)
+			}
+			config.WRED = wred
+		}
+	}
+	return config, nil
+}
+
+// reapply programs the queues of the ports with a scheduler that match the function again.
+func (q *queue) reapply(match func(*saipb.QueueAttribute) bool) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for portID := range q.schedulers {
+		portAttr := &saipb.PortAttribute{}
+		if err := q.mgr.PopulateAllAttributes(fmt.Sprint(portID), portAttr); err != nil {
+			return err
+		}
+		for _, oid := range portAttr.GetQosQueueList() {
+			attr := &saipb.QueueAttribute{}
+			if err := q.mgr.PopulateAllAttributes(fmt.Sprint(oid), attr); err != nil {
+				return err
+			}
+			if !match(attr) {
+				continue
+			}
+			if err := q.apply(oid); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newScheduler(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server, q *queue) *scheduler {
+	s := &scheduler{
+		mgr:       mgr,
+		dataplane: dataplane,
+		queue:     q,
+	}
+	saipb.RegisterSchedulerServer(srv, s)
+	return s
+}
+
+// scheduler implements the SAI scheduler profiles, which are applied to the queues using them.
+type scheduler struct {
+	saipb.UnimplementedSchedulerServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+	queue     *queue
+}
+
+func (s *scheduler) CreateScheduler(context.Context, *saipb.CreateSchedulerRequest) (*saipb.CreateSchedulerResponse, error) {
+	id := s.mgr.NextID()
+
+	s.mgr.StoreAttributes(id, &saipb.SchedulerAttribute{
+		MinBandwidthRate: proto.Uint64(0),
+	})
+
+	return &saipb.CreateSchedulerResponse{
+		Oid: id,
+	}, nil
+}
+
+// SetSchedulerAttribute updates the scheduler profile and the queues using it.
+func (s *scheduler) SetSchedulerAttribute(_ context.Context, req *saipb.SetSchedulerAttributeRequest) (*saipb.SetSchedulerAttributeResponse, error) {
+	s.mgr.StoreAttributes(req.GetOid(), req)
+	if err := s.queue.reapply(func(attr *saipb.QueueAttribute) bool {
+		return attr.GetSchedulerProfileId() == req.GetOid()
+	}); err != nil {
+		return nil, err
+	}
+	return &saipb.SetSchedulerAttributeResponse{}, nil
+}
+
+func newQOSMap(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server, q *queue) *qosMap {
+	m := &qosMap{
+		mgr:       mgr,
+		dataplane: dataplane,
+		queue:     q,
+		bindings:  map[qosMapBinding]*qosMapEntries{},
+	}
+	saipb.RegisterQosMapServer(srv, m)
+	return m
+}
+
+// qosMapBinding identifies the map of a type bound to a port.
+type qosMapBinding struct {
+	port uint64
+	typ  saipb.QosMapType
+}
+
+// qosMapEntries are the classifier entries programmed for a map bound to a port.
+type qosMapEntries struct {
+	oid     uint64
+	entries []*fwdconfig.EntryDescBuilder
+}
+
+// qosMap implements the SAI QoS maps. The DSCP and MPLS EXP to TC maps bound to
+// a port are programmed in the classifier table, and the TC to queue map bound
+// to a port is programmed in the port's scheduler.
+type qosMap struct {
+	saipb.UnimplementedQosMapServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+	queue     *queue
+
+	mu         sync.Mutex
+	classifier bool // true if the classifier table is created
+	bindings   map[qosMapBinding]*qosMapEntries
+}
+
+func (m *qosMap) CreateQosMap(context.Context, *saipb.CreateQosMapRequest) (*saipb.CreateQosMapResponse, error) {
+	id := m.mgr.NextID()
+
+	return &saipb.CreateQosMapResponse{
+		Oid: id,
+	}, nil
+}
+
+// SetQosMapAttribute updates the map and the ports it is bound to.
+func (m *qosMap) SetQosMapAttribute(ctx context.Context, req *saipb.SetQosMapAttributeRequest) (*saipb.SetQosMapAttributeResponse, error) {
+	m.mgr.StoreAttributes(req.GetOid(), req)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for b, e := range m.bindings {
+		if e.oid != req.GetOid() {
+			continue
+		}
+		if err := m.unbind(ctx, b); err != nil {
+			return nil, err
+		}
+		if err := m.bind(ctx, b, req.GetOid()); err != nil {
+			return nil, err
+		}
+	}
+	return &saipb.SetQosMapAttributeResponse{}, nil
+}
+
+// bindPort binds the map of the type to the port, replacing the previously bound map.
+// An oid of 0 unbinds the map.
+func (m *qosMap) bindPort(ctx context.Context, portID uint64, typ saipb.QosMapType, oid uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := qosMapBinding{port: portID, typ: typ}
+	if err := m.unbind(ctx, b); err != nil {
+		return err
+	}
+	if oid == 0 {
+		return nil
+	}
+	return m.bind(ctx, b, oid)
+}
+
+// removePort unbinds all the maps bound to the port.
+func (m *qosMap) removePort(ctx context.Context, portID uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for b := range m.bindings {
+		if b.port != portID {
+			continue
+		}
+		if err := m.unbind(ctx, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind programs the map for the binding. It must be called with the lock held.
+func (m *qosMap) bind(ctx context.Context, b qosMapBinding, oid uint64) error {
+	attr := &saipb.QosMapAttribute{}
+	if err := m.mgr.PopulateAllAttributes(fmt.Sprint(oid), attr); err != nil {
+		return err
+	}
+	if attr.GetType() != b.typ {
+		return status.Errorf(codes.InvalidArgument, "QoS map %d has type %v, want %v", oid, attr.GetType(), b.typ)
+	}
+	e := &qosMapEntries{oid: oid}
+	switch b.typ {
+	case saipb.QosMapType_QOS_MAP_TYPE_TC_TO_QUEUE:
+		tcToQueue := map[uint8]int{}
+		for _, v := range attr.GetMapToValueList() {
+			tcToQueue[uint8(v.GetKey().GetTc())] = int(v.GetValue().GetQueueIndex())
+		}
+		if err := m.queue.setTCToQueue(b.port, tcToQueue); err != nil {
+			return err
+		}
+	case saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, saipb.QosMapType_QOS_MAP_TYPE_MPLS_EXP_TO_TC:
+		if err := m.createClassifier(ctx); err != nil {
+			return err
+		}
+		nid, err := m.dataplane.ObjectNID(ctx, &fwdpb.ObjectNIDRequest{
+			ContextId: &fwdpb.ContextId{Id: m.dataplane.ID()},
+			ObjectId:  &fwdpb.ObjectId{Id: fmt.Sprint(b.port)},
+		})
+		if err != nil {
+			return err
+		}
+		req := fwdconfig.TableEntryAddRequest(m.dataplane.ID(), qosClassifierTable)
+		for _, v := range attr.GetMapToValueList() {
+			fields := []*fwdconfig.PacketFieldMaskedBytesBuilder{
+				fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT).WithUint64(nid.GetNid()),
+			}
+			if b.typ == saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC {
+				// The QOS header in lucius corresponds to DSCP and ECN, so shift the bits left by 2.
+				fields = append(fields,
+					fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_VERSION).WithBytes([]byte{0x4}, []byte{0x4}),
+					fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_QOS).WithBytes([]byte{byte(v.GetKey().GetDscp()) << 2}, []byte{0xfc}))
+			} else {
+				fields = append(fields,
+					fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE).WithUint16(0x8847),
+					fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_TC).WithBytes([]byte{byte(v.GetKey().GetMplsExp())}, []byte{0x7}))
+			}
+			entry := fwdconfig.EntryDesc(fwdconfig.FlowEntry(fields...))
+			req.AppendEntry(entry,
+				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ATTRIBUTE_8).
+					WithFieldIDInstance(fwdqos.TCInstance).WithValue([]byte{byte(v.GetValue().GetTc())}))
+			e.entries = append(e.entries, entry)
+		}
+		if len(e.entries) != 0 {
+			if _, err := m.dataplane.TableEntryAdd(ctx, req.Build()); err != nil {
+				return err
+			}
+		}
+	}
+	m.bindings[b] = e
+	return nil
+}
+
+// unbind removes the programming of the map bound for the binding, if any.
+// It must be called with the lock held.
+func (m *qosMap) unbind(ctx context.Context, b qosMapBinding) error {
+	e, ok := m.bindings[b]
+	if !ok {
+		return nil
+	}
+	switch b.typ {
+	case saipb.QosMapType_QOS_MAP_TYPE_TC_TO_QUEUE:
+		if err := m.queue.setTCToQueue(b.port, nil); err != nil {
+			return err
+		}
+	case saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, saipb.QosMapType_QOS_MAP_TYPE_MPLS_EXP_TO_TC:
+		if len(e.entries) != 0 {
+			req := fwdconfig.TableEntryRemoveRequest(m.dataplane.ID(), qosClassifierTable)
+			for _, entry := range e.entries {
+				req.AppendEntry(entry)
+			}
+			if _, err := m.dataplane.TableEntryRemove(ctx, req.Build()); err != nil {
+				return err
+			}
+		}
+	}
+	delete(m.bindings, b)
+	return nil
+}
+
+// createClassifier creates the classifier table and looks it up in the pre-ingress stage,
+// if the table doesn't exist. It must be called with the lock held.
+func (m *qosMap) createClassifier(ctx context.Context) error {
+	if m.classifier {
+		return nil
+	}
+	_, err := m.dataplane.TableCreate(ctx, &fwdpb.TableCreateRequest{
+		ContextId: &fwdpb.ContextId{Id: m.dataplane.ID()},
+		Desc: &fwdpb.TableDesc{
+			TableType: fwdpb.TableType_TABLE_TYPE_FLOW,
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: qosClassifierTable}},
+			Table: &fwdpb.TableDesc_Flow{
+				Flow: &fwdpb.FlowTableDesc{
+					BankCount: 1,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = m.dataplane.TableEntryAdd(ctx, fwdconfig.TableEntryAddRequest(m.dataplane.ID(), PreIngressActionTable).
+		AppendEntry(
+			fwdconfig.EntryDesc(fwdconfig.ActionEntry("qos", fwdpb.ActionEntryDesc_INSERT_METHOD_PREPEND)),
+			fwdconfig.LookupAction(qosClassifierTable)).
+		Build(),
+	)
+	if err != nil {
+		return err
+	}
+	m.classifier = true
+	return nil
+}
+
+type wred struct {
+	saipb.UnimplementedWredServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+	queue     *queue
+}
+
+func newWRED(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, srv *grpc.Server, q *queue) *wred {
+	w := &wred{
+		mgr:       mgr,
+		dataplane: dataplane,
+		queue:     q,
+	}
+	saipb.RegisterWredServer(srv, w)
+	return w
+}
+
+func (w *wred) CreateWred(context.Context, *saipb.CreateWredRequest) (*saipb.CreateWredResponse, error) {
+	id := w.mgr.NextID()
+
+	return &saipb.CreateWredResponse{
+		Oid: id,
+	}, nil
+}
+
+// SetWredAttribute updates the WRED profile and the queues using it.
+func (w *wred) SetWredAttribute(_ context.Context, req *saipb.SetWredAttributeRequest) (*saipb.SetWredAttributeResponse, error) {
+	w.mgr.StoreAttributes(req.GetOid(), req)
+	if err := w.queue.reapply(func(attr *saipb.QueueAttribute) bool {
+		return attr.GetWredProfileId() == req.GetOid()
+	}); err != nil {
+		return nil, err
+	}
+	return &saipb.SetWredAttributeResponse{}, nil
+}
+
+func (w *wred) RemoveWred(context.Context, *saipb.RemoveWredRequest) (*saipb.RemoveWredResponse, error) {
+	return &saipb.RemoveWredResponse{}, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdqos"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+func TestQosMapBindPort(t *testing.T) {
+	dplane := &fakeSwitchDataplane{}
+	var m *qosMap
+	conn, _, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
+		m = newQOSMap(mgr, dplane, srv, newQueue(mgr, dplane, srv))
+	})
+	defer stopFn()
+	c := saipb.NewQosMapClient(conn)
+	ctx := context.Background()
+
+	resp, err := c.CreateQosMap(ctx, &saipb.CreateQosMapRequest{
+		Type: saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC.Enum(),
+		MapToValueList: []*saipb.QOSMap{{
+			Key:   &saipb.QOSMapParams{Dscp: 46},
+			Value: &saipb.QOSMapParams{Tc: 5},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("CreateQosMap() unexpected err: %v", err)
+	}
+	if err := m.bindPort(ctx, 10, saipb.QosMapType_QOS_MAP_TYPE_TC_TO_QUEUE, resp.GetOid()); err == nil {
+		t.Errorf("bindPort() got no error for mismatched map type")
+	}
+	if err := m.bindPort(ctx, 10, saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, resp.GetOid()); err != nil {
+		t.Fatalf("bindPort() unexpected err: %v", err)
+	}
+
+	entry := fwdconfig.EntryDesc(fwdconfig.FlowEntry(
+		fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT).WithUint64(10),
+		fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_VERSION).WithBytes([]byte{0x4}, []byte{0x4}),
+		fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_QOS).WithBytes([]byte{46 << 2}, []byte{0xfc}),
+	))
+	wantAdd := []*fwdpb.TableEntryAddRequest{
+		fwdconfig.TableEntryAddRequest("foo", PreIngressActionTable).
+			AppendEntry(
+				fwdconfig.EntryDesc(fwdconfig.ActionEntry("qos", fwdpb.ActionEntryDesc_INSERT_METHOD_PREPEND)),
+				fwdconfig.LookupAction(qosClassifierTable)).
+			Build(),
+		fwdconfig.TableEntryAddRequest("foo", qosClassifierTable).
+			AppendEntry(entry,
+				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ATTRIBUTE_8).
+					WithFieldIDInstance(fwdqos.TCInstance).WithValue([]byte{5})).
+			Build(),
+	}
+	if d := cmp.Diff(dplane.gotEntryAddReqs, wantAdd, protocmp.Transform()); d != "" {
+		t.Errorf("bindPort() failed: diff(-got,+want)\n:%s", d)
+	}
+
+	if err := m.bindPort(ctx, 10, saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, 0); err != nil {
+		t.Fatalf("bindPort() unexpected err: %v", err)
+	}
+	wantRemove := []*fwdpb.TableEntryRemoveRequest{
+		fwdconfig.TableEntryRemoveRequest("foo", qosClassifierTable).AppendEntry(entry).Build(),
+	}
+	if d := cmp.Diff(dplane.gotEntryRemoveReqs, wantRemove, protocmp.Transform()); d != "" {
+		t.Errorf("bindPort() unbind failed: diff(-got,+want)\n:%s", d)
+	}
+
+	if err := m.bindPort(ctx, 10, saipb.QosMapType_QOS_MAP_TYPE_DSCP_TO_TC, resp.GetOid()); err != nil {
+		t.Fatalf("bindPort() unexpected err: %v", err)
+	}
+	dplane.gotEntryRemoveReqs = nil
+	if err := m.removePort(ctx, 10); err != nil {
+		t.Fatalf("removePort() unexpected err: %v", err)
+	}
+	if d := cmp.Diff(dplane.gotEntryRemoveReqs, wantRemove, protocmp.Transform()); d != "" {
+		t.Errorf("removePort() failed: diff(-got,+want)\n:%s", d)
+	}
+	if len(m.bindings) != 0 {
+		t.Errorf("removePort() left bindings: %v", m.bindings)
+	}
+}
+
+func TestGetQueueStats(t *testing.T) {
+	tests := []struct {
+		desc    string
+		req     *saipb.GetQueueStatsRequest
+		want    *saipb.GetQueueStatsResponse
+		wantErr string
+	}{{
+		desc: "port without scheduler",
+		req: &saipb.GetQueueStatsRequest{
+			Oid: 1,
+			CounterIds: []saipb.QueueStat{
+				saipb.QueueStat_QUEUE_STAT_PACKETS,
+				saipb.QueueStat_QUEUE_STAT_BYTES,
+				saipb.QueueStat_QUEUE_STAT_DROPPED_PACKETS,
+				saipb.QueueStat_QUEUE_STAT_DROPPED_BYTES,
+			},
+		},
+		want: &saipb.GetQueueStatsResponse{
+			Values: []uint64{0, 0, 0, 0},
+		},
+	}, {
+		desc: "unsupported stat",
+		req: &saipb.GetQueueStatsRequest{
+			Oid:        1,
+			CounterIds: []saipb.QueueStat{saipb.QueueStat_QUEUE_STAT_WATERMARK_BYTES},
+		},
+		wantErr: "unsupported",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dplane := &fakeSwitchDataplane{}
+			conn, mgr, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
+				newQueue(mgr, dplane, srv)
+			})
+			defer stopFn()
+			mgr.StoreAttributes(1, &saipb.QueueAttribute{Port: proto.Uint64(10), Index: proto.Uint32(0)})
+			got, gotErr := saipb.NewQueueClient(conn).GetQueueStats(context.Background(), tt.req)
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("GetQueueStats() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+			if d := cmp.Diff(got, tt.want, protocmp.Transform()); d != "" {
+				t.Errorf("GetQueueStats() failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
@@ -35,11 +35,6 @@ type bfd struct {
 	saipb.UnimplementedBfdServer
 }
 
-type counter struct {
-	saipb.UnimplementedCounterServer
-}
-
-
 type dtel struct {
 	saipb.UnimplementedDtelServer
 }
@@ -69,18 +64,10 @@ type mirror struct {
 	mgr *attrmgr.AttrMgr
 }
 
-type mpls struct {
-	saipb.UnimplementedMplsServer
-}
-
 type nat struct {
 	saipb.UnimplementedNatServer
 }
 
-type samplePacket struct {
-	saipb.UnimplementedSamplepacketServer
-}
-
 type srv6 struct {
 	saipb.UnimplementedSrv6Server
 }
@@ -122,9 +109,7 @@ type Server struct {
 	macsec       *macsec
 	mcastFdb     *mcastFdb
 	mirror       *mirror
-	mpls         *mpls
 	nat          *nat
-	samplePacket *samplePacket
 	srv6         *srv6
 	saiSwitch    *saiSwitch
 	systemPort   *systemPort
@@ -205,7 +190,7 @@ func New(ctx context.Context, mgr *attrmgr.AttrMgr, s *grpc.Server, opts *dplane
 		mgr:               mgr,
 		forwardingContext: fwdCtx,
 		bfd:               &bfd{},
-		counter:           &counter{},
+		counter:           newCounter(mgr, fwdCtx, s),
 		debugCounter:      newDebugCounter(mgr, fwdCtx, s),
 		dtel:              &dtel{},
 		fdb:               fdb,
@@ -215,9 +200,7 @@ func New(ctx context.Context, mgr *attrmgr.AttrMgr, s *grpc.Server, opts *dplane
 		macsec:            &macsec{},
 		mcastFdb:          &mcastFdb{},
 		mirror:            &mirror{mgr: mgr},
-		mpls:              &mpls{},
 		nat:               &nat{},
-		samplePacket:      &samplePacket{},
 		srv6:              &srv6{},
 		saiSwitch:         sw,
 		systemPort:        &systemPort{},
@@ -228,7 +211,6 @@ func New(ctx context.Context, mgr *attrmgr.AttrMgr, s *grpc.Server, opts *dplane
 	fwdpb.RegisterInfoServer(s, fwdCtx)
 	saipb.RegisterEntrypointServer(s, srv)
 	saipb.RegisterBfdServer(s, srv.bfd)
-	saipb.RegisterCounterServer(s, srv.counter)
 	saipb.RegisterDtelServer(s, srv.dtel)
 	saipb.RegisterIpmcGroupServer(s, srv.ipmcGroup)
 	saipb.RegisterIpmcServer(s, srv.ipmc)
@@ -236,9 +218,7 @@ func New(ctx context.Context, mgr *attrmgr.AttrMgr, s *grpc.Server, opts *dplane
 	saipb.RegisterMacsecServer(s, srv.macsec)
 	saipb.RegisterMcastFdbServer(s, srv.mcastFdb)
 	saipb.RegisterMirrorServer(s, srv.mirror)
-	saipb.RegisterMplsServer(s, srv.mpls)
 	saipb.RegisterNatServer(s, srv.nat)
-	saipb.RegisterSamplepacketServer(s, srv.samplePacket)
 	saipb.RegisterSrv6Server(s, srv.srv6)
 	saipb.RegisterSystemPortServer(s, srv.systemPort)
 	saipb.RegisterTamServer(s, srv.tam)
@@ -0,0 +1,264 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+const (
+	ingressSampleTable = "ingress-sample-table"
+	egressSampleTable  = "egress-sample-table"
+)
+
+// samplepacketBinding is a port and direction a samplepacket is bound to.
+type samplepacketBinding struct {
+	port    uint64
+	ingress bool
+}
+
+// samplepacket samples the packets received or transmitted by ports, and punts
+// the sampled packets to the CPU with the samplepacket trap.
+type samplepacket struct {
+	saipb.UnimplementedSamplepacketServer
+	mgr       *attrmgr.AttrMgr
+	dataplane switchDataplaneAPI
+	hostif    *hostif
+
+	mu       sync.Mutex
+	tables   bool                           // true if the sample tables are created.
+	bindings map[samplepacketBinding]uint64 // The samplepacket bound to a port and direction.
+}
+
+func newSamplepacket(mgr *attrmgr.AttrMgr, dataplane switchDataplaneAPI, s *grpc.Server, hostif *hostif) *samplepacket {
+	sp := &samplepacket{
+		mgr:       mgr,
+		dataplane: dataplane,
+		hostif:    hostif,
+		bindings:  map[samplepacketBinding]uint64{},
+	}
+	saipb.RegisterSamplepacketServer(s, sp)
+	return sp
+}
+
+func (sp *samplepacket) Reset() {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.tables = false
+	sp.bindings = map[samplepacketBinding]uint64{}
+}
+
+// CreateSamplepacket creates a samplepacket, only slow path sampling is supported.
+func (sp *samplepacket) CreateSamplepacket(_ context.Context, req *saipb.CreateSamplepacketRequest) (*saipb.CreateSamplepacketResponse, error) {
+	if req.GetSampleRate() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "sample rate must be positive")
+	}
+	switch req.GetType() {
+	case saipb.SamplepacketType_SAMPLEPACKET_TYPE_UNSPECIFIED, saipb.SamplepacketType_SAMPLEPACKET_TYPE_SLOW_PATH:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported samplepacket type %v", req.GetType())
+	}
+	return &saipb.CreateSamplepacketResponse{Oid: sp.mgr.NextID()}, nil
+}
+
+// RemoveSamplepacket removes a samplepacket that is not bound to any port.
+func (sp *samplepacket) RemoveSamplepacket(_ context.Context, req *saipb.RemoveSamplepacketRequest) (*saipb.RemoveSamplepacketResponse, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	for b, oid := range sp.bindings {
+		if oid == req.GetOid() {
+			return nil, status.Errorf(codes.FailedPrecondition, "samplepacket %d is bound to port %d", oid, b.port)
+		}
+	}
+	return &saipb.RemoveSamplepacketResponse{}, nil
+}
+
+// SetSamplepacketAttribute updates the sample rate of the ports the samplepacket is bound to.
+func (sp *samplepacket) SetSamplepacketAttribute(ctx context.Context, req *saipb.SetSamplepacketAttributeRequest) (*saipb.SetSamplepacketAttributeResponse, error) {
+	if req.SampleRate != nil && req.GetSampleRate() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "sample rate must be positive")
+	}
+	sp.mgr.StoreAttributes(req.GetOid(), req)
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	for b, oid := range sp.bindings {
+		if oid != req.GetOid() {
+			continue
+		}
+		if err := sp.bind(ctx, b, oid); err != nil {
+			return nil, err
+		}
+	}
+	return &saipb.SetSamplepacketAttributeResponse{}, nil
+}
+
+// bindPort binds the samplepacket to the port in the direction, replacing the previously bound samplepacket.
+// An oid of 0 unbinds the samplepacket.
+func (sp *samplepacket) bindPort(ctx context.Context, portID uint64, ingress bool, oid uint64) error {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	b := samplepacketBinding{port: portID, ingress: ingress}
+	if oid == 0 {
+		if _, ok := sp.bindings[b]; !ok {
+			return nil
+		}
+		table, entry, err := sp.entry(ctx, b)
+		if err != nil {
+			return err
+		}
+		req := fwdconfig.TableEntryRemoveRequest(sp.dataplane.ID(), table).AppendEntry(entry).Build()
+		if _, err := sp.dataplane.TableEntryRemove(ctx, req); err != nil {
+			return err
+		}
+		delete(sp.bindings, b)
+		return nil
+	}
+	if err := sp.bind(ctx, b, oid); err != nil {
+		return err
+	}
+	sp.bindings[b] = oid
+	return nil
+}
+
+// entry returns the sample table and the entry of the binding: ingress packets are matched by
+// their input port, and egress packets by their output port.
+func (sp *samplepacket) entry(ctx context.Context, b samplepacketBinding) (string, *fwdconfig.EntryDescBuilder, error) {
+	nid, err := sp.dataplane.ObjectNID(ctx, &fwdpb.ObjectNIDRequest{
+		ContextId: &fwdpb.ContextId{Id: sp.dataplane.ID()},
+		ObjectId:  &fwdpb.ObjectId{Id: fmt.Sprint(b.port)},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	table, field := ingressSampleTable, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT
+	if !b.ingress {
+		table, field = egressSampleTable, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_OUTPUT
+	}
+	return table, fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(field).WithUint64(nid.GetNid()))), nil
+}
+
+// bind adds the entry that samples the packets of the binding at the rate of the samplepacket,
+// replacing the existing entry. It must be called with the lock held.
+//
+// One in sample rate packets is selected at random, and a copy of the packet is punted to the CPU.
+// The copy of an egress packet carries the output port, like the packets punted with COPY AND FORWARD.
+func (sp *samplepacket) bind(ctx context.Context, b samplepacketBinding, oid uint64) error {
+	attr := &saipb.SamplepacketAttribute{}
+	if err := sp.mgr.PopulateAllAttributes(fmt.Sprint(oid), attr); err != nil {
+		return err
+	}
+	trapID := sp.hostif.sampleTrapID.Load()
+	if trapID == 0 {
+		return status.Errorf(codes.FailedPrecondition, "samplepacket trap is not created")
+	}
+	swAttr := &saipb.GetSwitchAttributeResponse{}
+	if err := sp.mgr.PopulateAttributes(&saipb.GetSwitchAttributeRequest{Oid: switchID, AttrType: []saipb.SwitchAttr{saipb.SwitchAttr_SWITCH_ATTR_CPU_PORT}}, swAttr); err != nil {
+		return err
+	}
+	if err := sp.createTables(ctx); err != nil {
+		return err
+	}
+	table, entry, err := sp.entry(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	copyActions := []*fwdconfig.ActionBuilder{
+		fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64Value(trapID)),
+	}
+	if !b.ingress {
+		copyActions = append(copyActions,
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TARGET_EGRESS_PORT).WithUint64Value(b.port)))
+	}
+	punt := fwdconfig.Action(fwdconfig.MirrorAction().
+		WithPort(fmt.Sprint(swAttr.GetAttr().GetCpuPort()), fwdpb.PortAction_PORT_ACTION_OUTPUT).
+		WithFields(fwdconfig.PacketFieldIDField(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT, 0)).
+		WithActions(copyActions...)).Build()
+
+	lists := []*fwdpb.ActionList{{Actions: []*fwdpb.ActionDesc{punt}, Weight: 1}}
+	if rate := uint64(attr.GetSampleRate()); rate > 1 {
+		lists = append(lists, &fwdpb.ActionList{Weight: rate - 1})
+	}
+
+	req := fwdconfig.TableEntryAddRequest(sp.dataplane.ID(), table).AppendEntry(entry).Build()
+	req.Entries[0].Actions = []*fwdpb.ActionDesc{{
+		ActionType: fwdpb.ActionType_ACTION_TYPE_SELECT_ACTION_LIST,
+		Action: &fwdpb.ActionDesc_Select{
+			Select: &fwdpb.SelectActionListActionDesc{
+				SelectAlgorithm: fwdpb.SelectActionListActionDesc_SELECT_ALGORITHM_RANDOM,
+				ActionLists:     lists,
+			},
+		},
+	}}
+	_, err = sp.dataplane.TableEntryAdd(ctx, req)
+	return err
+}
+
+// createTables creates the ingress and egress sample tables, and adds their lookups
+// to the pre-ingress and egress action tables. It must be called with the lock held.
+func (sp *samplepacket) createTables(ctx context.Context) error {
+	if sp.tables {
+		return nil
+	}
+	for _, t := range []struct {
+		id     string
+		field  fwdpb.PacketFieldNum
+		action string
+	}{
+		{ingressSampleTable, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT, PreIngressActionTable},
+		{egressSampleTable, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_OUTPUT, EgressActionTable},
+	} {
+		_, err := sp.dataplane.TableCreate(ctx, &fwdpb.TableCreateRequest{
+			ContextId: &fwdpb.ContextId{Id: sp.dataplane.ID()},
+			Desc: &fwdpb.TableDesc{
+				TableType: fwdpb.TableType_TABLE_TYPE_EXACT,
+				TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: t.id}},
+				Actions:   []*fwdpb.ActionDesc{{ActionType: fwdpb.ActionType_ACTION_TYPE_CONTINUE}},
+				Table: &fwdpb.TableDesc_Exact{
+					Exact: &fwdpb.ExactTableDesc{
+						FieldIds: []*fwdpb.PacketFieldId{{Field: &fwdpb.PacketField{FieldNum: t.field}}},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		// Sample before the other actions, so that the sampled packets are not affected by them.
+		_, err = sp.dataplane.TableEntryAdd(ctx, fwdconfig.TableEntryAddRequest(sp.dataplane.ID(), t.action).
+			AppendEntry(
+				fwdconfig.EntryDesc(fwdconfig.ActionEntry("sample", fwdpb.ActionEntryDesc_INSERT_METHOD_PREPEND)),
+				fwdconfig.LookupAction(t.id)).
+			Build(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	sp.tables = true
+	return nil
+}
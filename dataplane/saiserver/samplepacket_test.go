@@ -0,0 +1,142 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saiserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/openconfig/lemming/dataplane/dplaneopts"
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+)
+
+func TestSamplepacketBindPort(t *testing.T) {
+	tests := []struct {
+		desc      string
+		ingress   bool
+		rate      uint32
+		trap      bool
+		wantTable string
+		wantField fwdpb.PacketFieldNum
+		wantCopy  []*fwdconfig.ActionBuilder
+		wantLists int
+		wantErr   string
+	}{{
+		desc:      "ingress",
+		ingress:   true,
+		rate:      100,
+		trap:      true,
+		wantTable: ingressSampleTable,
+		wantField: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT,
+		wantCopy: []*fwdconfig.ActionBuilder{
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64Value(5)),
+		},
+		wantLists: 2,
+	}, {
+		desc:      "egress every packet",
+		rate:      1,
+		trap:      true,
+		wantTable: egressSampleTable,
+		wantField: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_OUTPUT,
+		wantCopy: []*fwdconfig.ActionBuilder{
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64Value(5)),
+			fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TARGET_EGRESS_PORT).WithUint64Value(10)),
+		},
+		wantLists: 1,
+	}, {
+		desc:    "no samplepacket trap",
+		ingress: true,
+		rate:    100,
+		wantErr: "trap is not created",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dplane := &fakeSwitchDataplane{}
+			var sp *samplepacket
+			conn, mgr, stopFn := newTestServer(t, func(mgr *attrmgr.AttrMgr, srv *grpc.Server) {
+				sp = newSamplepacket(mgr, dplane, srv, newHostif(mgr, dplane, srv, &dplaneopts.Options{}))
+			})
+			defer stopFn()
+			mgr.StoreAttributes(switchID, &saipb.SwitchAttribute{CpuPort: proto.Uint64(2)})
+			if tt.trap {
+				sp.hostif.sampleTrapID.Store(5)
+			}
+			ctx := context.Background()
+			resp, err := saipb.NewSamplepacketClient(conn).CreateSamplepacket(ctx, &saipb.CreateSamplepacketRequest{
+				SampleRate: proto.Uint32(tt.rate),
+			})
+			if err != nil {
+				t.Fatalf("CreateSamplepacket() unexpected err: %v", err)
+			}
+			gotErr := sp.bindPort(ctx, 10, tt.ingress, resp.GetOid())
+			if diff := errdiff.Check(gotErr, tt.wantErr); diff != "" {
+				t.Fatalf("bindPort() unexpected err: %s", diff)
+			}
+			if gotErr != nil {
+				return
+			}
+
+			entry := fwdconfig.EntryDesc(fwdconfig.ExactEntry(fwdconfig.PacketFieldBytes(tt.wantField).WithUint64(10)))
+			punt := fwdconfig.Action(fwdconfig.MirrorAction().
+				WithPort("2", fwdpb.PortAction_PORT_ACTION_OUTPUT).
+				WithFields(fwdconfig.PacketFieldIDField(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT, 0)).
+				WithActions(tt.wantCopy...)).Build()
+			lists := []*fwdpb.ActionList{{Actions: []*fwdpb.ActionDesc{punt}, Weight: 1}}
+			if tt.wantLists == 2 {
+				lists = append(lists, &fwdpb.ActionList{Weight: uint64(tt.rate) - 1})
+			}
+			want := fwdconfig.TableEntryAddRequest("foo", tt.wantTable).AppendEntry(entry).Build()
+			want.Entries[0].Actions = []*fwdpb.ActionDesc{{
+				ActionType: fwdpb.ActionType_ACTION_TYPE_SELECT_ACTION_LIST,
+				Action: &fwdpb.ActionDesc_Select{
+					Select: &fwdpb.SelectActionListActionDesc{
+						SelectAlgorithm: fwdpb.SelectActionListActionDesc_SELECT_ALGORITHM_RANDOM,
+						ActionLists:     lists,
+					},
+				},
+			}}
+			// The first two entries hook the sample tables into the action tables.
+			if got := len(dplane.gotEntryAddReqs); got != 3 {
+				t.Fatalf("bindPort() got %d entry adds, want 3", got)
+			}
+			if d := cmp.Diff(dplane.gotEntryAddReqs[2], want, protocmp.Transform()); d != "" {
+				t.Errorf("bindPort() failed: diff(-got,+want)\n:%s", d)
+			}
+
+			if _, err := saipb.NewSamplepacketClient(conn).RemoveSamplepacket(ctx, &saipb.RemoveSamplepacketRequest{Oid: resp.GetOid()}); err == nil {
+				t.Errorf("RemoveSamplepacket() got no error for bound samplepacket")
+			}
+			if err := sp.bindPort(ctx, 10, tt.ingress, 0); err != nil {
+				t.Fatalf("bindPort() unexpected err: %v", err)
+			}
+			wantRemove := []*fwdpb.TableEntryRemoveRequest{
+				fwdconfig.TableEntryRemoveRequest("foo", tt.wantTable).AppendEntry(entry).Build(),
+			}
+			if d := cmp.Diff(dplane.gotEntryRemoveReqs, wantRemove, protocmp.Transform()); d != "" {
+				t.Errorf("bindPort() unbind failed: diff(-got,+want)\n:%s", d)
+			}
+		})
+	}
+}
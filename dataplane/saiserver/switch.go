@@ -51,6 +51,7 @@ type saiSwitch struct {
 	l2mc            *l2mc
 	l2mcGroup       *l2mcGroup
 	myMac           *myMac
+	mpls            *mpls
 	neighbor        *neighbor
 	nextHopGroup    *nextHopGroup
 	nextHop         *nextHop
@@ -65,6 +66,7 @@ type saiSwitch struct {
 	udf             *udf
 	scheduler       *scheduler
 	qosMap          *qosMap
+	samplepacket    *samplepacket
 	rpf             *rpfGroup
 	wred            *wred
 	mgr             *attrmgr.AttrMgr
@@ -172,6 +174,7 @@ const (
 	IngressVRFTable       = "ingress-vrf"
 	FIBV4Table            = "fib-v4"
 	FIBV6Table            = "fib-v6"
+	MPLSFIBTable          = "fib-mpls"
 	SRCMACTable           = "port-mac"
 	FIBSelectorTable      = "fib-selector"
 	NeighborTable         = "neighbor"
@@ -205,7 +208,10 @@ func newSwitch(mgr *attrmgr.AttrMgr, engine switchDataplaneAPI, s *grpc.Server,
 	vlan := newVlan(mgr, dplane, s)
 	q := newQueue(mgr, dplane, s)
 	sg := newSchedulerGroup(mgr, dplane, s)
-	port, err := newPort(mgr, dplane, s, vlan, q, sg, opts)
+	qm := newQOSMap(mgr, dplane, s, q)
+	hostif := newHostif(mgr, engine, s, opts)
+	sp := newSamplepacket(mgr, dplane, s, hostif)
+	port, err := newPort(mgr, dplane, s, vlan, q, sg, qm, sp, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -219,12 +225,13 @@ func newSwitch(mgr *attrmgr.AttrMgr, engine switchDataplaneAPI, s *grpc.Server,
 		vlan:            vlan,
 		stp:             &stp{},
 		bridge:          newBridge(mgr, engine, s),
-		hostif:          newHostif(mgr, engine, s, opts),
+		hostif:          hostif,
 		hash:            newHash(mgr, engine, s),
 		isolationGroup:  newIsolationGroup(mgr, engine, s),
 		l2mc:            newL2mc(mgr, engine, s),
 		l2mcGroup:       newL2mcGroup(mgr, engine, s),
 		myMac:           newMyMac(mgr, engine, s, opts),
+		mpls:            newMPLS(mgr, engine, s),
 		neighbor:        newNeighbor(mgr, engine, s),
 		nextHopGroup:    newNextHopGroup(mgr, engine, s),
 		nextHop:         newNextHop(mgr, engine, s),
@@ -233,12 +240,13 @@ func newSwitch(mgr *attrmgr.AttrMgr, engine switchDataplaneAPI, s *grpc.Server,
 		Lag:             newLAG(mgr, engine, s),
 		tunnel:          newTunnel(mgr, engine, s),
 		udf:             newUdf(mgr, engine, s),
-		scheduler:       newScheduler(mgr, engine, s),
-		qosMap:          newQOSMap(mgr, engine, s),
+		scheduler:       newScheduler(mgr, engine, s, q),
+		qosMap:          qm,
+		samplepacket:    sp,
 		virtualRouter:   newVirtualRouter(mgr, engine, s),
 		rpf:             newRpfGroup(mgr, engine, s),
 		buffer:          newBuffer(mgr, engine, s),
-		wred:            newWRED(mgr, engine, s),
+		wred:            newWRED(mgr, engine, s, q),
 		queue:           q,
 		sg:              sg,
 		mgr:             mgr,
@@ -385,6 +393,28 @@ func (sw *saiSwitch) CreateSwitch(ctx context.Context, _ *saipb.CreateSwitchRequ
 	if _, err := sw.dataplane.TableCreate(ctx, v6FIB); err != nil {
 		return nil, err
 	}
+	mplsFIB := &fwdpb.TableCreateRequest{
+		ContextId: &fwdpb.ContextId{Id: sw.dataplane.ID()},
+		Desc: &fwdpb.TableDesc{
+			TableType: fwdpb.TableType_TABLE_TYPE_EXACT,
+			TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+			Actions: []*fwdpb.ActionDesc{
+				fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{0})).Build(),
+			},
+			Table: &fwdpb.TableDesc_Exact{
+				Exact: &fwdpb.ExactTableDesc{
+					FieldIds: []*fwdpb.PacketFieldId{{
+						Field: &fwdpb.PacketField{
+							FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_MPLS_LABEL,
+						},
+					}},
+				},
+			},
+		},
+	}
+	if _, err := sw.dataplane.TableCreate(ctx, mplsFIB); err != nil {
+		return nil, err
+	}
 	portMAC := &fwdpb.TableCreateRequest{
 		ContextId: &fwdpb.ContextId{Id: sw.dataplane.ID()},
 		Desc: &fwdpb.TableDesc{
@@ -951,6 +981,15 @@ func (sw *saiSwitch) CreateSwitch(ctx context.Context, _ *saipb.CreateSwitchRequ
 	}, nil
 }
 
+// Priorities of the rules in the invalid ingress tables, lower values have higher priority.
+// Packets with an invalid address are dropped even if their TTL would expire, instead of being
+// punted by the TTL error trap.
+const (
+	invalidAddrDropPriority = 0
+	ttlErrorTrapPriority    = 1
+	ttlDropPriority         = 2
+)
+
 // Set up rules to drop packets that contain invalid IP or ttl == 0.
 // https://www.rfc-editor.org/rfc/rfc1812#section-5.3.7
 func (sw *saiSwitch) createInvalidPacketFilter(ctx context.Context) error {
@@ -992,7 +1031,7 @@ func (sw *saiSwitch) createInvalidPacketFilter(ctx context.Context) error {
 				}
 				req := fwdconfig.TableEntryAddRequest(sw.dataplane.ID(), table).
 					AppendEntry(
-						fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(field).WithBytes(prefix.IP, prefix.Mask)).WithPriority(20)),
+						fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(field).WithBytes(prefix.IP, prefix.Mask)).WithPriority(invalidAddrDropPriority)),
 						fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{0}),
 					).Build()
 				if _, err := sw.dataplane.TableEntryAdd(ctx, req); err != nil {
@@ -1031,9 +1070,10 @@ func (sw *saiSwitch) createInvalidPacketFilter(ctx context.Context) error {
 			}
 		}
 		// Before the TTL is decremented and after the packets may be punted, drop packet with TTL == 1 or TTL == 0.
+		// Packets with TTL == 1 are punted instead if a TTL error trap is created.
 		req := fwdconfig.TableEntryAddRequest(sw.dataplane.ID(), table).
 			AppendEntry(
-				fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP).WithBytes([]byte{0x00}, []byte{0xFF}))),
+				fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP).WithBytes([]byte{0x00}, []byte{0xFF})).WithPriority(ttlDropPriority)),
 				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{0}),
 			).Build()
 		if _, err := sw.dataplane.TableEntryAdd(ctx, req); err != nil {
@@ -1041,7 +1081,7 @@ func (sw *saiSwitch) createInvalidPacketFilter(ctx context.Context) error {
 		}
 		req = fwdconfig.TableEntryAddRequest(sw.dataplane.ID(), table).
 			AppendEntry(
-				fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP).WithBytes([]byte{0x01}, []byte{0xFF}))),
+				fwdconfig.EntryDesc(fwdconfig.FlowEntry(fwdconfig.PacketFieldMaskedBytes(fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP).WithBytes([]byte{0x01}, []byte{0xFF})).WithPriority(ttlDropPriority)),
 				fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(1, 0).WithValue([]byte{0}),
 			).Build()
 		if _, err := sw.dataplane.TableEntryAdd(ctx, req); err != nil {
@@ -1233,6 +1273,7 @@ func (sw *saiSwitch) Reset() {
 	sw.vlan.Reset()
 	sw.port.Reset()
 	sw.hostif.Reset()
+	sw.samplepacket.Reset()
 }
 
 // GetSwitchStats returns the statistics for the switch.
@@ -1280,10 +1321,11 @@ func (sw *saiSwitch) GetSwitchStats(ctx context.Context, req *saipb.GetSwitchSta
 }
 
 // createFIBSelector creates a table that controls which forwarding table is used.
+// The ethertype is used instead of the IP version, since labelled packets may also carry IP.
 func (sw *saiSwitch) createFIBSelector(ctx context.Context) error {
 	fieldID := &fwdpb.PacketFieldId{
 		Field: &fwdpb.PacketField{
-			FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_VERSION,
+			FieldNum: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE,
 		},
 	}
 
@@ -1319,6 +1361,14 @@ func (sw *saiSwitch) createFIBSelector(ctx context.Context) error {
 			},
 		},
 	}}
+	mplsActs := []*fwdpb.ActionDesc{{
+		ActionType: fwdpb.ActionType_ACTION_TYPE_LOOKUP,
+		Action: &fwdpb.ActionDesc_Lookup{
+			Lookup: &fwdpb.LookupActionDesc{
+				TableId: &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: MPLSFIBTable}},
+			},
+		},
+	}}
 
 	entries := &fwdpb.TableEntryAddRequest{
 		ContextId: &fwdpb.ContextId{Id: sw.dataplane.ID()},
@@ -1332,7 +1382,7 @@ func (sw *saiSwitch) createFIBSelector(ctx context.Context) error {
 				Entry: &fwdpb.EntryDesc_Exact{
 					Exact: &fwdpb.ExactEntryDesc{
 						Fields: []*fwdpb.PacketFieldBytes{{
-							Bytes:   []byte{0x4},
+							Bytes:   []byte{0x08, 0x00},
 							FieldId: fieldID,
 						}},
 					},
@@ -1344,13 +1394,25 @@ func (sw *saiSwitch) createFIBSelector(ctx context.Context) error {
 				Entry: &fwdpb.EntryDesc_Exact{
 					Exact: &fwdpb.ExactEntryDesc{
 						Fields: []*fwdpb.PacketFieldBytes{{
-							Bytes:   []byte{0x6},
+							Bytes:   []byte{0x86, 0xdd},
 							FieldId: fieldID,
 						}},
 					},
 				},
 			},
 			Actions: v6Acts,
+		}, {
+			EntryDesc: &fwdpb.EntryDesc{
+				Entry: &fwdpb.EntryDesc_Exact{
+					Exact: &fwdpb.ExactEntryDesc{
+						Fields: []*fwdpb.PacketFieldBytes{{
+							Bytes:   []byte{0x88, 0x47},
+							FieldId: fieldID,
+						}},
+					},
+				},
+			},
+			Actions: mplsActs,
 		}},
 	}
 	if _, err := sw.dataplane.TableEntryAdd(ctx, entries); err != nil {
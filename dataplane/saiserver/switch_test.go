@@ -496,3 +496,45 @@ func TestLpmMissCountersScenario(t *testing.T) {
 		t.Errorf("Expected 42, got %v", stats.GetValues())
 	}
 }
+
+func TestInvalidPacketFilterPriority(t *testing.T) {
+	dplane := &fakeSwitchDataplane{}
+	c, _, stopFn := newTestSwitch(t, dplane)
+	defer stopFn()
+
+	if _, err := c.CreateSwitch(context.TODO(), &saipb.CreateSwitchRequest{}); err != nil {
+		t.Fatalf("CreateSwitch() failed: %v", err)
+	}
+
+	var addrDrops, ttlDrops int
+	for _, req := range dplane.gotEntryAddReqs {
+		if table := req.GetTableId().GetObjectId().GetId(); table != invalidIngressV4Table && table != invalidIngressV6Table {
+			continue
+		}
+		for _, e := range req.GetEntries() {
+			flow := e.GetEntryDesc().GetFlow()
+			// Skip the hop-by-hop rules, which match the packets already trapped.
+			if len(flow.GetFields()) != 1 {
+				continue
+			}
+			for _, f := range flow.GetFields() {
+				switch f.GetFieldId().GetField().GetFieldNum() {
+				case fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_SRC, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_DST:
+					addrDrops++
+					// A TTL=1 packet with an invalid address must be dropped, not punted by the TTL error trap.
+					if flow.GetPriority() >= ttlErrorTrapPriority {
+						t.Errorf("invalid address entry %v has priority %d, want lower than the TTL error trap priority %d", f, flow.GetPriority(), ttlErrorTrapPriority)
+					}
+				case fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP:
+					ttlDrops++
+					if flow.GetPriority() <= ttlErrorTrapPriority {
+						t.Errorf("ttl drop entry %v has priority %d, want higher than the TTL error trap priority %d", f, flow.GetPriority(), ttlErrorTrapPriority)
+					}
+				}
+			}
+		}
+	}
+	if addrDrops == 0 || ttlDrops == 0 {
+		t.Errorf("CreateSwitch() got %d invalid address entries and %d ttl drop entries, want both", addrDrops, ttlDrops)
+	}
+}
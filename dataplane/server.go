@@ -19,6 +19,9 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/netip"
+	"sync"
+	"time"
 
 	"github.com/openconfig/ygnmi/ygnmi"
 	"google.golang.org/grpc"
@@ -29,6 +32,7 @@ import (
 	"github.com/openconfig/lemming/dataplane/dplaneopts"
 	_ "github.com/openconfig/lemming/dataplane/kernel/tap"
 	"github.com/openconfig/lemming/dataplane/protocol"
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
 	"github.com/openconfig/lemming/dataplane/saiserver"
 	"github.com/openconfig/lemming/dataplane/saiserver/attrmgr"
 	"github.com/openconfig/lemming/dataplane/standalone/pkthandler/pktiohandler"
@@ -39,6 +43,7 @@ import (
 
 	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
 	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
 )
 
 // Dataplane is an implementation of Dataplane HAL API.
@@ -50,6 +55,22 @@ type Dataplane struct {
 	opt         *dplaneopts.Options
 	cancelFn    func()
 	pr          *protocol.Registry
+	swID        uint64
+
+	// mu protects prober and intfs, which are set by Start.
+	mu     sync.Mutex
+	prober *icmp.Prober
+	intfs  interfaces
+}
+
+// interfaces is the reconciler of the interfaces and network instances.
+type interfaces interface {
+	// VRF returns the OID of the virtual router of a network instance.
+	VRF(niName string) (uint64, bool)
+	ClearNeighbors(ctx context.Context, intf string, match func(netip.Addr) bool) error
+	ClearLLDPInterface(ctx context.Context, intf string) error
+	ClearLabelCounters(ctx context.Context, labels []uint32) error
+	ResetISISRoutes(ctx context.Context) error
 }
 
 // New create a new dataplane instance.
@@ -100,6 +121,7 @@ func (d *Dataplane) Start(ctx context.Context, c gpb.GNMIClient, target string)
 	if err != nil {
 		return err
 	}
+	d.swID = swResp.Oid
 	swAttrs, err := sw.GetSwitchAttribute(ctx, &saipb.GetSwitchAttributeRequest{
 		Oid: swResp.Oid,
 		AttrType: []saipb.SwitchAttr{
@@ -161,6 +183,54 @@ func (d *Dataplane) Start(ctx context.Context, c gpb.GNMIClient, target string)
 	if err != nil {
 		return err
 	}
+	// Punt the LACPDUs, the CPU negotiates the members of the aggregate interfaces.
+	_, err = hostif.CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       swResp.Oid,
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_LACP.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	})
+	if err != nil {
+		return err
+	}
+	// Punt the IS-IS PDUs, the CPU runs the IS-IS adjacencies and floods the LSPs.
+	_, err = hostif.CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       swResp.Oid,
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_ISIS.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Punt the routed packets that expire, the CPU replies with ICMP time exceeded messages.
+	// The trap is also the host port of the packets originated by the CPU, such as probes.
+	ttlTrap, err := hostif.CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       swResp.Oid,
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_TTL_ERROR.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Punt the packets rejected by an ACL, the CPU replies with ICMP unreachable messages.
+	aclTrap, err := hostif.CreateHostifUserDefinedTrap(ctx, &saipb.CreateHostifUserDefinedTrapRequest{
+		Switch: swResp.Oid,
+		Type:   saipb.HostifUserDefinedTrapType_HOSTIF_USER_DEFINED_TRAP_TYPE_ACL.Enum(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Punt the packets sampled by the ports, the CPU exports them to the sFlow collectors.
+	sampleTrap, err := hostif.CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       swResp.Oid,
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_SAMPLEPACKET.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	})
+	if err != nil {
+		return err
+	}
 
 	h, err := pktiohandler.New("")
 	if err != nil {
@@ -182,12 +252,25 @@ func (d *Dataplane) Start(ctx context.Context, c gpb.GNMIClient, target string)
 	if err != nil {
 		return err
 	}
+	// Packets originated by the CPU are injected with the forwarding API, which can set their VRF.
+	inj := icmp.NewForwardingInjector(ctx, fwdpb.NewForwardingClient(conn), "lucius", swAttrs.GetAttr().GetCpuPort(), ttlTrap.GetOid())
+	prober := icmp.NewProber(inj)
+	if err := d.pr.Register("icmp-probe", prober); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.prober = prober
+	d.mu.Unlock()
 	d.pr.Start()
 	go h.ManagePorts(portCtl)
 	go h.StreamPackets(d.pr)
 
 	if d.opt.Reconcilation {
-		d.reconcilers = append(d.reconcilers, getReconcilers(conn, swResp.Oid, *swAttrs.GetAttr().CpuPort, "lucius")...)
+		recs, intfs := getReconcilers(conn, swResp.Oid, *swAttrs.GetAttr().CpuPort, "lucius", d.pr, inj, ttlTrap.GetOid(), aclTrap.GetOid(), sampleTrap.GetOid(), d.opt.SysribAddr)
+		d.reconcilers = append(d.reconcilers, recs...)
+		d.mu.Lock()
+		d.intfs = intfs
+		d.mu.Unlock()
 
 		for _, rec := range d.reconcilers {
 			if err := rec.Start(ctx, c, target); err != nil {
@@ -212,6 +295,82 @@ func (d *Dataplane) SaiServer() *saiserver.Server {
 	return d.saiserv
 }
 
+// SwitchID returns the OID of the switch, it is zero until the dataplane is started.
+func (d *Dataplane) SwitchID() uint64 {
+	return d.swID
+}
+
+// Registry returns the protocol registry of the CPU port, it is nil until the dataplane is started.
+func (d *Dataplane) Registry() *protocol.Registry {
+	return d.pr
+}
+
+// interfaceHandler returns the interface handler, it is nil until the dataplane is started.
+func (d *Dataplane) interfaceHandler() interfaces {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.intfs
+}
+
+// Probe sends a probe from the CPU port in the network instance and waits for the reply,
+// it returns a nil reply if none is received.
+func (d *Dataplane) Probe(ctx context.Context, niName string, p *icmp.Probe, wait time.Duration) (*icmp.Reply, error) {
+	d.mu.Lock()
+	prober, intfs := d.prober, d.intfs
+	d.mu.Unlock()
+	if prober == nil {
+		return nil, fmt.Errorf("dataplane is not started")
+	}
+	if intfs == nil {
+		return nil, fmt.Errorf("network instances are not reconciled")
+	}
+	vrf, ok := intfs.VRF(niName)
+	if !ok {
+		return nil, fmt.Errorf("unknown network instance %q", niName)
+	}
+	probe := *p
+	probe.VRF = vrf
+	return prober.Probe(ctx, &probe, wait)
+}
+
+// ClearNeighbors removes the dynamic ARP and NDP entries whose address matches from the kernel and the dataplane.
+// If intf is not empty, only the entries of the interface are removed.
+func (d *Dataplane) ClearNeighbors(ctx context.Context, intf string, match func(netip.Addr) bool) error {
+	intfs := d.interfaceHandler()
+	if intfs == nil {
+		return fmt.Errorf("interfaces are not reconciled")
+	}
+	return intfs.ClearNeighbors(ctx, intf, match)
+}
+
+// ClearLLDPInterface resets the LLDP neighbor and counters of the interface.
+func (d *Dataplane) ClearLLDPInterface(ctx context.Context, intf string) error {
+	intfs := d.interfaceHandler()
+	if intfs == nil {
+		return fmt.Errorf("interfaces are not reconciled")
+	}
+	return intfs.ClearLLDPInterface(ctx, intf)
+}
+
+// ClearLabelCounters resets the counters of the label routes with the given incoming labels,
+// or of all label routes if labels is empty.
+func (d *Dataplane) ClearLabelCounters(ctx context.Context, labels []uint32) error {
+	intfs := d.interfaceHandler()
+	if intfs == nil {
+		return fmt.Errorf("interfaces are not reconciled")
+	}
+	return intfs.ClearLabelCounters(ctx, labels)
+}
+
+// ResetISISRoutes forgets the IS-IS routes installed in the sysrib, it must be called when the sysrib is reset.
+func (d *Dataplane) ResetISISRoutes(ctx context.Context) error {
+	intfs := d.interfaceHandler()
+	if intfs == nil {
+		return nil
+	}
+	return intfs.ResetISISRoutes(ctx)
+}
+
 // Stop gracefully stops the server.
 func (d *Dataplane) Stop(ctx context.Context) error {
 	d.cancelFn()
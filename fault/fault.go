@@ -66,6 +66,26 @@ type Interceptor struct {
 	configuredFaults map[string][]*faultpb.FaultMessage
 	// Mutex for configured faults.
 	configMu sync.Mutex
+	// onFault is called when a fault causes an RPC to return an error.
+	onFaultMu sync.Mutex
+	onFault   func(rpcMethod string, err error)
+}
+
+// OnFault sets the function that is called when an injected fault causes an RPC to return an error.
+func (i *Interceptor) OnFault(fn func(rpcMethod string, err error)) {
+	i.onFaultMu.Lock()
+	defer i.onFaultMu.Unlock()
+	i.onFault = fn
+}
+
+// reportFault calls the OnFault function, if set.
+func (i *Interceptor) reportFault(rpcMethod string, err error) {
+	i.onFaultMu.Lock()
+	fn := i.onFault
+	i.onFaultMu.Unlock()
+	if fn != nil {
+		fn(rpcMethod, err)
+	}
 }
 
 type faultMessage struct {
@@ -216,6 +236,7 @@ func (i *Interceptor) Unary(ctx context.Context, req any, info *grpc.UnaryServer
 
 		// If fault has an error status, return immediately without calling handler.
 		if faultErr != nil {
+			i.reportFault(info.FullMethod, faultErr)
 			return modifiedReq, faultErr
 		}
 		req = modifiedReq
@@ -232,11 +253,15 @@ func (i *Interceptor) Unary(ctx context.Context, req any, info *grpc.UnaryServer
 	rpcID := uuid.New().String()
 	modReq, oErr := i.sendRecvFault(sub.originMsgCh, rpcID, req, faultpb.MessageType_MESSAGE_TYPE_REQUEST, nil)
 	if oErr != nil { // If the fault client wants to return an error, don't run the handler and return.
+		i.reportFault(info.FullMethod, oErr)
 		return modReq, oErr
 	}
 	res, hErr := handler(ctx, modReq) // Run the implementation of the RPC.
 
 	modResp, err := i.sendRecvFault(sub.originMsgCh, rpcID, res, faultpb.MessageType_MESSAGE_TYPE_RESPONSE, hErr)
+	if err != nil && hErr == nil {
+		i.reportFault(info.FullMethod, err)
+	}
 	return modResp, err
 }
 
@@ -277,10 +302,14 @@ func (i *Interceptor) Stream(srv any, stream grpc.ServerStream, info *grpc.Strea
 	// Check for configured faults first - for streaming, apply fault immediately
 	if fault := i.nextConfiguredFault(info.FullMethod); fault != nil {
 		log.Infof("Applying configured stream fault for RPC %s: msg_id=%s", info.FullMethod, fault.GetMsgId())
+		err := status.Errorf(codes.Internal, "configured fault without status for %s", info.FullMethod)
 		if fault.GetStatus() != nil {
-			return status.FromProto(fault.GetStatus()).Err()
+			err = status.FromProto(fault.GetStatus()).Err()
+		}
+		if err != nil {
+			i.reportFault(info.FullMethod, err)
 		}
-		return status.Errorf(codes.Internal, "configured fault without status for %s", info.FullMethod)
+		return err
 	}
 
 	// Check for live fault subscriptions
@@ -302,6 +331,9 @@ func (i *Interceptor) Stream(srv any, stream grpc.ServerStream, info *grpc.Strea
 	// After the handler exits, there may be an additional to should be injected.
 	_, err := si.int.sendRecvFault(si.fs.originMsgCh, si.rpcID, nil, faultpb.MessageType_MESSAGE_TYPE_STREAM_END, hErr)
 	log.Infof("fault stream end, err %v", err)
+	if err != nil && hErr == nil {
+		i.reportFault(info.FullMethod, err)
+	}
 	return err
 }
 
@@ -591,6 +591,40 @@ func TestInterceptorStreamWithoutConfiguredFaults(t *testing.T) {
 	}
 }
 
+func TestInterceptorOnFault(t *testing.T) {
+	interceptor := NewInterceptor()
+	mustConfigureFaults(t, interceptor, rebootMethod, []*faultpb.FaultMessage{{
+		MsgId:  "reboot_fault",
+		Status: &statuspb.Status{Code: int32(codes.Internal), Message: "reboot failed"},
+	}})
+	mustConfigureFaults(t, interceptor, pingMethod, []*faultpb.FaultMessage{{
+		MsgId:  "ping_fault",
+		Status: &statuspb.Status{Code: int32(codes.Unavailable), Message: "ping failed"},
+	}})
+
+	var got []string
+	interceptor.OnFault(func(rpcMethod string, err error) {
+		got = append(got, fmt.Sprintf("%s: %v", rpcMethod, status.Code(err)))
+	})
+
+	unaryHandler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &spb.RebootResponse{}, nil
+	}
+	streamHandler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+	interceptor.Unary(context.Background(), &spb.RebootRequest{}, &grpc.UnaryServerInfo{FullMethod: rebootMethod}, unaryHandler)
+	interceptor.Stream(nil, nil, &grpc.StreamServerInfo{FullMethod: pingMethod}, streamHandler)
+	// The faults are exhausted, so these calls are not reported.
+	interceptor.Unary(context.Background(), &spb.RebootRequest{}, &grpc.UnaryServerInfo{FullMethod: rebootMethod}, unaryHandler)
+	interceptor.Stream(nil, nil, &grpc.StreamServerInfo{FullMethod: pingMethod}, streamHandler)
+
+	want := []string{rebootMethod + ": Internal", pingMethod + ": Unavailable"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("OnFault got calls %v, want %v", got, want)
+	}
+}
+
 // TestConfigureFaultsWithNil tests edge case with nil faults slice
 func TestConfigureFaultsWithNil(t *testing.T) {
 	interceptor := NewInterceptor()
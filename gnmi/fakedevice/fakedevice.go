@@ -50,6 +50,24 @@ func Reboot(ctx context.Context, c *ygnmi.Client, rebootTime int64) error {
 	return err
 }
 
+// SetSoftwareVersion updates the software version of the system and of the
+// chassis, supervisor, line card and fabric components to the provided version.
+func SetSoftwareVersion(ctx context.Context, c *ygnmi.Client, version string, cfg *configpb.Config) error {
+	batch := &ygnmi.SetBatch{}
+	gnmiclient.BatchReplace(batch, ocpath.Root().System().SoftwareVersion().State(), version)
+	components := []string{cfg.GetComponents().GetChassisName(), cfg.GetComponents().GetSupervisor1Name()}
+	if name := cfg.GetComponents().GetSupervisor2Name(); name != "" {
+		components = append(components, name)
+	}
+	components = append(components, config.GetAllLinecardNames(cfg)...)
+	components = append(components, config.GetAllFabricNames(cfg)...)
+	for _, name := range components {
+		gnmiclient.BatchReplace(batch, ocpath.Root().Component(name).SoftwareVersion().State(), version)
+	}
+	_, err := batch.Set(ctx, c)
+	return err
+}
+
 // RebootComponent updates the component's last reboot time and reason.
 func RebootComponent(ctx context.Context, c *ygnmi.Client, componentName string, rebootTime int64, cfg *configpb.Config) error {
 	log.Infof("Performing component reboot for %s at time %d", componentName, rebootTime)
@@ -337,11 +355,14 @@ func NewBootTimeTask(cfg *configpb.Config) *reconciler.BuiltReconciler {
 			if err := Reboot(ctx, c, now); err != nil {
 				return err
 			}
+			if _, err := gnmiclient.Replace(gnmi.AddTimestampMetadata(ctx, now), c, ocpath.Root().System().SoftwareVersion().State(), cfg.GetVendor().GetOsVersion()); err != nil {
+				return err
+			}
 			if _, err := gnmiclient.Replace(gnmi.AddTimestampMetadata(ctx, now), c, ocpath.Root().Component(chassisName).State(), &oc.Component{
 				Name:            ygot.String(chassisName),
 				Type:            oc.PlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_CHASSIS,
 				OperStatus:      oc.PlatformTypes_COMPONENT_OPER_STATUS_ACTIVE,
-				SoftwareVersion: ygot.String("current"),
+				SoftwareVersion: ygot.String(cfg.GetVendor().GetOsVersion()),
 			}); err != nil {
 				return err
 			}
@@ -382,7 +403,7 @@ func NewChassisComponentsTask(cfg *configpb.Config) *reconciler.BuiltReconciler
 					OperStatus:         oc.PlatformTypes_COMPONENT_OPER_STATUS_ACTIVE,
 					RedundantRole:      redundantRole,
 					Parent:             ygot.String(chassisName),
-					SoftwareVersion:    ygot.String("current"),
+					SoftwareVersion:    ygot.String(cfg.GetVendor().GetOsVersion()),
 					LastRebootTime:     ygot.Uint64(uint64(now)),
 					LastRebootReason:   oc.PlatformTypes_COMPONENT_REBOOT_REASON_UNSET,
 					SwitchoverReady:    ygot.Bool(true),
@@ -404,7 +425,7 @@ func NewChassisComponentsTask(cfg *configpb.Config) *reconciler.BuiltReconciler
 					Type:             oc.PlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_LINECARD,
 					OperStatus:       oc.PlatformTypes_COMPONENT_OPER_STATUS_ACTIVE,
 					Parent:           ygot.String(chassisName),
-					SoftwareVersion:  ygot.String("current"),
+					SoftwareVersion:  ygot.String(cfg.GetVendor().GetOsVersion()),
 					LastRebootTime:   ygot.Uint64(uint64(now)),
 					LastRebootReason: oc.PlatformTypes_COMPONENT_REBOOT_REASON_UNSET,
 				}
@@ -420,7 +441,7 @@ func NewChassisComponentsTask(cfg *configpb.Config) *reconciler.BuiltReconciler
 					Type:             oc.PlatformTypes_OPENCONFIG_HARDWARE_COMPONENT_FABRIC,
 					OperStatus:       oc.PlatformTypes_COMPONENT_OPER_STATUS_ACTIVE,
 					Parent:           ygot.String(chassisName),
-					SoftwareVersion:  ygot.String("current"),
+					SoftwareVersion:  ygot.String(cfg.GetVendor().GetOsVersion()),
 					LastRebootTime:   ygot.Uint64(uint64(now)),
 					LastRebootReason: oc.PlatformTypes_COMPONENT_REBOOT_REASON_UNSET,
 				}
@@ -982,3 +1003,114 @@ func restoreInterfaceOperStatus(ctx context.Context, c *ygnmi.Client, interfaceN
 	log.Infof("Restored interface operational status to %v", originalStatus)
 	return nil
 }
+
+const (
+	// bertBitRate is the simulated line rate of the PRBS pattern of a BERT.
+	bertBitRate = 10_000_000_000
+	// bertLockErrorRate is the bit error ratio from which the PRBS checker cannot lock
+	// onto the pattern, as it can no longer be told apart from noise.
+	bertLockErrorRate = 0.5
+	// bertSampleInterval is the interval at which the BERT error counts are updated.
+	bertSampleInterval = time.Second
+)
+
+// BERTResult represents the state of a bit error rate test on an interface.
+type BERTResult struct {
+	StartTime           time.Time
+	EndTime             time.Time
+	PeerLockEstablished bool
+	PeerLockLost        bool
+	ErrorCountPerMinute []uint32
+	TotalErrors         uint64
+}
+
+// RunBERT simulates a bit error rate test on an interface for the duration. The interface is in
+// the TESTING state while the test runs. The peer lock is established if the interface was up
+// and the packet error rate of the network simulation, used as the bit error ratio, is low enough.
+// Errors are counted until the test ends or until lockLost is closed.
+func RunBERT(ctx context.Context, c *ygnmi.Client, interfaceName string, duration time.Duration, lockLost <-chan struct{}, updateCallback func(*BERTResult), cfg *configpb.Config) error {
+	originalInterface, err := ygnmi.Get(ctx, c, ocpath.Root().Interface(interfaceName).State())
+	if err != nil {
+		return fmt.Errorf("failed to get interface %s: %w", interfaceName, err)
+	}
+	originalOperStatus := originalInterface.GetOperStatus()
+	defer func() {
+		if err := restoreInterfaceOperStatus(context.Background(), c, interfaceName, originalOperStatus); err != nil {
+			log.Errorf("Failed to restore interface %s status: %v", interfaceName, err)
+		}
+	}()
+
+	errorRate := float64(cfg.GetNetworkSimulation().GetPacketErrorRate())
+	result := &BERTResult{
+		StartTime:           time.Now(),
+		PeerLockEstablished: originalOperStatus == oc.Interface_OperStatus_UP && errorRate < bertLockErrorRate,
+	}
+	update := func() {
+		if updateCallback != nil {
+			r := *result
+			r.ErrorCountPerMinute = append([]uint32(nil), result.ErrorCountPerMinute...)
+			updateCallback(&r)
+		}
+	}
+	update()
+
+	if err := executeSetupPhase(ctx, c, interfaceName, 0); err != nil {
+		return fmt.Errorf("BERT on %s failed: %w", interfaceName, err)
+	}
+	log.Infof("BERT on %s: duration=%v, peer_lock=%v, error_rate=%v", interfaceName, duration, result.PeerLockEstablished, errorRate)
+
+	ticker := time.NewTicker(bertSampleInterval)
+	defer ticker.Stop()
+	endTime := result.StartTime.Add(duration)
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	for {
+		var now time.Time
+		var done, lost bool
+		select {
+		case <-ctx.Done():
+			now, done = time.Now(), true
+		case now = <-timer.C:
+			done = true
+		case now = <-ticker.C:
+		case <-lockLost:
+			now, lost = time.Now(), true
+			lockLost = nil
+		}
+		if now.After(endTime) {
+			now = endTime
+		}
+		// Errors are only counted while the peer lock is held.
+		if result.PeerLockEstablished && !result.PeerLockLost {
+			result.ErrorCountPerMinute, result.TotalErrors = bertErrors(now.Sub(result.StartTime), errorRate)
+			if lost {
+				log.Infof("BERT on %s lost the peer lock", interfaceName)
+				result.PeerLockLost = true
+			}
+		}
+		if done {
+			result.EndTime = now
+		}
+		update()
+		if done {
+			return ctx.Err()
+		}
+	}
+}
+
+// bertErrors returns the bit errors of each minute of a BERT, and their total, after elapsed
+// at the bit error ratio.
+func bertErrors(elapsed time.Duration, errorRate float64) ([]uint32, uint64) {
+	errorsPerSecond := errorRate * bertBitRate
+	var perMinute []uint32
+	var total uint64
+	for elapsed > 0 {
+		d := min(elapsed, time.Minute)
+		n := uint32(min(errorsPerSecond*d.Seconds(), math.MaxUint32))
+		perMinute = append(perMinute, n)
+		total += uint64(n)
+		elapsed -= d
+	}
+	return perMinute, total
+}
@@ -21,7 +21,7 @@ set -e
 git clone https://github.com/openconfig/public.git
 cd public && git checkout master && cd ..
 
-EXCLUDE_MODULES=ietf-interfaces,openconfig-bfd,openconfig-messages
+EXCLUDE_MODULES=ietf-interfaces,openconfig-messages
 
 YANG_FILES=(
   public/release/models/acl/openconfig-acl.yang
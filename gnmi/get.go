@@ -0,0 +1,717 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/gnmi/ctree"
+	"github.com/openconfig/gnmi/path"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/gnmi/oc"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// supportedEncodings are the encodings supported by gnmi.Get.
+var supportedEncodings = []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO}
+
+// Capabilities returns the models and encodings supported by the server.
+func (s *Server) Capabilities(context.Context, *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	ver, ok := proto.GetExtension(gpb.File_github_com_openconfig_gnmi_proto_gnmi_gnmi_proto.Options(), gpb.E_GnmiService).(string)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, "failed to get gNMI service version")
+	}
+	return &gpb.CapabilityResponse{
+		SupportedModels:    supportedModels,
+		SupportedEncodings: supportedEncodings,
+		GNMIVersion:        ver,
+	}, nil
+}
+
+// cachedLeaf is a leaf value read from the cache.
+type cachedLeaf struct {
+	elems     []*gpb.PathElem
+	val       *gpb.TypedValue
+	entry     *yang.Entry
+	config    bool
+	timestamp int64
+}
+
+// Get implements gnmi.Get by reading the values from the cache.
+//
+// Each path in the request results in a notification, paths may contain wildcards.
+// With PROTO encoding, the notification contains an update for each leaf.
+// With JSON and JSON_IETF encodings, the notification contains an update for each node matched by the path,
+// the value of the update is the JSON encoding of the subtree rooted at the node.
+// With JSON_IETF encoding, the names are qualified by their module as specified by RFC7951.
+func (s *Server) Get(ctx context.Context, req *gpb.GetRequest) (*gpb.GetResponse, error) {
+	switch req.GetEncoding() {
+	case gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO:
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported encoding %v", req.GetEncoding())
+	}
+	user, err := s.getUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := req.GetPath()
+	if len(paths) == 0 {
+		paths = []*gpb.Path{{}}
+	}
+	resp := &gpb.GetResponse{}
+	for _, p := range paths {
+		n, err := s.get(req, p, user)
+		if err != nil {
+			return nil, err
+		}
+		resp.Notification = append(resp.Notification, n)
+	}
+	return resp, nil
+}
+
+// getUser returns the user of the request, if path authorization is enabled.
+func (s *Server) getUser(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if s.pathAuth == nil || !s.pathAuth.IsInitialized() || !ok || p.Addr == nil {
+		return "", nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	user := md[usernameKey]
+	if len(user) != 1 || user[0] == "" {
+		return "", status.Errorf(codes.Unauthenticated, "no username set in metadata %v", user)
+	}
+	return user[0], nil
+}
+
+// get returns a notification containing the values at the path p.
+func (s *Server) get(req *gpb.GetRequest, p *gpb.Path, user string) (*gpb.Notification, error) {
+	prefix := req.GetPrefix()
+	if prefix.GetOrigin() != "" && p.GetOrigin() != "" {
+		return nil, status.Errorf(codes.InvalidArgument, "origin is set both in prefix and path")
+	}
+	origin := prefix.GetOrigin()
+	if origin == "" {
+		origin = p.GetOrigin()
+	}
+	if origin == "" {
+		origin = OpenConfigOrigin
+	}
+
+	elems := append(append([]*gpb.PathElem{}, prefix.GetElem()...), p.GetElem()...)
+	for i, e := range elems {
+		if e.GetName() != "..." {
+			continue
+		}
+		if i != len(elems)-1 {
+			return nil, status.Errorf(codes.Unimplemented, "multi-level wildcards are only supported at the end of the path")
+		}
+		// The cache query returns all descendant leaves so a trailing "..." is redundant.
+		elems = elems[:i]
+	}
+	reqPath := &gpb.Path{Origin: origin, Elem: elems}
+
+	var found bool
+	var leaves []*cachedLeaf
+	query := append([]string{origin}, path.ToStrings(&gpb.Path{Elem: elems}, false)...)
+	err := s.c.cache.Query(s.c.name, query, func(_ []string, l *ctree.Leaf, _ any) error {
+		n, ok := l.Value().(*gpb.Notification)
+		if !ok {
+			return nil
+		}
+		for _, u := range n.GetUpdate() {
+			found = true
+			leafElems := append(append([]*gpb.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)
+			dt, entry := s.dataType(origin, leafElems)
+			if !matchesDataType(req.GetType(), dt) {
+				continue
+			}
+			if s.pathAuth != nil && s.pathAuth.IsInitialized() && user != "" {
+				if !s.pathAuth.CheckPermit(&gpb.Path{Origin: origin, Elem: leafElems}, user, false) {
+					continue
+				}
+			}
+			leaves = append(leaves, &cachedLeaf{
+				elems:     leafElems,
+				val:       u.GetVal(),
+				entry:     entry,
+				config:    dt == gpb.GetRequest_CONFIG,
+				timestamp: n.GetTimestamp(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to query cache: %v", err)
+	}
+	if !found {
+		ps, err := ygot.PathToString(reqPath)
+		if err != nil {
+			ps = reqPath.String()
+		}
+		return nil, status.Errorf(codes.NotFound, "path %s not found", ps)
+	}
+
+	n := &gpb.Notification{
+		Prefix: &gpb.Path{Origin: origin, Target: prefix.GetTarget()},
+	}
+	for _, l := range leaves {
+		if l.timestamp > n.Timestamp {
+			n.Timestamp = l.timestamp
+		}
+	}
+	if n.Timestamp == 0 {
+		n.Timestamp = time.Now().UnixNano()
+	}
+
+	if req.GetEncoding() == gpb.Encoding_PROTO {
+		for _, l := range leaves {
+			n.Update = append(n.Update, &gpb.Update{Path: &gpb.Path{Elem: l.elems}, Val: l.val})
+		}
+		return n, nil
+	}
+
+	upds, err := s.jsonUpdates(origin, req.GetEncoding(), len(elems), leaves)
+	if err != nil {
+		return nil, err
+	}
+	n.Update = upds
+	return n, nil
+}
+
+// matchesDataType returns whether a leaf of data type dt is returned for a request of type reqType.
+// dt is ALL if the leaf is not described by the schema.
+func matchesDataType(reqType, dt gpb.GetRequest_DataType) bool {
+	switch reqType {
+	case gpb.GetRequest_ALL:
+		return true
+	case gpb.GetRequest_STATE:
+		return dt == gpb.GetRequest_STATE || dt == gpb.GetRequest_OPERATIONAL
+	default:
+		return dt == reqType
+	}
+}
+
+// dataType returns the data type and schema entry of the leaf at elems.
+//
+// Leaves under config containers are CONFIG. Leaves under state containers
+// are STATE if they are the applied value of a config leaf, otherwise they are
+// OPERATIONAL, as are all other read-only leaves.
+func (s *Server) dataType(origin string, elems []*gpb.PathElem) (gpb.GetRequest_DataType, *yang.Entry) {
+	if origin != OpenConfigOrigin || s.rootSchema == nil {
+		return gpb.GetRequest_ALL, nil
+	}
+	var parent, roParent *yang.Entry
+	roIdx := -1
+	entry := s.rootSchema
+	for i, e := range elems {
+		parent = entry
+		if entry = childEntry(entry, e.GetName()); entry == nil {
+			return gpb.GetRequest_ALL, nil
+		}
+		if roIdx == -1 && entry.Config == yang.TSFalse {
+			roIdx = i
+			roParent = parent
+		}
+	}
+	if !entry.IsLeaf() && !entry.IsLeafList() {
+		return gpb.GetRequest_ALL, nil
+	}
+	if roIdx == -1 {
+		return gpb.GetRequest_CONFIG, entry
+	}
+	if elems[roIdx].GetName() != "state" {
+		return gpb.GetRequest_OPERATIONAL, entry
+	}
+	cfg := childEntry(roParent, "config")
+	for _, e := range elems[roIdx+1:] {
+		if cfg == nil {
+			break
+		}
+		cfg = childEntry(cfg, e.GetName())
+	}
+	if cfg == nil {
+		return gpb.GetRequest_OPERATIONAL, entry
+	}
+	return gpb.GetRequest_STATE, entry
+}
+
+// childEntry returns the child of the entry with the given name, looking through choice and case statements.
+func childEntry(e *yang.Entry, name string) *yang.Entry {
+	if child, ok := e.Dir[name]; ok {
+		return child
+	}
+	for _, child := range e.Dir {
+		if !child.IsChoice() && !child.IsCase() {
+			continue
+		}
+		if c := childEntry(child, name); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// jsonList is list in the JSON tree, its entries are keyed by the key values of the list.
+type jsonList struct {
+	keys    []string
+	entries map[string]map[string]any
+}
+
+// jsonUpdates returns an update for each node at depth in the leaves' paths,
+// containing the JSON encoding of the leaves below the node.
+//
+// If the leaves are described by the schema, the JSON is rendered from GoStructs,
+// otherwise it is built from the paths of the leaves.
+func (s *Server) jsonUpdates(origin string, enc gpb.Encoding, depth int, leaves []*cachedLeaf) ([]*gpb.Update, error) {
+	var nodes []string
+	nodePaths := map[string]*gpb.Path{}
+	var vals map[string]any
+	var err error
+	if s.inSchema(origin, leaves) {
+		vals, err = s.schemaJSON(enc == gpb.Encoding_JSON_IETF, depth, leaves)
+	} else {
+		vals, err = pathJSON(depth, leaves)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range leaves {
+		node := &gpb.Path{Elem: l.elems[:depth]}
+		key, err := ygot.PathToString(node)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid path: %v", err)
+		}
+		if _, ok := nodePaths[key]; !ok {
+			nodes = append(nodes, key)
+			nodePaths[key] = node
+		}
+	}
+
+	var upds []*gpb.Update
+	for _, key := range nodes {
+		b, err := json.Marshal(vals[key])
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to marshal JSON: %v", err)
+		}
+		val := &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: b}}
+		if enc == gpb.Encoding_JSON_IETF {
+			val = &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: b}}
+		}
+		upds = append(upds, &gpb.Update{Path: nodePaths[key], Val: val})
+	}
+	return upds, nil
+}
+
+// inSchema returns whether all the leaves are described by the schema.
+func (s *Server) inSchema(origin string, leaves []*cachedLeaf) bool {
+	if origin != OpenConfigOrigin || s.configSchema == nil {
+		return false
+	}
+	for _, l := range leaves {
+		if l.entry == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// schemaJSON returns the JSON encoding of each node at depth in the leaves' paths, keyed by the path of the node.
+//
+// The config and state leaves are unmarshalled into separate roots, since the
+// GoStructs store both in the same field, and the JSON of the roots is merged.
+// With ietf set, the names are qualified by their module as specified by RFC7951.
+func (s *Server) schemaJSON(ietf bool, depth int, leaves []*cachedLeaf) (map[string]any, error) {
+	configReq, stateReq := &gpb.SetRequest{}, &gpb.SetRequest{}
+	for _, l := range leaves {
+		u := &gpb.Update{Path: &gpb.Path{Elem: l.elems}, Val: l.val}
+		if l.config {
+			configReq.Update = append(configReq.Update, u)
+		} else {
+			stateReq.Update = append(stateReq.Update, u)
+		}
+	}
+	tree := map[string]any{}
+	for _, r := range []struct {
+		req    *gpb.SetRequest
+		config bool
+	}{{configReq, true}, {stateReq, false}} {
+		if len(r.req.GetUpdate()) == 0 {
+			continue
+		}
+		schema := &ytypes.Schema{
+			Root:       &oc.Root{},
+			SchemaTree: s.configSchema.SchemaTree,
+			Unmarshal:  s.configSchema.Unmarshal,
+		}
+		var opts []ytypes.UnmarshalOpt
+		if r.config {
+			opts = append(opts, &ytypes.PreferShadowPath{})
+		}
+		if err := ytypes.UnmarshalSetRequest(schema, r.req, opts...); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to unmarshal cached values: %v", err)
+		}
+		j, err := ygot.ConstructIETFJSON(schema.Root, &ygot.RFC7951JSONConfig{
+			AppendModuleName: ietf,
+			PreferShadowPath: r.config,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to construct JSON: %v", err)
+		}
+		mergeJSON(tree, j, s.rootSchema)
+	}
+
+	vals := map[string]any{}
+	for _, l := range leaves {
+		node := &gpb.Path{Elem: l.elems[:depth]}
+		key, err := ygot.PathToString(node)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid path: %v", err)
+		}
+		if _, ok := vals[key]; ok {
+			continue
+		}
+		v, ok := jsonNode(tree, node.GetElem())
+		if !ok {
+			return nil, status.Errorf(codes.Internal, "node %s not found in JSON", key)
+		}
+		vals[key] = v
+	}
+	return vals, nil
+}
+
+// mergeJSON merges the JSON tree src into dst, the entries of lists are merged by their keys.
+func mergeJSON(dst, src map[string]any, entry *yang.Entry) {
+	for k, sv := range src {
+		var child *yang.Entry
+		if entry != nil {
+			child = childEntry(entry, localName(k))
+		}
+		switch sv := sv.(type) {
+		case map[string]any:
+			if dv, ok := dst[k].(map[string]any); ok {
+				mergeJSON(dv, sv, child)
+				continue
+			}
+		case []any:
+			if dv, ok := dst[k].([]any); ok && child != nil && child.Key != "" {
+				dst[k] = mergeList(dv, sv, child)
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
+
+// mergeList merges the entries of the list src into dst, matching them by the keys of the list entry.
+func mergeList(dst, src []any, entry *yang.Entry) []any {
+	keys := strings.Fields(entry.Key)
+	for _, sv := range src {
+		se, ok := sv.(map[string]any)
+		if !ok {
+			dst = append(dst, sv)
+			continue
+		}
+		merged := false
+		for _, dv := range dst {
+			de, ok := dv.(map[string]any)
+			if !ok || !sameKeys(de, se, keys) {
+				continue
+			}
+			mergeJSON(de, se, entry)
+			merged = true
+			break
+		}
+		if !merged {
+			dst = append(dst, se)
+		}
+	}
+	return dst
+}
+
+// sameKeys returns whether the list entries a and b have the same values for the keys.
+func sameKeys(a, b map[string]any, keys []string) bool {
+	for _, k := range keys {
+		av, aok := jsonChild(a, k)
+		bv, bok := jsonChild(b, k)
+		if !aok || !bok || fmt.Sprint(av) != fmt.Sprint(bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonNode returns the value in the JSON tree at elems.
+func jsonNode(tree map[string]any, elems []*gpb.PathElem) (any, bool) {
+	var v any = tree
+	for _, e := range elems {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = jsonChild(m, e.GetName()); !ok {
+			return nil, false
+		}
+		if len(e.GetKey()) == 0 {
+			continue
+		}
+		list, ok := v.([]any)
+		if !ok {
+			return nil, false
+		}
+		v = nil
+		for _, le := range list {
+			if m, ok := le.(map[string]any); ok && matchesKeys(m, e.GetKey()) {
+				v = m
+				break
+			}
+		}
+		if v == nil {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// matchesKeys returns whether the list entry has the key values of a path element.
+func matchesKeys(entry map[string]any, keys map[string]string) bool {
+	for k, want := range keys {
+		v, ok := jsonChild(entry, k)
+		if !ok {
+			return false
+		}
+		got := fmt.Sprint(v)
+		// Identity values may be qualified by their module.
+		if _, name, found := strings.Cut(got, ":"); got != want && (!found || name != localName(want)) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonChild returns the child of the JSON object with the name, which may be qualified by a module.
+func jsonChild(m map[string]any, name string) (any, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if localName(k) == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// localName returns the name without its module qualifier.
+func localName(name string) string {
+	if _, n, ok := strings.Cut(name, ":"); ok {
+		return n
+	}
+	return name
+}
+
+// pathJSON returns the JSON encoding of each node at depth in the leaves' paths, keyed by the path of the node.
+// The JSON is built from the paths of the leaves, so the names are not qualified by their module.
+func pathJSON(depth int, leaves []*cachedLeaf) (map[string]any, error) {
+	vals := map[string]any{}
+	for _, l := range leaves {
+		node := &gpb.Path{Elem: l.elems[:depth]}
+		key, err := ygot.PathToString(node)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid path: %v", err)
+		}
+		v, err := jsonValue(l.val, l.entry)
+		if err != nil {
+			return nil, err
+		}
+		if depth == len(l.elems) {
+			vals[key] = v
+			continue
+		}
+		tree, ok := vals[key].(map[string]any)
+		if !ok {
+			tree = map[string]any{}
+			if depth > 0 {
+				addKeys(tree, l.elems[depth-1].GetKey())
+			}
+			vals[key] = tree
+		}
+		insertJSON(tree, l.elems[depth:], v)
+	}
+	for key, v := range vals {
+		vals[key] = finalizeJSON(v)
+	}
+	return vals, nil
+}
+
+// addKeys sets the key leaves of a list entry, if they are not already set.
+func addKeys(tree map[string]any, keys map[string]string) {
+	for k, v := range keys {
+		if _, ok := tree[k]; !ok {
+			tree[k] = v
+		}
+	}
+}
+
+// insertJSON inserts the value v into the tree at elems.
+func insertJSON(tree map[string]any, elems []*gpb.PathElem, v any) {
+	for i, e := range elems {
+		if i == len(elems)-1 {
+			tree[e.GetName()] = v
+			return
+		}
+		if len(e.GetKey()) == 0 {
+			child, ok := tree[e.GetName()].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				tree[e.GetName()] = child
+			}
+			tree = child
+			continue
+		}
+		list, ok := tree[e.GetName()].(*jsonList)
+		if !ok {
+			list = &jsonList{entries: map[string]map[string]any{}}
+			tree[e.GetName()] = list
+		}
+		var keyNames []string
+		for k := range e.GetKey() {
+			keyNames = append(keyNames, k)
+		}
+		sort.Strings(keyNames)
+		var b strings.Builder
+		for _, k := range keyNames {
+			fmt.Fprintf(&b, "[%s=%s]", k, e.GetKey()[k])
+		}
+		entry, ok := list.entries[b.String()]
+		if !ok {
+			entry = map[string]any{}
+			addKeys(entry, e.GetKey())
+			list.keys = append(list.keys, b.String())
+			list.entries[b.String()] = entry
+		}
+		tree = entry
+	}
+}
+
+// finalizeJSON converts the lists in the tree to JSON arrays.
+func finalizeJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = finalizeJSON(child)
+		}
+		return v
+	case *jsonList:
+		arr := make([]any, 0, len(v.keys))
+		for _, k := range v.keys {
+			arr = append(arr, finalizeJSON(v.entries[k]))
+		}
+		return arr
+	default:
+		return v
+	}
+}
+
+// jsonValue returns the RFC7951 representation of the typed value.
+// The schema entry is used to encode 64-bit numbers as strings, it may be nil.
+func jsonValue(tv *gpb.TypedValue, entry *yang.Entry) (any, error) {
+	var kind yang.TypeKind
+	if entry != nil && entry.Type != nil {
+		kind = entry.Type.Kind
+	}
+	switch v := tv.GetValue().(type) {
+	case *gpb.TypedValue_StringVal:
+		return v.StringVal, nil
+	case *gpb.TypedValue_IntVal:
+		if kind == yang.Yint64 {
+			return strconv.FormatInt(v.IntVal, 10), nil
+		}
+		return v.IntVal, nil
+	case *gpb.TypedValue_UintVal:
+		if kind == yang.Yuint64 {
+			return strconv.FormatUint(v.UintVal, 10), nil
+		}
+		return v.UintVal, nil
+	case *gpb.TypedValue_BoolVal:
+		if kind == yang.Yempty {
+			return []any{nil}, nil
+		}
+		return v.BoolVal, nil
+	case *gpb.TypedValue_DoubleVal:
+		if kind == yang.Ydecimal64 {
+			return strconv.FormatFloat(v.DoubleVal, 'f', -1, 64), nil
+		}
+		return v.DoubleVal, nil
+	case *gpb.TypedValue_FloatVal: //nolint:staticcheck
+		return v.FloatVal, nil //nolint:staticcheck
+	case *gpb.TypedValue_DecimalVal: //nolint:staticcheck
+		return decimalString(v.DecimalVal), nil //nolint:staticcheck
+	case *gpb.TypedValue_BytesVal:
+		return v.BytesVal, nil
+	case *gpb.TypedValue_LeaflistVal:
+		var arr []any
+		for _, elem := range v.LeaflistVal.GetElement() {
+			ev, err := jsonValue(elem, entry)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, ev)
+		}
+		return arr, nil
+	case *gpb.TypedValue_JsonIetfVal:
+		return json.RawMessage(v.JsonIetfVal), nil
+	case *gpb.TypedValue_JsonVal:
+		return json.RawMessage(v.JsonVal), nil
+	default:
+		log.Warningf("unsupported typed value %T for JSON encoding", v)
+		return nil, status.Errorf(codes.Unimplemented, "unsupported typed value %T", v)
+	}
+}
+
+// decimalString returns the string representation of the decimal.
+func decimalString(d *gpb.Decimal64) string { //nolint:staticcheck
+	digits := d.GetDigits()
+	sign := ""
+	if digits < 0 {
+		sign = "-"
+		digits = -digits
+	}
+	s := strconv.FormatInt(digits, 10)
+	prec := int(d.GetPrecision())
+	if prec == 0 {
+		return sign + s
+	}
+	if len(s) <= prec {
+		s = strings.Repeat("0", prec-len(s)+1) + s
+	}
+	return sign + s[:len(s)-prec] + "." + s[len(s)-prec:]
+}
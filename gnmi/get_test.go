@@ -0,0 +1,229 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestGet(t *testing.T) {
+	gnmiServer, err := newServer(context.Background(), targetName, true)
+	if err != nil {
+		t.Fatalf("cannot create server, got err: %v", err)
+	}
+	if err := gnmiServer.c.GnmiUpdate(&gpb.Notification{
+		Prefix:    mustTargetPath(targetName, "/interfaces/interface[name=eth0]", true),
+		Timestamp: 42,
+		Update: []*gpb.Update{{
+			Path: mustPath("config/mtu"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 1500}},
+		}, {
+			Path: mustPath("state/mtu"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 1500}},
+		}, {
+			Path: mustPath("state/counters/in-pkts"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 10}},
+		}},
+	}); err != nil {
+		t.Fatalf("failed to update cache: %v", err)
+	}
+	if err := gnmiServer.c.GnmiUpdate(&gpb.Notification{
+		Prefix:    mustTargetPath(targetName, "/interfaces/interface[name=eth2]", true),
+		Timestamp: 43,
+		Update: []*gpb.Update{{
+			Path: mustPath("config/forwarding-viable"),
+			Val:  &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{BoolVal: true}},
+		}},
+	}); err != nil {
+		t.Fatalf("failed to update cache: %v", err)
+	}
+	uintVal := func(v uint64) *gpb.TypedValue {
+		return &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: v}}
+	}
+
+	tests := []struct {
+		desc    string
+		req     *gpb.GetRequest
+		want    *gpb.Notification
+		wantErr string
+	}{{
+		desc: "config",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth0]")},
+			Type:     gpb.GetRequest_CONFIG,
+			Encoding: gpb.Encoding_PROTO,
+		},
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/config/mtu"),
+				Val:  uintVal(1500),
+			}},
+		},
+	}, {
+		desc: "state",
+		req: &gpb.GetRequest{
+			Prefix:   &gpb.Path{Origin: OpenConfigOrigin, Target: targetName},
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth0]")},
+			Type:     gpb.GetRequest_STATE,
+			Encoding: gpb.Encoding_PROTO,
+		},
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin, Target: targetName},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/state/counters/in-pkts"),
+				Val:  uintVal(10),
+			}, {
+				Path: mustPath("/interfaces/interface[name=eth0]/state/mtu"),
+				Val:  uintVal(1500),
+			}},
+		},
+	}, {
+		desc: "operational",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth0]")},
+			Type:     gpb.GetRequest_OPERATIONAL,
+			Encoding: gpb.Encoding_PROTO,
+		},
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/state/counters/in-pkts"),
+				Val:  uintVal(10),
+			}},
+		},
+	}, {
+		desc: "json wildcard",
+		req: &gpb.GetRequest{
+			Prefix:   mustPath("/interfaces"),
+			Path:     []*gpb.Path{mustPath("interface[name=*]/state")},
+			Encoding: gpb.Encoding_JSON_IETF,
+		},
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/state"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"counters":{"in-pkts":"10"},"mtu":1500,"name":"eth0"}`)}},
+			}},
+		},
+	}, {
+		desc: "json leaf",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth0]/config/mtu")},
+			Encoding: gpb.Encoding_JSON_IETF,
+		},
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/config/mtu"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`1500`)}},
+			}},
+		},
+	}, {
+		desc: "json ietf module",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth2]/config")},
+			Encoding: gpb.Encoding_JSON_IETF,
+		},
+		want: &gpb.Notification{
+			Timestamp: 43,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth2]/config"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(`{"name":"eth2","openconfig-if-sdn-ext:forwarding-viable":true}`)}},
+			}},
+		},
+	}, {
+		desc: "json",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth2]/config")},
+			Encoding: gpb.Encoding_JSON,
+		},
+		want: &gpb.Notification{
+			Timestamp: 43,
+			Prefix:    &gpb.Path{Origin: OpenConfigOrigin},
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth2]/config"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: []byte(`{"forwarding-viable":true,"name":"eth2"}`)}},
+			}},
+		},
+	}, {
+		desc: "unsupported encoding",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces")},
+			Encoding: gpb.Encoding_ASCII,
+		},
+		wantErr: "unsupported encoding",
+	}, {
+		desc: "not found",
+		req: &gpb.GetRequest{
+			Path:     []*gpb.Path{mustPath("/interfaces/interface[name=eth1]")},
+			Encoding: gpb.Encoding_PROTO,
+		},
+		wantErr: "not found",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := gnmiServer.Get(context.Background(), tt.req)
+			if d := errdiff.Substring(err, tt.wantErr); d != "" {
+				t.Fatalf("Get() unexpected error: %s", d)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(&gpb.GetResponse{Notification: []*gpb.Notification{tt.want}}, got, protocmp.Transform()); d != "" {
+				t.Errorf("Get() unexpected diff (-want, +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	gnmiServer, err := newServer(context.Background(), targetName, false)
+	if err != nil {
+		t.Fatalf("cannot create server, got err: %v", err)
+	}
+	got, err := gnmiServer.Capabilities(context.Background(), &gpb.CapabilityRequest{})
+	if err != nil {
+		t.Fatalf("Capabilities() unexpected error: %v", err)
+	}
+	if got.GetGNMIVersion() == "" {
+		t.Errorf("Capabilities() got empty gNMI version")
+	}
+	if d := cmp.Diff([]gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF, gpb.Encoding_PROTO}, got.GetSupportedEncodings()); d != "" {
+		t.Errorf("Capabilities() unexpected encodings diff (-want, +got):\n%s", d)
+	}
+	var found bool
+	for _, m := range got.GetSupportedModels() {
+		if m.GetName() == "openconfig-interfaces" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Capabilities() got models %v, want openconfig-interfaces", got.GetSupportedModels())
+	}
+}
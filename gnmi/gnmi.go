@@ -26,6 +26,7 @@ import (
 
 	log "github.com/golang/glog"
 	"github.com/openconfig/gnmi/subscribe"
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
 	"github.com/openconfig/ygot/ygot"
 	"github.com/openconfig/ygot/ytypes"
@@ -57,15 +58,15 @@ type Server struct {
 	*subscribe.Server
 	c *Collector
 
-	// TODO(wenbli): Implement gnmi.Get and remove this.
-	GetResponses []interface{}
-
 	configMu     sync.Mutex
 	configSchema *ytypes.Schema
 
 	stateMu     sync.Mutex
 	stateSchema *ytypes.Schema
 
+	// rootSchema is the schema of the root, it is used to determine the data type of leaves for gnmi.Get.
+	rootSchema *yang.Entry
+
 	validators  []func(*oc.Root) error
 	reconcilers []reconciler.Reconciler
 
@@ -158,6 +159,7 @@ func newServer(ctx context.Context, targetName string, enableSet bool, recs ...r
 
 	gnmiServer.configSchema = configSchema
 	gnmiServer.stateSchema = stateSchema
+	gnmiServer.rootSchema = emptySchema.RootSchema()
 
 	return gnmiServer, nil
 }
@@ -575,26 +577,6 @@ func (s *Server) Set(ctx context.Context, req *gpb.SetRequest) (*gpb.SetResponse
 	}
 }
 
-func (s *Server) Capabilities(context.Context, *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "Reference Implementation Unimplemented")
-}
-
-func (s *Server) Get(context.Context, *gpb.GetRequest) (*gpb.GetResponse, error) {
-	if len(s.GetResponses) == 0 {
-		return nil, status.Errorf(codes.Unimplemented, "Reference Implementation Unimplemented")
-	}
-	resp := s.GetResponses[0]
-	s.GetResponses = s.GetResponses[1:]
-	switch v := resp.(type) {
-	case error:
-		return nil, v
-	case *gpb.GetResponse:
-		return v, nil
-	default:
-		return nil, status.Errorf(codes.DataLoss, "Unknown message type: %T", resp)
-	}
-}
-
 // PathAuth is an interface for checking authorization for gNMI paths.
 type PathAuth interface {
 	// CheckPermit returns if the user is allowed to read from or write from in the input path.
@@ -765,6 +747,33 @@ func (s *Server) StartReconcilers(ctx context.Context) error {
 	return nil
 }
 
+// ResetConfig deletes all configuration from the datastore, leaving only
+// the default values of the schema as in a newly created server.
+func (s *Server) ResetConfig(context.Context) error {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	if s.configSchema == nil {
+		return nil
+	}
+
+	defaultSchema, err := oc.Schema()
+	if err != nil {
+		return fmt.Errorf("cannot create ygot schema object: %v", err)
+	}
+	if err := setupSchema(defaultSchema, true); err != nil {
+		return err
+	}
+	if err := ygot.PruneConfigFalse(defaultSchema.RootSchema(), defaultSchema.Root); err != nil {
+		return fmt.Errorf("gnmi: %v", err)
+	}
+	if err := updateCache(s.c, defaultSchema.Root, s.configSchema.Root, OpenConfigOrigin, true, time.Now().UnixNano(), "", nil); err != nil {
+		return fmt.Errorf("cannot reset config: %v", err)
+	}
+	s.configSchema.Root = defaultSchema.Root
+	log.Info("gNMI configuration reset")
+	return nil
+}
+
 // StopReconcilers stops all the reconcilers.
 func (s *Server) StopReconcilers(ctx context.Context) error {
 	for _, rec := range s.reconcilers {
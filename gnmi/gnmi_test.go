@@ -1150,3 +1150,48 @@ func TestSubscribeWithAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestResetConfig(t *testing.T) {
+	ctx := context.Background()
+	gnmiServer, err := newServer(ctx, targetName, true)
+	if err != nil {
+		t.Fatalf("cannot create server, got err: %v", err)
+	}
+	defer gnmiServer.c.Stop()
+	c, err := ygnmi.NewClient(gnmiServer.LocalClient(), ygnmi.WithTarget(targetName))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().System().Hostname().Config(), "config-host"); err != nil {
+		t.Fatalf("cannot set hostname config: %v", err)
+	}
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().Interface("eth0").Description().Config(), "uplink"); err != nil {
+		t.Fatalf("cannot set interface config: %v", err)
+	}
+	if _, err := gnmiclient.Replace(ctx, c, ocpath.Root().System().Hostname().State(), "state-host"); err != nil {
+		t.Fatalf("cannot set hostname state: %v", err)
+	}
+
+	if err := gnmiServer.ResetConfig(ctx); err != nil {
+		t.Fatalf("ResetConfig() unexpected error: %v", err)
+	}
+
+	if v, err := ygnmi.Lookup(ctx, c, ocpath.Root().System().Hostname().Config()); err != nil || v.IsPresent() {
+		t.Errorf("hostname config got %v, %v, want not present", v, err)
+	}
+	if v, err := ygnmi.Lookup(ctx, c, ocpath.Root().Interface("eth0").Description().Config()); err != nil || v.IsPresent() {
+		t.Errorf("interface config got %v, %v, want not present", v, err)
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().System().Hostname().State()); err != nil || got != "state-host" {
+		t.Errorf("hostname state got %q, %v, want %q", got, err, "state-host")
+	}
+
+	// The datastore accepts new configuration after the reset.
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().System().Hostname().Config(), "new-host"); err != nil {
+		t.Fatalf("cannot set hostname config after reset: %v", err)
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().System().Hostname().Config()); err != nil || got != "new-host" {
+		t.Errorf("hostname config got %q, %v, want %q", got, err, "new-host")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	ocOrg   = "OpenConfig working group"
+	ietfOrg = "IETF NETMOD (NETCONF Data Modeling Language) Working Group"
+	ianaOrg = "IANA"
+)
+
+// supportedModels are the YANG modules compiled into the gnmi/oc package.
+// This must be kept in sync with YANG_FILES and EXCLUDE_MODULES in generate.sh.
+var supportedModels = []*gpb.ModelData{
+	{Name: "iana-if-type", Organization: ianaOrg},
+	{Name: "ietf-inet-types", Organization: ietfOrg},
+	{Name: "ietf-yang-types", Organization: ietfOrg},
+	{Name: "openconfig-acl", Organization: ocOrg},
+	{Name: "openconfig-aft", Organization: ocOrg},
+	{Name: "openconfig-aft-network-instance", Organization: ocOrg},
+	{Name: "openconfig-aft-summary", Organization: ocOrg},
+	{Name: "openconfig-bgp-gue", Organization: ocOrg},
+	{Name: "openconfig-bgp-policy", Organization: ocOrg},
+	{Name: "openconfig-bgp-types", Organization: ocOrg},
+	{Name: "openconfig-extensions", Organization: ocOrg},
+	{Name: "openconfig-gnsi", Organization: ocOrg},
+	{Name: "openconfig-gnsi-acctz", Organization: ocOrg},
+	{Name: "openconfig-gnsi-authz", Organization: ocOrg},
+	{Name: "openconfig-gnsi-certz", Organization: ocOrg},
+	{Name: "openconfig-gnsi-credentialz", Organization: ocOrg},
+	{Name: "openconfig-gnsi-pathz", Organization: ocOrg},
+	{Name: "openconfig-gribi", Organization: ocOrg},
+	{Name: "openconfig-if-aggregate", Organization: ocOrg},
+	{Name: "openconfig-if-ethernet", Organization: ocOrg},
+	{Name: "openconfig-if-ethernet-ext", Organization: ocOrg},
+	{Name: "openconfig-if-ip", Organization: ocOrg},
+	{Name: "openconfig-if-ip-ext", Organization: ocOrg},
+	{Name: "openconfig-if-sdn-ext", Organization: ocOrg},
+	{Name: "openconfig-inet-types", Organization: ocOrg},
+	{Name: "openconfig-interfaces", Organization: ocOrg},
+	{Name: "openconfig-isis", Organization: ocOrg},
+	{Name: "openconfig-isis-policy", Organization: ocOrg},
+	{Name: "openconfig-lacp", Organization: ocOrg},
+	{Name: "openconfig-lldp", Organization: ocOrg},
+	{Name: "openconfig-lldp-types", Organization: ocOrg},
+	{Name: "openconfig-local-routing", Organization: ocOrg},
+	{Name: "openconfig-metadata", Organization: ocOrg},
+	{Name: "openconfig-mpls-types", Organization: ocOrg},
+	{Name: "openconfig-network-instance", Organization: ocOrg},
+	{Name: "openconfig-ospf-policy", Organization: ocOrg},
+	{Name: "openconfig-ospfv2", Organization: ocOrg},
+	{Name: "openconfig-p4rt", Organization: ocOrg},
+	{Name: "openconfig-packet-match", Organization: ocOrg},
+	{Name: "openconfig-pim", Organization: ocOrg},
+	{Name: "openconfig-platform", Organization: ocOrg},
+	{Name: "openconfig-platform-common", Organization: ocOrg},
+	{Name: "openconfig-platform-controller-card", Organization: ocOrg},
+	{Name: "openconfig-platform-cpu", Organization: ocOrg},
+	{Name: "openconfig-platform-ext", Organization: ocOrg},
+	{Name: "openconfig-platform-fabric", Organization: ocOrg},
+	{Name: "openconfig-platform-fan", Organization: ocOrg},
+	{Name: "openconfig-platform-integrated-circuit", Organization: ocOrg},
+	{Name: "openconfig-platform-linecard", Organization: ocOrg},
+	{Name: "openconfig-platform-pipeline-counters", Organization: ocOrg},
+	{Name: "openconfig-platform-psu", Organization: ocOrg},
+	{Name: "openconfig-platform-software", Organization: ocOrg},
+	{Name: "openconfig-platform-transceiver", Organization: ocOrg},
+	{Name: "openconfig-policy-forwarding", Organization: ocOrg},
+	{Name: "openconfig-policy-types", Organization: ocOrg},
+	{Name: "openconfig-qos", Organization: ocOrg},
+	{Name: "openconfig-qos-elements", Organization: ocOrg},
+	{Name: "openconfig-qos-interfaces", Organization: ocOrg},
+	{Name: "openconfig-qos-types", Organization: ocOrg},
+	{Name: "openconfig-relay-agent", Organization: ocOrg},
+	{Name: "openconfig-rib-bgp", Organization: ocOrg},
+	{Name: "openconfig-sampling-sflow", Organization: ocOrg},
+	{Name: "openconfig-segment-routing-types", Organization: ocOrg},
+	{Name: "openconfig-system", Organization: ocOrg},
+	{Name: "openconfig-system-bootz", Organization: ocOrg},
+	{Name: "openconfig-system-controlplane", Organization: ocOrg},
+	{Name: "openconfig-system-utilization", Organization: ocOrg},
+	{Name: "openconfig-transport-types", Organization: ocOrg},
+	{Name: "openconfig-types", Organization: ocOrg},
+	{Name: "openconfig-vlan", Organization: ocOrg},
+	{Name: "openconfig-yang-types", Organization: ocOrg},
+}
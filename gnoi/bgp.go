@@ -0,0 +1,67 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"net/netip"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	fbgp "github.com/openconfig/lemming/bgp"
+
+	bpb "github.com/openconfig/gnoi/bgp"
+)
+
+// BGPClearer clears the sessions with BGP neighbors.
+type BGPClearer interface {
+	ClearNeighbor(ctx context.Context, niName string, addr netip.Addr, mode fbgp.ClearMode) error
+}
+
+type bgp struct {
+	bpb.UnimplementedBGPServer
+
+	// clearer, if set, clears the BGP neighbors.
+	clearer BGPClearer
+}
+
+// ClearBGPNeighbor clears the session with a BGP neighbor.
+func (b *bgp) ClearBGPNeighbor(ctx context.Context, r *bpb.ClearBGPNeighborRequest) (*bpb.ClearBGPNeighborResponse, error) {
+	log.Infof("Received ClearBGPNeighbor request: %v", r)
+	if b.clearer == nil {
+		return nil, status.Errorf(codes.Unimplemented, "BGP is not supported")
+	}
+	addr, err := netip.ParseAddr(r.GetAddress())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "address must be an IP address, got %q", r.GetAddress())
+	}
+	var mode fbgp.ClearMode
+	switch r.GetMode() {
+	case bpb.ClearBGPNeighborRequest_HARD:
+		mode = fbgp.ClearHard
+	case bpb.ClearBGPNeighborRequest_SOFT:
+		mode = fbgp.ClearSoft
+	case bpb.ClearBGPNeighborRequest_SOFTIN:
+		mode = fbgp.ClearSoftIn
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown mode %v", r.GetMode())
+	}
+	if err := b.clearer.ClearNeighbor(ctx, r.GetRoutingInstance(), addr, mode); err != nil {
+		return nil, err
+	}
+	return &bpb.ClearBGPNeighborResponse{}, nil
+}
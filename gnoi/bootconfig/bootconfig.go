@@ -3,13 +3,112 @@
 package bootconfig
 
 import (
-	bpb "github.com/openconfig/gnoi/bootconfig"
+	"context"
+	"slices"
+	"sync"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	bpb "github.com/openconfig/bootz/proto/bootz"
+	bcpb "github.com/openconfig/gnoi/bootconfig"
 )
 
+// Server implements the gNOI BootConfig service.
+//
+// The boot config is stored in memory, so it is retained across the
+// simulated reboots of the device. Its OpenConfig configuration is applied
+// to the gNMI datastore by Apply, which is called after each reboot.
 type Server struct {
-	bpb.UnimplementedBootConfigServer
+	bcpb.UnimplementedBootConfigServer
+
+	c *ygnmi.Client
+
+	mu sync.Mutex
+	// bootConfig is the boot config as it was set, it is nil if no boot config is set.
+	bootConfig *bpb.BootConfig
+	// vendorConfig is the native vendor configuration, it is stored but not applied.
+	vendorConfig []byte
+	// root is the parsed OpenConfig configuration, it is nil if the boot config has none.
+	root *oc.Root
+}
+
+// New returns a new BootConfig server that applies the boot config using the client.
+func New(c *ygnmi.Client) *Server {
+	return &Server{
+		c: c,
+	}
+}
+
+// GetBootConfig implements the gNOI BootConfig service GetBootConfig RPC.
+func (s *Server) GetBootConfig(context.Context, *bcpb.GetBootConfigRequest) (*bcpb.GetBootConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bootConfig == nil {
+		return nil, status.Error(codes.NotFound, "no boot config is set")
+	}
+	return &bcpb.GetBootConfigResponse{BootConfig: proto.Clone(s.bootConfig).(*bpb.BootConfig)}, nil
+}
+
+// SetBootConfig implements the gNOI BootConfig service SetBootConfig RPC.
+//
+// Only the boot config is supported, the security artifacts are managed with gNSI.
+func (s *Server) SetBootConfig(_ context.Context, req *bcpb.SetBootConfigRequest) (*bcpb.SetBootConfigResponse, error) {
+	log.Infof("BootConfig: received SetBootConfig request")
+	if req.GetCredentials() != nil || req.GetPathz() != nil || req.GetAuthz() != nil || req.GetCertificates() != nil || req.GetCertz() != nil {
+		return nil, status.Error(codes.Unimplemented, "only boot_config is supported, use gNSI to set credentials, pathz, authz and certificates")
+	}
+	bc := req.GetBootConfig()
+	if bc == nil {
+		return nil, status.Error(codes.InvalidArgument, "boot_config must be set")
+	}
+
+	var root *oc.Root
+	if len(bc.GetOcConfig()) > 0 {
+		root = &oc.Root{}
+		if err := oc.Unmarshal(bc.GetOcConfig(), root); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "cannot unmarshal OpenConfig configuration: %v", err)
+		}
+		if err := root.Validate(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid OpenConfig configuration: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bootConfig = proto.Clone(bc).(*bpb.BootConfig)
+	s.vendorConfig = slices.Clone(bc.GetVendorConfig())
+	s.root = root
+	log.Infof("BootConfig: stored boot config with %d bytes of vendor config and %d bytes of OpenConfig config", len(s.vendorConfig), len(bc.GetOcConfig()))
+	return &bcpb.SetBootConfigResponse{}, nil
+}
+
+// Apply replaces the configuration in the gNMI datastore with the
+// OpenConfig configuration of the boot config, if it has one.
+func (s *Server) Apply(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.root == nil {
+		return nil
+	}
+	if _, err := ygnmi.Replace(ctx, s.c, ocpath.Root().Config(), s.root); err != nil {
+		return err
+	}
+	log.Info("BootConfig: applied OpenConfig boot config")
+	return nil
 }
 
-func New() *Server {
-	return &Server{}
+// Reset removes the boot config.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bootConfig = nil
+	s.vendorConfig = nil
+	s.root = nil
 }
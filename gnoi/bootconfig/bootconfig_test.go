@@ -1 +1,121 @@
 package bootconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/openconfig/lemming/gnmi"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	bpb "github.com/openconfig/bootz/proto/bootz"
+	bcpb "github.com/openconfig/gnoi/bootconfig"
+	apb "github.com/openconfig/gnsi/authz"
+)
+
+const ocConfig = `{"system": {"config": {"hostname": "day0"}}}`
+
+func TestSetBootConfig(t *testing.T) {
+	tests := []struct {
+		desc     string
+		req      *bcpb.SetBootConfigRequest
+		wantCode codes.Code
+	}{{
+		desc: "success",
+		req: &bcpb.SetBootConfigRequest{BootConfig: &bpb.BootConfig{
+			VendorConfig: []byte("hostname day0"),
+			OcConfig:     []byte(ocConfig),
+		}},
+		wantCode: codes.OK,
+	}, {
+		desc: "vendor config only",
+		req: &bcpb.SetBootConfigRequest{BootConfig: &bpb.BootConfig{
+			VendorConfig: []byte("hostname day0"),
+		}},
+		wantCode: codes.OK,
+	}, {
+		desc:     "missing boot config",
+		req:      &bcpb.SetBootConfigRequest{},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc: "invalid OpenConfig",
+		req: &bcpb.SetBootConfigRequest{BootConfig: &bpb.BootConfig{
+			OcConfig: []byte(`{"system": {"config": {"no-such-leaf": "day0"}}}`),
+		}},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc: "authz",
+		req: &bcpb.SetBootConfigRequest{
+			BootConfig: &bpb.BootConfig{},
+			Authz:      &apb.UploadRequest{Version: "1"},
+		},
+		wantCode: codes.Unimplemented,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := New(nil)
+			_, err := s.SetBootConfig(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("SetBootConfig() got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+			resp, err := s.GetBootConfig(context.Background(), &bcpb.GetBootConfigRequest{})
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != codes.NotFound {
+					t.Errorf("GetBootConfig() got error %v, want NotFound", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetBootConfig() unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.req.GetBootConfig(), resp.GetBootConfig(), protocmp.Transform()); diff != "" {
+				t.Errorf("GetBootConfig() (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+	gnmiServer, err := gnmi.New(grpc.NewServer(), "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ygnmi.NewClient(gnmiServer.LocalClient(), ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	s := New(c)
+
+	// Applying without a boot config leaves the configuration unchanged.
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().System().Hostname().Config(), "running"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Apply(ctx); err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().System().Hostname().Config()); err != nil || got != "running" {
+		t.Errorf("hostname got %q, %v, want %q", got, err, "running")
+	}
+
+	if _, err := s.SetBootConfig(ctx, &bcpb.SetBootConfigRequest{BootConfig: &bpb.BootConfig{OcConfig: []byte(ocConfig)}}); err != nil {
+		t.Fatalf("SetBootConfig() unexpected error: %v", err)
+	}
+	if err := s.Apply(ctx); err != nil {
+		t.Fatalf("Apply() unexpected error: %v", err)
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().System().Hostname().Config()); err != nil || got != "day0" {
+		t.Errorf("hostname got %q, %v, want %q", got, err, "day0")
+	}
+
+	s.Reset()
+	if _, err := s.GetBootConfig(ctx, &bcpb.GetBootConfigRequest{}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetBootConfig() after Reset got error %v, want NotFound", err)
+	}
+}
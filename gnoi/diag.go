@@ -0,0 +1,290 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+	configpb "github.com/openconfig/lemming/proto/config"
+
+	diagpb "github.com/openconfig/gnoi/diag"
+	pb "github.com/openconfig/gnoi/types"
+)
+
+// maxBERTDuration is the longest BERT that can be run on a port.
+const maxBERTDuration = 24 * time.Hour
+
+type diag struct {
+	diagpb.UnimplementedDiagServer
+
+	c      *ygnmi.Client
+	config *configpb.Config
+
+	// Protect the BERT state tracking
+	mu sync.Mutex
+	// Last BERT of each port, running or completed
+	// interface -> state
+	berts map[string]*bertState
+}
+
+// bertState represents the BERT of a single port.
+type bertState struct {
+	id         string
+	intf       *pb.Path
+	polynomial diagpb.PrbsPolynomial
+	cancel     context.CancelFunc
+	// done is closed when the BERT is completed and the interface is restored.
+	done     chan struct{}
+	lockLost chan struct{}
+	lostOnce sync.Once
+
+	// Protect the result and last read time
+	mu      sync.Mutex
+	result  *fakedevice.BERTResult
+	lastGet time.Time
+}
+
+func newDiag(c *ygnmi.Client, config *configpb.Config) *diag {
+	return &diag{
+		c:      c,
+		config: config,
+		berts:  make(map[string]*bertState),
+	}
+}
+
+// running returns whether the BERT is still in progress.
+func (b *bertState) running() bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// StartBERT starts a BERT on each port of the request, which is in the TESTING state until the BERT completes.
+func (d *diag) StartBERT(ctx context.Context, r *diagpb.StartBERTRequest) (*diagpb.StartBERTResponse, error) {
+	log.Infof("Received StartBERT request: %v", r)
+	id := r.GetBertOperationId()
+	if id == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "BERT operation id is required")
+	}
+	if len(r.GetPerPortRequests()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no ports specified")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var idInUse bool
+	for _, b := range d.berts {
+		if b.id == id {
+			idInUse = true
+		}
+	}
+	resp := &diagpb.StartBERTResponse{BertOperationId: id}
+	started := map[string]bool{}
+	for _, req := range r.GetPerPortRequests() {
+		name := pathInterfaceName(req.GetInterface())
+		st := diagpb.BertStatus_BERT_STATUS_OK
+		duration := time.Duration(req.GetTestDurationInSecs()) * time.Second
+		switch {
+		case idInUse:
+			st = diagpb.BertStatus_BERT_STATUS_OPERATION_ID_IN_USE
+		case !d.interfaceExists(ctx, name):
+			st = diagpb.BertStatus_BERT_STATUS_NON_EXISTENT_PORT
+		case started[name] || d.berts[name] != nil && d.berts[name].running():
+			st = diagpb.BertStatus_BERT_STATUS_PORT_ALREADY_IN_BERT
+		case req.GetPrbsPolynomial() == diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_UNKNOWN:
+			st = diagpb.BertStatus_BERT_STATUS_UNSUPPORTED_PRBS_POLYNOMIAL
+		case duration == 0:
+			st = diagpb.BertStatus_BERT_STATUS_TEST_DURATION_TOO_SHORT
+		case duration > maxBERTDuration:
+			st = diagpb.BertStatus_BERT_STATUS_TEST_DURATION_TOO_LONG
+		default:
+			started[name] = true
+			d.berts[name] = d.startBERT(id, name, req, duration)
+		}
+		resp.PerPortResponses = append(resp.PerPortResponses, &diagpb.StartBERTResponse_PerPortResponse{
+			Interface: req.GetInterface(),
+			Status:    st,
+		})
+	}
+	return resp, nil
+}
+
+// startBERT runs the BERT of a port in the background.
+func (d *diag) startBERT(id, name string, req *diagpb.StartBERTRequest_PerPortRequest, duration time.Duration) *bertState {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &bertState{
+		id:         id,
+		intf:       req.GetInterface(),
+		polynomial: req.GetPrbsPolynomial(),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+		lockLost:   make(chan struct{}),
+		result:     &fakedevice.BERTResult{StartTime: time.Now()},
+	}
+	update := func(result *fakedevice.BERTResult) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.result = result
+	}
+	go func() {
+		defer close(b.done)
+		defer cancel()
+		if err := fakedevice.RunBERT(ctx, d.c, name, duration, b.lockLost, update, d.config); err != nil && !errors.Is(err, context.Canceled) {
+			log.Errorf("BERT %s on %s failed: %v", id, name, err)
+		}
+	}()
+	log.Infof("Started BERT %s on %s for %v", id, name, duration)
+	return b
+}
+
+// StopBERT stops the BERT of each port of the request and waits for the interfaces to be restored.
+func (d *diag) StopBERT(ctx context.Context, r *diagpb.StopBERTRequest) (*diagpb.StopBERTResponse, error) {
+	log.Infof("Received StopBERT request: %v", r)
+	if len(r.GetPerPortRequests()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no ports specified")
+	}
+
+	d.mu.Lock()
+	resp := &diagpb.StopBERTResponse{BertOperationId: r.GetBertOperationId()}
+	var stopped []*bertState
+	for _, req := range r.GetPerPortRequests() {
+		b, st := d.lookupBERT(ctx, r.GetBertOperationId(), req.GetInterface())
+		if b != nil {
+			b.cancel()
+			stopped = append(stopped, b)
+		}
+		resp.PerPortResponses = append(resp.PerPortResponses, &diagpb.StopBERTResponse_PerPortResponse{
+			Interface: req.GetInterface(),
+			Status:    st,
+		})
+	}
+	d.mu.Unlock()
+
+	for _, b := range stopped {
+		select {
+		case <-b.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return resp, nil
+}
+
+// GetBERTResult returns the results of the BERT of each port of the request, or of all ports.
+func (d *diag) GetBERTResult(ctx context.Context, r *diagpb.GetBERTResultRequest) (*diagpb.GetBERTResultResponse, error) {
+	log.Infof("Received GetBERTResult request: %v", r)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	resp := &diagpb.GetBERTResultResponse{}
+	if r.GetResultFromAllPorts() {
+		for _, name := range slices.Sorted(maps.Keys(d.berts)) {
+			resp.PerPortResponses = append(resp.PerPortResponses, d.berts[name].perPortResult(d.berts[name].intf, diagpb.BertStatus_BERT_STATUS_OK))
+		}
+		return resp, nil
+	}
+	if len(r.GetPerPortRequests()) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no ports specified")
+	}
+	for _, req := range r.GetPerPortRequests() {
+		b, st := d.lookupBERT(ctx, r.GetBertOperationId(), req.GetInterface())
+		if b == nil {
+			resp.PerPortResponses = append(resp.PerPortResponses, &diagpb.GetBERTResultResponse_PerPortResponse{
+				Interface: req.GetInterface(),
+				Status:    st,
+			})
+			continue
+		}
+		resp.PerPortResponses = append(resp.PerPortResponses, b.perPortResult(req.GetInterface(), st))
+	}
+	return resp, nil
+}
+
+// perPortResult returns the result of the BERT and records that it was read.
+func (b *bertState) perPortResult(intf *pb.Path, st diagpb.BertStatus) *diagpb.GetBERTResultResponse_PerPortResponse {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	res := &diagpb.GetBERTResultResponse_PerPortResponse{
+		Interface:              intf,
+		Status:                 st,
+		BertOperationId:        b.id,
+		PrbsPolynomial:         b.polynomial,
+		LastBertStartTimestamp: uint64(b.result.StartTime.UnixNano()),
+		PeerLockEstablished:    b.result.PeerLockEstablished,
+		PeerLockLost:           b.result.PeerLockLost,
+		ErrorCountPerMinute:    b.result.ErrorCountPerMinute,
+		TotalErrors:            b.result.TotalErrors,
+	}
+	if !b.lastGet.IsZero() {
+		res.LastBertGetResultTimestamp = uint64(b.lastGet.UnixNano())
+	}
+	b.lastGet = time.Now()
+	return res
+}
+
+// lookupBERT returns the BERT of the port if it has the operation id, or the status explaining why not.
+// It assumes the caller holds the lock on d.mu.
+func (d *diag) lookupBERT(ctx context.Context, id string, intf *pb.Path) (*bertState, diagpb.BertStatus) {
+	name := pathInterfaceName(intf)
+	if !d.interfaceExists(ctx, name) {
+		return nil, diagpb.BertStatus_BERT_STATUS_NON_EXISTENT_PORT
+	}
+	b, ok := d.berts[name]
+	switch {
+	case !ok:
+		return nil, diagpb.BertStatus_BERT_STATUS_PORT_NOT_RUNNING_BERT
+	case b.id != id:
+		return nil, diagpb.BertStatus_BERT_STATUS_OPERATION_ID_NOT_FOUND
+	}
+	return b, diagpb.BertStatus_BERT_STATUS_OK
+}
+
+// interfaceExists returns whether the interface exists in the system.
+func (d *diag) interfaceExists(ctx context.Context, name string) bool {
+	if name == "" {
+		return false
+	}
+	_, err := ygnmi.Get(ctx, d.c, ocpath.Root().Interface(name).State())
+	return err == nil
+}
+
+// loseLock makes the running BERTs lose their peer lock.
+func (d *diag) loseLock() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for name, b := range d.berts {
+		if b.running() {
+			log.Infof("BERT %s on %s is losing the peer lock", b.id, name)
+			b.lostOnce.Do(func() { close(b.lockLost) })
+		}
+	}
+}
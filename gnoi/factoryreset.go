@@ -0,0 +1,112 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+
+	frpb "github.com/openconfig/gnoi/factory_reset"
+)
+
+// factoryReset implements the gNOI FactoryReset service.
+//
+// A factory reset runs the registered reset functions, which clear the state
+// of the other services (e.g. configuration and RIBs), removes all files,
+// health events, the boot config and installed OS versions, and then reboots
+// the device.
+// Certificates are not managed by gNOI, so they are always retained.
+type factoryReset struct {
+	frpb.UnimplementedFactoryResetServer
+
+	system  *system
+	file    *file
+	os      *os
+	healthz *healthz
+
+	// mu protects resetters and ensures that only one reset runs at a time.
+	mu        sync.Mutex
+	resetters []func(context.Context) error
+}
+
+func newFactoryReset(system *system, file *file, os *os, healthz *healthz) *factoryReset {
+	return &factoryReset{
+		system:  system,
+		file:    file,
+		os:      os,
+		healthz: healthz,
+	}
+}
+
+// OnFactoryReset registers functions that reset the state of other services
+// during a factory reset, they are called in the order they are registered.
+func (s *Server) OnFactoryReset(fns ...func(context.Context) error) {
+	s.resetServer.mu.Lock()
+	defer s.resetServer.mu.Unlock()
+	s.resetServer.resetters = append(s.resetServer.resetters, fns...)
+}
+
+func resetErr(detail string) *frpb.StartResponse {
+	log.Warningf("FactoryReset: %s", detail)
+	return &frpb.StartResponse{Response: &frpb.StartResponse_ResetError{ResetError: &frpb.ResetError{
+		Other:  true,
+		Detail: detail,
+	}}}
+}
+
+// Start implements the gNOI FactoryReset service Start RPC.
+func (f *factoryReset) Start(ctx context.Context, req *frpb.StartRequest) (*frpb.StartResponse, error) {
+	log.Infof("FactoryReset: received request %v", req)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Hold the reboot lock so that no reboot is scheduled during the reset.
+	f.system.rebootMu.Lock()
+	defer f.system.rebootMu.Unlock()
+	if f.system.hasPendingReboot {
+		return resetErr("a reboot is pending"), nil
+	}
+
+	for _, reset := range f.resetters {
+		if err := reset(ctx); err != nil {
+			return resetErr(fmt.Sprintf("failed to reset state: %v", err)), nil
+		}
+	}
+	if req.GetZeroFill() {
+		f.file.zeroFill()
+	}
+	f.file.Reset()
+	f.healthz.reset()
+	if f.system.bootConfigServer != nil {
+		f.system.bootConfigServer.Reset()
+	}
+	if req.GetFactoryOs() {
+		f.os.factoryReset()
+	}
+
+	now := time.Now().UnixNano()
+	if err := fakedevice.Reboot(ctx, f.system.c, now); err != nil {
+		return resetErr(fmt.Sprintf("failed to reboot: %v", err)), nil
+	}
+	f.system.boot(ctx)
+	log.Info("FactoryReset: completed")
+	return &frpb.StartResponse{Response: &frpb.StartResponse_ResetSuccess{ResetSuccess: &frpb.ResetSuccess{}}}, nil
+}
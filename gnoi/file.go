@@ -398,6 +398,15 @@ func (f *file) ListFiles() []string {
 	return files
 }
 
+// zeroFill overwrites the contents of all files with zeros.
+func (f *file) zeroFill() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, fi := range f.files {
+		clear(fi.content)
+	}
+}
+
 // Reset clears all files from the simulated file system for testing.
 func (f *file) Reset() {
 	f.mu.Lock()
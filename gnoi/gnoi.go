@@ -29,10 +29,12 @@ import (
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/oc"
 	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+	"github.com/openconfig/lemming/gnoi/bootconfig"
 	configpb "github.com/openconfig/lemming/proto/config"
 
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 	bpb "github.com/openconfig/gnoi/bgp"
+	bcpb "github.com/openconfig/gnoi/bootconfig"
 	cmpb "github.com/openconfig/gnoi/cert"
 	diagpb "github.com/openconfig/gnoi/diag"
 	frpb "github.com/openconfig/gnoi/factory_reset"
@@ -57,40 +59,14 @@ const (
 	defaultPingInterval = 1000000000
 	defaultPingWait     = 2000000000
 	defaultPingSize     = 56
+	// defaultPingTTL is the TTL of the echo requests sent through the dataplane.
+	defaultPingTTL = 64
 )
 
-type bgp struct {
-	bpb.UnimplementedBGPServer
-}
-
 type cert struct {
 	cmpb.UnimplementedCertificateManagementServer
 }
 
-type diag struct {
-	diagpb.UnimplementedDiagServer
-}
-
-type factoryReset struct {
-	frpb.UnimplementedFactoryResetServer
-}
-
-type healthz struct {
-	hpb.UnimplementedHealthzServer
-}
-
-type layer2 struct {
-	lpb.UnimplementedLayer2Server
-}
-
-type mpls struct {
-	mpb.UnimplementedMPLSServer
-}
-
-type os struct {
-	ospb.UnimplementedOSServer
-}
-
 type otdr struct {
 	otpb.UnimplementedOTDRServer
 }
@@ -124,6 +100,16 @@ type system struct {
 	// processMu protects process operations and ensures
 	// only one process operation can be in progress at a time
 	processMu sync.Mutex
+	// osServer, if set, runs the activated OS version after a reboot.
+	osServer *os
+	// healthzServer, if set, records health events for component reboots and killed processes.
+	healthzServer *healthz
+	// bootConfigServer, if set, applies the boot config after a reboot.
+	bootConfigServer *bootconfig.Server
+	// prober, if set, sends traceroute probes through the dataplane.
+	prober Prober
+	// rib, if set, provides the routes used to pick the source address and simulate traceroute hops.
+	rib RIB
 }
 
 func newSystem(c *ygnmi.Client, config *configpb.Config) *system {
@@ -203,9 +189,11 @@ func (s *system) handleComponentReboot(ctx context.Context, r *spb.RebootRequest
 			}
 			s.componentRebootsMu.Unlock()
 			// Immediate reboot
-			if err := fakedevice.RebootComponent(context.Background(), s.c, componentName, time.Now().UnixNano(), s.config); err != nil {
+			now := time.Now().UnixNano()
+			if err := fakedevice.RebootComponent(context.Background(), s.c, componentName, now, s.config); err != nil {
 				return nil, status.Errorf(codes.Internal, "failed to reboot component %q: %v", componentName, err)
 			}
+			s.healthzServer.componentRebooted(componentName, now)
 			log.Infof("Component %q immediate reboot completed", componentName)
 			continue
 		}
@@ -244,6 +232,7 @@ func (s *system) handleComponentReboot(ctx context.Context, r *spb.RebootRequest
 					log.Errorf("delayed component reboot for %q failed: %v", compName, err)
 					return
 				}
+				s.healthzServer.componentRebooted(compName, now)
 				log.Infof("Component %q delayed reboot completed", compName)
 			}
 		}(componentName)
@@ -268,6 +257,7 @@ func (s *system) handleSystemReboot(ctx context.Context, r *spb.RebootRequest) e
 		if err := fakedevice.Reboot(ctx, s.c, now); err != nil {
 			return status.Error(codes.Internal, err.Error())
 		}
+		s.boot(ctx)
 		return nil
 	}
 
@@ -281,6 +271,8 @@ func (s *system) handleSystemReboot(ctx context.Context, r *spb.RebootRequest) e
 			now := time.Now().UnixNano()
 			if err := fakedevice.Reboot(ctx, s.c, now); err != nil {
 				log.Errorf("delayed reboot failed: %v", err)
+			} else {
+				s.boot(ctx)
 			}
 			s.rebootMu.Lock()
 			defer s.rebootMu.Unlock()
@@ -290,6 +282,18 @@ func (s *system) handleSystemReboot(ctx context.Context, r *spb.RebootRequest) e
 	return nil
 }
 
+// boot runs the OS version activated for the next boot and applies the boot config.
+func (s *system) boot(ctx context.Context) {
+	if s.osServer != nil {
+		s.osServer.boot(ctx)
+	}
+	if s.bootConfigServer != nil {
+		if err := s.bootConfigServer.Apply(ctx); err != nil {
+			log.Errorf("failed to apply boot config: %v", err)
+		}
+	}
+}
+
 func (s *system) CancelReboot(ctx context.Context, c *spb.CancelRebootRequest) (*spb.CancelRebootResponse, error) {
 	log.Infof("Received cancel reboot request %v", c)
 
@@ -473,11 +477,20 @@ func (s *system) KillProcess(ctx context.Context, r *spb.KillProcessRequest) (*s
 	if err := fakedevice.KillProcess(context.Background(), s.c, targetPID, processName, signal, restart, s.config); err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to kill process: %v", err)
 	}
+	if signal != spb.KillProcessRequest_SIGNAL_HUP {
+		// Processes run on the active supervisor.
+		supervisor, _, err := s.getSupervisorRole(ctx)
+		if err != nil {
+			supervisor = s.config.GetComponents().GetSupervisor1Name()
+		}
+		s.healthzServer.processKilled(supervisor, targetPID, processName, signal, restart)
+	}
 
 	return &spb.KillProcessResponse{}, nil
 }
 
-// Ping simulates ICMP ping operations with configurable network conditions
+// Ping sends ICMP echo requests through the dataplane, if the device has one.
+// Otherwise, it simulates ICMP ping operations with configurable network conditions.
 func (s *system) Ping(r *spb.PingRequest, stream spb.System_PingServer) error {
 	log.Infof("Received ping request: %v", r)
 
@@ -520,7 +533,15 @@ func (s *system) Ping(r *spb.PingRequest, stream spb.System_PingServer) error {
 		return status.Errorf(codes.InvalidArgument, "packet size must be between 8 and 65507 bytes, got %d", size)
 	}
 
-	// TODO: Add support for do_not_fragment, do_not_resolve, l3protocol, network_instance parameters
+	// The source, do_not_fragment, l3protocol and network_instance parameters are only used with the dataplane.
+	// TODO: Add support for do_not_resolve.
+	var dp *dataplanePing
+	if s.prober != nil {
+		var err error
+		if dp, err = s.newDataplanePing(ctx, r); err != nil {
+			return err
+		}
+	}
 
 	// Calculate appropriate buffer size based on interval and count
 	bufferSize := 100
@@ -535,8 +556,14 @@ func (s *system) Ping(r *spb.PingRequest, stream spb.System_PingServer) error {
 
 	go func() {
 		defer close(responseChan)
-		if err := fakedevice.PingSimulation(ctx, destination, count, time.Duration(interval), time.Duration(wait), uint32(size), responseChan, s.config); err != nil {
-			log.Errorf("Ping simulation error: %v", err)
+		var err error
+		if dp != nil {
+			err = dp.run(ctx, count, time.Duration(interval), time.Duration(wait), uint32(size), responseChan)
+		} else {
+			err = fakedevice.PingSimulation(ctx, destination, count, time.Duration(interval), time.Duration(wait), uint32(size), responseChan, s.config)
+		}
+		if err != nil {
+			log.Errorf("Ping error: %v", err)
 			select {
 			case errorChan <- err:
 			default:
@@ -559,7 +586,7 @@ func (s *system) Ping(r *spb.PingRequest, stream spb.System_PingServer) error {
 			return ctx.Err()
 		case err := <-errorChan:
 			if err != nil {
-				return status.Errorf(codes.Internal, "ping simulation failed: %v", err)
+				return status.Errorf(codes.Internal, "ping failed: %v", err)
 			}
 		case result, ok := <-responseChan:
 			if !ok {
@@ -567,7 +594,7 @@ func (s *system) Ping(r *spb.PingRequest, stream spb.System_PingServer) error {
 				select {
 				case err := <-errorChan:
 					if err != nil {
-						return status.Errorf(codes.Internal, "ping simulation failed: %v", err)
+						return status.Errorf(codes.Internal, "ping failed: %v", err)
 					}
 				default:
 				}
@@ -749,7 +776,8 @@ type wavelengthRouter struct {
 type Server struct {
 	s                       *grpc.Server
 	bgpServer               *bgp
-	certServer              *cert
+	bootConfigServer        *bootconfig.Server
+	certServer              cmpb.CertificateManagementServer
 	diagServer              *diag
 	fileServer              *file
 	resetServer             *factoryReset
@@ -763,29 +791,95 @@ type Server struct {
 	wavelengthRouterServer  *wavelengthRouter
 }
 
-func New(s *grpc.Server, gClient gpb.GNMIClient, target string, config *configpb.Config) (*Server, error) {
+// Option configures the gNOI server.
+type Option func(*Server)
+
+// WithProber sends the traceroute probes through the dataplane.
+func WithProber(p Prober) Option {
+	return func(s *Server) {
+		s.systemServer.prober = p
+	}
+}
+
+// WithBGP clears the BGP neighbors with the BGP implementation.
+func WithBGP(c BGPClearer) Option {
+	return func(s *Server) {
+		s.bgpServer.clearer = c
+	}
+}
+
+// WithCertificateManagement serves the gNOI cert service with the server.
+func WithCertificateManagement(cm cmpb.CertificateManagementServer) Option {
+	return func(s *Server) {
+		s.certServer = cm
+	}
+}
+
+// WithLayer2 clears the neighbor tables and LLDP with the dataplane.
+func WithLayer2(c Layer2Clearer) Option {
+	return func(s *Server) {
+		s.layer2Server.clearer = c
+	}
+}
+
+// WithLSPs clears the static LSPs with the RIB.
+func WithLSPs(m LSPManager) Option {
+	return func(s *Server) {
+		s.mplsServer.lsps = m
+	}
+}
+
+// WithLabelCounters clears the counters of the MPLS label entries with the dataplane.
+func WithLabelCounters(c LabelCounterClearer) Option {
+	return func(s *Server) {
+		s.mplsServer.counters = c
+	}
+}
+
+// WithRIB uses the routes of the RIB for traceroute.
+func WithRIB(r RIB) Option {
+	return func(s *Server) {
+		s.systemServer.rib = r
+	}
+}
+
+func New(s *grpc.Server, gClient gpb.GNMIClient, target string, config *configpb.Config, opts ...Option) (*Server, error) {
 	yclient, err := ygnmi.NewClient(gClient, ygnmi.WithTarget(target), ygnmi.WithRequestLogLevel(2))
 	if err != nil {
 		return nil, err
 	}
 
+	osServer := newOS(yclient, config)
+	systemServer := newSystem(yclient, config)
+	systemServer.osServer = osServer
+	healthzServer := newHealthz(yclient, config)
+	systemServer.healthzServer = healthzServer
+	fileServer := newFile()
+	bootConfigServer := bootconfig.New(yclient)
+	systemServer.bootConfigServer = bootConfigServer
+
 	srv := &Server{
 		s:                       s,
 		bgpServer:               &bgp{},
+		bootConfigServer:        bootConfigServer,
 		certServer:              &cert{},
-		diagServer:              &diag{},
-		fileServer:              newFile(),
-		resetServer:             &factoryReset{},
-		healthzServer:           &healthz{},
-		layer2Server:            &layer2{},
+		diagServer:              newDiag(yclient, config),
+		fileServer:              fileServer,
+		resetServer:             newFactoryReset(systemServer, fileServer, osServer, healthzServer),
+		healthzServer:           healthzServer,
+		layer2Server:            &layer2{c: yclient},
 		mplsServer:              &mpls{},
-		osServer:                &os{},
+		osServer:                osServer,
 		otdrServer:              &otdr{},
 		linkQualificationServer: newLinkQualification(yclient, config),
-		systemServer:            newSystem(yclient, config),
+		systemServer:            systemServer,
 		wavelengthRouterServer:  &wavelengthRouter{},
 	}
+	for _, opt := range opts {
+		opt(srv)
+	}
 	bpb.RegisterBGPServer(s, srv.bgpServer)
+	bcpb.RegisterBootConfigServer(s, srv.bootConfigServer)
 	cmpb.RegisterCertificateManagementServer(s, srv.certServer)
 	diagpb.RegisterDiagServer(s, srv.diagServer)
 	fpb.RegisterFileServer(s, srv.fileServer)
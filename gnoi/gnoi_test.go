@@ -8,6 +8,7 @@ import (
 	"io"
 	"math"
 	"net"
+	"net/netip"
 	"strings"
 	"testing"
 	"time"
@@ -19,21 +20,35 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	bpb "github.com/openconfig/bootz/proto/bootz"
+	bgppb "github.com/openconfig/gnoi/bgp"
+	bcpb "github.com/openconfig/gnoi/bootconfig"
 	cpb "github.com/openconfig/gnoi/common"
+	diagpb "github.com/openconfig/gnoi/diag"
+	frpb "github.com/openconfig/gnoi/factory_reset"
 	fpb "github.com/openconfig/gnoi/file"
+	hpb "github.com/openconfig/gnoi/healthz"
+	lpb "github.com/openconfig/gnoi/layer2"
+	mplspb "github.com/openconfig/gnoi/mpls"
+	ospb "github.com/openconfig/gnoi/os"
 	plqpb "github.com/openconfig/gnoi/packet_link_qualification"
 	spb "github.com/openconfig/gnoi/system"
 	pb "github.com/openconfig/gnoi/types"
 	configpb "github.com/openconfig/lemming/proto/config"
 
+	fbgp "github.com/openconfig/lemming/bgp"
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
 	"github.com/openconfig/lemming/gnmi"
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/gnmiclient"
 	"github.com/openconfig/lemming/gnmi/oc"
 	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+	"github.com/openconfig/lemming/gnoi/bootconfig"
 	"github.com/openconfig/lemming/internal/config"
 )
 
@@ -1613,6 +1628,309 @@ func TestPing(t *testing.T) {
 	}
 }
 
+func TestDataplanePing(t *testing.T) {
+	lemmingConfig := loadDefaultConfig(t)
+	tests := []struct {
+		desc         string
+		req          *spb.PingRequest
+		wantProbe    *icmp.Probe
+		wantReceived int32
+		wantCode     codes.Code
+	}{{
+		desc:         "ipv4",
+		req:          &spb.PingRequest{Destination: "10.0.2.1", Source: "10.0.0.1", Count: 2, Interval: int64(time.Millisecond), Size: 100, DoNotFragment: true},
+		wantProbe:    &icmp.Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("10.0.2.1"), TTL: defaultPingTTL, Size: 100, DoNotFragment: true},
+		wantReceived: 2,
+	}, {
+		desc:         "ipv6",
+		req:          &spb.PingRequest{Destination: "2001::2", Source: "2001::1", Count: 2, Interval: int64(time.Millisecond), L3Protocol: pb.L3Protocol_IPV6},
+		wantProbe:    &icmp.Probe{Src: netip.MustParseAddr("2001::1"), Dst: netip.MustParseAddr("2001::2"), TTL: defaultPingTTL, Size: defaultPingSize},
+		wantReceived: 2,
+	}, {
+		desc:      "unreachable",
+		req:       &spb.PingRequest{Destination: "10.0.3.1", Source: "10.0.0.1", Count: 2, Interval: int64(time.Millisecond)},
+		wantProbe: &icmp.Probe{Src: netip.MustParseAddr("10.0.0.1"), Dst: netip.MustParseAddr("10.0.3.1"), TTL: defaultPingTTL, Size: defaultPingSize},
+	}, {
+		desc:     "hostname destination",
+		req:      &spb.PingRequest{Destination: "lemming"},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "l3protocol mismatch",
+		req:      &spb.PingRequest{Destination: "10.0.2.1", L3Protocol: pb.L3Protocol_IPV6},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "source family mismatch",
+		req:      &spb.PingRequest{Destination: "10.0.2.1", Source: "2001::1"},
+		wantCode: codes.InvalidArgument,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := newSystem(nil, lemmingConfig)
+			var probes []*icmp.Probe
+			s.prober = fakeProber(func(p *icmp.Probe) *icmp.Reply {
+				probes = append(probes, p)
+				if p.Dst == netip.MustParseAddr("10.0.3.1") {
+					return &icmp.Reply{From: netip.MustParseAddr("10.0.1.2"), Type: icmp.ReplyUnreachable}
+				}
+				return &icmp.Reply{From: p.Dst, Type: icmp.ReplyEcho, TTL: 63, RTT: time.Millisecond}
+			})
+			stream := &mockPingServer{ctx: context.Background()}
+			err := s.Ping(tt.req, stream)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("Ping() got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			for _, p := range probes {
+				if d := cmp.Diff(tt.wantProbe, p, cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+					t.Errorf("Ping() unexpected probe (-want,+got):\n%s", d)
+				}
+			}
+			if len(stream.responses) != int(tt.req.GetCount())+1 {
+				t.Fatalf("Ping() got %d responses, want %d", len(stream.responses), tt.req.GetCount()+1)
+			}
+			for _, resp := range stream.responses[:tt.req.GetCount()] {
+				if tt.wantReceived > 0 && (resp.GetTtl() != 63 || resp.GetTime() != time.Millisecond.Nanoseconds()) {
+					t.Errorf("Ping() got response %v, want TTL 63 and time %v", resp, time.Millisecond)
+				}
+			}
+			summary := stream.responses[len(stream.responses)-1]
+			if summary.GetSent() != tt.req.GetCount() || summary.GetReceived() != tt.wantReceived {
+				t.Errorf("Ping() got summary sent %d, received %d, want %d, %d", summary.GetSent(), summary.GetReceived(), tt.req.GetCount(), tt.wantReceived)
+			}
+		})
+	}
+}
+
+// mockTracerouteServer implements spb.System_TracerouteServer for testing
+type mockTracerouteServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*spb.TracerouteResponse
+}
+
+func (m *mockTracerouteServer) Send(response *spb.TracerouteResponse) error {
+	m.responses = append(m.responses, response)
+	return nil
+}
+
+func (m *mockTracerouteServer) Context() context.Context {
+	return m.ctx
+}
+
+// fakeRIB returns the next hops of the destinations in the map.
+type fakeRIB map[netip.Addr][]netip.Addr
+
+func (r fakeRIB) NextHops(_ string, addr netip.Addr) ([]netip.Addr, bool) {
+	nhs, ok := r[addr]
+	return nhs, ok
+}
+
+// fakeProber replies to the probes with the function.
+type fakeProber func(*icmp.Probe) *icmp.Reply
+
+func (p fakeProber) Probe(_ context.Context, _ string, probe *icmp.Probe, _ time.Duration) (*icmp.Reply, error) {
+	return p(probe), nil
+}
+
+func TestTraceroute(t *testing.T) {
+	lemmingConfig := loadDefaultConfig(t)
+	latency := time.Duration(lemmingConfig.GetNetworkSimulation().GetBaseLatencyMs()) * time.Millisecond
+	if latency == 0 {
+		latency = defaultSimulatedHopLatency
+	}
+	dst := netip.MustParseAddr("10.0.2.1")
+	nh := netip.MustParseAddr("10.0.1.2")
+	rib := fakeRIB{dst: {nh}}
+	// prober simulates a path through nh to dst, the TTL is decremented once by the local dataplane.
+	prober := fakeProber(func(p *icmp.Probe) *icmp.Reply {
+		switch p.TTL - 1 {
+		case 1:
+			return &icmp.Reply{From: nh, Type: icmp.ReplyTimeExceeded, RTT: time.Millisecond}
+		case 2:
+			if p.Protocol == icmp.ProtocolUDP {
+				return &icmp.Reply{From: dst, Type: icmp.ReplyUnreachable, Code: 3, RTT: 2 * time.Millisecond}
+			}
+			return &icmp.Reply{From: dst, Type: icmp.ReplyEcho, RTT: 2 * time.Millisecond}
+		}
+		return nil
+	})
+	hop := func(ttl int32, addr string, rtt time.Duration, state spb.TracerouteResponse_State) []*spb.TracerouteResponse {
+		var resps []*spb.TracerouteResponse
+		for range tracerouteProbes {
+			resps = append(resps, &spb.TracerouteResponse{Hop: ttl, Address: addr, Rtt: rtt.Nanoseconds(), State: state})
+		}
+		return resps
+	}
+	first := &spb.TracerouteResponse{DestinationName: "10.0.2.1", DestinationAddress: "10.0.2.1", Hops: defaultTracerouteMaxTTL, PacketSize: 60}
+
+	tests := []struct {
+		desc     string
+		req      *spb.TracerouteRequest
+		prober   Prober
+		want     []*spb.TracerouteResponse
+		wantCode codes.Code
+	}{{
+		desc: "simulated",
+		req:  &spb.TracerouteRequest{Destination: "10.0.2.1"},
+		want: append(append([]*spb.TracerouteResponse{first},
+			hop(1, "10.0.1.2", latency, spb.TracerouteResponse_DEFAULT)...),
+			hop(2, "10.0.2.1", 2*latency, spb.TracerouteResponse_DEFAULT)...),
+	}, {
+		desc: "simulated no route",
+		req:  &spb.TracerouteRequest{Destination: "10.0.3.1"},
+		want: []*spb.TracerouteResponse{
+			{DestinationName: "10.0.3.1", DestinationAddress: "10.0.3.1", Hops: defaultTracerouteMaxTTL, PacketSize: 60},
+			{Hop: 1, State: spb.TracerouteResponse_NETWORK_UNREACHABLE},
+		},
+	}, {
+		desc:   "dataplane icmp",
+		req:    &spb.TracerouteRequest{Destination: "10.0.2.1", Source: "10.0.0.1"},
+		prober: prober,
+		want: append(append([]*spb.TracerouteResponse{first},
+			hop(1, "10.0.1.2", time.Millisecond, spb.TracerouteResponse_DEFAULT)...),
+			hop(2, "10.0.2.1", 2*time.Millisecond, spb.TracerouteResponse_DEFAULT)...),
+	}, {
+		desc:   "dataplane udp",
+		req:    &spb.TracerouteRequest{Destination: "10.0.2.1", Source: "10.0.0.1", L4Protocol: spb.TracerouteRequest_UDP},
+		prober: prober,
+		want: func() []*spb.TracerouteResponse {
+			resps := append([]*spb.TracerouteResponse{first}, hop(1, "10.0.1.2", time.Millisecond, spb.TracerouteResponse_DEFAULT)...)
+			for _, r := range hop(2, "10.0.2.1", 2*time.Millisecond, spb.TracerouteResponse_DEFAULT) {
+				r.IcmpCode = 3
+				resps = append(resps, r)
+			}
+			return resps
+		}(),
+	}, {
+		desc:   "dataplane no reply",
+		req:    &spb.TracerouteRequest{Destination: "10.0.2.1", Source: "10.0.0.1", InitialTtl: 3, MaxTtl: 3},
+		prober: prober,
+		want: append([]*spb.TracerouteResponse{
+			{DestinationName: "10.0.2.1", DestinationAddress: "10.0.2.1", Hops: 3, PacketSize: 60},
+		}, hop(3, "", 0, spb.TracerouteResponse_NONE)...),
+	}, {
+		desc:     "dataplane tcp",
+		req:      &spb.TracerouteRequest{Destination: "10.0.2.1", Source: "10.0.0.1", L4Protocol: spb.TracerouteRequest_TCP},
+		prober:   prober,
+		want:     []*spb.TracerouteResponse{first},
+		wantCode: codes.Unimplemented,
+	}, {
+		desc:     "missing destination",
+		req:      &spb.TracerouteRequest{},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "hostname destination",
+		req:      &spb.TracerouteRequest{Destination: "lemming"},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "l3protocol mismatch",
+		req:      &spb.TracerouteRequest{Destination: "10.0.2.1", L3Protocol: pb.L3Protocol_IPV6},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "source family mismatch",
+		req:      &spb.TracerouteRequest{Destination: "10.0.2.1", Source: "2001::1"},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "max ttl below initial ttl",
+		req:      &spb.TracerouteRequest{Destination: "10.0.2.1", InitialTtl: 5, MaxTtl: 2},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "negative wait",
+		req:      &spb.TracerouteRequest{Destination: "10.0.2.1", Wait: -1},
+		wantCode: codes.InvalidArgument,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := newSystem(nil, lemmingConfig)
+			s.rib = rib
+			s.prober = tt.prober
+			stream := &mockTracerouteServer{ctx: context.Background()}
+			err := s.Traceroute(tt.req, stream)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("Traceroute() got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+			if d := cmp.Diff(tt.want, stream.responses, protocmp.Transform()); d != "" {
+				t.Errorf("Traceroute() unexpected responses (-want,+got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestSourceAddr(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	go grpcServer.Serve(lis)
+	defer grpcServer.GracefulStop()
+	c, err := ygnmi.NewClient(gnmiServer.LocalClient(), ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	ctx := context.Background()
+
+	// eth0 is in the default network instance and eth1 is in VRF1.
+	for name, addr := range map[string]string{"eth0": "10.0.0.1", "eth1": "10.0.1.1"} {
+		intf := &oc.Interface{Name: ygot.String(name)}
+		intf.GetOrCreateSubinterface(0).GetOrCreateIpv4().GetOrCreateAddress(addr).PrefixLength = ygot.Uint8(24)
+		if _, err := gnmiclient.Replace(gnmi.AddTimestampMetadata(ctx, time.Now().UnixNano()), c, ocpath.Root().Interface(name).State(), intf); err != nil {
+			t.Fatalf("cannot set state of interface %q: %v", name, err)
+		}
+	}
+	ni := &oc.NetworkInstance{Name: ygot.String("VRF1"), Type: oc.NetworkInstanceTypes_NETWORK_INSTANCE_TYPE_L3VRF}
+	niIntf := ni.GetOrCreateInterface("eth1.0")
+	niIntf.Interface = ygot.String("eth1")
+	niIntf.Subinterface = ygot.Uint32(0)
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().NetworkInstance("VRF1").Config(), ni); err != nil {
+		t.Fatalf("cannot configure network instance: %v", err)
+	}
+
+	tests := []struct {
+		desc     string
+		niName   string
+		dst      string
+		want     string
+		wantCode codes.Code
+	}{{
+		desc:   "default network instance",
+		niName: fakedevice.DefaultNetworkInstance,
+		dst:    "10.0.1.2",
+		want:   "10.0.0.1",
+	}, {
+		desc:   "vrf",
+		niName: "VRF1",
+		dst:    "10.0.0.2",
+		want:   "10.0.1.1",
+	}, {
+		desc:     "no interface in network instance",
+		niName:   "VRF2",
+		dst:      "10.0.0.2",
+		wantCode: codes.FailedPrecondition,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := newSystem(c, loadDefaultConfig(t))
+			got, err := s.sourceAddr(ctx, tt.niName, netip.MustParseAddr(tt.dst))
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("sourceAddr(%q, %v) got code %v, want %v: %v", tt.niName, tt.dst, code, tt.wantCode, err)
+			}
+			if err != nil {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("sourceAddr(%q, %v) got %v, want %v", tt.niName, tt.dst, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLinkQualification(t *testing.T) {
 	grpcServer := grpc.NewServer()
 	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
@@ -3842,3 +4160,1121 @@ func TestFile_HelperMethods(t *testing.T) {
 		t.Fatalf("Expected 0 files after reset, got %d", len(files))
 	}
 }
+
+// OS service tests
+
+// mockInstallStream implements ospb.OS_InstallServer for testing
+type mockInstallStream struct {
+	grpc.ServerStream
+	requests  []*ospb.InstallRequest
+	responses []*ospb.InstallResponse
+}
+
+func (m *mockInstallStream) Recv() (*ospb.InstallRequest, error) {
+	if len(m.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := m.requests[0]
+	m.requests = m.requests[1:]
+	return req, nil
+}
+
+func (m *mockInstallStream) Send(response *ospb.InstallResponse) error {
+	m.responses = append(m.responses, response)
+	return nil
+}
+
+// testOSPackage returns a simulated OS package with the given version and image.
+func testOSPackage(version string, image []byte) []byte {
+	pkg := append([]byte(version+"\n"), image...)
+	h := sha256.Sum256(pkg)
+	return append(pkg, h[:]...)
+}
+
+// installRequests returns the requests to transfer the package in chunks of the given size.
+func installRequests(version string, pkg []byte, chunkSize int) []*ospb.InstallRequest {
+	reqs := []*ospb.InstallRequest{{
+		Request: &ospb.InstallRequest_TransferRequest{TransferRequest: &ospb.TransferRequest{Version: version}},
+	}}
+	for len(pkg) > 0 {
+		n := min(chunkSize, len(pkg))
+		reqs = append(reqs, &ospb.InstallRequest{Request: &ospb.InstallRequest_TransferContent{TransferContent: pkg[:n]}})
+		pkg = pkg[n:]
+	}
+	return append(reqs, &ospb.InstallRequest{Request: &ospb.InstallRequest_TransferEnd{TransferEnd: &ospb.TransferEnd{}}})
+}
+
+func TestOS_Install(t *testing.T) {
+	cfg := loadDefaultConfig(t)
+	running := cfg.GetVendor().GetOsVersion()
+	corrupt := testOSPackage("2.0.0", []byte("image"))
+	corrupt[len(corrupt)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		requests []*ospb.InstallRequest
+		want     *ospb.InstallResponse
+	}{{
+		name:     "valid package",
+		requests: installRequests("2.0.0", testOSPackage("2.0.0", bytes.Repeat([]byte{1}, 1000)), 7),
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{Version: "2.0.0"}}},
+	}, {
+		name:     "forced transfer",
+		requests: installRequests("", testOSPackage("2.0.0", []byte("image")), 64),
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{Version: "2.0.0"}}},
+	}, {
+		name:     "already installed",
+		requests: installRequests(running, nil, 64)[:1],
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{Version: running}}},
+	}, {
+		name:     "forced transfer of running package",
+		requests: installRequests("", testOSPackage(running, []byte("image")), 64),
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{Type: ospb.InstallError_INSTALL_RUN_PACKAGE}}},
+	}, {
+		name:     "hash mismatch",
+		requests: installRequests("2.0.0", corrupt, 64),
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{Type: ospb.InstallError_INTEGRITY_FAIL}}},
+	}, {
+		name:     "truncated package",
+		requests: installRequests("2.0.0", []byte("2.0.0"), 64),
+		want:     &ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{Type: ospb.InstallError_PARSE_FAIL}}},
+	}, {
+		name: "standby supervisor",
+		requests: []*ospb.InstallRequest{{
+			Request: &ospb.InstallRequest_TransferRequest{TransferRequest: &ospb.TransferRequest{Version: "2.0.0", StandbySupervisor: true}},
+		}},
+		want: &ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{Type: ospb.InstallError_NOT_SUPPORTED_ON_BACKUP}}},
+	}, {
+		name: "too large",
+		requests: []*ospb.InstallRequest{{
+			Request: &ospb.InstallRequest_TransferRequest{TransferRequest: &ospb.TransferRequest{Version: "2.0.0", PackageSize: maxOSPackageSize + 1}},
+		}},
+		want: &ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: &ospb.InstallError{Type: ospb.InstallError_TOO_LARGE}}},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOS(nil, cfg)
+			stream := &mockInstallStream{requests: tt.requests}
+			if err := o.Install(stream); err != nil {
+				t.Fatalf("Install() unexpected error: %v", err)
+			}
+			if len(stream.responses) == 0 {
+				t.Fatalf("Install() sent no responses")
+			}
+			got := stream.responses[len(stream.responses)-1]
+			if ie := got.GetInstallError(); ie != nil {
+				ie.Detail = ""
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("Install() got final response %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOS_InstallProgress(t *testing.T) {
+	o := newOS(nil, loadDefaultConfig(t))
+	stream := &mockInstallStream{requests: installRequests("2.0.0", testOSPackage("2.0.0", make([]byte, 3*osProgressInterval)), 64*1024)}
+	if err := o.Install(stream); err != nil {
+		t.Fatalf("Install() unexpected error: %v", err)
+	}
+	if got := stream.responses[0].GetTransferReady(); got == nil {
+		t.Errorf("Install() got first response %v, want TransferReady", stream.responses[0])
+	}
+	var progress int
+	for _, resp := range stream.responses {
+		if resp.GetTransferProgress() != nil {
+			progress++
+		}
+	}
+	if progress != 3 {
+		t.Errorf("Install() got %d TransferProgress responses, want 3", progress)
+	}
+}
+
+func TestOS_ActivateVerify(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := gnmiServer.LocalClient()
+	c, err := ygnmi.NewClient(client, ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	ctx := context.Background()
+	cfg := loadDefaultConfig(t)
+	if err := fakedevice.NewBootTimeTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fakedevice.NewChassisComponentsTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+
+	o := newOS(c, cfg)
+	s := newSystem(c, cfg)
+	s.osServer = o
+	for _, v := range []string{"2.0.0", "3.0.0"} {
+		stream := &mockInstallStream{requests: installRequests(v, testOSPackage(v, []byte("image")), 64)}
+		if err := o.Install(stream); err != nil {
+			t.Fatalf("Install(%q) unexpected error: %v", v, err)
+		}
+	}
+
+	checkVersion := func(t *testing.T, want string) {
+		t.Helper()
+		resp, err := o.Verify(ctx, &ospb.VerifyRequest{})
+		if err != nil {
+			t.Fatalf("Verify() unexpected error: %v", err)
+		}
+		if resp.GetVersion() != want || resp.GetActivationFailMessage() != "" {
+			t.Errorf("Verify() got version %q, fail message %q, want version %q", resp.GetVersion(), resp.GetActivationFailMessage(), want)
+		}
+		if got := resp.GetVerifyStandby().GetVerifyResponse().GetVersion(); got != want {
+			t.Errorf("Verify() got standby version %q, want %q", got, want)
+		}
+		got, err := ygnmi.Get(ctx, c, ocpath.Root().System().SoftwareVersion().State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("system software-version got %q, want %q", got, want)
+		}
+		for _, name := range []string{cfg.GetComponents().GetChassisName(), cfg.GetComponents().GetSupervisor1Name()} {
+			got, err := ygnmi.Get(ctx, c, ocpath.Root().Component(name).SoftwareVersion().State())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("component %q software-version got %q, want %q", name, got, want)
+			}
+		}
+	}
+	checkVersion(t, cfg.GetVendor().GetOsVersion())
+
+	t.Run("non-existent version", func(t *testing.T) {
+		resp, err := o.Activate(ctx, &ospb.ActivateRequest{Version: "9.9.9"})
+		if err != nil {
+			t.Fatalf("Activate() unexpected error: %v", err)
+		}
+		if got := resp.GetActivateError().GetType(); got != ospb.ActivateError_NON_EXISTENT_VERSION {
+			t.Errorf("Activate() got error type %v, want %v", got, ospb.ActivateError_NON_EXISTENT_VERSION)
+		}
+	})
+
+	t.Run("activate and reboot", func(t *testing.T) {
+		prevTime, err := ygnmi.Get(ctx, c, ocpath.Root().System().BootTime().State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := o.Activate(ctx, &ospb.ActivateRequest{Version: "2.0.0"})
+		if err != nil {
+			t.Fatalf("Activate() unexpected error: %v", err)
+		}
+		if resp.GetActivateOk() == nil {
+			t.Fatalf("Activate() got %v, want ActivateOK", resp)
+		}
+		afterTime, err := ygnmi.Get(ctx, c, ocpath.Root().System().BootTime().State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !(prevTime < afterTime) {
+			t.Errorf("boot time did not update after activate")
+		}
+		checkVersion(t, "2.0.0")
+	})
+
+	t.Run("activate without reboot", func(t *testing.T) {
+		resp, err := o.Activate(ctx, &ospb.ActivateRequest{Version: "3.0.0", NoReboot: true})
+		if err != nil {
+			t.Fatalf("Activate() unexpected error: %v", err)
+		}
+		if resp.GetActivateOk() == nil {
+			t.Fatalf("Activate() got %v, want ActivateOK", resp)
+		}
+		checkVersion(t, "2.0.0")
+		if _, err := s.Reboot(ctx, &spb.RebootRequest{}); err != nil {
+			t.Fatal(err)
+		}
+		checkVersion(t, "3.0.0")
+	})
+}
+
+// Healthz service tests
+
+// mockArtifactStream implements hpb.Healthz_ArtifactServer for testing
+type mockArtifactStream struct {
+	grpc.ServerStream
+	responses []*hpb.ArtifactResponse
+}
+
+func (m *mockArtifactStream) Send(response *hpb.ArtifactResponse) error {
+	m.responses = append(m.responses, response)
+	return nil
+}
+
+// fetchArtifact returns the contents of an artifact, verifying the header and trailer.
+func fetchArtifact(t *testing.T, h *healthz, header *hpb.ArtifactHeader) []byte {
+	t.Helper()
+	stream := &mockArtifactStream{}
+	if err := h.Artifact(&hpb.ArtifactRequest{Id: header.GetId()}, stream); err != nil {
+		t.Fatalf("Artifact(%q) unexpected error: %v", header.GetId(), err)
+	}
+	if len(stream.responses) < 2 {
+		t.Fatalf("Artifact(%q) got %d responses, want header and trailer", header.GetId(), len(stream.responses))
+	}
+	if got := stream.responses[0].GetHeader(); !proto.Equal(got, header) {
+		t.Errorf("Artifact(%q) got header %v, want %v", header.GetId(), got, header)
+	}
+	if stream.responses[len(stream.responses)-1].GetTrailer() == nil {
+		t.Errorf("Artifact(%q) last response is not a trailer", header.GetId())
+	}
+	var content []byte
+	for _, resp := range stream.responses[1 : len(stream.responses)-1] {
+		content = append(content, resp.GetBytes()...)
+	}
+	file := header.GetFile()
+	if int64(len(content)) != file.GetSize() {
+		t.Errorf("Artifact(%q) got %d bytes, want %d", header.GetId(), len(content), file.GetSize())
+	}
+	if hash := sha256.Sum256(content); !bytes.Equal(hash[:], file.GetHash().GetHash()) {
+		t.Errorf("Artifact(%q) hash mismatch", header.GetId())
+	}
+	return content
+}
+
+func TestHealthz(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := gnmiServer.LocalClient()
+	c, err := ygnmi.NewClient(client, ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	ctx := context.Background()
+	cfg := loadDefaultConfig(t)
+	if err := fakedevice.NewBootTimeTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fakedevice.NewChassisComponentsTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fakedevice.NewProcessMonitoringTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newHealthz(c, cfg)
+	s := newSystem(c, cfg)
+	s.healthzServer = h
+	chassis := cfg.GetComponents().GetChassisName()
+	linecard := config.GetAllLinecardNames(cfg)[0]
+
+	listEvents := func(t *testing.T, name string, includeAck bool) []*hpb.ComponentStatus {
+		t.Helper()
+		resp, err := h.List(ctx, &hpb.ListRequest{Path: componentPath(name), IncludeAcknowledged: includeAck})
+		if err != nil {
+			t.Fatalf("List(%q) unexpected error: %v", name, err)
+		}
+		return resp.GetStatuses()
+	}
+
+	t.Run("no events", func(t *testing.T) {
+		resp, err := h.Get(ctx, &hpb.GetRequest{Path: componentPath(linecard)})
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if got := resp.GetComponent(); got.GetStatus() != hpb.Status_STATUS_HEALTHY || got.GetId() != "" {
+			t.Errorf("Get() got %v, want healthy status without event", got)
+		}
+		if got := listEvents(t, linecard, true); len(got) != 0 {
+			t.Errorf("List() got %d events, want 0", len(got))
+		}
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		if _, err := h.Get(ctx, &hpb.GetRequest{Path: componentPath("non-existent")}); status.Code(err) != codes.NotFound {
+			t.Errorf("Get() got error %v, want NotFound", err)
+		}
+	})
+
+	var rebootEvent *hpb.ComponentStatus
+	t.Run("linecard reboot", func(t *testing.T) {
+		if _, err := s.Reboot(ctx, &spb.RebootRequest{Method: spb.RebootMethod_COLD, Subcomponents: []*pb.Path{componentPath(linecard)}}); err != nil {
+			t.Fatalf("Reboot() unexpected error: %v", err)
+		}
+		events := listEvents(t, linecard, false)
+		if len(events) != 1 {
+			t.Fatalf("List() got %d events, want 1", len(events))
+		}
+		rebootEvent = events[0]
+		if rebootEvent.GetStatus() != hpb.Status_STATUS_UNHEALTHY || len(rebootEvent.GetArtifacts()) != 1 {
+			t.Fatalf("List() got event %v, want unhealthy event with one artifact", rebootEvent)
+		}
+		if content := fetchArtifact(t, h, rebootEvent.GetArtifacts()[0]); !strings.Contains(string(content), "reboot") {
+			t.Errorf("reboot artifact got %q, want reboot log", content)
+		}
+
+		resp, err := h.Get(ctx, &hpb.GetRequest{Path: componentPath(chassis)})
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		var found bool
+		for _, sub := range resp.GetComponent().GetSubcomponents() {
+			if sub.GetId() == rebootEvent.GetId() {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Get(%q) subcomponents do not contain event %q", chassis, rebootEvent.GetId())
+		}
+	})
+
+	t.Run("check event", func(t *testing.T) {
+		resp, err := h.Check(ctx, &hpb.CheckRequest{Path: componentPath(linecard), EventId: rebootEvent.GetId()})
+		if err != nil {
+			t.Fatalf("Check() unexpected error: %v", err)
+		}
+		if got := len(resp.GetStatus().GetArtifacts()); got != 2 {
+			t.Errorf("Check() got %d artifacts, want 2", got)
+		}
+		if _, err := h.Check(ctx, &hpb.CheckRequest{Path: componentPath(linecard), EventId: "non-existent"}); status.Code(err) != codes.NotFound {
+			t.Errorf("Check() got error %v, want NotFound", err)
+		}
+	})
+
+	t.Run("acknowledge", func(t *testing.T) {
+		resp, err := h.Acknowledge(ctx, &hpb.AcknowledgeRequest{Path: componentPath(linecard), Id: rebootEvent.GetId()})
+		if err != nil {
+			t.Fatalf("Acknowledge() unexpected error: %v", err)
+		}
+		if !resp.GetStatus().GetAcknowledged() {
+			t.Errorf("Acknowledge() got %v, want acknowledged event", resp.GetStatus())
+		}
+		if got := listEvents(t, linecard, false); len(got) != 0 {
+			t.Errorf("List() got %d unacknowledged events, want 0", len(got))
+		}
+		if got := listEvents(t, linecard, true); len(got) != 1 {
+			t.Errorf("List() got %d events including acknowledged, want 1", len(got))
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		resp, err := h.Check(ctx, &hpb.CheckRequest{Path: componentPath(linecard)})
+		if err != nil {
+			t.Fatalf("Check() unexpected error: %v", err)
+		}
+		if got := resp.GetStatus(); got.GetStatus() != hpb.Status_STATUS_HEALTHY || got.GetId() == rebootEvent.GetId() {
+			t.Errorf("Check() got %v, want new healthy event", got)
+		}
+		getResp, err := h.Get(ctx, &hpb.GetRequest{Path: componentPath(linecard)})
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if getResp.GetComponent().GetId() != resp.GetStatus().GetId() {
+			t.Errorf("Get() got event %q, want latest event %q", getResp.GetComponent().GetId(), resp.GetStatus().GetId())
+		}
+	})
+
+	t.Run("kill process", func(t *testing.T) {
+		if _, err := s.KillProcess(ctx, &spb.KillProcessRequest{Pid: 1002, Signal: spb.KillProcessRequest_SIGNAL_KILL}); err != nil {
+			t.Fatalf("KillProcess() unexpected error: %v", err)
+		}
+		events := listEvents(t, cfg.GetComponents().GetSupervisor1Name(), false)
+		if len(events) != 1 || len(events[0].GetArtifacts()) != 2 {
+			t.Fatalf("List() got events %v, want one event with core and log artifacts", events)
+		}
+		core := events[0].GetArtifacts()[0]
+		if !strings.HasPrefix(core.GetFile().GetName(), "core.") {
+			t.Errorf("got artifact %q, want core file", core.GetFile().GetName())
+		}
+		if content := fetchArtifact(t, h, core); len(content) < coreFileSize {
+			t.Errorf("core file got %d bytes, want at least %d", len(content), coreFileSize)
+		}
+	})
+
+	t.Run("fault", func(t *testing.T) {
+		srv := &Server{healthzServer: h}
+		srv.ReportFault("/gnoi.system.System/Reboot", status.Error(codes.Internal, "injected"))
+		events := listEvents(t, chassis, false)
+		if len(events) != 1 {
+			t.Fatalf("List() got %d events, want 1", len(events))
+		}
+		if content := fetchArtifact(t, h, events[0].GetArtifacts()[0]); !strings.Contains(string(content), "/gnoi.system.System/Reboot") {
+			t.Errorf("fault artifact got %q, want RPC method", content)
+		}
+		// The chassis is still active, so it is healthy although its latest event is not.
+		resp, err := h.Get(ctx, &hpb.GetRequest{Path: componentPath(chassis)})
+		if err != nil {
+			t.Fatalf("Get() unexpected error: %v", err)
+		}
+		if got := resp.GetComponent(); got.GetStatus() != hpb.Status_STATUS_HEALTHY || got.GetId() != events[0].GetId() {
+			t.Errorf("Get() got %v, want healthy status with event %q", got, events[0].GetId())
+		}
+	})
+}
+
+// FactoryReset service tests
+
+func TestFactoryReset(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := gnmiServer.LocalClient()
+	c, err := ygnmi.NewClient(client, ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	ctx := context.Background()
+	cfg := loadDefaultConfig(t)
+	if err := fakedevice.NewBootTimeTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fakedevice.NewChassisComponentsTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+	factoryVersion := cfg.GetVendor().GetOsVersion()
+
+	newReset := func(t *testing.T) (*Server, *factoryReset) {
+		t.Helper()
+		sys := newSystem(c, cfg)
+		o := newOS(c, cfg)
+		sys.osServer = o
+		h := newHealthz(c, cfg)
+		sys.healthzServer = h
+		f := newFile()
+		fr := newFactoryReset(sys, f, o, h)
+		return &Server{resetServer: fr, healthzServer: h}, fr
+	}
+
+	t.Run("reset", func(t *testing.T) {
+		srv, fr := newReset(t)
+		content := []byte("startup config")
+		fr.file.files["/etc/config.json"] = &fileInfo{
+			path:        "/etc/config.json",
+			content:     content,
+			permissions: 0644,
+			created:     time.Now(),
+			modified:    time.Now(),
+		}
+		fr.healthz.componentRebooted(cfg.GetComponents().GetChassisName(), time.Now().UnixNano())
+		fr.os.installed["2.0"] = true
+		fr.os.next = "2.0"
+
+		var resets []string
+		srv.OnFactoryReset(func(context.Context) error {
+			resets = append(resets, "gribi")
+			return nil
+		}, func(context.Context) error {
+			resets = append(resets, "config")
+			return nil
+		})
+		srv.OnFactoryReset(func(context.Context) error {
+			resets = append(resets, "sysrib")
+			return nil
+		})
+
+		prevTime, err := ygnmi.Get(ctx, c, ocpath.Root().System().BootTime().State())
+		if err != nil {
+			t.Fatalf("cannot get boot time: %v", err)
+		}
+		resp, err := fr.Start(ctx, &frpb.StartRequest{FactoryOs: true, ZeroFill: true})
+		if err != nil {
+			t.Fatalf("Start() unexpected error: %v", err)
+		}
+		if resp.GetResetSuccess() == nil {
+			t.Fatalf("Start() got %v, want success", resp)
+		}
+
+		if diff := cmp.Diff([]string{"gribi", "config", "sysrib"}, resets); diff != "" {
+			t.Errorf("resetters called (-want, +got):\n%s", diff)
+		}
+		if got := fr.file.ListFiles(); len(got) != 0 {
+			t.Errorf("got files %v after reset, want none", got)
+		}
+		if !bytes.Equal(content, make([]byte, len(content))) {
+			t.Errorf("file content got %q, want zero filled", content)
+		}
+		if got := len(fr.healthz.events); got != 0 {
+			t.Errorf("got health events for %d components after reset, want none", got)
+		}
+		if diff := cmp.Diff(map[string]bool{factoryVersion: true}, fr.os.installed); diff != "" {
+			t.Errorf("installed OS versions (-want, +got):\n%s", diff)
+		}
+		if fr.os.running != factoryVersion {
+			t.Errorf("running OS version got %q, want %q", fr.os.running, factoryVersion)
+		}
+		afterTime, err := ygnmi.Get(ctx, c, ocpath.Root().System().BootTime().State())
+		if err != nil {
+			t.Fatalf("cannot get boot time: %v", err)
+		}
+		if afterTime <= prevTime {
+			t.Errorf("boot time got %d, want later than %d", afterTime, prevTime)
+		}
+	})
+
+	t.Run("keep OS", func(t *testing.T) {
+		_, fr := newReset(t)
+		fr.os.installed["2.0"] = true
+		if _, err := fr.Start(ctx, &frpb.StartRequest{}); err != nil {
+			t.Fatalf("Start() unexpected error: %v", err)
+		}
+		if !fr.os.installed["2.0"] {
+			t.Errorf("installed OS versions got %v, want version 2.0 to be kept", fr.os.installed)
+		}
+	})
+
+	t.Run("reset error", func(t *testing.T) {
+		srv, fr := newReset(t)
+		srv.OnFactoryReset(func(context.Context) error {
+			return status.Error(codes.Internal, "flush failed")
+		})
+		resp, err := fr.Start(ctx, &frpb.StartRequest{})
+		if err != nil {
+			t.Fatalf("Start() unexpected error: %v", err)
+		}
+		if re := resp.GetResetError(); !re.GetOther() || !strings.Contains(re.GetDetail(), "flush failed") {
+			t.Errorf("Start() got %v, want reset error", resp)
+		}
+	})
+
+	t.Run("reboot pending", func(t *testing.T) {
+		_, fr := newReset(t)
+		fr.system.hasPendingReboot = true
+		resp, err := fr.Start(ctx, &frpb.StartRequest{})
+		if err != nil {
+			t.Fatalf("Start() unexpected error: %v", err)
+		}
+		if !resp.GetResetError().GetOther() {
+			t.Errorf("Start() got %v, want reset error", resp)
+		}
+	})
+}
+
+func TestRebootAppliesBootConfig(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := gnmiServer.LocalClient()
+	c, err := ygnmi.NewClient(client, ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	ctx := context.Background()
+	cfg := loadDefaultConfig(t)
+	if err := fakedevice.NewBootTimeTask(cfg).Start(ctx, client, "local"); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSystem(c, cfg)
+	s.bootConfigServer = bootconfig.New(c)
+	if _, err := s.bootConfigServer.SetBootConfig(ctx, &bcpb.SetBootConfigRequest{BootConfig: &bpb.BootConfig{
+		OcConfig: []byte(`{"system": {"config": {"hostname": "day0"}}}`),
+	}}); err != nil {
+		t.Fatalf("SetBootConfig() unexpected error: %v", err)
+	}
+	if _, err := ygnmi.Replace(ctx, c, ocpath.Root().System().Hostname().Config(), "running"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Reboot(ctx, &spb.RebootRequest{Method: spb.RebootMethod_COLD}); err != nil {
+		t.Fatalf("Reboot() unexpected error: %v", err)
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().System().Hostname().Config()); err != nil || got != "day0" {
+		t.Errorf("hostname after reboot got %q, %v, want %q", got, err, "day0")
+	}
+}
+
+// fakeBGPClearer records the cleared neighbors.
+type fakeBGPClearer struct {
+	niName string
+	addr   netip.Addr
+	mode   fbgp.ClearMode
+}
+
+func (c *fakeBGPClearer) ClearNeighbor(_ context.Context, niName string, addr netip.Addr, mode fbgp.ClearMode) error {
+	if addr == netip.MustParseAddr("192.0.2.2") {
+		return status.Errorf(codes.NotFound, "BGP neighbor %v not found", addr)
+	}
+	c.niName, c.addr, c.mode = niName, addr, mode
+	return nil
+}
+
+func TestClearBGPNeighbor(t *testing.T) {
+	tests := []struct {
+		desc     string
+		req      *bgppb.ClearBGPNeighborRequest
+		want     *fakeBGPClearer
+		wantCode codes.Code
+	}{{
+		desc: "hard",
+		req:  &bgppb.ClearBGPNeighborRequest{Address: "192.0.2.1", Mode: bgppb.ClearBGPNeighborRequest_HARD},
+		want: &fakeBGPClearer{addr: netip.MustParseAddr("192.0.2.1"), mode: fbgp.ClearHard},
+	}, {
+		desc: "soft",
+		req:  &bgppb.ClearBGPNeighborRequest{Address: "2001:db8::1", RoutingInstance: fakedevice.DefaultNetworkInstance},
+		want: &fakeBGPClearer{niName: fakedevice.DefaultNetworkInstance, addr: netip.MustParseAddr("2001:db8::1"), mode: fbgp.ClearSoft},
+	}, {
+		desc: "soft in",
+		req:  &bgppb.ClearBGPNeighborRequest{Address: "192.0.2.1", Mode: bgppb.ClearBGPNeighborRequest_SOFTIN},
+		want: &fakeBGPClearer{addr: netip.MustParseAddr("192.0.2.1"), mode: fbgp.ClearSoftIn},
+	}, {
+		desc:     "invalid address",
+		req:      &bgppb.ClearBGPNeighborRequest{Address: "neighbor"},
+		want:     &fakeBGPClearer{},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc:     "unknown neighbor",
+		req:      &bgppb.ClearBGPNeighborRequest{Address: "192.0.2.2"},
+		want:     &fakeBGPClearer{},
+		wantCode: codes.NotFound,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c := &fakeBGPClearer{}
+			b := &bgp{clearer: c}
+			_, err := b.ClearBGPNeighbor(context.Background(), tt.req)
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("ClearBGPNeighbor() got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+			if d := cmp.Diff(tt.want, c, cmp.AllowUnexported(fakeBGPClearer{}), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+				t.Errorf("ClearBGPNeighbor() unexpected clear (-want,+got):\n%s", d)
+			}
+		})
+	}
+
+	if _, err := (&bgp{}).ClearBGPNeighbor(context.Background(), &bgppb.ClearBGPNeighborRequest{Address: "192.0.2.1"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("ClearBGPNeighbor() without BGP got error %v, want Unimplemented", err)
+	}
+}
+
+// fakeLayer2Clearer records the cleared neighbors and LLDP interfaces.
+type fakeLayer2Clearer struct {
+	neighbors []netip.Addr // neighbors are the addresses of the neighbors of all interfaces.
+	cleared   []netip.Addr
+	intf      string
+	lldp      string
+}
+
+func (c *fakeLayer2Clearer) ClearNeighbors(_ context.Context, intf string, match func(netip.Addr) bool) error {
+	c.intf = intf
+	for _, addr := range c.neighbors {
+		if match(addr) {
+			c.cleared = append(c.cleared, addr)
+		}
+	}
+	return nil
+}
+
+func (c *fakeLayer2Clearer) ClearLLDPInterface(_ context.Context, intf string) error {
+	c.lldp = intf
+	return nil
+}
+
+func TestLayer2(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ygnmi.NewClient(gnmiServer.LocalClient(), ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	setupTestInterfaces(t, c)
+	if _, err := gnmiclient.Replace(context.Background(), c, ocpath.Root().Lldp().Interface("eth0").Enabled().State(), true); err != nil {
+		t.Fatal(err)
+	}
+
+	neighbors := []netip.Addr{netip.MustParseAddr("192.0.2.1"), netip.MustParseAddr("192.0.2.2"), netip.MustParseAddr("2001:db8::1")}
+	intfPath := func(name string) *pb.Path {
+		return &pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": name}}}}
+	}
+	tests := []struct {
+		desc     string
+		clear    func(context.Context, *layer2) error
+		want     *fakeLayer2Clearer
+		wantCode codes.Code
+	}{{
+		desc: "clear all neighbors",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearNeighborDiscovery(ctx, &lpb.ClearNeighborDiscoveryRequest{})
+			return err
+		},
+		want: &fakeLayer2Clearer{cleared: neighbors},
+	}, {
+		desc: "clear ipv6 neighbors",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearNeighborDiscovery(ctx, &lpb.ClearNeighborDiscoveryRequest{Protocol: pb.L3Protocol_IPV6})
+			return err
+		},
+		want: &fakeLayer2Clearer{cleared: neighbors[2:]},
+	}, {
+		desc: "clear neighbor address",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearNeighborDiscovery(ctx, &lpb.ClearNeighborDiscoveryRequest{Protocol: pb.L3Protocol_IPV4, Address: "192.0.2.2"})
+			return err
+		},
+		want: &fakeLayer2Clearer{cleared: neighbors[1:2]},
+	}, {
+		desc: "neighbor address of other protocol",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearNeighborDiscovery(ctx, &lpb.ClearNeighborDiscoveryRequest{Protocol: pb.L3Protocol_IPV6, Address: "192.0.2.2"})
+			return err
+		},
+		want:     &fakeLayer2Clearer{},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc: "clear spanning tree",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearSpanningTree(ctx, &lpb.ClearSpanningTreeRequest{Interface: intfPath("eth1")})
+			return err
+		},
+		want: &fakeLayer2Clearer{cleared: neighbors, intf: "eth1"},
+	}, {
+		desc: "clear spanning tree unknown interface",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearSpanningTree(ctx, &lpb.ClearSpanningTreeRequest{Interface: intfPath("eth9")})
+			return err
+		},
+		want:     &fakeLayer2Clearer{},
+		wantCode: codes.NotFound,
+	}, {
+		desc: "clear lldp interface",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearLLDPInterface(ctx, &lpb.ClearLLDPInterfaceRequest{Interface: &pb.Path{Elem: []*pb.PathElem{{Name: "eth0"}}}})
+			return err
+		},
+		want: &fakeLayer2Clearer{lldp: "eth0"},
+	}, {
+		desc: "clear lldp interface not enabled",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearLLDPInterface(ctx, &lpb.ClearLLDPInterfaceRequest{Interface: intfPath("eth1")})
+			return err
+		},
+		want:     &fakeLayer2Clearer{},
+		wantCode: codes.FailedPrecondition,
+	}, {
+		desc: "clear lldp interface invalid path",
+		clear: func(ctx context.Context, l *layer2) error {
+			_, err := l.ClearLLDPInterface(ctx, &lpb.ClearLLDPInterfaceRequest{})
+			return err
+		},
+		want:     &fakeLayer2Clearer{},
+		wantCode: codes.InvalidArgument,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			clearer := &fakeLayer2Clearer{neighbors: neighbors}
+			err := tt.clear(context.Background(), &layer2{c: c, clearer: clearer})
+			if got := status.Code(err); got != tt.wantCode {
+				t.Fatalf("clear got code %v, want %v: %v", got, tt.wantCode, err)
+			}
+			tt.want.neighbors = neighbors
+			if d := cmp.Diff(tt.want, clearer, cmp.AllowUnexported(fakeLayer2Clearer{}), cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); d != "" {
+				t.Errorf("clear unexpected result (-want,+got):\n%s", d)
+			}
+		})
+	}
+
+	if _, err := (&layer2{c: c}).ClearNeighborDiscovery(context.Background(), &lpb.ClearNeighborDiscoveryRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("ClearNeighborDiscovery() without dataplane got error %v, want Unimplemented", err)
+	}
+}
+
+func TestDiagBERT(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ygnmi.NewClient(gnmiServer.LocalClient(), ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	setupTestInterfaces(t, c)
+	cfg := &configpb.Config{NetworkSimulation: &configpb.NetworkSimConfig{PacketErrorRate: 1e-9}}
+	d := newDiag(c, cfg)
+	srv := &Server{diagServer: d, healthzServer: newHealthz(c, cfg)}
+	ctx := context.Background()
+
+	intfPath := func(name string) *pb.Path {
+		return &pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}, {Name: "interface", Key: map[string]string{"name": name}}}}
+	}
+	startReq := func(name string, poly diagpb.PrbsPolynomial, secs uint32) *diagpb.StartBERTRequest_PerPortRequest {
+		return &diagpb.StartBERTRequest_PerPortRequest{Interface: intfPath(name), PrbsPolynomial: poly, TestDurationInSecs: secs}
+	}
+	startResp, err := d.StartBERT(ctx, &diagpb.StartBERTRequest{
+		BertOperationId: "bert1",
+		PerPortRequests: []*diagpb.StartBERTRequest_PerPortRequest{
+			startReq("eth0", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 60),
+			startReq("eth0", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 60),
+			startReq("eth1", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS7, 1),
+			startReq("eth2", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_UNKNOWN, 60),
+			startReq("eth3", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 0),
+			startReq("eth4", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 2*24*60*60),
+			startReq("eth99", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 60),
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartBERT() unexpected error: %v", err)
+	}
+	var gotStatus []diagpb.BertStatus
+	for _, r := range startResp.GetPerPortResponses() {
+		gotStatus = append(gotStatus, r.GetStatus())
+	}
+	wantStatus := []diagpb.BertStatus{
+		diagpb.BertStatus_BERT_STATUS_OK,
+		diagpb.BertStatus_BERT_STATUS_PORT_ALREADY_IN_BERT,
+		diagpb.BertStatus_BERT_STATUS_OK,
+		diagpb.BertStatus_BERT_STATUS_UNSUPPORTED_PRBS_POLYNOMIAL,
+		diagpb.BertStatus_BERT_STATUS_TEST_DURATION_TOO_SHORT,
+		diagpb.BertStatus_BERT_STATUS_TEST_DURATION_TOO_LONG,
+		diagpb.BertStatus_BERT_STATUS_NON_EXISTENT_PORT,
+	}
+	if diff := cmp.Diff(wantStatus, gotStatus); diff != "" {
+		t.Errorf("StartBERT() got unexpected statuses (-want, +got):\n%s", diff)
+	}
+
+	awaitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := ygnmi.Await(awaitCtx, c, ocpath.Root().Interface("eth0").OperStatus().State(), oc.Interface_OperStatus_TESTING); err != nil {
+		t.Fatalf("interface eth0 not in TESTING state during BERT: %v", err)
+	}
+	// The BERT on eth1 completes after a second and restores the interface.
+	d.mu.Lock()
+	eth1 := d.berts["eth1"]
+	d.mu.Unlock()
+	select {
+	case <-eth1.done:
+	case <-awaitCtx.Done():
+		t.Fatalf("BERT on eth1 did not complete")
+	}
+	if got, err := ygnmi.Get(ctx, c, ocpath.Root().Interface("eth1").OperStatus().State()); err != nil || got != oc.Interface_OperStatus_UP {
+		t.Fatalf("interface eth1 got oper status %v, %v after BERT, want UP", got, err)
+	}
+
+	t.Run("operation id in use", func(t *testing.T) {
+		resp, err := d.StartBERT(ctx, &diagpb.StartBERTRequest{
+			BertOperationId: "bert1",
+			PerPortRequests: []*diagpb.StartBERTRequest_PerPortRequest{startReq("eth5", diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS31, 60)},
+		})
+		if err != nil {
+			t.Fatalf("StartBERT() unexpected error: %v", err)
+		}
+		if got, want := resp.GetPerPortResponses()[0].GetStatus(), diagpb.BertStatus_BERT_STATUS_OPERATION_ID_IN_USE; got != want {
+			t.Errorf("StartBERT() got status %v, want %v", got, want)
+		}
+	})
+
+	t.Run("completed result", func(t *testing.T) {
+		resp, err := d.GetBERTResult(ctx, &diagpb.GetBERTResultRequest{
+			BertOperationId: "bert1",
+			PerPortRequests: []*diagpb.GetBERTResultRequest_PerPortRequest{{Interface: intfPath("eth1")}},
+		})
+		if err != nil {
+			t.Fatalf("GetBERTResult() unexpected error: %v", err)
+		}
+		got := resp.GetPerPortResponses()[0]
+		want := &diagpb.GetBERTResultResponse_PerPortResponse{
+			Interface:           intfPath("eth1"),
+			Status:              diagpb.BertStatus_BERT_STATUS_OK,
+			BertOperationId:     "bert1",
+			PrbsPolynomial:      diagpb.PrbsPolynomial_PRBS_POLYNOMIAL_PRBS7,
+			PeerLockEstablished: true,
+			// 10 Gb/s at a bit error ratio of 1e-9 for a second.
+			ErrorCountPerMinute: []uint32{10},
+			TotalErrors:         10,
+		}
+		if diff := cmp.Diff(want, got, protocmp.Transform(), protocmp.IgnoreFields(&diagpb.GetBERTResultResponse_PerPortResponse{}, "last_bert_start_timestamp")); diff != "" {
+			t.Errorf("GetBERTResult() got unexpected result (-want, +got):\n%s", diff)
+		}
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+		resp, err := d.GetBERTResult(ctx, &diagpb.GetBERTResultRequest{
+			BertOperationId: "bert2",
+			PerPortRequests: []*diagpb.GetBERTResultRequest_PerPortRequest{{Interface: intfPath("eth0")}, {Interface: intfPath("eth5")}},
+		})
+		if err != nil {
+			t.Fatalf("GetBERTResult() unexpected error: %v", err)
+		}
+		var got []diagpb.BertStatus
+		for _, r := range resp.GetPerPortResponses() {
+			got = append(got, r.GetStatus())
+		}
+		want := []diagpb.BertStatus{diagpb.BertStatus_BERT_STATUS_OPERATION_ID_NOT_FOUND, diagpb.BertStatus_BERT_STATUS_PORT_NOT_RUNNING_BERT}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("GetBERTResult() got unexpected statuses (-want, +got):\n%s", diff)
+		}
+	})
+
+	t.Run("fault loses peer lock", func(t *testing.T) {
+		srv.ReportFault("/gnoi.diag.Diag/GetBERTResult", status.Error(codes.Unavailable, "injected"))
+		awaitCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		for {
+			resp, err := d.GetBERTResult(awaitCtx, &diagpb.GetBERTResultRequest{ResultFromAllPorts: true})
+			if err != nil {
+				t.Fatalf("GetBERTResult() unexpected error: %v", err)
+			}
+			if len(resp.GetPerPortResponses()) != 2 {
+				t.Fatalf("GetBERTResult() got %d results, want 2", len(resp.GetPerPortResponses()))
+			}
+			eth0 := resp.GetPerPortResponses()[0]
+			if eth0.GetPeerLockLost() {
+				if eth1 := resp.GetPerPortResponses()[1]; eth1.GetPeerLockLost() {
+					t.Errorf("completed BERT on eth1 lost the peer lock")
+				}
+				break
+			}
+			select {
+			case <-awaitCtx.Done():
+				t.Fatalf("BERT on eth0 did not lose the peer lock")
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		resp, err := d.StopBERT(ctx, &diagpb.StopBERTRequest{
+			BertOperationId: "bert1",
+			PerPortRequests: []*diagpb.StopBERTRequest_PerPortRequest{{Interface: intfPath("eth0")}, {Interface: intfPath("eth1")}},
+		})
+		if err != nil {
+			t.Fatalf("StopBERT() unexpected error: %v", err)
+		}
+		for _, r := range resp.GetPerPortResponses() {
+			if r.GetStatus() != diagpb.BertStatus_BERT_STATUS_OK {
+				t.Errorf("StopBERT() got status %v for %v, want OK", r.GetStatus(), r.GetInterface())
+			}
+		}
+		got, err := ygnmi.Get(ctx, c, ocpath.Root().Interface("eth0").OperStatus().State())
+		if err != nil {
+			t.Fatalf("failed to get oper status of eth0: %v", err)
+		}
+		if got != oc.Interface_OperStatus_UP {
+			t.Errorf("interface eth0 got oper status %v after StopBERT, want UP", got)
+		}
+	})
+}
+
+// fakeLSPs records the resignaled LSPs and the cleared label counters.
+type fakeLSPs struct {
+	labels    map[string][]uint32 // labels are the incoming labels of each LSP.
+	resignals []string
+	cleared   [][]uint32
+}
+
+func (f *fakeLSPs) LSPLabels(name string) ([]uint32, bool) {
+	labels, ok := f.labels[name]
+	return labels, ok
+}
+
+func (f *fakeLSPs) ResignalLSP(_ context.Context, name string) error {
+	f.resignals = append(f.resignals, name)
+	return nil
+}
+
+func (f *fakeLSPs) ClearLabelCounters(_ context.Context, labels []uint32) error {
+	f.cleared = append(f.cleared, labels)
+	return nil
+}
+
+func TestMPLS(t *testing.T) {
+	lsps := map[string][]uint32{
+		"transit": {100},
+		"ingress": {},
+	}
+	t.Run("ClearLSP", func(t *testing.T) {
+		tests := []struct {
+			desc          string
+			req           *mplspb.ClearLSPRequest
+			wantResignals []string
+			wantCode      codes.Code
+		}{{
+			desc:          "default",
+			req:           &mplspb.ClearLSPRequest{Name: "transit"},
+			wantResignals: []string{"transit"},
+		}, {
+			desc:          "aggressive",
+			req:           &mplspb.ClearLSPRequest{Name: "transit", Mode: mplspb.ClearLSPRequest_AGGRESSIVE},
+			wantResignals: []string{"transit"},
+		}, {
+			desc:     "auto-bandwidth",
+			req:      &mplspb.ClearLSPRequest{Name: "transit", Mode: mplspb.ClearLSPRequest_AUTOBW_AGGRESSIVE},
+			wantCode: codes.Unimplemented,
+		}, {
+			desc:     "no name",
+			req:      &mplspb.ClearLSPRequest{},
+			wantCode: codes.InvalidArgument,
+		}, {
+			desc:     "unknown LSP",
+			req:      &mplspb.ClearLSPRequest{Name: "unknown"},
+			wantCode: codes.NotFound,
+		}}
+		for _, tt := range tests {
+			t.Run(tt.desc, func(t *testing.T) {
+				f := &fakeLSPs{labels: lsps}
+				m := &mpls{lsps: f, counters: f}
+				_, err := m.ClearLSP(context.Background(), tt.req)
+				if got := status.Code(err); got != tt.wantCode {
+					t.Fatalf("ClearLSP() got code %v, want %v: %v", got, tt.wantCode, err)
+				}
+				if d := cmp.Diff(tt.wantResignals, f.resignals); d != "" {
+					t.Errorf("ClearLSP() unexpected resignals (-want,+got):\n%s", d)
+				}
+			})
+		}
+	})
+	t.Run("ClearLSPCounters", func(t *testing.T) {
+		tests := []struct {
+			desc        string
+			req         *mplspb.ClearLSPCountersRequest
+			wantCleared [][]uint32
+			wantCode    codes.Code
+		}{{
+			desc:        "all",
+			req:         &mplspb.ClearLSPCountersRequest{},
+			wantCleared: [][]uint32{nil},
+		}, {
+			desc:        "named",
+			req:         &mplspb.ClearLSPCountersRequest{Name: "transit"},
+			wantCleared: [][]uint32{{100}},
+		}, {
+			desc: "no label entries",
+			req:  &mplspb.ClearLSPCountersRequest{Name: "ingress"},
+		}, {
+			desc:     "unknown LSP",
+			req:      &mplspb.ClearLSPCountersRequest{Name: "unknown"},
+			wantCode: codes.NotFound,
+		}}
+		for _, tt := range tests {
+			t.Run(tt.desc, func(t *testing.T) {
+				f := &fakeLSPs{labels: lsps}
+				m := &mpls{lsps: f, counters: f}
+				_, err := m.ClearLSPCounters(context.Background(), tt.req)
+				if got := status.Code(err); got != tt.wantCode {
+					t.Fatalf("ClearLSPCounters() got code %v, want %v: %v", got, tt.wantCode, err)
+				}
+				if d := cmp.Diff(tt.wantCleared, f.cleared); d != "" {
+					t.Errorf("ClearLSPCounters() unexpected clears (-want,+got):\n%s", d)
+				}
+			})
+		}
+	})
+
+	if _, err := (&mpls{}).ClearLSP(context.Background(), &mplspb.ClearLSPRequest{Name: "transit"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("ClearLSP() without LSPs got error %v, want Unimplemented", err)
+	}
+	if _, err := (&mpls{}).ClearLSPCounters(context.Background(), &mplspb.ClearLSPCountersRequest{}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("ClearLSPCounters() without counters got error %v, want Unimplemented", err)
+	}
+}
@@ -0,0 +1,386 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math/rand/v2"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/google/uuid"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+	"github.com/openconfig/lemming/internal/config"
+	configpb "github.com/openconfig/lemming/proto/config"
+
+	diagpb "github.com/openconfig/gnoi/diag"
+	hpb "github.com/openconfig/gnoi/healthz"
+	spb "github.com/openconfig/gnoi/system"
+	pb "github.com/openconfig/gnoi/types"
+)
+
+const (
+	// Max number of events stored per component, the oldest events are removed first.
+	maxHealthzEvents = 16
+	// Size of the synthetic core files.
+	coreFileSize = 256 * 1024
+	// Directory in which artifacts are reported to be stored.
+	artifactDir = "/var/healthz"
+)
+
+// healthz implements the gNOI Healthz service.
+//
+// Health events are raised when a component reboots, a process is killed or
+// a fault is injected into an RPC, and when a health check is requested.
+type healthz struct {
+	hpb.UnimplementedHealthzServer
+
+	c      *ygnmi.Client
+	config *configpb.Config
+
+	mu sync.Mutex
+	// events maps a component name to its events, ordered from the oldest.
+	events map[string][]*hpb.ComponentStatus
+	// artifacts maps an artifact ID to the artifact.
+	artifacts map[string]*artifact
+}
+
+// artifact is a file artifact collected for a health event.
+type artifact struct {
+	header  *hpb.ArtifactHeader
+	content []byte
+}
+
+func newHealthz(c *ygnmi.Client, config *configpb.Config) *healthz {
+	return &healthz{
+		c:         c,
+		config:    config,
+		events:    map[string][]*hpb.ComponentStatus{},
+		artifacts: map[string]*artifact{},
+	}
+}
+
+// newFileArtifact returns a file artifact with the given name and contents.
+func newFileArtifact(name, mimetype string, content []byte) *artifact {
+	id := uuid.New().String()
+	h := sha256.Sum256(content)
+	return &artifact{
+		header: &hpb.ArtifactHeader{
+			Id: id,
+			ArtifactType: &hpb.ArtifactHeader_File{File: &hpb.FileArtifactType{
+				Name:     name,
+				Path:     path.Join(artifactDir, id, name),
+				Mimetype: mimetype,
+				Size:     int64(len(content)),
+				Hash:     &pb.HashType{Method: pb.HashType_SHA256, Hash: h[:]},
+			}},
+		},
+		content: content,
+	}
+}
+
+// logArtifact returns a log file artifact containing the given lines.
+func logArtifact(name string, lines ...string) *artifact {
+	var b bytes.Buffer
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, l := range lines {
+		fmt.Fprintf(&b, "%s %s\n", now, l)
+	}
+	return newFileArtifact(name, "text/plain", b.Bytes())
+}
+
+// coreArtifact returns a synthetic core file for a process.
+func coreArtifact(pid uint32, processName string, signal spb.KillProcessRequest_Signal) *artifact {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "synthetic core file: process %s, pid %d, signal %v\n", processName, pid, signal)
+	r := rand.New(rand.NewPCG(uint64(pid), uint64(time.Now().UnixNano())))
+	for b.Len() < coreFileSize {
+		b.WriteByte(byte(r.Uint32()))
+	}
+	return newFileArtifact(fmt.Sprintf("core.%s.%d", processName, pid), "application/octet-stream", b.Bytes())
+}
+
+// componentPath returns the gNOI path of a component.
+func componentPath(name string) *pb.Path {
+	return &pb.Path{
+		Origin: "openconfig",
+		Elem: []*pb.PathElem{
+			{Name: "components"},
+			{Name: "component", Key: map[string]string{"name": name}},
+		},
+	}
+}
+
+// raise records a health event for the component with the given artifacts.
+func (h *healthz) raise(component string, st hpb.Status, artifacts ...*artifact) *hpb.ComponentStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	event := &hpb.ComponentStatus{
+		Path:    componentPath(component),
+		Status:  st,
+		Id:      uuid.New().String(),
+		Created: timestamppb.Now(),
+	}
+	for _, a := range artifacts {
+		h.artifacts[a.header.GetId()] = a
+		event.Artifacts = append(event.Artifacts, a.header)
+	}
+	events := append(h.events[component], event)
+	if len(events) > maxHealthzEvents {
+		for _, a := range events[0].GetArtifacts() {
+			delete(h.artifacts, a.GetId())
+		}
+		events = events[1:]
+	}
+	h.events[component] = events
+	log.Infof("Healthz: raised %v event %s for component %q", st, event.GetId(), component)
+	return proto.Clone(event).(*hpb.ComponentStatus)
+}
+
+// componentRebooted raises an event for a component that was rebooted.
+func (h *healthz) componentRebooted(component string, rebootTime int64) {
+	if h == nil {
+		return
+	}
+	h.raise(component, hpb.Status_STATUS_UNHEALTHY, logArtifact("reboot.log",
+		fmt.Sprintf("component %s: reboot requested at %d", component, rebootTime),
+		fmt.Sprintf("component %s: oper-status INACTIVE", component),
+		fmt.Sprintf("component %s: reboot completed, oper-status ACTIVE", component),
+	))
+}
+
+// processKilled raises an event for a process that was killed on the component.
+func (h *healthz) processKilled(component string, pid uint32, processName string, signal spb.KillProcessRequest_Signal, restart bool) {
+	if h == nil {
+		return
+	}
+	h.raise(component, hpb.Status_STATUS_UNHEALTHY,
+		coreArtifact(pid, processName, signal),
+		logArtifact("process.log",
+			fmt.Sprintf("process %s (pid %d) terminated by signal %v", processName, pid, signal),
+			fmt.Sprintf("process %s restart: %v", processName, restart),
+		))
+}
+
+// reset removes all health events and their artifacts.
+func (h *healthz) reset() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = map[string][]*hpb.ComponentStatus{}
+	h.artifacts = map[string]*artifact{}
+}
+
+// ReportFault raises a health event on the chassis for a fault injected into an RPC.
+// A fault injected into a Diag RPC also makes the running BERTs lose their peer lock.
+func (s *Server) ReportFault(rpcMethod string, err error) {
+	if strings.HasPrefix(rpcMethod, "/"+diagpb.Diag_ServiceDesc.ServiceName+"/") {
+		s.diagServer.loseLock()
+	}
+	chassis := s.healthzServer.config.GetComponents().GetChassisName()
+	s.healthzServer.raise(chassis, hpb.Status_STATUS_UNHEALTHY, logArtifact("fault.log",
+		fmt.Sprintf("fault injected into RPC %s", rpcMethod),
+		fmt.Sprintf("status: %v", status.Convert(err)),
+	))
+}
+
+// componentStatus returns the health status of a component from its oper status.
+func (h *healthz) componentStatus(ctx context.Context, name string) (hpb.Status, error) {
+	comp, err := ygnmi.Get(ctx, h.c, ocpath.Root().Component(name).State())
+	if err != nil {
+		return hpb.Status_STATUS_UNSPECIFIED, status.Errorf(codes.NotFound, "component %q not found: %v", name, err)
+	}
+	switch comp.GetOperStatus() {
+	case oc.PlatformTypes_COMPONENT_OPER_STATUS_ACTIVE, oc.PlatformTypes_COMPONENT_OPER_STATUS_UNSET:
+		return hpb.Status_STATUS_HEALTHY, nil
+	default:
+		return hpb.Status_STATUS_UNHEALTHY, nil
+	}
+}
+
+// subcomponents returns the names of the subcomponents of a component.
+func (h *healthz) subcomponents(name string) []string {
+	if name != h.config.GetComponents().GetChassisName() {
+		return nil
+	}
+	names := []string{h.config.GetComponents().GetSupervisor1Name()}
+	if sup2 := h.config.GetComponents().GetSupervisor2Name(); sup2 != "" {
+		names = append(names, sup2)
+	}
+	names = append(names, config.GetAllLinecardNames(h.config)...)
+	return append(names, config.GetAllFabricNames(h.config)...)
+}
+
+// latest returns the current status of a component. If the component has events, the status
+// carries the ID and artifacts of the latest one, the status of the event is the status when it
+// was raised so it is not reported.
+func (h *healthz) latest(ctx context.Context, name string) (*hpb.ComponentStatus, error) {
+	st, err := h.componentStatus(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	events := h.events[name]
+	if len(events) == 0 {
+		return &hpb.ComponentStatus{Path: componentPath(name), Status: st}, nil
+	}
+	cs := proto.Clone(events[len(events)-1]).(*hpb.ComponentStatus)
+	cs.Status = st
+	return cs, nil
+}
+
+// Get implements the gNOI Healthz service Get RPC.
+func (h *healthz) Get(ctx context.Context, req *hpb.GetRequest) (*hpb.GetResponse, error) {
+	name, err := extractComponentNameFromPath(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	cs, err := h.latest(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range h.subcomponents(name) {
+		subStatus, err := h.latest(ctx, sub)
+		if err != nil {
+			log.Warningf("Healthz: cannot get status of subcomponent %q: %v", sub, err)
+			continue
+		}
+		cs.Subcomponents = append(cs.Subcomponents, subStatus)
+	}
+	return &hpb.GetResponse{Component: cs}, nil
+}
+
+// List implements the gNOI Healthz service List RPC.
+func (h *healthz) List(ctx context.Context, req *hpb.ListRequest) (*hpb.ListResponse, error) {
+	name, err := extractComponentNameFromPath(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.componentStatus(ctx, name); err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	resp := &hpb.ListResponse{}
+	for _, event := range h.events[name] {
+		if event.GetAcknowledged() && !req.GetIncludeAcknowledged() {
+			continue
+		}
+		resp.Statuses = append(resp.Statuses, proto.Clone(event).(*hpb.ComponentStatus))
+	}
+	return resp, nil
+}
+
+// findEvent returns the event of the component with the given ID, h.mu must be held.
+func (h *healthz) findEvent(name, id string) (*hpb.ComponentStatus, error) {
+	for _, event := range h.events[name] {
+		if event.GetId() == id {
+			return event, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "event %q not found for component %q", id, name)
+}
+
+// Acknowledge implements the gNOI Healthz service Acknowledge RPC.
+func (h *healthz) Acknowledge(_ context.Context, req *hpb.AcknowledgeRequest) (*hpb.AcknowledgeResponse, error) {
+	name, err := extractComponentNameFromPath(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	event, err := h.findEvent(name, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	event.Acknowledged = true
+	return &hpb.AcknowledgeResponse{Status: proto.Clone(event).(*hpb.ComponentStatus)}, nil
+}
+
+// Artifact implements the gNOI Healthz service Artifact RPC.
+func (h *healthz) Artifact(req *hpb.ArtifactRequest, stream hpb.Healthz_ArtifactServer) error {
+	h.mu.Lock()
+	a, ok := h.artifacts[req.GetId()]
+	h.mu.Unlock()
+	if !ok {
+		return status.Errorf(codes.NotFound, "artifact %q not found", req.GetId())
+	}
+	if err := stream.Send(&hpb.ArtifactResponse{Contents: &hpb.ArtifactResponse_Header{Header: a.header}}); err != nil {
+		return err
+	}
+	for content := a.content; len(content) > 0; {
+		n := min(len(content), maxChunkSize)
+		if err := stream.Send(&hpb.ArtifactResponse{Contents: &hpb.ArtifactResponse_Bytes{Bytes: content[:n]}}); err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	return stream.Send(&hpb.ArtifactResponse{Contents: &hpb.ArtifactResponse_Trailer{Trailer: &hpb.ArtifactTrailer{}}})
+}
+
+// Check implements the gNOI Healthz service Check RPC.
+//
+// If an event ID is specified, a diagnostic artifact is added to that event,
+// otherwise a new event is raised with the current status of the component.
+func (h *healthz) Check(ctx context.Context, req *hpb.CheckRequest) (*hpb.CheckResponse, error) {
+	name, err := extractComponentNameFromPath(req.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	comp, err := ygnmi.Get(ctx, h.c, ocpath.Root().Component(name).State())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "component %q not found: %v", name, err)
+	}
+	st, err := h.componentStatus(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	diag := logArtifact("diag.log",
+		fmt.Sprintf("component %s: type %v", name, comp.GetType()),
+		fmt.Sprintf("component %s: oper-status %v", name, comp.GetOperStatus()),
+		fmt.Sprintf("component %s: last-reboot-time %d, last-reboot-reason %v", name, comp.GetLastRebootTime(), comp.GetLastRebootReason()),
+		fmt.Sprintf("component %s: health %v", name, st),
+	)
+
+	if req.GetEventId() == "" {
+		return &hpb.CheckResponse{Status: h.raise(name, st, diag)}, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	event, err := h.findEvent(name, req.GetEventId())
+	if err != nil {
+		return nil, err
+	}
+	h.artifacts[diag.header.GetId()] = diag
+	event.Artifacts = append(event.Artifacts, diag.header)
+	return &hpb.CheckResponse{Status: proto.Clone(event).(*hpb.ComponentStatus)}, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"net/netip"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	lpb "github.com/openconfig/gnoi/layer2"
+	pb "github.com/openconfig/gnoi/types"
+)
+
+// Layer2Clearer clears the neighbor tables and the layer 2 protocols of the interfaces.
+type Layer2Clearer interface {
+	// ClearNeighbors removes the dynamic ARP and NDP entries whose address matches,
+	// only those of the interface if intf is not empty.
+	ClearNeighbors(ctx context.Context, intf string, match func(netip.Addr) bool) error
+	// ClearLLDPInterface resets the LLDP neighbor and counters of the interface.
+	ClearLLDPInterface(ctx context.Context, intf string) error
+}
+
+type layer2 struct {
+	lpb.UnimplementedLayer2Server
+
+	c *ygnmi.Client
+	// clearer, if set, clears the layer 2 state.
+	clearer Layer2Clearer
+}
+
+// ClearNeighborDiscovery removes the dynamic ARP and NDP entries, optionally only those of
+// an address family or of a single address.
+func (l *layer2) ClearNeighborDiscovery(ctx context.Context, r *lpb.ClearNeighborDiscoveryRequest) (*lpb.ClearNeighborDiscoveryResponse, error) {
+	log.Infof("Received ClearNeighborDiscovery request: %v", r)
+	if l.clearer == nil {
+		return nil, status.Errorf(codes.Unimplemented, "neighbor discovery is not supported")
+	}
+	var addr netip.Addr
+	if r.GetAddress() != "" {
+		var err error
+		if addr, err = netip.ParseAddr(r.GetAddress()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "address must be an IP address, got %q", r.GetAddress())
+		}
+	}
+	var v4, v6 bool
+	switch r.GetProtocol() {
+	case pb.L3Protocol_UNSPECIFIED:
+		v4, v6 = true, true
+	case pb.L3Protocol_IPV4:
+		v4 = true
+	case pb.L3Protocol_IPV6:
+		v6 = true
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown protocol %v", r.GetProtocol())
+	}
+	if addr.IsValid() && (addr.Is4() && !v4 || addr.Is6() && !v6) {
+		return nil, status.Errorf(codes.InvalidArgument, "address %v does not match protocol %v", addr, r.GetProtocol())
+	}
+	match := func(a netip.Addr) bool {
+		if addr.IsValid() {
+			return a == addr
+		}
+		return a.Is4() && v4 || a.Is6() && v6
+	}
+	if err := l.clearer.ClearNeighbors(ctx, "", match); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear neighbors: %v", err)
+	}
+	return &lpb.ClearNeighborDiscoveryResponse{}, nil
+}
+
+// ClearSpanningTree flushes the ARP and NDP entries learned on the interface, or on all interfaces
+// if it is unset, as if the spanning tree topology changed. Spanning tree itself is not implemented.
+func (l *layer2) ClearSpanningTree(ctx context.Context, r *lpb.ClearSpanningTreeRequest) (*lpb.ClearSpanningTreeResponse, error) {
+	log.Infof("Received ClearSpanningTree request: %v", r)
+	if l.clearer == nil {
+		return nil, status.Errorf(codes.Unimplemented, "spanning tree is not supported")
+	}
+	var intf string
+	if r.GetInterface() != nil {
+		var err error
+		if intf, err = l.interfaceName(ctx, r.GetInterface()); err != nil {
+			return nil, err
+		}
+	}
+	if err := l.clearer.ClearNeighbors(ctx, intf, func(netip.Addr) bool { return true }); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear neighbors: %v", err)
+	}
+	return &lpb.ClearSpanningTreeResponse{}, nil
+}
+
+// ClearLLDPInterface resets the LLDP neighbor and counters of the interface.
+func (l *layer2) ClearLLDPInterface(ctx context.Context, r *lpb.ClearLLDPInterfaceRequest) (*lpb.ClearLLDPInterfaceResponse, error) {
+	log.Infof("Received ClearLLDPInterface request: %v", r)
+	if l.clearer == nil {
+		return nil, status.Errorf(codes.Unimplemented, "LLDP is not supported")
+	}
+	intf, err := l.interfaceName(ctx, r.GetInterface())
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := ygnmi.Lookup(ctx, l.c, ocpath.Root().Lldp().Interface(intf).Enabled().State())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get LLDP state of interface %q: %v", intf, err)
+	}
+	if v, ok := enabled.Val(); !ok || !v {
+		return nil, status.Errorf(codes.FailedPrecondition, "LLDP is not enabled on interface %q", intf)
+	}
+	if err := l.clearer.ClearLLDPInterface(ctx, intf); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear LLDP interface %q: %v", intf, err)
+	}
+	return &lpb.ClearLLDPInterfaceResponse{}, nil
+}
+
+// interfaceName returns the name of the interface of the path, either /interfaces/interface[name=...]
+// or a single element, and checks that the interface exists.
+func (l *layer2) interfaceName(ctx context.Context, path *pb.Path) (string, error) {
+	name := pathInterfaceName(path)
+	if name == "" {
+		return "", status.Errorf(codes.InvalidArgument, "invalid interface path, expected either single element or OpenConfig format (/interfaces/interface[name=...]), got: %v", path)
+	}
+	if _, err := ygnmi.Get(ctx, l.c, ocpath.Root().Interface(name).State()); err != nil {
+		return "", status.Errorf(codes.NotFound, "interface %s not found", name)
+	}
+	return name, nil
+}
+
+// pathInterfaceName returns the name of the interface of the path, either /interfaces/interface[name=...]
+// or a single element, or an empty string if the path is not an interface path.
+func pathInterfaceName(path *pb.Path) string {
+	elems := path.GetElem()
+	switch {
+	case len(elems) == 1:
+		return elems[0].GetName()
+	case len(elems) == 2 && elems[0].GetName() == "interfaces" && elems[1].GetName() == "interface":
+		return elems[1].GetKey()["name"]
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	mpb "github.com/openconfig/gnoi/mpls"
+)
+
+// LSPManager signals the static LSPs configured in the default network instance.
+type LSPManager interface {
+	// LSPLabels returns the incoming labels of the LSP, it returns false if the LSP doesn't exist.
+	LSPLabels(name string) ([]uint32, bool)
+	// ResignalLSP tears down the LSP and signals it again.
+	ResignalLSP(ctx context.Context, name string) error
+}
+
+// LabelCounterClearer clears the counters of the MPLS label entries.
+type LabelCounterClearer interface {
+	// ClearLabelCounters clears the counters of the entries of the labels, or of all entries if labels is empty.
+	ClearLabelCounters(ctx context.Context, labels []uint32) error
+}
+
+type mpls struct {
+	mpb.UnimplementedMPLSServer
+
+	// lsps, if set, signals the LSPs.
+	lsps LSPManager
+	// counters, if set, clears the label entry counters.
+	counters LabelCounterClearer
+}
+
+// ClearLSP tears down and signals again the named static LSP. Static LSPs have no
+// signaling protocol, so every mode except auto-bandwidth reprograms the label entries.
+func (m *mpls) ClearLSP(ctx context.Context, r *mpb.ClearLSPRequest) (*mpb.ClearLSPResponse, error) {
+	log.Infof("Received ClearLSP request: %v", r)
+	if m.lsps == nil {
+		return nil, status.Errorf(codes.Unimplemented, "LSPs are not supported")
+	}
+	if r.GetName() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "LSP name must be specified")
+	}
+	switch r.GetMode() {
+	case mpb.ClearLSPRequest_NONAGGRESSIVE, mpb.ClearLSPRequest_AGGRESSIVE, mpb.ClearLSPRequest_RESET:
+	case mpb.ClearLSPRequest_AUTOBW_AGGRESSIVE, mpb.ClearLSPRequest_AUTOBW_NONAGGRESSIVE:
+		return nil, status.Errorf(codes.Unimplemented, "auto-bandwidth is not supported")
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown mode %v", r.GetMode())
+	}
+	if _, ok := m.lsps.LSPLabels(r.GetName()); !ok {
+		return nil, status.Errorf(codes.NotFound, "LSP %q not found", r.GetName())
+	}
+	if err := m.lsps.ResignalLSP(ctx, r.GetName()); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear LSP %q: %v", r.GetName(), err)
+	}
+	return &mpb.ClearLSPResponse{}, nil
+}
+
+// ClearLSPCounters zeroes the counters of the label entries of the named LSP,
+// or of all label entries if the name is empty.
+func (m *mpls) ClearLSPCounters(ctx context.Context, r *mpb.ClearLSPCountersRequest) (*mpb.ClearLSPCountersResponse, error) {
+	log.Infof("Received ClearLSPCounters request: %v", r)
+	if m.counters == nil {
+		return nil, status.Errorf(codes.Unimplemented, "LSP counters are not supported")
+	}
+	var labels []uint32
+	if r.GetName() != "" {
+		if m.lsps == nil {
+			return nil, status.Errorf(codes.Unimplemented, "LSPs are not supported")
+		}
+		var ok bool
+		if labels, ok = m.lsps.LSPLabels(r.GetName()); !ok {
+			return nil, status.Errorf(codes.NotFound, "LSP %q not found", r.GetName())
+		}
+		// The LSP has no label entries on this device, e.g. it only has an ingress hop.
+		if len(labels) == 0 {
+			return &mpb.ClearLSPCountersResponse{}, nil
+		}
+	}
+	if err := m.counters.ClearLabelCounters(ctx, labels); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to clear LSP counters: %v", err)
+	}
+	return &mpb.ClearLSPCountersResponse{}, nil
+}
@@ -0,0 +1,301 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	configpb "github.com/openconfig/lemming/proto/config"
+
+	ospb "github.com/openconfig/gnoi/os"
+)
+
+const (
+	// 1GB max OS package size
+	maxOSPackageSize = 1024 * 1024 * 1024
+	// Send a transfer progress message every 1MB.
+	osProgressInterval = 1024 * 1024
+	// Max length of the version line of an OS package.
+	maxOSVersionLen = 256
+)
+
+// os implements the gNOI OS service.
+//
+// The OS packages are simulated and only their version is stored. A package
+// consists of the version string terminated by a newline, the image contents
+// and the SHA-256 hash of the preceding bytes.
+type os struct {
+	ospb.UnimplementedOSServer
+
+	c      *ygnmi.Client
+	config *configpb.Config
+
+	// installMu ensures that only one Install RPC runs at a time.
+	installMu sync.Mutex
+	// mu protects the fields below.
+	mu sync.Mutex
+	// running is the version of the running OS.
+	running string
+	// next is the version that will run after the next reboot.
+	next string
+	// installed is the set of installed OS versions.
+	installed map[string]bool
+	// activationFailMsg is the reason the last activation failed, if any.
+	activationFailMsg string
+}
+
+func newOS(c *ygnmi.Client, config *configpb.Config) *os {
+	version := config.GetVendor().GetOsVersion()
+	return &os{
+		c:         c,
+		config:    config,
+		running:   version,
+		next:      version,
+		installed: map[string]bool{version: true},
+	}
+}
+
+// osPackage parses and verifies an OS package as it is received.
+type osPackage struct {
+	size uint64
+	// version contains the bytes of the package until the first newline.
+	version    []byte
+	hasVersion bool
+	h          hash.Hash
+	// tail holds the last bytes received, which may be the hash.
+	tail []byte
+}
+
+func newOSPackage() *osPackage {
+	return &osPackage{
+		h: sha256.New(),
+	}
+}
+
+func (p *osPackage) Write(b []byte) (int, error) {
+	p.size += uint64(len(b))
+	p.tail = append(p.tail, b...)
+	if n := len(p.tail) - sha256.Size; n > 0 {
+		content := p.tail[:n]
+		if !p.hasVersion {
+			if i := bytes.IndexByte(content, '\n'); i >= 0 {
+				p.version = append(p.version, content[:i]...)
+				p.hasVersion = true
+			} else {
+				p.version = append(p.version, content...)
+			}
+			if len(p.version) > maxOSVersionLen {
+				return 0, fmt.Errorf("version is longer than %d bytes", maxOSVersionLen)
+			}
+		}
+		p.h.Write(content)
+		p.tail = append([]byte{}, p.tail[n:]...)
+	}
+	return len(b), nil
+}
+
+// verify returns the version of the package, or an install error if the package is invalid.
+func (p *osPackage) verify() (string, *ospb.InstallError) {
+	if !p.hasVersion || len(p.tail) < sha256.Size {
+		return "", &ospb.InstallError{Type: ospb.InstallError_PARSE_FAIL, Detail: "OS package does not contain a version and hash"}
+	}
+	if !bytes.Equal(p.h.Sum(nil), p.tail) {
+		return "", &ospb.InstallError{Type: ospb.InstallError_INTEGRITY_FAIL, Detail: "OS package hash does not match its contents"}
+	}
+	return string(p.version), nil
+}
+
+func installErr(stream ospb.OS_InstallServer, ie *ospb.InstallError) error {
+	log.Warningf("OS Install: %v: %s", ie.GetType(), ie.GetDetail())
+	return stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_InstallError{InstallError: ie}})
+}
+
+func validated(stream ospb.OS_InstallServer, version string) error {
+	return stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_Validated{Validated: &ospb.Validated{Version: version}}})
+}
+
+// Install implements the gNOI OS service Install RPC.
+func (o *os) Install(stream ospb.OS_InstallServer) error {
+	if !o.installMu.TryLock() {
+		return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_INSTALL_IN_PROGRESS, Detail: "another Install RPC is in progress"})
+	}
+	defer o.installMu.Unlock()
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	tr := req.GetTransferRequest()
+	if tr == nil {
+		return status.Errorf(codes.InvalidArgument, "expected TransferRequest, got %T", req.GetRequest())
+	}
+	log.Infof("OS Install: received transfer request %v", tr)
+	if tr.GetStandbySupervisor() {
+		return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_NOT_SUPPORTED_ON_BACKUP, Detail: "the OS is installed on both supervisors by a single Install RPC"})
+	}
+	if tr.GetPackageSize() > maxOSPackageSize {
+		return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_TOO_LARGE, Detail: fmt.Sprintf("package size %d exceeds maximum %d", tr.GetPackageSize(), maxOSPackageSize)})
+	}
+	if v := tr.GetVersion(); v != "" {
+		o.mu.Lock()
+		installed := o.installed[v]
+		o.mu.Unlock()
+		if installed {
+			log.Infof("OS Install: version %q is already installed", v)
+			return validated(stream, v)
+		}
+	}
+	if err := stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_TransferReady{TransferReady: &ospb.TransferReady{}}}); err != nil {
+		return err
+	}
+
+	pkg := newOSPackage()
+	var lastProgress uint64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return status.Error(codes.Aborted, "stream closed before TransferEnd")
+		}
+		if err != nil {
+			return err
+		}
+		switch r := req.GetRequest().(type) {
+		case *ospb.InstallRequest_TransferContent:
+			if pkg.size+uint64(len(r.TransferContent)) > maxOSPackageSize {
+				return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_TOO_LARGE, Detail: fmt.Sprintf("package exceeds maximum size %d", maxOSPackageSize)})
+			}
+			if _, err := pkg.Write(r.TransferContent); err != nil {
+				return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_PARSE_FAIL, Detail: err.Error()})
+			}
+			if pkg.size-lastProgress >= osProgressInterval {
+				lastProgress = pkg.size
+				if err := stream.Send(&ospb.InstallResponse{Response: &ospb.InstallResponse_TransferProgress{TransferProgress: &ospb.TransferProgress{BytesReceived: pkg.size}}}); err != nil {
+					return err
+				}
+			}
+		case *ospb.InstallRequest_TransferEnd:
+			version, ie := pkg.verify()
+			if ie != nil {
+				return installErr(stream, ie)
+			}
+			o.mu.Lock()
+			running := o.running
+			o.mu.Unlock()
+			if tr.GetVersion() == "" && version == running {
+				return installErr(stream, &ospb.InstallError{Type: ospb.InstallError_INSTALL_RUN_PACKAGE, Detail: fmt.Sprintf("version %q is running", version)})
+			}
+			o.mu.Lock()
+			o.installed[version] = true
+			o.mu.Unlock()
+			log.Infof("OS Install: installed version %q (%d bytes)", version, pkg.size)
+			return validated(stream, version)
+		default:
+			return status.Errorf(codes.InvalidArgument, "expected TransferContent or TransferEnd, got %T", req.GetRequest())
+		}
+	}
+}
+
+// Activate implements the gNOI OS service Activate RPC.
+func (o *os) Activate(ctx context.Context, req *ospb.ActivateRequest) (*ospb.ActivateResponse, error) {
+	log.Infof("OS Activate: received request %v", req)
+	if req.GetStandbySupervisor() {
+		return &ospb.ActivateResponse{Response: &ospb.ActivateResponse_ActivateError{ActivateError: &ospb.ActivateError{
+			Type:   ospb.ActivateError_NOT_SUPPORTED_ON_BACKUP,
+			Detail: "the OS is activated on both supervisors by a single Activate RPC",
+		}}}, nil
+	}
+	o.mu.Lock()
+	if !o.installed[req.GetVersion()] {
+		o.mu.Unlock()
+		return &ospb.ActivateResponse{Response: &ospb.ActivateResponse_ActivateError{ActivateError: &ospb.ActivateError{
+			Type:   ospb.ActivateError_NON_EXISTENT_VERSION,
+			Detail: fmt.Sprintf("version %q is not installed", req.GetVersion()),
+		}}}, nil
+	}
+	o.next = req.GetVersion()
+	o.mu.Unlock()
+
+	if !req.GetNoReboot() {
+		now := time.Now().UnixNano()
+		if err := fakedevice.Reboot(ctx, o.c, now); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to reboot: %v", err)
+		}
+		o.boot(ctx)
+	}
+	return &ospb.ActivateResponse{Response: &ospb.ActivateResponse_ActivateOk{ActivateOk: &ospb.ActivateOK{}}}, nil
+}
+
+// boot runs the version activated for the next boot, it is called after the device reboots.
+func (o *os) boot(ctx context.Context) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.next == o.running {
+		return
+	}
+	if err := fakedevice.SetSoftwareVersion(ctx, o.c, o.next, o.config); err != nil {
+		o.activationFailMsg = fmt.Sprintf("failed to activate version %q: %v", o.next, err)
+		log.Errorf("OS boot: %s", o.activationFailMsg)
+		o.next = o.running
+		return
+	}
+	log.Infof("OS boot: running version %q, previous version %q", o.next, o.running)
+	o.running = o.next
+	o.activationFailMsg = ""
+}
+
+// factoryReset removes all installed versions except the factory version
+// and activates it for the next boot.
+func (o *os) factoryReset() {
+	version := o.config.GetVendor().GetOsVersion()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.installed = map[string]bool{version: true}
+	o.next = version
+}
+
+// Verify implements the gNOI OS service Verify RPC.
+func (o *os) Verify(context.Context, *ospb.VerifyRequest) (*ospb.VerifyResponse, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	resp := &ospb.VerifyResponse{
+		Version:               o.running,
+		ActivationFailMessage: o.activationFailMsg,
+	}
+	if standby := o.config.GetComponents().GetSupervisor2Name(); standby != "" {
+		resp.VerifyStandby = &ospb.VerifyStandby{State: &ospb.VerifyStandby_VerifyResponse{VerifyResponse: &ospb.StandbyResponse{
+			Id:                    standby,
+			Version:               o.running,
+			ActivationFailMessage: o.activationFailMsg,
+		}}}
+	} else {
+		resp.VerifyStandby = &ospb.VerifyStandby{State: &ospb.VerifyStandby_StandbyState{StandbyState: &ospb.StandbyState{
+			State: ospb.StandbyState_NON_EXISTENT,
+		}}}
+	}
+	return resp, nil
+}
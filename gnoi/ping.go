@@ -0,0 +1,120 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+
+	spb "github.com/openconfig/gnoi/system"
+	pb "github.com/openconfig/gnoi/types"
+)
+
+// dataplanePing sends the echo requests of a ping through the dataplane.
+type dataplanePing struct {
+	prober        Prober
+	niName        string
+	src, dst      netip.Addr
+	doNotFragment bool
+}
+
+// newDataplanePing validates the addresses of the request, and picks the source address
+// from the interface towards the destination if it is unset.
+func (s *system) newDataplanePing(ctx context.Context, r *spb.PingRequest) (*dataplanePing, error) {
+	dst, err := netip.ParseAddr(r.GetDestination())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "destination must be an IP address, got %q", r.GetDestination())
+	}
+	switch r.GetL3Protocol() {
+	case pb.L3Protocol_IPV4:
+		if !dst.Is4() {
+			return nil, status.Errorf(codes.InvalidArgument, "destination %v is not an IPv4 address", dst)
+		}
+	case pb.L3Protocol_IPV6:
+		if !dst.Is6() {
+			return nil, status.Errorf(codes.InvalidArgument, "destination %v is not an IPv6 address", dst)
+		}
+	}
+	p := &dataplanePing{
+		prober:        s.prober,
+		niName:        r.GetNetworkInstance(),
+		dst:           dst,
+		doNotFragment: r.GetDoNotFragment(),
+	}
+	if p.niName == "" {
+		p.niName = fakedevice.DefaultNetworkInstance
+	}
+	if r.GetSource() != "" {
+		if p.src, err = netip.ParseAddr(r.GetSource()); err != nil || p.src.Is4() != dst.Is4() {
+			return nil, status.Errorf(codes.InvalidArgument, "source must be an IP address of the same family as the destination, got %q", r.GetSource())
+		}
+		return p, nil
+	}
+	if p.src, err = s.sourceAddr(ctx, p.niName, dst); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// run sends the echo requests and sends a result for each of them to the channel.
+func (p *dataplanePing) run(ctx context.Context, count int32, interval, wait time.Duration, size uint32, responseChan chan<- *fakedevice.PingPacketResult) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	maxPackets := count
+	if maxPackets == -1 {
+		maxPackets = math.MaxInt32
+	}
+	for seq := int32(1); seq <= maxPackets; seq++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		reply, err := p.prober.Probe(ctx, p.niName, &icmp.Probe{
+			Src:           p.src,
+			Dst:           p.dst,
+			TTL:           defaultPingTTL,
+			Protocol:      icmp.ProtocolICMP,
+			Size:          int(size),
+			DoNotFragment: p.doNotFragment,
+		}, wait)
+		if err != nil {
+			return err
+		}
+		result := &fakedevice.PingPacketResult{Sequence: seq}
+		// Errors such as destination unreachable are counted as lost packets.
+		if reply != nil && reply.Type == icmp.ReplyEcho {
+			result.Success = true
+			result.RTT = reply.RTT
+			result.Bytes = size + 8 // The ICMP header and the payload.
+			result.TTL = int32(reply.TTL)
+		}
+		select {
+		case responseChan <- result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
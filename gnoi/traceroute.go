@@ -0,0 +1,389 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnoi
+
+import (
+	"context"
+	"math"
+	"net/netip"
+	"slices"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/openconfig/lemming/dataplane/protocol/icmp"
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	spb "github.com/openconfig/gnoi/system"
+	pb "github.com/openconfig/gnoi/types"
+)
+
+const (
+	// Traceroute default values
+	defaultTracerouteInitialTTL = 1
+	defaultTracerouteMaxTTL     = 30
+	defaultTracerouteWait       = time.Second
+	// tracerouteProbes is the number of probes sent to each hop.
+	tracerouteProbes = 3
+	// tracerouteProbeSize is the size of the payload of each probe.
+	tracerouteProbeSize = 32
+	// defaultSimulatedHopLatency is the latency of each simulated hop, if the config has no base latency.
+	defaultSimulatedHopLatency = 10 * time.Millisecond
+)
+
+// Prober sends probes through the dataplane.
+type Prober interface {
+	// Probe sends the probe in the network instance and waits for the reply, it returns a nil reply if none is received.
+	Probe(ctx context.Context, niName string, p *icmp.Probe, wait time.Duration) (*icmp.Reply, error)
+}
+
+// RIB looks up the routes programmed in the dataplane.
+type RIB interface {
+	// NextHops returns the next-hop addresses of the route that contains the address in the network instance,
+	// or false if there is no such route.
+	NextHops(niName string, addr netip.Addr) ([]netip.Addr, bool)
+}
+
+// traceroute is a validated traceroute request.
+type traceroute struct {
+	src, dst      netip.Addr
+	niName        string
+	initialTTL    uint8
+	maxTTL        uint8
+	wait          time.Duration
+	protocol      spb.TracerouteRequest_L4Protocol
+	doNotFragment bool
+}
+
+func newTraceroute(r *spb.TracerouteRequest) (*traceroute, error) {
+	if r.GetDestination() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "destination address is required")
+	}
+	dst, err := netip.ParseAddr(r.GetDestination())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "destination must be an IP address, got %q", r.GetDestination())
+	}
+	switch r.GetL3Protocol() {
+	case pb.L3Protocol_IPV4:
+		if !dst.Is4() {
+			return nil, status.Errorf(codes.InvalidArgument, "destination %v is not an IPv4 address", dst)
+		}
+	case pb.L3Protocol_IPV6:
+		if !dst.Is6() {
+			return nil, status.Errorf(codes.InvalidArgument, "destination %v is not an IPv6 address", dst)
+		}
+	}
+	tr := &traceroute{
+		dst:           dst,
+		niName:        r.GetNetworkInstance(),
+		protocol:      r.GetL4Protocol(),
+		doNotFragment: r.GetDoNotFragment(),
+		wait:          time.Duration(r.GetWait()),
+	}
+	if tr.niName == "" {
+		tr.niName = fakedevice.DefaultNetworkInstance
+	}
+	if r.GetSource() != "" {
+		if tr.src, err = netip.ParseAddr(r.GetSource()); err != nil || tr.src.Is4() != dst.Is4() {
+			return nil, status.Errorf(codes.InvalidArgument, "source must be an IP address of the same family as the destination, got %q", r.GetSource())
+		}
+	}
+
+	initialTTL, maxTTL := int64(r.GetInitialTtl()), int64(r.GetMaxTtl())
+	if initialTTL == 0 {
+		initialTTL = defaultTracerouteInitialTTL
+	}
+	if maxTTL == 0 {
+		maxTTL = defaultTracerouteMaxTTL
+	}
+	// The probes are routed by the device, which decrements their TTL, so the largest TTL can't be used.
+	if maxTTL < initialTTL || maxTTL >= math.MaxUint8 {
+		return nil, status.Errorf(codes.InvalidArgument, "max_ttl must be between initial_ttl (%d) and %d, got %d", initialTTL, math.MaxUint8-1, maxTTL)
+	}
+	tr.initialTTL, tr.maxTTL = uint8(initialTTL), uint8(maxTTL)
+
+	switch {
+	case tr.wait == 0:
+		tr.wait = defaultTracerouteWait
+	case tr.wait < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "wait must be >= 0, got %d", r.GetWait())
+	}
+	return tr, nil
+}
+
+// packetSize returns the size of the IP packets of the probes.
+func (tr *traceroute) packetSize() int32 {
+	size := int32(8 + tracerouteProbeSize) // ICMP or UDP header and payload.
+	if tr.dst.Is4() {
+		return size + 20
+	}
+	return size + 40
+}
+
+// Traceroute implements the gNOI System service Traceroute RPC.
+//
+// If the device has a dataplane, probes are sent out of its ports and the ICMP replies are received
+// through the CPU port. Otherwise, the hops are simulated from the next hops of the route to the destination.
+func (s *system) Traceroute(r *spb.TracerouteRequest, stream spb.System_TracerouteServer) error {
+	log.Infof("Received traceroute request: %v", r)
+	tr, err := newTraceroute(r)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(&spb.TracerouteResponse{
+		DestinationName:    r.GetDestination(),
+		DestinationAddress: tr.dst.String(),
+		Hops:               int32(tr.maxTTL),
+		PacketSize:         tr.packetSize(),
+	}); err != nil {
+		return err
+	}
+	if s.prober != nil {
+		return s.probeTraceroute(stream, tr)
+	}
+	return s.simulateTraceroute(stream, tr)
+}
+
+// probeTraceroute sends probes with incrementing TTLs until the destination replies.
+func (s *system) probeTraceroute(stream spb.System_TracerouteServer, tr *traceroute) error {
+	ctx := stream.Context()
+	var proto icmp.Protocol
+	switch tr.protocol {
+	case spb.TracerouteRequest_ICMP:
+		proto = icmp.ProtocolICMP
+	case spb.TracerouteRequest_UDP:
+		proto = icmp.ProtocolUDP
+	default:
+		return status.Errorf(codes.Unimplemented, "unsupported protocol %v", tr.protocol)
+	}
+	if !tr.src.IsValid() {
+		src, err := s.sourceAddr(ctx, tr.niName, tr.dst)
+		if err != nil {
+			return err
+		}
+		tr.src = src
+	}
+
+	for ttl := int(tr.initialTTL); ttl <= int(tr.maxTTL); ttl++ {
+		done := false
+		for range tracerouteProbes {
+			// The probes are routed by the local dataplane, which decrements the TTL before they leave the device.
+			reply, err := s.prober.Probe(ctx, tr.niName, &icmp.Probe{
+				Src:           tr.src,
+				Dst:           tr.dst,
+				TTL:           uint8(ttl + 1),
+				Protocol:      proto,
+				Size:          tracerouteProbeSize,
+				DoNotFragment: tr.doNotFragment,
+			}, tr.wait)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return status.Errorf(codes.Internal, "failed to probe hop %d: %v", ttl, err)
+			}
+			resp := &spb.TracerouteResponse{
+				Hop:   int32(ttl),
+				State: spb.TracerouteResponse_NONE,
+			}
+			if reply != nil {
+				resp.Address = reply.From.String()
+				resp.Rtt = reply.RTT.Nanoseconds()
+				var reached bool
+				resp.State, reached = replyState(reply)
+				if reply.Type == icmp.ReplyUnreachable {
+					resp.IcmpCode = int32(reply.Code)
+				}
+				done = done || reached
+			}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+		if done {
+			break
+		}
+	}
+	return nil
+}
+
+// replyState returns the traceroute state of the reply, and whether the traceroute is complete.
+func replyState(reply *icmp.Reply) (spb.TracerouteResponse_State, bool) {
+	switch reply.Type {
+	case icmp.ReplyEcho:
+		return spb.TracerouteResponse_DEFAULT, true
+	case icmp.ReplyTimeExceeded:
+		return spb.TracerouteResponse_DEFAULT, false
+	case icmp.ReplyUnreachable:
+		if reply.From.Is4() {
+			switch reply.Code {
+			case 0:
+				return spb.TracerouteResponse_NETWORK_UNREACHABLE, true
+			case 1:
+				return spb.TracerouteResponse_HOST_UNREACHABLE, true
+			case 2:
+				return spb.TracerouteResponse_PROTOCOL_UNREACHABLE, true
+			case 3: // Port unreachable, the UDP probe reached the destination.
+				return spb.TracerouteResponse_DEFAULT, true
+			case 4:
+				return spb.TracerouteResponse_FRAGMENTATION_NEEDED, true
+			case 5:
+				return spb.TracerouteResponse_SOURCE_ROUTE_FAILED, true
+			case 9, 10, 13:
+				return spb.TracerouteResponse_PROHIBITED, true
+			case 14:
+				return spb.TracerouteResponse_PRECEDENCE_VIOLATION, true
+			case 15:
+				return spb.TracerouteResponse_PRECEDENCE_CUTOFF, true
+			}
+			return spb.TracerouteResponse_ICMP, true
+		}
+		switch reply.Code {
+		case 0:
+			return spb.TracerouteResponse_NETWORK_UNREACHABLE, true
+		case 1:
+			return spb.TracerouteResponse_PROHIBITED, true
+		case 3:
+			return spb.TracerouteResponse_HOST_UNREACHABLE, true
+		case 4: // Port unreachable, the UDP probe reached the destination.
+			return spb.TracerouteResponse_DEFAULT, true
+		}
+		return spb.TracerouteResponse_ICMP, true
+	}
+	return spb.TracerouteResponse_UNKNOWN, true
+}
+
+// sourceAddr returns the address of the interface of the network instance towards the destination,
+// which is used as the source of the probes.
+// If no interface is on the subnet of the next hop, any address of the same family is used.
+func (s *system) sourceAddr(ctx context.Context, niName string, dst netip.Addr) (netip.Addr, error) {
+	target := dst
+	if s.rib != nil {
+		if nhs, ok := s.rib.NextHops(niName, dst); ok && len(nhs) > 0 {
+			target = nhs[0]
+		}
+	}
+	nis, err := ygnmi.LookupAll(ctx, s.c, ocpath.Root().NetworkInstanceAny().Config())
+	if err != nil {
+		return netip.Addr{}, status.Errorf(codes.Internal, "failed to get network instances: %v", err)
+	}
+	// Subinterfaces that are not in any network instance are in the default one.
+	type subintfKey struct {
+		name  string
+		index uint32
+	}
+	subintfNI := map[subintfKey]string{}
+	for _, v := range nis {
+		ni, ok := v.Val()
+		if !ok {
+			continue
+		}
+		for _, intf := range ni.Interface {
+			if intf.Interface != nil && intf.Subinterface != nil {
+				subintfNI[subintfKey{name: intf.GetInterface(), index: intf.GetSubinterface()}] = ni.GetName()
+			}
+		}
+	}
+	intfs, err := ygnmi.LookupAll(ctx, s.c, ocpath.Root().InterfaceAny().State())
+	if err != nil {
+		return netip.Addr{}, status.Errorf(codes.Internal, "failed to get interface addresses: %v", err)
+	}
+	var prefixes []netip.Prefix
+	for _, v := range intfs {
+		intf, ok := v.Val()
+		if !ok {
+			continue
+		}
+		for _, subintf := range intf.Subinterface {
+			ni, ok := subintfNI[subintfKey{name: intf.GetName(), index: subintf.GetIndex()}]
+			if !ok {
+				ni = fakedevice.DefaultNetworkInstance
+			}
+			if ni != niName {
+				continue
+			}
+			if dst.Is4() {
+				for _, a := range subintf.GetIpv4().Address {
+					if ip, err := netip.ParseAddr(a.GetIp()); err == nil {
+						prefixes = append(prefixes, netip.PrefixFrom(ip, int(a.GetPrefixLength())))
+					}
+				}
+				continue
+			}
+			for _, a := range subintf.GetIpv6().Address {
+				if ip, err := netip.ParseAddr(a.GetIp()); err == nil && ip.IsGlobalUnicast() {
+					prefixes = append(prefixes, netip.PrefixFrom(ip, int(a.GetPrefixLength())))
+				}
+			}
+		}
+	}
+	if len(prefixes) == 0 {
+		return netip.Addr{}, status.Errorf(codes.FailedPrecondition, "no interface address in network instance %q to send packets to %v from", niName, dst)
+	}
+	// Sort the prefixes, so that the fallback address does not depend on the order of the interfaces.
+	slices.SortFunc(prefixes, func(a, b netip.Prefix) int {
+		return a.Addr().Compare(b.Addr())
+	})
+	for _, pfx := range prefixes {
+		if pfx.Contains(target) {
+			return pfx.Addr(), nil
+		}
+	}
+	return prefixes[0].Addr(), nil
+}
+
+// simulateTraceroute responds with the hops from the RIB: the next hop of the route to the destination, then the destination.
+func (s *system) simulateTraceroute(stream spb.System_TracerouteServer, tr *traceroute) error {
+	hops := []netip.Addr{}
+	if s.rib != nil {
+		nhs, ok := s.rib.NextHops(tr.niName, tr.dst)
+		if !ok {
+			return stream.Send(&spb.TracerouteResponse{
+				Hop:   int32(tr.initialTTL),
+				State: spb.TracerouteResponse_NETWORK_UNREACHABLE,
+			})
+		}
+		if len(nhs) > 0 {
+			hops = append(hops, nhs[0])
+		}
+	}
+	hops = append(hops, tr.dst)
+
+	latency := defaultSimulatedHopLatency
+	if base := s.config.GetNetworkSimulation().GetBaseLatencyMs(); base > 0 {
+		latency = time.Duration(base) * time.Millisecond
+	}
+	for ttl := int(tr.initialTTL); ttl <= int(tr.maxTTL); ttl++ {
+		addr := hops[min(ttl, len(hops))-1]
+		for range tracerouteProbes {
+			if err := stream.Send(&spb.TracerouteResponse{
+				Hop:     int32(ttl),
+				Address: addr.String(),
+				Rtt:     (time.Duration(ttl) * latency).Nanoseconds(),
+				State:   spb.TracerouteResponse_DEFAULT,
+			}); err != nil {
+				return err
+			}
+		}
+		if addr == tr.dst {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package authz is a gNSI authz server, that also enforces the policy using gRPC interceptors.
+package authz
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	authzpb "github.com/openconfig/gnsi/authz"
+)
+
+const (
+	usernameKey = "username"
+)
+
+type policyData struct {
+	policy    *policy
+	rawPolicy string
+	version   string
+	createdOn uint64
+}
+
+// Server implements the authz gRPC server.
+type Server struct {
+	authzpb.UnimplementedAuthzServer
+	rotationInProgress atomic.Bool
+	mu                 sync.RWMutex
+	active             *policyData
+}
+
+// New returns a new authz server, with no policy all RPCs are permitted.
+func New() *Server {
+	return &Server{}
+}
+
+// Rotate implements the authz Rotate RPC. The uploaded policy is enforced immediately,
+// but it is rolled back to the previous policy if the stream ends before the rotation is finalized.
+func (s *Server) Rotate(rs authzpb.Authz_RotateServer) error {
+	if !s.rotationInProgress.CompareAndSwap(false, true) {
+		return status.Error(codes.Unavailable, "another rotation is already in progress")
+	}
+	defer s.rotationInProgress.Store(false)
+
+	var prev *policyData
+	uploaded := false
+	defer func() {
+		if !uploaded {
+			return
+		}
+		log.Infof("authz rotation not finalized, rolling back policy")
+		s.mu.Lock()
+		s.active = prev
+		s.mu.Unlock()
+	}()
+
+	for {
+		resp, err := rs.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.GetAuthzProfileId() != "" {
+			return status.Errorf(codes.Unimplemented, "only the default authz profile is supported, got %q", resp.GetAuthzProfileId())
+		}
+		switch req := resp.RotateRequest.(type) {
+		case *authzpb.RotateAuthzRequest_UploadRequest:
+			if uploaded {
+				return status.Error(codes.FailedPrecondition, "only a single upload request can be sent per Rotate RPC")
+			}
+			p, err := parsePolicy(req.UploadRequest.GetPolicy())
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "invalid policy: %v", err)
+			}
+			s.mu.Lock()
+			if !resp.GetForceOverwrite() && s.active != nil && s.active.version == req.UploadRequest.GetVersion() {
+				s.mu.Unlock()
+				return status.Errorf(codes.AlreadyExists, "policy version %q already in use", req.UploadRequest.GetVersion())
+			}
+			prev = s.active
+			s.active = &policyData{
+				policy:    p,
+				rawPolicy: req.UploadRequest.GetPolicy(),
+				version:   req.UploadRequest.GetVersion(),
+				createdOn: req.UploadRequest.GetCreatedOn(),
+			}
+			s.mu.Unlock()
+			uploaded = true
+			if err := rs.Send(&authzpb.RotateAuthzResponse{
+				RotateResponse: &authzpb.RotateAuthzResponse_UploadResponse{
+					UploadResponse: &authzpb.UploadResponse{},
+				},
+			}); err != nil {
+				return err
+			}
+		case *authzpb.RotateAuthzRequest_FinalizeRotation:
+			if !uploaded {
+				return status.Error(codes.FailedPrecondition, "finalize rotation called before upload request")
+			}
+			uploaded = false
+			return nil
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", req)
+		}
+	}
+}
+
+// Probe implements the authz Probe RPC.
+func (s *Server) Probe(_ context.Context, req *authzpb.ProbeRequest) (*authzpb.ProbeResponse, error) {
+	if req.GetUser() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user not specified")
+	}
+	if req.GetRpc() == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "rpc not specified")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.active == nil {
+		return &authzpb.ProbeResponse{Action: authzpb.ProbeResponse_ACTION_PERMIT}, nil
+	}
+	act := authzpb.ProbeResponse_ACTION_DENY
+	if s.active.policy.allowed(req.GetRpc(), []string{req.GetUser()}, nil) {
+		act = authzpb.ProbeResponse_ACTION_PERMIT
+	}
+	return &authzpb.ProbeResponse{
+		Action:  act,
+		Version: s.active.version,
+	}, nil
+}
+
+// Get implements the authz Get RPC.
+func (s *Server) Get(context.Context, *authzpb.GetRequest) (*authzpb.GetResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.active == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no policy has been set")
+	}
+	return &authzpb.GetResponse{
+		Policy:    s.active.rawPolicy,
+		CreatedOn: s.active.createdOn,
+		Version:   s.active.version,
+	}, nil
+}
+
+// principals returns the identities of the client: the SANs and the common name of
+// the client certificate and the username from the metadata.
+func principals(ctx context.Context) []string {
+	var ps []string
+	if p, ok := peer.FromContext(ctx); ok {
+		if ti, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(ti.State.PeerCertificates) > 0 {
+			cert := ti.State.PeerCertificates[0]
+			for _, u := range cert.URIs {
+				ps = append(ps, u.String())
+			}
+			ps = append(ps, cert.DNSNames...)
+			if cert.Subject.CommonName != "" {
+				ps = append(ps, cert.Subject.CommonName)
+			}
+		}
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	ps = append(ps, md.Get(usernameKey)...)
+	return ps
+}
+
+// authorize returns a PermissionDenied error if the active policy doesn't allow the rpc.
+func (s *Server) authorize(ctx context.Context, rpc string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.active == nil {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	ps := principals(ctx)
+	if !s.active.policy.allowed(rpc, ps, md) {
+		log.V(1).Infof("authz denied rpc %q for principals %v", rpc, ps)
+		return status.Errorf(codes.PermissionDenied, "unauthorized RPC request rejected")
+	}
+	return nil
+}
+
+// UnaryInterceptor is a gRPC unary interceptor that enforces the active policy.
+func (s *Server) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor is a gRPC stream interceptor that enforces the active policy.
+func (s *Server) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,337 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	authzpb "github.com/openconfig/gnsi/authz"
+)
+
+const testPolicy = `{
+  "name": "test",
+  "allow_rules": [{
+    "name": "admin",
+    "source": {"principals": ["alice"]},
+    "request": {"paths": ["/gnsi.authz.v1.Authz/*"]}
+  }],
+  "deny_rules": [{
+    "name": "no-rotate",
+    "source": {"principals": ["bob"]},
+    "request": {"paths": ["/gnsi.authz.v1.Authz/Rotate"]}
+  }, {
+    "name": "no-probe",
+    "source": {"principals": ["alice"]},
+    "request": {"paths": ["*Probe"]}
+  }]
+}`
+
+func upload(version string, force bool) *authzpb.RotateAuthzRequest {
+	return &authzpb.RotateAuthzRequest{
+		ForceOverwrite: force,
+		RotateRequest: &authzpb.RotateAuthzRequest_UploadRequest{
+			UploadRequest: &authzpb.UploadRequest{
+				Version:   version,
+				CreatedOn: 42,
+				Policy:    testPolicy,
+			},
+		},
+	}
+}
+
+var finalize = &authzpb.RotateAuthzRequest{
+	RotateRequest: &authzpb.RotateAuthzRequest_FinalizeRotation{
+		FinalizeRotation: &authzpb.FinalizeRequest{},
+	},
+}
+
+func withUser(user string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), usernameKey, user)
+}
+
+// rotate uploads and finalizes the test policy.
+func rotate(t testing.TB, client authzpb.AuthzClient, version string) {
+	t.Helper()
+	rc, err := client.Rotate(withUser("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(upload(version, false)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	if err := rc.Send(finalize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != io.EOF {
+		t.Fatalf("Rotate() unexpected err after finalize: %v", err)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		existing string
+		reqs     []*authzpb.RotateAuthzRequest
+		wantErrs []string
+	}{{
+		desc: "invalid policy",
+		reqs: []*authzpb.RotateAuthzRequest{{
+			RotateRequest: &authzpb.RotateAuthzRequest_UploadRequest{
+				UploadRequest: &authzpb.UploadRequest{
+					Policy: `{"name": "test"}`,
+				},
+			},
+		}},
+		wantErrs: []string{"invalid policy"},
+	}, {
+		desc:     "finalize before upload",
+		reqs:     []*authzpb.RotateAuthzRequest{finalize},
+		wantErrs: []string{"finalize rotation called before upload request"},
+	}, {
+		desc:     "multiple uploads",
+		reqs:     []*authzpb.RotateAuthzRequest{upload("1", false), upload("2", false)},
+		wantErrs: []string{"", "single upload request"},
+	}, {
+		desc:     "version in use",
+		existing: "1",
+		reqs:     []*authzpb.RotateAuthzRequest{upload("1", false)},
+		wantErrs: []string{"already in use"},
+	}, {
+		desc:     "version in use with force overwrite",
+		existing: "1",
+		reqs:     []*authzpb.RotateAuthzRequest{upload("1", true), finalize},
+		wantErrs: []string{"", "EOF"},
+	}, {
+		desc: "non-default profile",
+		reqs: []*authzpb.RotateAuthzRequest{{
+			AuthzProfileId: "foo",
+			RotateRequest:  upload("1", false).RotateRequest,
+		}},
+		wantErrs: []string{"default authz profile"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			client, closeFn := start(t)
+			defer closeFn()
+			if tt.existing != "" {
+				rotate(t, client, tt.existing)
+			}
+			rot, err := client.Rotate(withUser("alice"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, req := range tt.reqs {
+				if err := rot.Send(req); err != nil {
+					t.Fatal(err)
+				}
+				_, err := rot.Recv()
+				if d := errdiff.Check(err, tt.wantErrs[i]); d != "" {
+					t.Errorf("Rotate() unexpected err: %s", d)
+				}
+			}
+		})
+	}
+	t.Run("concurrent rotation", func(t *testing.T) {
+		client, closeFn := start(t)
+		defer closeFn()
+		if _, err := client.Rotate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+		c, err := client.Rotate(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Recv()
+		if d := errdiff.Check(err, "another rotation is already in progress"); d != "" {
+			t.Errorf("Rotate() unexpected err: %s", d)
+		}
+	})
+}
+
+func TestRollback(t *testing.T) {
+	client, closeFn := start(t)
+	defer closeFn()
+
+	rotate(t, client, "1")
+	rc, err := client.Rotate(withUser("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(upload("2", false)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	got, err := client.Get(withUser("alice"), &authzpb.GetRequest{})
+	if err != nil {
+		t.Fatalf("Get() unexpected err: %v", err)
+	}
+	if got.GetVersion() != "2" {
+		t.Errorf("Get() got version %q before finalize, want %q", got.GetVersion(), "2")
+	}
+	rc.CloseSend()
+	if _, err := rc.Recv(); err != io.EOF {
+		t.Fatalf("Rotate() unexpected err after close: %v", err)
+	}
+
+	got, err = client.Get(withUser("alice"), &authzpb.GetRequest{})
+	if err != nil {
+		t.Fatalf("Get() unexpected err: %v", err)
+	}
+	want := &authzpb.GetResponse{
+		Version:   "1",
+		CreatedOn: 42,
+		Policy:    testPolicy,
+	}
+	if d := cmp.Diff(want, got, protocmp.Transform()); d != "" {
+		t.Errorf("Get() unexpected diff after rollback: %s", d)
+	}
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		desc    string
+		policy  bool
+		req     *authzpb.ProbeRequest
+		want    *authzpb.ProbeResponse
+		wantErr string
+	}{{
+		desc:    "no user",
+		req:     &authzpb.ProbeRequest{Rpc: "/gnsi.authz.v1.Authz/Get"},
+		wantErr: "user not specified",
+	}, {
+		desc:    "no rpc",
+		req:     &authzpb.ProbeRequest{User: "alice"},
+		wantErr: "rpc not specified",
+	}, {
+		desc: "no policy",
+		req:  &authzpb.ProbeRequest{User: "bob", Rpc: "/gnsi.authz.v1.Authz/Rotate"},
+		want: &authzpb.ProbeResponse{Action: authzpb.ProbeResponse_ACTION_PERMIT},
+	}, {
+		desc:   "allowed",
+		policy: true,
+		req:    &authzpb.ProbeRequest{User: "alice", Rpc: "/gnsi.authz.v1.Authz/Rotate"},
+		want:   &authzpb.ProbeResponse{Action: authzpb.ProbeResponse_ACTION_PERMIT, Version: "1"},
+	}, {
+		desc:   "denied by deny rule",
+		policy: true,
+		req:    &authzpb.ProbeRequest{User: "alice", Rpc: "/gnsi.authz.v1.Authz/Probe"},
+		want:   &authzpb.ProbeResponse{Action: authzpb.ProbeResponse_ACTION_DENY, Version: "1"},
+	}, {
+		desc:   "denied by default",
+		policy: true,
+		req:    &authzpb.ProbeRequest{User: "alice", Rpc: "/gnmi.gNMI/Get"},
+		want:   &authzpb.ProbeResponse{Action: authzpb.ProbeResponse_ACTION_DENY, Version: "1"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			s := New()
+			if tt.policy {
+				p, err := parsePolicy(testPolicy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.active = &policyData{policy: p, version: "1"}
+			}
+			got, err := s.Probe(context.Background(), tt.req)
+			if d := errdiff.Check(err, tt.wantErr); d != "" {
+				t.Errorf("Probe() unexpected err: %s", d)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(tt.want, got, protocmp.Transform()); d != "" {
+				t.Errorf("Probe() unexpected diff: %s", d)
+			}
+		})
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	client, closeFn := start(t)
+	defer closeFn()
+
+	if _, err := client.Get(withUser("bob"), &authzpb.GetRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Get() without policy got err %v, want %v", err, codes.FailedPrecondition)
+	}
+	rotate(t, client, "1")
+
+	tests := []struct {
+		desc string
+		user string
+		want codes.Code
+	}{{
+		desc: "allowed",
+		user: "alice",
+		want: codes.OK,
+	}, {
+		desc: "denied",
+		user: "bob",
+		want: codes.PermissionDenied,
+	}, {
+		desc: "no user",
+		want: codes.PermissionDenied,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.user != "" {
+				ctx = withUser(tt.user)
+			}
+			_, err := client.Get(ctx, &authzpb.GetRequest{})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("Get() got code %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func start(t testing.TB) (authzpb.AuthzClient, func()) {
+	t.Helper()
+	srv := New()
+	s := grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor), grpc.StreamInterceptor(srv.StreamInterceptor))
+	authzpb.RegisterAuthzServer(s, srv)
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+
+	go s.Serve(l)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed dial server: %v", err)
+	}
+	return authzpb.NewAuthzClient(conn), func() { s.Stop() }
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// policy is a gRPC authorization policy, as defined in
+// https://github.com/grpc/proposal/blob/master/A43-grpc-authorization-api.md.
+type policy struct {
+	Name                string          `json:"name"`
+	DenyRules           []*rule         `json:"deny_rules"`
+	AllowRules          []*rule         `json:"allow_rules"`
+	AuditLoggingOptions json.RawMessage `json:"audit_logging_options,omitempty"`
+}
+
+type rule struct {
+	Name    string  `json:"name"`
+	Source  source  `json:"source"`
+	Request request `json:"request"`
+}
+
+type source struct {
+	Principals []string `json:"principals"`
+}
+
+type request struct {
+	Paths   []string  `json:"paths"`
+	Headers []*header `json:"headers"`
+}
+
+type header struct {
+	Key    string   `json:"key"`
+	Values []string `json:"values"`
+}
+
+// parsePolicy parses and validates a JSON policy.
+func parsePolicy(s string) (*policy, error) {
+	dec := json.NewDecoder(bytes.NewBufferString(s))
+	dec.DisallowUnknownFields()
+	p := &policy{}
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy: %v", err)
+	}
+	if p.Name == "" {
+		return nil, fmt.Errorf("policy name is empty")
+	}
+	if len(p.AllowRules) == 0 {
+		return nil, fmt.Errorf("policy has no allow rules")
+	}
+	for _, rules := range [][]*rule{p.DenyRules, p.AllowRules} {
+		for i, r := range rules {
+			if err := r.validate(); err != nil {
+				return nil, fmt.Errorf("rule %d: %v", i, err)
+			}
+		}
+	}
+	return p, nil
+}
+
+func (r *rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule name is empty")
+	}
+	for _, h := range r.Request.Headers {
+		key := strings.ToLower(h.Key)
+		if key == "" || len(h.Values) == 0 {
+			return fmt.Errorf("header key and values must be set")
+		}
+		if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") || key == "host" {
+			return fmt.Errorf("unsupported header %q", h.Key)
+		}
+	}
+	return nil
+}
+
+// allowed returns whether the rpc is allowed for any of the principals.
+// Deny rules take precedence over allow rules, and rpcs not matched by any rule are denied.
+func (p *policy) allowed(rpc string, principals []string, md metadata.MD) bool {
+	for _, r := range p.DenyRules {
+		if r.matches(rpc, principals, md) {
+			return false
+		}
+	}
+	for _, r := range p.AllowRules {
+		if r.matches(rpc, principals, md) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *rule) matches(rpc string, principals []string, md metadata.MD) bool {
+	if len(r.Source.Principals) > 0 && !matchesAny(r.Source.Principals, principals) {
+		return false
+	}
+	if len(r.Request.Paths) > 0 && !matchesAny(r.Request.Paths, []string{rpc}) {
+		return false
+	}
+	for _, h := range r.Request.Headers {
+		if !matchesAny(h.Values, md.Get(h.Key)) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny returns whether any of the patterns match any of the values.
+func matchesAny(patterns, values []string) bool {
+	for _, p := range patterns {
+		if p == "*" {
+			return true
+		}
+		for _, v := range values {
+			if matches(p, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches returns whether the value matches the pattern, which may contain a
+// single leading or trailing wildcard.
+func matches(pattern, value string) bool {
+	switch {
+	case strings.HasSuffix(pattern, "*"):
+		return strings.HasPrefix(value, strings.TrimSuffix(pattern, "*"))
+	case strings.HasPrefix(pattern, "*"):
+		return strings.HasSuffix(value, strings.TrimPrefix(pattern, "*"))
+	default:
+		return pattern == value
+	}
+}
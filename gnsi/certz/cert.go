@@ -0,0 +1,527 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certz
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	cmpb "github.com/openconfig/gnoi/cert"
+	certzpb "github.com/openconfig/gnsi/certz"
+)
+
+// gnxiEndpoint is the endpoint reported for the certificate of the default profile.
+const gnxiEndpoint = "gnxi"
+
+// CertificateManagement implements the legacy gNOI cert service on the SSL profiles of a certz server.
+// The certificate IDs are the SSL profile IDs, so installing or rotating the certificate DefaultProfile
+// changes the TLS identity of the gNxI servers. The CA bundle is the trust bundle of the default profile.
+type CertificateManagement struct {
+	cmpb.UnimplementedCertificateManagementServer
+	s *Server
+
+	mu      sync.Mutex
+	pending map[string]crypto.Signer // keys generated by the GenerateCSR RPC, by certificate ID
+}
+
+// NewCertificateManagement returns a gNOI cert server storing the certificates in the certz server.
+func NewCertificateManagement(s *Server) *CertificateManagement {
+	return &CertificateManagement{
+		s:       s,
+		pending: map[string]crypto.Signer{},
+	}
+}
+
+// certOp is the operation on a certificate ID, which determines whether the certificate must exist.
+type certOp int
+
+const (
+	opInstall certOp = iota // the certificate must not exist
+	opRotate                // the certificate must exist
+	opLoad                  // the certificate is installed or replaced
+)
+
+// lock marks the certificate ID as being modified, so that it is not installed or rotated concurrently.
+func (cm *CertificateManagement) lock(id string, op certOp) error {
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "certificate id not specified")
+	}
+	s := cm.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, exists := s.profiles[id][certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN]
+	switch {
+	case op == opInstall && exists:
+		return status.Errorf(codes.AlreadyExists, "certificate %q already exists", id)
+	case op == opRotate && !exists:
+		return status.Errorf(codes.NotFound, "certificate %q not found", id)
+	}
+	if s.rotating[id] {
+		return status.Errorf(codes.Unavailable, "another rotation is already in progress for certificate %q", id)
+	}
+	s.rotating[id] = true
+	return nil
+}
+
+func (cm *CertificateManagement) unlock(id string) {
+	cm.s.mu.Lock()
+	defer cm.s.mu.Unlock()
+	delete(cm.s.rotating, id)
+}
+
+// claim locks the certificate ID of the request if the stream has none yet,
+// otherwise it checks that the request is for the same certificate.
+func (cm *CertificateManagement) claim(id *string, reqID string, op certOp) error {
+	switch {
+	case *id == "":
+		if err := cm.lock(reqID, op); err != nil {
+			return err
+		}
+		*id = reqID
+	case reqID != "" && reqID != *id:
+		return status.Errorf(codes.InvalidArgument, "certificate id %q doesn't match the certificate being loaded %q", reqID, *id)
+	}
+	return nil
+}
+
+// load sets the certificate of the profile, creating it if needed, and replaces the trust bundle
+// of the default profile if cas is not empty. It returns the previous profiles to restore them on rollback.
+// The trust bundle can't be replaced while the default profile is rotated by another request.
+func (cm *CertificateManagement) load(id string, cert *tls.Certificate, cas []*x509.Certificate) (map[string]profile, error) {
+	s := cm.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(cas) > 0 && id != DefaultProfile && s.rotating[DefaultProfile] {
+		return nil, status.Errorf(codes.Unavailable, "cannot replace the trust bundle, a rotation is in progress for certificate %q", DefaultProfile)
+	}
+	now := uint64(time.Now().Unix())
+	prev := map[string]profile{id: s.profiles[id]}
+	set := func(id string, typ certzpb.ExistingEntity_EntityType, e *entity) {
+		next := maps.Clone(s.profiles[id])
+		if next == nil {
+			next = profile{}
+		}
+		next[typ] = e
+		s.profiles[id] = next
+	}
+	set(id, certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN, &entity{cert: cert, createdOn: now})
+	if len(cas) > 0 {
+		if _, ok := prev[DefaultProfile]; !ok {
+			prev[DefaultProfile] = s.profiles[DefaultProfile]
+		}
+		set(DefaultProfile, certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE, &entity{trust: cas, createdOn: now})
+	}
+	return prev, nil
+}
+
+// restore puts back the profiles returned by load, deleting those that didn't exist.
+func (cm *CertificateManagement) restore(prev map[string]profile) {
+	cm.s.mu.Lock()
+	defer cm.s.mu.Unlock()
+	for id, p := range prev {
+		if p == nil {
+			delete(cm.s.profiles, id)
+			continue
+		}
+		cm.s.profiles[id] = p
+	}
+}
+
+// Install implements the gNOI cert Install RPC. The certificate is installed once it is loaded,
+// nothing is changed if the stream ends before.
+func (cm *CertificateManagement) Install(stream cmpb.CertificateManagement_InstallServer) error {
+	var (
+		id  string
+		key crypto.Signer
+	)
+	defer func() {
+		if id != "" {
+			cm.unlock(id)
+		}
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch r := req.InstallRequest.(type) {
+		case *cmpb.InstallCertificateRequest_GenerateCsr:
+			if key != nil {
+				return status.Error(codes.FailedPrecondition, "CSR already generated")
+			}
+			if err := cm.claim(&id, r.GenerateCsr.GetCertificateId(), opInstall); err != nil {
+				return err
+			}
+			csr, k, err := generateLegacyCSR(r.GenerateCsr.GetCsrParams())
+			if err != nil {
+				return err
+			}
+			key = k
+			if err := stream.Send(&cmpb.InstallCertificateResponse{
+				InstallResponse: &cmpb.InstallCertificateResponse_GeneratedCsr{
+					GeneratedCsr: &cmpb.GenerateCSRResponse{Csr: csr},
+				},
+			}); err != nil {
+				return err
+			}
+		case *cmpb.InstallCertificateRequest_LoadCertificate:
+			if err := cm.claim(&id, r.LoadCertificate.GetCertificateId(), opInstall); err != nil {
+				return err
+			}
+			cert, cas, err := parseLoadRequest(r.LoadCertificate, key)
+			if err != nil {
+				return err
+			}
+			if _, err := cm.load(id, cert, cas); err != nil {
+				return err
+			}
+			log.Infof("installed certificate %q", id)
+			return stream.Send(&cmpb.InstallCertificateResponse{
+				InstallResponse: &cmpb.InstallCertificateResponse_LoadCertificate{
+					LoadCertificate: &cmpb.LoadCertificateResponse{},
+				},
+			})
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", r)
+		}
+	}
+}
+
+// Rotate implements the gNOI cert Rotate RPC. The loaded certificate is used immediately,
+// but it is rolled back if the stream ends before the rotation is finalized.
+func (cm *CertificateManagement) Rotate(stream cmpb.CertificateManagement_RotateServer) error {
+	var (
+		id   string
+		key  crypto.Signer
+		prev map[string]profile
+	)
+	defer func() {
+		if prev != nil {
+			log.Infof("rotation of certificate %q not finalized, rolling back", id)
+			cm.restore(prev)
+		}
+		if id != "" {
+			cm.unlock(id)
+		}
+	}()
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch r := req.RotateRequest.(type) {
+		case *cmpb.RotateCertificateRequest_GenerateCsr:
+			if key != nil || prev != nil {
+				return status.Error(codes.FailedPrecondition, "CSR already generated")
+			}
+			if err := cm.claim(&id, r.GenerateCsr.GetCertificateId(), opRotate); err != nil {
+				return err
+			}
+			csr, k, err := generateLegacyCSR(r.GenerateCsr.GetCsrParams())
+			if err != nil {
+				return err
+			}
+			key = k
+			if err := stream.Send(&cmpb.RotateCertificateResponse{
+				RotateResponse: &cmpb.RotateCertificateResponse_GeneratedCsr{
+					GeneratedCsr: &cmpb.GenerateCSRResponse{Csr: csr},
+				},
+			}); err != nil {
+				return err
+			}
+		case *cmpb.RotateCertificateRequest_LoadCertificate:
+			if prev != nil {
+				return status.Error(codes.FailedPrecondition, "certificate already loaded")
+			}
+			if err := cm.claim(&id, r.LoadCertificate.GetCertificateId(), opRotate); err != nil {
+				return err
+			}
+			cert, cas, err := parseLoadRequest(r.LoadCertificate, key)
+			if err != nil {
+				return err
+			}
+			if prev, err = cm.load(id, cert, cas); err != nil {
+				return err
+			}
+			if err := stream.Send(&cmpb.RotateCertificateResponse{
+				RotateResponse: &cmpb.RotateCertificateResponse_LoadCertificate{
+					LoadCertificate: &cmpb.LoadCertificateResponse{},
+				},
+			}); err != nil {
+				return err
+			}
+		case *cmpb.RotateCertificateRequest_FinalizeRotation:
+			if prev == nil {
+				return status.Error(codes.FailedPrecondition, "finalize rotation called before load certificate request")
+			}
+			prev = nil
+			log.Infof("rotated certificate %q", id)
+			return nil
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", r)
+		}
+	}
+}
+
+// GenerateCSR implements the gNOI cert GenerateCSR RPC. The generated key is used by
+// the next LoadCertificate RPC for the same certificate ID.
+func (cm *CertificateManagement) GenerateCSR(_ context.Context, req *cmpb.GenerateCSRRequest) (*cmpb.GenerateCSRResponse, error) {
+	if req.GetCertificateId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "certificate id not specified")
+	}
+	csr, key, err := generateLegacyCSR(req.GetCsrParams())
+	if err != nil {
+		return nil, err
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.pending[req.GetCertificateId()] = key
+	return &cmpb.GenerateCSRResponse{Csr: csr}, nil
+}
+
+// LoadCertificate implements the gNOI cert LoadCertificate RPC, it installs or replaces the certificate.
+func (cm *CertificateManagement) LoadCertificate(_ context.Context, req *cmpb.LoadCertificateRequest) (*cmpb.LoadCertificateResponse, error) {
+	id := req.GetCertificateId()
+	if err := cm.lock(id, opLoad); err != nil {
+		return nil, err
+	}
+	defer cm.unlock(id)
+
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cert, cas, err := parseLoadRequest(req, cm.pending[id])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cm.load(id, cert, cas); err != nil {
+		return nil, err
+	}
+	delete(cm.pending, id)
+	log.Infof("loaded certificate %q", id)
+	return &cmpb.LoadCertificateResponse{}, nil
+}
+
+// LoadCertificateAuthorityBundle implements the gNOI cert LoadCertificateAuthorityBundle RPC.
+// The bundle replaces the trust bundle of the default profile, unless the profile is being rotated.
+func (cm *CertificateManagement) LoadCertificateAuthorityBundle(_ context.Context, req *cmpb.LoadCertificateAuthorityBundleRequest) (*cmpb.LoadCertificateAuthorityBundleResponse, error) {
+	if len(req.GetCaCertificates()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "CA bundle contains no certificates")
+	}
+	var cas []*x509.Certificate
+	for _, c := range req.GetCaCertificates() {
+		ca, err := parseLegacyCert(c)
+		if err != nil {
+			return nil, err
+		}
+		cas = append(cas, ca)
+	}
+	s := cm.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rotating[DefaultProfile] {
+		return nil, status.Errorf(codes.Unavailable, "cannot replace the trust bundle, a rotation is in progress for certificate %q", DefaultProfile)
+	}
+	next := maps.Clone(s.profiles[DefaultProfile])
+	next[certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE] = &entity{trust: cas, createdOn: uint64(time.Now().Unix())}
+	s.profiles[DefaultProfile] = next
+	return &cmpb.LoadCertificateAuthorityBundleResponse{}, nil
+}
+
+// GetCertificates implements the gNOI cert GetCertificates RPC. It returns the certificates of all profiles.
+func (cm *CertificateManagement) GetCertificates(context.Context, *cmpb.GetCertificatesRequest) (*cmpb.GetCertificatesResponse, error) {
+	s := cm.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp := &cmpb.GetCertificatesResponse{}
+	for _, id := range slices.Sorted(maps.Keys(s.profiles)) {
+		chain := s.profiles[id][certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN]
+		if chain == nil {
+			continue
+		}
+		info := &cmpb.CertificateInfo{
+			CertificateId: id,
+			Certificate: &cmpb.Certificate{
+				Type:        cmpb.CertificateType_CT_X509,
+				Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: chain.cert.Certificate[0]}),
+			},
+			ModificationTime: int64(chain.createdOn) * int64(time.Second),
+		}
+		if id == DefaultProfile {
+			info.Endpoints = []*cmpb.Endpoint{{Type: cmpb.Endpoint_EP_DAEMON, Endpoint: gnxiEndpoint}}
+		}
+		resp.CertificateInfo = append(resp.CertificateInfo, info)
+	}
+	return resp, nil
+}
+
+// RevokeCertificates implements the gNOI cert RevokeCertificates RPC. The certificate of the
+// default profile can't be revoked as the gNxI servers would be left without a TLS identity.
+func (cm *CertificateManagement) RevokeCertificates(_ context.Context, req *cmpb.RevokeCertificatesRequest) (*cmpb.RevokeCertificatesResponse, error) {
+	s := cm.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp := &cmpb.RevokeCertificatesResponse{}
+	for _, id := range req.GetCertificateId() {
+		var reason string
+		switch {
+		case id == DefaultProfile:
+			reason = "certificate is used by the gNxI servers, it can only be rotated"
+		case s.rotating[id]:
+			reason = "certificate is being rotated"
+		}
+		if reason != "" {
+			resp.CertificateRevocationError = append(resp.CertificateRevocationError, &cmpb.CertificateRevocationError{
+				CertificateId: id,
+				ErrorMessage:  reason,
+			})
+			continue
+		}
+		// Revoking a certificate that doesn't exist succeeds.
+		if p, ok := s.profiles[id]; ok {
+			next := maps.Clone(p)
+			delete(next, certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN)
+			if len(next) == 0 {
+				delete(s.profiles, id)
+			} else {
+				s.profiles[id] = next
+			}
+		}
+		resp.RevokedCertificateId = append(resp.RevokedCertificateId, id)
+	}
+	return resp, nil
+}
+
+// CanGenerateCSR implements the gNOI cert CanGenerateCSR RPC.
+func (cm *CertificateManagement) CanGenerateCSR(_ context.Context, req *cmpb.CanGenerateCSRRequest) (*cmpb.CanGenerateCSRResponse, error) {
+	_, ok := rsaSuite(req.GetKeySize())
+	ok = ok && (req.GetKeyType() == cmpb.KeyType_KT_RSA || req.GetKeyType() == cmpb.KeyType_KT_UNKNOWN)
+	ok = ok && (req.GetCertificateType() == cmpb.CertificateType_CT_X509 || req.GetCertificateType() == cmpb.CertificateType_CT_UNKNOWN)
+	return &cmpb.CanGenerateCSRResponse{CanGenerate: ok}, nil
+}
+
+// rsaSuite returns the RSA CSR suite with the smallest key of at least bits.
+func rsaSuite(bits uint32) (certzpb.CSRSuite, bool) {
+	switch {
+	case bits <= 2048:
+		return certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_256, true
+	case bits <= 3072:
+		return certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_256, true
+	case bits <= 4096:
+		return certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_256, true
+	}
+	return 0, false
+}
+
+// generateLegacyCSR generates a new RSA private key and a PEM encoded CSR for it.
+func generateLegacyCSR(params *cmpb.CSRParams) (*cmpb.CSR, crypto.Signer, error) {
+	if t := params.GetType(); t != cmpb.CertificateType_CT_X509 && t != cmpb.CertificateType_CT_UNKNOWN {
+		return nil, nil, status.Errorf(codes.Unimplemented, "certificate type %v is not supported", t)
+	}
+	if t := params.GetKeyType(); t != cmpb.KeyType_KT_RSA && t != cmpb.KeyType_KT_UNKNOWN {
+		return nil, nil, status.Errorf(codes.Unimplemented, "key type %v is not supported", t)
+	}
+	suite, ok := rsaSuite(params.GetMinKeySize())
+	if !ok {
+		return nil, nil, status.Errorf(codes.Unimplemented, "key size %d is not supported", params.GetMinKeySize())
+	}
+	csr, key, err := generateCSR(&certzpb.CSRParams{
+		CsrSuite:           suite,
+		CommonName:         params.GetCommonName(),
+		Country:            params.GetCountry(),
+		State:              params.GetState(),
+		City:               params.GetCity(),
+		Organization:       params.GetOrganization(),
+		OrganizationalUnit: params.GetOrganizationalUnit(),
+		IpAddress:          params.GetIpAddress(),
+		EmailId:            params.GetEmailId(),
+	})
+	if err != nil {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "failed to generate CSR: %v", err)
+	}
+	return &cmpb.CSR{
+		Type: cmpb.CertificateType_CT_X509,
+		Csr:  csr.GetCertificateSigningRequest(),
+	}, key, nil
+}
+
+// parseLegacyCert parses a PEM encoded X.509 certificate.
+func parseLegacyCert(c *cmpb.Certificate) (*x509.Certificate, error) {
+	if t := c.GetType(); t != cmpb.CertificateType_CT_X509 && t != cmpb.CertificateType_CT_UNKNOWN {
+		return nil, status.Errorf(codes.Unimplemented, "certificate type %v is not supported", t)
+	}
+	der, err := decode(certzpb.CertificateEncoding_CERTIFICATE_ENCODING_PEM, c.GetCertificate())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+	}
+	return cert, nil
+}
+
+// parseLoadRequest returns the TLS certificate and the CA certificates of the request.
+// The private key is either in the request or the key generated for the CSR.
+func parseLoadRequest(req *cmpb.LoadCertificateRequest, genKey crypto.Signer) (*tls.Certificate, []*x509.Certificate, error) {
+	leaf, err := parseLegacyCert(req.GetCertificate())
+	if err != nil {
+		return nil, nil, err
+	}
+	key := genKey
+	if pk := req.GetKeyPair().GetPrivateKey(); pk != nil {
+		if key, err = parseKey(pk); err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "invalid private key: %v", err)
+		}
+	}
+	if key == nil {
+		return nil, nil, status.Error(codes.InvalidArgument, "no key pair provided and no CSR was generated")
+	}
+	if err := checkKey(key, leaf); err != nil {
+		return nil, nil, err
+	}
+	var cas []*x509.Certificate
+	for _, c := range req.GetCaCertificates() {
+		ca, err := parseLegacyCert(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		cas = append(cas, ca)
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, cas, nil
+}
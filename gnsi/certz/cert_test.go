@@ -0,0 +1,373 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certz
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+
+	cmpb "github.com/openconfig/gnoi/cert"
+	certzpb "github.com/openconfig/gnsi/certz"
+)
+
+func legacyCert(cert []byte) *cmpb.Certificate {
+	return &cmpb.Certificate{Type: cmpb.CertificateType_CT_X509, Certificate: cert}
+}
+
+func loadRequest(id string, cert, key []byte, cas ...*testCA) *cmpb.LoadCertificateRequest {
+	req := &cmpb.LoadCertificateRequest{CertificateId: id, Certificate: legacyCert(cert)}
+	if key != nil {
+		req.KeyPair = &cmpb.KeyPair{PrivateKey: key}
+	}
+	for _, ca := range cas {
+		req.CaCertificates = append(req.CaCertificates, legacyCert(ca.pem()))
+	}
+	return req
+}
+
+// signCSR returns a certificate for the public key of the PEM encoded CSR signed by the CA.
+func signCSR(t testing.TB, ca *testCA, csrPEM []byte, serial int64) []byte {
+	t.Helper()
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		t.Fatalf("invalid CSR: %q", csrPEM)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse CSR: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("CSR has invalid signature: %v", err)
+	}
+	return ca.issue(t, csr.PublicKey, serial, csr.Subject.CommonName)
+}
+
+func startCert(t testing.TB, srv *Server) cmpb.CertificateManagementClient {
+	t.Helper()
+	conn, stop := serve(t, func(s *grpc.Server) {
+		cmpb.RegisterCertificateManagementServer(s, NewCertificateManagement(srv))
+	})
+	t.Cleanup(stop)
+	return cmpb.NewCertificateManagementClient(conn)
+}
+
+// leafName returns the common name of the certificate of the profile.
+func leafName(srv *Server, id string) string {
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	chain := srv.profiles[id][certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN]
+	if chain == nil {
+		return ""
+	}
+	return chain.cert.Leaf.Subject.CommonName
+}
+
+func TestInstall(t *testing.T) {
+	ca := newCA(t, "ca")
+	key := newKey(t)
+	srv := New(nil)
+	client := startCert(t, srv)
+	ctx := context.Background()
+
+	// The target generates the key.
+	ic, err := client.Install(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ic.Send(&cmpb.InstallCertificateRequest{
+		InstallRequest: &cmpb.InstallCertificateRequest_GenerateCsr{GenerateCsr: &cmpb.GenerateCSRRequest{
+			CertificateId: "generated",
+			CsrParams:     &cmpb.CSRParams{Type: cmpb.CertificateType_CT_X509, KeyType: cmpb.KeyType_KT_RSA, CommonName: "generated"},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := ic.Recv()
+	if err != nil {
+		t.Fatalf("Install() unexpected err: %v", err)
+	}
+	if err := ic.Send(&cmpb.InstallCertificateRequest{
+		InstallRequest: &cmpb.InstallCertificateRequest_LoadCertificate{
+			LoadCertificate: loadRequest("", signCSR(t, ca, resp.GetGeneratedCsr().GetCsr().GetCsr(), 2), nil),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ic.Recv(); err != nil {
+		t.Fatalf("Install() unexpected err: %v", err)
+	}
+	if _, err := ic.Recv(); err != io.EOF {
+		t.Fatalf("Install() unexpected err after load: %v", err)
+	}
+
+	tests := []struct {
+		desc    string
+		req     *cmpb.LoadCertificateRequest
+		wantErr string
+	}{{
+		desc: "client key pair",
+		req:  loadRequest("client", ca.issue(t, key.Public(), 3, "client"), keyPEM(t, key)),
+	}, {
+		desc:    "already exists",
+		req:     loadRequest("generated", ca.issue(t, key.Public(), 4, "generated"), keyPEM(t, key)),
+		wantErr: "already exists",
+	}, {
+		desc:    "no key",
+		req:     loadRequest("nokey", ca.issue(t, key.Public(), 5, "nokey"), nil),
+		wantErr: "no CSR was generated",
+	}, {
+		desc:    "key mismatch",
+		req:     loadRequest("mismatch", ca.issue(t, key.Public(), 6, "mismatch"), keyPEM(t, newKey(t))),
+		wantErr: "doesn't match",
+	}, {
+		desc:    "no certificate id",
+		req:     loadRequest("", ca.issue(t, key.Public(), 7, "noid"), keyPEM(t, key)),
+		wantErr: "certificate id not specified",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ic, err := client.Install(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ic.Send(&cmpb.InstallCertificateRequest{
+				InstallRequest: &cmpb.InstallCertificateRequest_LoadCertificate{LoadCertificate: tt.req},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			_, err = ic.Recv()
+			if d := errdiff.Check(err, tt.wantErr); d != "" {
+				t.Errorf("Install() unexpected err: %s", d)
+			}
+		})
+	}
+
+	got, err := client.GetCertificates(ctx, &cmpb.GetCertificatesRequest{})
+	if err != nil {
+		t.Fatalf("GetCertificates() unexpected err: %v", err)
+	}
+	var ids []string
+	for _, info := range got.GetCertificateInfo() {
+		ids = append(ids, info.GetCertificateId())
+		if info.GetModificationTime() == 0 {
+			t.Errorf("GetCertificates() got no modification time for certificate %q", info.GetCertificateId())
+		}
+	}
+	if d := cmp.Diff([]string{"client", "generated"}, ids); d != "" {
+		t.Errorf("GetCertificates() unexpected certificates (-want, +got):\n%s", d)
+	}
+}
+
+func TestRotateCertificate(t *testing.T) {
+	oldCA, newCA := newCA(t, "old-ca"), newCA(t, "new-ca")
+	key := newKey(t)
+	srv := New(nil)
+	client := startCert(t, srv)
+	ctx := context.Background()
+
+	if _, err := client.LoadCertificate(ctx, loadRequest(DefaultProfile, oldCA.issue(t, key.Public(), 2, "old"), keyPEM(t, key))); err != nil {
+		t.Fatalf("LoadCertificate() unexpected err: %v", err)
+	}
+
+	rotate := func(finalize bool) {
+		t.Helper()
+		rc, err := client.Rotate(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rc.Send(&cmpb.RotateCertificateRequest{
+			RotateRequest: &cmpb.RotateCertificateRequest_GenerateCsr{GenerateCsr: &cmpb.GenerateCSRRequest{
+				CertificateId: DefaultProfile,
+				CsrParams:     &cmpb.CSRParams{CommonName: "new"},
+			}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rc.Recv()
+		if err != nil {
+			t.Fatalf("Rotate() unexpected err: %v", err)
+		}
+		if err := rc.Send(&cmpb.RotateCertificateRequest{
+			RotateRequest: &cmpb.RotateCertificateRequest_LoadCertificate{
+				LoadCertificate: loadRequest("", signCSR(t, newCA, resp.GetGeneratedCsr().GetCsr().GetCsr(), 3), nil, newCA),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := rc.Recv(); err != nil {
+			t.Fatalf("Rotate() unexpected err: %v", err)
+		}
+		if got := leafName(srv, DefaultProfile); got != "new" {
+			t.Errorf("Rotate() got certificate %q before finalize, want %q", got, "new")
+		}
+		if finalize {
+			if err := rc.Send(&cmpb.RotateCertificateRequest{
+				RotateRequest: &cmpb.RotateCertificateRequest_FinalizeRotation{FinalizeRotation: &cmpb.FinalizeRequest{}},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			rc.CloseSend()
+		}
+		if _, err := rc.Recv(); err != io.EOF {
+			t.Fatalf("Rotate() unexpected err at the end of the rotation: %v", err)
+		}
+	}
+
+	rotate(false)
+	cfg, err := srv.tlsConfig(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Certificates[0].Leaf.Subject.CommonName; got != "old" || cfg.ClientCAs != nil {
+		t.Errorf("tlsConfig() got certificate %q and client CAs %v after rollback, want %q and none", got, cfg.ClientCAs, "old")
+	}
+
+	rotate(true)
+	cfg, err = srv.tlsConfig(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Certificates[0].Leaf.Subject.CommonName; got != "new" || cfg.ClientCAs == nil {
+		t.Errorf("tlsConfig() got certificate %q and client CAs %v after rotation, want %q and the new CA", got, cfg.ClientCAs, "new")
+	}
+
+	rc, err := client.Rotate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(&cmpb.RotateCertificateRequest{
+		RotateRequest: &cmpb.RotateCertificateRequest_LoadCertificate{
+			LoadCertificate: loadRequest("unknown", newCA.issue(t, key.Public(), 4, "unknown"), keyPEM(t, key)),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = rc.Recv()
+	if d := errdiff.Check(err, "not found"); d != "" {
+		t.Errorf("Rotate() unexpected err: %s", d)
+	}
+}
+
+func TestRevokeCertificates(t *testing.T) {
+	ca := newCA(t, "ca")
+	key := newKey(t)
+	srv := New(nil)
+	client := startCert(t, srv)
+	ctx := context.Background()
+
+	for _, id := range []string{DefaultProfile, "foo"} {
+		if _, err := client.LoadCertificate(ctx, loadRequest(id, ca.issue(t, key.Public(), 2, id), keyPEM(t, key))); err != nil {
+			t.Fatalf("LoadCertificate() unexpected err: %v", err)
+		}
+	}
+	got, err := client.RevokeCertificates(ctx, &cmpb.RevokeCertificatesRequest{CertificateId: []string{"foo", "bar", DefaultProfile}})
+	if err != nil {
+		t.Fatalf("RevokeCertificates() unexpected err: %v", err)
+	}
+	if d := cmp.Diff([]string{"foo", "bar"}, got.GetRevokedCertificateId()); d != "" {
+		t.Errorf("RevokeCertificates() unexpected revoked certificates (-want, +got):\n%s", d)
+	}
+	if errs := got.GetCertificateRevocationError(); len(errs) != 1 || errs[0].GetCertificateId() != DefaultProfile {
+		t.Errorf("RevokeCertificates() got errors %v, want error for %q", errs, DefaultProfile)
+	}
+	if _, ok := srv.profiles["foo"]; ok {
+		t.Errorf("RevokeCertificates() left profile %q", "foo")
+	}
+}
+
+func TestLoadCertificateAuthorityBundle(t *testing.T) {
+	ca := newCA(t, "ca")
+	srv := New(nil)
+	client := startCert(t, srv)
+
+	_, err := client.LoadCertificateAuthorityBundle(context.Background(), &cmpb.LoadCertificateAuthorityBundleRequest{})
+	if d := errdiff.Check(err, "no certificates"); d != "" {
+		t.Errorf("LoadCertificateAuthorityBundle() unexpected err: %s", d)
+	}
+	if _, err := client.LoadCertificateAuthorityBundle(context.Background(), &cmpb.LoadCertificateAuthorityBundleRequest{
+		CaCertificates: []*cmpb.Certificate{legacyCert(ca.pem())},
+	}); err != nil {
+		t.Fatalf("LoadCertificateAuthorityBundle() unexpected err: %v", err)
+	}
+	got := srv.profiles[DefaultProfile][certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE]
+	if got == nil || len(got.trust) != 1 || !got.trust[0].Equal(ca.cert) {
+		t.Errorf("LoadCertificateAuthorityBundle() got trust bundle %+v, want the CA", got)
+	}
+
+	// The trust bundle can't be replaced while the default profile is rotated.
+	srv.mu.Lock()
+	srv.rotating[DefaultProfile] = true
+	srv.mu.Unlock()
+	other := newCA(t, "other")
+	_, err = client.LoadCertificateAuthorityBundle(context.Background(), &cmpb.LoadCertificateAuthorityBundleRequest{
+		CaCertificates: []*cmpb.Certificate{legacyCert(other.pem())},
+	})
+	if d := errdiff.Check(err, "rotation is in progress"); d != "" {
+		t.Errorf("LoadCertificateAuthorityBundle() unexpected err: %s", d)
+	}
+	key := newKey(t)
+	_, err = client.LoadCertificate(context.Background(), loadRequest("foo", other.issue(t, key.Public(), 2, "foo"), keyPEM(t, key), other))
+	if d := errdiff.Check(err, "rotation is in progress"); d != "" {
+		t.Errorf("LoadCertificate() unexpected err: %s", d)
+	}
+	if got := srv.profiles[DefaultProfile][certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE]; !got.trust[0].Equal(ca.cert) {
+		t.Errorf("trust bundle was replaced during a rotation")
+	}
+	if _, ok := srv.profiles["foo"]; ok {
+		t.Errorf("LoadCertificate() created profile %q during a rotation", "foo")
+	}
+}
+
+func TestCanGenerateLegacyCSR(t *testing.T) {
+	cm := NewCertificateManagement(New(nil))
+	tests := []struct {
+		desc string
+		req  *cmpb.CanGenerateCSRRequest
+		want bool
+	}{{
+		desc: "default",
+		req:  &cmpb.CanGenerateCSRRequest{},
+		want: true,
+	}, {
+		desc: "rsa 4096",
+		req:  &cmpb.CanGenerateCSRRequest{KeyType: cmpb.KeyType_KT_RSA, CertificateType: cmpb.CertificateType_CT_X509, KeySize: 4096},
+		want: true,
+	}, {
+		desc: "key too large",
+		req:  &cmpb.CanGenerateCSRRequest{KeyType: cmpb.KeyType_KT_RSA, KeySize: 8192},
+	}, {
+		desc: "unknown key type",
+		req:  &cmpb.CanGenerateCSRRequest{KeyType: 501},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := cm.CanGenerateCSR(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("CanGenerateCSR() unexpected err: %v", err)
+			}
+			if got.GetCanGenerate() != tt.want {
+				t.Errorf("CanGenerateCSR() got %v, want %v", got.GetCanGenerate(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certz is a gNSI certz server. The default SSL profile is used as the
+// TLS identity of the gRPC servers, so that rotations apply to new connections.
+// The legacy gNOI cert service is also served on the same SSL profiles.
+package certz
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+
+	log "github.com/golang/glog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	certzpb "github.com/openconfig/gnsi/certz"
+)
+
+// DefaultProfile is the SSL profile used by the gNxI servers, it always exists and can't be deleted.
+const DefaultProfile = "system_default_profile"
+
+// entity is an uploaded certz entity, only the field matching its type is set.
+type entity struct {
+	version   string
+	createdOn uint64
+
+	cert   *tls.Certificate
+	trust  []*x509.Certificate
+	crls   []*x509.RevocationList
+	policy *certzpb.AuthenticationPolicy
+}
+
+// profile is the set of entities of an SSL profile, keyed by their type.
+type profile map[certzpb.ExistingEntity_EntityType]*entity
+
+// Server implements the certz gRPC server.
+type Server struct {
+	certzpb.UnimplementedCertzServer
+	mu       sync.RWMutex
+	profiles map[string]profile
+	rotating map[string]bool
+}
+
+// New returns a new certz server. If cert is not nil, it is used as
+// the certificate chain of the default profile.
+func New(cert *tls.Certificate) *Server {
+	def := profile{}
+	if cert != nil {
+		def[certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN] = &entity{cert: cert}
+	}
+	return &Server{
+		profiles: map[string]profile{DefaultProfile: def},
+		rotating: map[string]bool{},
+	}
+}
+
+// Rotate implements the certz Rotate RPC. Uploaded entities are used immediately,
+// but the profile is rolled back if the stream ends before the rotation is finalized.
+func (s *Server) Rotate(rs certzpb.Certz_RotateServer) error {
+	var (
+		id       string
+		prev     profile
+		started  bool
+		uploaded bool
+		key      crypto.Signer
+	)
+	defer func() {
+		if !started {
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if uploaded {
+			log.Infof("certz rotation of profile %q not finalized, rolling back", id)
+			s.profiles[id] = prev
+		}
+		delete(s.rotating, id)
+	}()
+
+	for {
+		req, err := rs.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Requests after the first one may omit the profile being rotated.
+		reqID := req.GetSslProfileId()
+		switch {
+		case !started:
+			if reqID == "" {
+				reqID = DefaultProfile
+			}
+			if err := s.startRotation(reqID); err != nil {
+				return err
+			}
+			id = reqID
+			started = true
+		case reqID != "" && reqID != id:
+			return status.Errorf(codes.InvalidArgument, "ssl profile %q doesn't match the profile being rotated %q", reqID, id)
+		}
+
+		switch r := req.RotateRequest.(type) {
+		case *certzpb.RotateCertificateRequest_GenerateCsr:
+			csr, k, err := generateCSR(r.GenerateCsr.GetParams())
+			if err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to generate CSR: %v", err)
+			}
+			key = k
+			if err := rs.Send(&certzpb.RotateCertificateResponse{
+				RotateResponse: &certzpb.RotateCertificateResponse_GeneratedCsr{
+					GeneratedCsr: &certzpb.GenerateCSRResponse{CertificateSigningRequest: csr},
+				},
+			}); err != nil {
+				return err
+			}
+		case *certzpb.RotateCertificateRequest_Certificates:
+			s.mu.Lock()
+			next, err := s.applyEntities(id, r.Certificates.GetEntities(), req.GetForceOverwrite(), key)
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			if !uploaded {
+				prev = s.profiles[id]
+			}
+			s.profiles[id] = next
+			s.mu.Unlock()
+			uploaded = true
+			if err := rs.Send(&certzpb.RotateCertificateResponse{
+				RotateResponse: &certzpb.RotateCertificateResponse_Certificates{
+					Certificates: &certzpb.UploadResponse{},
+				},
+			}); err != nil {
+				return err
+			}
+		case *certzpb.RotateCertificateRequest_FinalizeRotation:
+			if !uploaded {
+				return status.Error(codes.FailedPrecondition, "finalize rotation called before upload request")
+			}
+			uploaded = false
+			return nil
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", r)
+		}
+	}
+}
+
+// startRotation marks the profile as being rotated.
+func (s *Server) startRotation(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[id]; !ok {
+		return status.Errorf(codes.NotFound, "ssl profile %q not found", id)
+	}
+	if s.rotating[id] {
+		return status.Errorf(codes.Unavailable, "another rotation is already in progress for ssl profile %q", id)
+	}
+	s.rotating[id] = true
+	return nil
+}
+
+// applyEntities returns a copy of the profile with the entities applied.
+// key is the private key generated by a CSR request in the same rotation, if any.
+// The caller must hold s.mu.
+func (s *Server) applyEntities(id string, ents []*certzpb.Entity, force bool, key crypto.Signer) (profile, error) {
+	if len(ents) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "upload request contains no entities")
+	}
+	next := maps.Clone(s.profiles[id])
+	for _, e := range ents {
+		typ, ent, err := s.parseEntity(e, key)
+		if err != nil {
+			return nil, err
+		}
+		if cur := next[typ]; !force && cur != nil && cur.version == e.GetVersion() {
+			return nil, status.Errorf(codes.AlreadyExists, "%v version %q already in use", typ, e.GetVersion())
+		}
+		ent.version = e.GetVersion()
+		ent.createdOn = e.GetCreatedOn()
+		next[typ] = ent
+	}
+	return next, nil
+}
+
+// parseEntity validates the entity and returns its type and parsed value.
+func (s *Server) parseEntity(e *certzpb.Entity, key crypto.Signer) (certzpb.ExistingEntity_EntityType, *entity, error) {
+	switch ent := e.Entity.(type) {
+	case *certzpb.Entity_CertificateChain:
+		cert, err := parseCertChain(ent.CertificateChain, key)
+		if err != nil {
+			return 0, nil, err
+		}
+		return certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN, &entity{cert: cert}, nil
+	case *certzpb.Entity_TrustBundle:
+		var certs []*x509.Certificate
+		for c := ent.TrustBundle; c != nil; c = c.GetParent() {
+			cert, err := parseCert(c.GetCertificate())
+			if err != nil {
+				return 0, nil, err
+			}
+			certs = append(certs, cert)
+		}
+		if len(certs) == 0 {
+			return 0, nil, status.Error(codes.InvalidArgument, "trust bundle contains no certificates")
+		}
+		return certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE, &entity{trust: certs}, nil
+	case *certzpb.Entity_TrustBundlePkcs7:
+		certs, err := parsePKCS7(ent.TrustBundlePkcs7.GetPkcs7Block())
+		if err != nil {
+			return 0, nil, status.Errorf(codes.InvalidArgument, "invalid PKCS#7 trust bundle: %v", err)
+		}
+		return certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE, &entity{trust: certs}, nil
+	case *certzpb.Entity_CertificateRevocationListBundle:
+		var crls []*x509.RevocationList
+		for _, c := range ent.CertificateRevocationListBundle.GetCertificateRevocationLists() {
+			der, err := decode(c.GetEncoding(), c.GetCertificateRevocationList())
+			if err != nil {
+				return 0, nil, status.Errorf(codes.InvalidArgument, "invalid CRL %q: %v", c.GetId(), err)
+			}
+			crl, err := x509.ParseRevocationList(der)
+			if err != nil {
+				return 0, nil, status.Errorf(codes.InvalidArgument, "invalid CRL %q: %v", c.GetId(), err)
+			}
+			crls = append(crls, crl)
+		}
+		return certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_REVOCATION_LIST_BUNDLE, &entity{crls: crls}, nil
+	case *certzpb.Entity_AuthenticationPolicy:
+		return certzpb.ExistingEntity_ENTITY_TYPE_AUTHENTICATION_POLICY, &entity{policy: ent.AuthenticationPolicy}, nil
+	case *certzpb.Entity_ExistingEntity:
+		src, ok := s.profiles[ent.ExistingEntity.GetSslProfileId()]
+		if !ok {
+			return 0, nil, status.Errorf(codes.NotFound, "ssl profile %q not found", ent.ExistingEntity.GetSslProfileId())
+		}
+		typ := ent.ExistingEntity.GetEntityType()
+		existing, ok := src[typ]
+		if !ok {
+			return 0, nil, status.Errorf(codes.NotFound, "ssl profile %q has no %v", ent.ExistingEntity.GetSslProfileId(), typ)
+		}
+		cp := *existing
+		return typ, &cp, nil
+	default:
+		return 0, nil, status.Errorf(codes.InvalidArgument, "unsupported entity type %T", ent)
+	}
+}
+
+// AddProfile implements the certz AddProfile RPC.
+func (s *Server) AddProfile(_ context.Context, req *certzpb.AddProfileRequest) (*certzpb.AddProfileResponse, error) {
+	id := req.GetSslProfileId()
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "ssl profile id not specified")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[id]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "ssl profile %q already exists", id)
+	}
+	s.profiles[id] = profile{}
+	return &certzpb.AddProfileResponse{}, nil
+}
+
+// DeleteProfile implements the certz DeleteProfile RPC.
+func (s *Server) DeleteProfile(_ context.Context, req *certzpb.DeleteProfileRequest) (*certzpb.DeleteProfileResponse, error) {
+	id := req.GetSslProfileId()
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "ssl profile id not specified")
+	}
+	if id == DefaultProfile {
+		return nil, status.Errorf(codes.FailedPrecondition, "ssl profile %q is used by the gNxI servers and can't be deleted", id)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.profiles[id]; !ok {
+		return nil, status.Errorf(codes.NotFound, "ssl profile %q not found", id)
+	}
+	if s.rotating[id] {
+		return nil, status.Errorf(codes.FailedPrecondition, "ssl profile %q is being rotated", id)
+	}
+	delete(s.profiles, id)
+	return &certzpb.DeleteProfileResponse{}, nil
+}
+
+// GetProfileList implements the certz GetProfileList RPC.
+func (s *Server) GetProfileList(context.Context, *certzpb.GetProfileListRequest) (*certzpb.GetProfileListResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &certzpb.GetProfileListResponse{
+		SslProfileIds: slices.Sorted(maps.Keys(s.profiles)),
+	}, nil
+}
+
+// CanGenerateCSR implements the certz CanGenerateCSR RPC.
+func (s *Server) CanGenerateCSR(_ context.Context, req *certzpb.CanGenerateCSRRequest) (*certzpb.CanGenerateCSRResponse, error) {
+	_, ok := csrSuites[req.GetParams().GetCsrSuite()]
+	return &certzpb.CanGenerateCSRResponse{
+		CanGenerate: ok && req.GetParams().GetCommonName() != "",
+	}, nil
+}
+
+// ServerCredentials returns gRPC transport credentials using the entities of the default profile.
+// The profile is read on every handshake, so rotations apply to new connections without a restart.
+func (s *Server) ServerCredentials() credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.tlsConfig(DefaultProfile)
+		},
+	})
+}
+
+// tlsConfig returns the server TLS config of the profile.
+func (s *Server) tlsConfig(id string) (*tls.Config, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p := s.profiles[id]
+	chain := p[certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_CHAIN]
+	if chain == nil {
+		return nil, fmt.Errorf("ssl profile %q has no certificate chain", id)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{*chain.cert},
+	}
+	if trust := p[certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE]; trust != nil {
+		pool := x509.NewCertPool()
+		for _, c := range trust.trust {
+			pool.AddCert(c)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if crls := p[certzpb.ExistingEntity_ENTITY_TYPE_CERTIFICATE_REVOCATION_LIST_BUNDLE]; crls != nil {
+		cfg.VerifyPeerCertificate = func(_ [][]byte, chains [][]*x509.Certificate) error {
+			return checkRevoked(crls.crls, chains)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,595 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certz
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	certzpb "github.com/openconfig/gnsi/certz"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func newKey(t testing.TB) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newCA(t testing.TB, cn string) *testCA {
+	t.Helper()
+	key := newKey(t)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a PEM encoded certificate for the public key signed by the CA.
+func (ca *testCA) issue(t testing.TB, pub crypto.PublicKey, serial int64, cn string) []byte {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, pub, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func (ca *testCA) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+}
+
+func keyPEM(t testing.TB, key crypto.Signer) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func chainEntity(version string, cert, key []byte) *certzpb.Entity {
+	c := &certzpb.Certificate{
+		Type:            certzpb.CertificateType_CERTIFICATE_TYPE_X509,
+		Encoding:        certzpb.CertificateEncoding_CERTIFICATE_ENCODING_PEM,
+		CertificateType: &certzpb.Certificate_RawCertificate{RawCertificate: cert},
+	}
+	if key != nil {
+		c.PrivateKeyType = &certzpb.Certificate_RawPrivateKey{RawPrivateKey: key}
+	} else {
+		c.PrivateKeyType = &certzpb.Certificate_KeySource_{KeySource: certzpb.Certificate_KEY_SOURCE_GENERATED}
+	}
+	return &certzpb.Entity{
+		Version:   version,
+		CreatedOn: 42,
+		Entity:    &certzpb.Entity_CertificateChain{CertificateChain: &certzpb.CertificateChain{Certificate: c}},
+	}
+}
+
+func trustEntity(version string, ca *testCA) *certzpb.Entity {
+	return &certzpb.Entity{
+		Version: version,
+		Entity: &certzpb.Entity_TrustBundle{TrustBundle: &certzpb.CertificateChain{
+			Certificate: &certzpb.Certificate{
+				Type:            certzpb.CertificateType_CERTIFICATE_TYPE_X509,
+				Encoding:        certzpb.CertificateEncoding_CERTIFICATE_ENCODING_PEM,
+				CertificateType: &certzpb.Certificate_RawCertificate{RawCertificate: ca.pem()},
+			},
+		}},
+	}
+}
+
+func upload(profile string, force bool, ents ...*certzpb.Entity) *certzpb.RotateCertificateRequest {
+	return &certzpb.RotateCertificateRequest{
+		ForceOverwrite: force,
+		SslProfileId:   profile,
+		RotateRequest: &certzpb.RotateCertificateRequest_Certificates{
+			Certificates: &certzpb.UploadRequest{Entities: ents},
+		},
+	}
+}
+
+var finalize = &certzpb.RotateCertificateRequest{
+	RotateRequest: &certzpb.RotateCertificateRequest_FinalizeRotation{
+		FinalizeRotation: &certzpb.FinalizeRequest{},
+	},
+}
+
+func TestRotate(t *testing.T) {
+	ca := newCA(t, "ca")
+	key := newKey(t)
+	cert := ca.issue(t, key.Public(), 2, "lemming")
+	otherKey := newKey(t)
+
+	tests := []struct {
+		desc     string
+		existing string
+		reqs     []*certzpb.RotateCertificateRequest
+		wantErrs []string
+	}{{
+		desc:     "profile not found",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("foo", false, chainEntity("1", cert, keyPEM(t, key)))},
+		wantErrs: []string{"not found"},
+	}, {
+		desc:     "finalize before upload",
+		reqs:     []*certzpb.RotateCertificateRequest{finalize},
+		wantErrs: []string{"finalize rotation called before upload request"},
+	}, {
+		desc:     "no entities",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", false)},
+		wantErrs: []string{"no entities"},
+	}, {
+		desc:     "key mismatch",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", false, chainEntity("1", cert, keyPEM(t, otherKey)))},
+		wantErrs: []string{"doesn't match"},
+	}, {
+		desc:     "generated key without CSR",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", false, chainEntity("1", cert, nil))},
+		wantErrs: []string{"no CSR was generated"},
+	}, {
+		desc:     "invalid certificate",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", false, chainEntity("1", []byte("foo"), keyPEM(t, key)))},
+		wantErrs: []string{"invalid certificate"},
+	}, {
+		desc:     "version in use",
+		existing: "1",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", false, chainEntity("1", cert, keyPEM(t, key)))},
+		wantErrs: []string{"already in use"},
+	}, {
+		desc:     "version in use with force overwrite",
+		existing: "1",
+		reqs:     []*certzpb.RotateCertificateRequest{upload("", true, chainEntity("1", cert, keyPEM(t, key))), finalize},
+		wantErrs: []string{"", "EOF"},
+	}, {
+		desc: "multiple uploads",
+		reqs: []*certzpb.RotateCertificateRequest{
+			upload("", false, chainEntity("1", cert, keyPEM(t, key))),
+			upload("", false, trustEntity("1", ca)),
+			finalize,
+		},
+		wantErrs: []string{"", "", "EOF"},
+	}, {
+		desc: "mismatched profile",
+		reqs: []*certzpb.RotateCertificateRequest{
+			upload("", false, chainEntity("1", cert, keyPEM(t, key))),
+			upload("foo", false, trustEntity("1", ca)),
+		},
+		wantErrs: []string{"", "doesn't match"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			srv := New(nil)
+			client, closeFn := start(t, srv)
+			defer closeFn()
+			if tt.existing != "" {
+				rotate(t, client, upload("", false, chainEntity(tt.existing, cert, keyPEM(t, key))))
+			}
+			rot, err := client.Rotate(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, req := range tt.reqs {
+				if err := rot.Send(req); err != nil {
+					t.Fatal(err)
+				}
+				_, err := rot.Recv()
+				if d := errdiff.Check(err, tt.wantErrs[i]); d != "" {
+					t.Errorf("Rotate() unexpected err: %s", d)
+				}
+			}
+		})
+	}
+	t.Run("concurrent rotation", func(t *testing.T) {
+		client, closeFn := start(t, New(nil))
+		defer closeFn()
+		first, err := client.Rotate(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := first.Send(upload("", false, chainEntity("1", cert, keyPEM(t, key)))); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := first.Recv(); err != nil {
+			t.Fatal(err)
+		}
+		c, err := client.Rotate(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Send(finalize); err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Recv()
+		if d := errdiff.Check(err, "another rotation is already in progress"); d != "" {
+			t.Errorf("Rotate() unexpected err: %s", d)
+		}
+	})
+}
+
+// rotate uploads the request and finalizes the rotation.
+func rotate(t testing.TB, client certzpb.CertzClient, req *certzpb.RotateCertificateRequest) {
+	t.Helper()
+	rc, err := client.Rotate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(req); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	if err := rc.Send(finalize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != io.EOF {
+		t.Fatalf("Rotate() unexpected err after finalize: %v", err)
+	}
+}
+
+func TestGenerateCSR(t *testing.T) {
+	srv := New(nil)
+	client, closeFn := start(t, srv)
+	defer closeFn()
+	ca := newCA(t, "ca")
+
+	rc, err := client.Rotate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(&certzpb.RotateCertificateRequest{
+		RotateRequest: &certzpb.RotateCertificateRequest_GenerateCsr{
+			GenerateCsr: &certzpb.GenerateCSRRequest{
+				Params: &certzpb.CSRParams{
+					CsrSuite:   certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_256,
+					CommonName: "lemming",
+					San:        &certzpb.V3ExtensionSAN{Dns: []string{"lemming.example"}, Ips: []string{"192.0.2.1"}},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rc.Recv()
+	if err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	block, _ := pem.Decode(resp.GetGeneratedCsr().GetCertificateSigningRequest().GetCertificateSigningRequest())
+	if block == nil {
+		t.Fatalf("Rotate() got invalid CSR: %v", resp)
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse CSR: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("CSR has invalid signature: %v", err)
+	}
+	if csr.Subject.CommonName != "lemming" || !cmp.Equal(csr.DNSNames, []string{"lemming.example"}) || len(csr.IPAddresses) != 1 {
+		t.Errorf("Rotate() got CSR subject %v, DNS names %v, IPs %v", csr.Subject, csr.DNSNames, csr.IPAddresses)
+	}
+
+	if err := rc.Send(upload("", false, chainEntity("1", ca.issue(t, csr.PublicKey, 2, "lemming"), nil))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	if err := rc.Send(finalize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != io.EOF {
+		t.Fatalf("Rotate() unexpected err after finalize: %v", err)
+	}
+	cfg, err := srv.tlsConfig(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Certificates[0].Leaf.Subject.CommonName; got != "lemming" {
+		t.Errorf("tlsConfig() got certificate %q, want %q", got, "lemming")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	ca := newCA(t, "ca")
+	key := newKey(t)
+	srv := New(nil)
+	client, closeFn := start(t, srv)
+	defer closeFn()
+
+	rotate(t, client, upload("", false, chainEntity("1", ca.issue(t, key.Public(), 2, "old"), keyPEM(t, key))))
+
+	rc, err := client.Rotate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Send(upload("", false, chainEntity("2", ca.issue(t, key.Public(), 3, "new"), keyPEM(t, key)), trustEntity("1", ca))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rc.Recv(); err != nil {
+		t.Fatalf("Rotate() unexpected err: %v", err)
+	}
+	cfg, err := srv.tlsConfig(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Certificates[0].Leaf.Subject.CommonName; got != "new" {
+		t.Errorf("tlsConfig() got certificate %q before finalize, want %q", got, "new")
+	}
+	rc.CloseSend()
+	if _, err := rc.Recv(); err != io.EOF {
+		t.Fatalf("Rotate() unexpected err after close: %v", err)
+	}
+
+	cfg, err = srv.tlsConfig(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Certificates[0].Leaf.Subject.CommonName; got != "old" {
+		t.Errorf("tlsConfig() got certificate %q after rollback, want %q", got, "old")
+	}
+	if cfg.ClientCAs != nil {
+		t.Errorf("tlsConfig() got trust bundle after rollback")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	client, closeFn := start(t, New(nil))
+	defer closeFn()
+	ctx := context.Background()
+
+	if _, err := client.AddProfile(ctx, &certzpb.AddProfileRequest{SslProfileId: "foo"}); err != nil {
+		t.Fatalf("AddProfile() unexpected err: %v", err)
+	}
+	_, err := client.AddProfile(ctx, &certzpb.AddProfileRequest{SslProfileId: "foo"})
+	if d := errdiff.Check(err, "already exists"); d != "" {
+		t.Errorf("AddProfile() unexpected err: %s", d)
+	}
+	got, err := client.GetProfileList(ctx, &certzpb.GetProfileListRequest{})
+	if err != nil {
+		t.Fatalf("GetProfileList() unexpected err: %v", err)
+	}
+	if d := cmp.Diff([]string{"foo", DefaultProfile}, got.GetSslProfileIds()); d != "" {
+		t.Errorf("GetProfileList() unexpected diff (-want, +got):\n%s", d)
+	}
+	_, err = client.DeleteProfile(ctx, &certzpb.DeleteProfileRequest{SslProfileId: DefaultProfile})
+	if d := errdiff.Check(err, "can't be deleted"); d != "" {
+		t.Errorf("DeleteProfile() unexpected err: %s", d)
+	}
+	if _, err := client.DeleteProfile(ctx, &certzpb.DeleteProfileRequest{SslProfileId: "foo"}); err != nil {
+		t.Fatalf("DeleteProfile() unexpected err: %v", err)
+	}
+	_, err = client.DeleteProfile(ctx, &certzpb.DeleteProfileRequest{SslProfileId: "foo"})
+	if d := errdiff.Check(err, "not found"); d != "" {
+		t.Errorf("DeleteProfile() unexpected err: %s", d)
+	}
+}
+
+func TestExistingEntity(t *testing.T) {
+	ca := newCA(t, "ca")
+	srv := New(nil)
+	client, closeFn := start(t, srv)
+	defer closeFn()
+
+	if _, err := client.AddProfile(context.Background(), &certzpb.AddProfileRequest{SslProfileId: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	rotate(t, client, upload("foo", false, trustEntity("1", ca)))
+	rotate(t, client, upload("", false, &certzpb.Entity{
+		Version: "2",
+		Entity: &certzpb.Entity_ExistingEntity{ExistingEntity: &certzpb.ExistingEntity{
+			SslProfileId: "foo",
+			EntityType:   certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE,
+		}},
+	}))
+	got := srv.profiles[DefaultProfile][certzpb.ExistingEntity_ENTITY_TYPE_TRUST_BUNDLE]
+	if got == nil || got.version != "2" || !got.trust[0].Equal(ca.cert) {
+		t.Errorf("Rotate() got trust bundle %+v, want copy of profile foo with version 2", got)
+	}
+}
+
+func TestCanGenerateCSR(t *testing.T) {
+	srv := New(nil)
+	tests := []struct {
+		desc   string
+		params *certzpb.CSRParams
+		want   bool
+	}{{
+		desc: "supported",
+		params: &certzpb.CSRParams{
+			CsrSuite:   certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_EDDSA_ED25519,
+			CommonName: "lemming",
+		},
+		want: true,
+	}, {
+		desc:   "no common name",
+		params: &certzpb.CSRParams{CsrSuite: certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_EDDSA_ED25519},
+	}, {
+		desc:   "unspecified suite",
+		params: &certzpb.CSRParams{CommonName: "lemming"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := srv.CanGenerateCSR(context.Background(), &certzpb.CanGenerateCSRRequest{Params: tt.params})
+			if err != nil {
+				t.Fatalf("CanGenerateCSR() unexpected err: %v", err)
+			}
+			if got.GetCanGenerate() != tt.want {
+				t.Errorf("CanGenerateCSR() got %v, want %v", got.GetCanGenerate(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePKCS7(t *testing.T) {
+	ca1, ca2 := newCA(t, "ca1"), newCA(t, "ca2")
+	sd, err := asn1.Marshal(pkcs7SignedData{
+		Version:          1,
+		DigestAlgorithms: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+		ContentInfo:      asn1.RawValue{FullBytes: mustMarshal(t, struct{ ContentType asn1.ObjectIdentifier }{asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}})},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: append(ca1.cert.Raw, ca2.cert.Raw...)},
+		SignerInfos:      asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	der := mustMarshal(t, pkcs7ContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+	certs, err := parsePKCS7(string(pem.EncodeToMemory(&pem.Block{Type: "PKCS7", Bytes: der})))
+	if err != nil {
+		t.Fatalf("parsePKCS7() unexpected err: %v", err)
+	}
+	if len(certs) != 2 || !certs[0].Equal(ca1.cert) || !certs[1].Equal(ca2.cert) {
+		t.Errorf("parsePKCS7() got %d certificates, want ca1 and ca2", len(certs))
+	}
+}
+
+func mustMarshal(t testing.TB, v any) []byte {
+	t.Helper()
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestServerCredentials checks that a rotation applies to new connections to a running server.
+func TestServerCredentials(t *testing.T) {
+	oldCA, newCA := newCA(t, "old-ca"), newCA(t, "new-ca")
+	key := newKey(t)
+	cert, err := tls.X509KeyPair(oldCA.issue(t, key.Public(), 2, "lemming"), keyPEM(t, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := New(&cert)
+	s := grpc.NewServer(grpc.Creds(srv.ServerCredentials()))
+	certzpb.RegisterCertzServer(s, srv)
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	go s.Serve(l)
+	defer s.Stop()
+
+	dial := func(ca *testCA) (certzpb.CertzClient, error) {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+		if err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { conn.Close() })
+		client := certzpb.NewCertzClient(conn)
+		_, err = client.GetProfileList(context.Background(), &certzpb.GetProfileListRequest{})
+		return client, err
+	}
+
+	client, err := dial(oldCA)
+	if err != nil {
+		t.Fatalf("failed to connect with the initial certificate: %v", err)
+	}
+	rotate(t, client, upload("", false, chainEntity("1", newCA.issue(t, key.Public(), 3, "lemming"), keyPEM(t, key))))
+
+	if _, err := dial(newCA); err != nil {
+		t.Errorf("failed to connect with the rotated certificate: %v", err)
+	}
+	if _, err := dial(oldCA); err == nil {
+		t.Errorf("connected with the old CA after rotation, want error")
+	}
+}
+
+func start(t testing.TB, srv *Server) (certzpb.CertzClient, func()) {
+	t.Helper()
+	conn, stop := serve(t, func(s *grpc.Server) {
+		certzpb.RegisterCertzServer(s, srv)
+	})
+	return certzpb.NewCertzClient(conn), stop
+}
+
+// serve starts a gRPC server with the services added by register, it returns
+// a connection to the server and a function that stops the server.
+func serve(t testing.TB, register func(*grpc.Server)) (*grpc.ClientConn, func()) {
+	t.Helper()
+	s := grpc.NewServer()
+	register(s)
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+
+	go s.Serve(l)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed dial server: %v", err)
+	}
+	return conn, func() {
+		conn.Close()
+		s.Stop()
+	}
+}
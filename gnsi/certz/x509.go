@@ -0,0 +1,308 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certz
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	certzpb "github.com/openconfig/gnsi/certz"
+)
+
+// decode returns the DER bytes of a PEM or DER encoded object.
+func decode(enc certzpb.CertificateEncoding, data []byte) ([]byte, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		return block.Bytes, nil
+	}
+	if enc == certzpb.CertificateEncoding_CERTIFICATE_ENCODING_PEM {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	return data, nil
+}
+
+// parseCert parses a certificate without a private key.
+func parseCert(c *certzpb.Certificate) (*x509.Certificate, error) {
+	if c.GetCertSource() != certzpb.Certificate_CERT_SOURCE_UNSPECIFIED {
+		return nil, status.Errorf(codes.Unimplemented, "certificate source %v is not supported", c.GetCertSource())
+	}
+	der, err := decode(c.GetEncoding(), c.GetRawCertificate())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+	}
+	return cert, nil
+}
+
+// parseCertChain returns the TLS certificate of the chain. The private key of the leaf
+// certificate is either uploaded or the key generated by the last CSR request.
+func parseCertChain(chain *certzpb.CertificateChain, genKey crypto.Signer) (*tls.Certificate, error) {
+	leaf, err := parseCert(chain.GetCertificate())
+	if err != nil {
+		return nil, err
+	}
+	var key crypto.Signer
+	switch c := chain.GetCertificate(); {
+	case c.GetRawPrivateKey() != nil:
+		if key, err = parseKey(c.GetRawPrivateKey()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid private key: %v", err)
+		}
+	case c.GetKeySource() == certzpb.Certificate_KEY_SOURCE_GENERATED:
+		if genKey == nil {
+			return nil, status.Error(codes.FailedPrecondition, "no CSR was generated during this rotation")
+		}
+		key = genKey
+	case c.GetKeySource() == certzpb.Certificate_KEY_SOURCE_IDEVID_TPM:
+		return nil, status.Errorf(codes.Unimplemented, "key source %v is not supported", c.GetKeySource())
+	default:
+		return nil, status.Error(codes.InvalidArgument, "certificate chain has no private key")
+	}
+	if err := checkKey(key, leaf); err != nil {
+		return nil, err
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for p := chain.GetParent(); p != nil; p = p.GetParent() {
+		c, err := parseCert(p.GetCertificate())
+		if err != nil {
+			return nil, err
+		}
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	return cert, nil
+}
+
+// checkKey returns an error if the private key doesn't match the certificate.
+func checkKey(key crypto.Signer, leaf *x509.Certificate) error {
+	if pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(leaf.PublicKey) {
+		return status.Error(codes.InvalidArgument, "private key doesn't match the certificate")
+	}
+	return nil
+}
+
+// parseKey parses a PEM encoded PKCS #8, PKCS #1 or SEC 1 private key.
+func parseKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+type csrSuite struct {
+	newKey func() (crypto.Signer, error)
+	sigAlg x509.SignatureAlgorithm
+}
+
+func rsaKey(bits int) func() (crypto.Signer, error) {
+	return func() (crypto.Signer, error) {
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+}
+
+func ecdsaKey(c elliptic.Curve) func() (crypto.Signer, error) {
+	return func() (crypto.Signer, error) {
+		return ecdsa.GenerateKey(c, rand.Reader)
+	}
+}
+
+func ed25519Key() (crypto.Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+var csrSuites = map[certzpb.CSRSuite]csrSuite{
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_256:         {rsaKey(2048), x509.SHA256WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_384:         {rsaKey(2048), x509.SHA384WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_2048_SIGNATURE_ALGORITHM_SHA_2_512:         {rsaKey(2048), x509.SHA512WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_256:         {rsaKey(3072), x509.SHA256WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_384:         {rsaKey(3072), x509.SHA384WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_3072_SIGNATURE_ALGORITHM_SHA_2_512:         {rsaKey(3072), x509.SHA512WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_256:         {rsaKey(4096), x509.SHA256WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_384:         {rsaKey(4096), x509.SHA384WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_RSA_4096_SIGNATURE_ALGORITHM_SHA_2_512:         {rsaKey(4096), x509.SHA512WithRSA},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_256: {ecdsaKey(elliptic.P256()), x509.ECDSAWithSHA256},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_384: {ecdsaKey(elliptic.P256()), x509.ECDSAWithSHA384},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_PRIME256V1_SIGNATURE_ALGORITHM_SHA_2_512: {ecdsaKey(elliptic.P256()), x509.ECDSAWithSHA512},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_256:  {ecdsaKey(elliptic.P384()), x509.ECDSAWithSHA256},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_384:  {ecdsaKey(elliptic.P384()), x509.ECDSAWithSHA384},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP384R1_SIGNATURE_ALGORITHM_SHA_2_512:  {ecdsaKey(elliptic.P384()), x509.ECDSAWithSHA512},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_256:  {ecdsaKey(elliptic.P521()), x509.ECDSAWithSHA256},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_384:  {ecdsaKey(elliptic.P521()), x509.ECDSAWithSHA384},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_ECDSA_SECP521R1_SIGNATURE_ALGORITHM_SHA_2_512:  {ecdsaKey(elliptic.P521()), x509.ECDSAWithSHA512},
+	certzpb.CSRSuite_CSRSUITE_X509_KEY_TYPE_EDDSA_ED25519:                                  {ed25519Key, x509.PureEd25519},
+}
+
+// generateCSR generates a new private key and a PEM encoded CSR for it.
+func generateCSR(params *certzpb.CSRParams) (*certzpb.CertificateSigningRequest, crypto.Signer, error) {
+	suite, ok := csrSuites[params.GetCsrSuite()]
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported CSR suite %v", params.GetCsrSuite())
+	}
+	if params.GetCommonName() == "" {
+		return nil, nil, fmt.Errorf("common name not specified")
+	}
+	tmpl := &x509.CertificateRequest{
+		SignatureAlgorithm: suite.sigAlg,
+		Subject: pkix.Name{
+			CommonName:         params.GetCommonName(),
+			Country:            nonEmpty(params.GetCountry()),
+			Province:           nonEmpty(params.GetState()),
+			Locality:           nonEmpty(params.GetCity()),
+			Organization:       nonEmpty(params.GetOrganization()),
+			OrganizationalUnit: nonEmpty(params.GetOrganizationalUnit()),
+		},
+		DNSNames:       params.GetSan().GetDns(),
+		EmailAddresses: append(nonEmpty(params.GetEmailId()), params.GetSan().GetEmails()...),
+	}
+	for _, ip := range append(nonEmpty(params.GetIpAddress()), params.GetSan().GetIps()...) {
+		addr := net.ParseIP(ip)
+		if addr == nil {
+			return nil, nil, fmt.Errorf("invalid IP address %q", ip)
+		}
+		tmpl.IPAddresses = append(tmpl.IPAddresses, addr)
+	}
+	for _, u := range params.GetSan().GetUris() {
+		uri, err := url.Parse(u)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid URI %q: %v", u, err)
+		}
+		tmpl.URIs = append(tmpl.URIs, uri)
+	}
+
+	key, err := suite.newKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &certzpb.CertificateSigningRequest{
+		Type:                      certzpb.CertificateType_CERTIFICATE_TYPE_X509,
+		Encoding:                  certzpb.CertificateEncoding_CERTIFICATE_ENCODING_PEM,
+		CertificateSigningRequest: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}),
+	}, key, nil
+}
+
+func nonEmpty(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// checkRevoked returns an error if any certificate in the verified chains is revoked by the CRLs.
+func checkRevoked(crls []*x509.RevocationList, chains [][]*x509.Certificate) error {
+	for _, chain := range chains {
+		for _, cert := range chain {
+			for _, crl := range crls {
+				if !bytes.Equal(crl.RawIssuer, cert.RawIssuer) {
+					continue
+				}
+				for _, rc := range crl.RevokedCertificateEntries {
+					if rc.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+						return fmt.Errorf("certificate %q is revoked", cert.Subject)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// pkcs7ContentInfo and pkcs7SignedData are the parts of RFC 2315 needed to read
+// the certificates of a degenerate "certs-only" PKCS #7 bundle.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      asn1.RawValue
+}
+
+var oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+
+// parsePKCS7 returns the certificates of a PEM or DER encoded PKCS #7 bundle.
+func parsePKCS7(block string) ([]*x509.Certificate, error) {
+	der, err := decode(certzpb.CertificateEncoding_CERTIFICATE_ENCODING_UNSPECIFIED, []byte(block))
+	if err != nil {
+		return nil, err
+	}
+	var ci pkcs7ContentInfo
+	if _, err := asn1.Unmarshal(der, &ci); err != nil {
+		return nil, err
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("unsupported content type %v", ci.ContentType)
+	}
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, err
+	}
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
@@ -0,0 +1,523 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package credentialz is a gNSI credentialz server, the credentials are published
+// to the gNMI cache and can be enforced by a local SSH server.
+package credentialz
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	credzpb "github.com/openconfig/gnsi/credentialz"
+
+	"github.com/openconfig/lemming/gnmi/reconciler"
+)
+
+// version is the version of a set of credentials.
+type version struct {
+	version   string
+	createdOn uint64
+}
+
+type password struct {
+	version
+	hash string
+}
+
+type authorizedKeys struct {
+	version
+	keys []ssh.PublicKey
+}
+
+type authorizedPrincipals struct {
+	version
+	users []string
+}
+
+// account holds the credentials of an account, unset credentials are nil.
+type account struct {
+	password   *password
+	keys       *authorizedKeys
+	principals *authorizedPrincipals
+}
+
+type serverKeys struct {
+	version
+	keys    []ssh.Signer
+	hasCert bool
+}
+
+type caKeys struct {
+	version
+	keys []ssh.PublicKey
+}
+
+// host holds the SSH server parameters.
+type host struct {
+	serverKeys *serverKeys
+	caKeys     *caKeys
+	// allowedAuth is the set of allowed authentication types, nil means all are allowed.
+	allowedAuth map[credzpb.AuthenticationType]bool
+}
+
+// Server implements the credentialz gRPC server.
+type Server struct {
+	credzpb.UnimplementedCredentialzServer
+	accountRotation atomic.Bool
+	hostRotation    atomic.Bool
+
+	mu       sync.RWMutex
+	accounts map[string]account
+	host     host
+	counters counters
+
+	// pubMu serializes the telemetry updates.
+	pubMu     sync.Mutex
+	client    *ygnmi.Client
+	published map[string]bool
+}
+
+// New returns a new credentialz server, with a generated ED25519 host key.
+func New() *Server {
+	// Generating an ED25519 key only fails if crypto/rand fails, which never happens.
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	signer, _ := ssh.NewSignerFromSigner(key)
+	return &Server{
+		accounts: map[string]account{},
+		host: host{
+			serverKeys: &serverKeys{keys: []ssh.Signer{signer}},
+		},
+		published: map[string]bool{},
+	}
+}
+
+// Reconciler returns a reconciler that publishes the credentials to the gNMI cache.
+func (s *Server) Reconciler() *reconciler.BuiltReconciler {
+	return reconciler.NewBuilder("gnsi credentialz").
+		WithStart(func(ctx context.Context, c *ygnmi.Client) error {
+			s.pubMu.Lock()
+			s.client = c
+			s.pubMu.Unlock()
+			s.publish()
+			return nil
+		}).
+		WithStop(func(context.Context) error {
+			s.pubMu.Lock()
+			defer s.pubMu.Unlock()
+			s.client = nil
+			return nil
+		}).Build()
+}
+
+// RotateAccountCredentials implements the credentialz RotateAccountCredentials RPC. The credentials
+// are used immediately, but they are rolled back if the stream ends before the rotation is finalized.
+func (s *Server) RotateAccountCredentials(stream credzpb.Credentialz_RotateAccountCredentialsServer) error {
+	if !s.accountRotation.CompareAndSwap(false, true) {
+		return status.Error(codes.Unavailable, "another account credentials rotation is already in progress")
+	}
+	defer s.accountRotation.Store(false)
+
+	var prev map[string]account
+	changed := false
+	defer func() {
+		if !changed {
+			return
+		}
+		log.Infof("credentialz account rotation not finalized, rolling back")
+		s.mu.Lock()
+		s.accounts = prev
+		s.mu.Unlock()
+		s.publish()
+	}()
+	// apply applies the change to a copy of the accounts and makes it active.
+	apply := func(change func(map[string]account) error) error {
+		s.mu.Lock()
+		next := maps.Clone(s.accounts)
+		if err := change(next); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		if !changed {
+			prev = s.accounts
+			changed = true
+		}
+		s.accounts = next
+		s.mu.Unlock()
+		s.publish()
+		return nil
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := &credzpb.RotateAccountCredentialsResponse{}
+		switch r := req.Request.(type) {
+		case *credzpb.RotateAccountCredentialsRequest_Credential:
+			err = apply(func(accts map[string]account) error {
+				for _, c := range r.Credential.GetCredentials() {
+					if c.GetAccount() == "" {
+						return status.Error(codes.InvalidArgument, "account not specified")
+					}
+					keys := &authorizedKeys{version: version{c.GetVersion(), c.GetCreatedOn()}}
+					for _, k := range c.GetAuthorizedKeys() {
+						key, err := parsePublicKey(k.GetAuthorizedKey())
+						if err != nil {
+							return status.Errorf(codes.InvalidArgument, "invalid authorized key for account %q: %v", c.GetAccount(), err)
+						}
+						keys.keys = append(keys.keys, key)
+					}
+					a := accts[c.GetAccount()]
+					a.keys = keys
+					accts[c.GetAccount()] = a
+				}
+				return nil
+			})
+			resp.Response = &credzpb.RotateAccountCredentialsResponse_Credential{Credential: &credzpb.AuthorizedKeysResponse{}}
+		case *credzpb.RotateAccountCredentialsRequest_User:
+			err = apply(func(accts map[string]account) error {
+				for _, p := range r.User.GetPolicies() {
+					if p.GetAccount() == "" {
+						return status.Error(codes.InvalidArgument, "account not specified")
+					}
+					principals := &authorizedPrincipals{version: version{p.GetVersion(), p.GetCreatedOn()}}
+					for _, ap := range p.GetAuthorizedPrincipals().GetAuthorizedPrincipals() {
+						principals.users = append(principals.users, ap.GetAuthorizedUser())
+					}
+					a := accts[p.GetAccount()]
+					a.principals = principals
+					accts[p.GetAccount()] = a
+				}
+				return nil
+			})
+			resp.Response = &credzpb.RotateAccountCredentialsResponse_User{User: &credzpb.AuthorizedUsersResponse{}}
+		case *credzpb.RotateAccountCredentialsRequest_Password:
+			err = apply(func(accts map[string]account) error {
+				for _, p := range r.Password.GetAccounts() {
+					if p.GetAccount() == "" {
+						return status.Error(codes.InvalidArgument, "account not specified")
+					}
+					hash, err := passwordHash(p.GetPassword())
+					if err != nil {
+						return status.Errorf(codes.InvalidArgument, "invalid password for account %q: %v", p.GetAccount(), err)
+					}
+					a := accts[p.GetAccount()]
+					a.password = &password{version: version{p.GetVersion(), p.GetCreatedOn()}, hash: hash}
+					accts[p.GetAccount()] = a
+				}
+				return nil
+			})
+			resp.Response = &credzpb.RotateAccountCredentialsResponse_Password{Password: &credzpb.PasswordResponse{}}
+		case *credzpb.RotateAccountCredentialsRequest_Finalize:
+			if !changed {
+				return status.Error(codes.FailedPrecondition, "finalize called before any credentials were rotated")
+			}
+			changed = false
+			return nil
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", r)
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// RotateHostParameters implements the credentialz RotateHostParameters RPC. The parameters
+// are used immediately, but they are rolled back if the stream ends before the rotation is finalized.
+func (s *Server) RotateHostParameters(stream credzpb.Credentialz_RotateHostParametersServer) error {
+	if !s.hostRotation.CompareAndSwap(false, true) {
+		return status.Error(codes.Unavailable, "another host parameters rotation is already in progress")
+	}
+	defer s.hostRotation.Store(false)
+
+	var prev host
+	changed := false
+	defer func() {
+		if !changed {
+			return
+		}
+		log.Infof("credentialz host rotation not finalized, rolling back")
+		s.mu.Lock()
+		s.host = prev
+		s.mu.Unlock()
+		s.publish()
+	}()
+	apply := func(next host) {
+		s.mu.Lock()
+		if !changed {
+			prev = s.host
+			changed = true
+		}
+		s.host = next
+		s.mu.Unlock()
+		s.publish()
+	}
+	current := func() host {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		return s.host
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := &credzpb.RotateHostParametersResponse{}
+		next := current()
+		switch r := req.Request.(type) {
+		case *credzpb.RotateHostParametersRequest_SshCaPublicKey:
+			ca := &caKeys{version: version{r.SshCaPublicKey.GetVersion(), r.SshCaPublicKey.GetCreatedOn()}}
+			for _, k := range r.SshCaPublicKey.GetSshCaPublicKeys() {
+				key, err := parsePublicKey(k.GetPublicKey())
+				if err != nil {
+					return status.Errorf(codes.InvalidArgument, "invalid CA public key: %v", err)
+				}
+				ca.keys = append(ca.keys, key)
+			}
+			next.caKeys = ca
+			resp.Response = &credzpb.RotateHostParametersResponse_SshCaPublicKey{SshCaPublicKey: &credzpb.CaPublicKeyResponse{}}
+		case *credzpb.RotateHostParametersRequest_ServerKeys:
+			sk, err := parseServerKeys(r.ServerKeys)
+			if err != nil {
+				return err
+			}
+			next.serverKeys = sk
+			resp.Response = &credzpb.RotateHostParametersResponse_ServerKeys{ServerKeys: &credzpb.ServerKeysResponse{}}
+		case *credzpb.RotateHostParametersRequest_GenerateKeys:
+			if len(r.GenerateKeys.GetKeyParams()) == 0 {
+				return status.Error(codes.InvalidArgument, "no key parameters specified")
+			}
+			sk := &serverKeys{version: version{r.GenerateKeys.GetVersion(), r.GenerateKeys.GetCreatedOn()}}
+			genResp := &credzpb.GenerateKeysResponse{}
+			for _, p := range r.GenerateKeys.GetKeyParams() {
+				key, err := generateKey(p)
+				if err != nil {
+					return status.Errorf(codes.InvalidArgument, "failed to generate key: %v", err)
+				}
+				signer, err := ssh.NewSignerFromKey(key)
+				if err != nil {
+					return status.Errorf(codes.Internal, "failed to create signer: %v", err)
+				}
+				sk.keys = append(sk.keys, signer)
+				genResp.PublicKeys = append(genResp.PublicKeys, publicKeyProto(signer))
+			}
+			next.serverKeys = sk
+			resp.Response = &credzpb.RotateHostParametersResponse_GenerateKeys{GenerateKeys: genResp}
+		case *credzpb.RotateHostParametersRequest_AuthenticationAllowed:
+			allowed := map[credzpb.AuthenticationType]bool{}
+			for _, t := range r.AuthenticationAllowed.GetAuthenticationTypes() {
+				if t == credzpb.AuthenticationType_AUTHENTICATION_TYPE_UNSPECIFIED {
+					return status.Error(codes.InvalidArgument, "unspecified authentication type")
+				}
+				allowed[t] = true
+			}
+			next.allowedAuth = allowed
+			resp.Response = &credzpb.RotateHostParametersResponse_AuthenticationAllowed{AuthenticationAllowed: &credzpb.AllowedAuthenticationResponse{}}
+		case *credzpb.RotateHostParametersRequest_AuthorizedPrincipalCheck:
+			if tool := r.AuthorizedPrincipalCheck.GetTool(); tool != credzpb.AuthorizedPrincipalCheckRequest_TOOL_UNSPECIFIED {
+				return status.Errorf(codes.Unimplemented, "authorized principal check tool %v is not supported", tool)
+			}
+			resp.Response = &credzpb.RotateHostParametersResponse_AuthorizedPrincipalCheck{AuthorizedPrincipalCheck: &credzpb.AuthorizedPrincipalCheckResponse{}}
+		case *credzpb.RotateHostParametersRequest_Finalize:
+			if !changed {
+				return status.Error(codes.FailedPrecondition, "finalize called before any parameters were rotated")
+			}
+			changed = false
+			return nil
+		default:
+			return status.Errorf(codes.InvalidArgument, "unknown request type %T", r)
+		}
+		apply(next)
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// CanGenerateKey implements the credentialz CanGenerateKey RPC.
+func (s *Server) CanGenerateKey(_ context.Context, req *credzpb.CanGenerateKeyRequest) (*credzpb.CanGenerateKeyResponse, error) {
+	_, ok := keyGens[req.GetKeyParams()]
+	return &credzpb.CanGenerateKeyResponse{CanGenerate: ok}, nil
+}
+
+// GetPublicKeys implements the credentialz GetPublicKeys RPC.
+func (s *Server) GetPublicKeys(context.Context, *credzpb.GetPublicKeysRequest) (*credzpb.GetPublicKeysResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &credzpb.GetPublicKeysResponse{}
+	for _, k := range s.host.serverKeys.keys {
+		resp.PublicKeys = append(resp.PublicKeys, publicKeyProto(k))
+	}
+	return resp, nil
+}
+
+// passwordHash returns the crypt hash of the password.
+func passwordHash(p *credzpb.PasswordRequest_Password) (string, error) {
+	switch v := p.GetValue().(type) {
+	case *credzpb.PasswordRequest_Password_Plaintext:
+		if v.Plaintext == "" {
+			return "", fmt.Errorf("empty password")
+		}
+		return hashPassword(v.Plaintext)
+	case *credzpb.PasswordRequest_Password_CryptoHash:
+		hash := v.CryptoHash.GetHashValue()
+		switch v.CryptoHash.GetHashType() {
+		case credzpb.PasswordRequest_CryptoHash_HASH_TYPE_CRYPT_MD5:
+			if !strings.HasPrefix(hash, md5Prefix) {
+				return "", fmt.Errorf("hash %q is not a MD5 crypt hash", hash)
+			}
+		case credzpb.PasswordRequest_CryptoHash_HASH_TYPE_CRYPT_SHA_2_512:
+			if !strings.HasPrefix(hash, sha512Prefix) {
+				return "", fmt.Errorf("hash %q is not a SHA-512 crypt hash", hash)
+			}
+		default:
+			return "", fmt.Errorf("unsupported hash type %v", v.CryptoHash.GetHashType())
+		}
+		return hash, nil
+	default:
+		return "", fmt.Errorf("password not specified")
+	}
+}
+
+// parsePublicKey parses an OpenSSH authorized key, or a base64 encoded key in the SSH wire format.
+func parsePublicKey(b []byte) (ssh.PublicKey, error) {
+	if key, _, _, _, err := ssh.ParseAuthorizedKey(b); err == nil {
+		return key, nil
+	}
+	wire, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("key is neither in authorized keys nor base64 format")
+	}
+	return ssh.ParsePublicKey(wire)
+}
+
+// parseServerKeys parses the OpenSSH private keys and optional certificates.
+func parseServerKeys(req *credzpb.ServerKeysRequest) (*serverKeys, error) {
+	if len(req.GetAuthArtifacts()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no server keys specified")
+	}
+	sk := &serverKeys{version: version{req.GetVersion(), req.GetCreatedOn()}}
+	for _, a := range req.GetAuthArtifacts() {
+		signer, err := ssh.ParsePrivateKey(a.GetPrivateKey())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid private key: %v", err)
+		}
+		if len(a.GetCertificate()) > 0 {
+			key, err := parsePublicKey(a.GetCertificate())
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+			}
+			cert, ok := key.(*ssh.Certificate)
+			if !ok || cert.CertType != ssh.HostCert {
+				return nil, status.Error(codes.InvalidArgument, "certificate is not a SSH host certificate")
+			}
+			if signer, err = ssh.NewCertSigner(cert, signer); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid certificate: %v", err)
+			}
+			sk.hasCert = true
+		}
+		sk.keys = append(sk.keys, signer)
+	}
+	return sk, nil
+}
+
+var keyGens = map[credzpb.KeyGen]credzpb.KeyType{
+	credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_RSA_2048:      credzpb.KeyType_KEY_TYPE_RSA_2048,
+	credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_RSA_4096:      credzpb.KeyType_KEY_TYPE_RSA_4096,
+	credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_ECDSA_P_256:   credzpb.KeyType_KEY_TYPE_ECDSA_P_256,
+	credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_ECDSA_P_521:   credzpb.KeyType_KEY_TYPE_ECDSA_P_521,
+	credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_EDDSA_ED25519: credzpb.KeyType_KEY_TYPE_ED25519,
+}
+
+// generateKey generates a private key of the given type.
+func generateKey(kg credzpb.KeyGen) (crypto.Signer, error) {
+	switch kg {
+	case credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_RSA_2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_RSA_4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_ECDSA_P_256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_ECDSA_P_521:
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	case credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_EDDSA_ED25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %v", kg)
+	}
+}
+
+// publicKeyProto returns the public key of the host key in the OpenSSH format.
+func publicKeyProto(signer ssh.Signer) *credzpb.PublicKey {
+	pub := signer.PublicKey()
+	if cert, ok := pub.(*ssh.Certificate); ok {
+		pub = cert.Key
+	}
+	kt := credzpb.KeyType_KEY_TYPE_UNSPECIFIED
+	switch key := pub.(ssh.CryptoPublicKey).CryptoPublicKey().(type) {
+	case *rsa.PublicKey:
+		switch key.N.BitLen() {
+		case 2048:
+			kt = credzpb.KeyType_KEY_TYPE_RSA_2048
+		case 4096:
+			kt = credzpb.KeyType_KEY_TYPE_RSA_4096
+		}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			kt = credzpb.KeyType_KEY_TYPE_ECDSA_P_256
+		case elliptic.P521():
+			kt = credzpb.KeyType_KEY_TYPE_ECDSA_P_521
+		}
+	case ed25519.PublicKey:
+		kt = credzpb.KeyType_KEY_TYPE_ED25519
+	}
+	return &credzpb.PublicKey{
+		PublicKey: ssh.MarshalAuthorizedKey(pub),
+		KeyType:   kt,
+	}
+}
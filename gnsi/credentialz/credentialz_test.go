@@ -0,0 +1,472 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentialz
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+	"golang.org/x/crypto/ssh"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	credzpb "github.com/openconfig/gnsi/credentialz"
+)
+
+func TestCrypt(t *testing.T) {
+	tests := []struct {
+		desc     string
+		hash     string
+		password string
+		want     bool
+	}{{
+		desc:     "sha512",
+		hash:     "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+		password: "Hello world!",
+		want:     true,
+	}, {
+		desc:     "sha512 with rounds",
+		hash:     "$6$rounds=10000$saltstringsaltst$OW1/O6BYHV6BcXZu8QVeXbDWra3Oeqh0sbHbbMCVNSnCM/UrjmM0Dp8vOuZeHBy/YTBmSK6H9qs/y3RnOaw5v.",
+		password: "Hello world!",
+		want:     true,
+	}, {
+		desc:     "md5",
+		hash:     "$1$saltstri$qQY4WxjABChYG1ccLpfkz/",
+		password: "password",
+		want:     true,
+	}, {
+		desc:     "wrong password",
+		hash:     "$1$saltstri$qQY4WxjABChYG1ccLpfkz/",
+		password: "Password",
+	}, {
+		desc:     "unsupported hash",
+		hash:     "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZF9Gp9ZpP",
+		password: "Hello world!",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := checkPassword(tt.hash, tt.password); got != tt.want {
+				t.Errorf("checkPassword(%q, %q) got %v, want %v", tt.hash, tt.password, got, tt.want)
+			}
+		})
+	}
+	hash, err := hashPassword("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkPassword(hash, "foo") {
+		t.Errorf("checkPassword(%q, %q) got false, want true", hash, "foo")
+	}
+}
+
+func passwordReq(account, pwd, version string) *credzpb.RotateAccountCredentialsRequest {
+	return &credzpb.RotateAccountCredentialsRequest{
+		Request: &credzpb.RotateAccountCredentialsRequest_Password{
+			Password: &credzpb.PasswordRequest{
+				Accounts: []*credzpb.PasswordRequest_Account{{
+					Account:  account,
+					Version:  version,
+					Password: &credzpb.PasswordRequest_Password{Value: &credzpb.PasswordRequest_Password_Plaintext{Plaintext: pwd}},
+				}},
+			},
+		},
+	}
+}
+
+func keysReq(account string, version string, keys ...ssh.PublicKey) *credzpb.RotateAccountCredentialsRequest {
+	creds := &credzpb.AccountCredentials{Account: account, Version: version}
+	for _, k := range keys {
+		creds.AuthorizedKeys = append(creds.AuthorizedKeys, &credzpb.AccountCredentials_AuthorizedKey{AuthorizedKey: ssh.MarshalAuthorizedKey(k)})
+	}
+	return &credzpb.RotateAccountCredentialsRequest{
+		Request: &credzpb.RotateAccountCredentialsRequest_Credential{
+			Credential: &credzpb.AuthorizedKeysRequest{Credentials: []*credzpb.AccountCredentials{creds}},
+		},
+	}
+}
+
+func principalsReq(account string, principals ...string) *credzpb.RotateAccountCredentialsRequest {
+	p := &credzpb.UserPolicy_SshAuthorizedPrincipals{}
+	for _, user := range principals {
+		p.AuthorizedPrincipals = append(p.AuthorizedPrincipals, &credzpb.UserPolicy_SshAuthorizedPrincipal{AuthorizedUser: user})
+	}
+	return &credzpb.RotateAccountCredentialsRequest{
+		Request: &credzpb.RotateAccountCredentialsRequest_User{
+			User: &credzpb.AuthorizedUsersRequest{Policies: []*credzpb.UserPolicy{{Account: account, AuthorizedPrincipals: p}}},
+		},
+	}
+}
+
+var finalizeAccount = &credzpb.RotateAccountCredentialsRequest{
+	Request: &credzpb.RotateAccountCredentialsRequest_Finalize{Finalize: &credzpb.FinalizeRequest{}},
+}
+
+var finalizeHost = &credzpb.RotateHostParametersRequest{
+	Request: &credzpb.RotateHostParametersRequest_Finalize{Finalize: &credzpb.FinalizeRequest{}},
+}
+
+// rotateAccount sends the requests and finalizes the rotation.
+func rotateAccount(t testing.TB, client credzpb.CredentialzClient, reqs ...*credzpb.RotateAccountCredentialsRequest) {
+	t.Helper()
+	stream, err := client.RotateAccountCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range reqs {
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("RotateAccountCredentials() unexpected err: %v", err)
+		}
+	}
+	if err := stream.Send(finalizeAccount); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("RotateAccountCredentials() unexpected err after finalize: %v", err)
+	}
+}
+
+// rotateHost sends the requests and finalizes the rotation, it returns the last response.
+func rotateHost(t testing.TB, client credzpb.CredentialzClient, reqs ...*credzpb.RotateHostParametersRequest) *credzpb.RotateHostParametersResponse {
+	t.Helper()
+	stream, err := client.RotateHostParameters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp *credzpb.RotateHostParametersResponse
+	for _, req := range reqs {
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+		if resp, err = stream.Recv(); err != nil {
+			t.Fatalf("RotateHostParameters() unexpected err: %v", err)
+		}
+	}
+	if err := stream.Send(finalizeHost); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("RotateHostParameters() unexpected err after finalize: %v", err)
+	}
+	return resp
+}
+
+func TestRotateAccountCredentials(t *testing.T) {
+	tests := []struct {
+		desc     string
+		reqs     []*credzpb.RotateAccountCredentialsRequest
+		wantErrs []string
+	}{{
+		desc:     "finalize before rotation",
+		reqs:     []*credzpb.RotateAccountCredentialsRequest{finalizeAccount},
+		wantErrs: []string{"finalize called before"},
+	}, {
+		desc:     "no account",
+		reqs:     []*credzpb.RotateAccountCredentialsRequest{passwordReq("", "foo", "1")},
+		wantErrs: []string{"account not specified"},
+	}, {
+		desc:     "empty password",
+		reqs:     []*credzpb.RotateAccountCredentialsRequest{passwordReq("admin", "", "1")},
+		wantErrs: []string{"empty password"},
+	}, {
+		desc: "invalid authorized key",
+		reqs: []*credzpb.RotateAccountCredentialsRequest{{
+			Request: &credzpb.RotateAccountCredentialsRequest_Credential{
+				Credential: &credzpb.AuthorizedKeysRequest{Credentials: []*credzpb.AccountCredentials{{
+					Account:        "admin",
+					AuthorizedKeys: []*credzpb.AccountCredentials_AuthorizedKey{{AuthorizedKey: []byte("foo")}},
+				}}},
+			},
+		}},
+		wantErrs: []string{"invalid authorized key"},
+	}, {
+		desc: "mismatched hash type",
+		reqs: []*credzpb.RotateAccountCredentialsRequest{{
+			Request: &credzpb.RotateAccountCredentialsRequest_Password{
+				Password: &credzpb.PasswordRequest{Accounts: []*credzpb.PasswordRequest_Account{{
+					Account: "admin",
+					Password: &credzpb.PasswordRequest_Password{Value: &credzpb.PasswordRequest_Password_CryptoHash{CryptoHash: &credzpb.PasswordRequest_CryptoHash{
+						HashType:  credzpb.PasswordRequest_CryptoHash_HASH_TYPE_CRYPT_MD5,
+						HashValue: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1",
+					}}},
+				}}},
+			},
+		}},
+		wantErrs: []string{"not a MD5 crypt hash"},
+	}, {
+		desc:     "password and principals",
+		reqs:     []*credzpb.RotateAccountCredentialsRequest{passwordReq("admin", "foo", "1"), principalsReq("admin", "alice"), finalizeAccount},
+		wantErrs: []string{"", "", "EOF"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			client, closeFn := start(t, New())
+			defer closeFn()
+			stream, err := client.RotateAccountCredentials(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, req := range tt.reqs {
+				if err := stream.Send(req); err != nil {
+					t.Fatal(err)
+				}
+				_, err := stream.Recv()
+				if d := errdiff.Check(err, tt.wantErrs[i]); d != "" {
+					t.Errorf("RotateAccountCredentials() unexpected err: %s", d)
+				}
+			}
+		})
+	}
+}
+
+func TestAccountRollback(t *testing.T) {
+	srv := New()
+	client, closeFn := start(t, srv)
+	defer closeFn()
+
+	rotateAccount(t, client, passwordReq("admin", "old", "1"))
+	stream, err := client.RotateAccountCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(passwordReq("admin", "new", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("RotateAccountCredentials() unexpected err: %v", err)
+	}
+	srv.mu.RLock()
+	if got := srv.accounts["admin"].password; !checkPassword(got.hash, "new") || got.version.version != "2" {
+		t.Errorf("RotateAccountCredentials() got password version %q before finalize, want new password with version 2", got.version.version)
+	}
+	srv.mu.RUnlock()
+	stream.CloseSend()
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("RotateAccountCredentials() unexpected err after close: %v", err)
+	}
+
+	srv.mu.RLock()
+	defer srv.mu.RUnlock()
+	if got := srv.accounts["admin"].password; !checkPassword(got.hash, "old") || got.version.version != "1" {
+		t.Errorf("RotateAccountCredentials() got password version %q after rollback, want old password with version 1", got.version.version)
+	}
+}
+
+func TestRotateHostParameters(t *testing.T) {
+	srv := New()
+	client, closeFn := start(t, srv)
+	defer closeFn()
+
+	resp := rotateHost(t, client, &credzpb.RotateHostParametersRequest{
+		Request: &credzpb.RotateHostParametersRequest_GenerateKeys{
+			GenerateKeys: &credzpb.GenerateKeysRequest{
+				KeyParams: []credzpb.KeyGen{credzpb.KeyGen_KEY_GEN_SSH_KEY_TYPE_ECDSA_P_256},
+				Version:   "1",
+			},
+		},
+	})
+	gen := resp.GetGenerateKeys().GetPublicKeys()
+	if len(gen) != 1 || gen[0].GetKeyType() != credzpb.KeyType_KEY_TYPE_ECDSA_P_256 {
+		t.Fatalf("RotateHostParameters() got generated keys %v, want 1 ECDSA P-256 key", gen)
+	}
+	got, err := client.GetPublicKeys(context.Background(), &credzpb.GetPublicKeysRequest{})
+	if err != nil {
+		t.Fatalf("GetPublicKeys() unexpected err: %v", err)
+	}
+	if len(got.GetPublicKeys()) != 1 || string(got.GetPublicKeys()[0].GetPublicKey()) != string(gen[0].GetPublicKey()) {
+		t.Errorf("GetPublicKeys() got %v, want generated key %v", got.GetPublicKeys(), gen)
+	}
+
+	stream, err := client.RotateHostParameters(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(&credzpb.RotateHostParametersRequest{
+		Request: &credzpb.RotateHostParametersRequest_AuthorizedPrincipalCheck{
+			AuthorizedPrincipalCheck: &credzpb.AuthorizedPrincipalCheckRequest{Tool: credzpb.AuthorizedPrincipalCheckRequest_TOOL_HIBA_DEFAULT},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = stream.Recv()
+	if d := errdiff.Check(err, "not supported"); d != "" {
+		t.Errorf("RotateHostParameters() unexpected err: %s", d)
+	}
+}
+
+func TestCanGenerateKey(t *testing.T) {
+	srv := New()
+	for kg := range credzpb.KeyGen_name {
+		want := kg != int32(credzpb.KeyGen_KEY_GEN_SSH_KEY_UNSPECIFIED)
+		got, err := srv.CanGenerateKey(context.Background(), &credzpb.CanGenerateKeyRequest{KeyParams: credzpb.KeyGen(kg)})
+		if err != nil {
+			t.Fatalf("CanGenerateKey() unexpected err: %v", err)
+		}
+		if got.GetCanGenerate() != want {
+			t.Errorf("CanGenerateKey(%v) got %v, want %v", credzpb.KeyGen(kg), got.GetCanGenerate(), want)
+		}
+	}
+}
+
+func newSigner(t testing.TB) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// TestSSH checks that the SSH server enforces the rotated credentials.
+func TestSSH(t *testing.T) {
+	srv := New()
+	client, closeFn := start(t, srv)
+	defer closeFn()
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+	go srv.ServeSSH(l)
+	defer l.Close()
+
+	userKey, otherKey, caKey := newSigner(t), newSigner(t), newSigner(t)
+	userCert := &ssh.Certificate{
+		Key:             otherKey.PublicKey(),
+		CertType:        ssh.UserCert,
+		ValidPrincipals: []string{"alice"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := userCert.SignCert(rand.Reader, caKey); err != nil {
+		t.Fatal(err)
+	}
+	certSigner, err := ssh.NewCertSigner(userCert, otherKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotateAccount(t, client, passwordReq("admin", "secret", "1"), keysReq("admin", "1", userKey.PublicKey()), principalsReq("admin", "alice"))
+	rotateHost(t, client, &credzpb.RotateHostParametersRequest{
+		Request: &credzpb.RotateHostParametersRequest_SshCaPublicKey{
+			SshCaPublicKey: &credzpb.CaPublicKeyRequest{
+				SshCaPublicKeys: []*credzpb.PublicKey{{PublicKey: ssh.MarshalAuthorizedKey(caKey.PublicKey())}},
+				Version:         "1",
+			},
+		},
+	})
+
+	tests := []struct {
+		desc    string
+		user    string
+		auth    ssh.AuthMethod
+		wantErr string
+	}{{
+		desc: "password",
+		user: "admin",
+		auth: ssh.Password("secret"),
+	}, {
+		desc:    "wrong password",
+		user:    "admin",
+		auth:    ssh.Password("foo"),
+		wantErr: "unable to authenticate",
+	}, {
+		desc: "authorized key",
+		user: "admin",
+		auth: ssh.PublicKeys(userKey),
+	}, {
+		desc:    "unauthorized key",
+		user:    "admin",
+		auth:    ssh.PublicKeys(otherKey),
+		wantErr: "unable to authenticate",
+	}, {
+		desc: "certificate for authorized principal",
+		user: "admin",
+		auth: ssh.PublicKeys(certSigner),
+	}, {
+		desc:    "unknown user",
+		user:    "bob",
+		auth:    ssh.Password("secret"),
+		wantErr: "unable to authenticate",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			c, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+				User:            tt.user,
+				Auth:            []ssh.AuthMethod{tt.auth},
+				HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			})
+			if d := errdiff.Check(err, tt.wantErr); d != "" {
+				t.Fatalf("Dial() unexpected err: %s", d)
+			}
+			if err != nil {
+				return
+			}
+			defer c.Close()
+			sess, err := c.NewSession()
+			if err != nil {
+				t.Fatalf("NewSession() unexpected err: %v", err)
+			}
+			if _, err := sess.Output("show version"); err != nil {
+				t.Errorf("Output() unexpected err: %v", err)
+			}
+		})
+	}
+
+	rotateHost(t, client, &credzpb.RotateHostParametersRequest{
+		Request: &credzpb.RotateHostParametersRequest_AuthenticationAllowed{
+			AuthenticationAllowed: &credzpb.AllowedAuthenticationRequest{
+				AuthenticationTypes: []credzpb.AuthenticationType{credzpb.AuthenticationType_AUTHENTICATION_TYPE_PUBKEY},
+			},
+		},
+	})
+	_, err = ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+		User:            "admin",
+		Auth:            []ssh.AuthMethod{ssh.Password("secret")},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if d := errdiff.Check(err, "unable to authenticate"); d != "" {
+		t.Errorf("Dial() with password auth disallowed unexpected err: %s", d)
+	}
+}
+
+func start(t testing.TB, srv *Server) (credzpb.CredentialzClient, func()) {
+	t.Helper()
+	s := grpc.NewServer()
+	credzpb.RegisterCredentialzServer(s, srv)
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+
+	go s.Serve(l)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed dial server: %v", err)
+	}
+	return credzpb.NewCredentialzClient(conn), func() { s.Stop() }
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentialz
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file implements the MD5 ($1$) and SHA-512 ($6$) crypt(3) password hashes,
+// see https://www.akkadia.org/drepper/SHA-crypt.txt.
+
+const (
+	cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+	md5Prefix    = "$1$"
+	sha512Prefix = "$6$"
+
+	sha512DefaultRounds = 5000
+	sha512MinRounds     = 1000
+	sha512MaxRounds     = 999999999
+)
+
+// cryptEncode encodes the bytes at the indices using the crypt base64 alphabet,
+// each group of three bytes is encoded into four characters.
+func cryptEncode(sum []byte, groups [][3]int, last int, lastLen int) string {
+	var sb strings.Builder
+	enc := func(v uint32, n int) {
+		for ; n > 0; n-- {
+			sb.WriteByte(cryptAlphabet[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range groups {
+		enc(uint32(sum[g[0]])<<16|uint32(sum[g[1]])<<8|uint32(sum[g[2]]), 4)
+	}
+	enc(uint32(sum[last]), lastLen)
+	return sb.String()
+}
+
+var md5Groups = [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}}
+
+// md5Crypt returns the MD5 crypt hash of the password.
+func md5Crypt(password, salt []byte) string {
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+	alt := md5.Sum(append(append(append([]byte{}, password...), salt...), password...))
+
+	h := md5.New()
+	h.Write(password)
+	h.Write([]byte(md5Prefix))
+	h.Write(salt)
+	for i := len(password); i > 0; i -= 16 {
+		h.Write(alt[:min(i, 16)])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(password[:1])
+		}
+	}
+	sum := h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		h := md5.New()
+		if i&1 != 0 {
+			h.Write(password)
+		} else {
+			h.Write(sum)
+		}
+		if i%3 != 0 {
+			h.Write(salt)
+		}
+		if i%7 != 0 {
+			h.Write(password)
+		}
+		if i&1 != 0 {
+			h.Write(sum)
+		} else {
+			h.Write(password)
+		}
+		sum = h.Sum(nil)
+	}
+	return md5Prefix + string(salt) + "$" + cryptEncode(sum, md5Groups, 11, 2)
+}
+
+var sha512Groups = [][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4}, {47, 5, 26}, {6, 27, 48},
+	{28, 49, 7}, {50, 8, 29}, {9, 30, 51}, {31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13},
+	{56, 14, 35}, {15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19}, {62, 20, 41},
+}
+
+// sha512Crypt returns the SHA-512 crypt hash of the password. If rounds is 0,
+// the default number of rounds is used and omitted from the result.
+func sha512Crypt(password, salt []byte, rounds int) string {
+	if len(salt) > 16 {
+		salt = salt[:16]
+	}
+	prefix := sha512Prefix
+	if rounds == 0 {
+		rounds = sha512DefaultRounds
+	} else {
+		rounds = max(sha512MinRounds, min(rounds, sha512MaxRounds))
+		prefix += fmt.Sprintf("rounds=%d$", rounds)
+	}
+
+	b := sha512.New()
+	b.Write(password)
+	b.Write(salt)
+	b.Write(password)
+	alt := b.Sum(nil)
+
+	a := sha512.New()
+	a.Write(password)
+	a.Write(salt)
+	for i := len(password); i > 0; i -= 64 {
+		a.Write(alt[:min(i, 64)])
+	}
+	for i := len(password); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			a.Write(alt)
+		} else {
+			a.Write(password)
+		}
+	}
+	sum := a.Sum(nil)
+
+	dp := sha512.New()
+	for range password {
+		dp.Write(password)
+	}
+	p := repeat(dp.Sum(nil), len(password))
+
+	ds := sha512.New()
+	for i := 0; i < 16+int(sum[0]); i++ {
+		ds.Write(salt)
+	}
+	s := repeat(ds.Sum(nil), len(salt))
+
+	for i := 0; i < rounds; i++ {
+		h := sha512.New()
+		if i&1 != 0 {
+			h.Write(p)
+		} else {
+			h.Write(sum)
+		}
+		if i%3 != 0 {
+			h.Write(s)
+		}
+		if i%7 != 0 {
+			h.Write(p)
+		}
+		if i&1 != 0 {
+			h.Write(sum)
+		} else {
+			h.Write(p)
+		}
+		sum = h.Sum(nil)
+	}
+
+	return prefix + string(salt) + "$" + cryptEncode(sum, sha512Groups, 63, 2)
+}
+
+// repeat returns n bytes made of copies of b.
+func repeat(b []byte, n int) []byte {
+	out := make([]byte, 0, n)
+	for len(out) < n {
+		out = append(out, b[:min(len(b), n-len(out))]...)
+	}
+	return out
+}
+
+// hashPassword returns the SHA-512 crypt hash of the password with a random salt.
+func hashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	for i, b := range salt {
+		salt[i] = cryptAlphabet[int(b)%len(cryptAlphabet)]
+	}
+	return sha512Crypt([]byte(password), salt, 0), nil
+}
+
+// checkPassword returns whether the password matches the crypt hash.
+func checkPassword(hash, password string) bool {
+	var got string
+	switch {
+	case strings.HasPrefix(hash, md5Prefix):
+		salt, _, ok := strings.Cut(strings.TrimPrefix(hash, md5Prefix), "$")
+		if !ok {
+			return false
+		}
+		got = md5Crypt([]byte(password), []byte(salt))
+	case strings.HasPrefix(hash, sha512Prefix):
+		rest := strings.TrimPrefix(hash, sha512Prefix)
+		rounds := 0
+		if r, ok := strings.CutPrefix(rest, "rounds="); ok {
+			n, after, ok := strings.Cut(r, "$")
+			if !ok {
+				return false
+			}
+			var err error
+			if rounds, err = strconv.Atoi(n); err != nil {
+				return false
+			}
+			rest = after
+		}
+		salt, _, ok := strings.Cut(rest, "$")
+		if !ok {
+			return false
+		}
+		got = sha512Crypt([]byte(password), []byte(salt), rounds)
+	default:
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(hash)) == 1
+}
@@ -0,0 +1,202 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentialz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/golang/glog"
+	"golang.org/x/crypto/ssh"
+
+	credzpb "github.com/openconfig/gnsi/credentialz"
+)
+
+// counters are the SSH server access counters.
+type counters struct {
+	accepts    uint64
+	rejects    uint64
+	lastAccept uint64
+	lastReject uint64
+}
+
+// ServeSSH accepts SSH connections on the listener until it is closed. Clients are
+// authenticated using the credentials active at the time of the connection, but
+// no shell is provided once they are authenticated.
+func (s *Server) ServeSSH(lis net.Listener) error {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.handleSSH(conn)
+	}
+}
+
+func (s *Server) handleSSH(conn net.Conn) {
+	defer conn.Close()
+	sconn, chans, reqs, err := ssh.NewServerConn(conn, s.sshConfig())
+	s.recordAccess(err == nil)
+	if err != nil {
+		log.V(1).Infof("ssh connection from %v rejected: %v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sconn.Close()
+	go ssh.DiscardRequests(reqs)
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		ch, creqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		go serveSession(ch, creqs, sconn.User())
+	}
+}
+
+// serveSession replies to shell and exec requests with a message and exits.
+func serveSession(ch ssh.Channel, reqs <-chan *ssh.Request, user string) {
+	defer ch.Close()
+	for req := range reqs {
+		switch req.Type {
+		case "shell", "exec":
+			req.Reply(true, nil)
+			fmt.Fprintf(ch, "authenticated as %q, no shell is available\r\n", user)
+			ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+			return
+		default:
+			req.Reply(req.Type == "pty-req" || req.Type == "env", nil)
+		}
+	}
+}
+
+// recordAccess updates the access counters.
+func (s *Server) recordAccess(accepted bool) {
+	now := uint64(time.Now().UnixNano())
+	s.mu.Lock()
+	if accepted {
+		s.counters.accepts++
+		s.counters.lastAccept = now
+	} else {
+		s.counters.rejects++
+		s.counters.lastReject = now
+	}
+	s.mu.Unlock()
+	s.publish()
+}
+
+// sshConfig returns the SSH server config for the active credentials.
+func (s *Server) sshConfig() *ssh.ServerConfig {
+	s.mu.RLock()
+	accounts := s.accounts
+	h := s.host
+	s.mu.RUnlock()
+
+	allowed := func(t credzpb.AuthenticationType) bool {
+		return h.allowedAuth == nil || h.allowedAuth[t]
+	}
+	checkPwd := func(user, pwd string) error {
+		if p := accounts[user].password; p != nil && checkPassword(p.hash, pwd) {
+			return nil
+		}
+		return fmt.Errorf("invalid password for user %q", user)
+	}
+
+	cfg := &ssh.ServerConfig{}
+	for _, k := range h.serverKeys.keys {
+		cfg.AddHostKey(k)
+	}
+	if allowed(credzpb.AuthenticationType_AUTHENTICATION_TYPE_PASSWORD) {
+		cfg.PasswordCallback = func(c ssh.ConnMetadata, pwd []byte) (*ssh.Permissions, error) {
+			return nil, checkPwd(c.User(), string(pwd))
+		}
+	}
+	if allowed(credzpb.AuthenticationType_AUTHENTICATION_TYPE_KBDINTERACTIVE) {
+		cfg.KeyboardInteractiveCallback = func(c ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := client(c.User(), "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 {
+				return nil, fmt.Errorf("expected 1 answer, got %d", len(answers))
+			}
+			return nil, checkPwd(c.User(), answers[0])
+		}
+	}
+	if allowed(credzpb.AuthenticationType_AUTHENTICATION_TYPE_PUBKEY) {
+		cfg.PublicKeyCallback = func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			return nil, authorizeKey(c.User(), accounts[c.User()], h.caKeys, key)
+		}
+	}
+	return cfg
+}
+
+func keyEqual(a, b ssh.PublicKey) bool {
+	return bytes.Equal(a.Marshal(), b.Marshal())
+}
+
+// authorizeKey checks that the key is one of the authorized keys of the account, or that
+// it is a certificate signed by a trusted CA for one of the authorized principals.
+// If the account has no authorized principals, the certificate must be valid for the user.
+func authorizeKey(user string, a account, ca *caKeys, key ssh.PublicKey) error {
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		if a.keys != nil {
+			for _, k := range a.keys.keys {
+				if keyEqual(k, key) {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("unauthorized key for user %q", user)
+	}
+
+	if ca == nil {
+		return fmt.Errorf("no trusted user CA keys")
+	}
+	if cert.CertType != ssh.UserCert {
+		return fmt.Errorf("certificate is not a user certificate")
+	}
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			for _, k := range ca.keys {
+				if keyEqual(k, auth) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+	principals := []string{user}
+	if a.principals != nil {
+		principals = a.principals.users
+	}
+	var err error
+	for _, p := range principals {
+		if err = checker.CheckCert(p, cert); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("certificate not valid for user %q: %v", user, err)
+}
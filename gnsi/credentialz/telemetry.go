@@ -0,0 +1,118 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package credentialz
+
+import (
+	"context"
+	"maps"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/ygnmi/ygnmi"
+
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+)
+
+// versionLeaves are the version and created-on state leaves of a set of credentials.
+type versionLeaves struct {
+	version   ygnmi.SingletonQuery[string]
+	createdOn ygnmi.SingletonQuery[uint64]
+}
+
+// batchVersion replaces the leaves with the version, or deletes them if v is nil.
+func batchVersion(b *ygnmi.SetBatch, l versionLeaves, v *version) {
+	if v == nil {
+		gnmiclient.BatchDelete(b, l.version)
+		gnmiclient.BatchDelete(b, l.createdOn)
+		return
+	}
+	gnmiclient.BatchReplace(b, l.version, v.version)
+	gnmiclient.BatchReplace(b, l.createdOn, v.createdOn)
+}
+
+// publish writes the credentials versions and SSH server counters to the gNMI cache.
+func (s *Server) publish() {
+	s.pubMu.Lock()
+	defer s.pubMu.Unlock()
+	if s.client == nil {
+		return
+	}
+
+	s.mu.RLock()
+	accounts := maps.Clone(s.accounts)
+	h := s.host
+	cnt := s.counters
+	s.mu.RUnlock()
+
+	b := &ygnmi.SetBatch{}
+	for name := range s.published {
+		if _, ok := accounts[name]; !ok {
+			accounts[name] = account{}
+		}
+	}
+	for name, a := range accounts {
+		user := ocpath.Root().System().Aaa().Authentication().User(name)
+		var pwd, keys, principals *version
+		if a.password != nil {
+			pwd = &a.password.version
+		}
+		if a.keys != nil {
+			keys = &a.keys.version
+		}
+		if a.principals != nil {
+			principals = &a.principals.version
+		}
+		batchVersion(b, versionLeaves{user.PasswordVersion().State(), user.PasswordCreatedOn().State()}, pwd)
+		batchVersion(b, versionLeaves{user.AuthorizedKeysListVersion().State(), user.AuthorizedKeysListCreatedOn().State()}, keys)
+		batchVersion(b, versionLeaves{user.AuthorizedPrincipalsListVersion().State(), user.AuthorizedPrincipalsListCreatedOn().State()}, principals)
+	}
+
+	ssh := ocpath.Root().System().SshServer()
+	var hostKey, hostCert, ca *version
+	if h.serverKeys != nil {
+		hostKey = &h.serverKeys.version
+		if h.serverKeys.hasCert {
+			hostCert = &h.serverKeys.version
+		}
+	}
+	if h.caKeys != nil {
+		ca = &h.caKeys.version
+	}
+	batchVersion(b, versionLeaves{ssh.ActiveHostKeyVersion().State(), ssh.ActiveHostKeyCreatedOn().State()}, hostKey)
+	batchVersion(b, versionLeaves{ssh.ActiveHostCertificateVersion().State(), ssh.ActiveHostCertificateCreatedOn().State()}, hostCert)
+	batchVersion(b, versionLeaves{ssh.ActiveTrustedUserCaKeysVersion().State(), ssh.ActiveTrustedUserCaKeysCreatedOn().State()}, ca)
+
+	counters := ssh.Counters()
+	gnmiclient.BatchReplace(b, counters.AccessAccepts().State(), cnt.accepts)
+	gnmiclient.BatchReplace(b, counters.AccessRejects().State(), cnt.rejects)
+	if cnt.lastAccept != 0 {
+		gnmiclient.BatchReplace(b, counters.LastAccessAccept().State(), cnt.lastAccept)
+	}
+	if cnt.lastReject != 0 {
+		gnmiclient.BatchReplace(b, counters.LastAccessReject().State(), cnt.lastReject)
+	}
+
+	if _, err := b.Set(context.Background(), s.client); err != nil {
+		log.Warningf("failed to publish credentialz state: %v", err)
+		return
+	}
+	for name, a := range accounts {
+		if a == (account{}) {
+			delete(s.published, name)
+		} else {
+			s.published[name] = true
+		}
+	}
+}
@@ -15,80 +15,95 @@
 package gnsi
 
 import (
-	"context"
-
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 
 	authzpb "github.com/openconfig/gnsi/authz"
 	certzpb "github.com/openconfig/gnsi/certz"
 	credentialzpb "github.com/openconfig/gnsi/credentialz"
 	pathzpb "github.com/openconfig/gnsi/pathz"
 
+	"github.com/openconfig/lemming/gnsi/authz"
+	"github.com/openconfig/lemming/gnsi/certz"
+	"github.com/openconfig/lemming/gnsi/credentialz"
 	"github.com/openconfig/lemming/gnsi/pathz"
 )
 
-type authz struct {
-	authzpb.UnimplementedAuthzServer
-}
-
-func (a *authz) Rotate(authzpb.Authz_RotateServer) error {
-	return status.Errorf(codes.Unimplemented, "Fake UnImplemented")
-}
-
-func (a *authz) Get(context.Context, *authzpb.GetRequest) (*authzpb.GetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "Fake UnImplemented")
+// Server is a fake gNSI implementation.
+type Server struct {
+	s     *grpc.Server
+	authz *authz.Server
+	certz *certz.Server
+	pathz *pathz.Server
+	credz *credentialz.Server
 }
 
-type cert struct {
-	certzpb.UnimplementedCertzServer
+func (s *Server) GetPathZ() *pathz.Server {
+	return s.pathz
 }
 
-func (c *cert) CanGenerateCSR(context.Context, *certzpb.CanGenerateCSRRequest) (*certzpb.CanGenerateCSRResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "Fake UnImplemented")
+// GetAuthz returns the authz server.
+func (s *Server) GetAuthz() *authz.Server {
+	return s.authz
 }
 
-func (c *cert) Rotate(certzpb.Certz_RotateServer) error {
-	return status.Errorf(codes.Unimplemented, "Fake UnImplemented")
+// GetCertz returns the certz server.
+func (s *Server) GetCertz() *certz.Server {
+	return s.certz
 }
 
-type credentialz struct {
-	credentialzpb.UnimplementedCredentialzServer
+// GetCredentialz returns the credentialz server.
+func (s *Server) GetCredentialz() *credentialz.Server {
+	return s.credz
 }
 
-func (c *credentialz) MutateAccountCredentials(credentialzpb.Credentialz_RotateAccountCredentialsServer) error {
-	return status.Errorf(codes.Unimplemented, "Fake UnImplemented")
-}
+// Option configures the gNSI server.
+type Option func(*Server)
 
-func (c *credentialz) MutateHostCredentials(credentialzpb.Credentialz_RotateHostParametersServer) error {
-	return status.Errorf(codes.Unimplemented, "Fake UnImplemented")
+// WithAuthz sets the authz server registered as the gNSI authz service, its interceptors
+// should be installed on the gRPC servers to enforce the policy.
+func WithAuthz(az *authz.Server) Option {
+	return func(s *Server) {
+		s.authz = az
+	}
 }
 
-// Server is a fake gNSI implementation.
-type Server struct {
-	s     *grpc.Server
-	authz *authz
-	cert  *cert
-	pathz *pathz.Server
-	credz *credentialz
+// WithCertz sets the certz server registered as the gNSI certz service, its credentials
+// should be used by the gRPC servers for rotations to take effect.
+func WithCertz(cz *certz.Server) Option {
+	return func(s *Server) {
+		s.certz = cz
+	}
 }
 
-func (s *Server) GetPathZ() *pathz.Server {
-	return s.pathz
+// WithCredentialz sets the credentialz server registered as the gNSI credentialz service,
+// its reconciler should be registered with the gNMI server to publish the credentials state.
+func WithCredentialz(cz *credentialz.Server) Option {
+	return func(s *Server) {
+		s.credz = cz
+	}
 }
 
 // New returns a new fake gNMI server.
-func New(s *grpc.Server) *Server {
+// If the authz, certz or credentialz servers aren't set by the options, new ones are created.
+func New(s *grpc.Server, opts ...Option) *Server {
 	srv := &Server{
 		s:     s,
-		authz: &authz{},
-		cert:  &cert{},
 		pathz: &pathz.Server{},
-		credz: &credentialz{},
+	}
+	for _, opt := range opts {
+		opt(srv)
+	}
+	if srv.authz == nil {
+		srv.authz = authz.New()
+	}
+	if srv.certz == nil {
+		srv.certz = certz.New(nil)
+	}
+	if srv.credz == nil {
+		srv.credz = credentialz.New()
 	}
 	authzpb.RegisterAuthzServer(s, srv.authz)
-	certzpb.RegisterCertzServer(s, srv.cert)
+	certzpb.RegisterCertzServer(s, srv.certz)
 	credentialzpb.RegisterCredentialzServer(s, srv.credz)
 	pathzpb.RegisterPathzServer(s, srv.pathz)
 
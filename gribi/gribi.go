@@ -20,6 +20,7 @@ import (
 	"maps"
 	"net/netip"
 	"slices"
+	"sync/atomic"
 
 	log "github.com/golang/glog"
 	"github.com/openconfig/gribigo/aft"
@@ -48,6 +49,8 @@ import (
 type Server struct {
 	*server.Server
 	s *grpc.Server
+	// persist checkpoints the RIB, it is nil if persistence is disabled.
+	persist atomic.Pointer[persister]
 }
 
 // New returns a new fake gRIBI server.
@@ -59,29 +62,42 @@ type Server struct {
 //   - opts, if specified, will be used to control the underlying gRIBI server's
 //     behaviours.
 func New(s *grpc.Server, gClient gpb.GNMIClient, target string, root *oc.Root, sysribAddr string, opts ...server.ServerOpt) (*Server, error) {
-	gs, err := createGRIBIServer(gClient, target, root, sysribAddr, opts...)
+	srv := &Server{
+		s: s,
+	}
+	gs, err := createGRIBIServer(gClient, target, root, sysribAddr, srv.ribChanged, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create gRIBI server, %v", err)
 	}
-
-	srv := &Server{
-		Server: gs,
-		s:      s,
-	}
+	srv.Server = gs
 	gribipb.RegisterGRIBIServer(s, srv)
 
 	return srv, nil
 }
 
+// Reset removes all entries from the RIB, regardless of the client that
+// added them, which also removes them from the sysrib.
+func (s *Server) Reset(ctx context.Context) error {
+	if _, err := s.Server.Flush(ctx, &gribipb.FlushRequest{
+		NetworkInstance: &gribipb.FlushRequest_All{All: &gribipb.Empty{}},
+		Election:        &gribipb.FlushRequest_Override{Override: &gribipb.Empty{}},
+	}); err != nil {
+		return fmt.Errorf("cannot flush gRIBI RIB: %v", err)
+	}
+	log.Info("gRIBI RIB reset")
+	return nil
+}
+
 // createGRIBIServer creates and returns a gRIBI server that is ready be
 // registered by a gRPC server.
 //
 // - root, if specified, will be used to populate connected routes into the RIB
 // manager. Note this is intended to be used for unit/standalone device testing.
+// - changed is called after each change to the RIB.
 //
 // The ServerOpt slice provided is handed to the gRIBI fake server to control its
 // behaviour.
-func createGRIBIServer(gClient gpb.GNMIClient, target string, root *oc.Root, sysribAddr string, opts ...server.ServerOpt) (*server.Server, error) {
+func createGRIBIServer(gClient gpb.GNMIClient, target string, root *oc.Root, sysribAddr string, changed func(), opts ...server.ServerOpt) (*server.Server, error) {
 	gzebraConn, err := grpc.Dial(sysribAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		return nil, fmt.Errorf("cannot dial to sysrib, %v", err)
@@ -105,6 +121,7 @@ func createGRIBIServer(gClient gpb.GNMIClient, target string, root *oc.Root, sys
 		if err := updateAft(yclient, o, ni, data, o); err != nil {
 			log.Errorf("invalid notifications, %v", err)
 		}
+		changed()
 
 		// TODO(wenbli): Check if this is needed with @robshakir.
 		// server.WithFIBProgrammedCheck()
@@ -115,6 +132,10 @@ func createGRIBIServer(gClient gpb.GNMIClient, target string, root *oc.Root, sys
 	}
 
 	ribAddfn := func(ribs map[string]*aft.RIB, optype constants.OpType, netinst string, aft constants.AFT, key any, _ ...rib.ResolvedDetails) {
+		if aft == constants.MPLS {
+			setLabelRoute(gzebraClient, ribs, optype, netinst, key)
+			return
+		}
 		prefix, ok := key.(string)
 		if !ok {
 			log.Errorf("Key is not a string type: (%T, %v)", key, key)
@@ -216,6 +237,33 @@ func createSetRouteRequest(netinst, prefix string, nexthops []*afthelper.NextHop
 		return nil, fmt.Errorf("gribigo/sysrib: %v", err)
 	}
 
+	zNexthops, err := createNexthops(nexthops, ribs)
+	if err != nil {
+		return nil, err
+	}
+
+	family := sysribpb.Prefix_FAMILY_IPV4
+	if pfx.Addr().Is6() {
+		family = sysribpb.Prefix_FAMILY_IPV6
+	}
+
+	return &sysribpb.SetRouteRequest{
+		AdminDistance: 5,
+		ProtocolName:  "gRIBI",
+		Safi:          sysribpb.SetRouteRequest_SAFI_UNICAST,
+		Prefix: &sysribpb.Prefix{
+			Family:     family,
+			Address:    pfx.Addr().String(),
+			MaskLength: uint32(pfx.Bits()),
+		},
+		Nexthops:        zNexthops,
+		NetworkInstance: netinst,
+	}, nil
+}
+
+// createNexthops converts the next hops of a gRIBI entry to sysrib next hops,
+// including their encapsulation headers.
+func createNexthops(nexthops []*afthelper.NextHopSummary, ribs map[string]*aft.RIB) ([]*sysribpb.Nexthop, error) {
 	var zNexthops []*sysribpb.Nexthop
 	for _, nhs := range nexthops {
 		nh := &sysribpb.Nexthop{
@@ -224,10 +272,22 @@ func createSetRouteRequest(netinst, prefix string, nexthops []*afthelper.NextHop
 			Weight:  nhs.Weight,
 			Encap:   &routingpb.Headers{},
 		}
-		encaps := slices.Collect(maps.Keys(ribs[nhs.NetworkInstance].GetAfts().GetNextHop(nhs.Index).EncapHeader))
+		aftNH := ribs[nhs.NetworkInstance].GetAfts().GetNextHop(nhs.Index)
+		if len(aftNH.PushedMplsLabelStack) > 0 {
+			rh := &routingpb.Header{
+				Type: routingpb.HeaderType_HEADER_TYPE_MPLS,
+			}
+			for _, l := range aftNH.PushedMplsLabelStack {
+				if label, ok := mplsLabel(l); ok {
+					rh.Labels = append(rh.Labels, label)
+				}
+			}
+			nh.Encap.Headers = append(nh.Encap.Headers, rh)
+		}
+		encaps := slices.Collect(maps.Keys(aftNH.EncapHeader))
 		slices.Sort(encaps)
 		for _, i := range encaps {
-			eh := ribs[nhs.NetworkInstance].GetAfts().GetNextHop(nhs.Index).GetEncapHeader(i)
+			eh := aftNH.GetEncapHeader(i)
 			switch eh.Type {
 			case aft.AftTypes_EncapsulationHeaderType_UDPV4:
 				appendUDPHeader(nh, routingpb.HeaderType_HEADER_TYPE_UDP4, eh.GetUdpV4())
@@ -238,22 +298,8 @@ func createSetRouteRequest(netinst, prefix string, nexthops []*afthelper.NextHop
 					Type: routingpb.HeaderType_HEADER_TYPE_MPLS,
 				}
 				for _, l := range eh.GetMpls().GetMplsLabelStack() {
-					switch val := l.(type) {
-					case aft.UnionUint32:
-						rh.Labels = append(rh.Labels, uint32(val))
-					case aft.E_MplsTypes_MplsLabel_Enum: // https://www.iana.org/assignments/mpls-label-values/mpls-label-values.xhtml
-						switch val {
-						case aft.MplsTypes_MplsLabel_Enum_IPV4_EXPLICIT_NULL:
-							rh.Labels = append(rh.Labels, 0)
-						case aft.MplsTypes_MplsLabel_Enum_ROUTER_ALERT:
-							rh.Labels = append(rh.Labels, 1)
-						case aft.MplsTypes_MplsLabel_Enum_IPV6_EXPLICIT_NULL:
-							rh.Labels = append(rh.Labels, 2)
-						case aft.MplsTypes_MplsLabel_Enum_IMPLICIT_NULL:
-							rh.Labels = append(rh.Labels, 3)
-						case aft.MplsTypes_MplsLabel_Enum_ENTROPY_LABEL_INDICATOR:
-							rh.Labels = append(rh.Labels, 7)
-						}
+					if label, ok := mplsLabel(l); ok {
+						rh.Labels = append(rh.Labels, label)
 					}
 				}
 				nh.Encap.Headers = append(nh.Encap.Headers, rh)
@@ -263,21 +309,128 @@ func createSetRouteRequest(netinst, prefix string, nexthops []*afthelper.NextHop
 		}
 		zNexthops = append(zNexthops, nh)
 	}
+	return zNexthops, nil
+}
 
-	family := sysribpb.Prefix_FAMILY_IPV4
-	if pfx.Addr().Is6() {
-		family = sysribpb.Prefix_FAMILY_IPV6
+// mplsLabel returns the value of an MPLS label in the AFT, which is either a
+// number or one of the reserved labels.
+// See https://www.iana.org/assignments/mpls-label-values/mpls-label-values.xhtml
+func mplsLabel(l any) (uint32, bool) {
+	switch val := l.(type) {
+	case aft.UnionUint32:
+		return uint32(val), true
+	case aft.E_MplsTypes_MplsLabel_Enum:
+		switch val {
+		case aft.MplsTypes_MplsLabel_Enum_IPV4_EXPLICIT_NULL:
+			return 0, true
+		case aft.MplsTypes_MplsLabel_Enum_ROUTER_ALERT:
+			return 1, true
+		case aft.MplsTypes_MplsLabel_Enum_IPV6_EXPLICIT_NULL:
+			return 2, true
+		case aft.MplsTypes_MplsLabel_Enum_IMPLICIT_NULL:
+			return 3, true
+		case aft.MplsTypes_MplsLabel_Enum_ENTROPY_LABEL_INDICATOR:
+			return 7, true
+		}
+	}
+	return 0, false
+}
+
+// setLabelRoute sends the label entry with the given key to sysrib.
+// The key is the label as a uint64 for additions, and the label union of the
+// deleted entry for deletions.
+func setLabelRoute(client sysribpb.SysribClient, ribs map[string]*aft.RIB, optype constants.OpType, netinst string, key any) {
+	var label uint32
+	switch l := key.(type) {
+	case uint64:
+		label = uint32(l)
+	default:
+		var ok bool
+		if label, ok = mplsLabel(l); !ok {
+			log.Errorf("Key is not a valid MPLS label: (%T, %v)", key, key)
+			return
+		}
+	}
+
+	var routeReq *sysribpb.SetRouteRequest
+	switch optype {
+	case constants.Add, constants.Replace:
+		var err error
+		if routeReq, err = createSetLabelRouteRequest(netinst, label, ribs); err != nil {
+			log.Errorf("Cannot create SetRouteRequest: %v", err)
+			return
+		}
+	case constants.Delete:
+		routeReq = &sysribpb.SetRouteRequest{
+			Delete:       true,
+			ProtocolName: "gRIBI",
+			Prefix: &sysribpb.Prefix{
+				Family: sysribpb.Prefix_FAMILY_MPLS,
+				Label:  label,
+			},
+			NetworkInstance: netinst,
+		}
+	default:
+		return
+	}
+
+	resp, err := client.SetRoute(context.Background(), routeReq)
+	if err != nil {
+		log.Errorf("Error sending label route to sysrib: %v", err)
+		return
+	}
+	log.Infof("Sent label route %v with response %v", routeReq, resp)
+}
+
+// createSetLabelRouteRequest converts a label entry to a sysrib SetRouteRequest.
+//
+// The popped label stack of the entry, or the pop-top-label flag of its next
+// hops, determines the number of labels popped. Labels pushed by the next hops
+// are sent as MPLS encap headers.
+func createSetLabelRouteRequest(netinst string, label uint32, ribs map[string]*aft.RIB) (*sysribpb.SetRouteRequest, error) {
+	entry := ribs[netinst].GetAfts().GetLabelEntry(aft.UnionUint32(label))
+	if entry == nil {
+		return nil, fmt.Errorf("cannot find label %d in AFT of network instance %s", label, netinst)
+	}
+	nhNI := netinst
+	if entry.GetNextHopGroupNetworkInstance() != "" {
+		nhNI = entry.GetNextHopGroupNetworkInstance()
+	}
+	nhg := ribs[nhNI].GetAfts().GetNextHopGroup(entry.GetNextHopGroup())
+	if nhg == nil {
+		return nil, fmt.Errorf("got unknown NHG %d in NI %s", entry.GetNextHopGroup(), nhNI)
+	}
+
+	pops := uint32(len(entry.PoppedMplsLabelStack))
+	var nexthops []*afthelper.NextHopSummary
+	for _, id := range slices.Sorted(maps.Keys(nhg.NextHop)) {
+		nh := ribs[nhNI].GetAfts().GetNextHop(id)
+		if nh.GetIpAddress() == "" {
+			return nil, fmt.Errorf("invalid next-hop %d", id)
+		}
+		if nh.GetPopTopLabel() && pops == 0 {
+			pops = 1
+		}
+		nexthops = append(nexthops, &afthelper.NextHopSummary{
+			Address:         nh.GetIpAddress(),
+			Weight:          nhg.GetNextHop(id).GetWeight(),
+			NetworkInstance: nhNI,
+			Index:           id,
+		})
+	}
+	zNexthops, err := createNexthops(nexthops, ribs)
+	if err != nil {
+		return nil, err
 	}
 
 	return &sysribpb.SetRouteRequest{
 		AdminDistance: 5,
 		ProtocolName:  "gRIBI",
-		Safi:          sysribpb.SetRouteRequest_SAFI_UNICAST,
 		Prefix: &sysribpb.Prefix{
-			Family:     family,
-			Address:    pfx.Addr().String(),
-			MaskLength: uint32(pfx.Bits()),
+			Family: sysribpb.Prefix_FAMILY_MPLS,
+			Label:  label,
 		},
+		PopLabels:       pops,
 		Nexthops:        zNexthops,
 		NetworkInstance: netinst,
 	}, nil
@@ -0,0 +1,336 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gribi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/gribigo/server"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+
+	gribipb "github.com/openconfig/gribi/v1/proto/service"
+)
+
+// restoreTimeout is the maximum time to wait for the restored entries to be programmed.
+const restoreTimeout = time.Minute
+
+// persister checkpoints the entries of the gRIBI RIB to a file.
+//
+// The checkpoint is a ModifyRequest containing the election ID of the primary
+// client and an ADD operation for each entry in the RIB. The reference
+// implementation only accepts clients with the PRESERVE persistence mode, so
+// entries are kept in the RIB (and programmed in the sysrib) after their client
+// disconnects and are always included in the checkpoint.
+type persister struct {
+	file    string
+	srv     *server.Server
+	changed chan struct{}
+
+	mu         sync.Mutex
+	electionID *gribipb.Uint128
+}
+
+// EnablePersistence restores the RIB from the checkpoint in file, if it exists,
+// and then checkpoints the RIB to the file after every change until ctx is cancelled.
+//
+// The entries are restored using the election ID of the primary client at the
+// time of the checkpoint, so a reconnecting client with the same or a higher
+// election ID becomes primary and can modify the restored entries.
+func (s *Server) EnablePersistence(ctx context.Context, file string) error {
+	if p := s.persist.Load(); p != nil {
+		return fmt.Errorf("persistence is already enabled using file %q", p.file)
+	}
+	p := &persister{
+		file:    file,
+		srv:     s.Server,
+		changed: make(chan struct{}, 1),
+	}
+	if err := p.restore(ctx); err != nil {
+		return fmt.Errorf("cannot restore gRIBI RIB from %q: %v", file, err)
+	}
+	if !s.persist.CompareAndSwap(nil, p) {
+		return fmt.Errorf("persistence is already enabled using file %q", s.persist.Load().file)
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.changed:
+				if err := p.checkpoint(); err != nil {
+					log.Errorf("cannot checkpoint gRIBI RIB: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// ribChanged schedules a checkpoint of the RIB, if persistence is enabled.
+func (s *Server) ribChanged() {
+	if p := s.persist.Load(); p != nil {
+		p.notify()
+	}
+}
+
+// Modify implements the gRIBI Modify RPC, recording the election ID of the
+// primary client so that it can be checkpointed.
+func (s *Server) Modify(ms gribipb.GRIBI_ModifyServer) error {
+	return s.Server.Modify(&electionStream{GRIBI_ModifyServer: ms, srv: s})
+}
+
+// electionStream intercepts the responses of a Modify RPC to store the current election ID.
+type electionStream struct {
+	gribipb.GRIBI_ModifyServer
+	srv *Server
+}
+
+func (es *electionStream) Send(resp *gribipb.ModifyResponse) error {
+	if id := resp.GetElectionId(); id != nil {
+		if p := es.srv.persist.Load(); p != nil {
+			p.setElectionID(id)
+		}
+	}
+	return es.GRIBI_ModifyServer.Send(resp)
+}
+
+func (p *persister) notify() {
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+}
+
+func (p *persister) setElectionID(id *gribipb.Uint128) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if proto.Equal(p.electionID, id) {
+		return
+	}
+	p.electionID = proto.Clone(id).(*gribipb.Uint128)
+	p.notify()
+}
+
+// checkpoint writes the current contents of the RIB to the checkpoint file.
+func (p *persister) checkpoint() error {
+	gs := &getStream{}
+	if err := p.srv.Get(&gribipb.GetRequest{
+		NetworkInstance: &gribipb.GetRequest_All{All: &gribipb.Empty{}},
+		Aft:             gribipb.AFTType_ALL,
+	}, gs); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cp := &gribipb.ModifyRequest{
+		ElectionId: p.electionID,
+		Operation:  entriesToOperations(gs.entries),
+	}
+	b, err := prototext.MarshalOptions{Multiline: true}.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a crash does not leave a partial checkpoint.
+	tmp, err := os.CreateTemp(filepath.Dir(p.file), filepath.Base(p.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.file)
+}
+
+// restore replays the entries in the checkpoint file into the RIB using a Modify RPC.
+func (p *persister) restore(ctx context.Context) error {
+	b, err := os.ReadFile(p.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Infof("gRIBI checkpoint %q does not exist, starting with an empty RIB", p.file)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	cp := &gribipb.ModifyRequest{}
+	if err := prototext.Unmarshal(b, cp); err != nil {
+		return err
+	}
+	p.electionID = cp.GetElectionId()
+	if len(cp.GetOperation()) == 0 {
+		return nil
+	}
+	if cp.GetElectionId() == nil {
+		return fmt.Errorf("checkpoint contains %d entries but no election ID", len(cp.GetOperation()))
+	}
+	for _, op := range cp.GetOperation() {
+		op.ElectionId = cp.GetElectionId()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, restoreTimeout)
+	defer cancel()
+	rs := &restoreStream{
+		ctx: ctx,
+		reqs: []*gribipb.ModifyRequest{{
+			Params: &gribipb.SessionParameters{
+				Redundancy:  gribipb.SessionParameters_SINGLE_PRIMARY,
+				Persistence: gribipb.SessionParameters_PRESERVE,
+				AckType:     gribipb.SessionParameters_RIB_ACK,
+			},
+		}, {
+			ElectionId: cp.GetElectionId(),
+		}, {
+			Operation: cp.GetOperation(),
+		}},
+		resps: make(chan *gribipb.ModifyResponse),
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.srv.Modify(rs)
+	}()
+
+	results := map[uint64]gribipb.AFTResult_Status{}
+	for len(results) < len(cp.GetOperation()) {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("restored %d of %d entries: %v", len(results), len(cp.GetOperation()), ctx.Err())
+		case err := <-errCh:
+			return fmt.Errorf("modify RPC ended after %d of %d entries: %v", len(results), len(cp.GetOperation()), err)
+		case resp := <-rs.resps:
+			for _, res := range resp.GetResult() {
+				results[res.GetId()] = res.GetStatus()
+			}
+		}
+	}
+	// Ending the Modify RPC does not remove the entries, since the client uses the PRESERVE persistence mode.
+	cancel()
+	if err := <-errCh; err != nil {
+		return err
+	}
+	var failed int
+	for id, status := range results {
+		if status != gribipb.AFTResult_RIB_PROGRAMMED {
+			log.Warningf("failed to restore gRIBI operation %d, status %v", id, status)
+			failed++
+		}
+	}
+	log.Infof("restored %d of %d gRIBI entries from %q", len(results)-failed, len(results), p.file)
+	return nil
+}
+
+// entryRank orders entries so that they are restored after the entries they reference.
+func entryRank(op *gribipb.AFTOperation) int {
+	switch op.GetEntry().(type) {
+	case *gribipb.AFTOperation_NextHop:
+		return 0
+	case *gribipb.AFTOperation_NextHopGroup:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// entriesToOperations returns the ADD operations that program the given entries.
+func entriesToOperations(entries []*gribipb.AFTEntry) []*gribipb.AFTOperation {
+	var ops []*gribipb.AFTOperation
+	for _, e := range entries {
+		op := &gribipb.AFTOperation{
+			NetworkInstance: e.GetNetworkInstance(),
+			Op:              gribipb.AFTOperation_ADD,
+		}
+		switch v := e.GetEntry().(type) {
+		case *gribipb.AFTEntry_Ipv4:
+			op.Entry = &gribipb.AFTOperation_Ipv4{Ipv4: v.Ipv4}
+		case *gribipb.AFTEntry_Ipv6:
+			op.Entry = &gribipb.AFTOperation_Ipv6{Ipv6: v.Ipv6}
+		case *gribipb.AFTEntry_Mpls:
+			op.Entry = &gribipb.AFTOperation_Mpls{Mpls: v.Mpls}
+		case *gribipb.AFTEntry_NextHopGroup:
+			op.Entry = &gribipb.AFTOperation_NextHopGroup{NextHopGroup: v.NextHopGroup}
+		case *gribipb.AFTEntry_NextHop:
+			op.Entry = &gribipb.AFTOperation_NextHop{NextHop: v.NextHop}
+		default:
+			log.Warningf("cannot checkpoint unsupported gRIBI entry: %v", e)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	slices.SortStableFunc(ops, func(a, b *gribipb.AFTOperation) int {
+		return entryRank(a) - entryRank(b)
+	})
+	for i, op := range ops {
+		op.Id = uint64(i + 1)
+	}
+	return ops
+}
+
+// getStream collects the entries returned by a Get RPC.
+type getStream struct {
+	grpc.ServerStream
+	entries []*gribipb.AFTEntry
+}
+
+func (gs *getStream) Send(resp *gribipb.GetResponse) error {
+	gs.entries = append(gs.entries, resp.GetEntry()...)
+	return nil
+}
+
+// restoreStream is an in-process Modify RPC that sends the given requests
+// and then waits for its context to be cancelled before closing the stream.
+type restoreStream struct {
+	grpc.ServerStream
+	ctx   context.Context
+	reqs  []*gribipb.ModifyRequest
+	resps chan *gribipb.ModifyResponse
+}
+
+func (rs *restoreStream) Context() context.Context {
+	return rs.ctx
+}
+
+func (rs *restoreStream) Recv() (*gribipb.ModifyRequest, error) {
+	if len(rs.reqs) == 0 {
+		<-rs.ctx.Done()
+		return nil, io.EOF
+	}
+	req := rs.reqs[0]
+	rs.reqs = rs.reqs[1:]
+	return req, nil
+}
+
+func (rs *restoreStream) Send(resp *gribipb.ModifyResponse) error {
+	select {
+	case rs.resps <- resp:
+		return nil
+	case <-rs.ctx.Done():
+		return rs.ctx.Err()
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gribi
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gribigo/server"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	aftpb "github.com/openconfig/gribi/v1/proto/gribi_aft"
+	gribipb "github.com/openconfig/gribi/v1/proto/service"
+	wpb "github.com/openconfig/ygot/proto/ywrapper"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	gs, err := server.New()
+	if err != nil {
+		t.Fatalf("cannot create gRIBI server: %v", err)
+	}
+	return &Server{Server: gs}
+}
+
+func nhEntry() *gribipb.AFTEntry {
+	return &gribipb.AFTEntry{
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Entry: &gribipb.AFTEntry_NextHop{NextHop: &aftpb.Afts_NextHopKey{
+			Index: 1,
+			NextHop: &aftpb.Afts_NextHop{
+				IpAddress: &wpb.StringValue{Value: "192.0.2.1"},
+			},
+		}},
+	}
+}
+
+func nhgEntry() *gribipb.AFTEntry {
+	return &gribipb.AFTEntry{
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Entry: &gribipb.AFTEntry_NextHopGroup{NextHopGroup: &aftpb.Afts_NextHopGroupKey{
+			Id: 1,
+			NextHopGroup: &aftpb.Afts_NextHopGroup{
+				NextHop: []*aftpb.Afts_NextHopGroup_NextHopKey{{
+					Index:   1,
+					NextHop: &aftpb.Afts_NextHopGroup_NextHop{Weight: &wpb.UintValue{Value: 1}},
+				}},
+			},
+		}},
+	}
+}
+
+func prefixEntry() *gribipb.AFTEntry {
+	return &gribipb.AFTEntry{
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Entry: &gribipb.AFTEntry_Ipv4{Ipv4: &aftpb.Afts_Ipv4EntryKey{
+			Prefix: "198.51.100.0/24",
+			Ipv4Entry: &aftpb.Afts_Ipv4Entry{
+				NextHopGroup: &wpb.UintValue{Value: 1},
+			},
+		}},
+	}
+}
+
+// writeCheckpoint writes a checkpoint with the given election ID and entries, in the given order.
+func writeCheckpoint(t *testing.T, file string, electionID *gribipb.Uint128, entries ...*gribipb.AFTEntry) {
+	t.Helper()
+	var ops []*gribipb.AFTOperation
+	for i, e := range entries {
+		op := entriesToOperations([]*gribipb.AFTEntry{e})[0]
+		op.Id = uint64(i + 1)
+		ops = append(ops, op)
+	}
+	b, err := prototext.Marshal(&gribipb.ModifyRequest{ElectionId: electionID, Operation: ops})
+	if err != nil {
+		t.Fatalf("cannot marshal checkpoint: %v", err)
+	}
+	if err := os.WriteFile(file, b, 0o600); err != nil {
+		t.Fatalf("cannot write checkpoint: %v", err)
+	}
+}
+
+func readCheckpoint(t *testing.T, file string) *gribipb.ModifyRequest {
+	t.Helper()
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+	cp := &gribipb.ModifyRequest{}
+	if err := prototext.Unmarshal(b, cp); err != nil {
+		t.Fatalf("cannot unmarshal checkpoint: %v", err)
+	}
+	return cp
+}
+
+func TestEntriesToOperations(t *testing.T) {
+	got := entriesToOperations([]*gribipb.AFTEntry{prefixEntry(), nhgEntry(), nhEntry()})
+	want := []*gribipb.AFTOperation{{
+		Id:              1,
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Op:              gribipb.AFTOperation_ADD,
+		Entry:           &gribipb.AFTOperation_NextHop{NextHop: nhEntry().GetNextHop()},
+	}, {
+		Id:              2,
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Op:              gribipb.AFTOperation_ADD,
+		Entry:           &gribipb.AFTOperation_NextHopGroup{NextHopGroup: nhgEntry().GetNextHopGroup()},
+	}, {
+		Id:              3,
+		NetworkInstance: server.DefaultNetworkInstanceName,
+		Op:              gribipb.AFTOperation_ADD,
+		Entry:           &gribipb.AFTOperation_Ipv4{Ipv4: prefixEntry().GetIpv4()},
+	}}
+	if d := cmp.Diff(want, got, protocmp.Transform()); d != "" {
+		t.Errorf("entriesToOperations() unexpected diff (-want, +got):\n%s", d)
+	}
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dir := t.TempDir()
+	electionID := &gribipb.Uint128{Low: 42}
+
+	// Program the RIB of the first server from a checkpoint whose entries are not ordered.
+	seed := filepath.Join(dir, "seed.txtpb")
+	writeCheckpoint(t, seed, electionID, prefixEntry(), nhgEntry(), nhEntry())
+	s1 := newTestServer(t)
+	if err := s1.EnablePersistence(ctx, seed); err != nil {
+		t.Fatalf("EnablePersistence(%q) failed: %v", seed, err)
+	}
+	p1 := &persister{file: filepath.Join(dir, "checkpoint.txtpb"), srv: s1.Server, electionID: s1.persist.Load().electionID}
+	if err := p1.checkpoint(); err != nil {
+		t.Fatalf("checkpoint() failed: %v", err)
+	}
+	cp := readCheckpoint(t, p1.file)
+	if d := cmp.Diff(electionID, cp.GetElectionId(), protocmp.Transform()); d != "" {
+		t.Errorf("checkpoint election ID unexpected diff (-want, +got):\n%s", d)
+	}
+	if d := cmp.Diff(entriesToOperations([]*gribipb.AFTEntry{prefixEntry(), nhgEntry(), nhEntry()}), cp.GetOperation(), protocmp.Transform()); d != "" {
+		t.Errorf("checkpoint operations unexpected diff (-want, +got):\n%s", d)
+	}
+
+	// Restore the checkpoint into a second server, which must contain the same RIB.
+	s2 := newTestServer(t)
+	if err := s2.EnablePersistence(ctx, p1.file); err != nil {
+		t.Fatalf("EnablePersistence(%q) failed: %v", p1.file, err)
+	}
+	if d := cmp.Diff(electionID, s2.persist.Load().electionID, protocmp.Transform()); d != "" {
+		t.Errorf("restored election ID unexpected diff (-want, +got):\n%s", d)
+	}
+	p2 := &persister{file: filepath.Join(dir, "restored.txtpb"), srv: s2.Server, electionID: s2.persist.Load().electionID}
+	if err := p2.checkpoint(); err != nil {
+		t.Fatalf("checkpoint() failed: %v", err)
+	}
+	if d := cmp.Diff(cp, readCheckpoint(t, p2.file), protocmp.Transform()); d != "" {
+		t.Errorf("restored checkpoint unexpected diff (-want, +got):\n%s", d)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		desc           string
+		contents       string
+		wantElectionID *gribipb.Uint128
+		wantErr        string
+	}{{
+		desc: "missing file",
+	}, {
+		desc:     "empty file",
+		contents: " ",
+	}, {
+		desc:           "election ID only",
+		contents:       "election_id: { high: 1 low: 2 }",
+		wantElectionID: &gribipb.Uint128{High: 1, Low: 2},
+	}, {
+		desc:     "corrupt file",
+		contents: "election_id: {",
+		wantErr:  "cannot restore",
+	}, {
+		desc:     "entries without election ID",
+		contents: "operation: { id: 1 network_instance: \"DEFAULT\" op: ADD next_hop: { index: 1 } }",
+		wantErr:  "no election ID",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "checkpoint.txtpb")
+			if tt.contents != "" {
+				if err := os.WriteFile(file, []byte(tt.contents), 0o600); err != nil {
+					t.Fatalf("cannot write checkpoint: %v", err)
+				}
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := newTestServer(t)
+			err := s.EnablePersistence(ctx, file)
+			if (err != nil) != (tt.wantErr != "") || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("EnablePersistence(%q) got error %v, want error containing %q", file, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if d := cmp.Diff(tt.wantElectionID, s.persist.Load().electionID, protocmp.Transform()); d != "" {
+				t.Errorf("restored election ID unexpected diff (-want, +got):\n%s", d)
+			}
+			if err := s.EnablePersistence(ctx, file); err == nil {
+				t.Errorf("EnablePersistence(%q) called twice got nil error, want error", file)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package lemming
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"runtime"
@@ -30,6 +31,7 @@ import (
 
 	gribis "github.com/openconfig/gribigo/server"
 
+	"github.com/openconfig/lemming/bfd"
 	"github.com/openconfig/lemming/bgp"
 	"github.com/openconfig/lemming/dataplane"
 	"github.com/openconfig/lemming/dataplane/dplaneopts"
@@ -40,6 +42,9 @@ import (
 	"github.com/openconfig/lemming/gnmi/reconciler"
 	fgnoi "github.com/openconfig/lemming/gnoi"
 	fgnsi "github.com/openconfig/lemming/gnsi"
+	"github.com/openconfig/lemming/gnsi/authz"
+	"github.com/openconfig/lemming/gnsi/certz"
+	"github.com/openconfig/lemming/gnsi/credentialz"
 	fgribi "github.com/openconfig/lemming/gribi"
 	"github.com/openconfig/lemming/internal/config"
 	fp4rt "github.com/openconfig/lemming/p4rt"
@@ -62,7 +67,10 @@ type Device struct {
 	gribiService        *gRPCService
 	p4rtService         *gRPCService
 	faultService        *gRPCService
+	sshLis              net.Listener
 	stop                func()
+	// cancel stops the background tasks of the device, such as checkpointing the gRIBI RIB.
+	cancel context.CancelFunc
 
 	gnmiServer   *fgnmi.Server
 	gnoiServer   *fgnoi.Server
@@ -100,6 +108,16 @@ type opt struct {
 	dataplaneOpts  []dplaneopts.Option
 	gribiOpts      []gribis.ServerOpt
 	configFile     string
+
+	// tlsCert is the initial certificate of the gNSI certz default profile,
+	// which is used as the TLS identity of the gRPC servers.
+	tlsCert *tls.Certificate
+	// sshAddr is the address of the SSH server enforcing the gNSI credentialz
+	// credentials, it is disabled if empty.
+	sshAddr string
+	// gribiPersistFile is the file the gRIBI RIB is checkpointed to and
+	// restored from, persistence is disabled if empty.
+	gribiPersistFile string
 }
 
 // resolveOpts applies all the options and returns a struct containing the result.
@@ -154,20 +172,24 @@ func WithBGPPort(port uint16) Option {
 
 // WithTLSCredsFromFile loads the credentials from the specified cert and key file
 // and returns them such that they can be used for the gNMI and gRIBI servers.
+// The certificate can be rotated using gNSI certz, or gNOI cert with the ID of the certz default profile.
 func WithTLSCredsFromFile(certFile, keyFile string) (Option, error) {
-	t, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
 		return nil, err
 	}
 	return func(o *opt) {
-		o.tlsCredentials = t
+		o.tlsCert = &cert
+		o.tlsCredentials = nil
 	}, nil
 }
 
 // WithTransportCreds returns a wrapper of TransportCredentials into a DevOpt.
+// Certificates rotated using gNSI certz are not applied to these credentials.
 func WithTransportCreds(c credentials.TransportCredentials) Option {
 	return func(o *opt) {
 		o.tlsCredentials = c
+		o.tlsCert = nil
 	}
 }
 
@@ -199,6 +221,14 @@ func WithGRIBIOpts(opts ...gribis.ServerOpt) Option {
 	}
 }
 
+// WithGRIBIPersistence checkpoints the gRIBI RIB to the specified file,
+// and restores the RIB from the file when the device starts.
+func WithGRIBIPersistence(file string) Option {
+	return func(o *opt) {
+		o.gribiPersistFile = file
+	}
+}
+
 // WithFaultInjection enables the fault injection service.
 func WithFaultInjection(enable bool) Option {
 	return func(o *opt) {
@@ -213,6 +243,14 @@ func WithFaultAddr(addr string) Option {
 	}
 }
 
+// WithSSHAddr sets the address of the SSH server, which authenticates clients
+// using the credentials rotated with gNSI credentialz.
+func WithSSHAddr(addr string) Option {
+	return func(o *opt) {
+		o.sshAddr = addr
+	}
+}
+
 // WithConfigFile specifies a configuration file path for lemming device settings.
 // The file has to be in protobuf text (.textproto/.pb.txt) format.
 func WithConfigFile(configFile string) Option {
@@ -241,7 +279,9 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 
 		log.Info("enabling dataplane")
 		var err error
-		dplane, err = dataplane.New(context.Background(), resolvedOpts.dataplaneOpts...)
+		// The routing protocols of the dataplane install their routes in the sysrib.
+		dplaneOpts := append([]dplaneopts.Option{dplaneopts.WithSysribAddr(fmt.Sprintf("unix:%s", resolvedOpts.sysribAddr))}, resolvedOpts.dataplaneOpts...)
+		dplane, err = dataplane.New(context.Background(), dplaneOpts...)
 		if err != nil {
 			return nil, err
 		}
@@ -262,10 +302,17 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 	}
 
 	var grpcOpts []grpc.ServerOption
-	streamInt := []grpc.StreamServerInterceptor{fgnmi.NewSubscribeTargetUpdateInterceptor(targetName)}
-	unaryInt := []grpc.UnaryServerInterceptor{}
+	// The authz interceptors run first so that denied RPCs don't reach the other interceptors.
+	authzServer := authz.New()
+	streamInt := []grpc.StreamServerInterceptor{authzServer.StreamInterceptor, fgnmi.NewSubscribeTargetUpdateInterceptor(targetName)}
+	unaryInt := []grpc.UnaryServerInterceptor{authzServer.UnaryInterceptor}
 
+	// The certz server is the source of the TLS identity, so rotations apply to new connections.
+	certzServer := certz.New(resolvedOpts.tlsCert)
 	creds := resolvedOpts.tlsCredentials
+	if resolvedOpts.tlsCert != nil {
+		creds = certzServer.ServerCredentials()
+	}
 	if creds != nil {
 		grpcOpts = append(grpcOpts, grpc.Creds(creds))
 	}
@@ -297,18 +344,27 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 
 	s := grpc.NewServer(grpcOpts...)
 
+	credzServer := credentialz.New()
+	bgpServer := bgp.NewGoBGP(targetName, zapiURL, resolvedOpts.bgpPort)
 	recs = append(recs,
+		credzServer.Reconciler(),
 		fakedevice.NewSystemBaseTask(),
 		fakedevice.NewBootTimeTask(lemmingConfig),
 		fakedevice.NewCurrentTimeTask(),
 		fakedevice.NewChassisComponentsTask(lemmingConfig),
 		fakedevice.NewProcessMonitoringTask(lemmingConfig),
 		fakedevice.NewInterfaceInitializationTask(lemmingConfig),
-		bgp.NewGoBGPTask(targetName, zapiURL, resolvedOpts.bgpPort),
+		bgpServer.Reconciler(),
 	)
 
+	log.Info("starting BFD")
+	// The BFD ports are bound when the first session is added.
+	bfdServer := bfd.New()
+	bgpServer.EnableBFD(bfdServer)
+	recs = append(recs, bfdServer.Reconciler())
+
 	log.Info("starting gNSI")
-	gnsiServer := fgnsi.New(s)
+	gnsiServer := fgnsi.New(s, fgnsi.WithAuthz(authzServer), fgnsi.WithCertz(certzServer), fgnsi.WithCredentialz(credzServer))
 
 	gnmiServer, err := fgnmi.New(s, targetName, gnsiServer.GetPathZ(), recs...)
 	if err != nil {
@@ -322,6 +378,7 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 	if err != nil {
 		return nil, err
 	}
+	sysribServer.EnableBFD(bfdServer)
 	if err := sysribServer.Start(context.Background(), cacheClient, targetName, zapiURL, resolvedOpts.sysribAddr); err != nil {
 		return nil, fmt.Errorf("sysribServer failed to start: %v", err)
 	}
@@ -333,9 +390,26 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 	if err != nil {
 		return nil, err
 	}
+	// ctx is cancelled when the device stops, or if it fails to start.
+	ctx, cancel := context.WithCancel(context.Background())
+	started := false
+	defer func() {
+		if !started {
+			cancel()
+		}
+	}()
+	if resolvedOpts.gribiPersistFile != "" {
+		if err := gribiServer.EnablePersistence(ctx, resolvedOpts.gribiPersistFile); err != nil {
+			return nil, err
+		}
+	}
 
-	log.Info("starting P4RT (there is nothing here yet)")
+	log.Info("starting P4RT")
 	P4RTs := grpc.NewServer()
+	var p4rtOpts []fp4rt.Option
+	if dplane != nil {
+		p4rtOpts = append(p4rtOpts, fp4rt.WithDataplane(dplane))
+	}
 
 	log.Info("Create listeners")
 	lgnmi, err := net.Listen("tcp", resolvedOpts.gnmiAddr)
@@ -353,10 +427,36 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 		return nil, fmt.Errorf("cannot create gRPC server for P4RT, %v", err)
 	}
 
-	gnoiServer, err := fgnoi.New(s, cacheClient, targetName, lemmingConfig)
+	var lssh net.Listener
+	if resolvedOpts.sshAddr != "" {
+		if lssh, err = net.Listen("tcp", resolvedOpts.sshAddr); err != nil {
+			return nil, fmt.Errorf("cannot create SSH server, %v", err)
+		}
+	}
+
+	gnoiOpts := []fgnoi.Option{
+		fgnoi.WithRIB(sysribServer),
+		fgnoi.WithLSPs(sysribServer),
+		fgnoi.WithBGP(bgpServer),
+		// Certificates installed with the gNOI cert service are stored in the certz profiles.
+		fgnoi.WithCertificateManagement(certz.NewCertificateManagement(certzServer)),
+	}
+	if dplane != nil {
+		gnoiOpts = append(gnoiOpts, fgnoi.WithProber(dplane), fgnoi.WithLayer2(dplane), fgnoi.WithLabelCounters(dplane))
+	}
+	gnoiServer, err := fgnoi.New(s, cacheClient, targetName, lemmingConfig, gnoiOpts...)
 	if err != nil {
 		return nil, err
 	}
+	// Injected faults are reported as gNOI healthz events.
+	faultInt.OnFault(gnoiServer.ReportFault)
+	// A factory reset flushes gRIBI first, so its routes are removed from
+	// the sysrib, then deleting the config stops BGP and removes static routes.
+	gnoiServer.OnFactoryReset(gribiServer.Reset, gnmiServer.ResetConfig, sysribServer.Reset)
+	if dplane != nil {
+		// The sysrib reset removes the IS-IS routes, which are installed again once computed.
+		gnoiServer.OnFactoryReset(dplane.ResetISISRoutes)
+	}
 
 	d := &Device{
 		gnmignoignsiService: &gRPCService{
@@ -376,16 +476,28 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 			stopped: make(chan struct{}),
 		},
 		faultService: faultService,
+		sshLis:       lssh,
+		cancel:       cancel,
 		gnmiServer:   gnmiServer,
 		gnoiServer:   gnoiServer,
 		gribiServer:  gribiServer,
 		gnsiServer:   gnsiServer,
-		p4rtServer:   fp4rt.New(P4RTs),
+		p4rtServer:   fp4rt.New(P4RTs, p4rtOpts...),
 		dplaneServer: dplane,
 		config:       lemmingConfig,
 	}
 	reflection.Register(s)
 	d.startServer()
+	if lssh != nil {
+		log.Info("starting SSH")
+		go func() {
+			if err := credzServer.ServeSSH(lssh); err != nil {
+				d.errsMu.Lock()
+				d.errs = append(d.errs, err)
+				d.errsMu.Unlock()
+			}
+		}()
+	}
 
 	if err := gnmiServer.StartReconcilers(context.Background()); err != nil {
 		return nil, err
@@ -399,6 +511,7 @@ func New(targetName, zapiURL string, opts ...Option) (*Device, error) {
 	c.Add(context.Background(), 1)
 
 	log.Info("lemming created")
+	started = true
 	return d, nil
 }
 
@@ -441,7 +554,11 @@ func (d *Device) Stop() error {
 		klog.Infof("Server already stopped: %v", d.errs)
 	default:
 		d.stop()
+		if d.sshLis != nil {
+			d.sshLis.Close()
+		}
 	}
+	d.cancel()
 	d.errsMu.Lock()
 	defer d.errsMu.Unlock()
 	if err := d.gnmiServer.StopReconcilers(context.Background()); err != nil {
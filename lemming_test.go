@@ -54,32 +54,37 @@ func TestFakeGNMI(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to Dial fake: %v", err)
 	}
-	want := &gnmipb.GetResponse{
-		Notification: []*gnmipb.Notification{{
-			Update: []*gnmipb.Update{{
-				Path: &gnmipb.Path{
-					Elem: []*gnmipb.PathElem{
-						{Name: "intefaces"},
-						{Name: "inteface", Key: map[string]string{"name": "eth0"}},
-						{Name: "mtu"},
-					},
-				},
-				Val: &gnmipb.TypedValue{
-					Value: &gnmipb.TypedValue_IntVal{
-						IntVal: 1500,
-					},
-				},
-			}},
-		}},
-	}
-	f.gnmiServer.GetResponses = []interface{}{want}
 	cGNMI := gnmipb.NewGNMIClient(conn)
-	resp, err := cGNMI.Get(context.Background(), &gnmipb.GetRequest{})
+	hostname := &gnmipb.Path{
+		Origin: "openconfig",
+		Elem:   []*gnmipb.PathElem{{Name: "system"}, {Name: "config"}, {Name: "hostname"}},
+	}
+	val := &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{StringVal: "lemming"}}
+	if _, err := cGNMI.Set(context.Background(), &gnmipb.SetRequest{
+		Replace: []*gnmipb.Update{{Path: hostname, Val: val}},
+	}); err != nil {
+		t.Fatalf("gnmi.Set failed: %v", err)
+	}
+	resp, err := cGNMI.Get(context.Background(), &gnmipb.GetRequest{
+		Path:     []*gnmipb.Path{hostname},
+		Type:     gnmipb.GetRequest_CONFIG,
+		Encoding: gnmipb.Encoding_PROTO,
+	})
 	if err != nil {
 		t.Fatalf("gnmi.Get failed: %v", err)
 	}
-	if !proto.Equal(resp, want) {
-		t.Fatalf("gnmi.Get failed got %v, want %v", resp, want)
+	if len(resp.GetNotification()) != 1 || len(resp.GetNotification()[0].GetUpdate()) != 1 {
+		t.Fatalf("gnmi.Get failed got %v, want a single update", resp)
+	}
+	if got := resp.GetNotification()[0].GetUpdate()[0].GetVal(); !proto.Equal(got, val) {
+		t.Fatalf("gnmi.Get failed got %v, want %v", got, val)
+	}
+	caps, err := cGNMI.Capabilities(context.Background(), &gnmipb.CapabilityRequest{})
+	if err != nil {
+		t.Fatalf("gnmi.Capabilities failed: %v", err)
+	}
+	if len(caps.GetSupportedModels()) == 0 {
+		t.Fatalf("gnmi.Capabilities failed got no supported models")
 	}
 }
 
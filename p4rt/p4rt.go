@@ -12,26 +12,717 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package p4rt is a P4Runtime server that programs the lucius forwarding engine.
 package p4rt
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	log "github.com/golang/glog"
 
+	"github.com/openconfig/lemming/dataplane/protocol"
+
+	p4infopb "github.com/p4lang/p4runtime/go/p4/config/v1"
 	p4rtpb "github.com/p4lang/p4runtime/go/p4/v1"
 )
 
-// Server is a fake p4rt implementation.
+const apiVersion = "1.3.0"
+
+// Dataplane is the forwarding plane programmed by the P4RT server.
+type Dataplane interface {
+	// Conn returns a gRPC client connection to the dataplane.
+	Conn() (grpc.ClientConnInterface, error)
+	// SwitchID returns the SAI switch OID, it is zero until the dataplane is started.
+	SwitchID() uint64
+	// Registry returns the protocol registry of the CPU port, it is nil until the dataplane is started.
+	Registry() *protocol.Registry
+}
+
+// Option configures the P4RT server.
+type Option func(*Server)
+
+// WithDataplane programs the table entries and sends packets using the dataplane.
+// If no dataplane is set, the server only stores the table entries.
+func WithDataplane(d Dataplane) Option {
+	return func(s *Server) {
+		s.dplane = d
+	}
+}
+
+// Server is a P4RT server implementation.
 type Server struct {
 	p4rtpb.UnimplementedP4RuntimeServer
-	s *grpc.Server
+	s      *grpc.Server
+	dplane Dataplane
+
+	mu       sync.Mutex
+	roles    map[roleKey]*role
+	pipeline *p4rtpb.ForwardingPipelineConfig // The committed forwarding pipeline.
+	saved    *p4rtpb.ForwardingPipelineConfig // The saved forwarding pipeline, waiting for COMMIT.
+	info     *p4Info
+	entries  map[string]*p4rtpb.TableEntry // Installed table entries, keyed by entryKey.
+	prog     *programmer
 }
 
-// New returns a new fake p4rt server.
-func New(s *grpc.Server) *Server {
+// New returns a new P4RT server.
+func New(s *grpc.Server, opts ...Option) *Server {
 	srv := &Server{
-		s: s,
+		s:       s,
+		roles:   map[roleKey]*role{},
+		entries: map[string]*p4rtpb.TableEntry{},
+	}
+	for _, opt := range opts {
+		opt(srv)
 	}
 	p4rtpb.RegisterP4RuntimeServer(s, srv)
 
 	return srv
 }
+
+type roleKey struct {
+	deviceID uint64
+	name     string
+}
+
+// role is the arbitration state of a single role on a device.
+type role struct {
+	streams    map[*stream]bool
+	primary    *stream
+	electionID *p4rtpb.Uint128 // The highest election ID seen for this role.
+}
+
+// stream is a client connected using the StreamChannel RPC.
+type stream struct {
+	srv        p4rtpb.P4Runtime_StreamChannelServer
+	sendMu     sync.Mutex
+	key        roleKey
+	electionID *p4rtpb.Uint128
+}
+
+func (st *stream) send(resp *p4rtpb.StreamMessageResponse) error {
+	st.sendMu.Lock()
+	defer st.sendMu.Unlock()
+	return st.srv.Send(resp)
+}
+
+// compareElectionID returns -1, 0, 1 if a is less than, equal to, or greater than b.
+func compareElectionID(a, b *p4rtpb.Uint128) int {
+	switch {
+	case a.GetHigh() < b.GetHigh():
+		return -1
+	case a.GetHigh() > b.GetHigh():
+		return 1
+	case a.GetLow() < b.GetLow():
+		return -1
+	case a.GetLow() > b.GetLow():
+		return 1
+	}
+	return 0
+}
+
+// Capabilities returns the P4Runtime API version.
+func (s *Server) Capabilities(context.Context, *p4rtpb.CapabilitiesRequest) (*p4rtpb.CapabilitiesResponse, error) {
+	return &p4rtpb.CapabilitiesResponse{
+		P4RuntimeApiVersion: apiVersion,
+	}, nil
+}
+
+// StreamChannel handles client arbitration and packet I/O.
+func (s *Server) StreamChannel(srv p4rtpb.P4Runtime_StreamChannelServer) error {
+	st := &stream{srv: srv}
+	defer s.removeStream(st)
+
+	for {
+		req, err := srv.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch update := req.GetUpdate().(type) {
+		case *p4rtpb.StreamMessageRequest_Arbitration:
+			if err := s.arbitrate(st, update.Arbitration); err != nil {
+				return err
+			}
+		case *p4rtpb.StreamMessageRequest_Packet:
+			if err := s.packetOut(st, update.Packet); err != nil {
+				log.Warningf("failed to send packet out: %v", err)
+				sendErr := st.send(&p4rtpb.StreamMessageResponse{
+					Update: &p4rtpb.StreamMessageResponse_Error{
+						Error: &p4rtpb.StreamError{
+							CanonicalCode: int32(status.Code(err)),
+							Message:       err.Error(),
+							Details: &p4rtpb.StreamError_PacketOut{
+								PacketOut: &p4rtpb.PacketOutError{PacketOut: update.Packet},
+							},
+						},
+					},
+				})
+				if sendErr != nil {
+					return sendErr
+				}
+			}
+		default:
+			return status.Errorf(codes.Unimplemented, "unsupported stream message type: %T", update)
+		}
+	}
+}
+
+// arbitrate handles a MasterArbitrationUpdate from a client.
+func (s *Server) arbitrate(st *stream, req *p4rtpb.MasterArbitrationUpdate) error {
+	if req.GetElectionId() == nil {
+		return status.Errorf(codes.InvalidArgument, "election ID must be set")
+	}
+	if req.GetRole().GetConfig() != nil {
+		return status.Errorf(codes.Unimplemented, "role config is not supported")
+	}
+	key := roleKey{deviceID: req.GetDeviceId(), name: req.GetRole().GetName()}
+
+	s.mu.Lock()
+	if st.electionID != nil && st.key != key {
+		s.mu.Unlock()
+		return status.Errorf(codes.FailedPrecondition, "device ID and role cannot be changed for a stream")
+	}
+	r, ok := s.roles[key]
+	if !ok {
+		r = &role{streams: map[*stream]bool{}}
+		s.roles[key] = r
+	}
+	for other := range r.streams {
+		if other != st && compareElectionID(other.electionID, req.GetElectionId()) == 0 {
+			s.mu.Unlock()
+			return status.Errorf(codes.InvalidArgument, "election ID %v is already used by another client", req.GetElectionId())
+		}
+	}
+	st.key = key
+	st.electionID = proto.Clone(req.GetElectionId()).(*p4rtpb.Uint128)
+	r.streams[st] = true
+
+	prevPrimary := r.primary
+	if r.electionID == nil || compareElectionID(st.electionID, r.electionID) >= 0 {
+		r.electionID = st.electionID
+		r.primary = st
+	} else if r.primary == st {
+		// The primary lowered its election ID, so there is no primary until a client sends the highest election ID.
+		r.primary = nil
+	}
+	notify := []*stream{st}
+	if prevPrimary != r.primary {
+		notify = notify[:0]
+		for other := range r.streams {
+			notify = append(notify, other)
+		}
+	}
+	resps := s.arbitrationResponses(r, notify)
+	s.mu.Unlock()
+
+	return sendArbitrationResponses(st, notify, resps)
+}
+
+// arbitrationResponses returns the arbitration responses for the streams.
+// It must be called with the lock held.
+func (s *Server) arbitrationResponses(r *role, streams []*stream) []*p4rtpb.StreamMessageResponse {
+	var resps []*p4rtpb.StreamMessageResponse
+	for _, st := range streams {
+		code := codes.OK
+		msg := "primary"
+		switch {
+		case r.primary == nil:
+			code = codes.NotFound
+			msg = "no primary"
+		case r.primary != st:
+			code = codes.AlreadyExists
+			msg = "backup"
+		}
+		var rolepb *p4rtpb.Role
+		if st.key.name != "" {
+			rolepb = &p4rtpb.Role{Name: st.key.name}
+		}
+		resps = append(resps, &p4rtpb.StreamMessageResponse{
+			Update: &p4rtpb.StreamMessageResponse_Arbitration{
+				Arbitration: &p4rtpb.MasterArbitrationUpdate{
+					DeviceId:   st.key.deviceID,
+					Role:       rolepb,
+					ElectionId: r.electionID,
+					Status:     status.New(code, msg).Proto(),
+				},
+			},
+		})
+	}
+	return resps
+}
+
+// sendArbitrationResponses sends the responses, only errors from the caller's stream are returned.
+func sendArbitrationResponses(caller *stream, streams []*stream, resps []*p4rtpb.StreamMessageResponse) error {
+	for i, st := range streams {
+		if err := st.send(resps[i]); err != nil {
+			if st == caller {
+				return err
+			}
+			log.Warningf("failed to send arbitration update: %v", err)
+		}
+	}
+	return nil
+}
+
+// removeStream removes a disconnected client and notifies the other clients if the primary changed.
+func (s *Server) removeStream(st *stream) {
+	s.mu.Lock()
+	r, ok := s.roles[st.key]
+	if !ok || !r.streams[st] {
+		s.mu.Unlock()
+		return
+	}
+	delete(r.streams, st)
+	if r.primary != st {
+		s.mu.Unlock()
+		return
+	}
+	r.primary = nil
+	var notify []*stream
+	for other := range r.streams {
+		notify = append(notify, other)
+	}
+	resps := s.arbitrationResponses(r, notify)
+	s.mu.Unlock()
+
+	sendArbitrationResponses(nil, notify, resps)
+}
+
+// isPrimary returns whether the election ID is the primary for the role.
+// It must be called with the lock held.
+func (s *Server) isPrimary(deviceID uint64, roleName string, electionID *p4rtpb.Uint128) bool {
+	r, ok := s.roles[roleKey{deviceID: deviceID, name: roleName}]
+	if !ok || r.primary == nil || electionID == nil {
+		return false
+	}
+	return compareElectionID(r.primary.electionID, electionID) == 0
+}
+
+// SetForwardingPipelineConfig verifies, saves, and commits the forwarding pipeline.
+func (s *Server) SetForwardingPipelineConfig(ctx context.Context, req *p4rtpb.SetForwardingPipelineConfigRequest) (*p4rtpb.SetForwardingPipelineConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isPrimary(req.GetDeviceId(), req.GetRole(), req.GetElectionId()) {
+		return nil, status.Errorf(codes.PermissionDenied, "client is not the primary for device %d role %q", req.GetDeviceId(), req.GetRole())
+	}
+
+	switch req.GetAction() {
+	case p4rtpb.SetForwardingPipelineConfigRequest_VERIFY:
+		if _, err := newP4Info(req.GetConfig().GetP4Info()); err != nil {
+			return nil, err
+		}
+	case p4rtpb.SetForwardingPipelineConfigRequest_VERIFY_AND_SAVE:
+		if _, err := newP4Info(req.GetConfig().GetP4Info()); err != nil {
+			return nil, err
+		}
+		s.saved = req.GetConfig()
+	case p4rtpb.SetForwardingPipelineConfigRequest_VERIFY_AND_COMMIT:
+		if err := s.commit(ctx, req.GetConfig(), false); err != nil {
+			return nil, err
+		}
+	case p4rtpb.SetForwardingPipelineConfigRequest_COMMIT:
+		if s.saved == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "no saved forwarding pipeline config to commit")
+		}
+		if err := s.commit(ctx, s.saved, false); err != nil {
+			return nil, err
+		}
+		s.saved = nil
+	case p4rtpb.SetForwardingPipelineConfigRequest_RECONCILE_AND_COMMIT:
+		if err := s.commit(ctx, req.GetConfig(), true); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported action: %v", req.GetAction())
+	}
+	return &p4rtpb.SetForwardingPipelineConfigResponse{}, nil
+}
+
+// commit makes the config the active forwarding pipeline. If reconcile is true,
+// the existing table entries are kept if their table still exists.
+// It must be called with the lock held.
+func (s *Server) commit(ctx context.Context, config *p4rtpb.ForwardingPipelineConfig, reconcile bool) error {
+	info, err := newP4Info(config.GetP4Info())
+	if err != nil {
+		return err
+	}
+	prog, err := s.programmer(ctx)
+	if err != nil {
+		return err
+	}
+	for key, entry := range s.entries {
+		if reconcile && info.sameTable(s.info, entry.GetTableId()) {
+			continue
+		}
+		if prog != nil {
+			if err := prog.removeEntry(ctx, s.info, entry); err != nil {
+				return err
+			}
+		}
+		delete(s.entries, key)
+	}
+	if prog != nil {
+		if err := prog.createTables(ctx, info); err != nil {
+			return err
+		}
+	}
+	s.info = info
+	s.pipeline = config
+	return nil
+}
+
+// GetForwardingPipelineConfig returns the committed forwarding pipeline.
+func (s *Server) GetForwardingPipelineConfig(_ context.Context, req *p4rtpb.GetForwardingPipelineConfigRequest) (*p4rtpb.GetForwardingPipelineConfigResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &p4rtpb.GetForwardingPipelineConfigResponse{
+		Config: &p4rtpb.ForwardingPipelineConfig{},
+	}
+	if s.pipeline == nil {
+		return resp, nil
+	}
+	resp.Config.Cookie = s.pipeline.GetCookie()
+	switch req.GetResponseType() {
+	case p4rtpb.GetForwardingPipelineConfigRequest_ALL:
+		resp.Config.P4Info = s.pipeline.GetP4Info()
+		resp.Config.P4DeviceConfig = s.pipeline.GetP4DeviceConfig()
+	case p4rtpb.GetForwardingPipelineConfigRequest_COOKIE_ONLY:
+	case p4rtpb.GetForwardingPipelineConfigRequest_P4INFO_AND_COOKIE:
+		resp.Config.P4Info = s.pipeline.GetP4Info()
+	case p4rtpb.GetForwardingPipelineConfigRequest_DEVICE_CONFIG_AND_COOKIE:
+		resp.Config.P4DeviceConfig = s.pipeline.GetP4DeviceConfig()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported response type: %v", req.GetResponseType())
+	}
+	return resp, nil
+}
+
+// Write inserts, modifies, and deletes table entries.
+func (s *Server) Write(ctx context.Context, req *p4rtpb.WriteRequest) (*p4rtpb.WriteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.isPrimary(req.GetDeviceId(), req.GetRole(), req.GetElectionId()) {
+		return nil, status.Errorf(codes.PermissionDenied, "client is not the primary for device %d role %q", req.GetDeviceId(), req.GetRole())
+	}
+	if s.pipeline == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "forwarding pipeline config is not set")
+	}
+	if req.GetAtomicity() != p4rtpb.WriteRequest_CONTINUE_ON_ERROR {
+		return nil, status.Errorf(codes.Unimplemented, "unsupported atomicity: %v", req.GetAtomicity())
+	}
+
+	failed := false
+	var details []*p4rtpb.Error
+	for _, update := range req.GetUpdates() {
+		err := s.update(ctx, update)
+		if err != nil {
+			failed = true
+		}
+		st := status.Convert(err)
+		details = append(details, &p4rtpb.Error{
+			CanonicalCode: int32(st.Code()),
+			Message:       st.Message(),
+		})
+	}
+	if !failed {
+		return &p4rtpb.WriteResponse{}, nil
+	}
+	st := status.New(codes.Unknown, "one or more updates failed")
+	for _, d := range details {
+		var err error
+		if st, err = st.WithDetails(d); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to add error details: %v", err)
+		}
+	}
+	return nil, st.Err()
+}
+
+// update applies a single update.
+// It must be called with the lock held.
+func (s *Server) update(ctx context.Context, update *p4rtpb.Update) error {
+	entry := update.GetEntity().GetTableEntry()
+	if entry == nil {
+		return status.Errorf(codes.Unimplemented, "unsupported entity type: %T", update.GetEntity().GetEntity())
+	}
+	if entry.GetIsDefaultAction() {
+		return status.Errorf(codes.Unimplemented, "modifying the default action is not supported")
+	}
+	// A DELETE is identified by its key, the action is not required.
+	if update.GetType() == p4rtpb.Update_DELETE {
+		if err := s.info.validateKey(entry); err != nil {
+			return err
+		}
+	} else {
+		if err := s.info.validateEntry(entry); err != nil {
+			return err
+		}
+		if err := checkEntry(s.info, entry); err != nil {
+			return err
+		}
+	}
+	prog, err := s.programmer(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := entryKey(entry)
+	if err != nil {
+		return err
+	}
+	prev, exists := s.entries[key]
+
+	switch update.GetType() {
+	case p4rtpb.Update_INSERT:
+		if exists {
+			return status.Errorf(codes.AlreadyExists, "table entry already exists")
+		}
+		if prog != nil {
+			if err := prog.addEntry(ctx, s.info, entry); err != nil {
+				return err
+			}
+		}
+		s.entries[key] = entry
+	case p4rtpb.Update_MODIFY:
+		if !exists {
+			return status.Errorf(codes.NotFound, "table entry does not exist")
+		}
+		if prog != nil {
+			if err := prog.removeEntry(ctx, s.info, prev); err != nil {
+				return err
+			}
+			if err := prog.addEntry(ctx, s.info, entry); err != nil {
+				// Restore the previous entry, so the dataplane stays consistent with s.entries.
+				if rerr := prog.addEntry(ctx, s.info, prev); rerr != nil {
+					log.Warningf("failed to restore table entry after failed MODIFY: %v", rerr)
+				}
+				return err
+			}
+		}
+		s.entries[key] = entry
+	case p4rtpb.Update_DELETE:
+		if !exists {
+			return status.Errorf(codes.NotFound, "table entry does not exist")
+		}
+		if prog != nil {
+			if err := prog.removeEntry(ctx, s.info, prev); err != nil {
+				return err
+			}
+		}
+		delete(s.entries, key)
+	default:
+		return status.Errorf(codes.InvalidArgument, "unsupported update type: %v", update.GetType())
+	}
+	return nil
+}
+
+// Read returns the installed table entries that match the requested entities.
+func (s *Server) Read(req *p4rtpb.ReadRequest, srv p4rtpb.P4Runtime_ReadServer) error {
+	s.mu.Lock()
+	resp := &p4rtpb.ReadResponse{}
+	for _, entity := range req.GetEntities() {
+		filter := entity.GetTableEntry()
+		if filter == nil {
+			s.mu.Unlock()
+			return status.Errorf(codes.Unimplemented, "unsupported entity type: %T", entity.GetEntity())
+		}
+		if len(filter.GetMatch()) > 0 {
+			key, err := entryKey(filter)
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			if entry, ok := s.entries[key]; ok {
+				resp.Entities = append(resp.Entities, &p4rtpb.Entity{Entity: &p4rtpb.Entity_TableEntry{TableEntry: entry}})
+			}
+			continue
+		}
+		var keys []string
+		for key, entry := range s.entries {
+			if filter.GetTableId() == 0 || filter.GetTableId() == entry.GetTableId() {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			resp.Entities = append(resp.Entities, &p4rtpb.Entity{Entity: &p4rtpb.Entity_TableEntry{TableEntry: s.entries[key]}})
+		}
+	}
+	s.mu.Unlock()
+
+	return srv.Send(resp)
+}
+
+// entryKey returns a key that uniquely identifies a table entry by its table, match, and priority.
+func entryKey(entry *p4rtpb.TableEntry) (string, error) {
+	match := make([]*p4rtpb.FieldMatch, len(entry.GetMatch()))
+	copy(match, entry.GetMatch())
+	sort.Slice(match, func(i, j int) bool {
+		return match[i].GetFieldId() < match[j].GetFieldId()
+	})
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(&p4rtpb.TableEntry{
+		TableId:  entry.GetTableId(),
+		Match:    match,
+		Priority: entry.GetPriority(),
+	})
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to marshal table entry: %v", err)
+	}
+	return string(b), nil
+}
+
+// p4Info is an indexed P4Info.
+type p4Info struct {
+	info    *p4infopb.P4Info
+	tables  map[uint32]*tableInfo
+	actions map[uint32]*p4infopb.Action
+	// packetIn and packetOut map the controller packet metadata names to their IDs.
+	packetIn  map[string]uint32
+	packetOut map[uint32]string
+}
+
+type tableInfo struct {
+	table  *p4infopb.Table
+	fields map[uint32]*p4infopb.MatchField
+	// actions is the set of action IDs allowed in the table.
+	actions map[uint32]bool
+	// needsPriority is true if the table has ternary, range, or optional match fields.
+	needsPriority bool
+}
+
+// newP4Info validates and indexes the P4Info.
+func newP4Info(info *p4infopb.P4Info) (*p4Info, error) {
+	if info == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "p4info must be set")
+	}
+	p := &p4Info{
+		info:      info,
+		tables:    map[uint32]*tableInfo{},
+		actions:   map[uint32]*p4infopb.Action{},
+		packetIn:  map[string]uint32{},
+		packetOut: map[uint32]string{},
+	}
+	for _, a := range info.GetActions() {
+		if _, ok := p.actions[a.GetPreamble().GetId()]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate action ID %d", a.GetPreamble().GetId())
+		}
+		p.actions[a.GetPreamble().GetId()] = a
+	}
+	for _, t := range info.GetTables() {
+		id := t.GetPreamble().GetId()
+		if id == 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "table %q has no ID", t.GetPreamble().GetName())
+		}
+		if _, ok := p.tables[id]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate table ID %d", id)
+		}
+		ti := &tableInfo{
+			table:   t,
+			fields:  map[uint32]*p4infopb.MatchField{},
+			actions: map[uint32]bool{},
+		}
+		for _, f := range t.GetMatchFields() {
+			if _, ok := ti.fields[f.GetId()]; ok {
+				return nil, status.Errorf(codes.InvalidArgument, "table %q has duplicate match field ID %d", t.GetPreamble().GetName(), f.GetId())
+			}
+			if err := checkMatchField(f); err != nil {
+				return nil, err
+			}
+			ti.fields[f.GetId()] = f
+			switch f.GetMatchType() {
+			case p4infopb.MatchField_TERNARY, p4infopb.MatchField_RANGE, p4infopb.MatchField_OPTIONAL:
+				ti.needsPriority = true
+			}
+		}
+		for _, ref := range t.GetActionRefs() {
+			if _, ok := p.actions[ref.GetId()]; !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "table %q references unknown action ID %d", t.GetPreamble().GetName(), ref.GetId())
+			}
+			ti.actions[ref.GetId()] = true
+		}
+		p.tables[id] = ti
+	}
+	for _, m := range info.GetControllerPacketMetadata() {
+		for _, md := range m.GetMetadata() {
+			switch m.GetPreamble().GetName() {
+			case "packet_in":
+				p.packetIn[md.GetName()] = md.GetId()
+			case "packet_out":
+				p.packetOut[md.GetId()] = md.GetName()
+			}
+		}
+	}
+	return p, nil
+}
+
+// sameTable returns true if the table ID refers to the same table in both P4Infos.
+func (p *p4Info) sameTable(other *p4Info, id uint32) bool {
+	if p == nil || other == nil {
+		return false
+	}
+	t, ok := p.tables[id]
+	if !ok {
+		return false
+	}
+	o, ok := other.tables[id]
+	return ok && proto.Equal(t.table, o.table)
+}
+
+// validateEntry checks that the table entry is valid for the P4Info.
+func (p *p4Info) validateEntry(entry *p4rtpb.TableEntry) error {
+	if err := p.validateKey(entry); err != nil {
+		return err
+	}
+	t := p.tables[entry.GetTableId()]
+	action := entry.GetAction().GetAction()
+	if action == nil {
+		return status.Errorf(codes.InvalidArgument, "table entry must have a single action")
+	}
+	if !t.actions[action.GetActionId()] {
+		return status.Errorf(codes.InvalidArgument, "action ID %d is not allowed in table %q", action.GetActionId(), t.table.GetPreamble().GetName())
+	}
+	return nil
+}
+
+// validateKey checks that the table, match fields and priority of the table entry are valid for the P4Info.
+func (p *p4Info) validateKey(entry *p4rtpb.TableEntry) error {
+	t, ok := p.tables[entry.GetTableId()]
+	if !ok {
+		return status.Errorf(codes.NotFound, "unknown table ID %d", entry.GetTableId())
+	}
+	seen := map[uint32]bool{}
+	for _, m := range entry.GetMatch() {
+		if _, ok := t.fields[m.GetFieldId()]; !ok {
+			return status.Errorf(codes.InvalidArgument, "unknown match field ID %d for table %q", m.GetFieldId(), t.table.GetPreamble().GetName())
+		}
+		if seen[m.GetFieldId()] {
+			return status.Errorf(codes.InvalidArgument, "duplicate match field ID %d", m.GetFieldId())
+		}
+		seen[m.GetFieldId()] = true
+	}
+	if t.needsPriority && entry.GetPriority() <= 0 {
+		return status.Errorf(codes.InvalidArgument, "table %q requires a positive priority", t.table.GetPreamble().GetName())
+	}
+	if !t.needsPriority && entry.GetPriority() != 0 {
+		return status.Errorf(codes.InvalidArgument, "table %q does not support priority", t.table.GetPreamble().GetName())
+	}
+	return nil
+}
+
+// String returns a readable table name for logging.
+func (t *tableInfo) String() string {
+	return fmt.Sprintf("%s(%d)", t.table.GetPreamble().GetName(), t.table.GetPreamble().GetId())
+}
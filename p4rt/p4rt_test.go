@@ -0,0 +1,506 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package p4rt
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+	p4infopb "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4rtpb "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+const (
+	testTableID     = 1
+	testDstIPField  = 1
+	testEtherField  = 2
+	testInPortField = 3
+	testDropAction  = 10
+	testTrapAction  = 11
+	testSetVRF      = 12
+)
+
+var testP4Info = &p4infopb.P4Info{
+	Tables: []*p4infopb.Table{{
+		Preamble: &p4infopb.Preamble{Id: testTableID, Name: "ingress.acl_ingress.acl_ingress_table"},
+		MatchFields: []*p4infopb.MatchField{{
+			Id:       testDstIPField,
+			Name:     "dst_ip",
+			Bitwidth: 32,
+			Match:    &p4infopb.MatchField_MatchType_{MatchType: p4infopb.MatchField_TERNARY},
+		}, {
+			Id:       testEtherField,
+			Name:     "ether_type",
+			Bitwidth: 16,
+			Match:    &p4infopb.MatchField_MatchType_{MatchType: p4infopb.MatchField_TERNARY},
+		}, {
+			Id:       testInPortField,
+			Name:     "vlan_id",
+			Bitwidth: 12,
+			Match:    &p4infopb.MatchField_MatchType_{MatchType: p4infopb.MatchField_TERNARY},
+		}},
+		ActionRefs: []*p4infopb.ActionRef{{Id: testDropAction}, {Id: testTrapAction}, {Id: testSetVRF}},
+	}},
+	Actions: []*p4infopb.Action{{
+		Preamble: &p4infopb.Preamble{Id: testDropAction, Name: "ingress.acl_ingress.acl_drop"},
+	}, {
+		Preamble: &p4infopb.Preamble{Id: testTrapAction, Name: "ingress.acl_ingress.acl_trap"},
+	}, {
+		Preamble: &p4infopb.Preamble{Id: testSetVRF, Name: "ingress.acl_ingress.set_vrf"},
+	}},
+}
+
+func start(t testing.TB) (p4rtpb.P4RuntimeClient, func()) {
+	t.Helper()
+	s := grpc.NewServer()
+	New(s)
+
+	l, err := net.Listen("tcp", "127.0.0.1:")
+	if err != nil {
+		t.Fatalf("failed to start listener: %v", err)
+	}
+
+	go s.Serve(l)
+
+	conn, err := grpc.NewClient(l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed dial server: %v", err)
+	}
+	return p4rtpb.NewP4RuntimeClient(conn), func() { s.Stop() }
+}
+
+// arbitrate opens a stream and sends an arbitration request, returning the stream and the response status.
+func arbitrate(t testing.TB, c p4rtpb.P4RuntimeClient, electionID uint64) (p4rtpb.P4Runtime_StreamChannelClient, codes.Code) {
+	t.Helper()
+	sc, err := c.StreamChannel(context.Background())
+	if err != nil {
+		t.Fatalf("StreamChannel() unexpected error: %v", err)
+	}
+	if err := sc.Send(&p4rtpb.StreamMessageRequest{
+		Update: &p4rtpb.StreamMessageRequest_Arbitration{
+			Arbitration: &p4rtpb.MasterArbitrationUpdate{
+				DeviceId:   1,
+				ElectionId: &p4rtpb.Uint128{Low: electionID},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("Send() unexpected error: %v", err)
+	}
+	return sc, recvArbitration(t, sc)
+}
+
+func recvArbitration(t testing.TB, sc p4rtpb.P4Runtime_StreamChannelClient) codes.Code {
+	t.Helper()
+	resp, err := sc.Recv()
+	if err != nil {
+		t.Fatalf("Recv() unexpected error: %v", err)
+	}
+	return codes.Code(resp.GetArbitration().GetStatus().GetCode())
+}
+
+func TestArbitration(t *testing.T) {
+	c, stop := start(t)
+	defer stop()
+
+	backup, code := arbitrate(t, c, 1)
+	if code != codes.OK {
+		t.Fatalf("first client got status %v, want %v", code, codes.OK)
+	}
+
+	primary, code := arbitrate(t, c, 2)
+	if code != codes.OK {
+		t.Fatalf("second client got status %v, want %v", code, codes.OK)
+	}
+	if code := recvArbitration(t, backup); code != codes.AlreadyExists {
+		t.Fatalf("first client got status %v after new primary, want %v", code, codes.AlreadyExists)
+	}
+
+	if _, err := c.StreamChannel(context.Background()); err != nil {
+		t.Fatalf("StreamChannel() unexpected error: %v", err)
+	}
+	dup, err := c.StreamChannel(context.Background())
+	if err != nil {
+		t.Fatalf("StreamChannel() unexpected error: %v", err)
+	}
+	dup.Send(&p4rtpb.StreamMessageRequest{
+		Update: &p4rtpb.StreamMessageRequest_Arbitration{
+			Arbitration: &p4rtpb.MasterArbitrationUpdate{DeviceId: 1, ElectionId: &p4rtpb.Uint128{Low: 2}},
+		},
+	})
+	if _, err := dup.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("duplicate election ID got err %v, want %v", err, codes.InvalidArgument)
+	}
+
+	primary.CloseSend()
+	if code := recvArbitration(t, backup); code != codes.NotFound {
+		t.Fatalf("first client got status %v after primary disconnected, want %v", code, codes.NotFound)
+	}
+}
+
+func TestSetForwardingPipelineConfig(t *testing.T) {
+	c, stop := start(t)
+	defer stop()
+	if _, code := arbitrate(t, c, 5); code != codes.OK {
+		t.Fatalf("arbitration got status %v, want %v", code, codes.OK)
+	}
+
+	tests := []struct {
+		desc    string
+		req     *p4rtpb.SetForwardingPipelineConfigRequest
+		wantErr string
+	}{{
+		desc: "not primary",
+		req: &p4rtpb.SetForwardingPipelineConfigRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 4},
+			Action:     p4rtpb.SetForwardingPipelineConfigRequest_VERIFY_AND_COMMIT,
+			Config:     &p4rtpb.ForwardingPipelineConfig{P4Info: testP4Info},
+		},
+		wantErr: "not the primary",
+	}, {
+		desc: "missing p4info",
+		req: &p4rtpb.SetForwardingPipelineConfigRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 5},
+			Action:     p4rtpb.SetForwardingPipelineConfigRequest_VERIFY,
+			Config:     &p4rtpb.ForwardingPipelineConfig{},
+		},
+		wantErr: "p4info must be set",
+	}, {
+		desc: "bitwidth too large",
+		req: &p4rtpb.SetForwardingPipelineConfigRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 5},
+			Action:     p4rtpb.SetForwardingPipelineConfigRequest_VERIFY,
+			Config: &p4rtpb.ForwardingPipelineConfig{P4Info: &p4infopb.P4Info{
+				Tables: []*p4infopb.Table{{
+					Preamble: &p4infopb.Preamble{Id: testTableID, Name: "ingress.acl_ingress.acl_ingress_table"},
+					MatchFields: []*p4infopb.MatchField{{
+						Id:       testDstIPField,
+						Name:     "dst_ip",
+						Bitwidth: 128,
+						Match:    &p4infopb.MatchField_MatchType_{MatchType: p4infopb.MatchField_LPM},
+					}},
+				}},
+			}},
+		},
+		wantErr: "larger than 32 bits",
+	}, {
+		desc: "commit without save",
+		req: &p4rtpb.SetForwardingPipelineConfigRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 5},
+			Action:     p4rtpb.SetForwardingPipelineConfigRequest_COMMIT,
+		},
+		wantErr: "no saved",
+	}, {
+		desc: "verify and commit",
+		req: &p4rtpb.SetForwardingPipelineConfigRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 5},
+			Action:     p4rtpb.SetForwardingPipelineConfigRequest_VERIFY_AND_COMMIT,
+			Config: &p4rtpb.ForwardingPipelineConfig{
+				P4Info: testP4Info,
+				Cookie: &p4rtpb.ForwardingPipelineConfig_Cookie{Cookie: 10},
+			},
+		},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := c.SetForwardingPipelineConfig(context.Background(), tt.req)
+			if d := errdiff.Substring(err, tt.wantErr); d != "" {
+				t.Fatalf("SetForwardingPipelineConfig() unexpected error: %s", d)
+			}
+		})
+	}
+
+	resp, err := c.GetForwardingPipelineConfig(context.Background(), &p4rtpb.GetForwardingPipelineConfigRequest{
+		DeviceId:     1,
+		ResponseType: p4rtpb.GetForwardingPipelineConfigRequest_COOKIE_ONLY,
+	})
+	if err != nil {
+		t.Fatalf("GetForwardingPipelineConfig() unexpected error: %v", err)
+	}
+	want := &p4rtpb.ForwardingPipelineConfig{Cookie: &p4rtpb.ForwardingPipelineConfig_Cookie{Cookie: 10}}
+	if d := cmp.Diff(resp.GetConfig(), want, protocmp.Transform()); d != "" {
+		t.Errorf("GetForwardingPipelineConfig() unexpected diff (-got, +want):\n%s", d)
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	c, stop := start(t)
+	defer stop()
+	if _, code := arbitrate(t, c, 1); code != codes.OK {
+		t.Fatalf("arbitration got status %v, want %v", code, codes.OK)
+	}
+	if _, err := c.SetForwardingPipelineConfig(context.Background(), &p4rtpb.SetForwardingPipelineConfigRequest{
+		DeviceId:   1,
+		ElectionId: &p4rtpb.Uint128{Low: 1},
+		Action:     p4rtpb.SetForwardingPipelineConfigRequest_VERIFY_AND_COMMIT,
+		Config:     &p4rtpb.ForwardingPipelineConfig{P4Info: testP4Info},
+	}); err != nil {
+		t.Fatalf("SetForwardingPipelineConfig() unexpected error: %v", err)
+	}
+
+	entry := func(action uint32, match *p4rtpb.FieldMatch) *p4rtpb.TableEntry {
+		return &p4rtpb.TableEntry{
+			TableId:  testTableID,
+			Priority: 10,
+			Match:    []*p4rtpb.FieldMatch{match},
+			Action:   &p4rtpb.TableAction{Type: &p4rtpb.TableAction_Action{Action: &p4rtpb.Action{ActionId: action}}},
+		}
+	}
+	etherMatch := &p4rtpb.FieldMatch{
+		FieldId:        testEtherField,
+		FieldMatchType: &p4rtpb.FieldMatch_Ternary_{Ternary: &p4rtpb.FieldMatch_Ternary{Value: []byte{0x08, 0x06}, Mask: []byte{0xff, 0xff}}},
+	}
+	write := func(typ p4rtpb.Update_Type, e *p4rtpb.TableEntry) error {
+		_, err := c.Write(context.Background(), &p4rtpb.WriteRequest{
+			DeviceId:   1,
+			ElectionId: &p4rtpb.Uint128{Low: 1},
+			Updates:    []*p4rtpb.Update{{Type: typ, Entity: &p4rtpb.Entity{Entity: &p4rtpb.Entity_TableEntry{TableEntry: e}}}},
+		})
+		return err
+	}
+	read := func() []*p4rtpb.Entity {
+		rc, err := c.Read(context.Background(), &p4rtpb.ReadRequest{
+			DeviceId: 1,
+			Entities: []*p4rtpb.Entity{{Entity: &p4rtpb.Entity_TableEntry{TableEntry: &p4rtpb.TableEntry{}}}},
+		})
+		if err != nil {
+			t.Fatalf("Read() unexpected error: %v", err)
+		}
+		resp, err := rc.Recv()
+		if err != nil {
+			t.Fatalf("Read() unexpected error: %v", err)
+		}
+		return resp.GetEntities()
+	}
+
+	if err := write(p4rtpb.Update_INSERT, entry(testTrapAction, etherMatch)); err != nil {
+		t.Fatalf("Write() INSERT unexpected error: %v", err)
+	}
+	if err := write(p4rtpb.Update_INSERT, entry(testTrapAction, etherMatch)); status.Code(err) != codes.Unknown {
+		t.Fatalf("Write() duplicate INSERT got err %v, want %v", err, codes.Unknown)
+	}
+	unsupported := &p4rtpb.FieldMatch{
+		FieldId:        testInPortField,
+		FieldMatchType: &p4rtpb.FieldMatch_Ternary_{Ternary: &p4rtpb.FieldMatch_Ternary{Value: []byte{0x01}, Mask: []byte{0xff}}},
+	}
+	if err := write(p4rtpb.Update_INSERT, entry(testDropAction, unsupported)); status.Code(err) != codes.Unknown {
+		t.Fatalf("Write() unsupported field got err %v, want %v", err, codes.Unknown)
+	}
+	if err := write(p4rtpb.Update_MODIFY, entry(testDropAction, etherMatch)); err != nil {
+		t.Fatalf("Write() MODIFY unexpected error: %v", err)
+	}
+	want := []*p4rtpb.Entity{{Entity: &p4rtpb.Entity_TableEntry{TableEntry: entry(testDropAction, etherMatch)}}}
+	if d := cmp.Diff(read(), want, protocmp.Transform()); d != "" {
+		t.Errorf("Read() unexpected diff (-got, +want):\n%s", d)
+	}
+	// A DELETE only carries the key of the entry, without an action.
+	if err := write(p4rtpb.Update_DELETE, &p4rtpb.TableEntry{TableId: testTableID, Priority: 10, Match: []*p4rtpb.FieldMatch{etherMatch}}); err != nil {
+		t.Fatalf("Write() DELETE unexpected error: %v", err)
+	}
+	if got := read(); len(got) != 0 {
+		t.Errorf("Read() got %v, want no entities", got)
+	}
+	if err := write(p4rtpb.Update_DELETE, entry(testDropAction, etherMatch)); status.Code(err) != codes.Unknown {
+		t.Fatalf("Write() DELETE missing entry got err %v, want %v", err, codes.Unknown)
+	}
+}
+
+func TestFieldBytes(t *testing.T) {
+	tests := []struct {
+		desc      string
+		mf        *p4infopb.MatchField
+		fm        *p4rtpb.FieldMatch
+		wantBytes []byte
+		wantMask  []byte
+		wantErr   string
+	}{{
+		desc: "lpm ipv4",
+		mf:   &p4infopb.MatchField{Name: "dst_ip", Bitwidth: 32},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Lpm{
+			Lpm: &p4rtpb.FieldMatch_LPM{Value: []byte{10, 1, 0, 0}, PrefixLen: 16},
+		}},
+		wantBytes: []byte{10, 1, 0, 0},
+		wantMask:  []byte{0xff, 0xff, 0, 0},
+	}, {
+		desc: "canonical bytes are padded",
+		mf:   &p4infopb.MatchField{Name: "hdr.ethernet.ether_type", Bitwidth: 16},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Exact_{
+			Exact: &p4rtpb.FieldMatch_Exact{Value: []byte{0x06}},
+		}},
+		wantBytes: []byte{0x00, 0x06},
+		wantMask:  []byte{0xff, 0xff},
+	}, {
+		desc: "dscp is shifted",
+		mf:   &p4infopb.MatchField{Name: "dscp", Bitwidth: 6},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Ternary_{
+			Ternary: &p4rtpb.FieldMatch_Ternary{Value: []byte{0x2e}, Mask: []byte{0x3f}},
+		}},
+		wantBytes: []byte{0xb8},
+		wantMask:  []byte{0xfc},
+	}, {
+		desc: "is_ipv4",
+		mf:   &p4infopb.MatchField{Name: "is_ipv4", Bitwidth: 1},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Optional_{
+			Optional: &p4rtpb.FieldMatch_Optional{Value: []byte{0x01}},
+		}},
+		wantBytes: []byte{0x08, 0x00},
+		wantMask:  []byte{0xff, 0xff},
+	}, {
+		desc: "in_port",
+		mf:   &p4infopb.MatchField{Name: "in_port"},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Optional_{
+			Optional: &p4rtpb.FieldMatch_Optional{Value: []byte("7")},
+		}},
+		wantBytes: []byte{0, 0, 0, 0, 0, 0, 0, 70},
+		wantMask:  []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}, {
+		desc: "value too large",
+		mf:   &p4infopb.MatchField{Name: "ether_type", Bitwidth: 16},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Exact_{
+			Exact: &p4rtpb.FieldMatch_Exact{Value: []byte{0x01, 0x02, 0x03}},
+		}},
+		wantErr: "larger than",
+	}, {
+		desc: "lpm bitwidth too large",
+		mf:   &p4infopb.MatchField{Name: "dst_ip", Bitwidth: 128},
+		fm: &p4rtpb.FieldMatch{FieldMatchType: &p4rtpb.FieldMatch_Lpm{
+			Lpm: &p4rtpb.FieldMatch_LPM{Value: []byte{10, 1, 0, 0}, PrefixLen: 16},
+		}},
+		wantErr: "bitwidth 128 is larger than 32 bits",
+	}, {
+		desc:    "unsupported field",
+		mf:      &p4infopb.MatchField{Name: "vlan_id", Bitwidth: 12},
+		fm:      &p4rtpb.FieldMatch{},
+		wantErr: "unsupported match field",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := fieldBytes(tt.mf, tt.fm, func(id uint64) (uint64, error) { return id * 10, nil })
+			if d := errdiff.Substring(err, tt.wantErr); d != "" {
+				t.Fatalf("fieldBytes() unexpected error: %s", d)
+			}
+			if err != nil {
+				return
+			}
+			pb := got.Build()
+			if d := cmp.Diff(pb.GetBytes(), tt.wantBytes); d != "" {
+				t.Errorf("fieldBytes() unexpected bytes diff (-got, +want):\n%s", d)
+			}
+			if d := cmp.Diff(pb.GetMasks(), tt.wantMask); d != "" {
+				t.Errorf("fieldBytes() unexpected mask diff (-got, +want):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestEntryActions(t *testing.T) {
+	info, err := newP4Info(testP4Info)
+	if err != nil {
+		t.Fatalf("newP4Info() unexpected error: %v", err)
+	}
+	trap := &p4rtpb.TableEntry{Action: &p4rtpb.TableAction{Type: &p4rtpb.TableAction_Action{Action: &p4rtpb.Action{ActionId: testTrapAction}}}}
+	acts, err := entryActions(info, trap, 100)
+	if err != nil {
+		t.Fatalf("entryActions() unexpected error: %v", err)
+	}
+	if len(acts) != 2 || acts[0].GetUpdate().GetFieldId().GetField().GetFieldNum() != fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID {
+		t.Errorf("entryActions() got %v, want trap ID and packet action updates", acts)
+	}
+
+	unsupported := &p4rtpb.TableEntry{Action: &p4rtpb.TableAction{Type: &p4rtpb.TableAction_Action{Action: &p4rtpb.Action{ActionId: testSetVRF}}}}
+	if _, err := entryActions(info, unsupported, 100); status.Code(err) != codes.Unimplemented {
+		t.Errorf("entryActions() got err %v, want %v", err, codes.Unimplemented)
+	}
+}
+
+// fakeForwarding records the table entries added to and removed from the dataplane.
+type fakeForwarding struct {
+	fwdpb.ForwardingClient
+	entries map[string]int // The number of entries installed, keyed by the flow entry.
+	failAdd bool
+}
+
+func (f *fakeForwarding) TableEntryAdd(_ context.Context, req *fwdpb.TableEntryAddRequest, _ ...grpc.CallOption) (*fwdpb.TableEntryAddReply, error) {
+	if f.failAdd {
+		f.failAdd = false
+		return nil, status.Errorf(codes.Internal, "injected failure")
+	}
+	f.entries[req.GetEntries()[0].GetEntryDesc().String()]++
+	return &fwdpb.TableEntryAddReply{}, nil
+}
+
+func (f *fakeForwarding) TableEntryRemove(_ context.Context, req *fwdpb.TableEntryRemoveRequest, _ ...grpc.CallOption) (*fwdpb.TableEntryRemoveReply, error) {
+	k := req.GetEntries()[0].String()
+	if f.entries[k]--; f.entries[k] <= 0 {
+		delete(f.entries, k)
+	}
+	return &fwdpb.TableEntryRemoveReply{}, nil
+}
+
+func TestModifyRestore(t *testing.T) {
+	info, err := newP4Info(testP4Info)
+	if err != nil {
+		t.Fatalf("newP4Info() unexpected error: %v", err)
+	}
+	fwd := &fakeForwarding{entries: map[string]int{}}
+	s := &Server{
+		info:    info,
+		entries: map[string]*p4rtpb.TableEntry{},
+		prog:    &programmer{fwd: fwd, tables: map[string]bool{}},
+	}
+	entry := func(action uint32) *p4rtpb.TableEntry {
+		return &p4rtpb.TableEntry{
+			TableId:  testTableID,
+			Priority: 10,
+			Match: []*p4rtpb.FieldMatch{{
+				FieldId:        testEtherField,
+				FieldMatchType: &p4rtpb.FieldMatch_Ternary_{Ternary: &p4rtpb.FieldMatch_Ternary{Value: []byte{0x08, 0x06}, Mask: []byte{0xff, 0xff}}},
+			}},
+			Action: &p4rtpb.TableAction{Type: &p4rtpb.TableAction_Action{Action: &p4rtpb.Action{ActionId: action}}},
+		}
+	}
+	ctx := context.Background()
+	if err := s.update(ctx, &p4rtpb.Update{Type: p4rtpb.Update_INSERT, Entity: &p4rtpb.Entity{Entity: &p4rtpb.Entity_TableEntry{TableEntry: entry(testTrapAction)}}}); err != nil {
+		t.Fatalf("update() INSERT unexpected error: %v", err)
+	}
+	fwd.failAdd = true
+	if err := s.update(ctx, &p4rtpb.Update{Type: p4rtpb.Update_MODIFY, Entity: &p4rtpb.Entity{Entity: &p4rtpb.Entity_TableEntry{TableEntry: entry(testDropAction)}}}); status.Code(err) != codes.Internal {
+		t.Fatalf("update() MODIFY got err %v, want %v", err, codes.Internal)
+	}
+	if len(fwd.entries) != 1 {
+		t.Errorf("MODIFY failure left %d dataplane entries, want 1", len(fwd.entries))
+	}
+	var got []*p4rtpb.TableEntry
+	for _, e := range s.entries {
+		got = append(got, e)
+	}
+	if d := cmp.Diff([]*p4rtpb.TableEntry{entry(testTrapAction)}, got, protocmp.Transform()); d != "" {
+		t.Errorf("MODIFY failure unexpected entries diff (-want, +got):\n%s", d)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package p4rt
+
+import (
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	log "github.com/golang/glog"
+
+	pktiopb "github.com/openconfig/lemming/dataplane/proto/packetio"
+	p4rtpb "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+// packetHandler is a protocol handler for packets punted with the P4RT trap.
+type packetHandler struct {
+	s      *Server
+	trapID uint64
+}
+
+// Matched returns true if the packet was punted with the P4RT trap.
+func (h *packetHandler) Matched(po *pktiopb.PacketOut) bool {
+	return po.GetPacket().GetHostPort() == h.trapID
+}
+
+// Process sends the packet to the primary clients as a PacketIn.
+func (h *packetHandler) Process(po *pktiopb.PacketOut) error {
+	h.s.packetIn(po.GetPacket())
+	return nil
+}
+
+// packetIn sends the packet to the primary client of each role.
+func (s *Server) packetIn(pkt *pktiopb.Packet) {
+	s.mu.Lock()
+	in := &p4rtpb.PacketIn{
+		Payload: pkt.GetFrame(),
+	}
+	if s.info != nil {
+		if id, ok := s.info.packetIn["ingress_port"]; ok {
+			in.Metadata = append(in.Metadata, &p4rtpb.PacketMetadata{MetadataId: id, Value: []byte(strconv.FormatUint(pkt.GetInputPort(), 10))})
+		}
+		if id, ok := s.info.packetIn["target_egress_port"]; ok && pkt.GetOutputPort() != 0 {
+			in.Metadata = append(in.Metadata, &p4rtpb.PacketMetadata{MetadataId: id, Value: []byte(strconv.FormatUint(pkt.GetOutputPort(), 10))})
+		}
+	}
+	var primaries []*stream
+	for _, r := range s.roles {
+		if r.primary != nil {
+			primaries = append(primaries, r.primary)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, st := range primaries {
+		if err := st.send(&p4rtpb.StreamMessageResponse{Update: &p4rtpb.StreamMessageResponse_Packet{Packet: in}}); err != nil {
+			log.Warningf("failed to send packet in: %v", err)
+		}
+	}
+}
+
+// packetOut sends a packet from the client to the dataplane. If the packet has an egress port, it is transmitted
+// directly out of the port, otherwise it is submitted to the ingress pipeline.
+func (s *Server) packetOut(st *stream, out *p4rtpb.PacketOut) error {
+	s.mu.Lock()
+	r, ok := s.roles[st.key]
+	if !ok || r.primary != st {
+		s.mu.Unlock()
+		return status.Errorf(codes.PermissionDenied, "only the primary client can send packets")
+	}
+	if s.info == nil {
+		s.mu.Unlock()
+		return status.Errorf(codes.FailedPrecondition, "forwarding pipeline config is not set")
+	}
+	if s.prog == nil {
+		s.mu.Unlock()
+		return status.Errorf(codes.Unavailable, "dataplane is not available")
+	}
+	var egressPort uint64
+	submitToIngress := false
+	for _, md := range out.GetMetadata() {
+		switch s.info.packetOut[md.GetMetadataId()] {
+		case "egress_port":
+			port, err := parsePort(md.GetValue())
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			egressPort = port
+		case "submit_to_ingress":
+			for _, b := range md.GetValue() {
+				submitToIngress = submitToIngress || b != 0
+			}
+		}
+	}
+	trapID := s.prog.trapID
+	s.mu.Unlock()
+
+	if submitToIngress {
+		egressPort = 0
+	} else if egressPort == 0 {
+		return status.Errorf(codes.InvalidArgument, "packet must have an egress port or be submitted to ingress")
+	}
+	return s.dplane.Registry().Send(&pktiopb.PacketIn{
+		Msg: &pktiopb.PacketIn_Packet{
+			Packet: &pktiopb.Packet{
+				HostPort:   trapID,
+				OutputPort: egressPort,
+				Frame:      out.GetPayload(),
+			},
+		},
+	})
+}
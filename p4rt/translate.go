@@ -0,0 +1,398 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package p4rt
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	log "github.com/golang/glog"
+
+	"github.com/openconfig/lemming/dataplane/forwarding/fwdconfig"
+	"github.com/openconfig/lemming/dataplane/saiserver"
+
+	saipb "github.com/openconfig/lemming/dataplane/proto/sai"
+	fwdpb "github.com/openconfig/lemming/proto/forwarding"
+	p4infopb "github.com/p4lang/p4runtime/go/p4/config/v1"
+	p4rtpb "github.com/p4lang/p4runtime/go/p4/v1"
+)
+
+const (
+	contextID   = "lucius"
+	tablePrefix = "p4rt-"
+)
+
+// matchField describes how a P4 match field is translated to a lucius packet field.
+type matchField struct {
+	field fwdpb.PacketFieldNum
+	// size is the size of the lucius field in bytes.
+	size int
+	// shift is the number of bits to left shift the value and mask, for fields not aligned to the lucius field.
+	shift uint
+	// port is true if the value is a port ID that is matched by its NID.
+	port bool
+	// value is matched instead of the P4 value, for boolean fields such as is_ipv4 that match a fixed value.
+	value []byte
+}
+
+// matchFields maps P4 match field names to lucius packet fields.
+var matchFields = map[string]matchField{
+	"in_port":     {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_PORT_INPUT, size: 8, port: true},
+	"src_mac":     {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_MAC_SRC, size: 6},
+	"dst_mac":     {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_MAC_DST, size: 6},
+	"ether_type":  {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE, size: 2},
+	"is_ipv4":     {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE, size: 2, value: []byte{0x08, 0x00}},
+	"is_ipv6":     {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ETHER_TYPE, size: 2, value: []byte{0x86, 0xdd}},
+	"src_ip":      {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_SRC, size: 4},
+	"dst_ip":      {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_DST, size: 4},
+	"src_ipv6":    {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_SRC, size: 16},
+	"dst_ipv6":    {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_ADDR_DST, size: 16},
+	"ip_protocol": {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_PROTO, size: 1},
+	"ttl":         {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_HOP, size: 1},
+	"dscp":        {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_IP_QOS, size: 1, shift: 2},
+	"icmp_type":   {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ICMP_TYPE, size: 1},
+	"icmpv6_type": {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_ICMP_TYPE, size: 1},
+	"l4_src_port": {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_L4_PORT_SRC, size: 2},
+	"l4_dst_port": {field: fwdpb.PacketFieldNum_PACKET_FIELD_NUM_L4_PORT_DST, size: 2},
+}
+
+// shortName returns the last component of a fully qualified P4 name.
+func shortName(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// parsePort parses a P4RT port value, which is the decimal string of the SAI port OID.
+func parsePort(v []byte) (uint64, error) {
+	id, err := strconv.ParseUint(string(v), 10, 64)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid port %q: %v", v, err)
+	}
+	return id, nil
+}
+
+// padBytes left pads the P4RT canonical bytes to size bytes.
+func padBytes(v []byte, size int) ([]byte, error) {
+	if len(v) > size {
+		return nil, status.Errorf(codes.InvalidArgument, "value %x is larger than %d bytes", v, size)
+	}
+	b := make([]byte, size)
+	copy(b[size-len(v):], v)
+	return b, nil
+}
+
+// prefixMask returns a mask of size bytes, with the first prefixLen bits of the bitwidth set.
+func prefixMask(size int, bitwidth, prefixLen int32) ([]byte, error) {
+	if bitwidth < 0 || bitwidth > int32(size*8) {
+		return nil, status.Errorf(codes.InvalidArgument, "bitwidth %d is larger than %d bits", bitwidth, size*8)
+	}
+	if prefixLen < 0 || prefixLen > bitwidth {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid prefix length %d for bitwidth %d", prefixLen, bitwidth)
+	}
+	mask := make([]byte, size)
+	offset := int32(size*8) - bitwidth
+	for i := offset; i < offset+prefixLen; i++ {
+		mask[i/8] |= 0x80 >> (i % 8)
+	}
+	return mask, nil
+}
+
+// checkMatchField returns an error if the bitwidth of a supported match field is larger
+// than the lucius field it is translated to. The port fields are strings, their bitwidth
+// is not checked.
+func checkMatchField(mf *p4infopb.MatchField) error {
+	spec, ok := matchFields[shortName(mf.GetName())]
+	if !ok || spec.port {
+		return nil
+	}
+	if mf.GetBitwidth() > int32(spec.size*8) {
+		return status.Errorf(codes.InvalidArgument, "bitwidth %d of match field %q is larger than %d bits", mf.GetBitwidth(), mf.GetName(), spec.size*8)
+	}
+	return nil
+}
+
+// fullMask returns a mask with all bits set.
+func fullMask(size int) []byte {
+	mask := make([]byte, size)
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+	return mask
+}
+
+// fieldBytes translates a P4 field match to a lucius field.
+// resolvePort returns the NID of a port, it is used for port match fields.
+func fieldBytes(mf *p4infopb.MatchField, fm *p4rtpb.FieldMatch, resolvePort func(uint64) (uint64, error)) (*fwdconfig.PacketFieldMaskedBytesBuilder, error) {
+	spec, ok := matchFields[shortName(mf.GetName())]
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unsupported match field %q", mf.GetName())
+	}
+	size := spec.size
+
+	var value, mask []byte
+	switch m := fm.GetFieldMatchType().(type) {
+	case *p4rtpb.FieldMatch_Exact_:
+		value, mask = m.Exact.GetValue(), fullMask(size)
+	case *p4rtpb.FieldMatch_Optional_:
+		value, mask = m.Optional.GetValue(), fullMask(size)
+	case *p4rtpb.FieldMatch_Ternary_:
+		var err error
+		value = m.Ternary.GetValue()
+		if mask, err = padBytes(m.Ternary.GetMask(), size); err != nil {
+			return nil, err
+		}
+	case *p4rtpb.FieldMatch_Lpm:
+		if m.Lpm.GetPrefixLen() < 0 || m.Lpm.GetPrefixLen() > mf.GetBitwidth() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid prefix length %d for field %q", m.Lpm.GetPrefixLen(), mf.GetName())
+		}
+		var err error
+		value = m.Lpm.GetValue()
+		if mask, err = prefixMask(size, mf.GetBitwidth(), m.Lpm.GetPrefixLen()); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported match type %T for field %q", m, mf.GetName())
+	}
+
+	switch {
+	case spec.value != nil:
+		if len(value) != 1 || value[0] != 1 {
+			return nil, status.Errorf(codes.Unimplemented, "field %q only supports matching true", mf.GetName())
+		}
+		value = spec.value
+	case spec.port:
+		id, err := parsePort(value)
+		if err != nil {
+			return nil, err
+		}
+		nid, err := resolvePort(id)
+		if err != nil {
+			return nil, err
+		}
+		value = binary.BigEndian.AppendUint64(nil, nid)
+	}
+
+	value, err := padBytes(value, size)
+	if err != nil {
+		return nil, err
+	}
+	if spec.shift != 0 {
+		value[0] <<= spec.shift
+		mask[0] <<= spec.shift
+	}
+	for i := range value {
+		value[i] &= mask[i]
+	}
+	return fwdconfig.PacketFieldMaskedBytes(spec.field).WithBytes(value, mask), nil
+}
+
+// flowEntry translates a table entry to a lucius flow entry.
+func flowEntry(info *p4Info, entry *p4rtpb.TableEntry, resolvePort func(uint64) (uint64, error)) (*fwdconfig.EntryDescBuilder, error) {
+	t := info.tables[entry.GetTableId()]
+	var fields []*fwdconfig.PacketFieldMaskedBytesBuilder
+	for _, fm := range entry.GetMatch() {
+		f, err := fieldBytes(t.fields[fm.GetFieldId()], fm, resolvePort)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	// P4RT and Lucius have reversed definitions of priority.
+	return fwdconfig.EntryDesc(fwdconfig.FlowEntry(fields...).WithPriority(math.MaxUint32 - uint32(entry.GetPriority()))), nil
+}
+
+// checkEntry returns an error if the table entry can't be translated to lucius.
+func checkEntry(info *p4Info, entry *p4rtpb.TableEntry) error {
+	if _, err := flowEntry(info, entry, func(id uint64) (uint64, error) { return id, nil }); err != nil {
+		return err
+	}
+	_, err := entryActions(info, entry, 0)
+	return err
+}
+
+// packetAction returns an action that sets the packet action, the values match the SAI packet actions.
+func packetAction(val byte, count, offset int) *fwdpb.ActionDesc {
+	return fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_BIT_WRITE, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_PACKET_ACTION).WithBitOp(count, offset).WithValue([]byte{val})).Build()
+}
+
+// entryActions translates the action of a table entry to lucius actions.
+// Packets punted by the actions are sent to the CPU with the trap ID.
+func entryActions(info *p4Info, entry *p4rtpb.TableEntry, trapID uint64) ([]*fwdpb.ActionDesc, error) {
+	a := info.actions[entry.GetAction().GetAction().GetActionId()]
+	setTrap := fwdconfig.Action(fwdconfig.UpdateAction(fwdpb.UpdateType_UPDATE_TYPE_SET, fwdpb.PacketFieldNum_PACKET_FIELD_NUM_TRAP_ID).WithUint64Value(trapID)).Build()
+
+	switch name := shortName(a.GetPreamble().GetName()); name {
+	case "NoAction", "no_action", "forward", "acl_forward":
+		return nil, nil
+	case "drop", "acl_drop", "deny":
+		return []*fwdpb.ActionDesc{packetAction(0, 1, 0)}, nil
+	case "trap", "acl_trap", "punt":
+		return []*fwdpb.ActionDesc{setTrap, packetAction(2, 2, 0)}, nil
+	case "copy", "acl_copy":
+		return []*fwdpb.ActionDesc{setTrap, packetAction(1, 1, 1)}, nil
+	default:
+		return nil, status.Errorf(codes.Unimplemented, "unsupported action %q", a.GetPreamble().GetName())
+	}
+}
+
+// tableID returns the ID of lucius table for a P4 table.
+func tableID(t *p4infopb.Table) string {
+	name := t.GetPreamble().GetAlias()
+	if name == "" {
+		name = t.GetPreamble().GetName()
+	}
+	return tablePrefix + name
+}
+
+// tableStage returns the lucius table the P4 table is looked up from.
+func tableStage(t *p4infopb.Table) string {
+	name := strings.ToLower(t.GetPreamble().GetName())
+	switch {
+	case strings.Contains(name, "pre_ingress"):
+		return saiserver.PreIngressActionTable
+	case strings.Contains(name, "egress"):
+		return saiserver.EgressActionTable
+	default:
+		return saiserver.IngressActionTable
+	}
+}
+
+// programmer programs the P4 tables into the lucius dataplane.
+type programmer struct {
+	fwd    fwdpb.ForwardingClient
+	trapID uint64
+	tables map[string]bool // The lucius tables already created.
+}
+
+// programmer returns the dataplane programmer, creating it on first use.
+// It returns nil if the server has no dataplane.
+// It must be called with the lock held.
+func (s *Server) programmer(ctx context.Context) (*programmer, error) {
+	if s.prog != nil || s.dplane == nil {
+		return s.prog, nil
+	}
+	pr := s.dplane.Registry()
+	if pr == nil {
+		return nil, status.Errorf(codes.Unavailable, "dataplane is not started")
+	}
+	conn, err := s.dplane.Conn()
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to connect to dataplane: %v", err)
+	}
+	trap, err := saipb.NewHostifClient(conn).CreateHostifTrap(ctx, &saipb.CreateHostifTrapRequest{
+		Switch:       s.dplane.SwitchID(),
+		TrapType:     saipb.HostifTrapType_HOSTIF_TRAP_TYPE_P4RT.Enum(),
+		PacketAction: saipb.PacketAction_PACKET_ACTION_TRAP.Enum(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to create P4RT trap: %v", err)
+	}
+	if err := pr.Register("p4rt", &packetHandler{s: s, trapID: trap.GetOid()}); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to register packet handler: %v", err)
+	}
+	s.prog = &programmer{
+		fwd:    fwdpb.NewForwardingClient(conn),
+		trapID: trap.GetOid(),
+		tables: map[string]bool{},
+	}
+	log.Infof("P4RT programming dataplane, trap ID %d", trap.GetOid())
+	return s.prog, nil
+}
+
+// createTables creates a lucius flow table for each P4 table that doesn't already exist,
+// and looks them up from the matching pipeline stage.
+func (p *programmer) createTables(ctx context.Context, info *p4Info) error {
+	for _, t := range info.tables {
+		id := tableID(t.table)
+		if p.tables[id] {
+			continue
+		}
+		_, err := p.fwd.TableCreate(ctx, &fwdpb.TableCreateRequest{
+			ContextId: &fwdpb.ContextId{Id: contextID},
+			Desc: &fwdpb.TableDesc{
+				TableType: fwdpb.TableType_TABLE_TYPE_FLOW,
+				TableId:   &fwdpb.TableId{ObjectId: &fwdpb.ObjectId{Id: id}},
+				Table: &fwdpb.TableDesc_Flow{
+					Flow: &fwdpb.FlowTableDesc{
+						BankCount: 1,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to create table %v: %v", t, err)
+		}
+		_, err = p.fwd.TableEntryAdd(ctx, fwdconfig.TableEntryAddRequest(contextID, tableStage(t.table)).
+			AppendEntry(
+				fwdconfig.EntryDesc(fwdconfig.ActionEntry(id, fwdpb.ActionEntryDesc_INSERT_METHOD_APPEND)),
+				fwdconfig.LookupAction(id)).
+			Build())
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to add table %v to pipeline: %v", t, err)
+		}
+		p.tables[id] = true
+	}
+	return nil
+}
+
+// resolvePort returns the NID of the port with the SAI OID.
+func (p *programmer) resolvePort(ctx context.Context) func(uint64) (uint64, error) {
+	return func(id uint64) (uint64, error) {
+		nid, err := p.fwd.ObjectNID(ctx, &fwdpb.ObjectNIDRequest{
+			ContextId: &fwdpb.ContextId{Id: contextID},
+			ObjectId:  &fwdpb.ObjectId{Id: strconv.FormatUint(id, 10)},
+		})
+		if err != nil {
+			return 0, status.Errorf(codes.InvalidArgument, "unknown port %d: %v", id, err)
+		}
+		return nid.GetNid(), nil
+	}
+}
+
+// addEntry adds the table entry to the dataplane.
+func (p *programmer) addEntry(ctx context.Context, info *p4Info, entry *p4rtpb.TableEntry) error {
+	desc, err := flowEntry(info, entry, p.resolvePort(ctx))
+	if err != nil {
+		return err
+	}
+	acts, err := entryActions(info, entry, p.trapID)
+	if err != nil {
+		return err
+	}
+	req := fwdconfig.TableEntryAddRequest(contextID, tableID(info.tables[entry.GetTableId()].table)).AppendEntry(desc).Build()
+	req.Entries[0].Actions = acts
+	if _, err := p.fwd.TableEntryAdd(ctx, req); err != nil {
+		return status.Errorf(codes.Internal, "failed to add table entry: %v", err)
+	}
+	return nil
+}
+
+// removeEntry removes the table entry from the dataplane.
+func (p *programmer) removeEntry(ctx context.Context, info *p4Info, entry *p4rtpb.TableEntry) error {
+	desc, err := flowEntry(info, entry, p.resolvePort(ctx))
+	if err != nil {
+		return err
+	}
+	req := fwdconfig.TableEntryRemoveRequest(contextID, tableID(info.tables[entry.GetTableId()].table)).AppendEntry(desc).Build()
+	if _, err := p.fwd.TableEntryRemove(ctx, req); err != nil {
+		return status.Errorf(codes.Internal, "failed to remove table entry: %v", err)
+	}
+	return nil
+}
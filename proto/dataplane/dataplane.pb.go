@@ -543,6 +543,82 @@ func (*Route_NextHops) isRoute_Hop() {}
 
 func (*Route_Interface) isRoute_Hop() {}
 
+type LabelRoute struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Label          uint32                 `protobuf:"varint,1,opt,name=label,proto3" json:"label,omitempty"`
+	PopLabels      uint32                 `protobuf:"varint,2,opt,name=pop_labels,json=popLabels,proto3" json:"pop_labels,omitempty"` // Number of labels popped, including the matched label.
+	Action         PacketAction           `protobuf:"varint,3,opt,name=action,proto3,enum=lemming.dataplane.PacketAction" json:"action,omitempty"`
+	NextHops       *NextHopList           `protobuf:"bytes,4,opt,name=next_hops,json=nextHops,proto3" json:"next_hops,omitempty"`
+	SegmentRouting bool                   `protobuf:"varint,5,opt,name=segment_routing,json=segmentRouting,proto3" json:"segment_routing,omitempty"` // Static or segment routing SID, counted in the aggregate SID counters.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LabelRoute) Reset() {
+	*x = LabelRoute{}
+	mi := &file_proto_dataplane_dataplane_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LabelRoute) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LabelRoute) ProtoMessage() {}
+
+func (x *LabelRoute) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_dataplane_dataplane_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LabelRoute.ProtoReflect.Descriptor instead.
+func (*LabelRoute) Descriptor() ([]byte, []int) {
+	return file_proto_dataplane_dataplane_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *LabelRoute) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
+func (x *LabelRoute) GetPopLabels() uint32 {
+	if x != nil {
+		return x.PopLabels
+	}
+	return 0
+}
+
+func (x *LabelRoute) GetAction() PacketAction {
+	if x != nil {
+		return x.Action
+	}
+	return PacketAction_PACKET_ACTION_UNSPECIFIED
+}
+
+func (x *LabelRoute) GetNextHops() *NextHopList {
+	if x != nil {
+		return x.NextHops
+	}
+	return nil
+}
+
+func (x *LabelRoute) GetSegmentRouting() bool {
+	if x != nil {
+		return x.SegmentRouting
+	}
+	return false
+}
+
 var File_proto_dataplane_dataplane_proto protoreflect.FileDescriptor
 
 const file_proto_dataplane_dataplane_proto_rawDesc = "" +
@@ -573,7 +649,15 @@ const file_proto_dataplane_dataplane_proto_rawDesc = "" +
 	"\x06action\x18\x02 \x01(\x0e2\x1f.lemming.dataplane.PacketActionR\x06action\x12=\n" +
 	"\tnext_hops\x18\x03 \x01(\v2\x1e.lemming.dataplane.NextHopListH\x00R\bnextHops\x12>\n" +
 	"\tinterface\x18\x04 \x01(\v2\x1e.lemming.dataplane.OCInterfaceH\x00R\tinterfaceB\x05\n" +
-	"\x03hop*|\n" +
+	"\x03hop\"\xe0\x01\n" +
+	"\n" +
+	"LabelRoute\x12\x14\n" +
+	"\x05label\x18\x01 \x01(\rR\x05label\x12\x1d\n" +
+	"\n" +
+	"pop_labels\x18\x02 \x01(\rR\tpopLabels\x127\n" +
+	"\x06action\x18\x03 \x01(\x0e2\x1f.lemming.dataplane.PacketActionR\x06action\x12;\n" +
+	"\tnext_hops\x18\x04 \x01(\v2\x1e.lemming.dataplane.NextHopListR\bnextHops\x12'\n" +
+	"\x0fsegment_routing\x18\x05 \x01(\bR\x0esegmentRouting*|\n" +
 	"\fPortLocation\x12\x1d\n" +
 	"\x19PORT_LOCATION_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16PORT_LOCATION_INTERNAL\x10\x01\x12\x1a\n" +
@@ -597,7 +681,7 @@ func file_proto_dataplane_dataplane_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_dataplane_dataplane_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_proto_dataplane_dataplane_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_dataplane_dataplane_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_proto_dataplane_dataplane_proto_goTypes = []any{
 	(PortLocation)(0),       // 0: lemming.dataplane.PortLocation
 	(PacketAction)(0),       // 1: lemming.dataplane.PacketAction
@@ -607,22 +691,25 @@ var file_proto_dataplane_dataplane_proto_goTypes = []any{
 	(*NextHopList)(nil),     // 5: lemming.dataplane.NextHopList
 	(*RoutePrefix)(nil),     // 6: lemming.dataplane.RoutePrefix
 	(*Route)(nil),           // 7: lemming.dataplane.Route
-	(*routing.Headers)(nil), // 8: routing.Headers
+	(*LabelRoute)(nil),      // 8: lemming.dataplane.LabelRoute
+	(*routing.Headers)(nil), // 9: routing.Headers
 }
 var file_proto_dataplane_dataplane_proto_depIdxs = []int32{
-	2, // 0: lemming.dataplane.NextHop.interface:type_name -> lemming.dataplane.OCInterface
-	3, // 1: lemming.dataplane.NextHop.gue:type_name -> lemming.dataplane.GUE
-	8, // 2: lemming.dataplane.NextHop.headers:type_name -> routing.Headers
-	4, // 3: lemming.dataplane.NextHopList.hops:type_name -> lemming.dataplane.NextHop
-	6, // 4: lemming.dataplane.Route.prefix:type_name -> lemming.dataplane.RoutePrefix
-	1, // 5: lemming.dataplane.Route.action:type_name -> lemming.dataplane.PacketAction
-	5, // 6: lemming.dataplane.Route.next_hops:type_name -> lemming.dataplane.NextHopList
-	2, // 7: lemming.dataplane.Route.interface:type_name -> lemming.dataplane.OCInterface
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	2,  // 0: lemming.dataplane.NextHop.interface:type_name -> lemming.dataplane.OCInterface
+	3,  // 1: lemming.dataplane.NextHop.gue:type_name -> lemming.dataplane.GUE
+	9,  // 2: lemming.dataplane.NextHop.headers:type_name -> routing.Headers
+	4,  // 3: lemming.dataplane.NextHopList.hops:type_name -> lemming.dataplane.NextHop
+	6,  // 4: lemming.dataplane.Route.prefix:type_name -> lemming.dataplane.RoutePrefix
+	1,  // 5: lemming.dataplane.Route.action:type_name -> lemming.dataplane.PacketAction
+	5,  // 6: lemming.dataplane.Route.next_hops:type_name -> lemming.dataplane.NextHopList
+	2,  // 7: lemming.dataplane.Route.interface:type_name -> lemming.dataplane.OCInterface
+	1,  // 8: lemming.dataplane.LabelRoute.action:type_name -> lemming.dataplane.PacketAction
+	5,  // 9: lemming.dataplane.LabelRoute.next_hops:type_name -> lemming.dataplane.NextHopList
+	10, // [10:10] is the sub-list for method output_type
+	10, // [10:10] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
 }
 
 func init() { file_proto_dataplane_dataplane_proto_init() }
@@ -644,7 +731,7 @@ func file_proto_dataplane_dataplane_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_dataplane_dataplane_proto_rawDesc), len(file_proto_dataplane_dataplane_proto_rawDesc)),
 			NumEnums:      2,
-			NumMessages:   6,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
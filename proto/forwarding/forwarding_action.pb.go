@@ -43,6 +43,7 @@ const (
 	ActionType_ACTION_TYPE_SELECT_ACTION_LIST            ActionType = 17
 	ActionType_ACTION_TYPE_DEBUG                         ActionType = 18
 	ActionType_ACTION_TYPE_SWAP_OUTPUT_INTERNAL_EXTERNAL ActionType = 19
+	ActionType_ACTION_TYPE_POLICER                       ActionType = 20
 )
 
 // Enum value maps for ActionType.
@@ -67,6 +68,7 @@ var (
 		17: "ACTION_TYPE_SELECT_ACTION_LIST",
 		18: "ACTION_TYPE_DEBUG",
 		19: "ACTION_TYPE_SWAP_OUTPUT_INTERNAL_EXTERNAL",
+		20: "ACTION_TYPE_POLICER",
 	}
 	ActionType_value = map[string]int32{
 		"ACTION_TYPE_UNSPECIFIED":                   0,
@@ -88,6 +90,7 @@ var (
 		"ACTION_TYPE_SELECT_ACTION_LIST":            17,
 		"ACTION_TYPE_DEBUG":                         18,
 		"ACTION_TYPE_SWAP_OUTPUT_INTERNAL_EXTERNAL": 19,
+		"ACTION_TYPE_POLICER":                       20,
 	}
 )
 
@@ -118,6 +121,55 @@ func (ActionType) EnumDescriptor() ([]byte, []int) {
 	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{0}
 }
 
+type PolicerMode int32
+
+const (
+	PolicerMode_POLICER_MODE_UNSPECIFIED PolicerMode = 0
+	PolicerMode_POLICER_MODE_SR_TCM      PolicerMode = 1
+	PolicerMode_POLICER_MODE_TR_TCM      PolicerMode = 2
+)
+
+// Enum value maps for PolicerMode.
+var (
+	PolicerMode_name = map[int32]string{
+		0: "POLICER_MODE_UNSPECIFIED",
+		1: "POLICER_MODE_SR_TCM",
+		2: "POLICER_MODE_TR_TCM",
+	}
+	PolicerMode_value = map[string]int32{
+		"POLICER_MODE_UNSPECIFIED": 0,
+		"POLICER_MODE_SR_TCM":      1,
+		"POLICER_MODE_TR_TCM":      2,
+	}
+)
+
+func (x PolicerMode) Enum() *PolicerMode {
+	p := new(PolicerMode)
+	*p = x
+	return p
+}
+
+func (x PolicerMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PolicerMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_forwarding_forwarding_action_proto_enumTypes[1].Descriptor()
+}
+
+func (PolicerMode) Type() protoreflect.EnumType {
+	return &file_proto_forwarding_forwarding_action_proto_enumTypes[1]
+}
+
+func (x PolicerMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PolicerMode.Descriptor instead.
+func (PolicerMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{1}
+}
+
 type UpdateType int32
 
 const (
@@ -166,11 +218,11 @@ func (x UpdateType) String() string {
 }
 
 func (UpdateType) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_forwarding_forwarding_action_proto_enumTypes[1].Descriptor()
+	return file_proto_forwarding_forwarding_action_proto_enumTypes[2].Descriptor()
 }
 
 func (UpdateType) Type() protoreflect.EnumType {
-	return &file_proto_forwarding_forwarding_action_proto_enumTypes[1]
+	return &file_proto_forwarding_forwarding_action_proto_enumTypes[2]
 }
 
 func (x UpdateType) Number() protoreflect.EnumNumber {
@@ -179,7 +231,7 @@ func (x UpdateType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use UpdateType.Descriptor instead.
 func (UpdateType) EnumDescriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{1}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{2}
 }
 
 type SelectActionListActionDesc_SelectAlgorithm int32
@@ -218,11 +270,11 @@ func (x SelectActionListActionDesc_SelectAlgorithm) String() string {
 }
 
 func (SelectActionListActionDesc_SelectAlgorithm) Descriptor() protoreflect.EnumDescriptor {
-	return file_proto_forwarding_forwarding_action_proto_enumTypes[2].Descriptor()
+	return file_proto_forwarding_forwarding_action_proto_enumTypes[3].Descriptor()
 }
 
 func (SelectActionListActionDesc_SelectAlgorithm) Type() protoreflect.EnumType {
-	return &file_proto_forwarding_forwarding_action_proto_enumTypes[2]
+	return &file_proto_forwarding_forwarding_action_proto_enumTypes[3]
 }
 
 func (x SelectActionListActionDesc_SelectAlgorithm) Number() protoreflect.EnumNumber {
@@ -231,7 +283,7 @@ func (x SelectActionListActionDesc_SelectAlgorithm) Number() protoreflect.EnumNu
 
 // Deprecated: Use SelectActionListActionDesc_SelectAlgorithm.Descriptor instead.
 func (SelectActionListActionDesc_SelectAlgorithm) EnumDescriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{13, 0}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{14, 0}
 }
 
 type ActionDesc struct {
@@ -252,6 +304,7 @@ type ActionDesc struct {
 	//	*ActionDesc_Flow
 	//	*ActionDesc_Reparse
 	//	*ActionDesc_Select
+	//	*ActionDesc_Policer
 	Action        isActionDesc_Action `protobuf_oneof:"action"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -416,6 +469,15 @@ func (x *ActionDesc) GetSelect() *SelectActionListActionDesc {
 	return nil
 }
 
+func (x *ActionDesc) GetPolicer() *PolicerActionDesc {
+	if x != nil {
+		if x, ok := x.Action.(*ActionDesc_Policer); ok {
+			return x.Policer
+		}
+	}
+	return nil
+}
+
 type isActionDesc_Action interface {
 	isActionDesc_Action()
 }
@@ -468,6 +530,10 @@ type ActionDesc_Select struct {
 	Select *SelectActionListActionDesc `protobuf:"bytes,14,opt,name=select,proto3,oneof"`
 }
 
+type ActionDesc_Policer struct {
+	Policer *PolicerActionDesc `protobuf:"bytes,15,opt,name=policer,proto3,oneof"`
+}
+
 func (*ActionDesc_Transmit) isActionDesc_Action() {}
 
 func (*ActionDesc_Lookup) isActionDesc_Action() {}
@@ -492,6 +558,8 @@ func (*ActionDesc_Reparse) isActionDesc_Action() {}
 
 func (*ActionDesc_Select) isActionDesc_Action() {}
 
+func (*ActionDesc_Policer) isActionDesc_Action() {}
+
 type TransmitActionDesc struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	PortId        *PortId                `protobuf:"bytes,1,opt,name=port_id,json=portId,proto3" json:"port_id,omitempty"`
@@ -640,6 +708,114 @@ func (x *RateActionDesc) GetRateBps() int32 {
 	return 0
 }
 
+type PolicerActionDesc struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          PolicerMode            `protobuf:"varint,1,opt,name=mode,proto3,enum=forwarding.PolicerMode" json:"mode,omitempty"`
+	Packets       bool                   `protobuf:"varint,2,opt,name=packets,proto3" json:"packets,omitempty"`
+	Cir           uint64                 `protobuf:"varint,3,opt,name=cir,proto3" json:"cir,omitempty"`
+	Cbs           uint64                 `protobuf:"varint,4,opt,name=cbs,proto3" json:"cbs,omitempty"`
+	Pir           uint64                 `protobuf:"varint,5,opt,name=pir,proto3" json:"pir,omitempty"`
+	Pbs           uint64                 `protobuf:"varint,6,opt,name=pbs,proto3" json:"pbs,omitempty"`
+	GreenActions  []*ActionDesc          `protobuf:"bytes,7,rep,name=green_actions,json=greenActions,proto3" json:"green_actions,omitempty"`
+	YellowActions []*ActionDesc          `protobuf:"bytes,8,rep,name=yellow_actions,json=yellowActions,proto3" json:"yellow_actions,omitempty"`
+	RedActions    []*ActionDesc          `protobuf:"bytes,9,rep,name=red_actions,json=redActions,proto3" json:"red_actions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicerActionDesc) Reset() {
+	*x = PolicerActionDesc{}
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicerActionDesc) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicerActionDesc) ProtoMessage() {}
+
+func (x *PolicerActionDesc) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicerActionDesc.ProtoReflect.Descriptor instead.
+func (*PolicerActionDesc) Descriptor() ([]byte, []int) {
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PolicerActionDesc) GetMode() PolicerMode {
+	if x != nil {
+		return x.Mode
+	}
+	return PolicerMode_POLICER_MODE_UNSPECIFIED
+}
+
+func (x *PolicerActionDesc) GetPackets() bool {
+	if x != nil {
+		return x.Packets
+	}
+	return false
+}
+
+func (x *PolicerActionDesc) GetCir() uint64 {
+	if x != nil {
+		return x.Cir
+	}
+	return 0
+}
+
+func (x *PolicerActionDesc) GetCbs() uint64 {
+	if x != nil {
+		return x.Cbs
+	}
+	return 0
+}
+
+func (x *PolicerActionDesc) GetPir() uint64 {
+	if x != nil {
+		return x.Pir
+	}
+	return 0
+}
+
+func (x *PolicerActionDesc) GetPbs() uint64 {
+	if x != nil {
+		return x.Pbs
+	}
+	return 0
+}
+
+func (x *PolicerActionDesc) GetGreenActions() []*ActionDesc {
+	if x != nil {
+		return x.GreenActions
+	}
+	return nil
+}
+
+func (x *PolicerActionDesc) GetYellowActions() []*ActionDesc {
+	if x != nil {
+		return x.YellowActions
+	}
+	return nil
+}
+
+func (x *PolicerActionDesc) GetRedActions() []*ActionDesc {
+	if x != nil {
+		return x.RedActions
+	}
+	return nil
+}
+
 type EncapActionDesc struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	HeaderId      PacketHeaderId         `protobuf:"varint,1,opt,name=header_id,json=headerId,proto3,enum=forwarding.PacketHeaderId" json:"header_id,omitempty"`
@@ -649,7 +825,7 @@ type EncapActionDesc struct {
 
 func (x *EncapActionDesc) Reset() {
 	*x = EncapActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[4]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -661,7 +837,7 @@ func (x *EncapActionDesc) String() string {
 func (*EncapActionDesc) ProtoMessage() {}
 
 func (x *EncapActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[4]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -674,7 +850,7 @@ func (x *EncapActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EncapActionDesc.ProtoReflect.Descriptor instead.
 func (*EncapActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{4}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *EncapActionDesc) GetHeaderId() PacketHeaderId {
@@ -693,7 +869,7 @@ type DecapActionDesc struct {
 
 func (x *DecapActionDesc) Reset() {
 	*x = DecapActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[5]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -705,7 +881,7 @@ func (x *DecapActionDesc) String() string {
 func (*DecapActionDesc) ProtoMessage() {}
 
 func (x *DecapActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[5]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -718,7 +894,7 @@ func (x *DecapActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DecapActionDesc.ProtoReflect.Descriptor instead.
 func (*DecapActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{5}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DecapActionDesc) GetHeaderId() PacketHeaderId {
@@ -737,7 +913,7 @@ type BridgeLearnActionDesc struct {
 
 func (x *BridgeLearnActionDesc) Reset() {
 	*x = BridgeLearnActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[6]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -749,7 +925,7 @@ func (x *BridgeLearnActionDesc) String() string {
 func (*BridgeLearnActionDesc) ProtoMessage() {}
 
 func (x *BridgeLearnActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[6]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -762,7 +938,7 @@ func (x *BridgeLearnActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BridgeLearnActionDesc.ProtoReflect.Descriptor instead.
 func (*BridgeLearnActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{6}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *BridgeLearnActionDesc) GetTableId() *TableId {
@@ -786,7 +962,7 @@ type UpdateActionDesc struct {
 
 func (x *UpdateActionDesc) Reset() {
 	*x = UpdateActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[7]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -798,7 +974,7 @@ func (x *UpdateActionDesc) String() string {
 func (*UpdateActionDesc) ProtoMessage() {}
 
 func (x *UpdateActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[7]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -811,7 +987,7 @@ func (x *UpdateActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateActionDesc.ProtoReflect.Descriptor instead.
 func (*UpdateActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{7}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *UpdateActionDesc) GetFieldId() *PacketFieldId {
@@ -866,7 +1042,7 @@ type TestActionDesc struct {
 
 func (x *TestActionDesc) Reset() {
 	*x = TestActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[8]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -878,7 +1054,7 @@ func (x *TestActionDesc) String() string {
 func (*TestActionDesc) ProtoMessage() {}
 
 func (x *TestActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[8]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -891,7 +1067,7 @@ func (x *TestActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TestActionDesc.ProtoReflect.Descriptor instead.
 func (*TestActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{8}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *TestActionDesc) GetInt1() uint32 {
@@ -920,7 +1096,7 @@ type MirrorActionDesc struct {
 
 func (x *MirrorActionDesc) Reset() {
 	*x = MirrorActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[9]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -932,7 +1108,7 @@ func (x *MirrorActionDesc) String() string {
 func (*MirrorActionDesc) ProtoMessage() {}
 
 func (x *MirrorActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[9]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -945,7 +1121,7 @@ func (x *MirrorActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MirrorActionDesc.ProtoReflect.Descriptor instead.
 func (*MirrorActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{9}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *MirrorActionDesc) GetActions() []*ActionDesc {
@@ -985,7 +1161,7 @@ type FlowCounterActionDesc struct {
 
 func (x *FlowCounterActionDesc) Reset() {
 	*x = FlowCounterActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[10]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -997,7 +1173,7 @@ func (x *FlowCounterActionDesc) String() string {
 func (*FlowCounterActionDesc) ProtoMessage() {}
 
 func (x *FlowCounterActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[10]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1010,7 +1186,7 @@ func (x *FlowCounterActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FlowCounterActionDesc.ProtoReflect.Descriptor instead.
 func (*FlowCounterActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{10}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *FlowCounterActionDesc) GetCounterId() *FlowCounterId {
@@ -1031,7 +1207,7 @@ type ReparseActionDesc struct {
 
 func (x *ReparseActionDesc) Reset() {
 	*x = ReparseActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[11]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1043,7 +1219,7 @@ func (x *ReparseActionDesc) String() string {
 func (*ReparseActionDesc) ProtoMessage() {}
 
 func (x *ReparseActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[11]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1056,7 +1232,7 @@ func (x *ReparseActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReparseActionDesc.ProtoReflect.Descriptor instead.
 func (*ReparseActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{11}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *ReparseActionDesc) GetHeaderId() PacketHeaderId {
@@ -1090,7 +1266,7 @@ type ActionList struct {
 
 func (x *ActionList) Reset() {
 	*x = ActionList{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[12]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1102,7 +1278,7 @@ func (x *ActionList) String() string {
 func (*ActionList) ProtoMessage() {}
 
 func (x *ActionList) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[12]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1115,7 +1291,7 @@ func (x *ActionList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ActionList.ProtoReflect.Descriptor instead.
 func (*ActionList) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{12}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *ActionList) GetActions() []*ActionDesc {
@@ -1143,7 +1319,7 @@ type SelectActionListActionDesc struct {
 
 func (x *SelectActionListActionDesc) Reset() {
 	*x = SelectActionListActionDesc{}
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[13]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1155,7 +1331,7 @@ func (x *SelectActionListActionDesc) String() string {
 func (*SelectActionListActionDesc) ProtoMessage() {}
 
 func (x *SelectActionListActionDesc) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[13]
+	mi := &file_proto_forwarding_forwarding_action_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1168,7 +1344,7 @@ func (x *SelectActionListActionDesc) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SelectActionListActionDesc.ProtoReflect.Descriptor instead.
 func (*SelectActionListActionDesc) Descriptor() ([]byte, []int) {
-	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{13}
+	return file_proto_forwarding_forwarding_action_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SelectActionListActionDesc) GetSelectAlgorithm() SelectActionListActionDesc_SelectAlgorithm {
@@ -1197,7 +1373,7 @@ var File_proto_forwarding_forwarding_action_proto protoreflect.FileDescriptor
 const file_proto_forwarding_forwarding_action_proto_rawDesc = "" +
 	"\n" +
 	"(proto/forwarding/forwarding_action.proto\x12\n" +
-	"forwarding\x1a(proto/forwarding/forwarding_common.proto\"\x97\x06\n" +
+	"forwarding\x1a(proto/forwarding/forwarding_common.proto\"\xd2\x06\n" +
 	"\n" +
 	"ActionDesc\x127\n" +
 	"\vaction_type\x18\x01 \x01(\x0e2\x16.forwarding.ActionTypeR\n" +
@@ -1216,7 +1392,8 @@ const file_proto_forwarding_forwarding_action_proto_rawDesc = "" +
 	"\x06bridge\x18\v \x01(\v2!.forwarding.BridgeLearnActionDescH\x00R\x06bridge\x127\n" +
 	"\x04flow\x18\f \x01(\v2!.forwarding.FlowCounterActionDescH\x00R\x04flow\x129\n" +
 	"\areparse\x18\r \x01(\v2\x1d.forwarding.ReparseActionDescH\x00R\areparse\x12@\n" +
-	"\x06select\x18\x0e \x01(\v2&.forwarding.SelectActionListActionDescH\x00R\x06selectB\b\n" +
+	"\x06select\x18\x0e \x01(\v2&.forwarding.SelectActionListActionDescH\x00R\x06select\x129\n" +
+	"\apolicer\x18\x0f \x01(\v2\x1d.forwarding.PolicerActionDescH\x00R\apolicerB\b\n" +
 	"\x06action\"_\n" +
 	"\x12TransmitActionDesc\x12+\n" +
 	"\aport_id\x18\x01 \x01(\v2\x12.forwarding.PortIdR\x06portId\x12\x1c\n" +
@@ -1226,7 +1403,18 @@ const file_proto_forwarding_forwarding_action_proto_rawDesc = "" +
 	"\x0eRateActionDesc\x12\x1f\n" +
 	"\vburst_bytes\x18\x01 \x01(\x05R\n" +
 	"burstBytes\x12\x19\n" +
-	"\brate_bps\x18\x02 \x01(\x05R\arateBps\"J\n" +
+	"\brate_bps\x18\x02 \x01(\x05R\arateBps\"\xd7\x02\n" +
+	"\x11PolicerActionDesc\x12+\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x17.forwarding.PolicerModeR\x04mode\x12\x18\n" +
+	"\apackets\x18\x02 \x01(\bR\apackets\x12\x10\n" +
+	"\x03cir\x18\x03 \x01(\x04R\x03cir\x12\x10\n" +
+	"\x03cbs\x18\x04 \x01(\x04R\x03cbs\x12\x10\n" +
+	"\x03pir\x18\x05 \x01(\x04R\x03pir\x12\x10\n" +
+	"\x03pbs\x18\x06 \x01(\x04R\x03pbs\x12;\n" +
+	"\rgreen_actions\x18\a \x03(\v2\x16.forwarding.ActionDescR\fgreenActions\x12=\n" +
+	"\x0eyellow_actions\x18\b \x03(\v2\x16.forwarding.ActionDescR\ryellowActions\x127\n" +
+	"\vred_actions\x18\t \x03(\v2\x16.forwarding.ActionDescR\n" +
+	"redActions\"J\n" +
 	"\x0fEncapActionDesc\x127\n" +
 	"\theader_id\x18\x01 \x01(\x0e2\x1a.forwarding.PacketHeaderIdR\bheaderId\"J\n" +
 	"\x0fDecapActionDesc\x127\n" +
@@ -1269,7 +1457,7 @@ const file_proto_forwarding_forwarding_action_proto_rawDesc = "" +
 	"\x1cSELECT_ALGORITHM_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16SELECT_ALGORITHM_CRC16\x10\x02\x12\x1a\n" +
 	"\x16SELECT_ALGORITHM_CRC32\x10\x03\x12\x1b\n" +
-	"\x17SELECT_ALGORITHM_RANDOM\x10\x05*\x86\x04\n" +
+	"\x17SELECT_ALGORITHM_RANDOM\x10\x05*\x9f\x04\n" +
 	"\n" +
 	"ActionType\x12\x1b\n" +
 	"\x17ACTION_TYPE_UNSPECIFIED\x10\x00\x12\x14\n" +
@@ -1291,7 +1479,12 @@ const file_proto_forwarding_forwarding_action_proto_rawDesc = "" +
 	"\x13ACTION_TYPE_REPARSE\x10\x10\x12\"\n" +
 	"\x1eACTION_TYPE_SELECT_ACTION_LIST\x10\x11\x12\x15\n" +
 	"\x11ACTION_TYPE_DEBUG\x10\x12\x12-\n" +
-	")ACTION_TYPE_SWAP_OUTPUT_INTERNAL_EXTERNAL\x10\x13*\xca\x01\n" +
+	")ACTION_TYPE_SWAP_OUTPUT_INTERNAL_EXTERNAL\x10\x13\x12\x17\n" +
+	"\x13ACTION_TYPE_POLICER\x10\x14*]\n" +
+	"\vPolicerMode\x12\x1c\n" +
+	"\x18POLICER_MODE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13POLICER_MODE_SR_TCM\x10\x01\x12\x17\n" +
+	"\x13POLICER_MODE_TR_TCM\x10\x02*\xca\x01\n" +
 	"\n" +
 	"UpdateType\x12\x1b\n" +
 	"\x17UPDATE_TYPE_UNSPECIFIED\x10\x00\x12\x13\n" +
@@ -1315,71 +1508,78 @@ func file_proto_forwarding_forwarding_action_proto_rawDescGZIP() []byte {
 	return file_proto_forwarding_forwarding_action_proto_rawDescData
 }
 
-var file_proto_forwarding_forwarding_action_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_proto_forwarding_forwarding_action_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_proto_forwarding_forwarding_action_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_proto_forwarding_forwarding_action_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_proto_forwarding_forwarding_action_proto_goTypes = []any{
-	(ActionType)(0), // 0: forwarding.ActionType
-	(UpdateType)(0), // 1: forwarding.UpdateType
-	(SelectActionListActionDesc_SelectAlgorithm)(0), // 2: forwarding.SelectActionListActionDesc.SelectAlgorithm
-	(*ActionDesc)(nil),                 // 3: forwarding.ActionDesc
-	(*TransmitActionDesc)(nil),         // 4: forwarding.TransmitActionDesc
-	(*LookupActionDesc)(nil),           // 5: forwarding.LookupActionDesc
-	(*RateActionDesc)(nil),             // 6: forwarding.RateActionDesc
-	(*EncapActionDesc)(nil),            // 7: forwarding.EncapActionDesc
-	(*DecapActionDesc)(nil),            // 8: forwarding.DecapActionDesc
-	(*BridgeLearnActionDesc)(nil),      // 9: forwarding.BridgeLearnActionDesc
-	(*UpdateActionDesc)(nil),           // 10: forwarding.UpdateActionDesc
-	(*TestActionDesc)(nil),             // 11: forwarding.TestActionDesc
-	(*MirrorActionDesc)(nil),           // 12: forwarding.MirrorActionDesc
-	(*FlowCounterActionDesc)(nil),      // 13: forwarding.FlowCounterActionDesc
-	(*ReparseActionDesc)(nil),          // 14: forwarding.ReparseActionDesc
-	(*ActionList)(nil),                 // 15: forwarding.ActionList
-	(*SelectActionListActionDesc)(nil), // 16: forwarding.SelectActionListActionDesc
-	(*PortId)(nil),                     // 17: forwarding.PortId
-	(*TableId)(nil),                    // 18: forwarding.TableId
-	(PacketHeaderId)(0),                // 19: forwarding.PacketHeaderId
-	(*PacketFieldId)(nil),              // 20: forwarding.PacketFieldId
-	(PortAction)(0),                    // 21: forwarding.PortAction
-	(*FlowCounterId)(nil),              // 22: forwarding.FlowCounterId
+	(ActionType)(0),  // 0: forwarding.ActionType
+	(PolicerMode)(0), // 1: forwarding.PolicerMode
+	(UpdateType)(0),  // 2: forwarding.UpdateType
+	(SelectActionListActionDesc_SelectAlgorithm)(0), // 3: forwarding.SelectActionListActionDesc.SelectAlgorithm
+	(*ActionDesc)(nil),                 // 4: forwarding.ActionDesc
+	(*TransmitActionDesc)(nil),         // 5: forwarding.TransmitActionDesc
+	(*LookupActionDesc)(nil),           // 6: forwarding.LookupActionDesc
+	(*RateActionDesc)(nil),             // 7: forwarding.RateActionDesc
+	(*PolicerActionDesc)(nil),          // 8: forwarding.PolicerActionDesc
+	(*EncapActionDesc)(nil),            // 9: forwarding.EncapActionDesc
+	(*DecapActionDesc)(nil),            // 10: forwarding.DecapActionDesc
+	(*BridgeLearnActionDesc)(nil),      // 11: forwarding.BridgeLearnActionDesc
+	(*UpdateActionDesc)(nil),           // 12: forwarding.UpdateActionDesc
+	(*TestActionDesc)(nil),             // 13: forwarding.TestActionDesc
+	(*MirrorActionDesc)(nil),           // 14: forwarding.MirrorActionDesc
+	(*FlowCounterActionDesc)(nil),      // 15: forwarding.FlowCounterActionDesc
+	(*ReparseActionDesc)(nil),          // 16: forwarding.ReparseActionDesc
+	(*ActionList)(nil),                 // 17: forwarding.ActionList
+	(*SelectActionListActionDesc)(nil), // 18: forwarding.SelectActionListActionDesc
+	(*PortId)(nil),                     // 19: forwarding.PortId
+	(*TableId)(nil),                    // 20: forwarding.TableId
+	(PacketHeaderId)(0),                // 21: forwarding.PacketHeaderId
+	(*PacketFieldId)(nil),              // 22: forwarding.PacketFieldId
+	(PortAction)(0),                    // 23: forwarding.PortAction
+	(*FlowCounterId)(nil),              // 24: forwarding.FlowCounterId
 }
 var file_proto_forwarding_forwarding_action_proto_depIdxs = []int32{
 	0,  // 0: forwarding.ActionDesc.action_type:type_name -> forwarding.ActionType
-	4,  // 1: forwarding.ActionDesc.transmit:type_name -> forwarding.TransmitActionDesc
-	5,  // 2: forwarding.ActionDesc.lookup:type_name -> forwarding.LookupActionDesc
-	6,  // 3: forwarding.ActionDesc.rate:type_name -> forwarding.RateActionDesc
-	7,  // 4: forwarding.ActionDesc.encap:type_name -> forwarding.EncapActionDesc
-	8,  // 5: forwarding.ActionDesc.decap:type_name -> forwarding.DecapActionDesc
-	10, // 6: forwarding.ActionDesc.update:type_name -> forwarding.UpdateActionDesc
-	11, // 7: forwarding.ActionDesc.test:type_name -> forwarding.TestActionDesc
-	12, // 8: forwarding.ActionDesc.mirror:type_name -> forwarding.MirrorActionDesc
-	9,  // 9: forwarding.ActionDesc.bridge:type_name -> forwarding.BridgeLearnActionDesc
-	13, // 10: forwarding.ActionDesc.flow:type_name -> forwarding.FlowCounterActionDesc
-	14, // 11: forwarding.ActionDesc.reparse:type_name -> forwarding.ReparseActionDesc
-	16, // 12: forwarding.ActionDesc.select:type_name -> forwarding.SelectActionListActionDesc
-	17, // 13: forwarding.TransmitActionDesc.port_id:type_name -> forwarding.PortId
-	18, // 14: forwarding.LookupActionDesc.table_id:type_name -> forwarding.TableId
-	19, // 15: forwarding.EncapActionDesc.header_id:type_name -> forwarding.PacketHeaderId
-	19, // 16: forwarding.DecapActionDesc.header_id:type_name -> forwarding.PacketHeaderId
-	18, // 17: forwarding.BridgeLearnActionDesc.table_id:type_name -> forwarding.TableId
-	20, // 18: forwarding.UpdateActionDesc.field_id:type_name -> forwarding.PacketFieldId
-	1,  // 19: forwarding.UpdateActionDesc.type:type_name -> forwarding.UpdateType
-	20, // 20: forwarding.UpdateActionDesc.field:type_name -> forwarding.PacketFieldId
-	3,  // 21: forwarding.MirrorActionDesc.actions:type_name -> forwarding.ActionDesc
-	17, // 22: forwarding.MirrorActionDesc.port_id:type_name -> forwarding.PortId
-	21, // 23: forwarding.MirrorActionDesc.port_action:type_name -> forwarding.PortAction
-	20, // 24: forwarding.MirrorActionDesc.field_ids:type_name -> forwarding.PacketFieldId
-	22, // 25: forwarding.FlowCounterActionDesc.counter_id:type_name -> forwarding.FlowCounterId
-	19, // 26: forwarding.ReparseActionDesc.header_id:type_name -> forwarding.PacketHeaderId
-	20, // 27: forwarding.ReparseActionDesc.field_ids:type_name -> forwarding.PacketFieldId
-	3,  // 28: forwarding.ActionList.actions:type_name -> forwarding.ActionDesc
-	2,  // 29: forwarding.SelectActionListActionDesc.select_algorithm:type_name -> forwarding.SelectActionListActionDesc.SelectAlgorithm
-	20, // 30: forwarding.SelectActionListActionDesc.field_ids:type_name -> forwarding.PacketFieldId
-	15, // 31: forwarding.SelectActionListActionDesc.action_lists:type_name -> forwarding.ActionList
-	32, // [32:32] is the sub-list for method output_type
-	32, // [32:32] is the sub-list for method input_type
-	32, // [32:32] is the sub-list for extension type_name
-	32, // [32:32] is the sub-list for extension extendee
-	0,  // [0:32] is the sub-list for field type_name
+	5,  // 1: forwarding.ActionDesc.transmit:type_name -> forwarding.TransmitActionDesc
+	6,  // 2: forwarding.ActionDesc.lookup:type_name -> forwarding.LookupActionDesc
+	7,  // 3: forwarding.ActionDesc.rate:type_name -> forwarding.RateActionDesc
+	9,  // 4: forwarding.ActionDesc.encap:type_name -> forwarding.EncapActionDesc
+	10, // 5: forwarding.ActionDesc.decap:type_name -> forwarding.DecapActionDesc
+	12, // 6: forwarding.ActionDesc.update:type_name -> forwarding.UpdateActionDesc
+	13, // 7: forwarding.ActionDesc.test:type_name -> forwarding.TestActionDesc
+	14, // 8: forwarding.ActionDesc.mirror:type_name -> forwarding.MirrorActionDesc
+	11, // 9: forwarding.ActionDesc.bridge:type_name -> forwarding.BridgeLearnActionDesc
+	15, // 10: forwarding.ActionDesc.flow:type_name -> forwarding.FlowCounterActionDesc
+	16, // 11: forwarding.ActionDesc.reparse:type_name -> forwarding.ReparseActionDesc
+	18, // 12: forwarding.ActionDesc.select:type_name -> forwarding.SelectActionListActionDesc
+	8,  // 13: forwarding.ActionDesc.policer:type_name -> forwarding.PolicerActionDesc
+	19, // 14: forwarding.TransmitActionDesc.port_id:type_name -> forwarding.PortId
+	20, // 15: forwarding.LookupActionDesc.table_id:type_name -> forwarding.TableId
+	1,  // 16: forwarding.PolicerActionDesc.mode:type_name -> forwarding.PolicerMode
+	4,  // 17: forwarding.PolicerActionDesc.green_actions:type_name -> forwarding.ActionDesc
+	4,  // 18: forwarding.PolicerActionDesc.yellow_actions:type_name -> forwarding.ActionDesc
+	4,  // 19: forwarding.PolicerActionDesc.red_actions:type_name -> forwarding.ActionDesc
+	21, // 20: forwarding.EncapActionDesc.header_id:type_name -> forwarding.PacketHeaderId
+	21, // 21: forwarding.DecapActionDesc.header_id:type_name -> forwarding.PacketHeaderId
+	20, // 22: forwarding.BridgeLearnActionDesc.table_id:type_name -> forwarding.TableId
+	22, // 23: forwarding.UpdateActionDesc.field_id:type_name -> forwarding.PacketFieldId
+	2,  // 24: forwarding.UpdateActionDesc.type:type_name -> forwarding.UpdateType
+	22, // 25: forwarding.UpdateActionDesc.field:type_name -> forwarding.PacketFieldId
+	4,  // 26: forwarding.MirrorActionDesc.actions:type_name -> forwarding.ActionDesc
+	19, // 27: forwarding.MirrorActionDesc.port_id:type_name -> forwarding.PortId
+	23, // 28: forwarding.MirrorActionDesc.port_action:type_name -> forwarding.PortAction
+	22, // 29: forwarding.MirrorActionDesc.field_ids:type_name -> forwarding.PacketFieldId
+	24, // 30: forwarding.FlowCounterActionDesc.counter_id:type_name -> forwarding.FlowCounterId
+	21, // 31: forwarding.ReparseActionDesc.header_id:type_name -> forwarding.PacketHeaderId
+	22, // 32: forwarding.ReparseActionDesc.field_ids:type_name -> forwarding.PacketFieldId
+	4,  // 33: forwarding.ActionList.actions:type_name -> forwarding.ActionDesc
+	3,  // 34: forwarding.SelectActionListActionDesc.select_algorithm:type_name -> forwarding.SelectActionListActionDesc.SelectAlgorithm
+	22, // 35: forwarding.SelectActionListActionDesc.field_ids:type_name -> forwarding.PacketFieldId
+	17, // 36: forwarding.SelectActionListActionDesc.action_lists:type_name -> forwarding.ActionList
+	37, // [37:37] is the sub-list for method output_type
+	37, // [37:37] is the sub-list for method input_type
+	37, // [37:37] is the sub-list for extension type_name
+	37, // [37:37] is the sub-list for extension extendee
+	0,  // [0:37] is the sub-list for field type_name
 }
 
 func init() { file_proto_forwarding_forwarding_action_proto_init() }
@@ -1401,14 +1601,15 @@ func file_proto_forwarding_forwarding_action_proto_init() {
 		(*ActionDesc_Flow)(nil),
 		(*ActionDesc_Reparse)(nil),
 		(*ActionDesc_Select)(nil),
+		(*ActionDesc_Policer)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_forwarding_forwarding_action_proto_rawDesc), len(file_proto_forwarding_forwarding_action_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   14,
+			NumEnums:      4,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -74,6 +74,7 @@ const (
 	Prefix_FAMILY_UNSPECIFIED Prefix_Family = 0
 	Prefix_FAMILY_IPV4        Prefix_Family = 1
 	Prefix_FAMILY_IPV6        Prefix_Family = 2
+	Prefix_FAMILY_MPLS        Prefix_Family = 3
 )
 
 // Enum value maps for Prefix_Family.
@@ -82,11 +83,13 @@ var (
 		0: "FAMILY_UNSPECIFIED",
 		1: "FAMILY_IPV4",
 		2: "FAMILY_IPV6",
+		3: "FAMILY_MPLS",
 	}
 	Prefix_Family_value = map[string]int32{
 		"FAMILY_UNSPECIFIED": 0,
 		"FAMILY_IPV4":        1,
 		"FAMILY_IPV6":        2,
+		"FAMILY_MPLS":        3,
 	}
 )
 
@@ -227,8 +230,11 @@ type SetRouteRequest struct {
 	Nexthops        []*Nexthop             `protobuf:"bytes,8,rep,name=nexthops,proto3" json:"nexthops,omitempty"`
 	BackupNexthops  []*Nexthop             `protobuf:"bytes,9,rep,name=backup_nexthops,json=backupNexthops,proto3" json:"backup_nexthops,omitempty"`
 	NetworkInstance string                 `protobuf:"bytes,10,opt,name=network_instance,json=networkInstance,proto3" json:"network_instance,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// pop_labels is the number of labels removed from the top of the label
+	// stack, including the matched label, for FAMILY_MPLS prefixes.
+	PopLabels     uint32 `protobuf:"varint,11,opt,name=pop_labels,json=popLabels,proto3" json:"pop_labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SetRouteRequest) Reset() {
@@ -331,11 +337,21 @@ func (x *SetRouteRequest) GetNetworkInstance() string {
 	return ""
 }
 
+func (x *SetRouteRequest) GetPopLabels() uint32 {
+	if x != nil {
+		return x.PopLabels
+	}
+	return 0
+}
+
 type Prefix struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Family        Prefix_Family          `protobuf:"varint,1,opt,name=family,proto3,enum=sysrib.Prefix_Family" json:"family,omitempty"`
-	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
-	MaskLength    uint32                 `protobuf:"varint,3,opt,name=mask_length,json=maskLength,proto3" json:"mask_length,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Family     Prefix_Family          `protobuf:"varint,1,opt,name=family,proto3,enum=sysrib.Prefix_Family" json:"family,omitempty"`
+	Address    string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	MaskLength uint32                 `protobuf:"varint,3,opt,name=mask_length,json=maskLength,proto3" json:"mask_length,omitempty"`
+	// label is the incoming MPLS label for FAMILY_MPLS prefixes, which have no
+	// address or mask_length.
+	Label         uint32 `protobuf:"varint,4,opt,name=label,proto3" json:"label,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -391,6 +407,13 @@ func (x *Prefix) GetMaskLength() uint32 {
 	return 0
 }
 
+func (x *Prefix) GetLabel() uint32 {
+	if x != nil {
+		return x.Label
+	}
+	return 0
+}
+
 type Nexthop struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	VrfId         uint32                 `protobuf:"varint,1,opt,name=vrf_id,json=vrfId,proto3" json:"vrf_id,omitempty"`
@@ -515,7 +538,7 @@ var File_proto_sysrib_sysrib_proto protoreflect.FileDescriptor
 
 const file_proto_sysrib_sysrib_proto_rawDesc = "" +
 	"\n" +
-	"\x19proto/sysrib/sysrib.proto\x12\x06sysrib\x1a\x1bproto/routing/routing.proto\"\xc0\x03\n" +
+	"\x19proto/sysrib/sysrib.proto\x12\x06sysrib\x1a\x1bproto/routing/routing.proto\"\xdf\x03\n" +
 	"\x0fSetRouteRequest\x12\x16\n" +
 	"\x06delete\x18\x01 \x01(\bR\x06delete\x12\x15\n" +
 	"\x06vrf_id\x18\x02 \x01(\rR\x05vrfId\x12%\n" +
@@ -527,19 +550,23 @@ const file_proto_sysrib_sysrib_proto_rawDesc = "" +
 	"\bnexthops\x18\b \x03(\v2\x0f.sysrib.NexthopR\bnexthops\x128\n" +
 	"\x0fbackup_nexthops\x18\t \x03(\v2\x0f.sysrib.NexthopR\x0ebackupNexthops\x12)\n" +
 	"\x10network_instance\x18\n" +
-	" \x01(\tR\x0fnetworkInstance\".\n" +
+	" \x01(\tR\x0fnetworkInstance\x12\x1d\n" +
+	"\n" +
+	"pop_labels\x18\v \x01(\rR\tpopLabels\".\n" +
 	"\x04Safi\x12\x14\n" +
 	"\x10SAFI_UNSPECIFIED\x10\x00\x12\x10\n" +
-	"\fSAFI_UNICAST\x10\x01\"\xb6\x01\n" +
+	"\fSAFI_UNICAST\x10\x01\"\xdd\x01\n" +
 	"\x06Prefix\x12-\n" +
 	"\x06family\x18\x01 \x01(\x0e2\x15.sysrib.Prefix.FamilyR\x06family\x12\x18\n" +
 	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1f\n" +
 	"\vmask_length\x18\x03 \x01(\rR\n" +
-	"maskLength\"B\n" +
+	"maskLength\x12\x14\n" +
+	"\x05label\x18\x04 \x01(\rR\x05label\"S\n" +
 	"\x06Family\x12\x16\n" +
 	"\x12FAMILY_UNSPECIFIED\x10\x00\x12\x0f\n" +
 	"\vFAMILY_IPV4\x10\x01\x12\x0f\n" +
-	"\vFAMILY_IPV6\x10\x02\"\xe0\x01\n" +
+	"\vFAMILY_IPV6\x10\x02\x12\x0f\n" +
+	"\vFAMILY_MPLS\x10\x03\"\xe0\x01\n" +
 	"\aNexthop\x12\x15\n" +
 	"\x06vrf_id\x18\x01 \x01(\rR\x05vrfId\x12(\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x14.sysrib.Nexthop.TypeR\x04type\x12\x18\n" +
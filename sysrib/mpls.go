@@ -0,0 +1,288 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysrib
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	log "github.com/golang/glog"
+	"github.com/openconfig/gribigo/afthelper"
+	"github.com/openconfig/ygnmi/ygnmi"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/gnmiclient"
+	"github.com/openconfig/lemming/gnmi/oc"
+	"github.com/openconfig/lemming/gnmi/oc/ocpath"
+
+	routingpb "github.com/openconfig/lemming/proto/routing"
+)
+
+// staticLSPProtocol is the protocol of the label routes of the static LSPs.
+const staticLSPProtocol = "STATIC"
+
+// staticLSPHop is a next hop of the transit or egress hop of a static LSP.
+type staticLSPHop struct {
+	address string
+	// push is the label pushed for the next hop, it has the type of one of the push-label unions.
+	push any
+}
+
+// mplsLabel returns the numeric value of an MPLS label union. It returns false if
+// the label is unset or is a label that is never put on the wire (implicit null and no label).
+func mplsLabel(v any) (uint32, bool) {
+	switch v := v.(type) {
+	case oc.UnionUint32:
+		return uint32(v), true
+	case oc.E_MplsTypes_MplsLabel_Enum:
+		switch v {
+		case oc.MplsTypes_MplsLabel_Enum_IPV4_EXPLICIT_NULL:
+			return 0, true
+		case oc.MplsTypes_MplsLabel_Enum_ROUTER_ALERT:
+			return 1, true
+		case oc.MplsTypes_MplsLabel_Enum_IPV6_EXPLICIT_NULL:
+			return 2, true
+		case oc.MplsTypes_MplsLabel_Enum_ENTROPY_LABEL_INDICATOR:
+			return 7, true
+		}
+	}
+	return 0, false
+}
+
+// staticLabelRoute returns the label route that pops the incoming label and forwards to the next hops,
+// swapping the label for the pushed label of each next hop, if any.
+func staticLabelRoute(incoming any, hops []staticLSPHop) (*LabelRoute, error) {
+	label, ok := mplsLabel(incoming)
+	if !ok {
+		return nil, fmt.Errorf("unsupported incoming label %v", incoming)
+	}
+	if len(hops) == 0 {
+		return nil, fmt.Errorf("no next hop for incoming label %d", label)
+	}
+	r := &LabelRoute{
+		Label:     label,
+		Protocol:  staticLSPProtocol,
+		PopLabels: 1,
+		RoutePref: RoutePreference{
+			AdminDistance: 1,
+		},
+	}
+	for _, hop := range hops {
+		nh := &ResolvedNexthop{
+			NextHopSummary: afthelper.NextHopSummary{
+				Weight:          1,
+				Address:         hop.address,
+				NetworkInstance: fakedevice.DefaultNetworkInstance,
+			},
+		}
+		if push, ok := mplsLabel(hop.push); ok {
+			nh.Headers = []*routingpb.Header{{
+				Type:   routingpb.HeaderType_HEADER_TYPE_MPLS,
+				Labels: []uint32{push},
+			}}
+		}
+		r.NextHops = append(r.NextHops, nh)
+	}
+	return r, nil
+}
+
+// convertStaticLSP converts the transit and egress hops of an OC static LSP to sysrib label routes.
+// Ingress hops need IP routes to steer traffic into the LSP and are not supported.
+func convertStaticLSP(lsp *oc.NetworkInstance_Mpls_Lsps_StaticLsp) ([]*LabelRoute, error) {
+	var routes []*LabelRoute
+	if t := lsp.GetTransit(); t != nil && t.IncomingLabel != nil {
+		var hops []staticLSPHop
+		if t.NextHop != nil {
+			hops = append(hops, staticLSPHop{address: t.GetNextHop(), push: t.PushLabel})
+		}
+		for _, idx := range slices.Sorted(maps.Keys(t.LspNextHop)) {
+			if nh := t.LspNextHop[idx]; nh.IpAddress != nil {
+				hops = append(hops, staticLSPHop{address: nh.GetIpAddress(), push: nh.PushLabel})
+			}
+		}
+		r, err := staticLabelRoute(t.IncomingLabel, hops)
+		if err != nil {
+			return nil, fmt.Errorf("transit: %v", err)
+		}
+		routes = append(routes, r)
+	}
+	if e := lsp.GetEgress(); e != nil && e.IncomingLabel != nil {
+		var hops []staticLSPHop
+		if e.NextHop != nil {
+			hops = append(hops, staticLSPHop{address: e.GetNextHop(), push: e.PushLabel})
+		}
+		for _, idx := range slices.Sorted(maps.Keys(e.LspNextHop)) {
+			if nh := e.LspNextHop[idx]; nh.IpAddress != nil {
+				hops = append(hops, staticLSPHop{address: nh.GetIpAddress(), push: nh.PushLabel})
+			}
+		}
+		r, err := staticLabelRoute(e.IncomingLabel, hops)
+		if err != nil {
+			return nil, fmt.Errorf("egress: %v", err)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// monitorStaticLSPs starts a gothread to check for static LSP
+// configuration changes in the default network instance.
+// It returns an error if there is an error before monitoring can begin.
+func (s *Server) monitorStaticLSPs(ctx context.Context, yclient *ygnmi.Client) error {
+	lsps := ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance).Mpls().Lsps()
+
+	staticLSPWatcher := ygnmi.Watch(
+		ctx,
+		yclient,
+		lsps.StaticLspMap().Config(),
+		func(v *ygnmi.Value[map[string]*oc.NetworkInstance_Mpls_Lsps_StaticLsp]) error {
+			lspMap, _ := v.Val()
+			current := map[string][]*LabelRoute{}
+			for name, lsp := range lspMap {
+				routes, err := convertStaticLSP(lsp)
+				if err != nil {
+					log.Warningf("Failed to convert static LSP %q: %v", name, err)
+					continue
+				}
+				current[name] = routes
+			}
+
+			s.staticLSPsMu.Lock()
+			defer s.staticLSPsMu.Unlock()
+			// Only the label routes that changed are updated, the routes of the
+			// other protocols are kept.
+			prev, next := staticLabelRoutes(s.staticLSPs), staticLabelRoutes(current)
+			for label, r := range prev {
+				if _, ok := next[label]; !ok {
+					s.rib.setLabelRoute(r, true)
+				}
+			}
+			for label, r := range next {
+				if !reflect.DeepEqual(prev[label], r) {
+					s.rib.setLabelRoute(r, false)
+				}
+			}
+			for name := range s.staticLSPs {
+				if _, ok := current[name]; !ok {
+					if _, err := gnmiclient.Delete(ctx, yclient, lsps.StaticLsp(name).State()); err != nil {
+						log.Warningf("Failed to delete the state of static LSP %q: %v", name, err)
+					}
+				}
+			}
+			s.staticLSPs = current
+			if err := s.ResolveAndProgramDiff(ctx); err != nil {
+				log.Warningf("Failed to program static LSPs: %v", err)
+				return ygnmi.Continue
+			}
+			for name := range current {
+				if _, err := gnmiclient.Replace(ctx, yclient, lsps.StaticLsp(name).State(), lspMap[name]); err != nil {
+					log.Warningf("Failed to set the state of static LSP %q: %v", name, err)
+				}
+			}
+			return ygnmi.Continue
+		},
+	)
+
+	go func() {
+		if _, err := staticLSPWatcher.Await(); err != nil {
+			log.Warningf("Static LSP watcher has stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// staticLabelRoutes returns the label routes of the static LSPs keyed by incoming label.
+func staticLabelRoutes(lsps map[string][]*LabelRoute) map[uint32]*LabelRoute {
+	routes := map[uint32]*LabelRoute{}
+	for _, name := range slices.Sorted(maps.Keys(lsps)) {
+		for _, r := range lsps[name] {
+			if _, ok := routes[r.Label]; ok {
+				log.Warningf("Incoming label %d of static LSP %q is already used by another static LSP", r.Label, name)
+				continue
+			}
+			routes[r.Label] = r
+		}
+	}
+	return routes
+}
+
+// LSPLabels returns the incoming labels of the label routes of the named static LSP.
+// It returns false if the LSP is not configured.
+func (s *Server) LSPLabels(name string) ([]uint32, bool) {
+	s.staticLSPsMu.Lock()
+	defer s.staticLSPsMu.Unlock()
+	routes, ok := s.staticLSPs[name]
+	if !ok {
+		return nil, false
+	}
+	labels := []uint32{}
+	for _, r := range routes {
+		labels = append(labels, r.Label)
+	}
+	return labels, true
+}
+
+// installedLSPRoutes returns the label routes of the named static LSP that are set in the RIB.
+// The route of an incoming label used by several static LSPs belongs to the first LSP by name,
+// so it is not returned for the others. The caller must hold staticLSPsMu.
+func (s *Server) installedLSPRoutes(name string) []*LabelRoute {
+	owners := map[uint32]string{}
+	for _, n := range slices.Sorted(maps.Keys(s.staticLSPs)) {
+		for _, r := range s.staticLSPs[n] {
+			if _, ok := owners[r.Label]; !ok {
+				owners[r.Label] = n
+			}
+		}
+	}
+	s.rib.mu.RLock()
+	defer s.rib.mu.RUnlock()
+	var routes []*LabelRoute
+	for _, r := range s.staticLSPs[name] {
+		if owners[r.Label] != name {
+			continue
+		}
+		if _, ok := s.rib.Labels[LabelRouteKey{Label: r.Label, Protocol: r.Protocol}]; ok {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
+// ResignalLSP tears down the label routes of the named static LSP and programs them again.
+func (s *Server) ResignalLSP(ctx context.Context, name string) error {
+	s.staticLSPsMu.Lock()
+	defer s.staticLSPsMu.Unlock()
+	if _, ok := s.staticLSPs[name]; !ok {
+		return fmt.Errorf("static LSP %q not found", name)
+	}
+	routes := s.installedLSPRoutes(name)
+	for _, r := range routes {
+		s.rib.setLabelRoute(r, true)
+	}
+	if err := s.ResolveAndProgramDiff(ctx); err != nil {
+		return fmt.Errorf("error while tearing down LSP %q: %v", name, err)
+	}
+	for _, r := range routes {
+		s.rib.setLabelRoute(r, false)
+	}
+	if err := s.ResolveAndProgramDiff(ctx); err != nil {
+		return fmt.Errorf("error while signaling LSP %q: %v", name, err)
+	}
+	log.Infof("sysrib: resignaled static LSP %q", name)
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sysrib
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gribigo/afthelper"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/protobuf/testing/protocmp"
+
+	"github.com/openconfig/lemming/gnmi/fakedevice"
+	"github.com/openconfig/lemming/gnmi/oc"
+
+	routingpb "github.com/openconfig/lemming/proto/routing"
+)
+
+func TestConvertStaticLSP(t *testing.T) {
+	tests := []struct {
+		desc    string
+		lsp     *oc.NetworkInstance_Mpls_Lsps_StaticLsp
+		want    []*LabelRoute
+		wantErr bool
+	}{{
+		desc: "ingress only",
+		lsp: &oc.NetworkInstance_Mpls_Lsps_StaticLsp{
+			Name: ygot.String("lsp"),
+			Ingress: &oc.NetworkInstance_Mpls_Lsps_StaticLsp_Ingress{
+				NextHop:   ygot.String("192.0.2.1"),
+				PushLabel: oc.UnionUint32(100),
+			},
+		},
+	}, {
+		desc: "transit swap",
+		lsp: &oc.NetworkInstance_Mpls_Lsps_StaticLsp{
+			Name: ygot.String("lsp"),
+			Transit: &oc.NetworkInstance_Mpls_Lsps_StaticLsp_Transit{
+				IncomingLabel: oc.UnionUint32(100),
+				NextHop:       ygot.String("192.0.2.1"),
+				PushLabel:     oc.UnionUint32(200),
+			},
+		},
+		want: []*LabelRoute{{
+			Label:     100,
+			Protocol:  staticLSPProtocol,
+			PopLabels: 1,
+			NextHops: []*ResolvedNexthop{{
+				NextHopSummary: afthelper.NextHopSummary{
+					Weight:          1,
+					Address:         "192.0.2.1",
+					NetworkInstance: fakedevice.DefaultNetworkInstance,
+				},
+				Headers: []*routingpb.Header{{
+					Type:   routingpb.HeaderType_HEADER_TYPE_MPLS,
+					Labels: []uint32{200},
+				}},
+			}},
+			RoutePref: RoutePreference{AdminDistance: 1},
+		}},
+	}, {
+		desc: "egress pop with implicit null",
+		lsp: &oc.NetworkInstance_Mpls_Lsps_StaticLsp{
+			Name: ygot.String("lsp"),
+			Egress: &oc.NetworkInstance_Mpls_Lsps_StaticLsp_Egress{
+				IncomingLabel: oc.UnionUint32(300),
+				PushLabel:     oc.MplsTypes_MplsLabel_Enum_IMPLICIT_NULL,
+				LspNextHop: map[uint32]*oc.NetworkInstance_Mpls_Lsps_StaticLsp_Egress_LspNextHop{
+					2: {Index: ygot.Uint32(2), IpAddress: ygot.String("192.0.2.3")},
+					1: {Index: ygot.Uint32(1), IpAddress: ygot.String("192.0.2.2")},
+				},
+			},
+		},
+		want: []*LabelRoute{{
+			Label:     300,
+			Protocol:  staticLSPProtocol,
+			PopLabels: 1,
+			NextHops: []*ResolvedNexthop{{
+				NextHopSummary: afthelper.NextHopSummary{
+					Weight:          1,
+					Address:         "192.0.2.2",
+					NetworkInstance: fakedevice.DefaultNetworkInstance,
+				},
+			}, {
+				NextHopSummary: afthelper.NextHopSummary{
+					Weight:          1,
+					Address:         "192.0.2.3",
+					NetworkInstance: fakedevice.DefaultNetworkInstance,
+				},
+			}},
+			RoutePref: RoutePreference{AdminDistance: 1},
+		}},
+	}, {
+		desc: "transit without next hop",
+		lsp: &oc.NetworkInstance_Mpls_Lsps_StaticLsp{
+			Name: ygot.String("lsp"),
+			Transit: &oc.NetworkInstance_Mpls_Lsps_StaticLsp_Transit{
+				IncomingLabel: oc.UnionUint32(100),
+			},
+		},
+		wantErr: true,
+	}, {
+		desc: "reserved incoming label",
+		lsp: &oc.NetworkInstance_Mpls_Lsps_StaticLsp{
+			Name: ygot.String("lsp"),
+			Egress: &oc.NetworkInstance_Mpls_Lsps_StaticLsp_Egress{
+				IncomingLabel: oc.MplsTypes_MplsLabel_Enum_NO_LABEL,
+				NextHop:       ygot.String("192.0.2.1"),
+			},
+		},
+		wantErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := convertStaticLSP(tt.lsp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("convertStaticLSP() got err %v, want err %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, protocmp.Transform()); diff != "" {
+				t.Errorf("convertStaticLSP() (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
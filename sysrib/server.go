@@ -23,6 +23,7 @@ import (
 	"net/netip"
 	"os"
 	"reflect"
+	"slices"
 	"strconv"
 	"sync"
 
@@ -34,6 +35,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/openconfig/lemming/bfd"
 	"github.com/openconfig/lemming/dataplane/dplanerc"
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/oc"
@@ -90,6 +92,29 @@ type Server struct {
 	// have been resolved.
 	resolvedRoutes map[RouteKey]*Route
 
+	programmedLabelRoutesMu sync.Mutex
+	// programmedLabelRoutes contain a map of resolved MPLS routes, keyed
+	// by incoming label, with which to do diff for sending to the
+	// dataplane for programming.
+	programmedLabelRoutes map[uint32]*ResolvedLabelRoute
+
+	staticLSPsMu sync.Mutex
+	// staticLSPs contains the label routes of each configured static
+	// LSP, keyed by LSP name.
+	staticLSPs map[string][]*LabelRoute
+
+	staticMu sync.Mutex
+	// staticRoutes contains the configured static routes, keyed by prefix.
+	staticRoutes map[string]*oc.NetworkInstance_Protocol_Static
+	// staticPrefixes contains the prefixes of the static routes whose state
+	// is published, and whether they are set in the RIB.
+	staticPrefixes map[string]bool
+	// staticBFD contains the BFD sessions of the static next hops.
+	staticBFD map[bfd.Key]bfd.Params
+
+	// bfd runs the BFD sessions, nil if BFD is not enabled.
+	bfd *bfd.Server
+
 	dataplane dplane
 
 	zServer *ZServer
@@ -123,6 +148,20 @@ func (d *dplane) deprogramRoute(ctx context.Context, r *ResolvedRoute) error {
 	return err
 }
 
+// programLabelRoute programs the MPLS route in the dataplane, returning an error on failure.
+func (d *dplane) programLabelRoute(ctx context.Context, r *ResolvedLabelRoute) error {
+	log.V(1).Infof("sysrib: programming resolved label route: %+v", r)
+	_, err := ygnmi.Replace(ctx, d.Client, dplanerc.LabelRouteQuery(r.Label), resolvedLabelRouteToRequest(r), ygnmi.WithSetFallbackEncoding())
+	return err
+}
+
+// deprogramLabelRoute de-programs the MPLS route in the dataplane, returning an error on failure.
+func (d *dplane) deprogramLabelRoute(ctx context.Context, r *ResolvedLabelRoute) error {
+	log.V(1).Infof("sysrib: deprogramming newly unresolved label route: %+v", r)
+	_, err := ygnmi.Delete(ctx, d.Client, dplanerc.LabelRouteQuery(r.Label))
+	return err
+}
+
 // New instantiates server to handle client queries.
 //
 // If dp is nil, then a connection attempt is made.
@@ -138,6 +177,11 @@ func New(cfg *oc.Root) (*Server, error) {
 		bgpGUEPolicies:   map[string]GUEPolicy{},
 		programmedRoutes: map[RouteKey]*ResolvedRoute{},
 		resolvedRoutes:   map[RouteKey]*Route{},
+
+		programmedLabelRoutes: map[uint32]*ResolvedLabelRoute{},
+		staticLSPs:            map[string][]*LabelRoute{},
+		staticPrefixes:        map[string]bool{},
+		staticBFD:             map[bfd.Key]bfd.Params{},
 	}
 	return s, nil
 }
@@ -170,6 +214,10 @@ func (s *Server) Start(ctx context.Context, gClient gpb.GNMIClient, target, zapi
 		return err
 	}
 
+	if err := s.monitorStaticLSPs(ctx, yclient); err != nil {
+		return err
+	}
+
 	if err := os.RemoveAll(sysribAddr); err != nil {
 		return err
 	}
@@ -322,6 +370,15 @@ type ResolvedRoute struct {
 	// TODO(wenbli): backup nexthops.
 }
 
+// ResolvedLabelRoute represents an MPLS route that is ready to be programmed into the forwarding plane.
+type ResolvedLabelRoute struct {
+	Label     uint32
+	PopLabels uint32
+	Nexthops  []*ResolvedNexthop
+	// Protocol is the routing protocol of the route.
+	Protocol string
+}
+
 // ResolvedNexthop contains the information required to forward an IP packet.
 //
 // This type must be hashable, and uniquely identifies nexthops.
@@ -453,6 +510,31 @@ func resolvedRouteToRouteRequest(r *ResolvedRoute) (*dpb.Route, error) {
 	}, nil
 }
 
+func resolvedLabelRouteToRequest(r *ResolvedLabelRoute) *dpb.LabelRoute {
+	nexthops := &dpb.NextHopList{}
+	for _, nh := range r.Nexthops {
+		dnh := &dpb.NextHop{
+			Interface: &dpb.OCInterface{
+				Interface:    nh.Port.Name,
+				Subinterface: nh.Port.Subinterface,
+			},
+			NextHopIp: nh.Address,
+		}
+		if len(nh.Headers) > 0 {
+			dnh.Encap = &dpb.NextHop_Headers{Headers: &routingpb.Headers{Headers: nh.Headers}}
+		}
+		nexthops.Hops = append(nexthops.Hops, dnh)
+		nexthops.Weights = append(nexthops.Weights, nh.Weight)
+	}
+	return &dpb.LabelRoute{
+		Label:          r.Label,
+		PopLabels:      r.PopLabels,
+		Action:         dpb.PacketAction_PACKET_ACTION_FORWARD,
+		NextHops:       nexthops,
+		SegmentRouting: r.Protocol == staticLSPProtocol,
+	}
+}
+
 // ResolveAndProgramDiff walks through each prefix in the RIB, resolving it and
 // programs the forwarding plane.
 func (s *Server) ResolveAndProgramDiff(ctx context.Context) error {
@@ -473,6 +555,7 @@ func (s *Server) ResolveAndProgramDiff(ctx context.Context) error {
 			s.resolveAndProgramDiffAux(ctx, niName, ni, it.Address().String(), newResolvedRoutes)
 		}
 	}
+	s.resolveAndProgramLabelDiff(ctx)
 
 	s.resolvedRoutesMu.Lock()
 	defer s.resolvedRoutesMu.Unlock()
@@ -558,6 +641,89 @@ func (s *Server) resolveAndProgramDiffAux(ctx context.Context, niName string, ni
 	}
 }
 
+// resolveLabelRoute resolves the nexthops of the MPLS route, it returns nil if
+// none of them is resolvable.
+//
+// NOTE: s.rib.mu.RLock() must be called prior to calling this function.
+func (s *Server) resolveLabelRoute(r *LabelRoute) *ResolvedLabelRoute {
+	rr := &ResolvedLabelRoute{
+		Label:     r.Label,
+		PopLabels: r.PopLabels,
+		Protocol:  r.Protocol,
+	}
+	for _, nh := range r.NextHops {
+		nhop, err := addressToPrefix(nh.Address)
+		if err != nil {
+			log.Errorf("sysrib: %v", err)
+			continue
+		}
+		s.interfacesMu.Lock()
+		nhs, _, err := s.rib.egressNexthops(nh.NetworkInstance, nhop, s.interfaces)
+		s.interfacesMu.Unlock()
+		if err != nil {
+			log.Errorf("sysrib: %v", err)
+			continue
+		}
+		for _, rnh := range nhs {
+			rnh.Headers = slices.Concat(nh.Headers, rnh.Headers)
+			rnh.Weight = nh.Weight
+			rr.Nexthops = append(rr.Nexthops, rnh)
+		}
+	}
+	if len(rr.Nexthops) == 0 {
+		return nil
+	}
+	return rr
+}
+
+// resolveAndProgramLabelDiff resolves the nexthops of the MPLS routes,
+// programming the most preferred resolvable route of each label when it has
+// changed and deprogramming the labels that are no longer resolvable.
+//
+// NOTE: s.rib.mu.RLock() must be called prior to calling this function.
+func (s *Server) resolveAndProgramLabelDiff(ctx context.Context) {
+	resolved := map[uint32]bool{}
+	for label, routes := range s.rib.labelRoutes() {
+		var rr *ResolvedLabelRoute
+		for _, r := range routes {
+			if rr = s.resolveLabelRoute(r); rr != nil {
+				break
+			}
+		}
+		if rr == nil {
+			continue
+		}
+		resolved[label] = true
+
+		s.programmedLabelRoutesMu.Lock()
+		currentRoute := s.programmedLabelRoutes[label]
+		s.programmedLabelRoutesMu.Unlock()
+		if reflect.DeepEqual(currentRoute, rr) {
+			continue
+		}
+		if err := s.dataplane.programLabelRoute(ctx, rr); err != nil {
+			log.Warningf("failed to program label route %+v: %v", rr, err)
+			continue
+		}
+		s.programmedLabelRoutesMu.Lock()
+		s.programmedLabelRoutes[label] = rr
+		s.programmedLabelRoutesMu.Unlock()
+	}
+
+	for label, rr := range s.ProgrammedLabelRoutes() {
+		if resolved[label] {
+			continue
+		}
+		if err := s.dataplane.deprogramLabelRoute(ctx, rr); err != nil {
+			log.Warningf("failed to deprogram label route %+v: %v", rr, err)
+			continue
+		}
+		s.programmedLabelRoutesMu.Lock()
+		delete(s.programmedLabelRoutes, label)
+		s.programmedLabelRoutesMu.Unlock()
+	}
+}
+
 // ResolvedRoutes returns the shallow copy of the resolved routes of the RIB
 // manager.
 func (s *Server) ResolvedRoutes() map[RouteKey]*Route {
@@ -574,13 +740,48 @@ func (s *Server) ProgrammedRoutes() map[RouteKey]*ResolvedRoute {
 	return maps.Clone(s.programmedRoutes)
 }
 
-// SetRoute implements ROUTE_ADD and ROUTE_DELETE
-func (s *Server) SetRoute(ctx context.Context, req *sysribpb.SetRouteRequest) (*sysribpb.SetRouteResponse, error) {
-	pfx, err := prefixString(req.Prefix)
-	if err != nil {
-		return nil, err
+// ProgrammedLabelRoutes returns the shallow copy of the programmed MPLS routes
+// of the RIB manager.
+func (s *Server) ProgrammedLabelRoutes() map[uint32]*ResolvedLabelRoute {
+	s.programmedLabelRoutesMu.Lock()
+	defer s.programmedLabelRoutesMu.Unlock()
+	return maps.Clone(s.programmedLabelRoutes)
+}
+
+// NextHops returns the next-hop addresses of the programmed route with the
+// longest prefix that contains the address in the network instance. The next
+// hops of connected routes have no address, so none are returned for them.
+// It returns false if no route contains the address.
+func (s *Server) NextHops(niName string, addr netip.Addr) ([]netip.Addr, bool) {
+	s.programmedRoutesMu.Lock()
+	defer s.programmedRoutesMu.Unlock()
+	var best *ResolvedRoute
+	bestLen := -1
+	for key, route := range s.programmedRoutes {
+		if key.NIName != niName {
+			continue
+		}
+		pfx, err := netip.ParsePrefix(key.Prefix)
+		if err != nil || !pfx.Contains(addr) || pfx.Bits() <= bestLen {
+			continue
+		}
+		best, bestLen = route, pfx.Bits()
+	}
+	if best == nil {
+		return nil, false
+	}
+	var nhs []netip.Addr
+	for _, nh := range best.Nexthops {
+		if nhAddr, err := netip.ParseAddr(nh.Address); err == nil && nhAddr != addr {
+			nhs = append(nhs, nhAddr)
+		}
 	}
+	slices.SortFunc(nhs, netip.Addr.Compare)
+	return slices.Compact(nhs), true
+}
 
+// SetRoute implements ROUTE_ADD and ROUTE_DELETE
+func (s *Server) SetRoute(ctx context.Context, req *sysribpb.SetRouteRequest) (*sysribpb.SetRouteResponse, error) {
 	nexthops := []*ResolvedNexthop{}
 	for _, nh := range req.GetNexthops() {
 		if nh.GetType() != sysribpb.Nexthop_TYPE_IPV4 && nh.GetType() != sysribpb.Nexthop_TYPE_IPV6 {
@@ -600,6 +801,14 @@ func (s *Server) SetRoute(ctx context.Context, req *sysribpb.SetRouteRequest) (*
 	if niName == "" {
 		niName = vrfIDToNiName(req.GetVrfId())
 	}
+	if req.GetPrefix().GetFamily() == sysribpb.Prefix_FAMILY_MPLS {
+		return s.setLabelRoute(ctx, niName, req, nexthops)
+	}
+
+	pfx, err := prefixString(req.Prefix)
+	if err != nil {
+		return nil, err
+	}
 	if err := s.setRoute(ctx, niName, &Route{
 		Prefix:   pfx,
 		NextHops: nexthops,
@@ -623,6 +832,30 @@ func (s *Server) SetRoute(ctx context.Context, req *sysribpb.SetRouteRequest) (*
 	}, nil
 }
 
+// Reset removes all routes learned from routing protocols and all MPLS
+// routes, and deprograms them from the dataplane. Connected routes are kept
+// since they are derived from the state of the interfaces.
+// The static routes and LSPs are forgotten and the BFD sessions of the
+// static next hops are removed.
+func (s *Server) Reset(ctx context.Context) error {
+	s.staticLSPsMu.Lock()
+	s.staticLSPs = map[string][]*LabelRoute{}
+	s.staticLSPsMu.Unlock()
+
+	s.staticMu.Lock()
+	s.staticRoutes = nil
+	s.staticPrefixes = map[string]bool{}
+	s.syncStaticBFD()
+	s.staticMu.Unlock()
+
+	s.rib.removeProtocolRoutes()
+	if err := s.ResolveAndProgramDiff(ctx); err != nil {
+		return fmt.Errorf("error while resolving sysrib: %v", err)
+	}
+	log.Info("sysrib reset")
+	return nil
+}
+
 // setRoute adds/deletes a route from the RIB manager.
 func (s *Server) setRoute(ctx context.Context, niName string, route *Route, isDelete bool) error {
 	if err := s.rib.setRoute(niName, route, isDelete); err != nil {
@@ -635,6 +868,37 @@ func (s *Server) setRoute(ctx context.Context, niName string, route *Route, isDe
 	return nil
 }
 
+// setLabelRoute adds/deletes an MPLS route from the RIB manager.
+func (s *Server) setLabelRoute(ctx context.Context, niName string, req *sysribpb.SetRouteRequest, nexthops []*ResolvedNexthop) (*sysribpb.SetRouteResponse, error) {
+	if niName != s.rib.defaultNI {
+		return nil, status.Errorf(codes.Unimplemented, "MPLS routes are only supported in the default network instance, got %q", niName)
+	}
+	label := req.GetPrefix().GetLabel()
+	s.rib.setLabelRoute(&LabelRoute{
+		Label:     label,
+		Protocol:  req.GetProtocolName(),
+		PopLabels: req.GetPopLabels(),
+		NextHops:  nexthops,
+		RoutePref: RoutePreference{
+			AdminDistance: uint8(req.GetAdminDistance()),
+			Metric:        req.GetMetric(),
+		},
+	}, req.Delete)
+	if err := s.ResolveAndProgramDiff(ctx); err != nil {
+		return nil, status.Errorf(codes.Aborted, "error while resolving sysrib: %v", err)
+	}
+
+	status := sysribpb.SetRouteResponse_STATUS_FAIL
+	s.programmedLabelRoutesMu.Lock()
+	if _, ok := s.programmedLabelRoutes[label]; ok {
+		status = sysribpb.SetRouteResponse_STATUS_SUCCESS
+	}
+	s.programmedLabelRoutesMu.Unlock()
+	return &sysribpb.SetRouteResponse{
+		Status: status,
+	}, nil
+}
+
 type connectedRoute struct {
 	name    string
 	ifindex int32
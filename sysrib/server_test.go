@@ -16,6 +16,7 @@ package sysrib
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gribigo/afthelper"
 	"github.com/openconfig/ygnmi/schemaless"
 	"github.com/openconfig/ygnmi/ygnmi"
 	"github.com/openconfig/ygot/ygot"
@@ -2392,3 +2394,279 @@ func TestBGPGUEPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestLabelRoute(t *testing.T) {
+	labelRoutesQuery, err := schemaless.NewWildcard[*dpb.LabelRoute]("/dataplane/label-routes/route[label=*]", gnmi.InternalOrigin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inInterfaces, _ := getConnectedIntfSetupVarsV4()
+
+	tests := []struct {
+		desc       string
+		inReq      *pb.SetRouteRequest
+		wantErr    bool
+		wantStatus pb.SetRouteResponse_Status
+		wantRoutes []*dpb.LabelRoute
+	}{{
+		desc: "swap",
+		inReq: &pb.SetRouteRequest{
+			AdminDistance: 5,
+			Prefix: &pb.Prefix{
+				Family: pb.Prefix_FAMILY_MPLS,
+				Label:  100,
+			},
+			PopLabels: 1,
+			Nexthops: []*pb.Nexthop{{
+				Type:    pb.Nexthop_TYPE_IPV4,
+				Address: "192.168.1.42",
+				Weight:  1,
+				Encap: &routing.Headers{
+					Headers: []*routing.Header{{
+						Type:   routing.HeaderType_HEADER_TYPE_MPLS,
+						Labels: []uint32{200},
+					}},
+				},
+			}},
+		},
+		wantStatus: pb.SetRouteResponse_STATUS_SUCCESS,
+		wantRoutes: []*dpb.LabelRoute{{
+			Label:     100,
+			PopLabels: 1,
+			Action:    dpb.PacketAction_PACKET_ACTION_FORWARD,
+			NextHops: &dpb.NextHopList{
+				Weights: []uint64{1},
+				Hops: []*dpb.NextHop{{
+					NextHopIp: "192.168.1.42",
+					Interface: &dpb.OCInterface{
+						Interface: "eth0",
+					},
+					Encap: &dpb.NextHop_Headers{
+						Headers: &routing.Headers{
+							Headers: []*routing.Header{{
+								Type:   routing.HeaderType_HEADER_TYPE_MPLS,
+								Labels: []uint32{200},
+							}},
+						},
+					},
+				}},
+			},
+		}},
+	}, {
+		desc: "pop",
+		inReq: &pb.SetRouteRequest{
+			AdminDistance: 5,
+			Prefix: &pb.Prefix{
+				Family: pb.Prefix_FAMILY_MPLS,
+				Label:  101,
+			},
+			PopLabels: 1,
+			Nexthops: []*pb.Nexthop{{
+				Type:    pb.Nexthop_TYPE_IPV4,
+				Address: "192.168.2.42",
+			}},
+		},
+		wantStatus: pb.SetRouteResponse_STATUS_SUCCESS,
+		wantRoutes: []*dpb.LabelRoute{{
+			Label:     101,
+			PopLabels: 1,
+			Action:    dpb.PacketAction_PACKET_ACTION_FORWARD,
+			NextHops: &dpb.NextHopList{
+				Weights: []uint64{0},
+				Hops: []*dpb.NextHop{{
+					NextHopIp: "192.168.2.42",
+					Interface: &dpb.OCInterface{
+						Interface: "eth1",
+					},
+				}},
+			},
+		}},
+	}, {
+		desc: "static lsp",
+		inReq: &pb.SetRouteRequest{
+			AdminDistance: 1,
+			ProtocolName:  staticLSPProtocol,
+			Prefix: &pb.Prefix{
+				Family: pb.Prefix_FAMILY_MPLS,
+				Label:  104,
+			},
+			PopLabels: 1,
+			Nexthops: []*pb.Nexthop{{
+				Type:    pb.Nexthop_TYPE_IPV4,
+				Address: "192.168.2.42",
+			}},
+		},
+		wantStatus: pb.SetRouteResponse_STATUS_SUCCESS,
+		wantRoutes: []*dpb.LabelRoute{{
+			Label:     104,
+			PopLabels: 1,
+			Action:    dpb.PacketAction_PACKET_ACTION_FORWARD,
+			NextHops: &dpb.NextHopList{
+				Weights: []uint64{0},
+				Hops: []*dpb.NextHop{{
+					NextHopIp: "192.168.2.42",
+					Interface: &dpb.OCInterface{
+						Interface: "eth1",
+					},
+				}},
+			},
+			SegmentRouting: true,
+		}},
+	}, {
+		desc: "unresolvable",
+		inReq: &pb.SetRouteRequest{
+			AdminDistance: 5,
+			Prefix: &pb.Prefix{
+				Family: pb.Prefix_FAMILY_MPLS,
+				Label:  102,
+			},
+			PopLabels: 1,
+			Nexthops: []*pb.Nexthop{{
+				Type:    pb.Nexthop_TYPE_IPV4,
+				Address: "10.10.10.10",
+			}},
+		},
+		wantStatus: pb.SetRouteResponse_STATUS_FAIL,
+	}, {
+		desc: "non-default network instance",
+		inReq: &pb.SetRouteRequest{
+			AdminDistance: 5,
+			Prefix: &pb.Prefix{
+				Family: pb.Prefix_FAMILY_MPLS,
+				Label:  103,
+			},
+			NetworkInstance: "foo",
+		},
+		wantErr: true,
+	}}
+
+	grpcServer := grpc.NewServer()
+	gnmiServer, err := gnmi.New(grpcServer, "local", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("Failed to start listener: %v", err)
+	}
+	go func() {
+		grpcServer.Serve(lis)
+	}()
+
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := gnmiServer.LocalClient()
+	if err := s.Start(context.Background(), client, "local", "", "/tmp/sysrib.api"); err != nil {
+		t.Fatalf("cannot start sysrib server, %v", err)
+	}
+	defer s.Stop()
+
+	c, err := ygnmi.NewClient(client, ygnmi.WithTarget("local"))
+	if err != nil {
+		t.Fatalf("cannot create ygnmi client: %v", err)
+	}
+	for _, intf := range inInterfaces {
+		configureInterface(t, intf, c)
+	}
+
+	// Wait for Sysrib to pick up the connected prefixes.
+	routesQuery := programmedRoutesQuery(t)
+	for i := 0; i != maxGNMIWaitQuanta; i++ {
+		if routes, err := ygnmi.GetAll(context.Background(), c, routesQuery); err == nil && len(routes) == 5 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resp, err := s.SetRoute(context.Background(), tt.inReq)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error during call to SetRoute: %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := resp.GetStatus(); got != tt.wantStatus {
+				t.Errorf("SetRoute() got status %v, want %v", got, tt.wantStatus)
+			}
+
+			routes, err := ygnmi.GetAll(context.Background(), c, labelRoutesQuery)
+			if err != nil && !errors.Is(err, ygnmi.ErrNotPresent) {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantRoutes, routes, protocmp.Transform(), cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("label routes not equal to wantRoutes (-want, +got):\n%s", diff)
+			}
+
+			// Clean-up
+			tt.inReq.Delete = true
+			if _, err := s.SetRoute(context.Background(), tt.inReq); err != nil {
+				t.Fatalf("got error during call to SetRoute: %v", err)
+			}
+			if got := s.ProgrammedLabelRoutes(); len(got) != 0 {
+				t.Errorf("label routes not deprogrammed after delete: %v", got)
+			}
+		})
+	}
+}
+
+func TestNextHops(t *testing.T) {
+	nh := func(addr string) *ResolvedNexthop {
+		return &ResolvedNexthop{NextHopSummary: afthelper.NextHopSummary{Address: addr}}
+	}
+	s := &Server{programmedRoutes: map[RouteKey]*ResolvedRoute{
+		{Prefix: "192.168.0.0/24", NIName: "DEFAULT"}: {Nexthops: []*ResolvedNexthop{{}}},
+		{Prefix: "10.0.0.0/8", NIName: "DEFAULT"}:     {Nexthops: []*ResolvedNexthop{nh("192.168.0.2"), nh("192.168.0.1"), nh("192.168.0.2")}},
+		{Prefix: "10.1.0.0/16", NIName: "DEFAULT"}:    {Nexthops: []*ResolvedNexthop{nh("192.168.0.3")}},
+		{Prefix: "10.1.0.0/16", NIName: "vrf-a"}:      {Nexthops: []*ResolvedNexthop{nh("192.168.0.4")}},
+	}}
+	tests := []struct {
+		desc   string
+		niName string
+		addr   string
+		want   []netip.Addr
+		wantOk bool
+	}{{
+		desc:   "connected",
+		niName: "DEFAULT",
+		addr:   "192.168.0.1",
+		wantOk: true,
+	}, {
+		desc:   "ecmp",
+		niName: "DEFAULT",
+		addr:   "10.2.0.1",
+		want:   []netip.Addr{netip.MustParseAddr("192.168.0.1"), netip.MustParseAddr("192.168.0.2")},
+		wantOk: true,
+	}, {
+		desc:   "longest prefix",
+		niName: "DEFAULT",
+		addr:   "10.1.0.1",
+		want:   []netip.Addr{netip.MustParseAddr("192.168.0.3")},
+		wantOk: true,
+	}, {
+		desc:   "network instance",
+		niName: "vrf-a",
+		addr:   "10.1.0.1",
+		want:   []netip.Addr{netip.MustParseAddr("192.168.0.4")},
+		wantOk: true,
+	}, {
+		desc:   "no route",
+		niName: "DEFAULT",
+		addr:   "172.16.0.1",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, ok := s.NextHops(tt.niName, netip.MustParseAddr(tt.addr))
+			if ok != tt.wantOk {
+				t.Fatalf("NextHops() got ok %v, want %v", ok, tt.wantOk)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b netip.Addr) bool { return a == b })); diff != "" {
+				t.Errorf("NextHops() (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,24 +16,34 @@ package sysrib
 
 import (
 	"context"
+	"net/netip"
+	"time"
 
 	log "github.com/golang/glog"
 	"github.com/openconfig/gribigo/afthelper"
 	"github.com/openconfig/ygnmi/ygnmi"
 
+	"github.com/openconfig/lemming/bfd"
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/gnmiclient"
 	"github.com/openconfig/lemming/gnmi/oc"
 	"github.com/openconfig/lemming/gnmi/oc/ocpath"
 )
 
-// convertStaticRoute converts an OC static route to a sysrib Route
-func convertStaticRoute(prefix string, sroute *oc.NetworkInstance_Protocol_Static) *Route {
+// staticBFDOwner is the owner of the BFD sessions of the static next hops.
+const staticBFDOwner = "static"
+
+// convertStaticRoute converts an OC static route to a sysrib Route, skipping
+// the next hops for which down returns true.
+func convertStaticRoute(prefix string, sroute *oc.NetworkInstance_Protocol_Static, down func(*oc.NetworkInstance_Protocol_Static_NextHop) bool) *Route {
 	var nexthops []*ResolvedNexthop
 	if sroute != nil {
 		for _, snh := range sroute.NextHop {
 			// TODO(wenbli): Implement recurse option.
 			snh.SetRecurse(true)
+			if down != nil && down(snh) {
+				continue
+			}
 			switch nh := snh.NextHop.(type) {
 			case nil:
 			case oc.UnionString:
@@ -58,6 +68,114 @@ func convertStaticRoute(prefix string, sroute *oc.NetworkInstance_Protocol_Stati
 	}
 }
 
+// staticBFDKey returns the key of the BFD session of the static next hop, and
+// whether BFD is enabled for it.
+func staticBFDKey(snh *oc.NetworkInstance_Protocol_Static_NextHop) (bfd.Key, bool) {
+	if !snh.GetEnableBfd().GetEnabled() {
+		return bfd.Key{}, false
+	}
+	nh, ok := snh.NextHop.(oc.UnionString)
+	if !ok {
+		return bfd.Key{}, false
+	}
+	addr, err := netip.ParseAddr(string(nh))
+	if err != nil {
+		return bfd.Key{}, false
+	}
+	return bfd.Key{Peer: addr}, true
+}
+
+// EnableBFD enables the BFD sessions of the static next hops configured with
+// enable-bfd, the next hops are withdrawn while their session is down.
+// It must be called before Start.
+func (s *Server) EnableBFD(b *bfd.Server) {
+	s.bfd = b
+}
+
+// staticNextHopDown returns whether the BFD session of the static next hop is down.
+func (s *Server) staticNextHopDown(snh *oc.NetworkInstance_Protocol_Static_NextHop) bool {
+	key, ok := staticBFDKey(snh)
+	return ok && s.bfd != nil && !s.bfd.Up(key)
+}
+
+// syncStaticBFD adds the BFD sessions of the configured static next hops and
+// removes the ones that are no longer configured. The caller must hold staticMu.
+func (s *Server) syncStaticBFD() {
+	if s.bfd == nil {
+		return
+	}
+	sessions := map[bfd.Key]bfd.Params{}
+	for _, sroute := range s.staticRoutes {
+		for _, snh := range sroute.NextHop {
+			key, ok := staticBFDKey(snh)
+			if !ok {
+				continue
+			}
+			c := snh.GetEnableBfd()
+			sessions[key] = bfd.Params{
+				DesiredMinTx:     time.Duration(c.GetDesiredMinimumTxInterval()) * time.Microsecond,
+				RequiredMinRx:    time.Duration(c.GetRequiredMinimumReceive()) * time.Microsecond,
+				DetectMultiplier: c.GetDetectionMultiplier(),
+			}
+		}
+	}
+	for key, p := range sessions {
+		if err := s.bfd.AddSession(staticBFDOwner, key, p); err != nil {
+			log.Warningf("BFD session %v of static next hop stays down: %v", key, err)
+		}
+	}
+	for key := range s.staticBFD {
+		if _, ok := sessions[key]; !ok {
+			s.bfd.RemoveSession(staticBFDOwner, key)
+		}
+	}
+	s.staticBFD = sessions
+}
+
+// programStaticRoutes sets the configured static routes in the RIB, and
+// deletes the routes that are no longer configured or whose next hops are all
+// down. The caller must hold staticMu.
+func (s *Server) programStaticRoutes(ctx context.Context, yclient *ygnmi.Client) {
+	staticroot := ocpath.Root().NetworkInstance(fakedevice.DefaultNetworkInstance).Protocol(oc.PolicyTypes_INSTALL_PROTOCOL_TYPE_STATIC, fakedevice.StaticRoutingProtocol)
+	current := map[string]bool{}
+	for prefix, sroute := range s.staticRoutes {
+		if sroute == nil || sroute.Prefix == nil {
+			continue
+		}
+		route := convertStaticRoute(prefix, sroute, s.staticNextHopDown)
+		if len(route.NextHops) == 0 && len(sroute.NextHop) != 0 {
+			// All the next hops are down, the route is withdrawn until one of them comes up.
+			current[prefix] = false
+			if s.staticPrefixes[prefix] {
+				if err := s.setRoute(ctx, fakedevice.DefaultNetworkInstance, route, true); err != nil {
+					log.Warningf("Failed to withdraw static route: %v", err)
+				}
+			}
+			gnmiclient.Replace(ctx, yclient, staticroot.Static(sroute.GetPrefix()).State(), sroute)
+			continue
+		}
+		if err := s.setRoute(ctx, fakedevice.DefaultNetworkInstance, route, false); err != nil {
+			log.Warningf("Failed to add static route: %v", err)
+		} else {
+			current[prefix] = true
+			gnmiclient.Replace(ctx, yclient, staticroot.Static(sroute.GetPrefix()).State(), sroute)
+		}
+	}
+	for prefix, installed := range s.staticPrefixes {
+		if _, ok := current[prefix]; ok {
+			continue
+		}
+		if installed {
+			if err := s.setRoute(ctx, fakedevice.DefaultNetworkInstance, convertStaticRoute(prefix, nil, nil), true); err != nil {
+				log.Warningf("Failed to delete static route: %v", err)
+				continue
+			}
+		}
+		gnmiclient.Delete(ctx, yclient, staticroot.Static(prefix).State())
+	}
+	s.staticPrefixes = current
+}
+
 // monitorStaticRoutes starts a gothread to check for static route
 // configuration changes.
 // It returns an error if there is an error before monitoring can begin.
@@ -73,45 +191,31 @@ func (s *Server) monitorStaticRoutes(ctx context.Context, yclient *ygnmi.Client)
 		// staticpath.NextHopAny().Preference().Config(),
 		// staticpath.NextHopAny().Metric().Config(),
 		staticpath.NextHopAny().Recurse().Config(),
+		staticpath.NextHopAny().EnableBfd().Config(),
 		staticpath.Prefix().Config(),
 	)
 
-	prevIntfs := map[string]struct{}{}
+	if s.bfd != nil {
+		s.bfd.Watch(func(key bfd.Key, _ bool) {
+			s.staticMu.Lock()
+			defer s.staticMu.Unlock()
+			if _, ok := s.staticBFD[key]; ok {
+				s.programStaticRoutes(ctx, yclient)
+			}
+		})
+	}
 
 	staticRouteWatcher := ygnmi.Watch(
 		ctx,
 		yclient,
 		b.Query(),
 		func(static *ygnmi.Value[map[string]*oc.NetworkInstance_Protocol_Static]) error {
-			staticMap, ok := static.Val()
-			currentIntfs := map[string]struct{}{}
-			if ok {
-				for prefix, sroute := range staticMap {
-					if sroute == nil || sroute.Prefix == nil {
-						continue
-					}
-					if route := convertStaticRoute(prefix, sroute); route != nil {
-						currentIntfs[prefix] = struct{}{}
-						if err := s.setRoute(ctx, fakedevice.DefaultNetworkInstance, route, false); err != nil {
-							log.Warningf("Failed to add static route: %v", err)
-						} else {
-							gnmiclient.Replace(ctx, yclient, staticroot.Static(sroute.GetPrefix()).State(), sroute)
-						}
-					}
-				}
-			}
-			for prefix := range prevIntfs {
-				if _, ok := currentIntfs[prefix]; !ok {
-					if route := convertStaticRoute(prefix, nil); route != nil {
-						if err := s.setRoute(ctx, fakedevice.DefaultNetworkInstance, route, true); err != nil {
-							log.Warningf("Failed to delete static route: %v", err)
-						} else {
-							gnmiclient.Delete(ctx, yclient, staticroot.Static(prefix).State())
-						}
-					}
-				}
-			}
-			prevIntfs = currentIntfs
+			staticMap, _ := static.Val()
+			s.staticMu.Lock()
+			defer s.staticMu.Unlock()
+			s.staticRoutes = staticMap
+			s.syncStaticBFD()
+			s.programStaticRoutes(ctx, yclient)
 			return ygnmi.Continue
 		},
 	)
@@ -23,12 +23,14 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/gribigo/afthelper"
 	"github.com/openconfig/ygnmi/ygnmi"
 	"github.com/openconfig/ygot/ygot"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/local"
 	"google.golang.org/protobuf/testing/protocmp"
 
+	"github.com/openconfig/lemming/bfd"
 	"github.com/openconfig/lemming/gnmi"
 	"github.com/openconfig/lemming/gnmi/fakedevice"
 	"github.com/openconfig/lemming/gnmi/gnmiclient"
@@ -382,3 +384,118 @@ func TestStaticRouteAndIntfs(t *testing.T) {
 		})
 	}
 }
+
+func TestStaticBFDNextHops(t *testing.T) {
+	s := &Server{bfd: bfd.New(), staticBFD: map[bfd.Key]bfd.Params{}}
+	defer s.bfd.Stop()
+	s.staticRoutes = map[string]*oc.NetworkInstance_Protocol_Static{
+		"10.0.0.0/8": {
+			Prefix: ygot.String("10.0.0.0/8"),
+			NextHop: map[string]*oc.NetworkInstance_Protocol_Static_NextHop{
+				"bfd": {
+					Index:   ygot.String("bfd"),
+					NextHop: oc.UnionString("192.0.2.1"),
+					EnableBfd: &oc.NetworkInstance_Protocol_Static_NextHop_EnableBfd{
+						Enabled:             ygot.Bool(true),
+						DetectionMultiplier: ygot.Uint8(5),
+					},
+				},
+				"plain": {
+					Index:   ygot.String("plain"),
+					NextHop: oc.UnionString("192.0.2.2"),
+				},
+			},
+		},
+	}
+	s.syncStaticBFD()
+	sessions := s.bfd.Sessions()
+	if len(sessions) != 1 || sessions[0].Peer.String() != "192.0.2.1" || sessions[0].Multihop || sessions[0].Params.DetectMultiplier != 5 {
+		t.Fatalf("syncStaticBFD() got sessions %+v, want single-hop session to 192.0.2.1", sessions)
+	}
+
+	// The session is not up, its next hop is withdrawn.
+	got := convertStaticRoute("10.0.0.0/8", s.staticRoutes["10.0.0.0/8"], s.staticNextHopDown)
+	want := &Route{
+		Prefix: "10.0.0.0/8",
+		NextHops: []*ResolvedNexthop{{
+			NextHopSummary: afthelper.NextHopSummary{
+				Weight:          1,
+				Address:         "192.0.2.2",
+				NetworkInstance: fakedevice.DefaultNetworkInstance,
+			},
+		}},
+		RoutePref: RoutePreference{AdminDistance: 1},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("convertStaticRoute() (-want, +got):\n%s", diff)
+	}
+
+	s.staticRoutes = nil
+	s.syncStaticBFD()
+	if sessions := s.bfd.Sessions(); len(sessions) != 0 {
+		t.Errorf("syncStaticBFD() got sessions %+v after removing the routes, want none", sessions)
+	}
+}
+
+func TestResetStatic(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.bfd = bfd.New()
+	defer s.bfd.Stop()
+	s.staticLSPs = map[string][]*LabelRoute{"lsp": {{Label: 100, Protocol: staticLSPProtocol}}}
+	s.staticPrefixes = map[string]bool{"10.0.0.0/8": true}
+	s.staticRoutes = map[string]*oc.NetworkInstance_Protocol_Static{
+		"10.0.0.0/8": {
+			Prefix: ygot.String("10.0.0.0/8"),
+			NextHop: map[string]*oc.NetworkInstance_Protocol_Static_NextHop{
+				"bfd": {
+					Index:     ygot.String("bfd"),
+					NextHop:   oc.UnionString("192.0.2.1"),
+					EnableBfd: &oc.NetworkInstance_Protocol_Static_NextHop_EnableBfd{Enabled: ygot.Bool(true)},
+				},
+			},
+		},
+	}
+	s.syncStaticBFD()
+
+	if err := s.Reset(context.Background()); err != nil {
+		t.Fatalf("Reset() unexpected error: %v", err)
+	}
+	if len(s.staticLSPs) != 0 || len(s.staticRoutes) != 0 || len(s.staticPrefixes) != 0 || len(s.staticBFD) != 0 {
+		t.Errorf("Reset() kept static state: LSPs %v, routes %v, prefixes %v, BFD %v", s.staticLSPs, s.staticRoutes, s.staticPrefixes, s.staticBFD)
+	}
+	if sessions := s.bfd.Sessions(); len(sessions) != 0 {
+		t.Errorf("Reset() kept BFD sessions %+v, want none", sessions)
+	}
+}
+
+func TestResignalLSP(t *testing.T) {
+	s, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &LabelRoute{Label: 100, Protocol: staticLSPProtocol, PopLabels: 1}
+	b := &LabelRoute{Label: 100, Protocol: staticLSPProtocol, PopLabels: 2}
+	c := &LabelRoute{Label: 200, Protocol: staticLSPProtocol, PopLabels: 1}
+	s.staticLSPs = map[string][]*LabelRoute{"a": {a}, "b": {b, c}}
+	for _, r := range staticLabelRoutes(s.staticLSPs) {
+		s.rib.setLabelRoute(r, false)
+	}
+
+	// Label 100 is used by LSP "a", resignaling "b" must not replace its route.
+	if err := s.ResignalLSP(context.Background(), "b"); err != nil {
+		t.Fatalf("ResignalLSP() unexpected error: %v", err)
+	}
+	want := map[LabelRouteKey]*LabelRoute{
+		{Label: 100, Protocol: staticLSPProtocol}: a,
+		{Label: 200, Protocol: staticLSPProtocol}: c,
+	}
+	if diff := cmp.Diff(want, s.rib.Labels); diff != "" {
+		t.Errorf("ResignalLSP() unexpected label routes (-want, +got):\n%s", diff)
+	}
+	if err := s.ResignalLSP(context.Background(), "c"); err == nil {
+		t.Errorf("ResignalLSP() of unknown LSP got no error")
+	}
+}
@@ -49,6 +49,11 @@ type SysRIB struct {
 	// routes.
 	GUEPoliciesV4 *generics_tree.TreeV4[GUEPolicy]
 	GUEPoliciesV6 *generics_tree.TreeV6[GUEPolicy]
+
+	// Labels is the MPLS RIB keyed by incoming label and protocol. The label
+	// space is not specific to any network instance, the preferred route of
+	// each label is programmed.
+	Labels map[LabelRouteKey]*LabelRoute
 }
 
 // NIRIB is the RIB for a single network instance.
@@ -171,6 +176,27 @@ type Route struct {
 	RoutePref RoutePreference
 }
 
+// LabelRouteKey is the key of an MPLS route in the sysRIB.
+type LabelRouteKey struct {
+	Label    uint32
+	Protocol string
+}
+
+// LabelRoute is an MPLS route, which forwards packets by their top label.
+type LabelRoute struct {
+	// Label is the incoming label matched by the route.
+	Label uint32 `json:"label"`
+	// Protocol is the name of the protocol that added the route.
+	Protocol string `json:"protocol"`
+	// PopLabels is the number of labels removed from the top of the stack,
+	// including the matched label.
+	PopLabels uint32 `json:"pop-labels"`
+	// NextHops is the set of IP nexthops that the route uses, the labels
+	// pushed for each nexthop are stored in its headers.
+	NextHops  []*ResolvedNexthop `json:"nexthops"`
+	RoutePref RoutePreference
+}
+
 func (r *Route) String() string {
 	readable := fmt.Sprintf("%s (%+v)", r.Prefix, r.RoutePref)
 	switch {
@@ -203,6 +229,7 @@ func NewSysRIB(initialCfg *oc.Root) (*SysRIB, error) {
 		NI:            map[string]*NIRIB{},
 		GUEPoliciesV4: generics_tree.NewTreeV4[GUEPolicy](),
 		GUEPoliciesV6: generics_tree.NewTreeV6[GUEPolicy](),
+		Labels:        map[LabelRouteKey]*LabelRoute{},
 	}
 
 	if initialCfg != nil {
@@ -313,6 +340,68 @@ func (sr *SysRIB) setRoute(ni string, r *Route, isDelete bool) error {
 	}
 }
 
+// setLabelRoute adds or deletes an MPLS route r in the sysRIB.
+func (sr *SysRIB) setLabelRoute(r *LabelRoute, isDelete bool) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	key := LabelRouteKey{Label: r.Label, Protocol: r.Protocol}
+	if isDelete {
+		delete(sr.Labels, key)
+		return
+	}
+	sr.Labels[key] = r
+}
+
+// labelRoutes returns the MPLS routes of each label, sorted by preference.
+//
+// NOTE: sr.mu.RLock() must be called prior to calling this function.
+func (sr *SysRIB) labelRoutes() map[uint32][]*LabelRoute {
+	routes := map[uint32][]*LabelRoute{}
+	for key, r := range sr.Labels {
+		routes[key.Label] = append(routes[key.Label], r)
+	}
+	for _, rs := range routes {
+		sort.Slice(rs, func(i, j int) bool {
+			a, b := rs[i].RoutePref, rs[j].RoutePref
+			switch {
+			case a.AdminDistance != b.AdminDistance:
+				return a.AdminDistance < b.AdminDistance
+			case a.Metric != b.Metric:
+				return a.Metric < b.Metric
+			}
+			return rs[i].Protocol < rs[j].Protocol
+		})
+	}
+	return routes
+}
+
+// removeProtocolRoutes removes all routes except connected routes from the
+// sysRIB, including all MPLS routes.
+func (sr *SysRIB) removeProtocolRoutes() {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	for _, ni := range sr.NI {
+		v4 := generics_tree.NewTreeV4[*Route]()
+		for it := ni.IPV4.Iterate(); it.Next(); {
+			for _, r := range it.Tags() {
+				if r.Connected != nil {
+					v4.Add(it.Address(), r, routeKeyMatches)
+				}
+			}
+		}
+		v6 := generics_tree.NewTreeV6[*Route]()
+		for it := ni.IPV6.Iterate(); it.Next(); {
+			for _, r := range it.Tags() {
+				if r.Connected != nil {
+					v6.Add(it.Address(), r, routeKeyMatches)
+				}
+			}
+		}
+		ni.IPV4, ni.IPV6 = v4, v6
+	}
+	sr.Labels = map[LabelRouteKey]*LabelRoute{}
+}
+
 // SetGUEPolicy sets a GUE Policy in the RIB.
 func (sr *SysRIB) SetGUEPolicy(prefix string, policy GUEPolicy) error {
 	sr.mu.Lock()
@@ -1259,3 +1259,103 @@ func TestAddAndDeleteRouteSequences(t *testing.T) {
 		}
 	}
 }
+
+func TestRemoveProtocolRoutes(t *testing.T) {
+	s, err := NewSysRIB(nil)
+	if err != nil {
+		t.Fatalf("cannot create SysRIB: %v", err)
+	}
+	connectedV4 := &Route{
+		Prefix: "192.168.1.0/24",
+		Connected: &Interface{
+			Name: "eth0",
+		},
+	}
+	connectedV6 := &Route{
+		Prefix: "2001::/64",
+		Connected: &Interface{
+			Name: "eth0",
+		},
+	}
+	routes := map[string][]*Route{
+		fakedevice.DefaultNetworkInstance: {connectedV4, connectedV6, {
+			Prefix: "10.0.0.0/8",
+			NextHops: []*ResolvedNexthop{{
+				NextHopSummary: afthelper.NextHopSummary{Address: "192.168.1.42"},
+			}},
+			RoutePref: RoutePreference{AdminDistance: 20},
+		}, {
+			Prefix: "2002::/16",
+			NextHops: []*ResolvedNexthop{{
+				NextHopSummary: afthelper.NextHopSummary{Address: "2001::42"},
+			}},
+			RoutePref: RoutePreference{AdminDistance: 5},
+		}},
+		"vrf-a": {{
+			Prefix: "10.0.0.0/8",
+			NextHops: []*ResolvedNexthop{{
+				NextHopSummary: afthelper.NextHopSummary{Address: "192.168.1.42", NetworkInstance: fakedevice.DefaultNetworkInstance},
+			}},
+		}},
+	}
+	for ni, rs := range routes {
+		for _, r := range rs {
+			if err := s.AddRoute(ni, r); err != nil {
+				t.Fatalf("AddRoute(%q, %v) unexpected error: %v", ni, r, err)
+			}
+		}
+	}
+	s.setLabelRoute(&LabelRoute{Label: 100}, false)
+
+	s.removeProtocolRoutes()
+
+	got := map[string][]*Route{}
+	for name, ni := range s.NI {
+		for it := ni.IPV4.Iterate(); it.Next(); {
+			got[name] = append(got[name], it.Tags()...)
+		}
+		for it := ni.IPV6.Iterate(); it.Next(); {
+			got[name] = append(got[name], it.Tags()...)
+		}
+	}
+	want := map[string][]*Route{
+		fakedevice.DefaultNetworkInstance: {connectedV4, connectedV6},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("routes after removeProtocolRoutes (-want, +got):\n%s", diff)
+	}
+	if len(s.Labels) != 0 {
+		t.Errorf("got %d label routes after removeProtocolRoutes, want 0", len(s.Labels))
+	}
+}
+
+func TestLabelRoutes(t *testing.T) {
+	s, err := NewSysRIB(nil)
+	if err != nil {
+		t.Fatalf("cannot create SysRIB: %v", err)
+	}
+	static := &LabelRoute{Label: 100, Protocol: staticLSPProtocol, RoutePref: RoutePreference{AdminDistance: 1}}
+	gribi := &LabelRoute{Label: 100, Protocol: "gRIBI", RoutePref: RoutePreference{AdminDistance: 5}}
+	other := &LabelRoute{Label: 200, Protocol: "gRIBI", RoutePref: RoutePreference{AdminDistance: 5}}
+	s.setLabelRoute(gribi, false)
+	s.setLabelRoute(static, false)
+	s.setLabelRoute(other, false)
+
+	want := map[uint32][]*LabelRoute{
+		100: {static, gribi},
+		200: {other},
+	}
+	if diff := cmp.Diff(want, s.labelRoutes()); diff != "" {
+		t.Errorf("labelRoutes() (-want, +got):\n%s", diff)
+	}
+
+	// Deleting the static route of a label keeps the route of the other protocol.
+	s.setLabelRoute(&LabelRoute{Label: 100, Protocol: staticLSPProtocol}, true)
+	want = map[uint32][]*LabelRoute{
+		100: {gribi},
+		200: {other},
+	}
+	if diff := cmp.Diff(want, s.labelRoutes()); diff != "" {
+		t.Errorf("labelRoutes() after delete (-want, +got):\n%s", diff)
+	}
+}